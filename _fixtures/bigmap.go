@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	m1 := make(map[string]int)
+	m2 := make(map[int]string)
+	for i := 0; i < 1000; i++ {
+		m1[fmt.Sprintf("key%03d", i)] = i
+		m2[i] = fmt.Sprintf("value%d", i)
+	}
+	runtime.Breakpoint()
+	fmt.Println(len(m1), len(m2))
+}
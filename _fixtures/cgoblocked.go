@@ -0,0 +1,20 @@
+package main
+
+// #include <unistd.h>
+// static void blockinc(void) { sleep(1000); }
+import "C"
+
+import (
+	"runtime"
+	"time"
+)
+
+func main() {
+	for i := 0; i < 4; i++ {
+		go func() {
+			C.blockinc()
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+}
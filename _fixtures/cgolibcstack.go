@@ -0,0 +1,33 @@
+package main
+
+// #cgo CFLAGS: -g -Wall -O0
+/*
+#include <stdlib.h>
+
+#ifdef __amd64__
+#define BREAKPOINT asm("int3;")
+#elif __i386__
+#define BREAKPOINT asm("int3;")
+#elif __aarch64__
+#define BREAKPOINT asm("brk 0;")
+#endif
+
+int compareints(const void *a, const void *b) {
+	BREAKPOINT;
+	return *(const int *)a - *(const int *)b;
+}
+
+void sortints(int *v, int n) {
+	qsort(v, n, sizeof(int), compareints);
+}
+*/
+import "C"
+
+import "fmt"
+
+func main() {
+	v := (*[3]C.int)(C.malloc(3 * C.sizeof_int))
+	v[0], v[1], v[2] = 3, 1, 2
+	C.sortints(&v[0], 3)
+	fmt.Println(v[0], v[1], v[2])
+}
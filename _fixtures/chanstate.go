@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func main() {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	<-ch
+	ch <- 4
+
+	closedch := make(chan string, 2)
+	closedch <- "a"
+	close(closedch)
+
+	blocked := make(chan int)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			blocked <- i
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	fmt.Println(ch, closedch, blocked)
+}
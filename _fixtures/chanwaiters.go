@@ -0,0 +1,30 @@
+package main
+
+import (
+	"runtime"
+	"time"
+)
+
+func receiver(ch chan int) {
+	<-ch
+}
+
+func sender(ch chan int) {
+	ch <- 1
+}
+
+func main() {
+	recvch := make(chan int)
+	sendch := make(chan int)
+	for i := 0; i < 3; i++ {
+		go receiver(recvch)
+	}
+	for i := 0; i < 2; i++ {
+		go sender(sendch)
+	}
+	time.Sleep(time.Second)
+	runtime.Breakpoint()
+	close(recvch)
+	<-sendch
+	<-sendch
+}
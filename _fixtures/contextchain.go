@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+type ctxKey string
+
+func main() {
+	ctx := context.WithValue(context.Background(), ctxKey("reqid"), "abc123")
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	ctx, cancel2 := context.WithTimeout(ctx, time.Hour)
+	defer cancel2()
+	ctx = context.WithValue(ctx, ctxKey("user"), 42)
+	notctx := 1
+	runtime.Breakpoint()
+	fmt.Println(ctx, notctx)
+}
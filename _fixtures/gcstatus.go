@@ -0,0 +1,12 @@
+package main
+
+import "runtime"
+
+var sink []byte
+
+func main() {
+	for i := 0; i < 10; i++ {
+		sink = make([]byte, 1<<20)
+	}
+	runtime.GC()
+}
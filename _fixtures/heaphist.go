@@ -0,0 +1,17 @@
+package main
+
+import "runtime"
+
+type big struct {
+	pad  [100]uint64
+	next *big
+}
+
+func main() {
+	var head *big
+	for i := 0; i < 10; i++ {
+		head = &big{next: head}
+	}
+	runtime.Breakpoint()
+	runtime.KeepAlive(head)
+}
@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+var n int
+
+func incr() {
+	n++
+}
+
+func main() {
+	n = 1
+	fmt.Println(n)
+	incr()
+	fmt.Println(n)
+}
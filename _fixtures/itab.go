@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type Shape interface {
+	Area() float64
+	Name() string
+}
+
+type Square struct{ side float64 }
+
+func (s *Square) Area() float64 { return s.side * s.side }
+func (s *Square) Name() string  { return "square" }
+
+func main() {
+	var sh Shape = &Square{2}
+	var nilsh Shape
+	var e interface{} = 1
+	runtime.Breakpoint()
+	fmt.Println(sh, nilsh, e)
+}
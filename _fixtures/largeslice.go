@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	s := make([]int, 1000)
+	for i := range s {
+		s[i] = i
+	}
+	var a [200]int
+	for i := range a {
+		a[i] = i * 2
+	}
+	runtime.Breakpoint()
+	fmt.Println(s[0], a[0])
+}
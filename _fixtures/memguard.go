@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+func scribble(buf []byte) {
+	buf[10] = 1
+}
+
+func main() {
+	buf, err := syscall.Mmap(-1, 0, 4096, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic(err)
+	}
+	runtime.Breakpoint()
+	scribble(buf)
+	fmt.Println(buf[10])
+}
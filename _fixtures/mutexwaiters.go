@@ -0,0 +1,36 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+var mu sync.Mutex
+var rwmu sync.RWMutex
+
+func locker() {
+	mu.Lock()
+	mu.Unlock()
+}
+
+func reader() {
+	rwmu.RLock()
+	rwmu.RUnlock()
+}
+
+func main() {
+	mu.Lock()
+	for i := 0; i < 2; i++ {
+		go locker()
+	}
+	rwmu.Lock()
+	for i := 0; i < 3; i++ {
+		go reader()
+	}
+	time.Sleep(time.Second)
+	runtime.Breakpoint()
+	mu.Unlock()
+	rwmu.Unlock()
+	time.Sleep(time.Second)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type Node struct {
+	Name string
+	Next *Node
+}
+
+type Cache struct {
+	Items map[string]*Node
+	Order []*Node
+}
+
+func main() {
+	a := &Node{Name: "a"}
+	b := &Node{Name: "b", Next: a}
+	a.Next = b
+	cache := &Cache{Items: map[string]*Node{"a": a}, Order: []*Node{a, b}}
+	runtime.Breakpoint()
+	fmt.Println(cache, a, b)
+}
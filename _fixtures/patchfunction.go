@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func answer() int {
+	return 1
+}
+
+func greeting(name string) (string, bool) {
+	return "hello " + name, false
+}
+
+func main() {
+	runtime.Breakpoint()
+	a := answer()
+	s, ok := greeting("world")
+	runtime.Breakpoint()
+	fmt.Println(a, s, ok)
+}
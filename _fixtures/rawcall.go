@@ -0,0 +1,16 @@
+package main
+
+// long rawcalladd(long a, long b, long c, long d, long e, long f, long g, long h) {
+// 	return a + 2*b + 3*c + 4*d + 5*e + 6*f + 7*g + 8*h;
+// }
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	runtime.Breakpoint()
+	fmt.Println(C.rawcalladd(1, 2, 3, 4, 5, 6, 7, 8))
+}
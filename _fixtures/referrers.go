@@ -0,0 +1,28 @@
+package main
+
+import "runtime"
+
+type node struct {
+	name string
+	next *node
+}
+
+type big struct {
+	pad [100]uint64
+	ref *node
+}
+
+var holder struct {
+	n int
+	p *node
+}
+
+func main() {
+	target := &node{name: "target"}
+	head := &node{name: "head", next: target}
+	b := &big{ref: target}
+	holder.p = target
+	runtime.Breakpoint()
+	runtime.KeepAlive(head)
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type Header map[string][]string
+
+type Request struct {
+	Method  string
+	Header  Header
+	Body    interface{}
+	Parent  *Request
+	Retries int
+}
+
+type Payload struct {
+	IDs []string
+}
+
+func main() {
+	req := &Request{
+		Method:  "GET",
+		Header:  Header{"X-Request-Id": {"abc123"}},
+		Body:    &Payload{IDs: []string{"x", "abc123"}},
+		Retries: 3,
+	}
+	req.Parent = req
+	runtime.Breakpoint()
+	fmt.Println(req)
+}
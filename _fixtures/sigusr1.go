@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-ch:
+		fmt.Println("received")
+	case <-time.After(5 * time.Second):
+		fmt.Println("not received")
+	}
+	signal.Stop(ch)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1) // the process would be killed if this signal was delivered
+	fmt.Println("done")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type point struct {
+	x, y int
+}
+
+func (p point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.x, p.y)
+}
+
+type slowStringer struct{}
+
+func (slowStringer) String() string {
+	time.Sleep(time.Hour)
+	return "slow"
+}
+
+type bigStringer struct{}
+
+func (bigStringer) String() string {
+	return strings.Repeat("a", 4<<20)[:4]
+}
+
+type plain struct {
+	a int
+}
+
+func main() {
+	p := point{1, 2}
+	err := errors.New("some error")
+	slow := slowStringer{}
+	big := bigStringer{}
+	pl := plain{3}
+	runtime.Breakpoint()
+	fmt.Println(p, err, slow, big, pl)
+}
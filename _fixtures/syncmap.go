@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type Config struct {
+	Name    string
+	Retries int
+}
+
+func main() {
+	var m sync.Map
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.Delete("b")
+	m.Load("a")
+
+	var emptym sync.Map
+
+	var v atomic.Value
+	v.Store(&Config{Name: "cfg", Retries: 5})
+
+	var emptyv atomic.Value
+
+	runtime.Breakpoint()
+	fmt.Println(&m, &emptym, &v, &emptyv)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func wakeup() {
+	fmt.Println("wakeup")
+}
+
+func main() {
+	ticker := time.NewTicker(30 * time.Second)
+	timer := time.AfterFunc(time.Hour, wakeup)
+	runtime.Breakpoint()
+	ticker.Stop()
+	timer.Stop()
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+type Header struct {
+	Magic   uint32
+	Version uint16
+	Name    string
+}
+
+var keep *Header
+
+func main() {
+	keep = &Header{Magic: 0xcafebabe, Version: 3, Name: "hdr"}
+	p := unsafe.Pointer(keep)
+	addr := uintptr(p)
+	runtime.Breakpoint()
+	fmt.Println(p, addr)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"runtime"
+	"time"
+)
+
+func main() {
+	t1 := time.Date(2021, 3, 4, 5, 6, 7, 800, time.UTC)
+	t2 := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("X", 3600))
+	n, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	ip4 := netip.MustParseAddr("192.168.0.1")
+	ip6 := netip.MustParseAddr("2001:db8::1")
+	var ipz netip.Addr
+	runtime.Breakpoint()
+	fmt.Println(t1, t2, n, ip4, ip6, ipz)
+}
@@ -1,7 +1,12 @@
 package cmds
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/go-delve/delve/pkg/config"
 )
 
 func TestParseRedirects(t *testing.T) {
@@ -57,3 +62,47 @@ func TestParseRedirects(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerSubstitutePath(t *testing.T) {
+	root, err := ioutil.TempDir("", "container-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	host, err := ioutil.TempDir("", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(host)
+	hostFile := filepath.Join(host, "host.go")
+	if err := ioutil.WriteFile(hostFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/go/src/app/main.go", "/go/src/app/util.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []string{"/go/src/app/main.go", "/go/src/app/util.go", "/go/src/missing/missing.go", hostFile, "<autogenerated>"}
+	rules := containerSubstitutePath(sources, root)
+	tgt := config.SubstitutePathRules{{From: "/go/src/app", To: filepath.Join(root, "/go/src/app")}}
+	if len(rules) != len(tgt) || rules[0] != tgt[0] {
+		t.Fatalf("got %v expected %v", rules, tgt)
+	}
+}
+
+func TestTestRunPattern(t *testing.T) {
+	for _, tc := range []struct{ in, out string }{
+		{"TestFoo", "^TestFoo$"},
+		{"TestFoo/case 1/a.b", "^TestFoo$/^case_1$/^a\\.b$"},
+	} {
+		if out := testRunPattern(tc.in); out != tc.out {
+			t.Errorf("testRunPattern(%q) = %q, expected %q", tc.in, out, tc.out)
+		}
+	}
+}
@@ -1,14 +1,17 @@
 package cmds
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -18,6 +21,8 @@ import (
 	"github.com/go-delve/delve/pkg/gobuild"
 	"github.com/go-delve/delve/pkg/goversion"
 	"github.com/go-delve/delve/pkg/logflags"
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/linutil"
 	"github.com/go-delve/delve/pkg/terminal"
 	"github.com/go-delve/delve/pkg/version"
 	"github.com/go-delve/delve/service"
@@ -49,6 +54,8 @@ var (
 	addr string
 	// initFile is the path to initialization file.
 	initFile string
+	// tui enables the full-screen mode of the terminal client.
+	tui bool
 	// buildFlags is the flags passed during compiler invocation.
 	buildFlags string
 	// workingDir is the working directory for running the program.
@@ -60,6 +67,42 @@ var (
 	tty string
 	// disableASLR is used to disable ASLR
 	disableASLR bool
+	// stopAtSafePoints is used to advance threads to a safe point after a
+	// manual stop
+	stopAtSafePoints bool
+	// queueBreakpointsDuringNext is used to keep next from being interrupted
+	// by breakpoints hit by other goroutines
+	queueBreakpointsDuringNext bool
+	// stopAtInlinedCalls makes next stop at the beginning of inlined calls,
+	// see debugger.Config.StopAtInlinedCalls
+	stopAtInlinedCalls bool
+	// prettyPrinters is the list of starlark scripts that register pretty
+	// printers for user types
+	prettyPrinters []string
+	// tlsConfig describes how connections to and from a headless server are
+	// secured with TLS
+	tlsConfig service.TLSConfig
+	// websocket is true if a headless server should accept WebSocket
+	// connections instead of plain TCP connections
+	websocket bool
+	// websocketOrigins are the origins of the web pages allowed to connect
+	// to a headless server when websocket is set
+	websocketOrigins []string
+	// authPolicyFile is the path of the authentication policy of a headless
+	// server, see service.LoadAuthPolicy
+	authPolicyFile string
+	// metricsAddr is the address where a headless server serves its metrics,
+	// see service.Config.MetricsListener
+	metricsAddr string
+	// restAddr is the address where a headless server serves its HTTP+JSON
+	// interface, see service.Config.RESTListener
+	restAddr string
+	// restOrigins are the origins of the web pages allowed to send requests
+	// to the HTTP+JSON interface, see service.Config.RESTOrigins
+	restOrigins []string
+	// initStateFile is the path of a session, saved with the 'session save'
+	// command or the SaveSession API call, restored on startup
+	initStateFile string
 
 	// backend selection
 	backend string
@@ -76,12 +119,45 @@ var (
 	traceExecFile   string
 	traceTestBinary bool
 	traceStackDepth int
+	traceFormat     string
+	traceSummary    bool
+
+	// testRun is the name of the test, or subtest, run by 'dlv test'.
+	testRun string
+	// testBreakOnFail stops the target when a test fails.
+	testBreakOnFail bool
 
 	// redirect specifications for target process
 	redirects []string
+	// captureOutput is the mode used to capture the output of the target
+	// process in headless mode, see debugger.Config.CaptureOutput
+	captureOutput string
+
+	// attachContainer is the ID of the container running the process to
+	// attach to
+	attachContainer string
+	// attachSysRoot is the root directory of the process being attached to,
+	// if it runs in a different mount namespace
+	attachSysRoot string
+	// attachStub is the address of a gdbserial stub already attached to the
+	// process, see debugger.Config.AttachStub
+	attachStub string
+	// attachStubTLS is true if the connection to attachStub should be
+	// secured with TLS, configured by tlsConfig
+	attachStubTLS bool
+	// attachStubTLSConfig is the TLS configuration for attachStub
+	attachStubTLSConfig *tls.Config
+
+	// checkpointDir is the directory of the checkpoint to restore
+	checkpointDir string
 
 	allowNonTerminalInteractive bool
 
+	// observer is true if the connect command should connect as an observer
+	observer bool
+	// authToken is the token used by the connect command to authenticate
+	authToken string
+
 	conf *config.Config
 )
 
@@ -126,14 +202,31 @@ func New(docCall bool) *cobra.Command {
 	rootCommand.PersistentFlags().BoolVarP(&acceptMulti, "accept-multiclient", "", false, "Allows a headless server to accept multiple client connections.")
 	rootCommand.PersistentFlags().IntVar(&apiVersion, "api-version", 1, "Selects API version when headless. New clients should use v2. Can be reset via RPCServer.SetApiVersion. See Documentation/api/json-rpc/README.md.")
 	rootCommand.PersistentFlags().StringVar(&initFile, "init", "", "Init file, executed by the terminal client.")
+	rootCommand.PersistentFlags().BoolVar(&tui, "tui", false, "Shows the source, disassembly, local variables, goroutines and breakpoints in panes above the prompt of the terminal client.")
 	rootCommand.PersistentFlags().StringVar(&buildFlags, "build-flags", buildFlagsDefault, "Build flags, to be passed to the compiler. For example: --build-flags=\"-tags=integration -mod=vendor -cover -v\"")
 	rootCommand.PersistentFlags().StringVar(&workingDir, "wd", "", "Working directory for running the program.")
 	rootCommand.PersistentFlags().BoolVarP(&checkGoVersion, "check-go-version", "", true, "Checks that the version of Go in use is compatible with Delve.")
 	rootCommand.PersistentFlags().BoolVarP(&checkLocalConnUser, "only-same-user", "", true, "Only connections from the same user that started this instance of Delve are allowed to connect.")
 	rootCommand.PersistentFlags().StringVar(&backend, "backend", "default", `Backend selection (see 'dlv help backend').`)
 	rootCommand.PersistentFlags().StringArrayVarP(&redirects, "redirect", "r", []string{}, "Specifies redirect rules for target process (see 'dlv help redirect')")
+	rootCommand.PersistentFlags().StringVar(&captureOutput, "capture-output", "", "Captures the standard descriptors of the target process and makes them available to API clients, 'pipe' or 'pty' (see 'dlv help redirect').")
+	rootCommand.PersistentFlags().Lookup("capture-output").NoOptDefVal = "pipe"
 	rootCommand.PersistentFlags().BoolVar(&allowNonTerminalInteractive, "allow-non-terminal-interactive", false, "Allows interactive sessions of Delve that don't have a terminal as stdin, stdout and stderr")
 	rootCommand.PersistentFlags().BoolVar(&disableASLR, "disable-aslr", false, "Disables address space randomization")
+	rootCommand.PersistentFlags().BoolVar(&stopAtSafePoints, "stop-at-safe-points", false, "After a manual stop advances each thread to the nearest safe point, where function calls can be injected.")
+	rootCommand.PersistentFlags().BoolVar(&queueBreakpointsDuringNext, "queue-breakpoints-during-next", false, "Breakpoints hit by other goroutines do not interrupt next, step and stepout, they are reported when the command completes.")
+	rootCommand.PersistentFlags().BoolVar(&stopAtInlinedCalls, "stop-at-inlined-calls", false, "Next stops at the beginning of inlined calls, whose call site is then shown as the current location, so that they can be entered with 'step'.")
+	rootCommand.PersistentFlags().StringArrayVar(&prettyPrinters, "pretty-printers", []string{}, "Starlark script registering pretty printers for user types, can be specified multiple times (see 'dlv help pretty-printers').")
+	rootCommand.PersistentFlags().StringVar(&tlsConfig.CertFile, "tls-cert", "", "Certificate used to secure connections with TLS (see 'dlv help tls').")
+	rootCommand.PersistentFlags().StringVar(&tlsConfig.KeyFile, "tls-key", "", "Private key of the certificate specified by --tls-cert (see 'dlv help tls').")
+	rootCommand.PersistentFlags().BoolVar(&websocket, "websocket", false, "Accept WebSocket connections, instead of plain TCP connections, on the listen address (see 'dlv help websocket').")
+	rootCommand.PersistentFlags().StringArrayVar(&websocketOrigins, "websocket-origin", []string{}, "Origin of the web pages allowed to connect with --websocket, can be specified multiple times (see 'dlv help websocket').")
+	rootCommand.PersistentFlags().StringVar(&tlsConfig.CAFile, "tls-ca", "", "Certificate authorities used to verify the other side of TLS connections (see 'dlv help tls').")
+	rootCommand.PersistentFlags().StringVar(&initStateFile, "init-state", "", "Restores the breakpoints, watch expressions and substitute-path rules saved in the specified file by the 'session save' command, or by the SaveSession API call.")
+	rootCommand.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "Serves /metrics, in the Prometheus text format, and /healthz on the specified address, only for headless servers.")
+	rootCommand.PersistentFlags().StringVar(&restAddr, "rest-addr", "", "Serves an HTTP+JSON interface to the debugger, described by the OpenAPI document at /openapi.json, on the specified address, only for headless servers. The authentication policy, if any, applies to it: requests must send their token in an 'Authorization: Bearer <token>' header. If TLS options are specified the interface is only served over HTTPS.")
+	rootCommand.PersistentFlags().StringArrayVar(&restOrigins, "rest-origin", []string{}, "Origin of the web pages allowed to send requests to --rest-addr, can be specified multiple times, '*' allows every origin. Requests from any other web page are rejected.")
+	rootCommand.PersistentFlags().StringVar(&authPolicyFile, "auth-policy", "", "Requires clients of a headless server to authenticate with one of the tokens listed in the specified file (see 'dlv help auth').")
 
 	// 'attach' subcommand.
 	attachCommand := &cobra.Command{
@@ -144,9 +237,21 @@ func New(docCall bool) *cobra.Command {
 This command will cause Delve to take control of an already running process, and
 begin a new debug session.  When exiting the debug session you will have the
 option to let the process continue or kill it.
+
+If the process runs inside a container (i.e. in a different mount namespace)
+its shared libraries are read through /proc/<pid>/root and source files that
+only exist inside the container are accessed by adding substitute-path rules.
+Instead of a PID the ID of a container can be specified with --container, the
+PID of its main process will be retrieved using docker, podman or crictl.
+
+With --stub Delve does not attach to the process itself but connects to a
+gdbserial stub (for example debugserver, lldb-server or gdbserver) listening
+at the specified address, that is already attached to the process, possibly
+on another machine. With --stub-tls the connection to the stub is secured
+with TLS, see 'dlv help tls'.
 `,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
+			if len(args) == 0 && attachContainer == "" {
 				return errors.New("you must provide a PID")
 			}
 			return nil
@@ -154,6 +259,9 @@ option to let the process continue or kill it.
 		Run: attachCmd,
 	}
 	attachCommand.Flags().BoolVar(&continueOnStart, "continue", false, "Continue the debugged process on start.")
+	attachCommand.Flags().StringVar(&attachContainer, "container", "", "Attach to the main process of the specified container.")
+	attachCommand.Flags().StringVar(&attachStub, "stub", "", "Address of a gdbserial stub already attached to the process.")
+	attachCommand.Flags().BoolVar(&attachStubTLS, "stub-tls", false, "Secure the connection to the stub specified by --stub with TLS.")
 	rootCommand.AddCommand(attachCommand)
 
 	// 'connect' subcommand.
@@ -169,6 +277,8 @@ option to let the process continue or kill it.
 		},
 		Run: connectCmd,
 	}
+	connectCommand.Flags().BoolVar(&observer, "observer", false, "Connect as an observer, which can inspect the target but not change its state.")
+	connectCommand.Flags().StringVar(&authToken, "auth-token", "", "Token used to authenticate with a server started with --auth-policy, defaults to the value of $DELVE_AUTH_TOKEN (see 'dlv help auth').")
 	rootCommand.AddCommand(connectCommand)
 
 	// 'dap' subcommand.
@@ -253,10 +363,18 @@ or later, -gcflags="-N -l" on earlier versions of Go.`,
 The test command allows you to begin a new debug session in the context of your
 unit tests. By default Delve will debug the tests in the current directory.
 Alternatively you can specify a package name, and Delve will debug the tests in
-that package instead.`,
+that package instead.
+
+With --run only the specified test or subtest runs, the name of a subtest is
+the name of its parent test followed by '/' and its name, with spaces replaced
+by underscores. With --break-on-fail the execution stops every time a test
+fails, calling Error, Fatal or Fail, and the frame of the test function is
+selected.`,
 		Run: testCmd,
 	}
 	testCommand.Flags().String("output", "debug.test", "Output path for the binary.")
+	testCommand.Flags().StringVar(&testRun, "run", "", "Only run the test or subtest with the specified name, subtests are separated by '/', for example TestFoo/case_1.")
+	testCommand.Flags().BoolVar(&testBreakOnFail, "break-on-fail", false, "Stop when a test fails, with the frame of the failing test selected.")
 	rootCommand.AddCommand(testCommand)
 
 	// 'trace' subcommand.
@@ -271,7 +389,14 @@ is useful if you do not want to begin an entire debug session, but merely want
 to know what functions your process is executing.
 
 The output of the trace sub command is printed to stderr, so if you would like to
-only see the output of the trace operations you can redirect stdout.`,
+only see the output of the trace operations you can redirect stdout.
+
+With --format json every call and return of a traced function is printed as a
+JSON object on a single line, for consumption by other tools.
+
+With --summary calls are not printed, instead when the program exits a table
+with the number of calls of each traced function, their minimum, average and
+maximum duration and the number of calls made by each caller is printed.`,
 		Run: traceCmd,
 	}
 	traceCommand.Flags().IntVarP(&traceAttachPid, "pid", "p", 0, "Pid to attach to.")
@@ -279,6 +404,10 @@ only see the output of the trace operations you can redirect stdout.`,
 	traceCommand.Flags().BoolVarP(&traceTestBinary, "test", "t", false, "Trace a test binary.")
 	traceCommand.Flags().IntVarP(&traceStackDepth, "stack", "s", 0, "Show stack trace with given depth.")
 	traceCommand.Flags().String("output", "debug", "Output path for the binary.")
+	traceCommand.Flags().StringVar(&traceFormat, "format", "text", `Format of the trace output, one of:
+	text	human readable output
+	json	one JSON object for each call and return of a traced function, with a timestamp, the goroutine, the function, arguments, return values and the duration of the call`)
+	traceCommand.Flags().BoolVar(&traceSummary, "summary", false, "Instead of printing every call, print the number of calls of each traced function, the minimum, average and maximum duration of the calls and their callers when the program exits. Durations include the overhead of the debugger.")
 	rootCommand.AddCommand(traceCommand)
 
 	coreCommand := &cobra.Command{
@@ -290,7 +419,9 @@ The core command will open the specified core file and the associated
 executable and let you examine the state of the process when the
 core dump was taken.
 
-Currently supports linux/amd64 and linux/arm64 core files and windows/amd64 minidumps.`,
+Currently supports linux/amd64, linux/arm64 and linux/loong64 core files,
+freebsd, netbsd and openbsd core files (on amd64 and arm64) and
+windows/amd64 minidumps.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 2 {
 				return errors.New("you must provide a core file and an executable")
@@ -311,7 +442,7 @@ Currently supports linux/amd64 and linux/arm64 core files and windows/amd64 mini
 	}
 	rootCommand.AddCommand(versionCommand)
 
-	if path, _ := exec.LookPath("rr"); path != "" || docCall {
+	if recorderAvailable() || docCall {
 		replayCommand := &cobra.Command{
 			Use:   "replay [trace directory]",
 			Short: "Replays a rr trace.",
@@ -319,32 +450,78 @@ Currently supports linux/amd64 and linux/arm64 core files and windows/amd64 mini
 
 The replay command will open a trace generated by mozilla rr. Mozilla rr must be installed:
 https://github.com/mozilla/rr
+
+Traces generated by other record and replay engines can be opened by
+selecting them with the --backend flag (see 'dlv help backend').
 			`,
 			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 				if len(args) == 0 {
 					return errors.New("you must provide a path to a binary")
 				}
+				if backend != "default" {
+					if _, ok := proc.LookupRecorder(backend); !ok {
+						return fmt.Errorf("backend %q can not replay traces", backend)
+					}
+				}
 				return nil
 			},
 			Run: func(cmd *cobra.Command, args []string) {
-				backend = "rr"
+				if backend == "default" {
+					backend = "rr"
+				}
 				os.Exit(execute(0, []string{}, conf, args[0], debugger.ExecutingOther, args, buildFlags))
 			},
 		}
 		rootCommand.AddCommand(replayCommand)
 	}
 
+	if runtime.GOOS == "linux" || docCall {
+		restoreCommand := &cobra.Command{
+			Use:   "restore [checkpoint directory]",
+			Short: "Restores a checkpoint created with CRIU.",
+			Long: `Restores a checkpoint created with CRIU and begins debugging it.
+
+The restore command restores a process from the directory created by the
+'checkpoint' command of a previous debug session, on a live process using the
+native backend, and attaches to it. CRIU must be installed:
+https://criu.org
+`,
+			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+				if len(args) == 0 {
+					return errors.New("you must provide a checkpoint directory")
+				}
+				return nil
+			},
+			Run: func(cmd *cobra.Command, args []string) {
+				checkpointDir = args[0]
+				os.Exit(execute(0, []string{}, conf, "", debugger.ExecutingOther, args, buildFlags))
+			},
+		}
+		rootCommand.AddCommand(restoreCommand)
+	}
+
 	rootCommand.AddCommand(&cobra.Command{
 		Use:   "backend",
 		Short: "Help about the --backend flag.",
 		Long: `The --backend flag specifies which backend should be used, possible values
 are:
 
-	default		Uses lldb on macOS, native everywhere else.
+	default		Uses lldb on macOS and OpenBSD, native everywhere else.
 	native		Native backend.
 	lldb		Uses lldb-server or debugserver.
 	rr		Uses mozilla rr (https://github.com/mozilla/rr).
+	qemu		Uses qemu-user (linux only, see below).
 
+The qemu backend starts the program using the qemu-user executable for its
+architecture (for example qemu-aarch64 for arm64 programs) and connects to
+its gdb stub. It can be used to debug programs compiled for a different
+architecture than the one Delve is running on:
+
+	GOARCH=arm64 dlv debug --backend=qemu
+
+The rr backend is a record and replay engine: the program is recorded first
+and then debugged by replaying the recording, which can also be executed
+backwards. Recordings can be replayed later with 'dlv replay'.
 `})
 
 	rootCommand.AddCommand(&cobra.Command{
@@ -390,6 +567,138 @@ The syntax for '-r' argument is:
 Where source is one of 'stdin', 'stdout' or 'stderr' and destination is the path to a file. If the source is omitted stdin is used implicitly.
 
 File redirects can also be changed using the 'restart' command.
+
+When running in headless mode the --capture-output argument can be used to
+make the standard descriptors of the target process available to clients
+through the API: its output is reported to all clients and clients can write
+to its standard input. With --capture-output=pipe (the default) each
+descriptor is redirected to a separate pipe, with --capture-output=pty they
+are all redirected to a pseudo-terminal, merging stdout and stderr.
+`,
+	})
+
+	rootCommand.AddCommand(&cobra.Command{
+		Use:   "pretty-printers",
+		Short: "Help about pretty printers.",
+		Long: `The --pretty-printers flag loads a starlark script that can register pretty
+printers for user types, by calling the pretty_printer builtin:
+
+	pretty_printer(type_name, fn)
+
+Every time a variable of type type_name is sent to a client fn is called with
+the variable as argument, if fn returns a string it will be used as the value
+of the variable, if it returns None the variable is displayed normally.
+
+Structs are passed to fn as values whose fields can be accessed as attributes,
+arrays and slices as lists, maps as dicts and pointers and interfaces as the
+value they point to. For example:
+
+	def decimal(v):
+		if v.exp >= 0:
+			return str(v.value) + "0" * v.exp
+		return None
+
+	pretty_printer("main.Decimal", decimal)
+
+Pretty printers apply to all clients, including DAP clients.
+`,
+	})
+
+	rootCommand.AddCommand(&cobra.Command{
+		Use:   "tls",
+		Short: "Help about securing connections with TLS.",
+		Long: `Connections between a headless server (including dap servers) and its
+clients can be secured with TLS using the --tls-cert, --tls-key and
+--tls-ca flags.
+
+A headless server started with --tls-cert and --tls-key will only accept TLS
+connections, presenting the specified certificate to its clients. If --tls-ca
+is also specified the server will require clients to present a certificate
+signed by one of the certificate authorities contained in the file. The
+same applies to the HTTP+JSON interface served on --rest-addr.
+
+When used with the connect command --tls-ca specifies the certificate
+authorities used to verify the server certificate, instead of the system's
+certificate pool, and --tls-cert and --tls-key specify the client
+certificate. For example:
+
+	dlv exec --headless --listen=:2345 --tls-cert=server.pem --tls-key=server.key --tls-ca=ca.pem ./prog
+	dlv connect --tls-cert=client.pem --tls-key=client.key --tls-ca=ca.pem host:2345
+
+The connection to a gdbserial stub made by 'dlv attach --stub' is secured
+with TLS when --stub-tls is specified, in that case the flags have the same
+meaning they have for the connect command. This can be used to reach stubs
+through a TLS terminating proxy:
+
+	dlv attach --stub=host:1234 --stub-tls --tls-ca=ca.pem 4321 ./prog
+
+All certificates and keys must be PEM encoded.
+When --continue is used together with --tls-ca the server certificate must
+also be valid for client authentication.
+`,
+	})
+
+	rootCommand.AddCommand(&cobra.Command{
+		Use:   "websocket",
+		Short: "Help about connecting to a headless server from a browser.",
+		Long: `A headless server (including dap servers) started with --websocket accepts
+WebSocket connections instead of plain TCP connections, so that web based
+frontends can connect to it directly from a browser. For example:
+
+	dlv exec --headless --listen=:2345 --websocket --websocket-origin=http://localhost:8080 ./prog
+
+The WebSocket connection carries the same protocol, JSON-RPC or DAP, that a
+plain TCP connection would. Each message sent by the client is appended to
+the stream read by the server and each message sent by the server contains
+the next chunk of its replies: message boundaries have no meaning.
+Messages from the server are always sent as binary messages.
+
+Browsers can only connect if the origin of the web page is listed with
+--websocket-origin, which can be specified multiple times, '*' allows
+connections from all web pages. Clients that are not browsers are always
+allowed to connect. --websocket can be used together with the TLS options,
+see 'dlv help tls'.
+`,
+	})
+
+	rootCommand.AddCommand(&cobra.Command{
+		Use:   "auth",
+		Short: "Help about authenticating clients of a headless server.",
+		Long: `A headless server started with --auth-policy only serves clients that
+authenticate with one of the tokens listed in the policy file and can forbid
+some operations to each token. The policy file is a JSON file like this:
+
+	{
+		"tokens": [
+			{ "token": "TOKEN1" },
+			{ "token": "TOKEN2", "deny": ["set", "call", "detach", "exec", "dump"] }
+		]
+	}
+
+The operations that can be denied are:
+
+	set	changing the value of variables, writing memory, writing
+		to the standard input of the target, loading sessions and
+		enabling branch tracing
+	call	calling functions, including the String and Error methods
+		called while evaluating expressions, raw calls, function
+		patches and memory guards
+	detach	detaching from the target or killing it
+	exec	restarting the target, which can rebuild it and start it
+		with different arguments, and adding event hooks, which run
+		scripts on the server
+	dump	writing core files and checkpoints on the machine running
+		the server
+
+Clients connect with:
+
+	dlv connect --auth-token=TOKEN1 host:2345
+
+or by setting the DELVE_AUTH_TOKEN environment variable, which does not make
+the token visible to other users of the machine. Tokens are sent in clear
+text unless the connection is secured with TLS, see 'dlv help tls'.
+API clients must call RPCServer.Authenticate before any other method.
+--auth-policy is not supported by dap servers.
 `,
 	})
 
@@ -415,9 +724,27 @@ func dapCmd(cmd *cobra.Command, args []string) {
 		if initFile != "" {
 			fmt.Fprint(os.Stderr, "Warning: init file ignored with dap\n")
 		}
+		if tui {
+			fmt.Fprint(os.Stderr, "Warning: --tui ignored with dap\n")
+		}
 		if continueOnStart {
 			fmt.Fprintf(os.Stderr, "Warning: continue ignored with dap; specify via launch/attach request instead\n")
 		}
+		if authPolicyFile != "" {
+			fmt.Fprint(os.Stderr, "Error: --auth-policy is not supported with dap\n")
+			return 1
+		}
+		if initStateFile != "" {
+			fmt.Fprint(os.Stderr, "Warning: init state ignored with dap\n")
+		}
+		if metricsAddr != "" {
+			fmt.Fprint(os.Stderr, "Error: --metrics-addr is not supported with dap\n")
+			return 1
+		}
+		if restAddr != "" {
+			fmt.Fprint(os.Stderr, "Error: --rest-addr is not supported with dap\n")
+			return 1
+		}
 		if buildFlags != "" {
 			fmt.Fprintf(os.Stderr, "Warning: build flags ignored with dap; specify via launch/attach request instead\n")
 		}
@@ -432,7 +759,7 @@ func dapCmd(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(os.Stderr, "Warning: program flags ignored with dap; specify via launch/attach request instead\n")
 		}
 
-		listener, err := net.Listen("tcp", addr)
+		listener, err := listen(addr)
 		if err != nil {
 			fmt.Printf("couldn't start listener: %s\n", err)
 			return 1
@@ -447,6 +774,7 @@ func dapCmd(cmd *cobra.Command, args []string) {
 				DebugInfoDirectories: conf.DebugInfoDirectories,
 				CheckGoVersion:       checkGoVersion,
 				TTY:                  tty,
+				PrettyPrinters:       prettyPrinters,
 			},
 		})
 		defer server.Stop()
@@ -494,6 +822,10 @@ func traceCmd(cmd *cobra.Command, args []string) {
 		if acceptMulti {
 			fmt.Fprintf(os.Stderr, "Warning: accept multiclient mode not supported with trace")
 		}
+		if traceFormat != "text" && traceFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Unknown trace format %q\n", traceFormat)
+			return 1
+		}
 
 		var regexp string
 		var processArgs []string
@@ -568,12 +900,17 @@ func traceCmd(cmd *cobra.Command, args []string) {
 			fmt.Fprintln(os.Stderr, err)
 			return 1
 		}
+		stackDepth := traceStackDepth
+		if traceSummary && stackDepth < 1 {
+			// The caller of each call is needed by the summary.
+			stackDepth = 1
+		}
 		for i := range funcs {
 			_, err = client.CreateBreakpoint(&api.Breakpoint{
 				FunctionName: funcs[i],
 				Tracepoint:   true,
 				Line:         -1,
-				Stacktrace:   traceStackDepth,
+				Stacktrace:   stackDepth,
 				LoadArgs:     &terminal.ShortLoadConfig,
 			})
 			if err != nil && !isBreakpointExistsErr(err) {
@@ -601,8 +938,13 @@ func traceCmd(cmd *cobra.Command, args []string) {
 		}
 		cmds := terminal.DebugCommands(client)
 		t := terminal.New(client, nil)
+		t.TraceJSON = traceFormat == "json"
+		t.TraceSummary = traceSummary
 		defer t.Close()
 		cmds.Call("continue", t)
+		if traceSummary {
+			t.PrintTraceSummary(os.Stderr)
+		}
 		return 0
 	}()
 	os.Exit(status)
@@ -628,6 +970,12 @@ func testCmd(cmd *cobra.Command, args []string) {
 		}
 		defer gobuild.Remove(debugname)
 		processArgs := append([]string{debugname}, targetArgs...)
+		if testRun != "" {
+			processArgs = append(processArgs, "-test.run", testRunPattern(testRun))
+		}
+		if testBreakOnFail && headless {
+			fmt.Fprint(os.Stderr, "Warning: --break-on-fail ignored with --headless\n")
+		}
 
 		if workingDir == "" {
 			if len(dlvArgs) == 1 {
@@ -642,6 +990,16 @@ func testCmd(cmd *cobra.Command, args []string) {
 	os.Exit(status)
 }
 
+// testRunPattern returns the -test.run pattern that matches exactly the
+// test, or subtest, name.
+func testRunPattern(name string) string {
+	v := strings.Split(name, "/")
+	for i := range v {
+		v[i] = "^" + regexp.QuoteMeta(strings.Replace(v[i], " ", "_", -1)) + "$"
+	}
+	return strings.Join(v, "/")
+}
+
 func getPackageDir(pkg string) string {
 	out, err := exec.Command("go", "list", "--json", pkg).CombinedOutput()
 	if err != nil {
@@ -659,14 +1017,117 @@ func getPackageDir(pkg string) string {
 }
 
 func attachCmd(cmd *cobra.Command, args []string) {
-	pid, err := strconv.Atoi(args[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid pid: %s\n", args[0])
-		os.Exit(1)
+	var pid int
+	if attachContainer != "" {
+		var err error
+		pid, err = containerPid(attachContainer)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		args = append([]string{strconv.Itoa(pid)}, args...)
+	} else {
+		var err error
+		pid, err = strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid pid: %s\n", args[0])
+			os.Exit(1)
+		}
+	}
+	if attachStub != "" {
+		if attachStubTLS {
+			var err error
+			attachStubTLSConfig, err = tlsConfig.ClientConfig(attachStub)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	} else {
+		if attachStubTLS {
+			fmt.Fprintln(os.Stderr, "--stub-tls can only be used with --stub")
+			os.Exit(1)
+		}
+		attachSysRoot = linutil.ContainerRoot(pid)
 	}
 	os.Exit(execute(pid, args[1:], conf, "", debugger.ExecutingOther, args, buildFlags))
 }
 
+// recorderAvailable returns true if one of the record and replay engines
+// can be used.
+func recorderAvailable() bool {
+	for _, rec := range proc.Recorders() {
+		if rec.Available() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// containerPid returns the PID, in Delve's PID namespace, of the main
+// process of the container with the specified ID, using the first
+// container runtime, among docker, podman and crictl, that knows about it.
+func containerPid(id string) (int, error) {
+	runtimes := [][]string{
+		{"docker", "inspect", "--format", "{{.State.Pid}}", id},
+		{"podman", "inspect", "--format", "{{.State.Pid}}", id},
+		{"crictl", "inspect", "--output", "go-template", "--template", "{{.info.pid}}", id},
+	}
+	found := false
+	for _, argv := range runtimes {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		found = true
+		out, err := exec.Command(argv[0], argv[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil || pid == 0 {
+			continue
+		}
+		return pid, nil
+	}
+	if !found {
+		return 0, errors.New("could not find a container runtime (docker, podman or crictl)")
+	}
+	return 0, fmt.Errorf("could not find running container %s", id)
+}
+
+// containerSubstitutePath returns substitute-path rules for the source
+// files, in sources, that do not exist on the host but can be found inside
+// the filesystem of a container, rooted at root.
+func containerSubstitutePath(sources []string, root string) config.SubstitutePathRules {
+	var rules config.SubstitutePathRules
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		dir := filepath.Dir(source)
+		if !filepath.IsAbs(source) || seen[dir] {
+			continue
+		}
+		if _, err := os.Stat(source); err == nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, source)); err != nil {
+			continue
+		}
+		seen[dir] = true
+		rules = append(rules, config.SubstitutePathRule{From: dir, To: filepath.Join(root, dir)})
+	}
+	return rules
+}
+
+// substitutePathRules converts the substitute-path rules of the
+// configuration into the form used by the debugger.
+func substitutePathRules(rules config.SubstitutePathRules) [][2]string {
+	var r [][2]string
+	for _, rule := range rules {
+		r = append(r, [2]string{rule.From, rule.To})
+	}
+	return r
+}
+
 func coreCmd(cmd *cobra.Command, args []string) {
 	os.Exit(execute(0, []string{args[0]}, conf, args[1], debugger.ExecutingOther, args, buildFlags))
 }
@@ -721,12 +1182,44 @@ func splitArgs(cmd *cobra.Command, args []string) ([]string, []string) {
 func connect(addr string, clientConn net.Conn, conf *config.Config, kind debugger.ExecuteKind) int {
 	// Create and start a terminal - attach to running instance
 	var client *rpc2.RPCClient
-	if clientConn != nil {
+	if authToken == "" {
+		authToken = os.Getenv("DELVE_AUTH_TOKEN")
+	}
+	if authToken != "" {
+		conn := clientConn
+		if conn == nil {
+			var err error
+			conn, err = dialServer(addr, false)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+		var err error
+		client, err = rpc2.NewClientFromConnWithToken(conn, authToken)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not authenticate: %v\n", err)
+			return 1
+		}
+	} else if clientConn != nil {
 		client = rpc2.NewClientFromConn(clientConn)
+	} else if tlsConfig.Enabled() {
+		clientConfig, err := tlsConfig.ClientConfig(addr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		client = rpc2.NewClientTLS(addr, clientConfig)
 	} else {
 		client = rpc2.NewClient(addr)
 	}
-	if client.IsMulticlient() {
+	if observer {
+		if err := client.SetObserver(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not connect as an observer: %v\n", err)
+			return 1
+		}
+	}
+	if client.IsMulticlient() && !observer {
 		state, _ := client.GetStateNonBlocking()
 		// The error return of GetState will usually be the ErrProcessExited,
 		// which we don't care about. If there are other errors they will show up
@@ -740,8 +1233,24 @@ func connect(addr string, clientConn net.Conn, conf *config.Config, kind debugge
 			}
 		}
 	}
+	if attachSysRoot != "" {
+		// Source files of a program running inside a container are accessed
+		// through the root directory of the process.
+		if sources, err := client.ListSources(""); err == nil {
+			if rules := containerSubstitutePath(sources, attachSysRoot); len(rules) > 0 {
+				newconf := config.Config{}
+				if conf != nil {
+					newconf = *conf
+				}
+				newconf.SubstitutePath = append(append(config.SubstitutePathRules{}, newconf.SubstitutePath...), rules...)
+				conf = &newconf
+			}
+		}
+	}
 	term := terminal.New(client, conf)
 	term.InitFile = initFile
+	term.TUI = tui
+	term.BreakOnTestFailure = testBreakOnFail
 	status, err := term.Run()
 	if err != nil {
 		fmt.Println(err)
@@ -759,6 +1268,9 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	if headless && (initFile != "") {
 		fmt.Fprint(os.Stderr, "Warning: init file ignored with --headless\n")
 	}
+	if headless && tui {
+		fmt.Fprint(os.Stderr, "Warning: --tui ignored with --headless\n")
+	}
 	if continueOnStart {
 		if !headless {
 			fmt.Fprint(os.Stderr, "Error: --continue only works with --headless; use an init file\n")
@@ -768,6 +1280,37 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 			fmt.Fprint(os.Stderr, "Error: --continue requires --accept-multiclient\n")
 			return 1
 		}
+		if websocket {
+			fmt.Fprint(os.Stderr, "Error: --continue can not be used with --websocket\n")
+			return 1
+		}
+	}
+	if websocket && !headless {
+		fmt.Fprint(os.Stderr, "Error: --websocket only works with --headless\n")
+		return 1
+	}
+	var authPolicy *service.AuthPolicy
+	if authPolicyFile != "" {
+		if !headless {
+			fmt.Fprint(os.Stderr, "Error: --auth-policy only works with --headless\n")
+			return 1
+		}
+		var err error
+		authPolicy, err = service.LoadAuthPolicy(authPolicyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	if metricsAddr != "" && !headless {
+		fmt.Fprint(os.Stderr, "Error: --metrics-addr only works with --headless\n")
+		return 1
+	}
+
+	if restAddr != "" && !headless {
+		fmt.Fprint(os.Stderr, "Error: --rest-addr only works with --headless\n")
+		return 1
 	}
 
 	if !headless && acceptMulti {
@@ -797,18 +1340,50 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 		return 1
 	}
 
+	if captureOutput != "" {
+		if !headless {
+			fmt.Fprint(os.Stderr, "Error: --capture-output only works with --headless\n")
+			return 1
+		}
+		if len(redirects) > 0 || tty != "" {
+			fmt.Fprint(os.Stderr, "Error: --capture-output can not be used together with -r or --tty\n")
+			return 1
+		}
+		if captureOutput != "pipe" && captureOutput != "pty" {
+			fmt.Fprintf(os.Stderr, "Error: unknown --capture-output mode %q\n", captureOutput)
+			return 1
+		}
+	}
+
 	redirects, err := parseRedirects(redirects)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return 1
 	}
 
+	var initState *api.Session
+	if initStateFile != "" {
+		initState, err = loadSession(initStateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		if !headless && len(initState.SubstitutePath) > 0 {
+			newconf := *conf
+			newconf.SubstitutePath = append(config.SubstitutePathRules{}, conf.SubstitutePath...)
+			for _, r := range initState.SubstitutePath {
+				newconf.SubstitutePath = append(newconf.SubstitutePath, config.SubstitutePathRule{From: r[0], To: r[1]})
+			}
+			conf = &newconf
+		}
+	}
+
 	var listener net.Listener
 	var clientConn net.Conn
 
 	// Make a TCP listener
 	if headless {
-		listener, err = net.Listen("tcp", addr)
+		listener, err = listen(addr)
 	} else {
 		listener, clientConn = service.ListenerPipe()
 	}
@@ -818,6 +1393,26 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	}
 	defer listener.Close()
 
+	var metricsListener net.Listener
+	if metricsAddr != "" {
+		metricsListener, err = net.Listen("tcp", metricsAddr)
+		if err != nil {
+			fmt.Printf("couldn't start metrics listener: %s\n", err)
+			return 1
+		}
+		defer metricsListener.Close()
+	}
+
+	var restListener net.Listener
+	if restAddr != "" {
+		restListener, err = listenTLS(restAddr)
+		if err != nil {
+			fmt.Printf("couldn't start REST listener: %s\n", err)
+			return 1
+		}
+		defer restListener.Close()
+	}
+
 	var server service.Server
 
 	disconnectChan := make(chan struct{})
@@ -836,20 +1431,34 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 			APIVersion:         apiVersion,
 			CheckLocalConnUser: checkLocalConnUser,
 			DisconnectChan:     disconnectChan,
+			AuthPolicy:         authPolicy,
+			MetricsListener:    metricsListener,
+			RESTListener:       restListener,
+			RESTOrigins:        restOrigins,
 			Debugger: debugger.Config{
-				AttachPid:            attachPid,
-				WorkingDir:           workingDir,
-				Backend:              backend,
-				CoreFile:             coreFile,
-				Foreground:           headless && tty == "",
-				Packages:             dlvArgs,
-				BuildFlags:           buildFlags,
-				ExecuteKind:          kind,
-				DebugInfoDirectories: conf.DebugInfoDirectories,
-				CheckGoVersion:       checkGoVersion,
-				TTY:                  tty,
-				Redirects:            redirects,
-				DisableASLR:          disableASLR,
+				AttachPid:                  attachPid,
+				AttachStub:                 attachStub,
+				StubTLSConfig:              attachStubTLSConfig,
+				WorkingDir:                 workingDir,
+				Backend:                    backend,
+				CoreFile:                   coreFile,
+				CheckpointDir:              checkpointDir,
+				Foreground:                 headless && tty == "" && captureOutput == "",
+				Packages:                   dlvArgs,
+				BuildFlags:                 buildFlags,
+				ExecuteKind:                kind,
+				DebugInfoDirectories:       conf.DebugInfoDirectories,
+				SubstitutePath:             substitutePathRules(conf.SubstitutePath),
+				CheckGoVersion:             checkGoVersion,
+				TTY:                        tty,
+				Redirects:                  redirects,
+				CaptureOutput:              captureOutput,
+				DisableASLR:                disableASLR,
+				StopAtSafePoints:           stopAtSafePoints,
+				QueueBreakpointsDuringNext: queueBreakpointsDuringNext,
+				StopAtInlinedCalls:         stopAtInlinedCalls,
+				PrettyPrinters:             prettyPrinters,
+				InitState:                  initState,
 			},
 		})
 	default:
@@ -877,7 +1486,30 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	var status int
 	if headless {
 		if continueOnStart {
-			client := rpc2.NewClient(listener.Addr().String())
+			var client *rpc2.RPCClient
+			if authPolicy != nil {
+				// We are connecting to ourselves, any token will do.
+				conn, err := dialServer(listener.Addr().String(), true)
+				if err == nil {
+					client, err = rpc2.NewClientFromConnWithToken(conn, authPolicy.Tokens[0].Token)
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 1
+				}
+			} else if tlsConfig.Enabled() {
+				clientConfig, err := tlsConfig.ClientConfig(listener.Addr().String())
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 1
+				}
+				// We are connecting to ourselves, there is no need to verify the
+				// server certificate.
+				clientConfig.InsecureSkipVerify = true
+				client = rpc2.NewClientTLS(listener.Addr().String(), clientConfig)
+			} else {
+				client = rpc2.NewClient(listener.Addr().String())
+			}
 			client.Disconnect(true) // true = continue after disconnect
 		}
 		waitForDisconnectSignal(disconnectChan)
@@ -892,6 +1524,66 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	return connect(listener.Addr().String(), clientConn, conf, kind)
 }
 
+// listen creates the listener of a headless server, if TLS options were
+// specified the listener will only accept TLS connections, if --websocket
+// was specified it will only accept WebSocket connections.
+func listen(addr string) (net.Listener, error) {
+	listener, err := listenTLS(addr)
+	if err != nil {
+		return nil, err
+	}
+	if websocket {
+		listener = service.WebSocketListener(listener, websocketOrigins)
+	}
+	return listener, nil
+}
+
+// listenTLS creates a listener on addr that, if TLS options were
+// specified, will only accept TLS connections.
+func listenTLS(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig.Enabled() {
+		serverConfig, err := tlsConfig.ServerConfig()
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		listener = tls.NewListener(listener, serverConfig)
+	}
+	return listener, nil
+}
+
+// loadSession reads a session saved by the 'session save' command.
+func loadSession(path string) (*api.Session, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &api.Session{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// dialServer connects to the headless server listening at addr, using TLS
+// if TLS options were specified. If skipVerify is true the certificate of
+// the server is not verified.
+func dialServer(addr string, skipVerify bool) (net.Conn, error) {
+	if !tlsConfig.Enabled() {
+		return net.Dial("tcp", addr)
+	}
+	clientConfig, err := tlsConfig.ClientConfig(addr)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig.InsecureSkipVerify = skipVerify
+	return tls.Dial("tcp", addr, clientConfig)
+}
+
 func parseRedirects(redirects []string) ([3]string, error) {
 	r := [3]string{}
 	names := [3]string{"stdin", "stdout", "stderr"}
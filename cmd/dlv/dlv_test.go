@@ -3,6 +3,7 @@ package main_test
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -663,6 +664,58 @@ func TestTrace(t *testing.T) {
 	cmd.Wait()
 }
 
+func TestTraceJSON(t *testing.T) {
+	dlvbin, tmpdir := getDlvBin(t)
+	defer os.RemoveAll(tmpdir)
+
+	fixtures := protest.FindFixturesDir()
+	cmd := exec.Command(dlvbin, "trace", "--format", "json", "--output", filepath.Join(tmpdir, "__debug"), filepath.Join(fixtures, "issue573.go"), "foo")
+	rdr, err := cmd.StderrPipe()
+	assertNoError(err, t, "stderr pipe")
+	defer rdr.Close()
+
+	cmd.Dir = filepath.Join(fixtures, "buildtest")
+
+	assertNoError(cmd.Start(), t, "running trace")
+
+	output, err := ioutil.ReadAll(rdr)
+	assertNoError(err, t, "ReadAll")
+	cmd.Wait()
+
+	type traceValue struct {
+		Name, Value string
+	}
+	type traceEvent struct {
+		Kind         string
+		Goroutine    int
+		Function     string
+		Args         []traceValue
+		ReturnValues []traceValue
+		Duration     int64
+	}
+	var events []traceEvent
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var ev traceEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("could not parse %q: %v", line, err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected two events, got:\n%s", string(output))
+	}
+	call, ret := events[0], events[1]
+	if call.Kind != "call" || call.Goroutine != 1 || call.Function != "main.foo" || len(call.Args) != 2 || call.Args[0].Value != "99" {
+		t.Errorf("wrong call event %#v", call)
+	}
+	if ret.Kind != "return" || ret.Function != "main.foo" || len(ret.ReturnValues) != 1 || ret.ReturnValues[0].Value != "9900" || ret.Duration <= 0 {
+		t.Errorf("wrong return event %#v", ret)
+	}
+}
+
 func TestTracePid(t *testing.T) {
 	if runtime.GOOS == "linux" {
 		bs, _ := ioutil.ReadFile("/proc/sys/kernel/yama/ptrace_scope")
@@ -33,6 +33,19 @@ type SubstitutePathRules []SubstitutePathRule
 type Config struct {
 	// Commands aliases.
 	Aliases map[string][]string `yaml:"aliases"`
+	// User defined commands, each line of a definition is a command.
+	Commands map[string]string `yaml:"commands,omitempty"`
+	// Commands executed when the debugging session starts, every time the
+	// target stops and when the target exits.
+	OnStart []string `yaml:"on-start,omitempty"`
+	OnStop  []string `yaml:"on-stop,omitempty"`
+	OnExit  []string `yaml:"on-exit,omitempty"`
+	// HistoryFile is the file where the command history is saved, by
+	// default .dbg_history in the configuration directory.
+	HistoryFile string `yaml:"history-file,omitempty"`
+	// HistoryExclude is a list of regular expressions, commands matching
+	// any of them are not saved in the history.
+	HistoryExclude []string `yaml:"history-exclude,omitempty"`
 	// Source code path substitution rules.
 	SubstitutePath SubstitutePathRules `yaml:"substitute-path"`
 
@@ -49,6 +62,19 @@ type Config struct {
 	// this list "intel"(default), "gnu", "go"
 	DisassembleFlavor *string `yaml:"disassemble-flavor,omitempty"`
 
+	// DisableWellKnownTypes disables the human readable representation of
+	// variables of well known types (time.Time, math/big.Int, sync.Map,
+	// etc).
+	DisableWellKnownTypes bool `yaml:"disable-well-known-types,omitempty"`
+
+	// DisablePager disables the pager used to show the output of commands
+	// that does not fit in the terminal.
+	DisablePager bool `yaml:"disable-pager,omitempty"`
+
+	// BytesView selects how slices and arrays of bytes are printed, one of
+	// "elements" (default), "string", "hex" or "both".
+	BytesView *string `yaml:"bytes-view,omitempty"`
+
 	// If ShowLocationExpr is true whatis will print the DWARF location
 	// expression for its argument.
 	ShowLocationExpr bool `yaml:"show-location-expr"`
@@ -57,6 +83,16 @@ type Config struct {
 	// here: https://en.wikipedia.org/wiki/ANSI_escape_code#Colors)
 	SourceListLineColor int `yaml:"source-list-line-color"`
 
+	// DisableSyntaxHighlight disables the syntax highlighting of the Go
+	// source code printed by list and when the target stops.
+	DisableSyntaxHighlight bool `yaml:"disable-syntax-highlight,omitempty"`
+	// Colors of keywords, string and character literals, numbers and
+	// comments in source listings, same codes as SourceListLineColor.
+	SourceListKeywordColor int `yaml:"source-list-keyword-color,omitempty"`
+	SourceListStringColor  int `yaml:"source-list-string-color,omitempty"`
+	SourceListNumberColor  int `yaml:"source-list-number-color,omitempty"`
+	SourceListCommentColor int `yaml:"source-list-comment-color,omitempty"`
+
 	// number of lines to list above and below cursor when printfile() is
 	// called (i.e. when execution stops, listCommand is used, etc)
 	SourceListLineCount *int `yaml:"source-list-line-count,omitempty"`
@@ -212,11 +248,24 @@ func writeDefaultConfig(f *os.File) error {
 # This is the default configuration file. Available options are provided, but disabled.
 # Delete the leading hash mark to enable an item.
 
+# Output of commands like goroutines, print and stack that does not fit in the
+# terminal is shown with the program specified by $PAGER or, if it is not set,
+# with an internal pager. Uncomment to disable.
+# disable-pager: true
+
 # Uncomment the following line and set your preferred ANSI foreground color
 # for source line numbers in the (list) command (if unset, default is 34,
 # dark blue) See https://en.wikipedia.org/wiki/ANSI_escape_code#3/4_bit
 # source-list-line-color: 34
 
+# Uncomment to change the colors used to highlight the syntax of Go source
+# code, or to disable syntax highlighting.
+# source-list-keyword-color: 33
+# source-list-string-color: 32
+# source-list-number-color: 36
+# source-list-comment-color: 90
+# disable-syntax-highlight: true
+
 # Uncomment to change the number of lines printed above and below cursor when
 # listing source code.
 # source-list-line-count: 5
@@ -225,6 +274,25 @@ func writeDefaultConfig(f *os.File) error {
 aliases:
   # command: ["alias1", "alias2"]
 
+# User defined commands, see 'help def'. Each line of a definition is executed as a command.
+# commands:
+  # bphere: break %file:%line
+
+# Commands executed automatically when the debugging session starts, every
+# time the target stops and when the target exits. Starlark scripts can be
+# run with the 'source' command.
+# on-start: ["break main.main"]
+# on-stop: ["print main.counter"]
+# on-exit: ["source exit.star"]
+
+# File where the command history is saved, by default .dbg_history in the
+# configuration directory.
+# history-file: "/home/user/.dlv_history"
+
+# Commands matching any of these regular expressions are not saved in the
+# command history.
+# history-exclude: ["(?i)password", "(?i)token"]
+
 # Define sources path substitution rules. Can be used to rewrite a source path stored
 # in program's debug information, if the sources were moved to a different place
 # between compilation and debugging.
@@ -242,6 +310,12 @@ substitute-path:
 # Output evaluation.
 # max-variable-recurse: 1
 
+# Uncomment the following line to print variables of well known types (time.Time, math/big.Int, sync.Map, etc) using their internal representation.
+# disable-well-known-types: true
+
+# Print slices and arrays of bytes as a list of numbers ("elements", the default), as a string ("string"), as a hexdump ("hex") or both as a string and as a hexdump ("both").
+# bytes-view: elements
+
 # Uncomment the following line to make the whatis command also print the DWARF location expression of its argument.
 # show-location-expr: true
 
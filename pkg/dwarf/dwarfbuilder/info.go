@@ -233,6 +233,8 @@ func (b *Builder) makeAbbrevTable() []byte {
 		util.EncodeULEB128(&abbrev, 0)
 		util.EncodeULEB128(&abbrev, 0)
 	}
+	// terminate the abbrev table
+	util.EncodeULEB128(&abbrev, 0)
 
 	return abbrev.Bytes()
 }
@@ -18,6 +18,9 @@ type CommonInformationEntry struct {
 	ReturnAddressRegister uint64
 	InitialInstructions   []byte
 	staticBase            uint64
+
+	// eh_frame pointer encoding
+	ptrEncAddr ptrEnc
 }
 
 // FrameDescriptionEntry represents a Frame Descriptor Entry in the
@@ -78,10 +81,62 @@ func (fdes FrameDescriptionEntries) FDEForPC(pc uint64) (*FrameDescriptionEntry,
 }
 
 // Append appends otherFDEs to fdes and returns the result.
+// If an entry of otherFDEs covers exactly the same range as an entry
+// already in fdes it is discarded, this happens when a binary has both a
+// .debug_frame and a .eh_frame section.
 func (fdes FrameDescriptionEntries) Append(otherFDEs FrameDescriptionEntries) FrameDescriptionEntries {
 	r := append(fdes, otherFDEs...)
-	sort.Slice(r, func(i, j int) bool {
+	sort.SliceStable(r, func(i, j int) bool {
 		return r[i].Begin() < r[j].Begin()
 	})
-	return r
+	uniq := r[:0]
+	for _, fde := range r {
+		if len(uniq) > 0 {
+			last := uniq[len(uniq)-1]
+			if last.Begin() == fde.Begin() && last.End() == fde.End() {
+				continue
+			}
+		}
+		uniq = append(uniq, fde)
+	}
+	return uniq
+}
+
+// ptrEnc represents a pointer encoding value, used during .eh_frame
+// parsing.
+type ptrEnc uint8
+
+const (
+	ptrEncAbs    ptrEnc = 0x00 // pointer-sized unsigned integer
+	ptrEncOmit   ptrEnc = 0xff // omitted
+	ptrEncUleb   ptrEnc = 0x01 // ULEB128
+	ptrEncUdata2 ptrEnc = 0x02 // 2 bytes
+	ptrEncUdata4 ptrEnc = 0x03 // 4 bytes
+	ptrEncUdata8 ptrEnc = 0x04 // 8 bytes
+	ptrEncSigned ptrEnc = 0x08 // pointer-sized signed integer
+	ptrEncSleb   ptrEnc = 0x09 // SLEB128
+	ptrEncSdata2 ptrEnc = 0x0a // 2 bytes, signed
+	ptrEncSdata4 ptrEnc = 0x0b // 4 bytes, signed
+	ptrEncSdata8 ptrEnc = 0x0c // 8 bytes, signed
+
+	ptrEncPCRel    ptrEnc = 0x10 // value is relative to the memory address where it appears
+	ptrEncTextRel  ptrEnc = 0x20 // value is relative to the address of the text section
+	ptrEncDataRel  ptrEnc = 0x30 // value is relative to the address of the data section
+	ptrEncFuncRel  ptrEnc = 0x40 // value is relative to the start of the function
+	ptrEncAligned  ptrEnc = 0x50 // value should be aligned
+	ptrEncIndirect ptrEnc = 0x80 // value is an address where the real value of the pointer is stored
+)
+
+// Supported returns true if this pointer encoding is supported.
+func (ptrEnc ptrEnc) Supported() bool {
+	if ptrEnc == ptrEncOmit {
+		return true
+	}
+	szenc := ptrEnc & 0x0f
+	if (szenc > ptrEncUdata8 && szenc < ptrEncSigned) || szenc > ptrEncSdata8 {
+		// These values aren't defined at the moment
+		return false
+	}
+	// Only absolute and PC relative pointers are supported
+	return ptrEnc&0xf0 == 0 || ptrEnc&0xf0 == ptrEncPCRel
 }
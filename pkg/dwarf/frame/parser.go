@@ -6,6 +6,7 @@ package frame
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/go-delve/delve/pkg/dwarf/util"
 )
@@ -15,39 +16,90 @@ type parsefunc func(*parseContext) parsefunc
 type parseContext struct {
 	staticBase uint64
 
-	buf     *bytes.Buffer
-	entries FrameDescriptionEntries
-	common  *CommonInformationEntry
-	frame   *FrameDescriptionEntry
-	length  uint32
-	ptrSize int
+	buf      *bytes.Buffer
+	totalLen int
+	entries  FrameDescriptionEntries
+	common   *CommonInformationEntry
+	frame    *FrameDescriptionEntry
+	length   uint32
+	ptrSize  int
+
+	// ehFrameAddr is the address of the .eh_frame section, zero if we are
+	// parsing a .debug_frame section.
+	ehFrameAddr uint64
+	// ciemap maps the offset of each CIE to the CIE itself, only used when
+	// parsing .eh_frame.
+	ciemap map[int]*CommonInformationEntry
+	err    error
 }
 
 // Parse takes in data (a byte slice) and returns FrameDescriptionEntries,
 // which is a slice of FrameDescriptionEntry. Each FrameDescriptionEntry
 // has a pointer to CommonInformationEntry.
 func Parse(data []byte, order binary.ByteOrder, staticBase uint64, ptrSize int) FrameDescriptionEntries {
-	var (
-		buf  = bytes.NewBuffer(data)
-		pctx = &parseContext{buf: buf, entries: newFrameIndex(), staticBase: staticBase, ptrSize: ptrSize}
-	)
+	pctx := newParseContext(data, staticBase, ptrSize, 0)
+	pctx.parse(order)
+	return pctx.entries
+}
 
-	for fn := parselength; buf.Len() != 0; {
-		fn = fn(pctx)
+// ParseEHFrame is like Parse but reads data in the .eh_frame format, a
+// minor variant of .debug_frame used by C compilers and documented at
+// https://refspecs.linuxfoundation.org/LSB_5.0.0/LSB-Core-generic/LSB-Core-generic/ehframechpt.html
+// The ehFrameAddr argument is the (unrelocated) address of the .eh_frame
+// section, it is needed to decode PC relative pointers.
+func ParseEHFrame(data []byte, order binary.ByteOrder, staticBase uint64, ptrSize int, ehFrameAddr uint64) (FrameDescriptionEntries, error) {
+	if ehFrameAddr == 0 {
+		return nil, fmt.Errorf("invalid address for .eh_frame section")
 	}
+	pctx := newParseContext(data, staticBase, ptrSize, ehFrameAddr)
+	pctx.parse(order)
+	if pctx.err != nil {
+		return nil, pctx.err
+	}
+	return pctx.entries, nil
+}
 
-	for i := range pctx.entries {
-		pctx.entries[i].order = order
+func newParseContext(data []byte, staticBase uint64, ptrSize int, ehFrameAddr uint64) *parseContext {
+	return &parseContext{
+		buf:         bytes.NewBuffer(data),
+		totalLen:    len(data),
+		entries:     newFrameIndex(),
+		staticBase:  staticBase,
+		ptrSize:     ptrSize,
+		ehFrameAddr: ehFrameAddr,
+		ciemap:      make(map[int]*CommonInformationEntry),
 	}
+}
 
-	return pctx.entries
+func (ctx *parseContext) parse(order binary.ByteOrder) {
+	for fn := parselength; ctx.buf.Len() != 0 && ctx.err == nil; {
+		fn = fn(ctx)
+	}
+
+	for i := range ctx.entries {
+		ctx.entries[i].order = order
+	}
+}
+
+func (ctx *parseContext) parsingEHFrame() bool {
+	return ctx.ehFrameAddr != 0
+}
+
+// offset returns the offset of the read cursor from the start of the
+// section.
+func (ctx *parseContext) offset() int {
+	return ctx.totalLen - ctx.buf.Len()
 }
 
-func cieEntry(data []byte) bool {
-	return bytes.Equal(data, []byte{0xff, 0xff, 0xff, 0xff})
+func (ctx *parseContext) cieEntry(cieid uint32) bool {
+	if ctx.parsingEHFrame() {
+		return cieid == 0
+	}
+	return cieid == 0xffffffff
 }
 
 func parselength(ctx *parseContext) parsefunc {
+	start := ctx.offset()
 	binary.Read(ctx.buf, binary.LittleEndian, &ctx.length)
 
 	if ctx.length == 0 {
@@ -55,37 +107,59 @@ func parselength(ctx *parseContext) parsefunc {
 		return parselength
 	}
 
-	var data = ctx.buf.Next(4)
+	var cieid uint32
+	binary.Read(ctx.buf, binary.LittleEndian, &cieid)
 
 	ctx.length -= 4 // take off the length of the CIE id / CIE pointer.
 
-	if cieEntry(data) {
-		ctx.common = &CommonInformationEntry{Length: ctx.length, staticBase: ctx.staticBase}
+	if ctx.cieEntry(cieid) {
+		ctx.common = &CommonInformationEntry{Length: ctx.length, staticBase: ctx.staticBase, CIE_id: cieid, ptrEncAddr: ptrEncAbs}
+		ctx.ciemap[start] = ctx.common
 		return parseCIE
 	}
 
+	if ctx.parsingEHFrame() {
+		// In .eh_frame the CIE pointer is the distance between the CIE pointer
+		// field itself and the start of the CIE.
+		cieoff := ctx.offset() - 4 - int(cieid)
+		ctx.common = ctx.ciemap[cieoff]
+		if ctx.common == nil {
+			ctx.err = fmt.Errorf("unknown CIE at %#x referenced by FDE at %#x", cieoff, start)
+			return nil
+		}
+	}
+
 	ctx.frame = &FrameDescriptionEntry{Length: ctx.length, CIE: ctx.common}
 	return parseFDE
 }
 
 func parseFDE(ctx *parseContext) parsefunc {
-	var num uint64
+	startOff := ctx.offset()
 	r := ctx.buf.Next(int(ctx.length))
 
-	reader := bytes.NewReader(r)
-	num, _ = util.ReadUintRaw(reader, binary.LittleEndian, ctx.ptrSize)
-	ctx.frame.begin = num + ctx.staticBase
-	num, _ = util.ReadUintRaw(reader, binary.LittleEndian, ctx.ptrSize)
-	ctx.frame.size = num
+	buf := bytes.NewBuffer(r)
+	curaddr := func() uint64 {
+		return ctx.ehFrameAddr + uint64(startOff) + uint64(len(r)-buf.Len())
+	}
+	ctx.frame.begin = ctx.readEncodedPtr(curaddr, buf, ctx.frame.CIE.ptrEncAddr) + ctx.staticBase
+	// Only the size part of the pointer encoding applies to the size field,
+	// see decode_frame_entry_1 in gdb/dwarf2-frame.c.
+	ctx.frame.size = ctx.readEncodedPtr(curaddr, buf, ctx.frame.CIE.ptrEncAddr&0x0f)
 
 	// Insert into the tree after setting address range begin
 	// otherwise compares won't work.
 	ctx.entries = append(ctx.entries, ctx.frame)
 
+	if ctx.parsingEHFrame() && len(ctx.frame.CIE.Augmentation) > 0 {
+		// Skip the augmentation data, we don't use any of it.
+		n, _ := util.DecodeULEB128(buf)
+		buf.Next(int(n))
+	}
+
 	// The rest of this entry consists of the instructions
 	// so we can just grab all of the data from the buffer
 	// cursor to length.
-	ctx.frame.Instructions = r[2*ctx.ptrSize:]
+	ctx.frame.Instructions = buf.Bytes()
 	ctx.length = 0
 
 	return parselength
@@ -100,6 +174,11 @@ func parseCIE(ctx *parseContext) parsefunc {
 	// parse augmentation
 	ctx.common.Augmentation, _ = util.ParseString(buf)
 
+	if ctx.parsingEHFrame() && len(ctx.common.Augmentation) > 0 && ctx.common.Augmentation[0] != 'z' {
+		ctx.err = fmt.Errorf("unsupported augmentation %q at %#x", ctx.common.Augmentation, ctx.offset())
+		return nil
+	}
+
 	// parse code alignment factor
 	ctx.common.CodeAlignmentFactor, _ = util.DecodeULEB128(buf)
 
@@ -107,7 +186,46 @@ func parseCIE(ctx *parseContext) parsefunc {
 	ctx.common.DataAlignmentFactor, _ = util.DecodeSLEB128(buf)
 
 	// parse return address register
-	ctx.common.ReturnAddressRegister, _ = util.DecodeULEB128(buf)
+	if ctx.parsingEHFrame() && ctx.common.Version == 1 {
+		b, _ := buf.ReadByte()
+		ctx.common.ReturnAddressRegister = uint64(b)
+	} else {
+		ctx.common.ReturnAddressRegister, _ = util.DecodeULEB128(buf)
+	}
+
+	if ctx.parsingEHFrame() && len(ctx.common.Augmentation) > 0 {
+		util.DecodeULEB128(buf) // augmentation data length
+		for _, ch := range ctx.common.Augmentation[1:] {
+			switch ch {
+			case 'L':
+				// LSDA pointer encoding, not used.
+				buf.ReadByte()
+			case 'R':
+				// Pointer encoding used for the begin and size fields of FDEs.
+				b, _ := buf.ReadByte()
+				ctx.common.ptrEncAddr = ptrEnc(b)
+				if !ctx.common.ptrEncAddr.Supported() {
+					ctx.err = fmt.Errorf("unsupported pointer encoding %#x at %#x", b, ctx.offset())
+					return nil
+				}
+			case 'S':
+				// Signal handler frame, no associated data.
+			case 'P':
+				// Personality routine, a pointer encoding followed by a pointer
+				// encoded as specified. Not used but must be skipped.
+				b, _ := buf.ReadByte()
+				e := ptrEnc(b) &^ ptrEncIndirect
+				if !e.Supported() {
+					ctx.err = fmt.Errorf("unsupported pointer encoding %#x at %#x", b, ctx.offset())
+					return nil
+				}
+				ctx.readEncodedPtr(func() uint64 { return 0 }, buf, e)
+			default:
+				ctx.err = fmt.Errorf("unsupported augmentation %q at %#x", ctx.common.Augmentation, ctx.offset())
+				return nil
+			}
+		}
+	}
 
 	// parse initial instructions
 	// The rest of this entry consists of the instructions
@@ -119,6 +237,56 @@ func parseCIE(ctx *parseContext) parsefunc {
 	return parselength
 }
 
+// readEncodedPtr reads a pointer from buf encoded as specified by enc.
+// When parsing .debug_frame enc is always ptrEncAbs. The addr function
+// returns the address of the current read position, it's used to decode
+// PC relative pointers.
+func (ctx *parseContext) readEncodedPtr(addr func() uint64, buf *bytes.Buffer, enc ptrEnc) uint64 {
+	if enc == ptrEncOmit {
+		return 0
+	}
+
+	var base uint64
+	if enc&0xf0 == ptrEncPCRel {
+		base = addr()
+	}
+
+	var ptr uint64
+	switch enc & 0x0f {
+	case ptrEncAbs, ptrEncSigned:
+		ptr, _ = util.ReadUintRaw(buf, binary.LittleEndian, ctx.ptrSize)
+	case ptrEncUleb:
+		ptr, _ = util.DecodeULEB128(buf)
+	case ptrEncUdata2:
+		var n uint16
+		binary.Read(buf, binary.LittleEndian, &n)
+		ptr = uint64(n)
+	case ptrEncSdata2:
+		var n int16
+		binary.Read(buf, binary.LittleEndian, &n)
+		ptr = uint64(n)
+	case ptrEncUdata4:
+		var n uint32
+		binary.Read(buf, binary.LittleEndian, &n)
+		ptr = uint64(n)
+	case ptrEncSdata4:
+		var n int32
+		binary.Read(buf, binary.LittleEndian, &n)
+		ptr = uint64(n)
+	case ptrEncUdata8, ptrEncSdata8:
+		binary.Read(buf, binary.LittleEndian, &ptr)
+	case ptrEncSleb:
+		n, _ := util.DecodeSLEB128(buf)
+		ptr = uint64(n)
+	}
+
+	ptr += base
+	if ctx.ptrSize == 4 {
+		ptr = uint64(uint32(ptr))
+	}
+	return ptr
+}
+
 // DwarfEndian determines the endianness of the DWARF by using the version number field in the debug_info section
 // Trick borrowed from "debug/dwarf".New()
 func DwarfEndian(infoSec []byte) binary.ByteOrder {
@@ -56,3 +56,57 @@ func BenchmarkParse(b *testing.B) {
 		Parse(data, binary.BigEndian, 0, ptrSizeByRuntimeArch())
 	}
 }
+
+func TestParseEHFrame(t *testing.T) {
+	const ehFrameAddr = 0x1000
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+
+	// CIE: version 1, augmentation "zR", code alignment 1, data alignment
+	// -8, return address register 16, FDE pointers encoded as pcrel|sdata4.
+	cie := []byte{1, 'z', 'R', 0, 1, 0x78, 16, 1, 0x1b, 0x0c, 7, 8, 0x90, 1, 0, 0}
+	binary.Write(&buf, le, uint32(len(cie)+4))
+	binary.Write(&buf, le, uint32(0))
+	buf.Write(cie)
+
+	// FDE for [0x2000, 0x2040)
+	fdeStart := buf.Len()
+	binary.Write(&buf, le, uint32(4+4+4+1+3))
+	binary.Write(&buf, le, uint32(buf.Len())) // CIE pointer, the CIE is at offset 0
+	pcbeginAddr := ehFrameAddr + buf.Len()
+	binary.Write(&buf, le, int32(0x2000-pcbeginAddr))
+	binary.Write(&buf, le, int32(0x40))
+	buf.Write([]byte{0})              // augmentation data length
+	buf.Write([]byte{0x41, 0x0e, 16}) // advance_loc 1, def_cfa_offset 16
+	if buf.Len()-fdeStart != 4+4+4+4+1+3 {
+		t.Fatalf("wrong FDE length %d", buf.Len()-fdeStart)
+	}
+
+	binary.Write(&buf, le, uint32(0)) // terminator
+
+	fdes, err := ParseEHFrame(buf.Bytes(), le, 0x10000, 8, ehFrameAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fdes) != 1 {
+		t.Fatalf("expected 1 FDE got %d", len(fdes))
+	}
+	fde := fdes[0]
+	if fde.Begin() != 0x12000 || fde.End() != 0x12040 {
+		t.Fatalf("wrong FDE range %#x-%#x", fde.Begin(), fde.End())
+	}
+	if fde.CIE.ReturnAddressRegister != 16 || fde.CIE.DataAlignmentFactor != -8 {
+		t.Fatalf("wrong CIE %#v", fde.CIE)
+	}
+	fctx := fde.EstablishFrame(0x12000)
+	if fctx.CFA.Reg != 7 || fctx.CFA.Offset != 8 {
+		t.Fatalf("wrong CFA rule at entry point: %#v", fctx.CFA)
+	}
+	fctx = fde.EstablishFrame(0x12010)
+	if fctx.CFA.Reg != 7 || fctx.CFA.Offset != 16 {
+		t.Fatalf("wrong CFA rule after prologue: %#v", fctx.CFA)
+	}
+	if rule := fctx.Regs[16]; rule.Rule != RuleOffset || rule.Offset != -8 {
+		t.Fatalf("wrong return address rule: %#v", rule)
+	}
+}
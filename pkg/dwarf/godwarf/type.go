@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/go-delve/delve/pkg/dwarf/op"
 	"github.com/go-delve/delve/pkg/dwarf/util"
@@ -777,6 +778,12 @@ func readType(d *dwarf.Data, name string, r *dwarf.Reader, off dwarf.Offset, typ
 		// There is much more to handle C++, all ignored for now.
 		t := new(StructType)
 		t.ReflectKind = getKind(e)
+		if sname, _ := e.Val(dwarf.AttrName).(string); t.ReflectKind == reflect.Invalid && e.Tag == dwarf.TagStructType && strings.HasPrefix(sname, "[]") {
+			// TinyGo doesn't emit DW_AT_go_kind, slices are structs named after
+			// the slice type, the element type is recovered from the ptr field
+			// below.
+			t.ReflectKind = reflect.Slice
+		}
 		switch t.ReflectKind {
 		case reflect.Slice:
 			slice := new(SliceType)
@@ -876,6 +883,10 @@ func readType(d *dwarf.Data, name string, r *dwarf.Reader, off dwarf.Offset, typ
 				zeroArray(lastFieldType)
 			}
 		}
+		if getKind(e) == reflect.Invalid {
+			typ = tinyGoStructType(typ, t)
+			typeCache[off] = typ
+		}
 
 	case dwarf.TagConstType, dwarf.TagVolatileType, dwarf.TagRestrictType:
 		// Type modifier (DWARF v2 §5.2)
@@ -1074,6 +1085,32 @@ Error:
 	return nil, err
 }
 
+// tinyGoStructType completes the conversion of the structs TinyGo uses to
+// describe strings and slices, without a DW_AT_go_kind attribute, into
+// StringType and SliceType.
+// Strings are structs named 'string' with a ptr and len field, slices are
+// structs named after their type with a ptr, len and cap field.
+func tinyGoStructType(typ Type, t *StructType) Type {
+	switch typ := typ.(type) {
+	case *SliceType:
+		if _, isvoid := typ.ElemType.(*VoidType); !isvoid {
+			return typ
+		}
+		for _, f := range t.Field {
+			if ptr, isptr := f.Type.(*PtrType); isptr && f.Name == "ptr" {
+				typ.ElemType = ptr.Type
+			}
+		}
+		return typ
+	case *StructType:
+		if t.Name == "string" && len(t.Field) == 2 && t.Field[0].Name == "ptr" && t.Field[1].Name == "len" {
+			t.ReflectKind = reflect.String
+			return &StringType{StructType: *t}
+		}
+	}
+	return typ
+}
+
 func zeroArray(t Type) {
 	for {
 		at, ok := t.(*ArrayType)
@@ -14,6 +14,9 @@ type Variable struct {
 	// a particular pc by another one with the same name declared in an inner
 	// block.
 	Depth int
+	// Scope is the lexical block, inlined subroutine or function in which
+	// this variable was declared.
+	Scope *godwarf.Tree
 }
 
 // VariablesFlags specifies some configuration flags for the Variables function.
@@ -31,12 +34,13 @@ const (
 // returned. If the VariablesSkipInlinedSubroutines is set, variables from
 // inlined subroutines will be skipped.
 func Variables(root *godwarf.Tree, pc uint64, line int, flags VariablesFlags) []Variable {
-	return variablesInternal(nil, root, 0, pc, line, flags)
+	return variablesInternal(nil, root, nil, 0, pc, line, flags)
 }
 
-// variablesInternal appends to 'v' variables from 'root'. The function calls
-// itself with an incremented scope for all sub-blocks in 'root'.
-func variablesInternal(v []Variable, root *godwarf.Tree, depth int, pc uint64, line int, flags VariablesFlags) []Variable {
+// variablesInternal appends to 'v' variables from 'root', which is contained
+// in the block 'scope'. The function calls itself with an incremented scope
+// for all sub-blocks in 'root'.
+func variablesInternal(v []Variable, root, scope *godwarf.Tree, depth int, pc uint64, line int, flags VariablesFlags) []Variable {
 	switch root.Tag {
 	case dwarf.TagInlinedSubroutine:
 		if flags&VariablesSkipInlinedSubroutines != 0 {
@@ -48,7 +52,7 @@ func variablesInternal(v []Variable, root *godwarf.Tree, depth int, pc uint64, l
 		// pc (or if we don't care about visibility).
 		if (flags&VariablesOnlyVisible == 0) || root.ContainsPC(pc) {
 			for _, child := range root.Children {
-				v = variablesInternal(v, child, depth+1, pc, line, flags)
+				v = variablesInternal(v, child, root, depth+1, pc, line, flags)
 			}
 		}
 		return v
@@ -61,7 +65,7 @@ func variablesInternal(v []Variable, root *godwarf.Tree, depth int, pc uint64, l
 			o = 1
 		}
 		if declLine, ok := root.Val(dwarf.AttrDeclLine).(int64); !ok || line >= int(declLine)+o {
-			return append(v, Variable{root, depth})
+			return append(v, Variable{root, depth, scope})
 		}
 		return v
 	}
@@ -0,0 +1,166 @@
+// Package elfwriter is a package to write ELF files without having their
+// entire contents in memory at any one time.
+// Only 64bit little endian ELF files are supported, the program header
+// table is written at the end of the file.
+package elfwriter
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"io"
+)
+
+// WriteCloserSeeker is the union of io.Writer, io.Closer and io.Seeker.
+type WriteCloserSeeker interface {
+	io.Writer
+	io.Seeker
+	io.Closer
+}
+
+// Writer writes ELF files.
+type Writer struct {
+	w    WriteCloserSeeker
+	fhdr *elf.FileHeader
+	off  int64
+
+	// Err is the first error encountered while writing the file, once it is
+	// set all further writes are skipped.
+	Err error
+
+	// Progs is the list of program headers that will be written by
+	// WriteProgramHeaders.
+	Progs []*elf.ProgHeader
+}
+
+// Note is a note from the PT_NOTE prog.
+type Note struct {
+	Type elf.NType
+	Name string
+	Data []byte
+}
+
+// New creates a new Writer that writes to w, the ELF file header is written
+// immediately and updated by WriteProgramHeaders.
+func New(w WriteCloserSeeker, fhdr *elf.FileHeader) *Writer {
+	r := &Writer{w: w, fhdr: fhdr}
+	r.writeFileHeader(0, 0)
+	return r
+}
+
+// Here returns the current offset of the writer.
+func (w *Writer) Here() uint64 {
+	return uint64(w.off)
+}
+
+// Write writes p at the current offset, it implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.Err != nil {
+		return 0, w.Err
+	}
+	n, err := w.w.Write(p)
+	w.off += int64(n)
+	if err != nil {
+		w.Err = err
+	}
+	return n, err
+}
+
+// Align writes zeroes until the current offset is a multiple of align.
+func (w *Writer) Align(align int64) {
+	if rem := w.off % align; rem != 0 {
+		w.Write(make([]byte, align-rem))
+	}
+}
+
+func (w *Writer) writeFileHeader(phoff uint64, phnum int) {
+	hdr := elf.Header64{
+		Type:      uint16(w.fhdr.Type),
+		Machine:   uint16(w.fhdr.Machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Entry:     w.fhdr.Entry,
+		Phoff:     phoff,
+		Ehsize:    uint16(binary.Size(elf.Header64{})),
+		Phentsize: uint16(binary.Size(elf.Prog64{})),
+		Phnum:     uint16(phnum),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	hdr.Ident[elf.EI_OSABI] = byte(w.fhdr.OSABI)
+	w.writeStruct(&hdr)
+}
+
+func (w *Writer) writeStruct(v interface{}) {
+	if w.Err != nil {
+		return
+	}
+	sz := binary.Size(v)
+	if err := binary.Write(w.w, binary.LittleEndian, v); err != nil {
+		w.Err = err
+		return
+	}
+	w.off += int64(sz)
+}
+
+// WriteNotes writes notes to the current offset and returns a program
+// header describing them, which is also appended to w.Progs.
+func (w *Writer) WriteNotes(notes []Note) *elf.ProgHeader {
+	w.Align(4)
+	h := &elf.ProgHeader{
+		Type:  elf.PT_NOTE,
+		Off:   w.Here(),
+		Align: 4,
+	}
+	for i := range notes {
+		note := &notes[i]
+		name := append([]byte(note.Name), 0)
+		w.writeStruct(&struct {
+			Namesz, Descsz, Type uint32
+		}{uint32(len(name)), uint32(len(note.Data)), uint32(note.Type)})
+		w.Write(name)
+		w.Align(4)
+		w.Write(note.Data)
+		w.Align(4)
+	}
+	h.Filesz = w.Here() - h.Off
+	w.Progs = append(w.Progs, h)
+	return h
+}
+
+// WriteProgramHeaders writes the program header table, containing w.Progs,
+// at the current offset and updates the file header to point to it.
+func (w *Writer) WriteProgramHeaders() {
+	w.Align(8)
+	phoff := w.Here()
+	for _, prog := range w.Progs {
+		w.writeStruct(&elf.Prog64{
+			Type:   uint32(prog.Type),
+			Flags:  uint32(prog.Flags),
+			Off:    prog.Off,
+			Vaddr:  prog.Vaddr,
+			Paddr:  prog.Paddr,
+			Filesz: prog.Filesz,
+			Memsz:  prog.Memsz,
+			Align:  prog.Align,
+		})
+	}
+	if w.Err != nil {
+		return
+	}
+	end := w.off
+	if _, err := w.w.Seek(0, io.SeekStart); err != nil {
+		w.Err = err
+		return
+	}
+	w.off = 0
+	w.writeFileHeader(phoff, len(w.Progs))
+	if w.Err != nil {
+		return
+	}
+	if _, err := w.w.Seek(end, io.SeekStart); err != nil {
+		w.Err = err
+		return
+	}
+	w.off = end
+}
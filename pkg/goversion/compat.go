@@ -16,6 +16,12 @@ var (
 // Compatible checks that the version specified in the producer string is compatible with
 // this version of delve.
 func Compatible(producer string) error {
+	if IsTinyGo(producer) {
+		// TinyGo binaries don't record which version of the Go standard
+		// library they were built against, they are only supported in degraded
+		// form regardless.
+		return nil
+	}
 	ver := parseProducer(producer)
 	if ver.IsDevel() {
 		return nil
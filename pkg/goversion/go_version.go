@@ -169,7 +169,10 @@ func VersionAfterOrEqualRev(version string, major, minor, rev int) bool {
 	return ver.AfterOrEqual(GoVersion{major, minor, rev, 0, 0, ""})
 }
 
-const producerVersionPrefix = "Go cmd/compile "
+const (
+	producerVersionPrefix = "Go cmd/compile "
+	tinyGoProducerPrefix  = "TinyGo"
+)
 
 // ProducerAfterOrEqual checks that the DW_AT_producer version is
 // major.minor or a later version, or a development version.
@@ -181,6 +184,12 @@ func ProducerAfterOrEqual(producer string, major, minor int) bool {
 	return ver.AfterOrEqual(GoVersion{major, minor, -1, 0, 0, ""})
 }
 
+// IsTinyGo returns true if the DW_AT_producer string was emitted by the
+// TinyGo compiler.
+func IsTinyGo(producer string) bool {
+	return strings.HasPrefix(producer, tinyGoProducerPrefix)
+}
+
 func parseProducer(producer string) GoVersion {
 	if strings.HasPrefix(producer, producerVersionPrefix) {
 		producer = producer[len(producerVersionPrefix):]
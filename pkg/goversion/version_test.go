@@ -50,3 +50,15 @@ func TestInstalled(t *testing.T) {
 		t.Fatalf("version mismatch %#v %#v", installedVersion, runtimeVersion)
 	}
 }
+
+func TestTinyGoProducer(t *testing.T) {
+	if !IsTinyGo("TinyGo") {
+		t.Errorf("TinyGo producer not recognized")
+	}
+	if IsTinyGo("Go cmd/compile go1.16") {
+		t.Errorf("gc producer recognized as TinyGo")
+	}
+	if err := Compatible("TinyGo"); err != nil {
+		t.Errorf("TinyGo producer not compatible: %v", err)
+	}
+}
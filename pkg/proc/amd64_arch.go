@@ -37,6 +37,7 @@ func AMD64Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        amd64RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: amd64AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            amd64DwarfRegisterToString,
+		isVectorRegister:                 amd64IsVectorRegister,
 		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
 		asmDecode:                        amd64AsmDecode,
 	}
@@ -429,6 +430,10 @@ func amd64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floa
 	}
 }
 
+func amd64IsVectorRegister(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "xmm")
+}
+
 func formatSSEReg(name string, reg []byte) string {
 	out := new(bytes.Buffer)
 	formatSSERegInternal(reg, out)
@@ -38,6 +38,7 @@ func ARM64Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        arm64RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: arm64AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            arm64DwarfRegisterToString,
+		isVectorRegister:                 arm64IsVectorRegister,
 		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
 		asmDecode:                        arm64AsmDecode,
 		usesLR:                           true,
@@ -369,6 +370,10 @@ func arm64AddrAndStackRegsToDwarfRegisters(staticBase, pc, sp, bp, lr uint64) op
 	return *op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, arm64DwarfIPRegNum, arm64DwarfSPRegNum, arm64DwarfBPRegNum, arm64DwarfLRRegNum)
 }
 
+func arm64IsVectorRegister(name string) bool {
+	return len(name) > 1 && name[0] == 'V'
+}
+
 func arm64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floatingPoint bool, repr string) {
 	// see arm64DwarfToHardware table for explanation
 	switch {
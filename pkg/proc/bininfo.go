@@ -51,6 +51,13 @@ type BinaryInfo struct {
 
 	debugInfoDirectories []string
 
+	// SysRoot, if not empty, is the directory where the root of the
+	// filesystem of the target process can be found. It is set for processes
+	// running in a different mount namespace, for example inside a
+	// container, and used to open their shared libraries and separate debug
+	// info files.
+	SysRoot string
+
 	// Functions is a list of all DW_TAG_subprogram entries in debug_info, sorted by entry point
 	Functions []Function
 	// Sources is a list of all source files found in debug_line.
@@ -61,6 +68,11 @@ type BinaryInfo struct {
 	// SymNames maps addr to a description *elf.Symbol of this addr.
 	SymNames map[uint64]*elf.Symbol
 
+	// funcSyms is a list of function symbols read from the symbol tables of
+	// all images, sorted by address. It is used to describe functions that
+	// don't have debug info (for example functions in C shared libraries).
+	funcSyms []funcSym
+
 	// Images is a list of loaded shared libraries (also known as
 	// shared objects on linux or DLLs on windows).
 	Images []*Image
@@ -121,6 +133,7 @@ var (
 		elf.EM_X86_64:  true,
 		elf.EM_AARCH64: true,
 		elf.EM_386:     true,
+		emLoongArch:    true,
 	}
 
 	supportedWindowsArch = map[PEMachine]bool{
@@ -436,6 +449,8 @@ func NewBinaryInfo(goos, goarch string) *BinaryInfo {
 		r.Arch = AMD64Arch(goos)
 	case "arm64":
 		r.Arch = ARM64Arch(goos)
+	case "loong64":
+		r.Arch = LOONG64Arch(goos)
 	}
 	return r
 }
@@ -457,7 +472,7 @@ func loadBinaryInfo(bi *BinaryInfo, image *Image, path string, entryPoint uint64
 	defer wg.Wait()
 
 	switch bi.GOOS {
-	case "linux", "freebsd":
+	case "linux", "freebsd", "openbsd", "netbsd":
 		return loadBinaryInfoElf(bi, image, path, entryPoint, &wg)
 	case "windows":
 		return loadBinaryInfoPE(bi, image, path, entryPoint, &wg)
@@ -641,7 +656,9 @@ func (bi *BinaryInfo) PCToImage(pc uint64) *Image {
 type Image struct {
 	Path       string
 	StaticBase uint64
-	addr       uint64
+	// BuildID is the build ID of the image, if it has one.
+	BuildID string
+	addr    uint64
 
 	index int // index of this object in BinaryInfo.SharedObjects
 
@@ -700,6 +717,11 @@ func (bi *BinaryInfo) AddImage(path string, addr uint64) error {
 	// add Image regardless of error so that we don't attempt to re-add it every time we stop
 	image.index = len(bi.Images)
 	bi.Images = append(bi.Images, image)
+	if image.index > 0 && bi.SysRoot != "" {
+		// The paths of shared libraries are relative to the filesystem of the
+		// target process.
+		path = filepath.Join(bi.SysRoot, path)
+	}
 	err := loadBinaryInfo(bi, image, path, addr)
 	if err != nil {
 		bi.Images[len(bi.Images)-1].loadErr = err
@@ -1029,6 +1051,15 @@ func (e *ErrNoBuildIDNote) Error() string {
 // Alternatively, if the debug file cannot be found be the build-id, Delve
 // will look in directories specified by the debug-info-directories config value.
 func (bi *BinaryInfo) openSeparateDebugInfo(image *Image, exe *elf.File, debugInfoDirectories []string) (*os.File, *elf.File, error) {
+	if bi.SysRoot != "" {
+		// Look for separate debug info files inside the filesystem of the
+		// target process first.
+		dirs := make([]string, 0, 2*len(debugInfoDirectories))
+		for _, dir := range debugInfoDirectories {
+			dirs = append(dirs, filepath.Join(bi.SysRoot, dir))
+		}
+		debugInfoDirectories = append(dirs, debugInfoDirectories...)
+	}
 	var debugFilePath string
 	for _, dir := range debugInfoDirectories {
 		var potentialDebugFilePath string
@@ -1103,6 +1134,31 @@ func parseBuildID(exe *elf.File) (string, string, error) {
 	return desc[:2], desc[2:], nil
 }
 
+// elfBuildID returns the build ID of exe, read from its GNU build ID note
+// or, if it doesn't have one, from the Go build ID note.
+func elfBuildID(exe *elf.File) string {
+	if desc1, desc2, err := parseBuildID(exe); err == nil {
+		return desc1 + desc2
+	}
+	sec := exe.Section(".note.go.buildid")
+	if sec == nil {
+		return ""
+	}
+	data, err := sec.Data()
+	if err != nil || len(data) < 12 {
+		return ""
+	}
+	namesz := uint64(exe.ByteOrder.Uint32(data[0:]))
+	descsz := uint64(exe.ByteOrder.Uint32(data[4:]))
+	// The description follows the 12 bytes header and the name, padded to 4
+	// bytes.
+	off := 12 + (namesz+3)&^3
+	if off+descsz > uint64(len(data)) {
+		return ""
+	}
+	return string(data[off : off+descsz])
+}
+
 // loadBinaryInfoElf specifically loads information from an ELF binary.
 func loadBinaryInfoElf(bi *BinaryInfo, image *Image, path string, addr uint64, wg *sync.WaitGroup) error {
 	exe, err := os.OpenFile(path, 0, os.ModePerm)
@@ -1117,6 +1173,7 @@ func loadBinaryInfoElf(bi *BinaryInfo, image *Image, path string, addr uint64, w
 	if !supportedLinuxArch[elfFile.Machine] {
 		return &ErrUnsupportedArch{os: "linux", cpuArch: elfFile.Machine}
 	}
+	image.BuildID = elfBuildID(elfFile)
 
 	if image.index == 0 {
 		// adding executable file:
@@ -1146,6 +1203,14 @@ func loadBinaryInfoElf(bi *BinaryInfo, image *Image, path string, addr uint64, w
 		var serr error
 		sepFile, dwarfFile, serr = bi.openSeparateDebugInfo(image, elfFile, bi.debugInfoDirectories)
 		if serr != nil {
+			if image.index > 0 {
+				// Shared libraries written in C are frequently stripped of their
+				// debug info, we can still use their .eh_frame section and symbol
+				// table to unwind through them and describe their functions.
+				wg.Add(2)
+				go bi.parseDebugFrameElf(image, nil, elfFile, nil, wg)
+				go bi.loadSymbolName(image, elfFile, wg)
+			}
 			return serr
 		}
 		image.sepDebugCloser = sepFile
@@ -1174,7 +1239,7 @@ func loadBinaryInfoElf(bi *BinaryInfo, image *Image, path string, addr uint64, w
 	image.debugAddr = godwarf.ParseAddr(debugAddrBytes)
 
 	wg.Add(3)
-	go bi.parseDebugFrameElf(image, dwarfFile, debugInfoBytes, wg)
+	go bi.parseDebugFrameElf(image, dwarfFile, elfFile, debugInfoBytes, wg)
 	go bi.loadDebugInfoMaps(image, debugInfoBytes, debugLineBytes, wg, nil)
 	go bi.loadSymbolName(image, elfFile, wg)
 	if image.index == 0 {
@@ -1194,26 +1259,93 @@ func (bi *BinaryInfo) loadSymbolName(image *Image, file *elf.File, wg *sync.Wait
 		bi.SymNames = make(map[uint64]*elf.Symbol)
 	}
 	symSecs, _ := file.Symbols()
+	if len(symSecs) == 0 {
+		// Stripped shared libraries still have their dynamic symbol table.
+		symSecs, _ = file.DynamicSymbols()
+	}
 	if symSecs != nil {
 		for _, symSec := range symSecs {
-			if symSec.Info == STT_FUNC { // TODO(chainhelen), need to parse others types.
+			if symSec.Info&0xf == STT_FUNC { // TODO(chainhelen), need to parse others types.
 				s := symSec
-				bi.SymNames[symSec.Value+image.StaticBase] = &s
+				if symSec.Info == STT_FUNC {
+					bi.SymNames[symSec.Value+image.StaticBase] = &s
+				}
+				if symSec.Value != 0 {
+					bi.funcSyms = append(bi.funcSyms, funcSym{name: symSec.Name, addr: symSec.Value + image.StaticBase, size: symSec.Size})
+				}
 			}
 		}
 	}
+	sort.Slice(bi.funcSyms, func(i, j int) bool { return bi.funcSyms[i].addr < bi.funcSyms[j].addr })
 }
 
-func (bi *BinaryInfo) parseDebugFrameElf(image *Image, exe *elf.File, debugInfoBytes []byte, wg *sync.WaitGroup) {
+// funcSym describes a function symbol read from the symbol table of an
+// image.
+type funcSym struct {
+	name       string
+	addr, size uint64
+}
+
+// PCToSymbol returns the name and address of the function symbol
+// containing pc, it is used to describe functions without debug info (for
+// example the functions of C libraries). Returns an empty string if no
+// symbol could be found.
+func (bi *BinaryInfo) PCToSymbol(pc uint64) (string, uint64) {
+	i := sort.Search(len(bi.funcSyms), func(i int) bool {
+		return bi.funcSyms[i].addr > pc
+	})
+	if i == 0 {
+		return "", 0
+	}
+	sym := &bi.funcSyms[i-1]
+	if pc >= sym.addr+sym.size && !(sym.size == 0 && pc == sym.addr) {
+		return "", 0
+	}
+	return sym.name, sym.addr
+}
+
+// parseDebugFrameElf loads the frame descriptor entries of image. Both
+// .debug_frame (from dwarfFile) and .eh_frame (from exeFile) are used,
+// .eh_frame is the only source of unwind information for C libraries
+// compiled without debug info.
+// The dwarfFile argument can be nil.
+func (bi *BinaryInfo) parseDebugFrameElf(image *Image, dwarfFile, exeFile *elf.File, debugInfoBytes []byte, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	debugFrameData, err := godwarf.GetDebugSectionElf(exe, "frame")
-	if err != nil {
-		image.setLoadError("could not get .debug_frame section: %v", err)
+	var debugFrameData []byte
+	var debugFrameErr error
+	if dwarfFile != nil {
+		debugFrameData, debugFrameErr = godwarf.GetDebugSectionElf(dwarfFile, "frame")
+	} else {
+		debugFrameErr = ErrNoDebugInfoFound
+	}
+
+	var ehFrameData []byte
+	var ehFrameAddr uint64
+	if sec := exeFile.Section(".eh_frame"); sec != nil && sec.Type != elf.SHT_NOBITS {
+		ehFrameAddr = sec.Addr
+		ehFrameData, _ = sec.Data()
+	}
+
+	if debugFrameData == nil && ehFrameData == nil {
+		image.setLoadError("could not get .debug_frame section: %v", debugFrameErr)
 		return
 	}
 
-	bi.frameEntries = bi.frameEntries.Append(frame.Parse(debugFrameData, frame.DwarfEndian(debugInfoBytes), image.StaticBase, bi.Arch.PtrSize()))
+	if debugFrameData != nil {
+		bi.frameEntries = bi.frameEntries.Append(frame.Parse(debugFrameData, frame.DwarfEndian(debugInfoBytes), image.StaticBase, bi.Arch.PtrSize()))
+	}
+
+	if ehFrameData != nil && ehFrameAddr != 0 {
+		fdes, err := frame.ParseEHFrame(ehFrameData, exeFile.ByteOrder, image.StaticBase, bi.Arch.PtrSize(), ehFrameAddr)
+		if err != nil {
+			if debugFrameData == nil {
+				image.setLoadError("could not parse .eh_frame section: %v", err)
+			}
+			return
+		}
+		bi.frameEntries = bi.frameEntries.Append(fdes)
+	}
 }
 
 func (bi *BinaryInfo) setGStructOffsetElf(image *Image, exe *elf.File, wg *sync.WaitGroup) {
@@ -1613,7 +1745,9 @@ func (bi *BinaryInfo) loadDebugInfoMaps(image *Image, debugInfoBytes, debugLineB
 			if cu.isgo && cu.producer != "" {
 				semicolon := strings.Index(cu.producer, ";")
 				if semicolon < 0 {
-					cu.optimized = goversion.ProducerAfterOrEqual(cu.producer, 1, 10)
+					// TinyGo doesn't report its optimization flags, it optimizes by
+					// default.
+					cu.optimized = goversion.ProducerAfterOrEqual(cu.producer, 1, 10) || goversion.IsTinyGo(cu.producer)
 				} else {
 					cu.optimized = !strings.Contains(cu.producer[semicolon:], "-N") || !strings.Contains(cu.producer[semicolon:], "-l")
 					cu.producer = cu.producer[:semicolon]
@@ -0,0 +1,156 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/go-delve/delve/pkg/proc/intelpt"
+)
+
+// Branch is a change in the flow of execution of a thread, from the
+// instruction at From to the instruction at To.
+type Branch struct {
+	From, To Location
+}
+
+// ErrBranchTraceNotSupported is returned by the EnableBranchTrace method of
+// backends, or CPUs, that can not record the branches executed by the
+// target.
+var ErrBranchTraceNotSupported = errors.New("branch tracing is not supported by this backend")
+
+// EnableBranchTrace starts recording, or stops if enable is false, the
+// branches executed by all threads of the target, using Intel Processor
+// Trace. The branches are then returned by BranchHistory.
+func (t *Target) EnableBranchTrace(enable bool) error {
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	if t.BinInfo().Arch.Name != "amd64" {
+		return ErrBranchTraceNotSupported
+	}
+	return t.proc.EnableBranchTrace(enable)
+}
+
+// BranchHistory returns the last max branches executed by thread, oldest
+// first, up to the instruction where it is currently stopped.
+// Branch tracing must have been enabled with EnableBranchTrace.
+func (t *Target) BranchHistory(thread Thread, max int) ([]Branch, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	trace, err := t.proc.BranchTrace(thread.ThreadID())
+	if err != nil {
+		return nil, err
+	}
+	regs, err := thread.Registers()
+	if err != nil {
+		return nil, err
+	}
+	branches, err := intelpt.Decode(trace, t.branchTraceDecoder(), regs.PC(), max)
+	if err != nil {
+		return nil, err
+	}
+	bi := t.BinInfo()
+	loc := func(pc uint64) Location {
+		file, line, fn := bi.PCToLine(pc)
+		return Location{PC: pc, File: file, Line: line, Fn: fn}
+	}
+	r := make([]Branch, len(branches))
+	for i := range branches {
+		r[i] = Branch{From: loc(branches[i].From), To: loc(branches[i].To)}
+	}
+	return r, nil
+}
+
+// branchTraceDecoder returns a function that decodes the instructions of
+// the target for intelpt.Decode. Memory is read one page at a time and
+// the original instructions replaced by breakpoints are restored.
+func (t *Target) branchTraceDecoder() intelpt.DecodeFunc {
+	mem := t.Memory()
+	breakpoints := t.Breakpoints()
+	pageSize := uint64(os.Getpagesize())
+	maxLen := t.BinInfo().Arch.MaxInstructionLength()
+	pages := make(map[uint64][]byte)
+	cache := make(map[uint64]intelpt.Instruction)
+
+	readPage := func(addr uint64) ([]byte, error) {
+		if page, ok := pages[addr]; ok {
+			return page, nil
+		}
+		page := make([]byte, pageSize)
+		if _, err := mem.ReadMemory(page, addr); err != nil {
+			return nil, err
+		}
+		for _, bp := range breakpoints.M {
+			if bp.Addr >= addr && bp.Addr < addr+pageSize {
+				copy(page[bp.Addr-addr:], bp.OriginalData)
+			}
+		}
+		pages[addr] = page
+		return page, nil
+	}
+
+	return func(pc uint64) (intelpt.Instruction, error) {
+		if inst, ok := cache[pc]; ok {
+			return inst, nil
+		}
+		base := pc &^ (pageSize - 1)
+		page, err := readPage(base)
+		if err != nil {
+			return intelpt.Instruction{}, err
+		}
+		buf := page[pc-base:]
+		if len(buf) < maxLen {
+			// The instruction could continue in the next page.
+			if next, err := readPage(base + pageSize); err == nil {
+				buf = append(buf[:len(buf):len(buf)], next[:maxLen-len(buf)]...)
+			}
+		}
+		x86inst, err := x86asm.Decode(buf, 64)
+		if err != nil {
+			return intelpt.Instruction{}, fmt.Errorf("could not decode instruction at %#x: %v", pc, err)
+		}
+		patchPCRelX86(pc, &x86inst)
+		inst := x86BranchKind(&x86inst)
+		cache[pc] = inst
+		return inst, nil
+	}
+}
+
+// x86BranchKind classifies inst according to how it is traced by Intel
+// Processor Trace. Returns are traced as indirect jumps because tracing
+// is started with return compression disabled.
+func x86BranchKind(inst *x86asm.Inst) intelpt.Instruction {
+	r := intelpt.Instruction{Kind: intelpt.InstOther, Len: inst.Len}
+	target := func() bool {
+		if imm, ok := inst.Args[0].(x86asm.Imm); ok {
+			r.Target = uint64(imm)
+			return true
+		}
+		return false
+	}
+	switch inst.Op {
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS, x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		if target() {
+			r.Kind = intelpt.InstCondJump
+		}
+	case x86asm.JMP:
+		r.Kind = intelpt.InstIndirect
+		if target() {
+			r.Kind = intelpt.InstJump
+		}
+	case x86asm.CALL:
+		r.Kind = intelpt.InstIndirect
+		if target() {
+			r.Kind = intelpt.InstCall
+		}
+	case x86asm.RET, x86asm.LRET, x86asm.IRET, x86asm.IRETD, x86asm.IRETQ:
+		r.Kind = intelpt.InstIndirect
+	case x86asm.LJMP, x86asm.LCALL, x86asm.SYSCALL, x86asm.SYSENTER, x86asm.SYSEXIT, x86asm.SYSRET, x86asm.INT, x86asm.INTO:
+		r.Kind = intelpt.InstFar
+	}
+	return r
+}
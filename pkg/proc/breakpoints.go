@@ -91,6 +91,9 @@ const (
 	// Continue will set a new breakpoint (of NextBreakpoint kind) on the
 	// destination of CALL, delete this breakpoint and then continue again
 	StepBreakpoint
+	// RawCallBreakpoint is a breakpoint set by RawCall and RawSyscall on the
+	// return address of the call, Continue will stop on it and delete it.
+	RawCallBreakpoint
 )
 
 func (bp *Breakpoint) String() string {
@@ -263,9 +266,20 @@ func (t *Target) SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr)
 		return bp, nil
 	}
 
-	f, l, fn, originalData, err := t.proc.WriteBreakpoint(addr)
-	if err != nil {
-		return nil, err
+	var (
+		f            string
+		l            int
+		fn           *Function
+		originalData []byte
+		err          error
+	)
+	if t.batchingBreakpoints {
+		f, l, fn = t.BinInfo().PCToLine(addr)
+	} else {
+		f, l, fn, originalData, err = t.proc.WriteBreakpoint(addr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	fnName := ""
@@ -294,10 +308,83 @@ func (t *Target) SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr)
 	}
 
 	bpmap.M[addr] = newBreakpoint
+	if t.batchingBreakpoints {
+		t.pendingBreakpoints = append(t.pendingBreakpoints, newBreakpoint)
+	}
 
 	return newBreakpoint, nil
 }
 
+// BreakpointWriteRequest is one of the breakpoints written by a batch
+// write, OriginalData and Err are set to the result of writing a
+// breakpoint at Addr.
+type BreakpointWriteRequest struct {
+	Addr         uint64
+	OriginalData []byte
+	Err          error
+}
+
+// BreakpointBatchWriter is implemented by backends that can write many
+// breakpoints with a single operation.
+type BreakpointBatchWriter interface {
+	// WriteBreakpoints writes a breakpoint at the address of each request.
+	WriteBreakpoints(reqs []BreakpointWriteRequest)
+}
+
+// BatchBreakpoints calls fn, the breakpoints created by fn are not written
+// to memory until fn returns, they are then written all together, with a
+// single operation if the backend supports it. This is faster, and
+// disturbs the target less, than writing them one at a time when many
+// breakpoints are set, for example when they are restored after a restart.
+// Breakpoints that can not be written are removed, the first error is
+// returned if fn did not return one.
+func (t *Target) BatchBreakpoints(fn func() error) error {
+	if t.batchingBreakpoints {
+		return fn()
+	}
+	t.batchingBreakpoints = true
+	err := fn()
+	t.batchingBreakpoints = false
+	pending := t.pendingBreakpoints
+	t.pendingBreakpoints = nil
+	if err2 := t.writeBreakpoints(pending); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// writeBreakpoints writes the breakpoints in bps to memory and sets their
+// OriginalData.
+func (t *Target) writeBreakpoints(bps []*Breakpoint) error {
+	if len(bps) == 0 {
+		return nil
+	}
+	reqs := make([]BreakpointWriteRequest, len(bps))
+	for i := range bps {
+		reqs[i].Addr = bps[i].Addr
+	}
+	if bw, ok := t.proc.(BreakpointBatchWriter); ok {
+		bw.WriteBreakpoints(reqs)
+	} else {
+		for i := range reqs {
+			_, _, _, reqs[i].OriginalData, reqs[i].Err = t.proc.WriteBreakpoint(reqs[i].Addr)
+		}
+	}
+	var err error
+	bpmap := t.Breakpoints()
+	for i := range reqs {
+		if reqs[i].Err != nil {
+			if err == nil {
+				err = fmt.Errorf("could not write breakpoint at %#x: %v", reqs[i].Addr, reqs[i].Err)
+			}
+			delete(bpmap.M, reqs[i].Addr)
+			continue
+		}
+		bps[i].OriginalData = reqs[i].OriginalData
+	}
+	return err
+}
+
 // setBreakpointWithID creates a breakpoint at addr, with the specified logical ID.
 func (t *Target) setBreakpointWithID(id int, addr uint64) (*Breakpoint, error) {
 	bpmap := t.Breakpoints()
@@ -326,8 +413,10 @@ func (t *Target) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
 		return bp, nil
 	}
 
-	if err := t.proc.EraseBreakpoint(bp); err != nil {
-		return nil, err
+	if !t.removePendingBreakpoint(bp) {
+		if err := t.proc.EraseBreakpoint(bp); err != nil {
+			return nil, err
+		}
 	}
 
 	delete(bpmap.M, addr)
@@ -335,6 +424,19 @@ func (t *Target) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
 	return bp, nil
 }
 
+// removePendingBreakpoint removes bp from the breakpoints that will be
+// written at the end of BatchBreakpoints, returns false if bp was already
+// written to memory.
+func (t *Target) removePendingBreakpoint(bp *Breakpoint) bool {
+	for i := range t.pendingBreakpoints {
+		if t.pendingBreakpoints[i] == bp {
+			t.pendingBreakpoints = append(t.pendingBreakpoints[:i], t.pendingBreakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // ClearInternalBreakpoints removes all internal breakpoints from the map,
 // calling clearBreakpoint on each one.
 func (t *Target) ClearInternalBreakpoints() error {
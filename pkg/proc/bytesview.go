@@ -0,0 +1,117 @@
+package proc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// BytesView selects how slices and arrays of bytes are loaded, see
+// LoadConfig.BytesView.
+type BytesView uint8
+
+const (
+	// BytesViewHex requests a hexdump of the contents of slices and arrays
+	// of bytes.
+	BytesViewHex BytesView = 1 << iota
+
+	// BytesViewString requests the contents of slices and arrays of bytes
+	// as a string.
+	BytesViewString
+)
+
+// BytesInfo contains alternative representations of the contents of a
+// slice or array of bytes.
+type BytesInfo struct {
+	// Hex is a hexdump of the contents, in the same format as 'hexdump -C'.
+	Hex string
+	// String is the contents as a string, with invalid UTF-8 sequences and
+	// non printable characters escaped.
+	String string
+	// Encoding is the encoding detected for the contents, one of "ascii",
+	// "utf-8" or "binary".
+	Encoding string
+	// Count is the number of bytes read, it can be less than the length of
+	// the slice or array.
+	Count int64
+}
+
+// isByteType returns true if typ is byte (or uint8).
+func isByteType(typ godwarf.Type) bool {
+	t, ok := resolveTypedef(typ).(*godwarf.UintType)
+	return ok && t.ByteSize == 1
+}
+
+// loadBytes reads the first count bytes of the slice or array of bytes v
+// and sets v.Bytes.
+func (v *Variable) loadBytes(count int64, view BytesView) {
+	mem := v.mem
+	if v.Kind != reflect.Array {
+		mem = DereferenceMemory(mem)
+	}
+	buf := make([]byte, count)
+	if count > 0 {
+		if _, err := mem.ReadMemory(buf, v.Base); err != nil {
+			v.Unreadable = err
+			return
+		}
+	}
+	v.Bytes = &BytesInfo{Encoding: bytesEncoding(buf), Count: count}
+	if view&BytesViewHex != 0 {
+		v.Bytes.Hex = hex.Dump(buf)
+	}
+	if view&BytesViewString != 0 {
+		v.Bytes.String = bytesAsString(buf)
+	}
+}
+
+// bytesEncoding returns "ascii" if buf only contains printable ASCII
+// characters and white space, "utf-8" if it is valid UTF-8 and only
+// contains printable characters and white space, "binary" otherwise.
+func bytesEncoding(buf []byte) string {
+	ascii := true
+	for len(buf) > 0 {
+		r, sz := utf8.DecodeRune(buf)
+		buf = buf[sz:]
+		if r == utf8.RuneError && sz == 1 {
+			return "binary"
+		}
+		if !strconv.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return "binary"
+		}
+		if r >= utf8.RuneSelf {
+			ascii = false
+		}
+	}
+	if ascii {
+		return "ascii"
+	}
+	return "utf-8"
+}
+
+// bytesAsString converts buf to a string, escaping non printable
+// characters and invalid UTF-8 sequences (as \xNN).
+func bytesAsString(buf []byte) string {
+	var out strings.Builder
+	for len(buf) > 0 {
+		r, sz := utf8.DecodeRune(buf)
+		switch {
+		case r == utf8.RuneError && sz == 1:
+			fmt.Fprintf(&out, `\x%02x`, buf[0])
+		case r == '\\':
+			out.WriteString(`\\`)
+		case strconv.IsPrint(r):
+			out.WriteRune(r)
+		default:
+			q := strconv.QuoteRune(r)
+			out.WriteString(q[1 : len(q)-1])
+		}
+		buf = buf[sz:]
+	}
+	return out.String()
+}
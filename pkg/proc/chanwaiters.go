@@ -0,0 +1,222 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// ChanWaiters lists the goroutines blocked on a channel.
+type ChanWaiters struct {
+	// Recv lists the goroutines waiting to receive from the channel.
+	Recv []*G
+	// Send lists the goroutines waiting to send to the channel.
+	Send []*G
+}
+
+// ChannelWaiters returns the goroutines blocked sending to, or receiving
+// from, the channel ch, by decoding the sendq and recvq wait queues of its
+// runtime.hchan struct.
+// Goroutines blocked in a select statement are listed for every channel
+// they are waiting on.
+func ChannelWaiters(ch *Variable) (*ChanWaiters, error) {
+	if ch.Unreadable != nil {
+		return nil, ch.Unreadable
+	}
+	if ch.Kind != reflect.Chan {
+		return nil, fmt.Errorf("%s (type %s) is not a channel", ch.Name, ch.TypeString())
+	}
+	hchanv := ch.clone()
+	hchanv.RealType = resolveTypedef(&(hchanv.RealType.(*godwarf.ChanType).TypedefType))
+	hchanv = hchanv.maybeDereference()
+	if hchanv.Unreadable != nil {
+		return nil, hchanv.Unreadable
+	}
+	r := &ChanWaiters{}
+	if hchanv.Addr == 0 {
+		// nil channel
+		return r, nil
+	}
+	var err error
+	r.Recv, err = waitqGoroutines(hchanv, "recvq")
+	if err != nil {
+		return nil, err
+	}
+	r.Send, err = waitqGoroutines(hchanv, "sendq")
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// waitqGoroutines returns the goroutines of the sudog list contained in the
+// runtime.waitq field called name of hchanv.
+func waitqGoroutines(hchanv *Variable, name string) ([]*G, error) {
+	var gs []*G
+	err := walkWaitq(hchanv, name, func(gv *Variable) error {
+		g, err := gv.parseG()
+		if err != nil {
+			return err
+		}
+		gs = append(gs, g)
+		return nil
+	})
+	return gs, err
+}
+
+// walkWaitq calls fn on the g field of every sudog of the list contained
+// in the runtime.waitq field called name of hchanv.
+func walkWaitq(hchanv *Variable, name string, fn func(gv *Variable) error) error {
+	waitqv, err := hchanv.structMember(name)
+	if err != nil {
+		return err
+	}
+	sudogv, err := waitqv.structMember("first")
+	if err != nil {
+		return err
+	}
+	seen := make(map[uint64]bool)
+	for {
+		sudogv = sudogv.maybeDereference()
+		if sudogv.Unreadable != nil {
+			return sudogv.Unreadable
+		}
+		if sudogv.Addr == 0 || seen[sudogv.Addr] {
+			return nil
+		}
+		seen[sudogv.Addr] = true
+		gv, err := sudogv.structMember("g")
+		if err != nil {
+			return err
+		}
+		if err := fn(gv); err != nil {
+			return err
+		}
+		sudogv, err = sudogv.structMember("next")
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// loadChanState loads the state of channel v, replacing the fields of its
+// runtime.hchan struct with:
+//
+//   - buffer: the elements in the buffer of the channel, in the order they
+//     will be received
+//   - closed: whether the channel is closed
+//   - recvq, sendq: the IDs of the goroutines waiting to receive from, and
+//     send to, the channel
+//
+// The Len and Cap fields of v are set to the number of elements in the
+// buffer and to its size respectively.
+func (v *Variable) loadChanState(recurseLevel int, cfg LoadConfig) {
+	chanType := v.RealType.(*godwarf.ChanType)
+	sv := v.clone()
+	sv.RealType = resolveTypedef(&(chanType.TypedefType))
+	sv = sv.maybeDereference()
+	if sv.Unreadable != nil {
+		v.Unreadable = sv.Unreadable
+		return
+	}
+	v.Base = sv.Addr
+	if sv.Addr == 0 {
+		// nil channel
+		return
+	}
+
+	field := func(name string) uint64 {
+		if v.Unreadable != nil {
+			return 0
+		}
+		val, err := sv.wellKnownField(name)
+		if err != nil {
+			v.Unreadable = err
+			return 0
+		}
+		n, _ := constant.Uint64Val(val)
+		return n
+	}
+	qcount, dataqsiz, recvx, closed := field("qcount"), field("dataqsiz"), field("recvx"), field("closed")
+	bufaddr := field("buf")
+	if v.Unreadable != nil {
+		return
+	}
+	v.Len, v.Cap = int64(qcount), int64(dataqsiz)
+	if qcount > dataqsiz {
+		v.Unreadable = fmt.Errorf("invalid channel length %d (size %d)", qcount, dataqsiz)
+		return
+	}
+
+	bufv := &Variable{Name: "buffer", Addr: bufaddr, DwarfType: fakeArrayType(qcount, chanType.ElemType), Kind: reflect.Array, Len: int64(qcount), Flags: VariableFakeAddress, mem: v.mem, bi: v.bi, loaded: true}
+	bufv.RealType = bufv.DwarfType
+	if recurseLevel <= cfg.MaxVariableRecurse {
+		stride := uint64(alignAddr(chanType.ElemType.Common().ByteSize, chanType.ElemType.Align()))
+		for i := uint64(0); i < qcount && i < uint64(cfg.MaxArrayValues); i++ {
+			ev := v.newVariable("", bufaddr+((recvx+i)%dataqsiz)*stride, chanType.ElemType, DereferenceMemory(v.mem))
+			ev.loadValueInternal(recurseLevel+1, cfg)
+			bufv.Children = append(bufv.Children, *ev)
+		}
+	}
+
+	closedv := newConstant(constant.MakeBool(closed != 0), v.mem)
+	closedv.Name = "closed"
+
+	v.Children = []Variable{*bufv, *closedv}
+	goidType, err := v.bi.findType("int64")
+	if err != nil {
+		return
+	}
+	for _, name := range []string{"recvq", "sendq"} {
+		waitqv, err := sv.structMember(name)
+		if err != nil {
+			v.Unreadable = err
+			return
+		}
+		var goids []Variable
+		err = walkWaitq(sv, name, func(gv *Variable) error {
+			goid, err := gv.maybeDereference().wellKnownField("goid")
+			if err != nil {
+				return err
+			}
+			if len(goids) < cfg.MaxArrayValues {
+				n, _ := constant.Int64Val(goid)
+				goidv := newConstant(constant.MakeInt64(n), v.mem)
+				goidv.DwarfType, goidv.RealType = goidType, goidType
+				goids = append(goids, *goidv)
+			}
+			return nil
+		})
+		if err != nil {
+			v.Unreadable = err
+			return
+		}
+		waitersv := &Variable{Name: name, Addr: waitqv.Addr, DwarfType: fakeArrayType(uint64(len(goids)), goidType), Kind: reflect.Array, Len: int64(len(goids)), Children: goids, Flags: VariableFakeAddress, mem: v.mem, bi: v.bi, loaded: true}
+		waitersv.RealType = waitersv.DwarfType
+		v.Children = append(v.Children, *waitersv)
+	}
+}
+
+// chanStateMember returns the child called name of channel v, as loaded by
+// loadChanState, so that expressions like 'ch.buffer' and 'ch.closed' can
+// be evaluated. Returns nil if name is not one of those children.
+func (v *Variable) chanStateMember(name string) (*Variable, error) {
+	switch name {
+	case "buffer", "closed", "recvq", "sendq":
+	default:
+		return nil, nil
+	}
+	cv := v.clone()
+	cv.loadChanState(0, loadFullValue)
+	if cv.Unreadable != nil {
+		return nil, cv.Unreadable
+	}
+	for i := range cv.Children {
+		if cv.Children[i].Name == name {
+			return &cv.Children[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s is a nil channel", v.Name)
+}
@@ -0,0 +1,151 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// maxContextChainLen is the maximum number of layers returned by
+// ContextChain.
+const maxContextChainLen = 100
+
+// ContextLayer describes one of the contexts wrapped by a context.Context.
+type ContextLayer struct {
+	// Type is the concrete type of this context, for example
+	// *context.valueCtx.
+	Type string
+	Addr uint64
+	// Key and Value are the key/value pair stored by a context created
+	// with context.WithValue.
+	Key, Value *Variable
+	// Deadline is the deadline of a context created with
+	// context.WithDeadline or context.WithTimeout, formatted as RFC3339.
+	Deadline string
+	// Canceled is true if the context was canceled, Err is the error
+	// returned by its Err method.
+	Canceled bool
+	Err      *Variable
+}
+
+// ContextChain walks the chain of contexts wrapped by v, which must be a
+// context.Context or a pointer to one of the context types of the standard
+// library, and describes each of them, starting from v and ending with the
+// root context (usually context.Background()).
+// Contexts of types that are not defined by the context package are only
+// followed if they embed a context.Context.
+func ContextChain(v *Variable, cfg LoadConfig) ([]ContextLayer, error) {
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	var r []ContextLayer
+	for len(r) < maxContextChainLen {
+		viaIface := v.Kind == reflect.Interface
+		if viaIface {
+			v.loadInterface(0, false, cfg)
+			if v.Unreadable != nil {
+				return r, v.Unreadable
+			}
+			if len(v.Children) == 0 || v.Children[0].Addr == 0 {
+				// nil context
+				return r, nil
+			}
+			v = &v.Children[0]
+		} else if len(r) == 0 && v.Kind != reflect.Ptr && v.Kind != reflect.Struct {
+			return nil, fmt.Errorf("%s (type %s) is not a context", v.Name, v.TypeString())
+		}
+
+		layer := ContextLayer{Type: v.TypeString(), Addr: v.Addr}
+		sv := v
+		for sv.Kind == reflect.Ptr {
+			sv = sv.maybeDereference()
+			if sv.Unreadable != nil {
+				return r, sv.Unreadable
+			}
+			layer.Addr = sv.Addr
+		}
+
+		next := "Context"
+		switch sv.RealType.Common().Name {
+		case "context.emptyCtx", "context.backgroundCtx", "context.todoCtx":
+			next = ""
+		case "context.valueCtx":
+			key, err := sv.structMember("key")
+			if err != nil {
+				return r, err
+			}
+			val, err := sv.structMember("val")
+			if err != nil {
+				return r, err
+			}
+			key.loadValue(cfg)
+			val.loadValue(cfg)
+			layer.Key, layer.Value = key, val
+		case "context.cancelCtx", "context.afterFuncCtx":
+			if err := readContextErr(sv, &layer, cfg); err != nil {
+				return r, err
+			}
+		case "context.timerCtx":
+			if err := readContextErr(sv, &layer, cfg); err != nil {
+				return r, err
+			}
+			deadline, err := sv.structMember("deadline")
+			if err != nil {
+				return r, err
+			}
+			layer.Deadline, err = deadline.wellKnownTime()
+			if err != nil {
+				return r, err
+			}
+		case "context.withoutCancelCtx":
+			next = "c"
+		default:
+			if sv.Kind != reflect.Struct {
+				next = ""
+			}
+		}
+		r = append(r, layer)
+		if next == "" {
+			return r, nil
+		}
+
+		parent, err := sv.structMember(next)
+		if err != nil {
+			if len(r) == 1 && !viaIface {
+				return nil, fmt.Errorf("%s (type %s) is not a context", v.Name, v.TypeString())
+			}
+			// a user defined context that doesn't embed context.Context
+			return r, nil
+		}
+		if parent.Kind != reflect.Interface {
+			return r, nil
+		}
+		v = parent
+	}
+	return r, errors.New("context chain too long")
+}
+
+// readContextErr reads the err field of a cancelCtx (or of a type
+// embedding it) into layer.
+func readContextErr(v *Variable, layer *ContextLayer, cfg LoadConfig) error {
+	errv, err := v.structMember("err")
+	if err != nil {
+		return err
+	}
+	if errv.Kind == reflect.Struct {
+		// Go 1.25 and later, err is an atomic.Value
+		errv, err = errv.structMember("v")
+		if err != nil {
+			return err
+		}
+	}
+	errv.loadValue(cfg)
+	if errv.Unreadable != nil {
+		return errv.Unreadable
+	}
+	if len(errv.Children) > 0 && errv.Children[0].Addr != 0 {
+		layer.Canceled = true
+		layer.Err = errv
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// bsdNoteNames are the prefixes of the names of the notes written in core
+// files by the BSDs. Their descriptors are not decoded by readNote.
+var bsdNoteNames = []string{openbsdNoteName, freebsdNoteName, netbsdNoteName}
+
+func isBSDNoteName(name string) bool {
+	for _, prefix := range bsdNoteNames {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBSDCore reads an ELF core file produced by one of the BSDs from
+// corePath, corresponding to the executable at exePath. The core file is
+// recognized by the presence of notes whose name starts with noteName,
+// otherwise ErrUnrecognizedFormat is returned. The threads of the process
+// are created by threadsFromNotes, which also sets the PID and entry point
+// of the process.
+func readBSDCore(corePath, exePath, goos, noteName string, threadsFromNotes func(p *process, notes []*note, machineType elf.Machine) (proc.Thread, error)) (*process, proc.Thread, error) {
+	coreFile, err := elf.Open(corePath)
+	if err != nil {
+		// let readLinuxCore report the error
+		return nil, nil, ErrUnrecognizedFormat
+	}
+	if coreFile.Type != elf.ET_CORE || (coreFile.Machine != elf.EM_X86_64 && coreFile.Machine != elf.EM_AARCH64) {
+		coreFile.Close()
+		return nil, nil, ErrUnrecognizedFormat
+	}
+	notes, err := readNotes(coreFile, coreFile.Machine)
+	if err != nil || !hasNoteNamed(notes, noteName) {
+		coreFile.Close()
+		return nil, nil, ErrUnrecognizedFormat
+	}
+
+	exe, err := os.Open(exePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	exeELF, err := elf.NewFile(exe)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exeELF.Type != elf.ET_EXEC && exeELF.Type != elf.ET_DYN {
+		return nil, nil, fmt.Errorf("%v is not an exe file", exeELF)
+	}
+	if exeELF.Machine != coreFile.Machine {
+		return nil, nil, fmt.Errorf("machine type of the executable (%v) does not match the core file (%v)", exeELF.Machine, coreFile.Machine)
+	}
+
+	var bi *proc.BinaryInfo
+	switch coreFile.Machine {
+	case elf.EM_X86_64:
+		bi = proc.NewBinaryInfo(goos, "amd64")
+	case elf.EM_AARCH64:
+		bi = proc.NewBinaryInfo(goos, "arm64")
+	}
+
+	p := &process{
+		mem:         buildMemory(coreFile, exeELF, exe, nil),
+		Threads:     map[int]*thread{},
+		breakpoints: proc.NewBreakpointMap(),
+		bi:          bi,
+	}
+
+	currentThread, err := threadsFromNotes(p, notes, coreFile.Machine)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, currentThread, nil
+}
+
+func hasNoteNamed(notes []*note, noteName string) bool {
+	for _, note := range notes {
+		if strings.HasPrefix(note.Name, noteName) {
+			return true
+		}
+	}
+	return false
+}
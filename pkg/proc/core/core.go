@@ -1,9 +1,12 @@
 package core
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 
 	"github.com/go-delve/delve/pkg/proc"
 )
@@ -146,6 +149,17 @@ func (r *offsetReaderAt) ReadMemory(buf []byte, addr uint64) (n int, err error)
 	return r.reader.ReadAt(buf, int64(addr-r.offset))
 }
 
+// zeroMemory is a memory region that only contains zeroes.
+type zeroMemory struct{}
+
+// ReadMemory fills buf with zeroes.
+func (zeroMemory) ReadMemory(buf []byte, addr uint64) (n int, err error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
+
 // process represents a core file.
 type process struct {
 	mem     proc.MemoryReader
@@ -156,6 +170,13 @@ type process struct {
 
 	bi          *proc.BinaryInfo
 	breakpoints proc.BreakpointMap
+
+	// decompressedPath is the path of the temporary file containing the
+	// decompressed core file, if the core file was compressed.
+	decompressedPath string
+
+	// files are closed by Detach.
+	files []io.Closer
 }
 
 var _ proc.ProcessInternal = &process{}
@@ -189,7 +210,7 @@ var (
 
 type openFn func(string, string) (*process, proc.Thread, error)
 
-var openFns = []openFn{readLinuxCore, readAMD64Minidump}
+var openFns = []openFn{readDelveCore, readOpenBSDCore, readFreeBSDCore, readNetBSDCore, readLinuxCore, readAMD64Minidump}
 
 // ErrUnrecognizedFormat is returned when the core file is not recognized as
 // any of the supported formats.
@@ -199,9 +220,16 @@ var ErrUnrecognizedFormat = errors.New("unrecognized core format")
 // If the DWARF information cannot be found in the binary, Delve will look
 // for external debug files in the directories passed in.
 func OpenCore(corePath, exePath string, debugInfoDirs []string) (*proc.Target, error) {
+	decompressedPath, err := decompressCore(corePath)
+	if err != nil {
+		return nil, err
+	}
+	if decompressedPath != "" {
+		corePath = decompressedPath
+	}
+
 	var p *process
 	var currentThread proc.Thread
-	var err error
 	for _, openFn := range openFns {
 		p, currentThread, err = openFn(corePath, exePath)
 		if err != ErrUnrecognizedFormat {
@@ -209,8 +237,12 @@ func OpenCore(corePath, exePath string, debugInfoDirs []string) (*proc.Target, e
 		}
 	}
 	if err != nil {
+		if decompressedPath != "" {
+			os.Remove(decompressedPath)
+		}
 		return nil, err
 	}
+	p.decompressedPath = decompressedPath
 
 	return proc.NewTarget(p, currentThread, proc.NewTargetConfig{
 		Path:                exePath,
@@ -219,6 +251,43 @@ func OpenCore(corePath, exePath string, debugInfoDirs []string) (*proc.Target, e
 		StopReason:          proc.StopAttached})
 }
 
+// decompressCore decompresses corePath to a temporary file if it is
+// compressed with gzip and returns the path of the temporary file. If
+// corePath is not compressed the empty string is returned.
+func decompressCore(corePath string) (string, error) {
+	fh, err := os.Open(corePath)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	var magic [2]byte
+	if _, err := io.ReadFull(fh, magic[:]); err != nil || magic != [2]byte{0x1f, 0x8b} {
+		return "", nil
+	}
+	if _, err := fh.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	gz, err := gzip.NewReader(fh)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile("", "delve-core")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, gz)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("could not decompress core file: %v", err)
+	}
+	return tmp.Name(), nil
+}
+
 // BinInfo will return the binary info.
 func (p *process) BinInfo() *proc.BinaryInfo {
 	return p.bi
@@ -386,6 +455,40 @@ func (p *process) ContinueOnce() (proc.Thread, proc.StopReason, error) {
 	return nil, proc.StopUnknown, ErrContinueCore
 }
 
+// SetSignalPolicy will always return an error because you
+// cannot control execution of a core file.
+func (p *process) SetSignalPolicy(int, proc.SignalPolicy) error {
+	return ErrContinueCore
+}
+
+// SetMemoryGuards returns ErrMemoryGuardsNotSupported, core files can not
+// be resumed.
+func (p *process) SetMemoryGuards([]*proc.MemoryGuard) error {
+	return proc.ErrMemoryGuardsNotSupported
+}
+
+// EnableBranchTrace returns ErrBranchTraceNotSupported, core files can
+// not be resumed.
+func (p *process) EnableBranchTrace(bool) error {
+	return proc.ErrBranchTraceNotSupported
+}
+
+// BranchTrace returns ErrBranchTraceNotSupported.
+func (p *process) BranchTrace(int) ([]byte, error) {
+	return nil, proc.ErrBranchTraceNotSupported
+}
+
+// WriteMinidump returns ErrMinidumpNotSupported.
+func (p *process) WriteMinidump(*os.File, bool) error {
+	return proc.ErrMinidumpNotSupported
+}
+
+// MemoryMap returns ErrMemoryMapNotSupported, core files can not be
+// dumped.
+func (p *process) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	return nil, proc.ErrMemoryMapNotSupported
+}
+
 // StepInstruction will always return an error
 // as you cannot control execution of a core file.
 func (p *process) StepInstruction() error {
@@ -414,6 +517,14 @@ func (p *process) Memory() proc.MemoryReadWriter {
 // effect as you cannot detach from a core file
 // and have it continue execution or exit.
 func (p *process) Detach(bool) error {
+	for _, f := range p.files {
+		f.Close()
+	}
+	p.files = nil
+	if p.decompressedPath != "" {
+		os.Remove(p.decompressedPath)
+		p.decompressedPath = ""
+	}
 	return nil
 }
 
@@ -2,6 +2,9 @@ package core
 
 import (
 	"bytes"
+	"compress/gzip"
+	"debug/elf"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"go/constant"
@@ -15,8 +18,11 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/arch/x86/x86asm"
+
 	"github.com/go-delve/delve/pkg/goversion"
 	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/fbsdutil"
 	"github.com/go-delve/delve/pkg/proc/test"
 )
 
@@ -473,3 +479,280 @@ func procdump(t *testing.T, exePath string) string {
 	t.Fatalf("could not find dump file")
 	return ""
 }
+
+// appendCoreNote appends an ELF note to notes.
+func appendCoreNote(notes *bytes.Buffer, name string, typ elf.NType, desc interface{}) {
+	var d bytes.Buffer
+	binary.Write(&d, binary.LittleEndian, desc)
+	name += "\x00"
+	binary.Write(notes, binary.LittleEndian, elfNotesHdr{Namesz: uint32(len(name)), Descsz: uint32(d.Len()), Type: uint32(typ)})
+	notes.WriteString(name)
+	for notes.Len()%4 != 0 {
+		notes.WriteByte(0)
+	}
+	notes.Write(d.Bytes())
+	for notes.Len()%4 != 0 {
+		notes.WriteByte(0)
+	}
+}
+
+// writeELFCore writes a minimal amd64 ELF core file containing the given
+// notes and a single memory segment at memAddr.
+func writeELFCore(t *testing.T, path string, osabi elf.OSABI, notes []byte, memAddr uint64, mem []byte) {
+	const hdrsz, phsz = 64, 56
+	noteOff := uint64(hdrsz + 2*phsz)
+	memOff := noteOff + uint64(len(notes))
+	var buf bytes.Buffer
+	hdr := elf.Header64{Type: uint16(elf.ET_CORE), Machine: uint16(elf.EM_X86_64), Version: uint32(elf.EV_CURRENT), Phoff: hdrsz, Ehsize: hdrsz, Phentsize: phsz, Phnum: 2}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	hdr.Ident[elf.EI_OSABI] = byte(osabi)
+	binary.Write(&buf, binary.LittleEndian, hdr)
+	binary.Write(&buf, binary.LittleEndian, elf.Prog64{Type: uint32(elf.PT_NOTE), Off: noteOff, Filesz: uint64(len(notes))})
+	binary.Write(&buf, binary.LittleEndian, elf.Prog64{Type: uint32(elf.PT_LOAD), Flags: uint32(elf.PF_R | elf.PF_W), Off: memOff, Vaddr: memAddr, Filesz: uint64(len(mem)), Memsz: uint64(len(mem))})
+	buf.Write(notes)
+	buf.Write(mem)
+	assertNoError(ioutil.WriteFile(path, buf.Bytes(), 0600), t, "WriteFile")
+}
+
+// writeOpenBSDCore writes a minimal amd64 OpenBSD core file containing a
+// process with two threads and a single memory segment at memAddr.
+func writeOpenBSDCore(t *testing.T, path string, memAddr uint64, mem []byte) {
+	var notes bytes.Buffer
+	appendCoreNote(&notes, "OpenBSD", _NT_OPENBSD_PROCINFO, openbsdProcInfo{Version: 1, Pid: 1234})
+	appendCoreNote(&notes, "OpenBSD@100005", _NT_OPENBSD_REGS, openbsdRegsAMD64{Rip: 0x401000, Rsp: memAddr + 8, Rax: 42})
+	appendCoreNote(&notes, "OpenBSD@100006", _NT_OPENBSD_REGS, openbsdRegsAMD64{Rip: 0x402000})
+	appendCoreNote(&notes, "OpenBSD@100006", _NT_OPENBSD_FPREGS, [512]byte{})
+	writeELFCore(t, path, elf.ELFOSABI_OPENBSD, notes.Bytes(), memAddr, mem)
+}
+
+func TestOpenBSDCore(t *testing.T) {
+	exePath, err := os.Executable()
+	assertNoError(err, t, "os.Executable")
+	if f, err := elf.Open(exePath); err != nil || f.Machine != elf.EM_X86_64 {
+		t.Skip("test executable is not an amd64 ELF file")
+	}
+
+	tempDir, err := ioutil.TempDir("", "")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(tempDir)
+	corePath := filepath.Join(tempDir, "openbsd.core")
+	const memAddr = 0xc000000000
+	writeOpenBSDCore(t, corePath, memAddr, []byte("deadbeef"))
+
+	p, currentThread, err := readOpenBSDCore(corePath, exePath)
+	assertNoError(err, t, "readOpenBSDCore")
+	if p.pid != 1234 {
+		t.Errorf("wrong pid %d", p.pid)
+	}
+	if p.bi.GOOS != "openbsd" || p.bi.Arch.Name != "amd64" {
+		t.Errorf("wrong target %s/%s", p.bi.GOOS, p.bi.Arch.Name)
+	}
+	if len(p.Threads) != 2 || currentThread.ThreadID() != 100005 {
+		t.Fatalf("wrong threads %v (current %d)", p.Threads, currentThread.ThreadID())
+	}
+
+	regs, err := currentThread.Registers()
+	assertNoError(err, t, "Registers")
+	if regs.PC() != 0x401000 || regs.SP() != memAddr+8 {
+		t.Errorf("wrong registers PC=%#x SP=%#x", regs.PC(), regs.SP())
+	}
+	if rax, _ := regs.Get(int(x86asm.RAX)); rax != 42 {
+		t.Errorf("wrong value of RAX %d", rax)
+	}
+	regs, err = p.Threads[100006].Registers()
+	assertNoError(err, t, "Registers")
+	if fpregs, _ := regs.Slice(true); len(fpregs) <= 24 {
+		t.Errorf("floating point registers of thread 100006 not loaded")
+	}
+
+	buf := make([]byte, 4)
+	_, err = p.Memory().ReadMemory(buf, memAddr+4)
+	assertNoError(err, t, "ReadMemory")
+	if string(buf) != "beef" {
+		t.Errorf("wrong memory contents %q", buf)
+	}
+
+	// linux core files must not be recognized as OpenBSD core files
+	linuxCore := filepath.Join(tempDir, "linux.core")
+	data, _ := ioutil.ReadFile(corePath)
+	data[elf.EI_OSABI] = 0
+	data = bytes.ReplaceAll(data, []byte("OpenBSD"), []byte("NotBSD\x00"))
+	assertNoError(ioutil.WriteFile(linuxCore, data, 0600), t, "WriteFile")
+	if _, _, err := readOpenBSDCore(linuxCore, exePath); err != ErrUnrecognizedFormat {
+		t.Errorf("linux core file recognized as OpenBSD core file: %v", err)
+	}
+}
+
+// bsdCoreTestSetup returns the path of the test executable, skipping the
+// test if it can't be used with the core files written by writeELFCore,
+// and a temporary directory.
+func TestCompressedCore(t *testing.T) {
+	exePath, tempDir := bsdCoreTestSetup(t)
+	defer os.RemoveAll(tempDir)
+	const memAddr = 0xc000000000
+
+	corePath := filepath.Join(tempDir, "openbsd.core")
+	writeOpenBSDCore(t, corePath, memAddr, []byte("deadbeef"))
+	if decompressed, err := decompressCore(corePath); err != nil || decompressed != "" {
+		t.Fatalf("uncompressed core file decompressed to %q: %v", decompressed, err)
+	}
+
+	buf, err := ioutil.ReadFile(corePath)
+	assertNoError(err, t, "ReadFile")
+	var gzbuf bytes.Buffer
+	gz := gzip.NewWriter(&gzbuf)
+	gz.Write(buf)
+	assertNoError(gz.Close(), t, "gzip")
+	compressedPath := corePath + ".gz"
+	assertNoError(ioutil.WriteFile(compressedPath, gzbuf.Bytes(), 0600), t, "WriteFile")
+
+	decompressed, err := decompressCore(compressedPath)
+	assertNoError(err, t, "decompressCore")
+	defer os.Remove(decompressed)
+	p, _, err := readOpenBSDCore(decompressed, exePath)
+	assertNoError(err, t, "readOpenBSDCore")
+	p.decompressedPath = decompressed
+	mem := make([]byte, 8)
+	_, err = p.ReadMemory(mem, memAddr)
+	assertNoError(err, t, "ReadMemory")
+	if string(mem) != "deadbeef" {
+		t.Errorf("wrong memory %q", mem)
+	}
+	p.Detach(false)
+	if _, err := os.Stat(decompressed); !os.IsNotExist(err) {
+		t.Errorf("decompressed core file %q not removed by Detach: %v", decompressed, err)
+	}
+}
+
+func bsdCoreTestSetup(t *testing.T) (exePath, tempDir string) {
+	exePath, err := os.Executable()
+	assertNoError(err, t, "os.Executable")
+	if f, err := elf.Open(exePath); err != nil || f.Machine != elf.EM_X86_64 {
+		t.Skip("test executable is not an amd64 ELF file")
+	}
+	tempDir, err = ioutil.TempDir("", "")
+	assertNoError(err, t, "TempDir")
+	return exePath, tempDir
+}
+
+func TestFreeBSDCore(t *testing.T) {
+	exePath, tempDir := bsdCoreTestSetup(t)
+	defer os.RemoveAll(tempDir)
+	const memAddr = 0xc000000000
+
+	prstatus := func(tid int32, regs fbsdutil.AMD64PtraceRegs) interface{} {
+		return struct {
+			freebsdPrStatusHdr
+			fbsdutil.AMD64PtraceRegs
+		}{freebsdPrStatusHdr{Version: 1, Pid: tid}, regs}
+	}
+	var notes bytes.Buffer
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_PRPSINFO, freebsdPrPsInfo{Version: 1, Pid: 1234})
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_PRSTATUS, prstatus(100005, fbsdutil.AMD64PtraceRegs{Rip: 0x401000, Rsp: memAddr + 8, Rax: 42}))
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_X86_SEGBASES, [2]uint64{0x1000, 0})
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_PRSTATUS, prstatus(100006, fbsdutil.AMD64PtraceRegs{Rip: 0x402000}))
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_FPREGSET, [512]byte{})
+	appendCoreNote(&notes, "FreeBSD", _NT_FREEBSD_PROCSTAT_AUXV, struct {
+		Structsize int32
+		Auxv       [4]uint64
+	}{16, [4]uint64{9, 0x401234, 0, 0}})
+	corePath := filepath.Join(tempDir, "freebsd.core")
+	writeELFCore(t, corePath, elf.ELFOSABI_FREEBSD, notes.Bytes(), memAddr, []byte("deadbeef"))
+
+	p, currentThread, err := readFreeBSDCore(corePath, exePath)
+	assertNoError(err, t, "readFreeBSDCore")
+	if p.pid != 1234 {
+		t.Errorf("wrong pid %d", p.pid)
+	}
+	if p.entryPoint != 0x401234 {
+		t.Errorf("wrong entry point %#x", p.entryPoint)
+	}
+	if p.bi.GOOS != "freebsd" || p.bi.Arch.Name != "amd64" {
+		t.Errorf("wrong target %s/%s", p.bi.GOOS, p.bi.Arch.Name)
+	}
+	if len(p.Threads) != 2 || currentThread.ThreadID() != 100005 {
+		t.Fatalf("wrong threads %v (current %d)", p.Threads, currentThread.ThreadID())
+	}
+
+	regs, err := currentThread.Registers()
+	assertNoError(err, t, "Registers")
+	if regs.PC() != 0x401000 || regs.SP() != memAddr+8 || regs.TLS() != 0x1000 {
+		t.Errorf("wrong registers PC=%#x SP=%#x TLS=%#x", regs.PC(), regs.SP(), regs.TLS())
+	}
+	if rax, _ := regs.Get(int(x86asm.RAX)); rax != 42 {
+		t.Errorf("wrong value of RAX %d", rax)
+	}
+	regs, err = p.Threads[100006].Registers()
+	assertNoError(err, t, "Registers")
+	if fpregs, _ := regs.Slice(true); len(fpregs) <= 24 {
+		t.Errorf("floating point registers of thread 100006 not loaded")
+	}
+
+	buf := make([]byte, 4)
+	_, err = p.Memory().ReadMemory(buf, memAddr+4)
+	assertNoError(err, t, "ReadMemory")
+	if string(buf) != "beef" {
+		t.Errorf("wrong memory contents %q", buf)
+	}
+
+	// OpenBSD core files must not be recognized as FreeBSD core files
+	openbsdCore := filepath.Join(tempDir, "openbsd.core")
+	writeOpenBSDCore(t, openbsdCore, memAddr, []byte("deadbeef"))
+	if _, _, err := readFreeBSDCore(openbsdCore, exePath); err != ErrUnrecognizedFormat {
+		t.Errorf("OpenBSD core file recognized as FreeBSD core file: %v", err)
+	}
+}
+
+func TestNetBSDCore(t *testing.T) {
+	exePath, tempDir := bsdCoreTestSetup(t)
+	defer os.RemoveAll(tempDir)
+	const memAddr = 0xc000000000
+
+	var notes bytes.Buffer
+	appendCoreNote(&notes, "NetBSD-CORE", _NT_NETBSDCORE_PROCINFO, netbsdProcInfo{Version: 1, Pid: 1234, Nlwps: 2, Siglwp: 2})
+	appendCoreNote(&notes, "NetBSD-CORE", _NT_NETBSDCORE_AUXV, []uint64{9, 0x401234, 0, 0})
+	appendCoreNote(&notes, "NetBSD-CORE@1", _NT_NETBSDCORE_AMD64_REGS, netbsdRegsAMD64{Rip: 0x402000})
+	appendCoreNote(&notes, "NetBSD-CORE@1", _NT_NETBSDCORE_AMD64_FPREGS, [512]byte{})
+	appendCoreNote(&notes, "NetBSD-CORE@2", _NT_NETBSDCORE_AMD64_REGS, netbsdRegsAMD64{Rip: 0x401000, Rsp: memAddr + 8, Rax: 42})
+	corePath := filepath.Join(tempDir, "netbsd.core")
+	writeELFCore(t, corePath, elf.ELFOSABI_NETBSD, notes.Bytes(), memAddr, []byte("deadbeef"))
+
+	p, currentThread, err := readNetBSDCore(corePath, exePath)
+	assertNoError(err, t, "readNetBSDCore")
+	if p.pid != 1234 {
+		t.Errorf("wrong pid %d", p.pid)
+	}
+	if p.entryPoint != 0x401234 {
+		t.Errorf("wrong entry point %#x", p.entryPoint)
+	}
+	if p.bi.GOOS != "netbsd" || p.bi.Arch.Name != "amd64" {
+		t.Errorf("wrong target %s/%s", p.bi.GOOS, p.bi.Arch.Name)
+	}
+	if len(p.Threads) != 2 || currentThread.ThreadID() != 2 {
+		t.Fatalf("wrong threads %v (current %d)", p.Threads, currentThread.ThreadID())
+	}
+
+	regs, err := currentThread.Registers()
+	assertNoError(err, t, "Registers")
+	if regs.PC() != 0x401000 || regs.SP() != memAddr+8 {
+		t.Errorf("wrong registers PC=%#x SP=%#x", regs.PC(), regs.SP())
+	}
+	if rax, _ := regs.Get(int(x86asm.RAX)); rax != 42 {
+		t.Errorf("wrong value of RAX %d", rax)
+	}
+	regs, err = p.Threads[1].Registers()
+	assertNoError(err, t, "Registers")
+	if fpregs, _ := regs.Slice(true); len(fpregs) <= 24 {
+		t.Errorf("floating point registers of thread 1 not loaded")
+	}
+
+	buf := make([]byte, 4)
+	_, err = p.Memory().ReadMemory(buf, memAddr+4)
+	assertNoError(err, t, "ReadMemory")
+	if string(buf) != "beef" {
+		t.Errorf("wrong memory contents %q", buf)
+	}
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/proc"
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// readDelveCore reads a core file written by (*proc.Target).Dump, see the
+// documentation of proc.DelveThreadNoteType for a description of the
+// format.
+func readDelveCore(corePath, exePath string) (*process, proc.Thread, error) {
+	coreFile, err := elf.Open(corePath)
+	if err != nil {
+		return nil, nil, ErrUnrecognizedFormat
+	}
+
+	var notes []*note
+	for _, prog := range coreFile.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		r := prog.Open()
+		for {
+			note, err := readDelveNote(r)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				coreFile.Close()
+				return nil, nil, ErrUnrecognizedFormat
+			}
+			notes = append(notes, note)
+		}
+	}
+	if len(notes) == 0 || notes[0].Name != proc.DelveNoteName || notes[0].Type != proc.DelveHeaderNoteType {
+		coreFile.Close()
+		return nil, nil, ErrUnrecognizedFormat
+	}
+
+	var goos, goarch string
+	var entryPoint uint64
+	var pid, currentThreadID int
+	for _, line := range strings.Split(string(notes[0].Desc.([]byte)), "\n") {
+		switch {
+		case strings.HasPrefix(line, proc.DelveHeaderTargetOSPrefix):
+			goos = line[len(proc.DelveHeaderTargetOSPrefix):]
+		case strings.HasPrefix(line, proc.DelveHeaderTargetArchPrefix):
+			goarch = line[len(proc.DelveHeaderTargetArchPrefix):]
+		case strings.HasPrefix(line, proc.DelveHeaderTargetPidPrefix):
+			pid, err = strconv.Atoi(line[len(proc.DelveHeaderTargetPidPrefix):])
+		case strings.HasPrefix(line, proc.DelveHeaderEntryPointPrefix):
+			entryPoint, err = strconv.ParseUint(line[len(proc.DelveHeaderEntryPointPrefix):], 0, 64)
+		case strings.HasPrefix(line, proc.DelveHeaderCurrentThreadPrefix):
+			currentThreadID, err = strconv.Atoi(line[len(proc.DelveHeaderCurrentThreadPrefix):])
+		}
+		if err != nil {
+			coreFile.Close()
+			return nil, nil, fmt.Errorf("malformed header line %q: %v", line, err)
+		}
+	}
+	if goos == "" || goarch == "" {
+		coreFile.Close()
+		return nil, nil, errors.New("malformed core file header: missing target OS or architecture")
+	}
+
+	memory := &splicedMemory{}
+	// The memory of the process is read from the core file, and possibly
+	// from the executable, they are closed by Detach.
+	files := []io.Closer{coreFile}
+
+	// If the executable is an ELF file loaded at its preferred address use
+	// its segments for memory that was not included in the dump.
+	if exe, err := os.Open(exePath); err == nil {
+		if exeELF, err := elf.NewFile(exe); err == nil && exeELF.Type == elf.ET_EXEC {
+			for _, prog := range exeELF.Progs {
+				if prog.Type == elf.PT_LOAD && prog.Filesz != 0 {
+					memory.Add(&offsetReaderAt{reader: prog.ReaderAt, offset: prog.Vaddr}, prog.Vaddr, prog.Filesz)
+				}
+			}
+			files = append(files, exe)
+		} else {
+			exe.Close()
+		}
+	}
+	for _, prog := range coreFile.Progs {
+		if prog.Type != elf.PT_LOAD {
+			continue
+		}
+		if prog.Filesz != 0 {
+			memory.Add(&offsetReaderAt{reader: prog.ReaderAt, offset: prog.Vaddr}, prog.Vaddr, prog.Filesz)
+		}
+		// Pages omitted from sparse dumps are zeroes.
+		if prog.Memsz > prog.Filesz {
+			memory.Add(zeroMemory{}, prog.Vaddr+prog.Filesz, prog.Memsz-prog.Filesz)
+		}
+	}
+
+	p := &process{
+		mem:         memory,
+		Threads:     map[int]*thread{},
+		pid:         pid,
+		entryPoint:  entryPoint,
+		bi:          proc.NewBinaryInfo(goos, goarch),
+		breakpoints: proc.NewBreakpointMap(),
+		files:       files,
+	}
+
+	var currentThread proc.Thread
+	for _, note := range notes[1:] {
+		if note.Name != proc.DelveNoteName || note.Type != proc.DelveThreadNoteType {
+			continue
+		}
+		th, err := readDelveThread(note.Desc.([]byte), goarch)
+		if err != nil {
+			p.Detach(false)
+			return nil, nil, err
+		}
+		p.Threads[th.id] = &thread{th, p, proc.CommonThread{}}
+		if currentThread == nil || th.id == currentThreadID {
+			currentThread = p.Threads[th.id]
+		}
+	}
+
+	return p, currentThread, nil
+}
+
+// readDelveNote reads a single note from r, the descriptor is not decoded.
+func readDelveNote(r io.ReadSeeker) (*note, error) {
+	hdr := &elfNotesHdr{}
+	err := binary.Read(r, binary.LittleEndian, hdr)
+	if err != nil {
+		return nil, err // don't wrap so that the caller sees EOF.
+	}
+	name := make([]byte, hdr.Namesz)
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, fmt.Errorf("reading name: %v", err)
+	}
+	if err := skipPadding(r, 4); err != nil {
+		return nil, fmt.Errorf("aligning after name: %v", err)
+	}
+	desc := make([]byte, hdr.Descsz)
+	if _, err := io.ReadFull(r, desc); err != nil {
+		return nil, fmt.Errorf("reading desc: %v", err)
+	}
+	if err := skipPadding(r, 4); err != nil {
+		return nil, fmt.Errorf("aligning after desc: %v", err)
+	}
+	return &note{Type: elf.NType(hdr.Type), Name: strings.TrimRight(string(name), "\x00"), Desc: desc}, nil
+}
+
+// readDelveThread decodes a DelveThreadNoteType note.
+func readDelveThread(desc []byte, goarch string) (*delveThread, error) {
+	buf := bytes.NewReader(desc)
+	var err error
+	get := func(v interface{}) {
+		if err == nil {
+			err = binary.Read(buf, binary.LittleEndian, v)
+		}
+	}
+	var id uint64
+	var hasGAddr uint8
+	var nregs uint32
+	regs := &delveRegisters{goarch: goarch}
+	get(&id)
+	get(&regs.pc)
+	get(&regs.sp)
+	get(&regs.bp)
+	get(&regs.tls)
+	get(&hasGAddr)
+	get(&regs.gaddr)
+	get(&nregs)
+	regs.hasGAddr = hasGAddr != 0
+	for i := uint32(0); i < nregs && err == nil; i++ {
+		var namelen uint16
+		var kind uint8
+		get(&namelen)
+		name := make([]byte, namelen)
+		get(name)
+		get(&kind)
+		var reg *op.DwarfRegister
+		if kind != 0 {
+			var n uint32
+			get(&n)
+			val := make([]byte, n)
+			get(val)
+			reg = op.DwarfRegisterFromBytes(val)
+		} else {
+			var val uint64
+			get(&val)
+			reg = op.DwarfRegisterFromUint64(val)
+		}
+		regs.slice = append(regs.slice, proc.Register{Name: string(name), Reg: reg})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("malformed thread note: %v", err)
+	}
+	return &delveThread{id: int(id), regs: regs}, nil
+}
+
+// delveThread is a thread read from a core file written by Delve.
+type delveThread struct {
+	id   int
+	regs *delveRegisters
+}
+
+func (th *delveThread) pid() int {
+	return th.id
+}
+
+func (th *delveThread) registers() (proc.Registers, error) {
+	return th.regs, nil
+}
+
+// delveRegisters implements proc.Registers for the registers saved in a
+// core file written by Delve.
+type delveRegisters struct {
+	goarch          string
+	pc, sp, bp, tls uint64
+	hasGAddr        bool
+	gaddr           uint64
+	slice           []proc.Register
+}
+
+func (regs *delveRegisters) PC() uint64                    { return regs.pc }
+func (regs *delveRegisters) SP() uint64                    { return regs.sp }
+func (regs *delveRegisters) BP() uint64                    { return regs.bp }
+func (regs *delveRegisters) TLS() uint64                   { return regs.tls }
+func (regs *delveRegisters) GAddr() (uint64, bool)         { return regs.gaddr, regs.hasGAddr }
+func (regs *delveRegisters) Copy() (proc.Registers, error) { return regs, nil }
+
+// Get returns the value of the register with the given x86asm or arm64asm
+// register number (or DWARF register number on loong64), looking it up by
+// name.
+func (regs *delveRegisters) Get(n int) (uint64, error) {
+	var name string
+	switch regs.goarch {
+	case "amd64", "386":
+		name = x86asm.Reg(n).String()
+	case "arm64":
+		name = arm64asm.Reg(n).String()
+	case "loong64":
+		name = fmt.Sprintf("R%d", n)
+	}
+	for _, reg := range regs.slice {
+		if strings.EqualFold(reg.Name, name) {
+			return reg.Reg.Uint64Val, nil
+		}
+	}
+	return 0, proc.ErrUnknownRegister
+}
+
+func (regs *delveRegisters) Slice(floatingPoint bool) ([]proc.Register, error) {
+	return regs.slice, nil
+}
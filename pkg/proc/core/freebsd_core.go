@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/amd64util"
+	"github.com/go-delve/delve/pkg/proc/fbsdutil"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// FreeBSD ELF core note types, see sys/sys/elf_common.h.
+// All notes written by FreeBSD are named "FreeBSD".
+const (
+	_NT_FREEBSD_PRSTATUS      elf.NType = 1
+	_NT_FREEBSD_FPREGSET      elf.NType = 2
+	_NT_FREEBSD_PRPSINFO      elf.NType = 3
+	_NT_FREEBSD_PROCSTAT_AUXV elf.NType = 16
+	_NT_FREEBSD_X86_SEGBASES  elf.NType = 0x200
+	_NT_FREEBSD_X86_XSTATE    elf.NType = 0x202
+
+	freebsdNoteName = "FreeBSD"
+)
+
+// freebsdPrStatusHdr is a copy of the fields of struct prstatus that
+// precede the registers, see sys/sys/procfs.h.
+// The Pid field is the ID of the thread.
+type freebsdPrStatusHdr struct {
+	Version                         int32
+	_                               [4]byte
+	Statussz, Gregsetsz, Fpregsetsz uint64
+	Osreldate, Cursig, Pid          int32
+	_                               [4]byte
+}
+
+// freebsdPrPsInfo is a copy of struct prpsinfo, see sys/sys/procfs.h.
+// The Pid field was added in FreeBSD 12.
+type freebsdPrPsInfo struct {
+	Version  int32
+	_        [4]byte
+	Psinfosz uint64
+	Fname    [17]uint8
+	Psargs   [81]uint8
+	_        [2]byte
+	Pid      int32
+}
+
+// readFreeBSDCore reads a core file produced by FreeBSD from corePath,
+// corresponding to the executable at exePath.
+// FreeBSD core files are ELF files like Linux core files but the layout
+// of their notes is different, see __elfN(coredump) in
+// sys/kern/imgact_elf.c.
+func readFreeBSDCore(corePath, exePath string) (*process, proc.Thread, error) {
+	return readBSDCore(corePath, exePath, "freebsd", freebsdNoteName, freebsdThreadsFromNotes)
+}
+
+// freebsdThreadsFromNotes creates the threads of p from the notes of the
+// core file and reads the PID and entry point of the process. The thread
+// that caused the core dump is written first by the kernel and is
+// returned as the current thread.
+func freebsdThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) (proc.Thread, error) {
+	var currentThread proc.Thread
+	var lastThread *freebsdThread
+	for _, note := range notes {
+		desc, _ := note.Desc.([]byte)
+		if strings.TrimRight(note.Name, "\x00") != freebsdNoteName || desc == nil {
+			continue
+		}
+		switch note.Type {
+		case _NT_FREEBSD_PRPSINFO:
+			var info freebsdPrPsInfo
+			if len(desc) >= binary.Size(info) {
+				if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &info); err != nil {
+					return nil, fmt.Errorf("reading NT_PRPSINFO: %v", err)
+				}
+				p.pid = int(info.Pid)
+			}
+		case _NT_FREEBSD_PROCSTAT_AUXV:
+			// the auxiliary vector is preceded by the size of its entries
+			if len(desc) > 4 {
+				p.entryPoint = linutil.EntryPointFromAuxv(desc[4:], p.bi.Arch.PtrSize())
+			}
+		case _NT_FREEBSD_PRSTATUS:
+			rdr := bytes.NewReader(desc)
+			var hdr freebsdPrStatusHdr
+			if err := binary.Read(rdr, binary.LittleEndian, &hdr); err != nil {
+				return nil, fmt.Errorf("reading NT_PRSTATUS: %v", err)
+			}
+			regs, err := freebsdRegisters(rdr, machineType)
+			if err != nil {
+				return nil, err
+			}
+			lastThread = &freebsdThread{regs: regs, tid: int(hdr.Pid)}
+			p.Threads[lastThread.tid] = &thread{lastThread, p, proc.CommonThread{}}
+			if currentThread == nil {
+				currentThread = p.Threads[lastThread.tid]
+			}
+		case _NT_FREEBSD_FPREGSET, _NT_FREEBSD_X86_XSTATE:
+			if lastThread == nil {
+				continue
+			}
+			if err := lastThread.readFpRegisters(note.Type, desc); err != nil {
+				return nil, err
+			}
+		case _NT_FREEBSD_X86_SEGBASES:
+			if r, ok := lastThread.regsAMD64(); ok && len(desc) >= 8 {
+				r.Fsbase = binary.LittleEndian.Uint64(desc)
+			}
+		}
+	}
+	if currentThread == nil {
+		return nil, fmt.Errorf("no threads found in core file")
+	}
+	return currentThread, nil
+}
+
+// freebsdRegisters reads a struct reg from rdr.
+func freebsdRegisters(rdr *bytes.Reader, machineType elf.Machine) (proc.Registers, error) {
+	switch machineType {
+	case elf.EM_X86_64:
+		var regs fbsdutil.AMD64PtraceRegs
+		if err := binary.Read(rdr, binary.LittleEndian, &regs); err != nil {
+			return nil, fmt.Errorf("reading NT_PRSTATUS registers: %v", err)
+		}
+		return fbsdutil.NewAMD64Registers(&regs, 0, nil), nil
+	case elf.EM_AARCH64:
+		var regs fbsdutil.ARM64PtraceRegs
+		if err := binary.Read(rdr, binary.LittleEndian, &regs); err != nil {
+			return nil, fmt.Errorf("reading NT_PRSTATUS registers: %v", err)
+		}
+		return fbsdutil.NewARM64Registers(&regs, nil), nil
+	}
+	return nil, fmt.Errorf("unsupported machine type")
+}
+
+type freebsdThread struct {
+	regs proc.Registers
+	tid  int
+}
+
+func (t *freebsdThread) regsAMD64() (*fbsdutil.AMD64Registers, bool) {
+	if t == nil {
+		return nil, false
+	}
+	r, ok := t.regs.(*fbsdutil.AMD64Registers)
+	return r, ok
+}
+
+// readFpRegisters decodes a NT_FPREGSET note, which is a FXSAVE area on
+// amd64 and a struct fpreg on arm64, or a NT_X86_XSTATE note, which
+// supersedes NT_FPREGSET on amd64.
+func (t *freebsdThread) readFpRegisters(typ elf.NType, desc []byte) error {
+	switch r := t.regs.(type) {
+	case *fbsdutil.AMD64Registers:
+		var fpregs amd64util.AMD64Xstate
+		if typ == _NT_FREEBSD_X86_XSTATE {
+			if err := amd64util.AMD64XstateRead(desc, true, &fpregs); err != nil {
+				return fmt.Errorf("reading NT_X86_XSTATE: %v", err)
+			}
+		} else if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &fpregs.AMD64PtraceFpRegs); err != nil {
+			return fmt.Errorf("reading NT_FPREGSET: %v", err)
+		}
+		r.Fpregs = fpregs.Decode()
+		r.Fpregset = &fpregs
+	case *fbsdutil.ARM64Registers:
+		if typ != _NT_FREEBSD_FPREGSET {
+			return nil
+		}
+		var fpregs fbsdutil.ARM64PtraceFpRegs
+		if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &fpregs); err != nil {
+			return fmt.Errorf("reading NT_FPREGSET: %v", err)
+		}
+		r.Fpregs = fpregs.Decode()
+		r.Fpregset = &fpregs
+	}
+	return nil
+}
+
+func (t *freebsdThread) registers() (proc.Registers, error) {
+	return t.regs.Copy()
+}
+
+func (t *freebsdThread) pid() int {
+	return t.tid
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"debug/elf"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -37,6 +38,7 @@ const _NT_FPREGSET elf.NType = 0x2
 // Refer http://man7.org/linux/man-pages/man5/elf.5.html
 const (
 	_EM_AARCH64          = 183
+	_EM_LOONGARCH        = 258
 	_EM_X86_64           = 62
 	_ARM_FP_HEADER_START = 512
 )
@@ -47,6 +49,7 @@ func linuxThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) p
 	var currentThread proc.Thread
 	var lastThreadAMD *linuxAMD64Thread
 	var lastThreadARM *linuxARM64Thread
+	var lastThreadLOONG64 *linuxLOONG64Thread
 	for _, note := range notes {
 		switch note.Type {
 		case elf.NT_PRSTATUS:
@@ -64,12 +67,23 @@ func linuxThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) p
 				if currentThread == nil {
 					currentThread = p.Threads[int(t.Pid)]
 				}
+			} else if machineType == _EM_LOONGARCH {
+				t := note.Desc.(*linuxPrStatusLOONG64)
+				lastThreadLOONG64 = &linuxLOONG64Thread{linutil.LOONG64Registers{Regs: &t.Reg}, t}
+				p.Threads[int(t.Pid)] = &thread{lastThreadLOONG64, p, proc.CommonThread{}}
+				if currentThread == nil {
+					currentThread = p.Threads[int(t.Pid)]
+				}
 			}
 		case _NT_FPREGSET:
 			if machineType == _EM_AARCH64 {
 				if lastThreadARM != nil {
 					lastThreadARM.regs.Fpregs = note.Desc.(*linutil.ARM64PtraceFpRegs).Decode()
 				}
+			} else if machineType == _EM_LOONGARCH {
+				if lastThreadLOONG64 != nil {
+					lastThreadLOONG64.regs.Fpregs = note.Desc.(*linutil.LOONG64PtraceFpRegs).Decode()
+				}
 			}
 		case _NT_X86_XSTATE:
 			if machineType == _EM_X86_64 {
@@ -129,6 +143,8 @@ func readLinuxCore(corePath, exePath string) (*process, proc.Thread, error) {
 		bi = proc.NewBinaryInfo("linux", "amd64")
 	case _EM_AARCH64:
 		bi = proc.NewBinaryInfo("linux", "arm64")
+	case _EM_LOONGARCH:
+		bi = proc.NewBinaryInfo("linux", "loong64")
 	default:
 		return nil, nil, fmt.Errorf("unsupported machine type")
 	}
@@ -157,6 +173,11 @@ type linuxARM64Thread struct {
 	t    *linuxPrStatusARM64
 }
 
+type linuxLOONG64Thread struct {
+	regs linutil.LOONG64Registers
+	t    *linuxPrStatusLOONG64
+}
+
 func (t *linuxAMD64Thread) registers() (proc.Registers, error) {
 	var r linutil.AMD64Registers
 	r.Regs = t.regs.Regs
@@ -171,6 +192,13 @@ func (t *linuxARM64Thread) registers() (proc.Registers, error) {
 	return &r, nil
 }
 
+func (t *linuxLOONG64Thread) registers() (proc.Registers, error) {
+	var r linutil.LOONG64Registers
+	r.Regs = t.regs.Regs
+	r.Fpregs = t.regs.Fpregs
+	return &r, nil
+}
+
 func (t *linuxAMD64Thread) pid() int {
 	return int(t.t.Pid)
 }
@@ -179,6 +207,10 @@ func (t *linuxARM64Thread) pid() int {
 	return int(t.t.Pid)
 }
 
+func (t *linuxLOONG64Thread) pid() int {
+	return int(t.t.Pid)
+}
+
 // Note is a note from the PT_NOTE prog.
 // Relevant types:
 // - NT_FILE: File mapping information, e.g. program text mappings. Desc is a LinuxNTFile.
@@ -201,6 +233,9 @@ func readNotes(core *elf.File, machineType elf.Machine) ([]*note, error) {
 			break
 		}
 	}
+	if notesProg == nil {
+		return nil, errors.New("no PT_NOTE segment in core file")
+	}
 
 	r := notesProg.Open()
 	notes := []*note{}
@@ -244,12 +279,24 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 		return nil, fmt.Errorf("reading desc: %v", err)
 	}
 	descReader := bytes.NewReader(desc)
+	if isBSDNoteName(note.Name) {
+		// the BSDs reuse some of the note types used by linux with different
+		// layouts, their notes are decoded by the corresponding read*Core
+		// function.
+		note.Desc = desc
+		if err := skipPadding(r, 4); err != nil {
+			return nil, fmt.Errorf("aligning after desc: %v", err)
+		}
+		return note, nil
+	}
 	switch note.Type {
 	case elf.NT_PRSTATUS:
 		if machineType == _EM_X86_64 {
 			note.Desc = &linuxPrStatusAMD64{}
 		} else if machineType == _EM_AARCH64 {
 			note.Desc = &linuxPrStatusARM64{}
+		} else if machineType == _EM_LOONGARCH {
+			note.Desc = &linuxPrStatusLOONG64{}
 		} else {
 			return nil, fmt.Errorf("unsupported machine type")
 		}
@@ -296,6 +343,12 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 				return nil, err
 			}
 			note.Desc = fpregs
+		} else if machineType == _EM_LOONGARCH {
+			fpregs, err := linutil.LOONG64FpRegsFromBytes(desc)
+			if err != nil {
+				return nil, err
+			}
+			note.Desc = fpregs
 		}
 	}
 	if err := skipPadding(r, 4); err != nil {
@@ -408,6 +461,19 @@ type linuxPrStatusARM64 struct {
 	Fpvalid                      int32
 }
 
+// LinuxPrStatusLOONG64 is a copy of the prstatus kernel struct.
+type linuxPrStatusLOONG64 struct {
+	Siginfo                      linuxSiginfo
+	Cursig                       uint16
+	_                            [2]uint8
+	Sigpend                      uint64
+	Sighold                      uint64
+	Pid, Ppid, Pgrp, Sid         int32
+	Utime, Stime, CUtime, CStime linuxCoreTimeval
+	Reg                          linutil.LOONG64PtraceRegs
+	Fpvalid                      int32
+}
+
 // LinuxSiginfo is a copy of the
 // siginfo kernel struct.
 type linuxSiginfo struct {
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/amd64util"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// NetBSD ELF core note types, see sys/sys/exec_elf.h.
+// Notes describing the process are named "NetBSD-CORE", notes describing
+// a thread are named "NetBSD-CORE@<lwpid>" and their type is the ptrace
+// request that returns the same data, which depends on the architecture
+// (see sys/arch/*/include/ptrace.h).
+const (
+	_NT_NETBSDCORE_PROCINFO elf.NType = 1
+	_NT_NETBSDCORE_AUXV     elf.NType = 2
+
+	_NT_NETBSDCORE_AMD64_REGS   elf.NType = 33
+	_NT_NETBSDCORE_AMD64_FPREGS elf.NType = 35
+	_NT_NETBSDCORE_ARM64_REGS   elf.NType = 32
+	_NT_NETBSDCORE_ARM64_FPREGS elf.NType = 34
+
+	netbsdNoteName = "NetBSD-CORE"
+)
+
+// netbsdProcInfo is a copy of struct netbsd_elfcore_procinfo, see
+// sys/sys/exec_elf.h.
+type netbsdProcInfo struct {
+	Version, Cpisize                      uint32
+	Signo, Sigcode                        uint32
+	Sigpend, Sigmask, Sigignore, Sigcatch [4]uint32
+	Pid, Ppid, Pgrp, Sid                  int32
+	Ruid, Euid, Svuid, Rgid, Egid, Svgid  uint32
+	Nlwps                                 uint32
+	Name                                  [32]int8
+	Siglwp                                int32
+}
+
+// netbsdRegsAMD64 is a copy of struct reg, see
+// sys/arch/amd64/include/reg.h and sys/arch/amd64/include/mcontext.h.
+type netbsdRegsAMD64 struct {
+	Rdi, Rsi, Rdx, Rcx, R8, R9, R10, R11 uint64
+	R12, R13, R14, R15, Rbp, Rbx, Rax    uint64
+	Gs, Fs, Es, Ds, Trapno, Err          uint64
+	Rip, Cs, Rflags, Rsp, Ss             uint64
+}
+
+// netbsdRegsARM64 is a copy of struct reg, see
+// sys/arch/aarch64/include/reg.h.
+type netbsdRegsARM64 struct {
+	X     [31]uint64
+	Sp    uint64
+	Pc    uint64
+	Spsr  uint64
+	Tpidr uint64
+}
+
+// readNetBSDCore reads a core file produced by NetBSD from corePath,
+// corresponding to the executable at exePath.
+// NetBSD core files are ELF files like Linux core files but use different
+// notes, see sys/kern/core_elf32.c.
+// The registers of each thread are converted to their linux equivalent.
+// Since the FS base register is not saved on amd64 the goroutine running
+// on each thread can not be determined on amd64.
+func readNetBSDCore(corePath, exePath string) (*process, proc.Thread, error) {
+	return readBSDCore(corePath, exePath, "netbsd", netbsdNoteName, netbsdThreadsFromNotes)
+}
+
+// netbsdThreadsFromNotes creates the threads of p from the notes of the
+// core file and reads the PID and entry point of the process. The current
+// thread is the one that received the signal that caused the core dump.
+func netbsdThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) (proc.Thread, error) {
+	regsType, fpregsType := _NT_NETBSDCORE_AMD64_REGS, _NT_NETBSDCORE_AMD64_FPREGS
+	if machineType == elf.EM_AARCH64 {
+		regsType, fpregsType = _NT_NETBSDCORE_ARM64_REGS, _NT_NETBSDCORE_ARM64_FPREGS
+	}
+	siglwp := 0
+	var firstThread proc.Thread
+	for _, note := range notes {
+		desc, _ := note.Desc.([]byte)
+		name := strings.TrimRight(note.Name, "\x00")
+		if desc == nil {
+			continue
+		}
+		if name == netbsdNoteName {
+			switch note.Type {
+			case _NT_NETBSDCORE_PROCINFO:
+				var info netbsdProcInfo
+				if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &info); err != nil {
+					return nil, fmt.Errorf("reading NT_NETBSDCORE_PROCINFO: %v", err)
+				}
+				p.pid = int(info.Pid)
+				siglwp = int(info.Siglwp)
+			case _NT_NETBSDCORE_AUXV:
+				p.entryPoint = linutil.EntryPointFromAuxv(desc, p.bi.Arch.PtrSize())
+			}
+			continue
+		}
+		if !strings.HasPrefix(name, netbsdNoteName+"@") {
+			continue
+		}
+		tid, err := strconv.Atoi(strings.TrimPrefix(name, netbsdNoteName+"@"))
+		if err != nil {
+			return nil, fmt.Errorf("malformed note name %q", name)
+		}
+		switch note.Type {
+		case regsType:
+			regs, err := netbsdRegisters(desc, machineType)
+			if err != nil {
+				return nil, err
+			}
+			p.Threads[tid] = &thread{&netbsdThread{regs: regs, tid: tid}, p, proc.CommonThread{}}
+			if firstThread == nil {
+				firstThread = p.Threads[tid]
+			}
+		case fpregsType:
+			th := p.Threads[tid]
+			if th == nil {
+				continue
+			}
+			fpregs, err := netbsdFpRegisters(desc, machineType)
+			if err != nil {
+				return nil, err
+			}
+			switch r := th.th.(*netbsdThread).regs.(type) {
+			case *linutil.AMD64Registers:
+				r.Fpregs = fpregs
+			case *linutil.ARM64Registers:
+				r.Fpregs = fpregs
+			}
+		}
+	}
+	if firstThread == nil {
+		return nil, fmt.Errorf("no threads found in core file")
+	}
+	if th := p.Threads[siglwp]; th != nil {
+		return th, nil
+	}
+	return firstThread, nil
+}
+
+// netbsdRegisters converts the contents of a registers note to the
+// equivalent linux registers.
+func netbsdRegisters(desc []byte, machineType elf.Machine) (proc.Registers, error) {
+	rdr := bytes.NewReader(desc)
+	switch machineType {
+	case elf.EM_X86_64:
+		var r netbsdRegsAMD64
+		if err := binary.Read(rdr, binary.LittleEndian, &r); err != nil {
+			return nil, fmt.Errorf("reading registers note: %v", err)
+		}
+		return &linutil.AMD64Registers{Regs: &linutil.AMD64PtraceRegs{
+			R15: r.R15, R14: r.R14, R13: r.R13, R12: r.R12,
+			Rbp: r.Rbp, Rbx: r.Rbx, R11: r.R11, R10: r.R10,
+			R9: r.R9, R8: r.R8, Rax: r.Rax, Rcx: r.Rcx,
+			Rdx: r.Rdx, Rsi: r.Rsi, Rdi: r.Rdi, Rip: r.Rip,
+			Cs: r.Cs, Eflags: r.Rflags, Rsp: r.Rsp, Ss: r.Ss,
+			Ds: r.Ds, Es: r.Es, Fs: r.Fs, Gs: r.Gs,
+		}}, nil
+	case elf.EM_AARCH64:
+		var r netbsdRegsARM64
+		if err := binary.Read(rdr, binary.LittleEndian, &r); err != nil {
+			return nil, fmt.Errorf("reading registers note: %v", err)
+		}
+		regs := &linutil.ARM64PtraceRegs{Sp: r.Sp, Pc: r.Pc, Pstate: r.Spsr}
+		copy(regs.Regs[:], r.X[:])
+		return &linutil.ARM64Registers{Regs: regs}, nil
+	}
+	return nil, fmt.Errorf("unsupported machine type")
+}
+
+// netbsdFpRegisters decodes the contents of a floating point registers
+// note, which is a FXSAVE area on amd64 and a struct fpreg on arm64.
+func netbsdFpRegisters(desc []byte, machineType elf.Machine) ([]proc.Register, error) {
+	switch machineType {
+	case elf.EM_X86_64:
+		var fpregs amd64util.AMD64Xstate
+		if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &fpregs.AMD64PtraceFpRegs); err != nil {
+			return nil, fmt.Errorf("reading floating point registers note: %v", err)
+		}
+		return fpregs.Decode(), nil
+	case elf.EM_AARCH64:
+		fpregs := &linutil.ARM64PtraceFpRegs{}
+		if len(desc) < _ARM_FP_HEADER_START {
+			return nil, fmt.Errorf("reading floating point registers note: short note")
+		}
+		copy(fpregs.Byte(), desc[:_ARM_FP_HEADER_START])
+		return fpregs.Decode(), nil
+	}
+	return nil, nil
+}
+
+type netbsdThread struct {
+	regs proc.Registers
+	tid  int
+}
+
+func (t *netbsdThread) registers() (proc.Registers, error) {
+	return t.regs.Copy()
+}
+
+func (t *netbsdThread) pid() int {
+	return t.tid
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/amd64util"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// OpenBSD ELF core note types, see sys/sys/exec_elf.h.
+// Notes describing the process are named "OpenBSD", notes describing a
+// thread are named "OpenBSD@<tid>".
+const (
+	_NT_OPENBSD_PROCINFO elf.NType = 10
+	_NT_OPENBSD_AUXV     elf.NType = 11
+	_NT_OPENBSD_REGS     elf.NType = 20
+	_NT_OPENBSD_FPREGS   elf.NType = 21
+
+	openbsdNoteName = "OpenBSD"
+)
+
+// openbsdProcInfo is a copy of struct elfcore_procinfo, see
+// sys/sys/exec_elf.h.
+type openbsdProcInfo struct {
+	Version, Cpisize                      uint32
+	Signo, Sigcode                        uint32
+	Sigpend, Sigmask, Sigignore, Sigcatch uint32
+	Pid, Ppid, Pgrp, Sid                  int32
+	Ruid, Euid, Svuid, Rgid, Egid, Svgid  uint32
+	Name                                  [32]int8
+}
+
+// openbsdRegsAMD64 is a copy of struct reg, see
+// sys/arch/amd64/include/reg.h.
+type openbsdRegsAMD64 struct {
+	Rdi, Rsi, Rdx, Rcx, R8, R9, R10, R11 uint64
+	R12, R13, R14, R15, Rbp, Rbx, Rax    uint64
+	Rsp, Rip, Rflags, Cs, Ss, Ds, Es     uint64
+	Fs, Gs                               uint64
+}
+
+// openbsdRegsARM64 is a copy of struct reg, see
+// sys/arch/arm64/include/reg.h.
+type openbsdRegsARM64 struct {
+	X     [30]uint64
+	Lr    uint64
+	Sp    uint64
+	Pc    uint64
+	Spsr  uint64
+	Tpidr uint64
+}
+
+// readOpenBSDCore reads a core file produced by OpenBSD from corePath,
+// corresponding to the executable at exePath.
+// OpenBSD core files are ELF files like Linux core files but use different
+// notes, see coredump_notes_elf in sys/kern/exec_elf.c.
+// The registers of each thread are converted to their linux equivalent.
+// Since the FS base register is not saved on amd64 the goroutine running
+// on each thread can not be determined on amd64.
+func readOpenBSDCore(corePath, exePath string) (*process, proc.Thread, error) {
+	return readBSDCore(corePath, exePath, "openbsd", openbsdNoteName, openbsdThreadsFromNotes)
+}
+
+// openbsdThreadsFromNotes creates the threads of p from the notes of the
+// core file and reads the PID and entry point of the process. The thread
+// that caused the core dump is written first by the kernel and is
+// returned as the current thread.
+func openbsdThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) (proc.Thread, error) {
+	var currentThread proc.Thread
+	var lastThread *openbsdThread
+	for _, note := range notes {
+		desc, _ := note.Desc.([]byte)
+		name := strings.TrimRight(note.Name, "\x00")
+		if !strings.HasPrefix(name, openbsdNoteName) || desc == nil {
+			continue
+		}
+		switch note.Type {
+		case _NT_OPENBSD_PROCINFO:
+			var info openbsdProcInfo
+			if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &info); err != nil {
+				return nil, fmt.Errorf("reading NT_OPENBSD_PROCINFO: %v", err)
+			}
+			p.pid = int(info.Pid)
+		case _NT_OPENBSD_AUXV:
+			p.entryPoint = linutil.EntryPointFromAuxv(desc, p.bi.Arch.PtrSize())
+		case _NT_OPENBSD_REGS:
+			tid, err := strconv.Atoi(strings.TrimPrefix(name, openbsdNoteName+"@"))
+			if err != nil {
+				return nil, fmt.Errorf("malformed note name %q", name)
+			}
+			regs, err := openbsdRegisters(desc, machineType)
+			if err != nil {
+				return nil, err
+			}
+			lastThread = &openbsdThread{regs: regs, tid: tid}
+			p.Threads[tid] = &thread{lastThread, p, proc.CommonThread{}}
+			if currentThread == nil {
+				currentThread = p.Threads[tid]
+			}
+		case _NT_OPENBSD_FPREGS:
+			if lastThread == nil {
+				continue
+			}
+			fpregs, err := openbsdFpRegisters(desc, machineType)
+			if err != nil {
+				return nil, err
+			}
+			switch r := lastThread.regs.(type) {
+			case *linutil.AMD64Registers:
+				r.Fpregs = fpregs
+			case *linutil.ARM64Registers:
+				r.Fpregs = fpregs
+			}
+		}
+	}
+	if currentThread == nil {
+		return nil, fmt.Errorf("no threads found in core file")
+	}
+	return currentThread, nil
+}
+
+// openbsdRegisters converts the contents of a NT_OPENBSD_REGS note to the
+// equivalent linux registers.
+func openbsdRegisters(desc []byte, machineType elf.Machine) (proc.Registers, error) {
+	rdr := bytes.NewReader(desc)
+	switch machineType {
+	case elf.EM_X86_64:
+		var r openbsdRegsAMD64
+		if err := binary.Read(rdr, binary.LittleEndian, &r); err != nil {
+			return nil, fmt.Errorf("reading NT_OPENBSD_REGS: %v", err)
+		}
+		return &linutil.AMD64Registers{Regs: &linutil.AMD64PtraceRegs{
+			R15: r.R15, R14: r.R14, R13: r.R13, R12: r.R12,
+			Rbp: r.Rbp, Rbx: r.Rbx, R11: r.R11, R10: r.R10,
+			R9: r.R9, R8: r.R8, Rax: r.Rax, Rcx: r.Rcx,
+			Rdx: r.Rdx, Rsi: r.Rsi, Rdi: r.Rdi, Rip: r.Rip,
+			Cs: r.Cs, Eflags: r.Rflags, Rsp: r.Rsp, Ss: r.Ss,
+			Ds: r.Ds, Es: r.Es, Fs: r.Fs, Gs: r.Gs,
+		}}, nil
+	case elf.EM_AARCH64:
+		var r openbsdRegsARM64
+		if err := binary.Read(rdr, binary.LittleEndian, &r); err != nil {
+			return nil, fmt.Errorf("reading NT_OPENBSD_REGS: %v", err)
+		}
+		regs := &linutil.ARM64PtraceRegs{Sp: r.Sp, Pc: r.Pc, Pstate: r.Spsr}
+		copy(regs.Regs[:], r.X[:])
+		regs.Regs[30] = r.Lr
+		return &linutil.ARM64Registers{Regs: regs}, nil
+	}
+	return nil, fmt.Errorf("unsupported machine type")
+}
+
+// openbsdFpRegisters decodes the contents of a NT_OPENBSD_FPREGS note,
+// which is a FXSAVE area on amd64 and a struct fpreg on arm64.
+func openbsdFpRegisters(desc []byte, machineType elf.Machine) ([]proc.Register, error) {
+	switch machineType {
+	case elf.EM_X86_64:
+		var fpregs amd64util.AMD64Xstate
+		if err := binary.Read(bytes.NewReader(desc), binary.LittleEndian, &fpregs.AMD64PtraceFpRegs); err != nil {
+			return nil, fmt.Errorf("reading NT_OPENBSD_FPREGS: %v", err)
+		}
+		return fpregs.Decode(), nil
+	case elf.EM_AARCH64:
+		fpregs := &linutil.ARM64PtraceFpRegs{}
+		if len(desc) < _ARM_FP_HEADER_START {
+			return nil, fmt.Errorf("reading NT_OPENBSD_FPREGS: short note")
+		}
+		copy(fpregs.Byte(), desc[:_ARM_FP_HEADER_START])
+		return fpregs.Decode(), nil
+	}
+	return nil, nil
+}
+
+type openbsdThread struct {
+	regs proc.Registers
+	tid  int
+}
+
+func (t *openbsdThread) registers() (proc.Registers, error) {
+	return t.regs.Copy()
+}
+
+func (t *openbsdThread) pid() int {
+	return t.tid
+}
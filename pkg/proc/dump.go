@@ -0,0 +1,713 @@
+package proc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/go-delve/delve/pkg/elfwriter"
+)
+
+// MemoryMapEntry describes a memory mapping of the target process.
+type MemoryMapEntry struct {
+	Addr uint64
+	Size uint64
+
+	Read, Write, Exec bool
+
+	// Filename is the name of the file mapped at Addr, if any, and Offset
+	// is the offset in the file of the start of the mapping.
+	Filename string
+	Offset   uint64
+}
+
+// ErrMemoryMapNotSupported is returned by the MemoryMap method of backends
+// that can not read the memory map of the target process.
+var ErrMemoryMapNotSupported = errors.New("MemoryMap not supported")
+
+// ErrMinidumpNotSupported is returned by the WriteMinidump method of
+// backends that can not write minidumps of the target process.
+var ErrMinidumpNotSupported = errors.New("minidumps can only be written by the native backend on windows")
+
+// DumpFlags is used to configure (*Target).Dump.
+type DumpFlags uint8
+
+const (
+	// DumpSelective restricts the dump to the stacks of all threads and
+	// goroutines, the global variables of the target and the memory reachable
+	// from them, instead of all the readable memory of the target.
+	DumpSelective DumpFlags = 1 << iota
+	// DumpMinidump writes a Windows minidump, using the facilities of the
+	// operating system, instead of an ELF core file. Combined with
+	// DumpSelective only heap, stacks and data segments are written.
+	DumpMinidump
+	// DumpCompress compresses the output file with gzip, compressed files
+	// can be opened with core.OpenCore or decompressed with gunzip.
+	DumpCompress
+	// DumpSparse omits memory pages that only contain zeroes from the
+	// output file, segments of a sparse dump have a memory size larger than
+	// their file size and the missing pages are read back as zeroes.
+	DumpSparse
+)
+
+// Dump files written by (*Target).Dump are ELF core files containing one
+// PT_LOAD segment for every dumped memory range and a PT_NOTE segment
+// containing a header note followed by one note for each thread.
+// All notes are called DelveNoteName.
+// The header note (DelveHeaderNoteType) contains a series of lines, each
+// one starting with one of the DelveHeader prefixes.
+// Each thread note (DelveThreadNoteType) contains, in little endian:
+//
+//	thread ID (uint64)
+//	PC, SP, BP, TLS (uint64)
+//	1 if the address of the G struct is known, 0 otherwise (uint8)
+//	address of the G struct (uint64)
+//	number of registers (uint32)
+//
+// followed by, for each register, the length of its name (uint16), the
+// name, 1 if the value is a byte slice or 0 if it is an integer (uint8)
+// and either the value (uint64) or the length of the byte slice (uint32)
+// followed by its contents.
+const (
+	DelveNoteName       = "Delve"
+	DelveHeaderNoteType = elf.NType(0x444c5645) // "DLVE"
+	DelveThreadNoteType = elf.NType(0x444c5654) // "DLVT"
+
+	DelveHeaderTargetOSPrefix      = "Target OS: "
+	DelveHeaderTargetArchPrefix    = "Target Arch: "
+	DelveHeaderTargetPidPrefix     = "Target Pid: "
+	DelveHeaderEntryPointPrefix    = "Entry Point: "
+	DelveHeaderCurrentThreadPrefix = "Current Thread: "
+)
+
+const (
+	dumpChunkSize   = 1 << 20
+	dumpPageSize    = 0x1000
+	runtimePageSize = 0x2000 // runtime._PageSize
+	stackRedZone    = 128
+)
+
+// dumpRange is a range of memory written to a dump.
+type dumpRange struct {
+	addr, size uint64
+	flags      elf.ProgFlag
+}
+
+// Dump writes a core file of the target process to out, which is closed
+// before returning. The resulting file can be opened with core.OpenCore.
+func (t *Target) Dump(out elfwriter.WriteCloserSeeker, flags DumpFlags) error {
+	defer out.Close()
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	if flags&DumpCompress != 0 {
+		return t.dumpCompressed(out, flags&^DumpCompress)
+	}
+	return t.dump(out, flags)
+}
+
+// dumpCompressed writes an uncompressed dump to a temporary file, then
+// copies it to out through a gzip compressor. The ELF header is only
+// written once all the memory has been dumped, therefore the output of
+// dump can not be compressed while it is produced.
+func (t *Target) dumpCompressed(out io.Writer, flags DumpFlags) error {
+	tmp, err := ioutil.TempFile("", "delve-dump")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := t.dump(tmp, flags); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return err
+	}
+	defer tmp.Close()
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, tmp); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (t *Target) dump(out elfwriter.WriteCloserSeeker, flags DumpFlags) error {
+	if flags&DumpMinidump != 0 {
+		fh, ok := out.(*os.File)
+		if !ok {
+			return errors.New("minidumps can only be written to a file")
+		}
+		return t.proc.WriteMinidump(fh, flags&DumpSelective == 0)
+	}
+
+	bi := t.BinInfo()
+	var machine elf.Machine
+	switch bi.Arch.Name {
+	case "amd64":
+		machine = elf.EM_X86_64
+	case "arm64":
+		machine = elf.EM_AARCH64
+	case "386":
+		machine = elf.EM_386
+	case "loong64":
+		machine = emLoongArch
+	default:
+		return fmt.Errorf("can not dump a %s process", bi.Arch.Name)
+	}
+
+	mmap, err := t.proc.MemoryMap()
+	if err != nil {
+		return err
+	}
+	sort.Slice(mmap, func(i, j int) bool { return mmap[i].Addr < mmap[j].Addr })
+
+	entryPoint, err := t.EntryPoint()
+	if err != nil {
+		return err
+	}
+
+	w := elfwriter.New(out, &elf.FileHeader{
+		Class:   elf.ELFCLASS64,
+		Data:    elf.ELFDATA2LSB,
+		Version: elf.EV_CURRENT,
+		OSABI:   elf.ELFOSABI_NONE,
+		Type:    elf.ET_CORE,
+		Machine: machine,
+		Entry:   entryPoint,
+	})
+
+	threads := t.ThreadList()
+	sort.Slice(threads, func(i, j int) bool { return threads[i].ThreadID() < threads[j].ThreadID() })
+
+	var hdr bytes.Buffer
+	fmt.Fprintf(&hdr, "%s%s\n", DelveHeaderTargetOSPrefix, bi.GOOS)
+	fmt.Fprintf(&hdr, "%s%s\n", DelveHeaderTargetArchPrefix, bi.Arch.Name)
+	fmt.Fprintf(&hdr, "%s%d\n", DelveHeaderTargetPidPrefix, t.Pid())
+	fmt.Fprintf(&hdr, "%s%#x\n", DelveHeaderEntryPointPrefix, entryPoint)
+	if t.CurrentThread() != nil {
+		fmt.Fprintf(&hdr, "%s%d\n", DelveHeaderCurrentThreadPrefix, t.CurrentThread().ThreadID())
+	}
+	notes := []elfwriter.Note{{Type: DelveHeaderNoteType, Name: DelveNoteName, Data: hdr.Bytes()}}
+	for _, th := range threads {
+		data, err := dumpThreadNote(th)
+		if err != nil {
+			return fmt.Errorf("could not dump thread %d: %v", th.ThreadID(), err)
+		}
+		notes = append(notes, elfwriter.Note{Type: DelveThreadNoteType, Name: DelveNoteName, Data: data})
+	}
+	w.WriteNotes(notes)
+
+	var ranges []dumpRange
+	if flags&DumpSelective != 0 {
+		ranges, err = t.selectiveDumpRanges(mmap, threads)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, m := range mmap {
+			if m.Read {
+				ranges = append(ranges, dumpRange{m.Addr, m.Size, memoryMapFlags(&m)})
+			}
+		}
+	}
+
+	mem := t.Memory()
+	for _, r := range ranges {
+		dumpMemory(w, mem, r, flags&DumpSparse != 0)
+		if w.Err != nil {
+			return w.Err
+		}
+	}
+
+	w.WriteProgramHeaders()
+	return w.Err
+}
+
+func memoryMapFlags(m *MemoryMapEntry) elf.ProgFlag {
+	var flags elf.ProgFlag
+	if m.Read {
+		flags |= elf.PF_R
+	}
+	if m.Write {
+		flags |= elf.PF_W
+	}
+	if m.Exec {
+		flags |= elf.PF_X
+	}
+	return flags
+}
+
+// dumpThreadNote encodes the registers of th as described in the
+// documentation of DelveThreadNoteType.
+func dumpThreadNote(th Thread) ([]byte, error) {
+	regs, err := th.Registers()
+	if err != nil {
+		return nil, err
+	}
+	regslice, err := regs.Slice(true)
+	if err != nil {
+		regslice, err = regs.Slice(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	put := func(v interface{}) {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	put(uint64(th.ThreadID()))
+	put(regs.PC())
+	put(regs.SP())
+	put(regs.BP())
+	put(regs.TLS())
+	gaddr, hasGAddr := regs.GAddr()
+	if hasGAddr {
+		put(uint8(1))
+	} else {
+		put(uint8(0))
+	}
+	put(gaddr)
+	put(uint32(len(regslice)))
+	for _, reg := range regslice {
+		put(uint16(len(reg.Name)))
+		buf.WriteString(reg.Name)
+		if reg.Reg.Bytes != nil {
+			put(uint8(1))
+			put(uint32(len(reg.Reg.Bytes)))
+			buf.Write(reg.Reg.Bytes)
+		} else {
+			put(uint8(0))
+			put(reg.Reg.Uint64Val)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// dumpMemory writes the memory in r to w, one PT_LOAD segment for every
+// readable part of it, unreadable parts are skipped.
+// If sparse is set pages containing only zeroes are not written, they are
+// described by the difference between Memsz and Filesz of a segment
+// instead.
+func dumpMemory(w *elfwriter.Writer, mem MemoryReader, r dumpRange, sparse bool) {
+	var prog *elf.ProgHeader
+	newProg := func(addr uint64) {
+		prog = &elf.ProgHeader{
+			Type:  elf.PT_LOAD,
+			Flags: r.flags,
+			Off:   w.Here(),
+			Vaddr: addr,
+			Align: 1,
+		}
+		w.Progs = append(w.Progs, prog)
+	}
+	writeData := func(addr uint64, data []byte) {
+		// Data can only follow data in the same segment, after the first
+		// omitted page the rest of the segment must be zeroes.
+		if prog == nil || prog.Vaddr+prog.Memsz != addr || prog.Memsz != prog.Filesz {
+			newProg(addr)
+		}
+		w.Write(data)
+		prog.Filesz += uint64(len(data))
+		prog.Memsz += uint64(len(data))
+	}
+	write := func(addr uint64, data []byte) {
+		if !sparse {
+			writeData(addr, data)
+			return
+		}
+		for len(data) > 0 {
+			n := int(dumpPageSize - addr%dumpPageSize)
+			if n > len(data) {
+				n = len(data)
+			}
+			if isZeroes(data[:n]) {
+				if prog == nil || prog.Vaddr+prog.Memsz != addr {
+					newProg(addr)
+				}
+				prog.Memsz += uint64(n)
+			} else {
+				writeData(addr, data[:n])
+			}
+			addr += uint64(n)
+			data = data[n:]
+		}
+	}
+
+	buf := make([]byte, dumpChunkSize)
+	end := r.addr + r.size
+	for addr := r.addr; addr < end && w.Err == nil; {
+		sz := uint64(len(buf))
+		if end-addr < sz {
+			sz = end - addr
+		}
+		n, err := mem.ReadMemory(buf[:sz], addr)
+		if err == nil && uint64(n) == sz {
+			write(addr, buf[:sz])
+			addr += sz
+			continue
+		}
+		// Retry one page at a time to skip only the unreadable pages.
+		for pageEnd := addr + sz; addr < pageEnd && w.Err == nil; {
+			psz := dumpPageSize - addr%dumpPageSize
+			if pageEnd-addr < psz {
+				psz = pageEnd - addr
+			}
+			n, err := mem.ReadMemory(buf[:psz], addr)
+			if err == nil && uint64(n) == psz {
+				write(addr, buf[:psz])
+			} else {
+				prog = nil
+			}
+			addr += psz
+		}
+	}
+}
+
+func isZeroes(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// selectiveDumpRanges returns the memory ranges written by a selective dump:
+// the stacks of all threads and goroutines, the data and bss sections of
+// all Go modules and, transitively, every heap object (or, for memory not
+// managed by the Go heap, every page) pointed to by them. Memory is scanned
+// conservatively, any word that looks like a pointer is treated as one.
+func (t *Target) selectiveDumpRanges(mmap []MemoryMapEntry, threads []Thread) ([]dumpRange, error) {
+	bi := t.BinInfo()
+	mem := t.Memory()
+	rs := &reachableSet{
+		mem:     mem,
+		mmap:    mmap,
+		ptrSize: uint64(bi.Arch.PtrSize()),
+		seen:    make(map[uint64]bool),
+	}
+	rs.spans = readHeapSpans(bi, mem)
+
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	var stacks []stack
+	for _, g := range gs {
+		if g.stack.hi > g.stack.lo {
+			stacks = append(stacks, g.stack)
+			rs.add(g.stack.lo, g.stack.hi-g.stack.lo, true)
+		}
+	}
+
+	for _, th := range threads {
+		regs, err := th.Registers()
+		if err != nil {
+			return nil, err
+		}
+		regslice, _ := regs.Slice(false)
+		for _, reg := range regslice {
+			if reg.Reg.Bytes == nil {
+				rs.markPointer(reg.Reg.Uint64Val)
+			}
+		}
+		sp := regs.SP()
+		onGoroutineStack := false
+		for _, s := range stacks {
+			if sp >= s.lo && sp < s.hi {
+				onGoroutineStack = true
+				break
+			}
+		}
+		if onGoroutineStack {
+			continue
+		}
+		// Thread stack, dump everything between the stack pointer and the end
+		// of the span or mapping containing it.
+		lo := sp - stackRedZone
+		if span := rs.spanOf(sp); span != nil {
+			rs.add(lo, span.base+span.size-lo, true)
+		} else if m := rs.mappingOf(sp); m != nil {
+			if lo < m.Addr {
+				lo = m.Addr
+			}
+			rs.add(lo, m.Addr+m.Size-lo, true)
+		}
+	}
+
+	if !rs.addModuleData(bi, mem) {
+		// Could not read the runtime module data, use the writable mappings
+		// of the executable.
+		for i := range mmap {
+			m := &mmap[i]
+			if m.Read && m.Write && m.Filename != "" && m.Filename == bi.Images[0].Path {
+				rs.add(m.Addr, m.Size, true)
+			}
+		}
+	}
+
+	rs.scanAll()
+
+	return rs.ranges(), nil
+}
+
+// heapSpan is a span of the Go heap.
+type heapSpan struct {
+	base, size, elemsize uint64
+
+	// The following fields are only used by FindReferrers and are best
+	// effort, they are left to their zero value if they could not be read.
+	state     uint64 // mSpanInUse for spans containing heap objects
+	spanclass uint64 // size class of the span, the lowest bit is set for noscan spans
+	freeindex uint64 // all objects before freeindex are allocated
+	allocBits uint64 // address of the allocation bitmap of the span
+	largeType uint64 // address of the runtime._type of the object of a large object span
+}
+
+const mSpanInUse = 1 // runtime.mSpanInUse
+
+// readHeapSpans returns the list of spans in runtime.mheap_.allspans, sorted
+// by address.
+func readHeapSpans(bi *BinaryInfo, mem MemoryReadWriter) []heapSpan {
+	scope := globalScope(bi, bi.Images[0], mem)
+	mheapv, err := scope.findGlobal("runtime", "mheap_")
+	if err != nil {
+		return nil
+	}
+	allspansv, err := mheapv.structMember("allspans")
+	if err != nil || allspansv.Unreadable != nil {
+		return nil
+	}
+	spans := make([]heapSpan, 0, allspansv.Len)
+	for i := int64(0); i < allspansv.Len; i++ {
+		spanv, err := allspansv.sliceAccess(int(i))
+		if err != nil {
+			break
+		}
+		spanv = spanv.maybeDereference()
+		if spanv.Unreadable != nil || spanv.Addr == 0 {
+			continue
+		}
+		spanv.mem = cacheMemory(spanv.mem, spanv.Addr, int(spanv.RealType.Size()))
+		span := heapSpan{state: mSpanInUse}
+		var npages uint64
+		for _, field := range []struct {
+			name string
+			dst  *uint64
+		}{
+			{"startAddr", &span.base}, {"npages", &npages}, {"elemsize", &span.elemsize},
+			{"state", &span.state}, {"spanclass", &span.spanclass},
+			{"freeindex", &span.freeindex}, {"freeIndexForScan", &span.freeindex},
+			{"allocBits", &span.allocBits}, {"largeType", &span.largeType},
+		} {
+			fieldv, err := spanv.structMember(field.name)
+			if err != nil {
+				continue
+			}
+			if fieldv.Kind == reflect.Ptr {
+				*field.dst, _ = readUintRaw(fieldv.mem, fieldv.Addr, int64(bi.Arch.PtrSize()))
+				continue
+			}
+			// mspan.state is wrapped in one or more structs, depending on
+			// the version of Go.
+			if n, err := loadUintValue(unwrapAtomic(fieldv)); err == nil {
+				*field.dst = n
+			}
+		}
+		span.size = npages * runtimePageSize
+		if span.base != 0 && span.size != 0 {
+			spans = append(spans, span)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].base < spans[j].base })
+	return spans
+}
+
+// reachableSet is the set of memory ranges included in a selective dump.
+type reachableSet struct {
+	mem     MemoryReader
+	mmap    []MemoryMapEntry
+	spans   []heapSpan
+	ptrSize uint64
+
+	seen     map[uint64]bool // start addresses of the ranges in included
+	included []dumpRange
+	queue    []dumpRange // ranges that still need to be scanned for pointers
+}
+
+func (rs *reachableSet) mappingOf(addr uint64) *MemoryMapEntry {
+	i := sort.Search(len(rs.mmap), func(i int) bool { return rs.mmap[i].Addr+rs.mmap[i].Size > addr })
+	if i < len(rs.mmap) && rs.mmap[i].Addr <= addr {
+		return &rs.mmap[i]
+	}
+	return nil
+}
+
+func (rs *reachableSet) spanOf(addr uint64) *heapSpan {
+	i := sort.Search(len(rs.spans), func(i int) bool { return rs.spans[i].base+rs.spans[i].size > addr })
+	if i < len(rs.spans) && rs.spans[i].base <= addr {
+		return &rs.spans[i]
+	}
+	return nil
+}
+
+// add adds the range [addr, addr+size) to the set, if scan is true the
+// range will also be scanned for pointers.
+func (rs *reachableSet) add(addr, size uint64, scan bool) {
+	if size == 0 || rs.seen[addr] {
+		return
+	}
+	rs.seen[addr] = true
+	r := dumpRange{addr: addr, size: size, flags: elf.PF_R | elf.PF_W}
+	if m := rs.mappingOf(addr); m != nil {
+		r.flags = memoryMapFlags(m)
+	}
+	rs.included = append(rs.included, r)
+	if scan {
+		rs.queue = append(rs.queue, r)
+	}
+}
+
+// markPointer adds the object pointed to by p to the set.
+func (rs *reachableSet) markPointer(p uint64) {
+	m := rs.mappingOf(p)
+	if m == nil || !m.Read {
+		return
+	}
+	if span := rs.spanOf(p); span != nil && span.elemsize > 0 {
+		off := (p - span.base) / span.elemsize * span.elemsize
+		size := span.elemsize
+		if off+size > span.size {
+			size = span.size - off
+		}
+		rs.add(span.base+off, size, true)
+		return
+	}
+	page := p &^ (dumpPageSize - 1)
+	size := uint64(dumpPageSize)
+	if page < m.Addr {
+		page = m.Addr
+	}
+	if page+size > m.Addr+m.Size {
+		size = m.Addr + m.Size - page
+	}
+	// Read-only memory (code and constant data) does not point to anything
+	// that needs to be dumped.
+	rs.add(page, size, m.Write)
+}
+
+// scanAll scans all queued ranges for pointers, until no new range is added.
+func (rs *reachableSet) scanAll() {
+	buf := make([]byte, dumpChunkSize)
+	for len(rs.queue) > 0 {
+		r := rs.queue[len(rs.queue)-1]
+		rs.queue = rs.queue[:len(rs.queue)-1]
+		end := r.addr + r.size
+		for addr := r.addr; addr < end; {
+			sz := uint64(len(buf))
+			if end-addr < sz {
+				sz = end - addr
+			}
+			n, _ := rs.mem.ReadMemory(buf[:sz], addr)
+			for off := uint64(0); off+rs.ptrSize <= uint64(n); off += rs.ptrSize {
+				var p uint64
+				if rs.ptrSize == 4 {
+					p = uint64(binary.LittleEndian.Uint32(buf[off:]))
+				} else {
+					p = binary.LittleEndian.Uint64(buf[off:])
+				}
+				if p != 0 {
+					rs.markPointer(p)
+				}
+			}
+			addr += sz
+		}
+	}
+}
+
+// addModuleData adds the data and bss sections of all Go modules to the
+// set, it returns false if runtime.firstmoduledata could not be read.
+func (rs *reachableSet) addModuleData(bi *BinaryInfo, mem MemoryReadWriter) bool {
+	return forEachModuleDataSection(bi, mem, func(start, end uint64, scan bool) {
+		rs.add(start, end-start, scan)
+	})
+}
+
+// forEachModuleDataSection calls fn for the data, bss, noptrdata and
+// noptrbss sections of all Go modules, scan is false for the sections that
+// do not contain pointers. It returns false if runtime.firstmoduledata
+// could not be read.
+func forEachModuleDataSection(bi *BinaryInfo, mem MemoryReadWriter, fn func(start, end uint64, scan bool)) bool {
+	scope := globalScope(bi, bi.Images[0], mem)
+	mdv, err := scope.findGlobal("runtime", "firstmoduledata")
+	if err != nil {
+		return false
+	}
+	for mdv != nil && mdv.Addr != 0 && mdv.Unreadable == nil {
+		for _, section := range []struct {
+			start, end string
+			scan       bool
+		}{
+			{"data", "edata", true},
+			{"bss", "ebss", true},
+			{"noptrdata", "enoptrdata", false},
+			{"noptrbss", "enoptrbss", false},
+		} {
+			startv, err1 := mdv.structMember(section.start)
+			endv, err2 := mdv.structMember(section.end)
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			start, _ := loadUintValue(startv)
+			end, _ := loadUintValue(endv)
+			if end > start {
+				fn(start, end, section.scan)
+			}
+		}
+		nextv, err := mdv.structMember("next")
+		if err != nil {
+			break
+		}
+		mdv = nextv.maybeDereference()
+	}
+	return true
+}
+
+// ranges returns the ranges in the set sorted by address, overlapping
+// ranges are merged.
+func (rs *reachableSet) ranges() []dumpRange {
+	sort.Slice(rs.included, func(i, j int) bool { return rs.included[i].addr < rs.included[j].addr })
+	var r []dumpRange
+	for _, cur := range rs.included {
+		if len(r) > 0 {
+			last := &r[len(r)-1]
+			if cur.addr <= last.addr+last.size && cur.flags == last.flags {
+				if cur.addr+cur.size > last.addr+last.size {
+					last.size = cur.addr + cur.size - last.addr
+				}
+				continue
+			}
+			if cur.addr < last.addr+last.size {
+				// Overlapping ranges with different flags, only keep the part
+				// that doesn't overlap.
+				if cur.addr+cur.size <= last.addr+last.size {
+					continue
+				}
+				cur.size = cur.addr + cur.size - (last.addr + last.size)
+				cur.addr = last.addr + last.size
+			}
+		}
+		r = append(r, cur)
+	}
+	return r
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"go/constant"
+	"reflect"
 	"testing"
 	"unsafe"
 
@@ -353,3 +354,134 @@ func TestNestedCompileUnts(t *testing.T) {
 		t.Errorf("expected 2 variables, got %d", n)
 	}
 }
+
+func TestTinyGoTypes(t *testing.T) {
+	// TinyGo does not emit DW_AT_go_kind, strings and slices are recognized
+	// by their name and layout, maps and channels are pointers to runtime
+	// structs and are decoded in degraded form.
+	dwb := dwarfbuilder.New()
+	dwb.Attr(dwarf.AttrProducer, "TinyGo")
+
+	uint8off := dwb.AddBaseType("uint8", dwarfbuilder.DW_ATE_unsigned, 1)
+	uintptroff := dwb.AddBaseType("uintptr", dwarfbuilder.DW_ATE_unsigned, 8)
+	intoff := dwb.AddBaseType("int", dwarfbuilder.DW_ATE_signed, 8)
+	byteptroff := dwb.AddPointerType("", uint8off)
+	intptroff := dwb.AddPointerType("", intoff)
+
+	stringoff := dwb.AddStructType("string", 16)
+	dwb.AddMember("ptr", byteptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(0)))
+	dwb.AddMember("len", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(8)))
+	dwb.TagClose()
+
+	sliceoff := dwb.AddStructType("[]int", 24)
+	dwb.AddMember("ptr", intptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(0)))
+	dwb.AddMember("len", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(8)))
+	dwb.AddMember("cap", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(16)))
+	dwb.TagClose()
+
+	hashmapoff := dwb.AddStructType("runtime.hashmap", 40)
+	dwb.AddMember("buckets", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(0)))
+	dwb.AddMember("count", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(8)))
+	dwb.AddMember("keySize", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(16)))
+	dwb.AddMember("valueSize", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(24)))
+	dwb.AddMember("bucketBits", uint8off, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(32)))
+	dwb.TagClose()
+	hashmapptroff := dwb.AddPointerType("", hashmapoff)
+
+	channeloff := dwb.AddStructType("runtime.channel", 32)
+	dwb.AddMember("elementSize", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(0)))
+	dwb.AddMember("bufSize", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(8)))
+	dwb.AddMember("bufUsed", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(16)))
+	dwb.AddMember("buf", uintptroff, dwarfbuilder.LocationBlock(op.DW_OP_plus_uconst, uint(24)))
+	dwb.TagClose()
+	channelptroff := dwb.AddPointerType("", channeloff)
+
+	cfaVar := func(name string, typ dwarf.Offset, off int) {
+		dwb.AddVariable(name, typ, dwarfbuilder.LocationBlock(op.DW_OP_call_frame_cfa, op.DW_OP_consts, off, op.DW_OP_plus))
+	}
+
+	dwb.AddSubprogram("main.main", 0x40100, 0x41000)
+	cfaVar("s", stringoff, 0)
+	cfaVar("sl", sliceoff, 16)
+	cfaVar("m", hashmapptroff, 40)
+	cfaVar("ch", channelptroff, 48)
+	dwb.TagClose()
+
+	bi, _ := fakeBinaryInfo(t, dwb)
+	mainfn := bi.LookupFunc["main.main"]
+
+	base := fakeCFA()
+	const (
+		hashmapOff  = 56
+		channelOff  = 96
+		bucketOff   = 128
+		strdataOff  = 168
+		slicedatOff = 176
+	)
+	mem := newFakeMemory(base,
+		// s
+		base+strdataOff, uint64(5),
+		// sl
+		base+slicedatOff, uint64(3), uint64(4),
+		// m, ch
+		base+hashmapOff, base+channelOff,
+		// runtime.hashmap: buckets, count, keySize, valueSize, bucketBits
+		base+bucketOff, uint64(2), uint64(2), uint64(1), uint64(0),
+		// runtime.channel: elementSize, bufSize, bufUsed, buf
+		uint64(8), uint64(4), uint64(1), uint64(0),
+		// bucket: tophash, next, keys, values
+		[8]uint8{1, 0, 7, 0, 0, 0, 0, 0}, uint64(0),
+		[8]uint16{0x1234, 0, 0xabcd, 0, 0, 0, 0, 0},
+		[8]uint8{0x56, 0, 0x78, 0, 0, 0, 0, 0},
+		[]byte("hello\x00\x00\x00"),
+		[4]int64{1, 2, 3, 0})
+
+	regs := linutil.AMD64Registers{Regs: &linutil.AMD64PtraceRegs{Rip: 0x40100}}
+	scope := &proc.EvalScope{Location: proc.Location{PC: 0x40100, Fn: mainfn}, Regs: dwarfRegisters(bi, &regs), Mem: mem, BinInfo: bi}
+
+	eval := func(expr string) *proc.Variable {
+		t.Helper()
+		v, err := scope.EvalExpression(expr, normalLoadConfig)
+		assertNoError(err, t, fmt.Sprintf("EvalExpression(%s)", expr))
+		if v.Unreadable != nil {
+			t.Fatalf("%s unreadable: %v", expr, v.Unreadable)
+		}
+		return v
+	}
+
+	if s := eval("s"); s.Kind != reflect.String || constant.StringVal(s.Value) != "hello" {
+		t.Errorf("wrong value for s: %v %v", s.Kind, s.Value)
+	}
+
+	sl := eval("sl")
+	if sl.Kind != reflect.Slice || sl.Len != 3 || sl.Cap != 4 || len(sl.Children) != 3 {
+		t.Fatalf("wrong value for sl: %v len=%d cap=%d %d children", sl.Kind, sl.Len, sl.Cap, len(sl.Children))
+	}
+	for i := range sl.Children {
+		if n, _ := constant.Int64Val(sl.Children[i].Value); n != int64(i+1) {
+			t.Errorf("wrong value for sl[%d]: %d", i, n)
+		}
+	}
+
+	m := eval("*m")
+	if m.DecodedKind != reflect.Map || m.Len != 2 || len(m.Children) != 4 {
+		t.Fatalf("wrong value for *m: %v len=%d %d children", m.DecodedKind, m.Len, len(m.Children))
+	}
+	for i, tgt := range [][]uint64{{0x34, 0x12}, {0x56}, {0xcd, 0xab}, {0x78}} {
+		child := m.Children[i]
+		if len(child.Children) != len(tgt) {
+			t.Errorf("wrong size for map entry %d: %d", i, len(child.Children))
+			continue
+		}
+		for j := range tgt {
+			if n, _ := constant.Uint64Val(child.Children[j].Value); n != tgt[j] {
+				t.Errorf("wrong value for byte %d of map entry %d: %#x", j, i, n)
+			}
+		}
+	}
+
+	ch := eval("*ch")
+	if ch.DecodedKind != reflect.Chan || ch.Len != 1 || ch.Cap != 4 {
+		t.Errorf("wrong value for *ch: %v %d/%d", ch.DecodedKind, ch.Len, ch.Cap)
+	}
+}
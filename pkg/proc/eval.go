@@ -235,6 +235,7 @@ func (scope *EvalScope) Locals() ([]*Variable, error) {
 			addr = uint64(alignAddr(int64(addr), val.DwarfType.Align()))
 			val = newVariable(val.Name, addr, val.DwarfType, scope.BinInfo, scope.Mem)
 		}
+		val.ScopeStartLine, val.ScopeEndLine = scope.blockLines(entry.Scope)
 		vars = append(vars, val)
 		depth := entry.Depth
 		if entry.Tag == dwarf.TagFormalParameter {
@@ -263,6 +264,7 @@ func (scope *EvalScope) Locals() ([]*Variable, error) {
 		if name := v.Name; len(name) > 1 && name[0] == '&' {
 			locationExpr := v.LocationExpr
 			declLine := v.DeclLine
+			scopeStartLine, scopeEndLine := v.ScopeStartLine, v.ScopeEndLine
 			v = v.maybeDereference()
 			if v.Addr == 0 && v.Unreadable == nil {
 				v.Unreadable = fmt.Errorf("no address for escaped variable")
@@ -275,6 +277,7 @@ func (scope *EvalScope) Locals() ([]*Variable, error) {
 				v.LocationExpr = locationExpr
 			}
 			v.DeclLine = declLine
+			v.ScopeStartLine, v.ScopeEndLine = scopeStartLine, scopeEndLine
 			vars[i] = v
 		}
 		if otherv := lvn[v.Name]; otherv != nil {
@@ -286,6 +289,23 @@ func (scope *EvalScope) Locals() ([]*Variable, error) {
 	return vars, nil
 }
 
+// blockLines returns the lines of the first and last instructions of
+// block.
+func (scope *EvalScope) blockLines(block *godwarf.Tree) (start, end int) {
+	if block == nil || len(block.Ranges) == 0 {
+		return 0, 0
+	}
+	lowpc, highpc := block.Ranges[0][0], block.Ranges[len(block.Ranges)-1][1]
+	_, start, _ = scope.BinInfo.PCToLine(lowpc)
+	_, end, _ = scope.BinInfo.PCToLine(highpc - 1)
+	if end < start {
+		// the last instruction of the block is not necessarily the one with
+		// the highest line number
+		end = start
+	}
+	return start, end
+}
+
 func afterLastArgAddr(vars []*Variable) uint64 {
 	for i := len(vars) - 1; i >= 0; i-- {
 		v := vars[i]
@@ -379,6 +399,142 @@ func (scope *EvalScope) EvalVariable(name string, cfg LoadConfig) (*Variable, er
 	return scope.EvalExpression(name, cfg)
 }
 
+// LoadArrayRange loads elements [start, end) of the slice or array of type
+// typ stored at addr. The returned variable is a slice containing exactly
+// those elements, regardless of cfg.MaxArrayValues (which still applies to
+// the elements themselves), end is clamped to the length of the slice or
+// array.
+// This allows clients to page through large slices and arrays without
+// evaluating an indexing expression for every element.
+func (scope *EvalScope) LoadArrayRange(addr uint64, typ string, start, end int64, cfg LoadConfig) (*Variable, error) {
+	t, err := scope.BinInfo.findTypeExpr(&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(typ)})
+	if err != nil {
+		return nil, err
+	}
+	v := newVariable("", addr, t, scope.BinInfo, scope.Mem)
+	if v.Kind != reflect.Slice && v.Kind != reflect.Array {
+		return nil, fmt.Errorf("can not load a range of elements of type %s", typ)
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if end > v.Len {
+		end = v.Len
+	}
+	if start < 0 || start > end {
+		return nil, fmt.Errorf("index out of bounds")
+	}
+	if start == end {
+		// reslice does not allow empty slices past the last element
+		r := v.newVariable("", 0, fakeSliceType(v.fieldType), v.mem)
+		r.Base = v.Base + uint64(start*v.stride)
+		r.stride = v.stride
+		r.fieldType = v.fieldType
+		r.loaded = true
+		return r, nil
+	}
+	r, err := v.reslice(start, end)
+	if err != nil {
+		return nil, err
+	}
+	r.loaded = true
+	r.loadArrayElements(r.Len, 0, cfg)
+	return r, nil
+}
+
+// ViewAs evaluates expr, which must be a pointer, an unsafe.Pointer or an
+// integer, and returns a variable of type typ at the address it evaluates
+// to. If typ is a pointer type the returned variable is a pointer to the
+// address instead, i.e. "ViewAs(addr, *T)" is the same as evaluating
+// "(*T)(addr)" and "ViewAs(addr, T)" the same as "*(*T)(addr)".
+// This is used to inspect memory obtained through unsafe code or foreign
+// allocators, typ can be any type known to the target, including types
+// specified with their full package path.
+func (scope *EvalScope) ViewAs(expr, typ string, cfg LoadConfig) (*Variable, error) {
+	av, err := scope.EvalExpression(expr, loadSingleValue)
+	if err != nil {
+		return nil, err
+	}
+	if av.Unreadable != nil {
+		return nil, av.Unreadable
+	}
+	var addr uint64
+	switch av.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(av.Children) != 1 {
+			return nil, fmt.Errorf("can not read pointer %s", expr)
+		}
+		addr = av.Children[0].Addr
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := av.asInt()
+		if err != nil {
+			return nil, err
+		}
+		addr = uint64(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		addr, err = av.asUint()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s (type %s) is not an address", expr, av.TypeString())
+	}
+
+	t, err := scope.findViewType(typ)
+	if err != nil {
+		return nil, err
+	}
+	if pt, isptr := resolveTypedef(t).(*godwarf.PtrType); isptr {
+		v := newVariable("", 0, t, scope.BinInfo, scope.Mem)
+		v.loaded = true
+		v.Len = 1
+		v.Children = []Variable{*newVariable("", addr, pt.Type, scope.BinInfo, scope.Mem)}
+		if cfg.FollowPointers && addr != 0 {
+			v.Children[0].loadValue(cfg)
+		} else {
+			v.Children[0].OnlyAddr = true
+		}
+		return v, nil
+	}
+	if addr == 0 {
+		return nil, errors.New("nil pointer dereference")
+	}
+	v := newVariable("", addr, t, scope.BinInfo, scope.Mem)
+	v.loadValue(cfg)
+	return v, nil
+}
+
+// findViewType returns the type described by typ, which is either a Go type
+// expression or a type name as it appears in DWARF, optionally preceded by
+// pointer and array type modifiers.
+func (scope *EvalScope) findViewType(typ string) (godwarf.Type, error) {
+	if texpr, err := parser.ParseExpr(typ); err == nil {
+		if t, err := scope.BinInfo.findTypeExpr(texpr); err == nil {
+			return t, nil
+		}
+	}
+	if strings.HasPrefix(typ, "*") {
+		t, err := scope.findViewType(typ[1:])
+		if err != nil {
+			return nil, err
+		}
+		return pointerTo(t, scope.BinInfo.Arch), nil
+	}
+	if strings.HasPrefix(typ, "[") {
+		if i := strings.Index(typ, "]"); i > 0 {
+			n, err := strconv.ParseUint(typ[1:i], 0, 64)
+			if err == nil {
+				t, err := scope.findViewType(typ[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				return fakeArrayType(n, t), nil
+			}
+		}
+	}
+	return scope.BinInfo.findTypeExpr(&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(typ)})
+}
+
 // SetVariable sets the value of the named variable
 func (scope *EvalScope) SetVariable(name, value string) error {
 	t, err := parser.ParseExpr(name)
@@ -726,15 +882,24 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 		// try to interpret the selector as a package variable
 		if maybePkg, ok := node.X.(*ast.Ident); ok {
 			if maybePkg.Name == "runtime" && node.Sel.Name == "curg" {
-				if scope.g == nil {
-					typ, err := scope.BinInfo.findType("runtime.g")
+				if scope.g == nil || scope.BinInfo.isTinyGo() {
+					// TinyGo goroutines are not runtime.g structs and have no goid
+					// field, their (fake) ID is used instead.
+					gtyp, goid := "runtime.g", 0
+					if scope.BinInfo.isTinyGo() {
+						gtyp = "internal/task.Task"
+					}
+					if scope.g != nil {
+						goid = scope.g.ID
+					}
+					typ, err := scope.BinInfo.findType(gtyp)
 					if err != nil {
 						return nil, fmt.Errorf("blah: %v", err)
 					}
 					gvar := newVariable("curg", fakeAddress, typ, scope.BinInfo, scope.Mem)
 					gvar.loaded = true
 					gvar.Flags = VariableFakeAddress
-					gvar.Children = append(gvar.Children, *newConstant(constant.MakeInt64(0), scope.Mem))
+					gvar.Children = append(gvar.Children, *newConstant(constant.MakeInt64(int64(goid)), scope.Mem))
 					gvar.Children[0].Name = "goid"
 					return gvar, nil
 				}
@@ -991,7 +1156,7 @@ func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		if arg.Base == 0 {
 			return newConstant(constant.MakeInt64(0), arg.mem), nil
 		}
-		return newConstant(arg.Children[1].Value, arg.mem), nil
+		return newConstant(constant.MakeInt64(arg.Cap), arg.mem), nil
 	default:
 		return nil, invalidArgErr
 	}
@@ -1024,7 +1189,7 @@ func lenBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		if arg.Base == 0 {
 			return newConstant(constant.MakeInt64(0), arg.mem), nil
 		}
-		return newConstant(arg.Children[0].Value, arg.mem), nil
+		return newConstant(constant.MakeInt64(arg.Len), arg.mem), nil
 	case reflect.Map:
 		it := arg.mapIterator()
 		if arg.Unreadable != nil {
@@ -1178,6 +1343,12 @@ func (scope *EvalScope) evalStructSelector(node *ast.SelectorExpr) (*Variable, e
 	if rv != nil {
 		return rv, nil
 	}
+	if xv.Kind == reflect.Chan {
+		rv, err := xv.chanStateMember(node.Sel.Name)
+		if rv != nil || err != nil {
+			return rv, err
+		}
+	}
 	return xv.structMember(node.Sel.Name)
 }
 
@@ -1256,6 +1427,21 @@ func (scope *EvalScope) evalIndex(node *ast.IndexExpr) (*Variable, error) {
 		fallthrough
 
 	case reflect.Slice, reflect.Array, reflect.String:
+		if xev.Kind == reflect.Array && xev.loaded && xev.Flags&VariableFakeAddress != 0 {
+			// arrays that are not in memory, for example the buffer of a
+			// channel, see loadChanState.
+			n, err := idxev.asInt()
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 || n >= xev.Len {
+				return nil, fmt.Errorf("index out of bounds")
+			}
+			if n >= int64(len(xev.Children)) {
+				return nil, fmt.Errorf("element %d of \"%s\" was not loaded", n, exprToString(node.X))
+			}
+			return &xev.Children[n], nil
+		}
 		if xev.Base == 0 {
 			return nil, fmt.Errorf("can not index \"%s\"", exprToString(node.X))
 		}
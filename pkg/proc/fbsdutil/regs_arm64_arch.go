@@ -0,0 +1,167 @@
+package fbsdutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// ARM64Registers implements the proc.Registers interface for the
+// native/freebsd backend, on ARM64.
+type ARM64Registers struct {
+	Regs     *ARM64PtraceRegs
+	Fpregs   []proc.Register
+	Fpregset *ARM64PtraceFpRegs
+
+	loadFpRegs func(*ARM64Registers) error
+}
+
+func NewARM64Registers(regs *ARM64PtraceRegs, loadFpRegs func(*ARM64Registers) error) *ARM64Registers {
+	return &ARM64Registers{Regs: regs, loadFpRegs: loadFpRegs}
+}
+
+// ARM64PtraceRegs is the struct used by the freebsd kernel to return the
+// general purpose registers for ARM64 CPUs.
+// source: sys/arm64/include/reg.h
+type ARM64PtraceRegs struct {
+	X    [30]uint64
+	Lr   uint64
+	Sp   uint64
+	Elr  uint64
+	Spsr uint32
+	_    [4]byte
+}
+
+// Slice returns the registers as a list of (name, value) pairs.
+func (r *ARM64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	out := make([]proc.Register, 0, len(r.Regs.X)+4+len(r.Fpregs))
+	for i, v := range r.Regs.X {
+		out = proc.AppendUint64Register(out, fmt.Sprintf("X%d", i), v)
+	}
+	out = proc.AppendUint64Register(out, "X30", r.Regs.Lr)
+	out = proc.AppendUint64Register(out, "SP", r.Regs.Sp)
+	out = proc.AppendUint64Register(out, "PC", r.Regs.Elr)
+	out = proc.AppendUint64Register(out, "PSTATE", uint64(r.Regs.Spsr))
+	var floatLoadError error
+	if floatingPoint {
+		if r.loadFpRegs != nil {
+			floatLoadError = r.loadFpRegs(r)
+			r.loadFpRegs = nil
+		}
+		out = append(out, r.Fpregs...)
+	}
+	return out, floatLoadError
+}
+
+// PC returns the value of the PC register.
+func (r *ARM64Registers) PC() uint64 {
+	return r.Regs.Elr
+}
+
+// SP returns the value of the SP register.
+func (r *ARM64Registers) SP() uint64 {
+	return r.Regs.Sp
+}
+
+func (r *ARM64Registers) BP() uint64 {
+	return r.Regs.X[29]
+}
+
+// TLS returns the address of the thread local storage memory segment.
+func (r *ARM64Registers) TLS() uint64 {
+	return 0
+}
+
+// GAddr returns the address of the G variable if it is known, 0 and false
+// otherwise.
+func (r *ARM64Registers) GAddr() (uint64, bool) {
+	return r.Regs.X[28], true
+}
+
+// Get returns the value of the n-th register (in arm64asm order).
+func (r *ARM64Registers) Get(n int) (uint64, error) {
+	reg := arm64asm.Reg(n)
+
+	switch {
+	case reg >= arm64asm.X0 && reg < arm64asm.X30:
+		return r.Regs.X[reg-arm64asm.X0], nil
+	case reg == arm64asm.X30:
+		return r.Regs.Lr, nil
+	}
+
+	return 0, proc.ErrUnknownRegister
+}
+
+// SetReg changes the value of the register called name (as returned by
+// Slice) in r, it does not change the registers of the thread.
+func (r *ARM64Registers) SetReg(name string, value uint64) error {
+	name = strings.ToUpper(name)
+	switch name {
+	case "X30", "LR":
+		r.Regs.Lr = value
+	case "SP":
+		r.Regs.Sp = value
+	case "PC":
+		r.Regs.Elr = value
+	case "PSTATE":
+		r.Regs.Spsr = uint32(value)
+	default:
+		if !strings.HasPrefix(name, "X") {
+			return proc.ErrUnknownRegister
+		}
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n < 0 || n >= len(r.Regs.X) {
+			return proc.ErrUnknownRegister
+		}
+		r.Regs.X[n] = value
+	}
+	return nil
+}
+
+// Copy returns a copy of these registers that is guaranteed not to change.
+func (r *ARM64Registers) Copy() (proc.Registers, error) {
+	if r.loadFpRegs != nil {
+		err := r.loadFpRegs(r)
+		r.loadFpRegs = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rr ARM64Registers
+	rr.Regs = &ARM64PtraceRegs{}
+	*(rr.Regs) = *(r.Regs)
+	if r.Fpregs != nil {
+		rr.Fpregs = make([]proc.Register, len(r.Fpregs))
+		copy(rr.Fpregs, r.Fpregs)
+	}
+	if r.Fpregset != nil {
+		rr.Fpregset = &ARM64PtraceFpRegs{}
+		*(rr.Fpregset) = *(r.Fpregset)
+	}
+	return &rr, nil
+}
+
+// ARM64PtraceFpRegs is the struct used by the freebsd kernel to return the
+// floating point registers for ARM64 CPUs.
+// source: sys/arm64/include/reg.h
+type ARM64PtraceFpRegs struct {
+	Vregs [32][16]byte
+	Fpsr  uint32
+	Fpcr  uint32
+	_     [8]byte
+}
+
+// Decode returns the floating point registers as a list of (name, value)
+// pairs.
+func (fpregs *ARM64PtraceFpRegs) Decode() (regs []proc.Register) {
+	for i := range fpregs.Vregs {
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("V%d", i), fpregs.Vregs[i][:])
+	}
+	regs = proc.AppendUint64Register(regs, "FPSR", uint64(fpregs.Fpsr))
+	regs = proc.AppendUint64Register(regs, "FPCR", uint64(fpregs.Fpcr))
+	return
+}
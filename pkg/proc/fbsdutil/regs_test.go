@@ -2,7 +2,9 @@ package fbsdutil
 
 import (
 	"testing"
+	"unsafe"
 
+	"golang.org/x/arch/arm64/arm64asm"
 	"golang.org/x/arch/x86/x86asm"
 )
 
@@ -58,3 +60,46 @@ func TestAMD64Get(t *testing.T) {
 		t.Fatalf("expected %#v, got %#v\n", val, rax)
 	}
 }
+
+func TestARM64Get(t *testing.T) {
+	regs := ARM64Registers{
+		Regs: &ARM64PtraceRegs{
+			Lr:  0x1234,
+			Elr: 0x5678,
+		},
+	}
+	regs.Regs.X[28] = 0xdeadbeef
+	x28, err := regs.Get(int(arm64asm.X28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x28 != 0xdeadbeef {
+		t.Fatalf("expected %#v, got %#v\n", uint64(0xdeadbeef), x28)
+	}
+	if g, _ := regs.GAddr(); g != 0xdeadbeef {
+		t.Fatalf("expected G address %#v, got %#v\n", uint64(0xdeadbeef), g)
+	}
+
+	// X30 is the link register, stored separately by the kernel
+	x30, err := regs.Get(int(arm64asm.X30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x30 != 0x1234 {
+		t.Fatalf("expected %#v, got %#v\n", 0x1234, x30)
+	}
+	if regs.PC() != 0x5678 {
+		t.Fatalf("expected PC %#v, got %#v\n", 0x5678, regs.PC())
+	}
+}
+
+func TestARM64PtraceRegsSize(t *testing.T) {
+	// must match the size of struct reg and struct fpreg in
+	// sys/arm64/include/reg.h
+	if sz := unsafe.Sizeof(ARM64PtraceRegs{}); sz != 272 {
+		t.Errorf("wrong size of ARM64PtraceRegs: %d", sz)
+	}
+	if sz := unsafe.Sizeof(ARM64PtraceFpRegs{}); sz != 528 {
+		t.Errorf("wrong size of ARM64PtraceFpRegs: %d", sz)
+	}
+}
@@ -50,6 +50,7 @@ var (
 	errFuncCallUnsupported        = errors.New("function calls not supported by this version of Go")
 	errFuncCallUnsupportedBackend = errors.New("backend does not support function calls")
 	errFuncCallInProgress         = errors.New("cannot call function while another function call is already in progress")
+	errNoFuncCallInProgress       = errors.New("no function call in progress")
 	errNotACallExpr               = errors.New("not a function call")
 	errNoGoroutine                = errors.New("no goroutine selected")
 	errGoroutineNotRunning        = errors.New("selected goroutine not running")
@@ -58,6 +59,7 @@ var (
 	errNotEnoughArguments         = errors.New("not enough arguments")
 	errNoAddrUnsupported          = errors.New("arguments to a function call must have an address")
 	errNotAGoFunction             = errors.New("not a Go function")
+	errFuncCallCancelled          = errors.New("function call cancelled")
 	errFuncCallNotAllowed         = errors.New("function calls not allowed without using 'call'")
 	errFuncCallNotAllowedStrAlloc = errors.New("literal string can not be allocated because function calls are not allowed without using 'call'")
 )
@@ -130,6 +132,9 @@ type callInjection struct {
 	continueCompleted chan<- *G
 	continueRequest   <-chan continueRequest
 	startThreadID     int
+	// cancelled is set by CancelFunctionCall, the function is not called if
+	// it wasn't already and its return values are discarded.
+	cancelled bool
 }
 
 func (callCtx *callContext) doContinue() *G {
@@ -206,7 +211,11 @@ func finishEvalExpressionWithCalls(t *Target, g *G, contReq continueRequest, ok
 	fncallLog("stashing return values for %d in thread=%d", g.ID, g.Thread.ThreadID())
 	g.Thread.Common().CallReturn = true
 	var err error
-	if !ok {
+	if t.fncallForG[g.ID].cancelled {
+		fncallLog("call on goroutine %d was cancelled, discarding return values", g.ID)
+		g.Thread.Common().CallReturn = false
+		g.Thread.Common().returnValues = nil
+	} else if !ok {
 		err = errors.New("internal error EvalExpressionWithCalls didn't return anything")
 	} else if contReq.err != nil {
 		if fpe, ispanic := contReq.err.(fncallPanicErr); ispanic {
@@ -232,6 +241,25 @@ func finishEvalExpressionWithCalls(t *Target, g *G, contReq continueRequest, ok
 	return err
 }
 
+// CancelFunctionCall cancels the function call injected in goroutine g by
+// EvalExpressionWithCalls, which is still in progress because the target
+// stopped before it completed.
+// The call can not be interrupted while the target is stopped, but when
+// the target is resumed the injection protocol is driven to completion
+// without calling the function, if it was not called yet, and the return
+// values are discarded. Once the called function returns the goroutine
+// continues from where it was when the call was injected.
+// Until then it is not possible to inject another call in g.
+func (t *Target) CancelFunctionCall(g *G) error {
+	callinj := t.fncallForG[g.ID]
+	if callinj == nil || callinj.continueCompleted == nil {
+		return errNoFuncCallInProgress
+	}
+	fncallLog("cancelling call on goroutine %d", g.ID)
+	callinj.cancelled = true
+	return nil
+}
+
 // evalFunctionCall evaluates a function call.
 // If this is a built-in function it's evaluated directly.
 // Otherwise this will start the function call injection protocol and
@@ -250,6 +278,10 @@ func evalFunctionCall(scope *EvalScope, node *ast.CallExpr) (*Variable, error) {
 	if scope.callCtx == nil {
 		return nil, errFuncCallNotAllowed
 	}
+	if callinj := scope.callCtx.p.fncallForG[scope.g.ID]; callinj != nil && callinj.cancelled {
+		// a previous call in the same expression was cancelled
+		return nil, errFuncCallCancelled
+	}
 	thread := scope.g.Thread
 	stacklo := scope.g.stack.lo
 	if thread == nil {
@@ -456,7 +488,7 @@ func funcCallEvalFuncExpr(scope *EvalScope, fncall *functionCallState, allowCall
 	if fnvar.Kind != reflect.Func {
 		return fmt.Errorf("expression %q is not a function", exprToString(fncall.expr.Fun))
 	}
-	fnvar.loadValue(LoadConfig{false, 0, 0, 0, 0, 0})
+	fnvar.loadValue(LoadConfig{false, 0, 0, 0, 0, 0, false, 0})
 	if fnvar.Unreadable != nil {
 		return fnvar.Unreadable
 	}
@@ -735,6 +767,13 @@ func funcCallStep(callScope *EvalScope, fncall *functionCallState, thread Thread
 
 	case debugCallAXCompleteCall:
 		p.fncallForG[callScope.g.ID].startThreadID = 0
+		if p.fncallForG[callScope.g.ID].cancelled {
+			// do not call the function, the runtime will proceed to restore the
+			// registers
+			fncall.err = errFuncCallCancelled
+			fncall.lateCallFailure = true
+			break
+		}
 		// evaluate arguments of the target function, copy them into its argument frame and call the function
 		if fncall.fn == nil || fncall.receiver != nil || fncall.closureAddr != 0 {
 			// if we couldn't figure out which function we are calling before
@@ -797,7 +836,7 @@ func funcCallStep(callScope *EvalScope, fncall *functionCallState, thread Thread
 
 	case debugCallAXReadReturn:
 		// read return arguments from stack
-		if fncall.panicvar != nil || fncall.lateCallFailure {
+		if fncall.panicvar != nil || fncall.lateCallFailure || p.fncallForG[callScope.g.ID].cancelled {
 			break
 		}
 		retScope, err := ThreadScope(thread)
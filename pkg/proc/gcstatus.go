@@ -0,0 +1,145 @@
+package proc
+
+import (
+	"errors"
+	"go/constant"
+	"reflect"
+)
+
+// GCPhase is the phase of the garbage collector of the target process, it
+// mirrors the value of runtime.gcphase.
+type GCPhase uint8
+
+const (
+	GCOff             GCPhase = iota // GC not running, or sweeping in the background
+	GCMark                           // GC marking roots and workbufs
+	GCMarkTermination                // GC mark termination, the world is stopped
+)
+
+func (phase GCPhase) String() string {
+	switch phase {
+	case GCOff:
+		return "off"
+	case GCMark:
+		return "mark"
+	case GCMarkTermination:
+		return "mark termination"
+	default:
+		return "unknown"
+	}
+}
+
+// GCStatus describes the state of the garbage collector of the target
+// process.
+type GCStatus struct {
+	Phase GCPhase
+	// NumGC is the number of completed GC cycles.
+	NumGC uint64
+	// StopTheWorld is true if the runtime is stopping, or has stopped, the
+	// world.
+	StopTheWorld bool
+	// Workers lists the IDs of the GC background mark worker goroutines
+	// currently running on a thread.
+	Workers []int
+}
+
+// GCStatus returns the current state of the garbage collector of the
+// target process. The status is computed once per stop, the returned value
+// must not be modified.
+func (t *Target) GCStatus() (*GCStatus, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	if t.gcStatus != nil {
+		return t.gcStatus, nil
+	}
+	scope := globalScope(t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+
+	phasev, err := scope.findGlobal("runtime", "gcphase")
+	if err != nil {
+		return nil, err
+	}
+	phase, err := loadUintValue(phasev)
+	if err != nil {
+		return nil, err
+	}
+	status := &GCStatus{Phase: GCPhase(phase)}
+
+	// The following are best effort, their definition changed between
+	// versions of Go.
+	if memstatsv, err := scope.findGlobal("runtime", "memstats"); err == nil {
+		if numgcv, err := memstatsv.structMember("numgc"); err == nil {
+			status.NumGC, _ = loadUintValue(numgcv)
+		}
+	}
+	if schedv, err := scope.findGlobal("runtime", "sched"); err == nil {
+		if gcwaitingv, err := schedv.structMember("gcwaiting"); err == nil {
+			gcwaiting, _ := loadUintValue(gcwaitingv)
+			status.StopTheWorld = gcwaiting != 0
+		}
+	}
+
+	for _, th := range t.ThreadList() {
+		g, _ := GetG(th)
+		if g == nil {
+			continue
+		}
+		if fn := t.BinInfo().PCToFunc(g.StartPC); fn != nil && fn.Name == "runtime.gcBgMarkWorker" {
+			status.Workers = append(status.Workers, g.ID)
+		}
+	}
+
+	t.gcStatus = status
+	return status, nil
+}
+
+// unwrapAtomic returns the value wrapped by v, if v is one of the structs
+// used by the runtime to wrap integers (for example the types of package
+// internal/runtime/atomic or runtime.mSpanStateBox), otherwise it returns
+// v. The returned value is not loaded.
+func unwrapAtomic(v *Variable) *Variable {
+	for v.Kind == reflect.Struct {
+		var inner *Variable
+		var err error
+		for _, name := range []string{"s", "value", "v"} {
+			inner, err = v.structMember(name)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		v = inner
+	}
+	return v
+}
+
+// loadUintValue loads v and returns its value, v must be an unsigned
+// integer, a boolean or a struct wrapping one of them in a field called v
+// (like the types of package runtime/internal/atomic).
+func loadUintValue(v *Variable) (uint64, error) {
+	if v.Kind == reflect.Struct {
+		fieldv, err := v.structMember("v")
+		if err != nil {
+			return 0, err
+		}
+		v = fieldv
+	}
+	v.loadValue(loadSingleValue)
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	switch v.Value.Kind() {
+	case constant.Bool:
+		if constant.BoolVal(v.Value) {
+			return 1, nil
+		}
+		return 0, nil
+	case constant.Int:
+		n, _ := constant.Uint64Val(v.Value)
+		return n, nil
+	default:
+		return 0, errors.New("not an integer")
+	}
+}
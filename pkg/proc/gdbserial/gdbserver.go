@@ -58,11 +58,15 @@
 // unavailable but the inferior is run in single threaded mode.
 //
 // Therefore the following code will assume lldb-server-like behavior.
+// Non-stop mode can be enabled explicitly with SetNonStop, on stubs that
+// support it, to stop and resume threads individually.
 
 package gdbserial
 
 import (
 	"bytes"
+	"crypto/tls"
+	"debug/elf"
 	"debug/macho"
 	"encoding/binary"
 	"errors"
@@ -159,9 +163,12 @@ type gdbProcess struct {
 	waitChan chan *os.ProcessState
 
 	onDetach func() // called after a successful detach
+
+	signalPolicies map[int]proc.SignalPolicy // policies used to handle signals, see proc.SignalPolicy
 }
 
 var _ proc.ProcessInternal = &gdbProcess{}
+var _ proc.NonStopController = &gdbProcess{}
 
 // gdbThread represents an operating system thread.
 type gdbThread struct {
@@ -194,6 +201,7 @@ type gdbRegisters struct {
 	hasgaddr bool
 	buf      []byte
 	arch     *proc.Arch
+	names    *gdbArchInfo
 }
 
 type gdbRegister struct {
@@ -271,6 +279,26 @@ func (p *gdbProcess) Dial(addr string, path string, pid int, debugInfoDirs []str
 	}
 }
 
+// Remote connects to a stub listening at addr that is already attached to
+// the target process. If tlsConfig is not nil the connection is secured
+// with TLS, this allows stubs to be reached through a TLS terminating proxy
+// on other machines.
+// Path and pid have the same meaning they have for Connect.
+func Remote(addr string, path string, pid int, tlsConfig *tls.Config, debugInfoDirs []string) (*proc.Target, error) {
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := newProcess(nil)
+	return p.Connect(conn, path, pid, debugInfoDirs, proc.StopAttached)
+}
+
 // Connect connects to a stub and performs a handshake.
 //
 // Path and pid are, respectively, the path to the executable of the target
@@ -286,6 +314,12 @@ func (p *gdbProcess) Connect(conn net.Conn, path string, pid int, debugInfoDirs
 		return nil, err
 	}
 
+	if p.conn.goarch != p.bi.Arch.Name {
+		// The architecture of the target is different from ours, this happens
+		// for example when the stub is qemu-user.
+		p.bi = proc.NewBinaryInfo(p.bi.GOOS, p.conn.goarch)
+	}
+
 	if verbuf, err := p.conn.exec([]byte("$qGDBServerVersion"), "init"); err == nil {
 		for _, v := range strings.Split(string(verbuf), ";") {
 			if strings.HasPrefix(v, "version:") {
@@ -655,9 +689,61 @@ func (p *gdbProcess) initialize(path string, debugInfoDirs []string, stopReason
 		p.conn.conn.Close()
 		return nil, err
 	}
+
+	// updateSharedObjects can only be done after NewTarget because it needs
+	// an initialized BinaryInfo object to work. Not being able to read the
+	// list of shared libraries shouldn't stop us from debugging the
+	// executable.
+	if err := p.updateSharedObjects(); err != nil {
+		p.conn.log.Errorf("could not read shared libraries: %v", err)
+	}
 	return tgt, nil
 }
 
+// updateSharedObjects updates the list of shared libraries loaded by the
+// target. If the stub supports it the list is requested with
+// qXfer:libraries-svr4:read or qXfer:libraries:read, otherwise on linux it
+// is read from the rendezvous structure of the dynamic linker.
+func (p *gdbProcess) updateSharedObjects() error {
+	if !p.conn.librariesSvr4 && !p.conn.libraries {
+		if p.bi.GOOS == "linux" {
+			return linutil.ElfUpdateSharedObjects(p)
+		}
+		return nil
+	}
+	libs, err := p.conn.readLibraries()
+	if err != nil {
+		return err
+	}
+	for _, lib := range libs {
+		addr := lib.addr
+		if lib.segment {
+			addr, err = elfLoadBias(lib.path, lib.addr)
+			if err != nil {
+				continue
+			}
+		}
+		p.bi.AddImage(lib.path, addr)
+	}
+	return nil
+}
+
+// elfLoadBias returns the load bias of the ELF file at path given the
+// address where its first loadable segment was mapped.
+func elfLoadBias(path string, segaddr uint64) (uint64, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD {
+			return segaddr - prog.Vaddr, nil
+		}
+	}
+	return segaddr, nil
+}
+
 func queryProcessInfo(p *gdbProcess, pid int) (int, string, error) {
 	pi, err := p.conn.queryProcessInfo(pid)
 	if err != nil {
@@ -745,6 +831,9 @@ func (p *gdbProcess) ContinueOnce() (proc.Thread, proc.StopReason, error) {
 	if p.exited {
 		return nil, proc.StopExited, &proc.ErrProcessExited{Pid: p.conn.pid}
 	}
+	if p.conn.nonStop {
+		return nil, proc.StopUnknown, errNonStopMode
+	}
 
 	if p.conn.direction == proc.Forward {
 		// step threads stopped at any breakpoint over their breakpoint
@@ -806,10 +895,8 @@ continueLoop:
 		stopReason = proc.StopLaunched
 	}
 
-	if p.BinInfo().GOOS == "linux" {
-		if err := linutil.ElfUpdateSharedObjects(p); err != nil {
-			return nil, stopReason, err
-		}
+	if err := p.updateSharedObjects(); err != nil {
+		p.conn.log.Errorf("could not read shared libraries: %v", err)
 	}
 
 	if err := p.setCurrentBreakpoints(); err != nil {
@@ -843,6 +930,171 @@ continueLoop:
 	return trapthread, stopReason, err
 }
 
+var (
+	errNonStopMode    = errors.New("operation not supported in non-stop mode")
+	errNotNonStopMode = errors.New("operation only supported in non-stop mode")
+)
+
+// NonStopSupported returns true if the stub supports non-stop mode.
+func (p *gdbProcess) NonStopSupported() bool {
+	return p.conn.nonStopSupported
+}
+
+// SetNonStop enables or disables non-stop mode, if the stub supports it.
+// While non-stop mode is enabled threads are resumed and stopped
+// individually, using ResumeThread and StopThread, their stops are
+// reported by WaitThreadStop and ContinueOnce can not be used.
+func (p *gdbProcess) SetNonStop(enabled bool) error {
+	if p.exited {
+		return &proc.ErrProcessExited{Pid: p.conn.pid}
+	}
+	if !p.conn.nonStopSupported {
+		return proc.ErrNonStopNotSupported
+	}
+	if p.conn.direction != proc.Forward {
+		return errors.New("non-stop mode not supported when executing backwards")
+	}
+	return p.conn.setNonStop(enabled)
+}
+
+// ResumeThread resumes the thread with the specified ID in non-stop mode.
+// If the thread is stopped at a breakpoint it is stepped over it first.
+func (p *gdbProcess) ResumeThread(tid int) error {
+	if !p.conn.nonStop {
+		return errNotNonStopMode
+	}
+	th, ok := p.threads[tid]
+	if !ok {
+		return fmt.Errorf("could not find thread %d", tid)
+	}
+	if th.CurrentBreakpoint.Breakpoint != nil {
+		if err := th.StepInstruction(); err != nil {
+			return err
+		}
+	}
+	th.clearBreakpointState()
+	th.regs.regs = nil
+	sig := th.sig
+	th.sig = 0
+	return p.conn.resumeThread(th.strID, sig)
+}
+
+// StopThread requests that the thread with the specified ID is stopped, in
+// non-stop mode. The stop is reported by WaitThreadStop.
+func (p *gdbProcess) StopThread(tid int) error {
+	if !p.conn.nonStop {
+		return errNotNonStopMode
+	}
+	th, ok := p.threads[tid]
+	if !ok {
+		return fmt.Errorf("could not find thread %d", tid)
+	}
+	return p.conn.stopThread(th.strID)
+}
+
+// WaitThreadStop waits until a thread stops, in non-stop mode, and returns
+// it. Threads that were not known before are added to the thread list.
+func (p *gdbProcess) WaitThreadStop() (proc.Thread, error) {
+	if !p.conn.nonStop {
+		return nil, errNotNonStopMode
+	}
+	sp, err := p.conn.waitForStopNotification("", nil)
+	if err != nil {
+		if _, exited := err.(proc.ErrProcessExited); exited {
+			p.exited = true
+		}
+		return nil, err
+	}
+	th := p.findThreadByStrID(sp.threadID)
+	if th == nil {
+		tu := threadUpdater{p: p}
+		if err := tu.Add([]string{sp.threadID}); err != nil {
+			return nil, err
+		}
+		th = p.findThreadByStrID(sp.threadID)
+	}
+	th.regs.regs = nil
+	th.sig = sp.sig
+	switch th.sig {
+	case breakpointSignal, stopSignal:
+		// stops requested by StopThread are reported either with signal 0 or
+		// with stopSignal, neither should be propagated to the inferior.
+		th.sig = 0
+	}
+	th.setbp = sp.reason == "breakpoint" || (sp.reason == "" && sp.sig == breakpointSignal)
+	if th.setbp {
+		if err := th.SetCurrentBreakpoint(true); err != nil {
+			return nil, err
+		}
+	}
+	return th, nil
+}
+
+// MemoryMap returns the memory map of the target process, using the
+// qMemoryRegionInfo command. Stubs that do not support it (including rr)
+// return ErrMemoryMapNotSupported.
+func (p *gdbProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	var r []proc.MemoryMapEntry
+	addr := uint64(0)
+	for addr != ^uint64(0) {
+		mme, err := p.conn.memoryRegionInfo(addr)
+		if err != nil {
+			if isProtocolErrorUnsupported(err) {
+				return nil, proc.ErrMemoryMapNotSupported
+			}
+			return nil, err
+		}
+		if mme.Addr+mme.Size <= addr {
+			break
+		}
+		if mme.Read || mme.Write || mme.Exec {
+			r = append(r, *mme)
+		}
+		addr = mme.Addr + mme.Size
+	}
+	return r, nil
+}
+
+// SetMemoryGuards returns ErrMemoryGuardsNotSupported, the gdb remote
+// protocol does not report the address of memory faults.
+func (p *gdbProcess) SetMemoryGuards([]*proc.MemoryGuard) error {
+	return proc.ErrMemoryGuardsNotSupported
+}
+
+// EnableBranchTrace returns ErrBranchTraceNotSupported.
+func (p *gdbProcess) EnableBranchTrace(bool) error {
+	return proc.ErrBranchTraceNotSupported
+}
+
+// BranchTrace returns ErrBranchTraceNotSupported.
+func (p *gdbProcess) BranchTrace(int) ([]byte, error) {
+	return nil, proc.ErrBranchTraceNotSupported
+}
+
+// WriteMinidump returns ErrMinidumpNotSupported.
+func (p *gdbProcess) WriteMinidump(*os.File, bool) error {
+	return proc.ErrMinidumpNotSupported
+}
+
+// SetSignalPolicy changes how signal sig is handled.
+func (p *gdbProcess) SetSignalPolicy(sig int, policy proc.SignalPolicy) error {
+	if p.tracedir != "" {
+		return errors.New("can not change signal handling while replaying a recording")
+	}
+	if p.signalPolicies == nil {
+		p.signalPolicies = make(map[int]proc.SignalPolicy)
+	}
+	p.signalPolicies[sig] = policy
+	return nil
+}
+
+func (p *gdbProcess) signalPolicy(sig int) proc.SignalPolicy {
+	if policy, ok := p.signalPolicies[sig]; ok {
+		return policy
+	}
+	return proc.DefaultSignalPolicy
+}
+
 func (p *gdbProcess) findThreadByStrID(threadID string) *gdbThread {
 	for _, thread := range p.threads {
 		if thread.strID == threadID {
@@ -899,7 +1151,19 @@ func (p *gdbProcess) handleThreadSignals(trapthread *gdbThread) (trapthreadOut *
 			}
 
 		default:
-			// any other signal is always propagated to inferior
+			// any other signal is handled according to the policy set by the
+			// user, by default it is propagated to the inferior.
+			policy := p.signalPolicy(int(th.sig))
+			if policy.Stop || policy.Print {
+				th.common.Signal = int(th.sig)
+				if trapthreadCandidate == nil {
+					trapthreadCandidate = th
+				}
+				shouldStop = true
+			}
+			if !policy.Pass {
+				th.sig = 0
+			}
 		}
 
 		if isStopSignal {
@@ -1353,7 +1617,7 @@ func (t *gdbThread) Location() (*proc.Location, error) {
 	if err != nil {
 		return nil, err
 	}
-	if pcreg, ok := regs.(*gdbRegisters).regs[regnamePC]; !ok {
+	if pcreg, ok := regs.(*gdbRegisters).regs[t.p.conn.arch.pc]; !ok {
 		t.p.conn.log.Errorf("thread %d could not find RIP register", t.ID)
 	} else if len(pcreg.value) < t.p.bi.Arch.PtrSize() {
 		t.p.conn.log.Errorf("thread %d bad length for RIP register: %d", t.ID, len(pcreg.value))
@@ -1450,7 +1714,7 @@ func (p *gdbProcess) loadGInstr() []byte {
 	case "windows", "darwin", "freebsd":
 		// mov rcx, QWORD PTR gs:{uint32(off)}
 		op = []byte{0x65, 0x48, 0x8b, 0x0c, 0x25}
-	case "linux":
+	case "linux", "openbsd":
 		// mov rcx,QWORD PTR fs:{uint32(off)}
 		op = []byte{0x64, 0x48, 0x8B, 0x0C, 0x25}
 	default:
@@ -1462,8 +1726,9 @@ func (p *gdbProcess) loadGInstr() []byte {
 	return buf.Bytes()
 }
 
-func (regs *gdbRegisters) init(regsInfo []gdbRegisterInfo, arch *proc.Arch) {
+func (regs *gdbRegisters) init(regsInfo []gdbRegisterInfo, arch *proc.Arch, names *gdbArchInfo) {
 	regs.arch = arch
+	regs.names = names
 	regs.regs = make(map[string]gdbRegister)
 	regs.regsInfo = regsInfo
 
@@ -1477,6 +1742,14 @@ func (regs *gdbRegisters) init(regsInfo []gdbRegisterInfo, arch *proc.Arch) {
 	for _, reginfo := range regsInfo {
 		regs.regs[reginfo.Name] = gdbRegister{regnum: reginfo.Regnum, value: regs.buf[reginfo.Offset : reginfo.Offset+reginfo.Bitsize/8]}
 	}
+	for alias, name := range names.aliases {
+		if _, ok := regs.regs[alias]; ok {
+			continue
+		}
+		if reg, ok := regs.regs[name]; ok {
+			regs.regs[alias] = reg
+		}
+	}
 }
 
 // reloadRegisters loads the current value of the thread's registers.
@@ -1485,7 +1758,7 @@ func (regs *gdbRegisters) init(regsInfo []gdbRegisterInfo, arch *proc.Arch) {
 // the stub can allocate memory, or reloadGAtPC, if the stub can't.
 func (t *gdbThread) reloadRegisters() error {
 	if t.regs.regs == nil {
-		t.regs.init(t.p.conn.regsInfo, t.p.bi.Arch)
+		t.regs.init(t.p.conn.regsInfo, t.p.bi.Arch, t.p.conn.arch)
 	}
 
 	if t.p.gcmdok {
@@ -1506,8 +1779,8 @@ func (t *gdbThread) reloadRegisters() error {
 	}
 
 	switch t.p.bi.GOOS {
-	case "linux":
-		if reg, hasFsBase := t.regs.regs[regnameFsBase]; hasFsBase {
+	case "linux", "openbsd":
+		if reg, hasFsBase := t.regs.regs[t.p.conn.arch.fsBase]; hasFsBase {
 			t.regs.gaddr = 0
 			t.regs.tls = binary.LittleEndian.Uint64(reg.value)
 			t.regs.hasgaddr = false
@@ -1620,7 +1893,7 @@ func (t *gdbThread) reloadGAtPC() error {
 		}
 		t.regs.setPC(pc)
 		t.regs.setCX(cx)
-		err1 := t.writeSomeRegisters(regnamePC, regnameCX)
+		err1 := t.writeSomeRegisters(t.p.conn.arch.pc, t.p.conn.arch.cx)
 		if err == nil {
 			err = err1
 		}
@@ -1637,7 +1910,7 @@ func (t *gdbThread) reloadGAtPC() error {
 		return err
 	}
 
-	if err := t.readSomeRegisters(regnamePC, regnameCX); err != nil {
+	if err := t.readSomeRegisters(t.p.conn.arch.pc, t.p.conn.arch.cx); err != nil {
 		return err
 	}
 
@@ -1664,7 +1937,7 @@ func (t *gdbThread) reloadGAlloc() error {
 	pc := t.regs.PC()
 
 	t.regs.setPC(t.p.loadGInstrAddr)
-	if err := t.writeSomeRegisters(regnamePC); err != nil {
+	if err := t.writeSomeRegisters(t.p.conn.arch.pc); err != nil {
 		return err
 	}
 
@@ -1673,7 +1946,7 @@ func (t *gdbThread) reloadGAlloc() error {
 	defer func() {
 		t.regs.setPC(pc)
 		t.regs.setCX(cx)
-		err1 := t.writeSomeRegisters(regnamePC, regnameCX)
+		err1 := t.writeSomeRegisters(t.p.conn.arch.pc, t.p.conn.arch.cx)
 		if err == nil {
 			err = err1
 		}
@@ -1690,7 +1963,7 @@ func (t *gdbThread) reloadGAlloc() error {
 		return err
 	}
 
-	if err := t.readSomeRegisters(regnameCX); err != nil {
+	if err := t.readSomeRegisters(t.p.conn.arch.cx); err != nil {
 		return err
 	}
 
@@ -1733,34 +2006,34 @@ func (t *gdbThread) SetCurrentBreakpoint(adjustPC bool) error {
 }
 
 func (regs *gdbRegisters) PC() uint64 {
-	return binary.LittleEndian.Uint64(regs.regs[regnamePC].value)
+	return binary.LittleEndian.Uint64(regs.regs[regs.names.pc].value)
 }
 
 func (regs *gdbRegisters) setPC(value uint64) {
-	binary.LittleEndian.PutUint64(regs.regs[regnamePC].value, value)
+	binary.LittleEndian.PutUint64(regs.regs[regs.names.pc].value, value)
 }
 
 func (regs *gdbRegisters) SP() uint64 {
-	return binary.LittleEndian.Uint64(regs.regs[regnameSP].value)
+	return binary.LittleEndian.Uint64(regs.regs[regs.names.sp].value)
 }
 func (regs *gdbRegisters) setSP(value uint64) {
-	binary.LittleEndian.PutUint64(regs.regs[regnameSP].value, value)
+	binary.LittleEndian.PutUint64(regs.regs[regs.names.sp].value, value)
 }
 
 func (regs *gdbRegisters) setDX(value uint64) {
-	binary.LittleEndian.PutUint64(regs.regs[regnameDX].value, value)
+	binary.LittleEndian.PutUint64(regs.regs[regs.names.dx].value, value)
 }
 
 func (regs *gdbRegisters) BP() uint64 {
-	return binary.LittleEndian.Uint64(regs.regs[regnameBP].value)
+	return binary.LittleEndian.Uint64(regs.regs[regs.names.bp].value)
 }
 
 func (regs *gdbRegisters) CX() uint64 {
-	return binary.LittleEndian.Uint64(regs.regs[regnameCX].value)
+	return binary.LittleEndian.Uint64(regs.regs[regs.names.cx].value)
 }
 
 func (regs *gdbRegisters) setCX(value uint64) {
-	binary.LittleEndian.PutUint64(regs.regs[regnameCX].value, value)
+	binary.LittleEndian.PutUint64(regs.regs[regs.names.cx].value, value)
 }
 
 func (regs *gdbRegisters) TLS() uint64 {
@@ -2159,7 +2432,7 @@ func (t *gdbThread) SetPC(pc uint64) error {
 	if t.p.gcmdok {
 		return t.p.conn.writeRegisters(t.strID, t.regs.buf)
 	}
-	reg := t.regs.regs[regnamePC]
+	reg := t.regs.regs[t.p.conn.arch.pc]
 	return t.p.conn.writeRegister(t.strID, reg.regnum, reg.value)
 }
 
@@ -2170,7 +2443,7 @@ func (t *gdbThread) SetSP(sp uint64) error {
 	if t.p.gcmdok {
 		return t.p.conn.writeRegisters(t.strID, t.regs.buf)
 	}
-	reg := t.regs.regs[regnameSP]
+	reg := t.regs.regs[t.p.conn.arch.sp]
 	return t.p.conn.writeRegister(t.strID, reg.regnum, reg.value)
 }
 
@@ -2185,7 +2458,7 @@ func (t *gdbThread) SetDX(dx uint64) error {
 	if t.p.gcmdok {
 		return t.p.conn.writeRegisters(t.strID, t.regs.buf)
 	}
-	reg := t.regs.regs[regnameDX]
+	reg := t.regs.regs[t.p.conn.arch.dx]
 	return t.p.conn.writeRegister(t.strID, reg.regnum, reg.value)
 }
 
@@ -2242,9 +2515,31 @@ func (regs *gdbRegisters) Slice(floatingPoint bool) ([]proc.Register, error) {
 	return r, nil
 }
 
+// SetReg changes the value of the register called name (as returned by
+// Slice) in regs, it does not change the registers of the thread.
+func (regs *gdbRegisters) SetReg(name string, value uint64) error {
+	name = strings.ToLower(name)
+	if name == "rflags" {
+		name = "eflags"
+	}
+	reg, ok := regs.regs[name]
+	if !ok {
+		return proc.ErrUnknownRegister
+	}
+	switch len(reg.value) {
+	case 4:
+		binary.LittleEndian.PutUint32(reg.value, uint32(value))
+	case 8:
+		binary.LittleEndian.PutUint64(reg.value, value)
+	default:
+		return proc.ErrUnknownRegister
+	}
+	return nil
+}
+
 func (regs *gdbRegisters) Copy() (proc.Registers, error) {
 	savedRegs := &gdbRegisters{}
-	savedRegs.init(regs.regsInfo, regs.arch)
+	savedRegs.init(regs.regsInfo, regs.arch, regs.names)
 	copy(savedRegs.buf, regs.buf)
 	return savedRegs, nil
 }
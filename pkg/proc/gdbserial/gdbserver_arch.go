@@ -0,0 +1,51 @@
+package gdbserial
+
+// gdbArchInfo describes the names used by stubs for the registers that
+// Delve accesses directly and the kind argument of breakpoints for an
+// architecture.
+// This depends on the architecture of the target, which isn't necessarily
+// the one Delve was compiled for (for example when debugging an arm64
+// program through qemu-user on an amd64 machine).
+type gdbArchInfo struct {
+	pc, cx, sp, dx, bp string
+	fsBase, gsBase     string
+
+	breakpointKind int
+
+	// aliases maps register names used by debugserver to the names used by
+	// gdbserver and qemu for the same register.
+	aliases map[string]string
+}
+
+var gdbArchInfos = map[string]*gdbArchInfo{
+	"amd64": {
+		pc: "rip", cx: "rcx", sp: "rsp", dx: "rdx", bp: "rbp",
+		fsBase: "fs_base", gsBase: "gs_base",
+		breakpointKind: 1,
+	},
+	"386": {
+		pc: "rip", cx: "rcx", sp: "rsp", dx: "rdx", bp: "rbp",
+		fsBase: "fs_base", gsBase: "gs_base",
+		breakpointKind: 1,
+	},
+	"arm64": {
+		pc: "pc", cx: "x0", sp: "sp", bp: "fp",
+		breakpointKind: 4,
+		aliases:        map[string]string{"fp": "x29", "lr": "x30"},
+	},
+}
+
+// goarchFromTargetXml converts the architecture name reported in
+// target.xml to the corresponding GOARCH value. Returns the empty string
+// for unsupported architectures.
+func goarchFromTargetXml(arch string) string {
+	switch arch {
+	case "i386:x86-64":
+		return "amd64"
+	case "i386":
+		return "386"
+	case "aarch64":
+		return "arm64"
+	}
+	return ""
+}
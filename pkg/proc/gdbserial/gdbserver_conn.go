@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"debug/macho"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,6 +38,8 @@ type gdbConn struct {
 
 	packetSize int               // maximum packet size supported by stub
 	regsInfo   []gdbRegisterInfo // list of registers
+	goarch     string            // architecture of the target
+	arch       *gdbArchInfo      // register names used by the stub for the target architecture
 
 	pid int // cache process id
 
@@ -44,6 +48,13 @@ type gdbConn struct {
 	maxTransmitAttempts   int  // maximum number of transmit or receive attempts when bad checksums are read
 	threadSuffixSupported bool // thread suffix supported by stub
 	isDebugserver         bool // true if the stub is debugserver
+	librariesSvr4         bool // stub supports qXfer:libraries-svr4:read
+	libraries             bool // stub supports qXfer:libraries:read
+	nonStopSupported      bool // stub supports QNonStop
+	nonStop               bool // non-stop mode is active
+
+	stopNotifications   [][]byte // stop replies received in non-stop mode that haven't been processed yet
+	notificationPending bool     // a stop notification was received and the stub is waiting for vStopped
 
 	log *logrus.Entry
 }
@@ -96,6 +107,8 @@ const (
 func (conn *gdbConn) handshake() error {
 	conn.ack = true
 	conn.packetSize = 256
+	conn.goarch = runtime.GOARCH
+	conn.arch = gdbArchInfos[conn.goarch]
 	conn.rdr = bufio.NewReader(conn.conn)
 
 	// This first ack packet is needed to start up the connection
@@ -120,6 +133,7 @@ func (conn *gdbConn) handshake() error {
 			return err
 		}
 		conn.multiprocess = features["multiprocess"]
+		conn.setFeatures(features)
 
 		// for some reason gdbserver won't let us read target.xml unless first we
 		// select a thread.
@@ -131,10 +145,13 @@ func (conn *gdbConn) handshake() error {
 	} else {
 		// execute qSupported with the multiprocess feature disabled (the
 		// interaction of thread suffixes and multiprocess is not documented), we
-		// only need this call to configure conn.packetSize.
-		if _, err := conn.qSupported(false); err != nil {
+		// only need this call to configure conn.packetSize and to find out
+		// which library lists the stub supports.
+		features, err := conn.qSupported(false)
+		if err != nil {
 			return err
 		}
+		conn.setFeatures(features)
 	}
 
 	// Attempt to figure out the name of the processor register.
@@ -194,6 +211,14 @@ func (conn *gdbConn) qSupported(multiprocess bool) (features map[string]bool, er
 	return features, nil
 }
 
+// setFeatures records which library list transfers are supported by the
+// stub and whether it supports non-stop mode.
+func (conn *gdbConn) setFeatures(features map[string]bool) {
+	conn.librariesSvr4 = features["qXfer:libraries-svr4:read"]
+	conn.libraries = features["qXfer:libraries:read"]
+	conn.nonStopSupported = features["QNonStop"]
+}
+
 // disableAck disables protocol acks.
 func (conn *gdbConn) disableAck() error {
 	_, err := conn.exec([]byte("$QStartNoAckMode"), "init/disableAck")
@@ -205,8 +230,9 @@ func (conn *gdbConn) disableAck() error {
 
 // gdbTarget is a struct type used to parse target.xml
 type gdbTarget struct {
-	Includes  []gdbTargetInclude `xml:"xi include"`
-	Registers []gdbRegisterInfo  `xml:"reg"`
+	Architecture string             `xml:"architecture"`
+	Includes     []gdbTargetInclude `xml:"xi include"`
+	Registers    []gdbRegisterInfo  `xml:"reg"`
 }
 
 type gdbTargetInclude struct {
@@ -226,10 +252,24 @@ type gdbRegisterInfo struct {
 // The schema of target.xml is described by:
 //  https://github.com/bminor/binutils-gdb/blob/61baf725eca99af2569262d10aca03dcde2698f6/gdb/features/gdb-target.dtd
 func (conn *gdbConn) readTargetXml() (err error) {
-	conn.regsInfo, err = conn.readAnnex("target.xml")
+	var arch string
+	conn.regsInfo, arch, err = conn.readAnnex("target.xml")
 	if err != nil {
 		return err
 	}
+	if arch != "" {
+		// The target can have a different architecture from ours, for example
+		// when the stub is qemu-user.
+		goarch := goarchFromTargetXml(arch)
+		if gdbArchInfos[goarch] == nil {
+			return fmt.Errorf("unsupported target architecture %q", arch)
+		}
+		conn.goarch = goarch
+		conn.arch = gdbArchInfos[goarch]
+	}
+	if conn.arch == nil {
+		return fmt.Errorf("unsupported target architecture %q", conn.goarch)
+	}
 	var offset int
 	var pcFound, cxFound, spFound bool
 	regnum := 0
@@ -242,24 +282,24 @@ func (conn *gdbConn) readTargetXml() (err error) {
 		conn.regsInfo[i].Offset = offset
 		offset += conn.regsInfo[i].Bitsize / 8
 		switch conn.regsInfo[i].Name {
-		case regnamePC:
+		case conn.arch.pc:
 			pcFound = true
-		case regnameCX:
+		case conn.arch.cx:
 			cxFound = true
-		case regnameSP:
+		case conn.arch.sp:
 			spFound = true
 		}
 		regnum++
 	}
 
 	if !pcFound {
-		return errors.New("could not find RIP register")
+		return fmt.Errorf("could not find %s register", conn.arch.pc)
 	}
 	if !spFound {
-		return errors.New("could not find RSP register")
+		return fmt.Errorf("could not find %s register", conn.arch.sp)
 	}
 	if !cxFound {
-		return errors.New("could not find RCX register")
+		return fmt.Errorf("could not find %s register", conn.arch.cx)
 	}
 
 	return nil
@@ -322,12 +362,16 @@ func (conn *gdbConn) readRegisterInfo() (err error) {
 			continue
 		}
 
+		if conn.arch == nil {
+			return fmt.Errorf("unsupported target architecture %q", conn.goarch)
+		}
+
 		switch regname {
-		case regnamePC:
+		case conn.arch.pc:
 			pcFound = true
-		case regnameCX:
+		case conn.arch.cx:
 			cxFound = true
-		case regnameSP:
+		case conn.arch.sp:
 			spFound = true
 		}
 
@@ -337,36 +381,39 @@ func (conn *gdbConn) readRegisterInfo() (err error) {
 	}
 
 	if !pcFound {
-		return errors.New("could not find RIP register")
+		return fmt.Errorf("could not find %s register", conn.arch.pc)
 	}
 	if !spFound {
-		return errors.New("could not find RSP register")
+		return fmt.Errorf("could not find %s register", conn.arch.sp)
 	}
 	if !cxFound {
-		return errors.New("could not find RCX register")
+		return fmt.Errorf("could not find %s register", conn.arch.cx)
 	}
 
 	return nil
 }
 
-func (conn *gdbConn) readAnnex(annex string) ([]gdbRegisterInfo, error) {
+func (conn *gdbConn) readAnnex(annex string) ([]gdbRegisterInfo, string, error) {
 	tgtbuf, err := conn.qXfer("features", annex, false)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	var tgt gdbTarget
 	if err := xml.Unmarshal(tgtbuf, &tgt); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	for _, incl := range tgt.Includes {
-		regs, err := conn.readAnnex(incl.Href)
+		regs, arch, err := conn.readAnnex(incl.Href)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		tgt.Registers = append(tgt.Registers, regs...)
+		if tgt.Architecture == "" {
+			tgt.Architecture = arch
+		}
 	}
-	return tgt.Registers, nil
+	return tgt.Registers, tgt.Architecture, nil
 }
 
 func (conn *gdbConn) readExecFile() (string, error) {
@@ -381,6 +428,99 @@ func (conn *gdbConn) readAuxv() ([]byte, error) {
 	return conn.qXfer("auxv", "", true)
 }
 
+// librarySvr4List is a struct type used to parse the response of
+// qXfer:libraries-svr4:read, its format is described by:
+//  https://sourceware.org/gdb/onlinedocs/gdb/Library-List-Format-for-SVR4-Targets.html
+type librarySvr4List struct {
+	Libraries []librarySvr4 `xml:"library"`
+}
+
+type librarySvr4 struct {
+	Name string `xml:"name,attr"`
+	Lm   string `xml:"lm,attr"`
+	Addr string `xml:"l_addr,attr"`
+	Ld   string `xml:"l_ld,attr"`
+}
+
+// libraryList is a struct type used to parse the response of
+// qXfer:libraries:read, its format is described by:
+//  https://sourceware.org/gdb/onlinedocs/gdb/Library-List-Format.html
+type libraryList struct {
+	Libraries []library `xml:"library"`
+}
+
+type library struct {
+	Name     string           `xml:"name,attr"`
+	Segments []libraryAddress `xml:"segment"`
+}
+
+type libraryAddress struct {
+	Address string `xml:"address,attr"`
+}
+
+// sharedLibrary describes a shared library loaded by the target.
+type sharedLibrary struct {
+	path string
+	addr uint64
+	// segment is true if addr is the address where the first loadable
+	// segment of the library was mapped, rather than the load bias.
+	segment bool
+}
+
+// readLibraries reads the list of shared libraries loaded by the target,
+// using qXfer:libraries-svr4:read if the stub supports it and
+// qXfer:libraries:read otherwise.
+func (conn *gdbConn) readLibraries() ([]sharedLibrary, error) {
+	if conn.librariesSvr4 {
+		buf, err := conn.qXfer("libraries-svr4", "", false)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) == 0 {
+			return nil, nil
+		}
+		var list librarySvr4List
+		if err := xml.Unmarshal(buf, &list); err != nil {
+			return nil, fmt.Errorf("could not parse library list: %v", err)
+		}
+		libs := make([]sharedLibrary, 0, len(list.Libraries))
+		for _, lib := range list.Libraries {
+			addr, err := strconv.ParseUint(lib.Addr, 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed load address for %s: %q", lib.Name, lib.Addr)
+			}
+			libs = append(libs, sharedLibrary{path: lib.Name, addr: addr})
+		}
+		return libs, nil
+	}
+
+	buf, err := conn.qXfer("libraries", "", false)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	var list libraryList
+	if err := xml.Unmarshal(buf, &list); err != nil {
+		return nil, fmt.Errorf("could not parse library list: %v", err)
+	}
+	libs := make([]sharedLibrary, 0, len(list.Libraries))
+	for _, lib := range list.Libraries {
+		if len(lib.Segments) == 0 {
+			// Libraries described by the addresses of their sections are not
+			// supported.
+			continue
+		}
+		addr, err := strconv.ParseUint(lib.Segments[0].Address, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed segment address for %s: %q", lib.Name, lib.Segments[0].Address)
+		}
+		libs = append(libs, sharedLibrary{path: lib.Name, addr: addr, segment: true})
+	}
+	return libs, nil
+}
+
 // qXfer executes a 'qXfer' read with the specified kind (i.e. feature,
 // exec-file, etc...) and annex.
 func (conn *gdbConn) qXfer(kind, annex string, binary bool) ([]byte, error) {
@@ -407,7 +547,7 @@ func (conn *gdbConn) qXfer(kind, annex string, binary bool) ([]byte, error) {
 // setBreakpoint executes a 'Z' (insert breakpoint) command of type '0' and kind '1' or '4'
 func (conn *gdbConn) setBreakpoint(addr uint64) error {
 	conn.outbuf.Reset()
-	fmt.Fprintf(&conn.outbuf, "$Z0,%x,%d", addr, breakpointKind)
+	fmt.Fprintf(&conn.outbuf, "$Z0,%x,%d", addr, conn.arch.breakpointKind)
 	_, err := conn.exec(conn.outbuf.Bytes(), "set breakpoint")
 	return err
 }
@@ -415,7 +555,7 @@ func (conn *gdbConn) setBreakpoint(addr uint64) error {
 // clearBreakpoint executes a 'z' (remove breakpoint) command of type '0' and kind '1' or '4'
 func (conn *gdbConn) clearBreakpoint(addr uint64) error {
 	conn.outbuf.Reset()
-	fmt.Fprintf(&conn.outbuf, "$z0,%x,%d", addr, breakpointKind)
+	fmt.Fprintf(&conn.outbuf, "$z0,%x,%d", addr, conn.arch.breakpointKind)
 	_, err := conn.exec(conn.outbuf.Bytes(), "clear breakpoint")
 	return err
 }
@@ -595,14 +735,24 @@ func (conn *gdbConn) step(threadID string, tu *threadUpdater, ignoreFaultSignal
 		} else {
 			fmt.Fprintf(&conn.outbuf, "$vCont;S%02x:%s", sig, threadID)
 		}
-		if err := conn.send(conn.outbuf.Bytes()); err != nil {
+		if conn.nonStop {
+			if _, err := conn.exec(conn.outbuf.Bytes(), "singlestep"); err != nil {
+				return err
+			}
+		} else if err := conn.send(conn.outbuf.Bytes()); err != nil {
 			return err
 		}
 		if tu != nil {
 			tu.Reset()
 		}
 		var err error
-		_, sig, err = conn.waitForvContStop("singlestep", threadID, tu)
+		if conn.nonStop {
+			var sp stopPacket
+			sp, err = conn.waitForStopNotification(threadID, tu)
+			sig = sp.sig
+		} else {
+			_, sig, err = conn.waitForvContStop("singlestep", threadID, tu)
+		}
 		if err != nil {
 			return err
 		}
@@ -659,6 +809,111 @@ func (conn *gdbConn) waitForvContStop(context string, threadID string, tu *threa
 	}
 }
 
+var errStopNotification = errors.New("stop notification")
+
+// setNonStop enables or disables non-stop mode. In non-stop mode resuming
+// or stopping a thread does not affect the other threads and the stub
+// reports stops asynchronously using stop notifications.
+func (conn *gdbConn) setNonStop(enabled bool) error {
+	cmd := "$QNonStop:0"
+	if enabled {
+		cmd = "$QNonStop:1"
+	}
+	if _, err := conn.exec([]byte(cmd), "non-stop"); err != nil {
+		return err
+	}
+	conn.nonStop = enabled
+	conn.stopNotifications = nil
+	conn.notificationPending = false
+	return nil
+}
+
+// resumeThread executes a 'vCont' command with 'c' action on the specified
+// thread, in non-stop mode. If sig is not zero it is delivered to the
+// thread.
+func (conn *gdbConn) resumeThread(threadID string, sig uint8) error {
+	conn.outbuf.Reset()
+	if sig == 0 {
+		fmt.Fprintf(&conn.outbuf, "$vCont;c:%s", threadID)
+	} else {
+		fmt.Fprintf(&conn.outbuf, "$vCont;C%02x:%s", sig, threadID)
+	}
+	_, err := conn.exec(conn.outbuf.Bytes(), "resume thread")
+	return err
+}
+
+// stopThread executes a 'vCont' command with 't' action on the specified
+// thread, in non-stop mode. The stop will be reported by a stop
+// notification.
+func (conn *gdbConn) stopThread(threadID string) error {
+	conn.outbuf.Reset()
+	fmt.Fprintf(&conn.outbuf, "$vCont;t:%s", threadID)
+	_, err := conn.exec(conn.outbuf.Bytes(), "stop thread")
+	return err
+}
+
+// queueStopNotification queues the stop reply contained in the
+// notification packet resp. Returns false if resp isn't a stop notification.
+func (conn *gdbConn) queueStopNotification(resp []byte) bool {
+	_, msg := wiredecode(resp, nil)
+	if !bytes.HasPrefix(msg, []byte("Stop:")) {
+		return false
+	}
+	conn.stopNotifications = append(conn.stopNotifications, msg[len("Stop:"):])
+	conn.notificationPending = true
+	return true
+}
+
+// waitForStopNotification waits for a stop reply in non-stop mode. If
+// threadID isn't empty only stops of that thread are returned, stops of
+// other threads are kept for later calls.
+// The details of how stop notifications work are described here:
+//  https://sourceware.org/gdb/onlinedocs/gdb/Notification-Packets.html
+func (conn *gdbConn) waitForStopNotification(threadID string, tu *threadUpdater) (stopPacket, error) {
+	for {
+		if conn.notificationPending {
+			// The stub will not send any other stop notification until we
+			// acknowledge this one and read all the stop replies it has queued.
+			conn.notificationPending = false
+			for {
+				resp, err := conn.exec([]byte("$vStopped"), "stop notification")
+				if err != nil {
+					return stopPacket{}, err
+				}
+				if string(resp) == "OK" {
+					break
+				}
+				conn.stopNotifications = append(conn.stopNotifications, append([]byte(nil), resp...))
+			}
+		}
+
+		for i, resp := range conn.stopNotifications {
+			if threadID != "" {
+				_, sp, err := conn.parseStopPacket(resp, threadID, nil)
+				if err == nil && sp.threadID != threadID {
+					continue
+				}
+			}
+			conn.stopNotifications = append(conn.stopNotifications[:i], conn.stopNotifications[i+1:]...)
+			repeat, sp, err := conn.parseStopPacket(resp, threadID, tu)
+			if !repeat {
+				return sp, err
+			}
+			break
+		}
+
+		if !conn.notificationPending {
+			_, err := conn.recv(nil, "stop notification", false)
+			if err != errStopNotification {
+				if err == nil {
+					err = errors.New("unexpected packet while waiting for a stop notification")
+				}
+				return stopPacket{}, err
+			}
+		}
+	}
+}
+
 type stopPacket struct {
 	threadID string
 	sig      uint8
@@ -803,6 +1058,46 @@ func (conn *gdbConn) queryProcessInfo(pid int) (map[string]string, error) {
 	return pi, nil
 }
 
+// memoryRegionInfo executes a qMemoryRegionInfo command, returning the
+// memory region containing addr, or the unmapped region following it.
+// This is an LLDB extension, described here:
+//  https://github.com/llvm/llvm-project/blob/master/lldb/docs/lldb-gdb-remote.txt
+func (conn *gdbConn) memoryRegionInfo(addr uint64) (*proc.MemoryMapEntry, error) {
+	conn.outbuf.Reset()
+	fmt.Fprintf(&conn.outbuf, "$qMemoryRegionInfo:%x", addr)
+	resp, err := conn.exec(conn.outbuf.Bytes(), "memory region info")
+	if err != nil {
+		return nil, err
+	}
+
+	mme := &proc.MemoryMapEntry{}
+	for _, keyval := range strings.Split(string(resp), ";") {
+		colon := strings.Index(keyval, ":")
+		if colon < 0 {
+			continue
+		}
+		key, value := keyval[:colon], keyval[colon+1:]
+		switch key {
+		case "start":
+			mme.Addr, err = strconv.ParseUint(value, 16, 64)
+		case "size":
+			mme.Size, err = strconv.ParseUint(value, 16, 64)
+		case "permissions":
+			mme.Read = strings.Contains(value, "r")
+			mme.Write = strings.Contains(value, "w")
+			mme.Exec = strings.Contains(value, "x")
+		case "name":
+			var name []byte
+			name, err = hex.DecodeString(value)
+			mme.Filename = string(name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed qMemoryRegionInfo response %q: %v", resp, err)
+		}
+	}
+	return mme, nil
+}
+
 // executes qfThreadInfo/qsThreadInfo commands
 func (conn *gdbConn) queryThreads(first bool) (threads []string, err error) {
 	// https://sourceware.org/gdb/onlinedocs/gdb/General-Query-Packets.html
@@ -1107,6 +1402,16 @@ func (conn *gdbConn) recv(cmd []byte, context string, binary bool) (resp []byte,
 			}
 		}
 
+		if resp[0] == '%' && conn.nonStop {
+			// In non-stop mode the stub reports stops with notification packets
+			// (starting with % instead of $), that can arrive at any time. Stop
+			// notifications are queued for waitForStopNotification.
+			if conn.queueStopNotification(resp) && cmd == nil {
+				return nil, errStopNotification
+			}
+			continue
+		}
+
 		if !conn.ack {
 			break
 		}
@@ -1,15 +0,0 @@
-//+build 386
-
-package gdbserial
-
-const (
-	regnamePC     = "rip"
-	regnameCX     = "rcx"
-	regnameSP     = "rsp"
-	regnameDX     = "rdx"
-	regnameBP     = "rbp"
-	regnameFsBase = "fs_base"
-	regnameGsBase = "gs_base"
-
-	breakpointKind = 1
-)
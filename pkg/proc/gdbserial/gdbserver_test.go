@@ -0,0 +1,78 @@
+package gdbserial_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-delve/delve/pkg/proc/gdbserial"
+)
+
+func TestRemoteTLS(t *testing.T) {
+	// A self signed certificate, used both by the stub and by the client.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assertNoError(err, t, "GenerateKey")
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stub"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assertNoError(err, t, "CreateCertificate")
+	cert, err := x509.ParseCertificate(der)
+	assertNoError(err, t, "ParseCertificate")
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	tlsCert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	assertNoError(err, t, "Listen")
+	defer listener.Close()
+
+	// The fake stub reads the first packet sent by the client and then
+	// closes the connection, making the handshake fail.
+	packetChan := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			packetChan <- err.Error()
+			return
+		}
+		defer conn.Close()
+		packet, err := bufio.NewReader(conn).ReadString('#')
+		if err != nil {
+			packet = err.Error()
+		}
+		packetChan <- packet
+	}()
+
+	_, err = gdbserial.Remote(listener.Addr().String(), "", 0, &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		RootCAs:      pool,
+	}, nil)
+	if err == nil {
+		t.Fatal("connecting to the fake stub succeeded")
+	}
+	if packet := <-packetChan; !strings.HasPrefix(packet, "+$QStartNoAckMode") {
+		t.Fatalf("wrong first packet received by the stub: %q", packet)
+	}
+}
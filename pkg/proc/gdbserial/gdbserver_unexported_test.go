@@ -0,0 +1,416 @@
+package gdbserial
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-delve/delve/pkg/logflags"
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// newFakeStubConn returns a gdbConn connected to a fake stub. The stub
+// answers every packet it receives by writing the packets returned by reply,
+// which is called with the contents of the received packet.
+func newFakeStubConn(reply func(cmd string) []string) *gdbConn {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		rdr := bufio.NewReader(server)
+		for {
+			cmd, err := rdr.ReadString('#')
+			if err != nil {
+				return
+			}
+			if _, err := io.ReadFull(rdr, make([]byte, 2)); err != nil {
+				return
+			}
+			for _, resp := range reply(cmd[1 : len(cmd)-1]) {
+				if _, err := server.Write([]byte(resp)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return &gdbConn{
+		conn:                client,
+		rdr:                 bufio.NewReader(client),
+		inbuf:               make([]byte, 0, initialInputBufferSize),
+		maxTransmitAttempts: maxTransmitAttempts,
+		log:                 logflags.GdbWireLogger(),
+	}
+}
+
+// stubPacket returns a packet containing data, as sent by a stub.
+func stubPacket(data string) string {
+	return fmt.Sprintf("$%s#%02x", data, checksum([]byte("$"+data)))
+}
+
+func TestReadLibraries(t *testing.T) {
+	const (
+		svr4List = `<library-list-svr4 version="1.0" main-lm="0x7ffff7ffe190">` +
+			`<library name="/lib/x86_64-linux-gnu/libc.so.6" lm="0x7ffff7fc3000" l_addr="0x7ffff7dd5000" l_ld="0x7ffff7fbfbc0"/>` +
+			`<library name="/lib64/ld-linux-x86-64.so.2" lm="0x7ffff7ffd9f0" l_addr="0x7ffff7fcf000" l_ld="0x7ffff7ffce68"/>` +
+			`</library-list-svr4>`
+		libraryList = `<library-list version="1.0">` +
+			`<library name="/usr/lib/libc.so.6"><segment address="0x7ffff7dd5000"/></library>` +
+			`<library name="/usr/lib/libsections.so"><section address="0x7ffff7a00000"/></library>` +
+			`<library name="/usr/lib/libm.so.6"><segment address="0x7ffff7c00000"/><segment address="0x7ffff7c80000"/></library>` +
+			`</library-list>`
+	)
+
+	// chunks splits data into the replies of a qXfer transfer, each at most n
+	// bytes long. If lastEmpty is set the last chunk is sent with 'm' and an
+	// empty 'l' reply follows it.
+	chunks := func(data string, n int, lastEmpty bool) []string {
+		var r []string
+		for len(data) > n {
+			r = append(r, "m"+data[:n])
+			data = data[n:]
+		}
+		if lastEmpty {
+			return append(r, "m"+data, "l")
+		}
+		return append(r, "l"+data)
+	}
+
+	tests := []struct {
+		name          string
+		librariesSvr4 bool
+		kind          string
+		replies       []string
+		tgt           []sharedLibrary
+	}{
+		{
+			name:          "svr4",
+			librariesSvr4: true,
+			kind:          "libraries-svr4",
+			replies:       chunks(svr4List, 1000, false),
+			tgt: []sharedLibrary{
+				{path: "/lib/x86_64-linux-gnu/libc.so.6", addr: 0x7ffff7dd5000},
+				{path: "/lib64/ld-linux-x86-64.so.2", addr: 0x7ffff7fcf000},
+			},
+		},
+		{
+			name:          "svr4 chunked",
+			librariesSvr4: true,
+			kind:          "libraries-svr4",
+			replies:       chunks(svr4List, 100, true),
+			tgt: []sharedLibrary{
+				{path: "/lib/x86_64-linux-gnu/libc.so.6", addr: 0x7ffff7dd5000},
+				{path: "/lib64/ld-linux-x86-64.so.2", addr: 0x7ffff7fcf000},
+			},
+		},
+		{
+			name:          "svr4 empty",
+			librariesSvr4: true,
+			kind:          "libraries-svr4",
+			replies:       chunks(`<library-list-svr4 version="1.0"/>`, 1000, false),
+			tgt:           []sharedLibrary{},
+		},
+		{
+			name:          "svr4 no data",
+			librariesSvr4: true,
+			kind:          "libraries-svr4",
+			replies:       []string{"l"},
+			tgt:           nil,
+		},
+		{
+			name:    "libraries",
+			kind:    "libraries",
+			replies: chunks(libraryList, 1000, false),
+			tgt: []sharedLibrary{
+				{path: "/usr/lib/libc.so.6", addr: 0x7ffff7dd5000, segment: true},
+				{path: "/usr/lib/libm.so.6", addr: 0x7ffff7c00000, segment: true},
+			},
+		},
+		{
+			name:    "libraries chunked",
+			kind:    "libraries",
+			replies: chunks(libraryList, 50, true),
+			tgt: []sharedLibrary{
+				{path: "/usr/lib/libc.so.6", addr: 0x7ffff7dd5000, segment: true},
+				{path: "/usr/lib/libm.so.6", addr: 0x7ffff7c00000, segment: true},
+			},
+		},
+		{
+			name:    "libraries empty",
+			kind:    "libraries",
+			replies: chunks(`<library-list version="1.0"></library-list>`, 1000, true),
+			tgt:     []sharedLibrary{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cmds []string
+			replies := tc.replies
+			conn := newFakeStubConn(func(cmd string) []string {
+				cmds = append(cmds, cmd)
+				if len(replies) == 0 {
+					return []string{stubPacket("E01")}
+				}
+				resp := replies[0]
+				replies = replies[1:]
+				return []string{stubPacket(resp)}
+			})
+			defer conn.conn.Close()
+			conn.librariesSvr4 = tc.librariesSvr4
+			conn.libraries = !tc.librariesSvr4
+
+			libs, err := conn.readLibraries()
+			if err != nil {
+				t.Fatalf("readLibraries: %v", err)
+			}
+			if !reflect.DeepEqual(libs, tc.tgt) {
+				t.Errorf("wrong libraries %#v (expected %#v)", libs, tc.tgt)
+			}
+			if len(replies) != 0 {
+				t.Errorf("transfer stopped before the last chunk, %d replies left", len(replies))
+			}
+			off := 0
+			for i, cmd := range cmds {
+				if tgt := fmt.Sprintf("qXfer:%s:read::%x,fff", tc.kind, off); cmd != tgt {
+					t.Errorf("wrong packet %d %q (expected %q)", i, cmd, tgt)
+				}
+				off += len(tc.replies[i]) - 1
+			}
+		})
+	}
+}
+
+// writeTestELF writes an ELF file with the specified program headers and no
+// sections to path.
+func writeTestELF(t *testing.T, path string, progs []elf.Prog64) {
+	t.Helper()
+	var buf bytes.Buffer
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     64,
+		Ehsize:    64,
+		Phentsize: 56,
+		Phnum:     uint16(len(progs)),
+		Shentsize: 64,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	binary.Write(&buf, binary.LittleEndian, &hdr)
+	for i := range progs {
+		binary.Write(&buf, binary.LittleEndian, &progs[i])
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestElfLoadBias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "elfloadbias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		progs   []elf.Prog64
+		segaddr uint64
+		tgt     uint64
+	}{
+		{
+			name: "shared library",
+			progs: []elf.Prog64{
+				{Type: uint32(elf.PT_LOAD), Vaddr: 0, Memsz: 0x1000},
+				{Type: uint32(elf.PT_LOAD), Vaddr: 0x1000, Memsz: 0x1000},
+			},
+			segaddr: 0x7ffff7dd5000,
+			tgt:     0x7ffff7dd5000,
+		},
+		{
+			name: "first segment not at zero",
+			progs: []elf.Prog64{
+				{Type: uint32(elf.PT_PHDR), Vaddr: 0x40, Memsz: 0x70},
+				{Type: uint32(elf.PT_INTERP), Vaddr: 0xb0, Memsz: 0x1c},
+				{Type: uint32(elf.PT_LOAD), Vaddr: 0x400000, Memsz: 0x1000},
+				{Type: uint32(elf.PT_LOAD), Vaddr: 0x401000, Memsz: 0x1000},
+			},
+			segaddr: 0x7ffff7e00000,
+			tgt:     0x7ffff7a00000,
+		},
+		{
+			name:    "executable",
+			progs:   []elf.Prog64{{Type: uint32(elf.PT_LOAD), Vaddr: 0x400000, Memsz: 0x1000}},
+			segaddr: 0x400000,
+			tgt:     0,
+		},
+		{
+			name:    "no loadable segments",
+			progs:   []elf.Prog64{{Type: uint32(elf.PT_NOTE), Vaddr: 0x200, Memsz: 0x20}},
+			segaddr: 0x7ffff7dd5000,
+			tgt:     0x7ffff7dd5000,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("lib%d.so", i))
+			writeTestELF(t, path, tc.progs)
+			bias, err := elfLoadBias(path, tc.segaddr)
+			if err != nil {
+				t.Fatalf("elfLoadBias: %v", err)
+			}
+			if bias != tc.tgt {
+				t.Errorf("wrong load bias %#x (expected %#x)", bias, tc.tgt)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		notelf := filepath.Join(dir, "notelf.so")
+		if err := ioutil.WriteFile(notelf, []byte("not an ELF file"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{notelf, filepath.Join(dir, "missing.so")} {
+			if _, err := elfLoadBias(path, 0x7ffff7dd5000); err == nil {
+				t.Errorf("elfLoadBias(%q) did not return an error", path)
+			}
+		}
+	})
+}
+
+// stubNotification returns a notification packet containing data, as sent
+// by a stub.
+func stubNotification(data string) string {
+	return fmt.Sprintf("%%%s#%02x", data, checksum([]byte("%"+data)))
+}
+
+func TestNonStop(t *testing.T) {
+	var cmds []string
+	vStopped := []string{"T13thread:p1.2;", "T05thread:p1.4;reason:breakpoint;", "OK", "OK"}
+	conn := newFakeStubConn(func(cmd string) []string {
+		cmds = append(cmds, cmd)
+		switch cmd {
+		case "qSupported:swbreak+;hwbreak+;no-resumed+;xmlRegisters=i386":
+			return []string{stubPacket("PacketSize=4000;QNonStop+;qXfer:libraries-svr4:read+")}
+		case "QNonStop:0", "QNonStop:1", "vCont;t:p1.2":
+			return []string{stubPacket("OK")}
+		case "vCont;c:p1.2":
+			// The notification for the stop of another thread arrives while we
+			// are waiting for the reply.
+			return []string{stubNotification("Stop:T05thread:p1.3;"), stubPacket("OK")}
+		case "vCont;t:p1.5":
+			// The notification arrives after the reply.
+			return []string{stubPacket("OK"), stubNotification("Stop:T13thread:p1.5;")}
+		case "vStopped":
+			resp := vStopped[0]
+			vStopped = vStopped[1:]
+			return []string{stubPacket(resp)}
+		}
+		return []string{stubPacket("")}
+	})
+	defer conn.conn.Close()
+
+	features, err := conn.qSupported(false)
+	if err != nil {
+		t.Fatalf("qSupported: %v", err)
+	}
+	conn.setFeatures(features)
+	if !conn.nonStopSupported {
+		t.Fatal("QNonStop feature not detected")
+	}
+
+	if err := conn.setNonStop(true); err != nil {
+		t.Fatalf("setNonStop(true): %v", err)
+	}
+	if !conn.nonStop {
+		t.Fatal("non-stop mode not enabled")
+	}
+
+	// A stop notification received while executing a command is queued.
+	if err := conn.resumeThread("p1.2", 0); err != nil {
+		t.Fatalf("resumeThread: %v", err)
+	}
+	if len(conn.stopNotifications) != 1 || string(conn.stopNotifications[0]) != "T05thread:p1.3;" || !conn.notificationPending {
+		t.Fatalf("stop notification not queued: %q (pending %v)", conn.stopNotifications, conn.notificationPending)
+	}
+	if err := conn.stopThread("p1.2"); err != nil {
+		t.Fatalf("stopThread: %v", err)
+	}
+
+	// Waiting for the stop of p1.2 drains the stop replies queued by the
+	// stub with vStopped and skips the stops of the other threads.
+	sp, err := conn.waitForStopNotification("p1.2", nil)
+	if err != nil {
+		t.Fatalf("waitForStopNotification(p1.2): %v", err)
+	}
+	if sp.threadID != "p1.2" || sp.sig != stopSignal {
+		t.Errorf("wrong stop for p1.2: %#v", sp)
+	}
+	if len(vStopped) != 1 {
+		t.Errorf("stop replies not drained, %d vStopped replies left", len(vStopped))
+	}
+	for _, tgt := range []stopPacket{{threadID: "p1.3", sig: breakpointSignal}, {threadID: "p1.4", sig: breakpointSignal, reason: "breakpoint"}} {
+		sp, err := conn.waitForStopNotification("", nil)
+		if err != nil {
+			t.Fatalf("waitForStopNotification: %v", err)
+		}
+		if sp != tgt {
+			t.Errorf("wrong stop %#v (expected %#v)", sp, tgt)
+		}
+	}
+
+	// A stop notification that arrives while we are waiting.
+	if err := conn.stopThread("p1.5"); err != nil {
+		t.Fatalf("stopThread: %v", err)
+	}
+	sp, err = conn.waitForStopNotification("p1.5", nil)
+	if err != nil {
+		t.Fatalf("waitForStopNotification(p1.5): %v", err)
+	}
+	if sp.threadID != "p1.5" || sp.sig != stopSignal {
+		t.Errorf("wrong stop for p1.5: %#v", sp)
+	}
+
+	if err := conn.setNonStop(false); err != nil {
+		t.Fatalf("setNonStop(false): %v", err)
+	}
+	if conn.nonStop || len(conn.stopNotifications) != 0 || conn.notificationPending {
+		t.Errorf("non-stop mode not disabled")
+	}
+
+	tgt := []string{
+		"qSupported:swbreak+;hwbreak+;no-resumed+;xmlRegisters=i386",
+		"QNonStop:1",
+		"vCont;c:p1.2",
+		"vCont;t:p1.2",
+		"vStopped", "vStopped", "vStopped",
+		"vCont;t:p1.5",
+		"vStopped",
+		"QNonStop:0",
+	}
+	if !reflect.DeepEqual(cmds, tgt) {
+		t.Errorf("wrong packets sent to the stub:\n%q\nexpected:\n%q", cmds, tgt)
+	}
+}
+
+func TestSetNonStopNotSupported(t *testing.T) {
+	p := &gdbProcess{}
+	if err := p.SetNonStop(true); err != proc.ErrNonStopNotSupported {
+		t.Errorf("wrong error enabling non-stop mode on a stub that doesn't support it: %v", err)
+	}
+	if p.conn.nonStop {
+		t.Error("non-stop mode enabled")
+	}
+}
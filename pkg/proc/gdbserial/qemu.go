@@ -0,0 +1,80 @@
+package gdbserial
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// qemuArchNames maps ELF machine types to the suffix of the corresponding
+// qemu-user executable.
+var qemuArchNames = map[elf.Machine]string{
+	elf.EM_X86_64:  "x86_64",
+	elf.EM_386:     "i386",
+	elf.EM_AARCH64: "aarch64",
+}
+
+// QemuLaunch starts the program specified by cmd under qemu-user, with its
+// gdb stub enabled, and connects to it.
+// The qemu-user executable is selected according to the architecture of
+// the program, which doesn't need to match the architecture Delve is
+// running on, allowing, for example, arm64 programs to be debugged on
+// amd64 machines.
+func QemuLaunch(cmd []string, wd string, debugInfoDirs []string, redirects [3]string) (*proc.Target, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("qemu backend only supported on linux")
+	}
+
+	qemu, err := qemuExecutable(cmd[0])
+	if err != nil {
+		return nil, err
+	}
+
+	port := unusedPort()
+	args := make([]string, 0, len(cmd)+2)
+	args = append(args, "-g", strings.TrimPrefix(port, ":"))
+	args = append(args, cmd...)
+
+	process := commandLogger(qemu, args...)
+	var closefn func()
+	process.Stdin, process.Stdout, process.Stderr, closefn, err = openRedirects(redirects, false)
+	if err != nil {
+		return nil, err
+	}
+	defer closefn()
+	if wd != "" {
+		process.Dir = wd
+	}
+
+	if err := process.Start(); err != nil {
+		return nil, err
+	}
+
+	p := newProcess(process.Process)
+	return p.Dial(port, cmd[0], process.Process.Pid, debugInfoDirs, proc.StopLaunched)
+}
+
+// qemuExecutable returns the path of the qemu-user executable that can run
+// the program at path.
+func qemuExecutable(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	name, ok := qemuArchNames[f.Machine]
+	if !ok {
+		return "", fmt.Errorf("unsupported architecture %s", f.Machine)
+	}
+	for _, exe := range []string{"qemu-" + name, "qemu-" + name + "-static"} {
+		if qemu, err := exec.LookPath(exe); err == nil {
+			return qemu, nil
+		}
+	}
+	return "", &ErrBackendUnavailable{}
+}
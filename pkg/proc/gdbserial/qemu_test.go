@@ -0,0 +1,67 @@
+package gdbserial_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/gdbserial"
+	protest "github.com/go-delve/delve/pkg/proc/test"
+)
+
+func withTestQemu(goarch, qemu, name string, t *testing.T, fn func(p *proc.Target)) {
+	if runtime.GOOS != "linux" {
+		t.Skip("qemu-user is only available on linux")
+	}
+	if path, _ := exec.LookPath(qemu); path == "" {
+		t.Skipf("test skipped, %s not found", qemu)
+	}
+
+	dir, err := ioutil.TempDir("", "dlvqemu")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(dir)
+	exe := filepath.Join(dir, name)
+	cmd := exec.Command("go", "build", "-gcflags=-N -l", "-o", exe, name+".go")
+	cmd.Dir = protest.FindFixturesDir()
+	cmd.Env = append(os.Environ(), "GOARCH="+goarch, "CGO_ENABLED=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("could not build fixture for %s: %v\n%s", goarch, err, out)
+	}
+
+	p, err := gdbserial.QemuLaunch([]string{exe}, ".", []string{}, [3]string{})
+	assertNoError(err, t, "QemuLaunch")
+	defer p.Detach(true)
+
+	fn(p)
+}
+
+func TestQemu(t *testing.T) {
+	for _, tc := range []struct{ goarch, qemu string }{
+		{"amd64", "qemu-x86_64"},
+		{"arm64", "qemu-aarch64"},
+	} {
+		t.Run(tc.goarch, func(t *testing.T) {
+			withTestQemu(tc.goarch, tc.qemu, "testnextprog", t, func(p *proc.Target) {
+				if arch := p.BinInfo().Arch.Name; arch != tc.goarch {
+					t.Fatalf("wrong architecture %s (expected %s)", arch, tc.goarch)
+				}
+				setFunctionBreakpoint(p, t, "main.main")
+				assertNoError(p.Continue(), t, "Continue")
+				loc, err := p.CurrentThread().Location()
+				assertNoError(err, t, "Location")
+				if loc.Fn == nil || loc.Fn.Name != "main.main" {
+					t.Fatalf("wrong location %#v", loc)
+				}
+				frames, err := proc.ThreadStacktrace(p.CurrentThread(), 10)
+				assertNoError(err, t, "ThreadStacktrace")
+				if len(frames) < 2 {
+					t.Fatalf("stacktrace too short: %d frames", len(frames))
+				}
+			})
+		})
+	}
+}
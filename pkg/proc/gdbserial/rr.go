@@ -3,6 +3,7 @@ package gdbserial
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -17,6 +18,35 @@ import (
 	"github.com/go-delve/delve/pkg/proc"
 )
 
+func init() {
+	proc.RegisterRecorder(rrRecorder{})
+}
+
+// rrRecorder is the proc.Recorder using mozilla rr, registered as the
+// "rr" backend.
+type rrRecorder struct{}
+
+func (rrRecorder) Name() string { return "rr" }
+
+func (rrRecorder) Available() error {
+	if _, err := exec.LookPath("rr"); err != nil {
+		return errors.New("rr not found: install mozilla rr (https://github.com/mozilla/rr)")
+	}
+	return nil
+}
+
+func (rrRecorder) Capabilities() proc.RecorderCapabilities {
+	return proc.RecorderCapabilities{ReverseExecution: true, Checkpoints: true, RestartFromEvent: true}
+}
+
+func (rrRecorder) RecordAsync(cmd []string, wd string, quiet bool, redirects [3]string) (run func() (string, error), stop func() error, err error) {
+	return RecordAsync(cmd, wd, quiet, redirects)
+}
+
+func (rrRecorder) Replay(tracedir string, quiet, deleteOnDetach bool, debugInfoDirs []string) (*proc.Target, error) {
+	return Replay(tracedir, quiet, deleteOnDetach, debugInfoDirs)
+}
+
 // RecordAsync configures rr to record the execution of the specified
 // program. Returns a run function which will actually record the program, a
 // stop function which will prematurely terminate the recording of the
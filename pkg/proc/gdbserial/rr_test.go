@@ -277,6 +277,29 @@ func TestCheckpoints(t *testing.T) {
 	})
 }
 
+func TestRunToEvent(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue")
+		when0, _ := getPosition(p, t)
+		var event uint64
+		if _, err := fmt.Sscanf(when0, "Current event: %d", &event); err != nil {
+			t.Fatalf("can not parse output of when %q: %v", when0, err)
+		}
+
+		assertNoError(p.Next(), t, "First Next")
+		assertNoError(p.Next(), t, "Second Next")
+
+		// Restart positions that are not checkpoints are event numbers.
+		assertNoError(p.Restart(fmt.Sprintf("%d", event)), t, "Restart")
+		when1, _ := getPosition(p, t)
+		if when1 != when0 {
+			t.Fatalf("when output mismatch %q != %q", when0, when1)
+		}
+	})
+}
+
 func TestIssue1376(t *testing.T) {
 	// Backward Continue should terminate when it encounters the start of the process.
 	protest.AllowRecording(t)
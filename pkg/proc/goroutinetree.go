@@ -0,0 +1,71 @@
+package proc
+
+import "sort"
+
+// maxGoroutineTreeAncestors is the maximum number of ancestors of each
+// goroutine read by GoroutineTree.
+const maxGoroutineTreeAncestors = 100
+
+// GoroutineTreeNode is a goroutine of the tree returned by GoroutineTree.
+type GoroutineTreeNode struct {
+	ID int
+	// ParentID is the ID of the goroutine that created this goroutine, 0 if
+	// it is not known.
+	ParentID int
+	// G is the goroutine, nil if it has exited.
+	G *G
+	// Creation is the ancestor record of the parent goroutine, its stack is
+	// the stack of the parent when it created this goroutine. It is nil if
+	// the parent is not known.
+	Creation *Ancestor
+}
+
+// GoroutineTree returns the tree of the goroutines in gs and of their
+// ancestors, as recorded by the runtime when the target is run with
+// GODEBUG=tracebackancestors=N. Ancestors that have exited are included,
+// up to the depth recorded by the runtime.
+// The nodes are returned sorted by goroutine ID.
+func GoroutineTree(p *Target, gs []*G) ([]GoroutineTreeNode, error) {
+	nodes := make(map[int]*GoroutineTreeNode)
+	for _, g := range gs {
+		if g == nil {
+			continue
+		}
+		nodes[g.ID] = &GoroutineTreeNode{ID: g.ID, G: g}
+	}
+
+	for _, g := range gs {
+		if g == nil {
+			continue
+		}
+		ancestors, err := Ancestors(p, g, maxGoroutineTreeAncestors)
+		if err != nil {
+			if err == errTracebackAncestorsDisabled {
+				return nil, err
+			}
+			continue
+		}
+		child := nodes[g.ID]
+		for i := range ancestors {
+			if ancestors[i].Unreadable != nil || child.ParentID != 0 {
+				break
+			}
+			id := int(ancestors[i].ID)
+			child.ParentID = id
+			child.Creation = &ancestors[i]
+			parent := nodes[id]
+			if parent == nil {
+				parent = &GoroutineTreeNode{ID: id}
+				nodes[id] = parent
+			}
+			child = parent
+		}
+	}
+
+	r := make([]GoroutineTreeNode, 0, len(nodes))
+	for _, n := range nodes {
+		r = append(r, *n)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	return r, nil
+}
@@ -0,0 +1,203 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+// minSizeForMallocHeader is the minimum size of heap objects that, starting
+// with Go 1.22, are prefixed by a pointer to their runtime._type (see
+// runtime.minSizeForMallocHeader).
+const minSizeForMallocHeader = 512
+
+// HeapHistogramEntry is the number of heap objects of a type, and the
+// memory they use, see HeapHistogram.
+type HeapHistogramEntry struct {
+	// Type is the type of the objects. If their type is unknown it is a
+	// description of their size class, for example "<unknown, 48 bytes>",
+	// objects that can not contain pointers are described as "noscan".
+	Type  string
+	Count uint64
+	// Bytes is the memory used by the objects, including the unused space
+	// at the end of their size class.
+	Bytes uint64
+}
+
+// HeapHistogram walks the spans of the heap and returns the number of
+// allocated objects of each type and the memory they use, sorted by the
+// memory used.
+//
+// The type of heap objects is only known for objects with a malloc header,
+// i.e. objects larger than 512 bytes allocated by Go 1.22 or later, other
+// objects are grouped by size class.
+// Objects that are unreachable but have not been swept yet are counted as
+// allocated.
+func (t *Target) HeapHistogram() ([]HeapHistogramEntry, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	bi := t.BinInfo()
+	mem := t.Memory()
+	spans := readHeapSpans(bi, mem)
+	if len(spans) == 0 {
+		return nil, errors.New("could not read the heap spans of the target process")
+	}
+	h := newHeapTypeReader(bi, mem)
+	entries := make(map[string]*HeapHistogramEntry)
+	add := func(typ string, size uint64) {
+		e := entries[typ]
+		if e == nil {
+			e = &HeapHistogramEntry{Type: typ}
+			entries[typ] = e
+		}
+		e.Count++
+		e.Bytes += size
+	}
+
+	for i := range spans {
+		span := &spans[i]
+		if span.state != mSpanInUse || span.elemsize == 0 {
+			continue
+		}
+		unknown := fmt.Sprintf("<unknown, %d bytes>", span.elemsize)
+		if span.spanclass&1 != 0 {
+			unknown = fmt.Sprintf("<unknown noscan, %d bytes>", span.elemsize)
+		}
+		allocBits := span.readAllocBits(mem)
+		for idx := uint64(0); idx < span.size/span.elemsize; idx++ {
+			if !span.isAllocated(allocBits, idx) {
+				continue
+			}
+			typ, n, _ := h.objectType(span, span.base+idx*span.elemsize)
+			if typ == nil {
+				add(unknown, span.elemsize)
+				continue
+			}
+			add(heapObjectTypeString(typ, n), span.elemsize)
+		}
+	}
+
+	r := make([]HeapHistogramEntry, 0, len(entries))
+	for _, e := range entries {
+		r = append(r, *e)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Bytes != r[j].Bytes {
+			return r[i].Bytes > r[j].Bytes
+		}
+		return r[i].Type < r[j].Type
+	})
+	return r, nil
+}
+
+// readAllocBits reads the allocation bitmap of span, it returns nil if the
+// bitmap could not be read.
+func (span *heapSpan) readAllocBits(mem MemoryReader) []byte {
+	if span.allocBits == 0 || span.elemsize == 0 {
+		return nil
+	}
+	buf := make([]byte, (span.size/span.elemsize+7)/8)
+	if _, err := mem.ReadMemory(buf, span.allocBits); err != nil {
+		return nil
+	}
+	return buf
+}
+
+// isAllocated returns true if the object with index idx of span is
+// allocated, see runtime.(*mspan).isFree. If allocBits is nil all objects
+// are considered allocated.
+func (span *heapSpan) isAllocated(allocBits []byte, idx uint64) bool {
+	if idx < span.freeindex || idx/8 >= uint64(len(allocBits)) {
+		return true
+	}
+	return allocBits[idx/8]&(1<<(idx%8)) != 0
+}
+
+// heapTypeReader reads the types of heap objects from their malloc
+// headers.
+type heapTypeReader struct {
+	bi      *BinaryInfo
+	mem     MemoryReadWriter
+	ptrSize uint64
+
+	mds   []moduleData
+	rtyp  godwarf.Type
+	types map[uint64]godwarf.Type // cache of the types of malloc headers
+}
+
+func newHeapTypeReader(bi *BinaryInfo, mem MemoryReadWriter) *heapTypeReader {
+	return &heapTypeReader{bi: bi, mem: mem, ptrSize: uint64(bi.Arch.PtrSize()), types: make(map[uint64]godwarf.Type)}
+}
+
+// objectType returns the type of the heap object at addr, read from its
+// malloc header, the number of values of that type stored in the object
+// and the size of the header.
+func (h *heapTypeReader) objectType(span *heapSpan, addr uint64) (typ godwarf.Type, n int64, hdrsize uint64) {
+	if producer := h.bi.Producer(); producer == "" || !goversion.ProducerAfterOrEqual(producer, 1, 22) {
+		return nil, 0, 0
+	}
+	if span.largeType != 0 {
+		typ = h.runtimeType(span.largeType)
+	} else {
+		if span.elemsize <= minSizeForMallocHeader || span.spanclass>>1 == 0 {
+			return nil, 0, 0
+		}
+		typaddr, err := readUintRaw(h.mem, addr, int64(h.ptrSize))
+		if err != nil {
+			return nil, 0, 0
+		}
+		typ, hdrsize = h.runtimeType(typaddr), h.ptrSize
+	}
+	if typ == nil {
+		return nil, 0, 0
+	}
+	n = (int64(span.elemsize) - int64(hdrsize)) / typ.Size()
+	if n < 1 {
+		n = 1
+	}
+	return typ, n, hdrsize
+}
+
+// runtimeType returns the type described by the runtime._type at addr, or
+// nil if addr does not point to a runtime._type.
+func (h *heapTypeReader) runtimeType(addr uint64) godwarf.Type {
+	if typ, ok := h.types[addr]; ok {
+		return typ
+	}
+	h.types[addr] = nil
+	if h.rtyp == nil {
+		var err error
+		h.mds, err = loadModuleData(h.bi, h.mem)
+		if err != nil {
+			return nil
+		}
+		h.rtyp, err = h.bi.findType("runtime._type")
+		if err != nil {
+			return nil
+		}
+	}
+	// the malloc header of an object that was freed, or of an object that
+	// is being initialized, may not be a valid pointer
+	if findModuleDataForType(h.bi, h.mds, addr, h.mem) == nil {
+		return nil
+	}
+	typ, _, err := runtimeTypeToDIE(newVariable("", addr, h.rtyp, h.bi, h.mem), 0)
+	if err != nil || typ.Size() <= 0 {
+		return nil
+	}
+	h.types[addr] = typ
+	return typ
+}
+
+// heapObjectTypeString returns the type of a heap object containing n
+// values of type typ.
+func heapObjectTypeString(typ godwarf.Type, n int64) string {
+	if n > 1 {
+		return fmt.Sprintf("[%d]%s", n, typ.String())
+	}
+	return typ.String()
+}
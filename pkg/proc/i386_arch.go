@@ -34,6 +34,7 @@ func I386Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        i386RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: i386AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            i386DwarfRegisterToString,
+		isVectorRegister:                 amd64IsVectorRegister,
 		inhibitStepInto:                  i386InhibitStepInto,
 		asmDecode:                        i386AsmDecode,
 	}
@@ -0,0 +1,235 @@
+package intelpt
+
+import "errors"
+
+// InstKind classifies instructions according to how they change the flow
+// of execution.
+type InstKind uint8
+
+const (
+	// InstOther is an instruction that does not change the flow of execution.
+	InstOther InstKind = iota
+	// InstCondJump is a conditional direct jump, traced with a TNT bit.
+	InstCondJump
+	// InstJump is an unconditional direct jump, not traced.
+	InstJump
+	// InstCall is a direct call, not traced.
+	InstCall
+	// InstIndirect is an indirect jump, indirect call or return, traced
+	// with a TIP packet.
+	InstIndirect
+	// InstFar is a far transfer (for example a system call), traced with a
+	// TIP or, if the destination is not traced, a TIP.PGD packet.
+	InstFar
+)
+
+// Instruction describes the instruction at an address.
+type Instruction struct {
+	Kind InstKind
+	Len  int
+	// Target is the destination of direct jumps and calls.
+	Target uint64
+}
+
+// DecodeFunc returns the instruction at pc.
+type DecodeFunc func(pc uint64) (Instruction, error)
+
+// Branch is a change in the flow of execution, from the instruction at
+// From to the instruction at To.
+type Branch struct {
+	From, To uint64
+}
+
+// maxWalk is the maximum number of instructions executed without
+// consuming a packet, protects against loops of direct jumps caused by
+// decoding the wrong instructions.
+const maxWalk = 1000000
+
+var (
+	errNeedInput = errors.New("instruction needs a packet")
+	errDesync    = errors.New("trace does not match the program")
+)
+
+// flow reconstructs the branches taken by the traced thread.
+type flow struct {
+	decode DecodeFunc
+
+	pc      uint64
+	running bool // pc is valid and tracing is enabled
+
+	// branches is a ring buffer containing the last len(branches) branches,
+	// next is the index where the next branch is stored and n the total
+	// number of branches.
+	branches []Branch
+	next, n  int
+}
+
+func (f *flow) record(from, to uint64) {
+	f.branches[f.next] = Branch{From: from, To: to}
+	f.next = (f.next + 1) % len(f.branches)
+	f.n++
+}
+
+// walk executes instructions, starting at f.pc, that do not need a packet
+// to be decoded until it finds one that does (or until it reaches stop, if
+// stop is not zero), and returns it. f.pc is left at the address of the
+// returned instruction.
+func (f *flow) walk(stop uint64) (Instruction, error) {
+	for i := 0; i < maxWalk; i++ {
+		if stop != 0 && f.pc == stop {
+			return Instruction{}, nil
+		}
+		inst, err := f.decode(f.pc)
+		if err != nil {
+			return inst, err
+		}
+		switch inst.Kind {
+		case InstOther:
+			f.pc += uint64(inst.Len)
+		case InstJump, InstCall:
+			f.record(f.pc, inst.Target)
+			f.pc = inst.Target
+		default:
+			if stop != 0 {
+				return inst, errNeedInput
+			}
+			return inst, nil
+		}
+	}
+	return Instruction{}, errDesync
+}
+
+// Decode decodes trace and returns the last max branches executed,
+// oldest first. The trace can start at any point, decoding starts at the
+// first synchronization point (PSB packet). Parts of the trace that can not
+// be decoded, because they are malformed or do not match the instructions
+// returned by decode, are skipped until the next synchronization point.
+// If end is not zero it is the address where the thread stopped, the
+// direct jumps and calls executed after the last packet are followed up to
+// it.
+func Decode(trace []byte, decode DecodeFunc, end uint64, max int) ([]Branch, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	f := &flow{decode: decode, branches: make([]Branch, max)}
+	p := &parser{buf: trace}
+	if !p.sync() {
+		return nil, errors.New("no synchronization point in Intel PT trace")
+	}
+
+	// inPSB is true between PSB and PSBEND, statusFUP is true if the FUP
+	// packet with the current address was found between them.
+	inPSB, statusFUP := false, false
+	// fup is the source address of the asynchronous event reported by the
+	// last FUP packet, the next TIP or TIP.PGD packet is its destination.
+	var fup uint64
+	haveFUP := false
+
+	for p.off < len(p.buf) {
+		pkt, err := p.next()
+		if err != nil {
+			f.running = false
+			p.off++
+			if !p.sync() {
+				break
+			}
+			continue
+		}
+
+		switch pkt.kind {
+		case pktPSB:
+			inPSB, statusFUP = true, false
+			haveFUP = false
+
+		case pktPSBEnd:
+			inPSB = false
+			if !statusFUP {
+				// Tracing is disabled (for example the thread is executing a
+				// system call).
+				f.running = false
+			}
+
+		case pktOVF:
+			f.running = false
+			haveFUP = false
+
+		case pktFUP:
+			if inPSB {
+				// Status FUP: the current address.
+				if pkt.ipValid {
+					if f.running {
+						f.walk(pkt.ip)
+					}
+					f.pc = pkt.ip
+					f.running = true
+					statusFUP = true
+				}
+				continue
+			}
+			if !pkt.ipValid {
+				continue
+			}
+			if f.running {
+				if _, err := f.walk(pkt.ip); err != nil {
+					f.running = false
+				}
+			}
+			fup, haveFUP = pkt.ip, true
+
+		case pktTIP:
+			switch {
+			case haveFUP:
+				if pkt.ipValid {
+					f.record(fup, pkt.ip)
+				}
+				haveFUP = false
+			case f.running:
+				inst, err := f.walk(0)
+				if err == nil && (inst.Kind == InstIndirect || inst.Kind == InstFar) && pkt.ipValid {
+					f.record(f.pc, pkt.ip)
+				}
+			}
+			f.pc, f.running = pkt.ip, pkt.ipValid
+
+		case pktTIPPGE:
+			haveFUP = false
+			f.pc, f.running = pkt.ip, pkt.ipValid
+
+		case pktTIPPGD:
+			if !haveFUP && f.running {
+				// Tracing was disabled by the next branch, for example a system
+				// call, its destination is not traced.
+				f.walk(0)
+			}
+			haveFUP = false
+			f.running = false
+
+		case pktTNT:
+			for _, taken := range pkt.tnt {
+				if !f.running {
+					break
+				}
+				inst, err := f.walk(0)
+				if err != nil || inst.Kind != InstCondJump {
+					f.running = false
+					break
+				}
+				if taken {
+					f.record(f.pc, inst.Target)
+					f.pc = inst.Target
+				} else {
+					f.pc += uint64(inst.Len)
+				}
+			}
+		}
+	}
+
+	if f.running && end != 0 {
+		f.walk(end)
+	}
+
+	if f.n < len(f.branches) {
+		return f.branches[:f.n], nil
+	}
+	return append(f.branches[f.next:], f.branches[:f.next]...), nil
+}
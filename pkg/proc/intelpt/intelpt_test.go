@@ -0,0 +1,177 @@
+package intelpt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testProgram is a fake program:
+//
+//	0x1000	other
+//	0x1004	jcc 0x1010
+//	0x1006	call 0x2000
+//	0x100b	other
+//	0x1010	call *rax
+//	0x2000	other
+//	0x2001	ret
+//	0x3000	other
+//	0x3001	syscall
+//	0x3003	jmp 0x3000
+var testProgram = map[uint64]Instruction{
+	0x1000: {Kind: InstOther, Len: 4},
+	0x1004: {Kind: InstCondJump, Len: 2, Target: 0x1010},
+	0x1006: {Kind: InstCall, Len: 5, Target: 0x2000},
+	0x100b: {Kind: InstOther, Len: 5},
+	0x1010: {Kind: InstIndirect, Len: 2},
+	0x2000: {Kind: InstOther, Len: 1},
+	0x2001: {Kind: InstIndirect, Len: 1},
+	0x3000: {Kind: InstOther, Len: 1},
+	0x3001: {Kind: InstFar, Len: 2},
+	0x3003: {Kind: InstJump, Len: 2, Target: 0x3000},
+}
+
+func decodeTestProgram(pc uint64) (Instruction, error) {
+	inst, ok := testProgram[pc]
+	if !ok {
+		return Instruction{}, fmt.Errorf("no instruction at %#x", pc)
+	}
+	return inst, nil
+}
+
+type traceBuilder []byte
+
+func (tb *traceBuilder) psb(ip uint64) {
+	*tb = append(*tb, psb...)
+	tb.ip(0x1d, 6, ip) // FUP
+	*tb = append(*tb, 0x02, 0x23)
+}
+
+// ip appends a packet of the TIP family with the specified IP compression.
+func (tb *traceBuilder) ip(opcode byte, ipbytes byte, ip uint64) {
+	*tb = append(*tb, opcode|ipbytes<<5)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], ip)
+	switch ipbytes {
+	case 1:
+		*tb = append(*tb, buf[:2]...)
+	case 2:
+		*tb = append(*tb, buf[:4]...)
+	case 6:
+		*tb = append(*tb, buf[:]...)
+	}
+}
+
+// tnt appends a short TNT packet.
+func (tb *traceBuilder) tnt(taken ...bool) {
+	b := byte(1)
+	for _, t := range taken {
+		b <<= 1
+		if t {
+			b |= 1
+		}
+	}
+	*tb = append(*tb, b<<1)
+}
+
+func TestDecode(t *testing.T) {
+	var tb traceBuilder
+	tb = append(tb, 0x4d, 0xff, 0x02) // garbage before the first PSB
+	tb.psb(0x1000)
+	tb.tnt(false)
+	tb = append(tb, 0x00, 0x59, 0x12)          // PAD, MTC
+	tb.ip(0x0d, 1, 0x100b)                     // ret
+	tb.ip(0x0d, 2, 0x3000)                     // call *rax
+	tb.ip(0x01, 0, 0)                          // syscall, TIP.PGD
+	tb.ip(0x11, 1, 0x3003)                     // TIP.PGE
+	tb = append(tb, 0x19, 1, 2, 3, 4, 5, 6, 7) // TSC
+
+	tgt := []Branch{
+		{0x1006, 0x2000},
+		{0x2001, 0x100b},
+		{0x1010, 0x3000},
+		{0x3003, 0x3000},
+	}
+	branches, err := Decode(tb, decodeTestProgram, 0x3001, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branches, tgt) {
+		t.Fatalf("got %#x expected %#x", branches, tgt)
+	}
+
+	branches, err = Decode(tb, decodeTestProgram, 0x3001, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branches, tgt[2:]) {
+		t.Fatalf("got %#x expected %#x", branches, tgt[2:])
+	}
+}
+
+func TestDecodeAsync(t *testing.T) {
+	// An interrupt at 0x1010, delivered to 0x2000
+	var tb traceBuilder
+	tb.psb(0x1000)
+	tb.tnt(false)
+	tb.ip(0x0d, 1, 0x100b) // ret
+	tb.ip(0x1d, 1, 0x1010) // FUP
+	tb.ip(0x0d, 1, 0x2000) // TIP
+	tb.ip(0x0d, 1, 0x1010) // ret
+
+	tgt := []Branch{
+		{0x1006, 0x2000},
+		{0x2001, 0x100b},
+		{0x1010, 0x2000},
+		{0x2001, 0x1010},
+	}
+	branches, err := Decode(tb, decodeTestProgram, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branches, tgt) {
+		t.Fatalf("got %#x expected %#x", branches, tgt)
+	}
+}
+
+func TestDecodeDesync(t *testing.T) {
+	// The TNT packet does not match the program, decoding resumes at the
+	// next packet containing an address.
+	var tb traceBuilder
+	tb.psb(0x2000)
+	tb.tnt(true)
+	tb.ip(0x0d, 1, 0x100b)
+	tb.psb(0x1000)
+	tb.tnt(true)
+
+	tgt := []Branch{{0x1004, 0x1010}}
+	branches, err := Decode(tb, decodeTestProgram, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branches, tgt) {
+		t.Fatalf("got %#x expected %#x", branches, tgt)
+	}
+
+	if _, err := Decode([]byte{0x00, 0x00}, decodeTestProgram, 0, 10); err == nil {
+		t.Fatal("expected error for trace without PSB")
+	}
+}
+
+func TestTNTBits(t *testing.T) {
+	for _, tc := range []struct {
+		v   uint64
+		n   int
+		tgt []bool
+	}{
+		{0x02, 6, []bool{false}},
+		{0x05, 6, []bool{false, true}},
+		{0x7f, 6, []bool{true, true, true, true, true, true}},
+		{1<<47 | 1, 47, append(make([]bool, 46), true)},
+	} {
+		if got := tntBits(tc.v, tc.n); !reflect.DeepEqual(got, tc.tgt) {
+			t.Errorf("tntBits(%#x, %d) = %v expected %v", tc.v, tc.n, got, tc.tgt)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package intelpt decodes traces produced by Intel Processor Trace and
+// reconstructs the branches executed by the traced thread.
+//
+// See chapter 33, "Intel Processor Trace", of Intel® 64 and IA-32
+// Architectures Software Developer’s Manual, Volume 3C.
+package intelpt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type packetKind uint8
+
+const (
+	pktPSB    packetKind = iota // synchronization point
+	pktPSBEnd                   // end of the status packets following PSB
+	pktTNT                      // taken/not-taken bits of conditional branches
+	pktTIP                      // target of an indirect branch
+	pktTIPPGE                   // tracing enabled, at ip
+	pktTIPPGD                   // tracing disabled
+	pktFUP                      // source address of an asynchronous event
+	pktOVF                      // internal buffer overflow, packets were lost
+	pktOther                    // timing, mode and power packets
+)
+
+// packet is a decoded packet.
+type packet struct {
+	kind packetKind
+
+	// ip is the address carried by TIP, TIP.PGE, TIP.PGD and FUP packets,
+	// ipValid is false if the packet did not carry an address (IP
+	// suppressed).
+	ip      uint64
+	ipValid bool
+
+	// tnt contains the taken/not-taken bits of a TNT packet, oldest first.
+	tnt []bool
+}
+
+// psb is the 16 bytes PSB packet.
+var psb = bytes.Repeat([]byte{0x02, 0x82}, 8)
+
+// FormatError describes a malformed trace.
+type FormatError struct {
+	Off int
+	Msg string
+}
+
+func (err *FormatError) Error() string {
+	return fmt.Sprintf("malformed Intel PT trace at offset %#x: %s", err.Off, err.Msg)
+}
+
+// extendedLen maps the second byte of packets starting with 0x02 to their
+// size, for packets that are skipped.
+var extendedLen = map[byte]int{
+	0x03: 4,  // CBR
+	0x43: 8,  // PIP
+	0x73: 7,  // TMA
+	0xc8: 7,  // VMCS
+	0x83: 2,  // TraceStop
+	0xc3: 11, // MNT
+	0x62: 2,  // EXSTOP
+	0xe2: 2,  // EXSTOP.IP
+	0xc2: 10, // MWAIT
+	0x22: 4,  // PWRE
+	0xa2: 7,  // PWRX
+	0x33: 2,  // BEP
+	0xb3: 2,  // BEP.IP
+	0x53: 4,  // CFE
+	0xd3: 11, // EVD
+}
+
+// parser splits a trace into packets.
+type parser struct {
+	buf    []byte
+	off    int
+	lastIP uint64
+}
+
+// sync moves the parser to the next PSB packet, returns false if there are
+// none.
+func (p *parser) sync() bool {
+	i := bytes.Index(p.buf[p.off:], psb)
+	if i < 0 {
+		p.off = len(p.buf)
+		return false
+	}
+	p.off += i
+	return true
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &FormatError{Off: p.off, Msg: fmt.Sprintf(format, args...)}
+}
+
+// need returns an error if the buffer does not contain n bytes starting
+// at the current offset.
+func (p *parser) need(n int) error {
+	if p.off+n > len(p.buf) {
+		return p.errorf("truncated packet")
+	}
+	return nil
+}
+
+// next decodes the packet at the current offset.
+func (p *parser) next() (packet, error) {
+	b := p.buf[p.off]
+	switch {
+	case b == 0x00: // PAD
+		p.off++
+		return packet{kind: pktOther}, nil
+
+	case b == 0x02:
+		return p.extended()
+
+	case b&0x01 == 0: // TNT-8
+		p.off++
+		return packet{kind: pktTNT, tnt: tntBits(uint64(b>>1), 6)}, nil
+
+	case b == 0x19: // TSC
+		return p.skip(8)
+
+	case b == 0x59: // MTC
+		return p.skip(2)
+
+	case b == 0x99: // MODE
+		return p.skip(2)
+
+	case b&0x03 == 0x03: // CYC
+		n := 1
+		if b&0x04 != 0 {
+			for {
+				if err := p.need(n + 1); err != nil {
+					return packet{}, err
+				}
+				n++
+				if p.buf[p.off+n-1]&0x01 == 0 {
+					break
+				}
+			}
+		}
+		return p.skip(n)
+	}
+
+	var kind packetKind
+	switch b & 0x1f {
+	case 0x0d:
+		kind = pktTIP
+	case 0x11:
+		kind = pktTIPPGE
+	case 0x01:
+		kind = pktTIPPGD
+	case 0x1d:
+		kind = pktFUP
+	default:
+		return packet{}, p.errorf("unknown packet %#x", b)
+	}
+	return p.ipPacket(kind, b>>5)
+}
+
+// extended decodes packets starting with 0x02.
+func (p *parser) extended() (packet, error) {
+	if err := p.need(2); err != nil {
+		return packet{}, err
+	}
+	switch b := p.buf[p.off+1]; b {
+	case 0x82:
+		if err := p.need(len(psb)); err != nil {
+			return packet{}, err
+		}
+		if !bytes.Equal(p.buf[p.off:p.off+len(psb)], psb) {
+			return packet{}, p.errorf("malformed PSB")
+		}
+		p.off += len(psb)
+		// The last IP is reset at every PSB.
+		p.lastIP = 0
+		return packet{kind: pktPSB}, nil
+	case 0x23:
+		p.off += 2
+		return packet{kind: pktPSBEnd}, nil
+	case 0xf3:
+		p.off += 2
+		return packet{kind: pktOVF}, nil
+	case 0xa3: // Long TNT
+		if err := p.need(8); err != nil {
+			return packet{}, err
+		}
+		var payload [8]byte
+		copy(payload[:], p.buf[p.off+2:p.off+8])
+		p.off += 8
+		return packet{kind: pktTNT, tnt: tntBits(binary.LittleEndian.Uint64(payload[:]), 47)}, nil
+	default:
+		if b&0x1f == 0x12 { // PTW
+			n := 2 + 4
+			if b&0x60 == 0x20 {
+				n = 2 + 8
+			}
+			return p.skip(n)
+		}
+		n, ok := extendedLen[b]
+		if !ok {
+			return packet{}, p.errorf("unknown packet 0x02 %#x", b)
+		}
+		return p.skip(n)
+	}
+}
+
+func (p *parser) skip(n int) (packet, error) {
+	if err := p.need(n); err != nil {
+		return packet{}, err
+	}
+	p.off += n
+	return packet{kind: pktOther}, nil
+}
+
+// ipPacket decodes the payload of TIP, TIP.PGE, TIP.PGD and FUP packets,
+// which is compressed against the last IP.
+func (p *parser) ipPacket(kind packetKind, ipbytes byte) (packet, error) {
+	var n int
+	switch ipbytes {
+	case 0:
+		p.off++
+		return packet{kind: kind}, nil
+	case 1:
+		n = 2
+	case 2:
+		n = 4
+	case 3, 4:
+		n = 6
+	case 6:
+		n = 8
+	default:
+		return packet{}, p.errorf("reserved IP compression %d", ipbytes)
+	}
+	if err := p.need(1 + n); err != nil {
+		return packet{}, err
+	}
+	var payload [8]byte
+	copy(payload[:], p.buf[p.off+1:p.off+1+n])
+	v := binary.LittleEndian.Uint64(payload[:])
+	p.off += 1 + n
+
+	var ip uint64
+	switch ipbytes {
+	case 1, 2, 4:
+		mask := uint64(1)<<(uint(n)*8) - 1
+		ip = (p.lastIP &^ mask) | v
+	case 3:
+		// sign extended from bit 47
+		ip = uint64(int64(v<<16) >> 16)
+	case 6:
+		ip = v
+	}
+	p.lastIP = ip
+	return packet{kind: kind, ip: ip, ipValid: true}, nil
+}
+
+// tntBits returns the taken/not-taken bits in the n least significant bits
+// of v, the most significant set bit among them is the stop bit and the
+// bits following it are returned, oldest first.
+func tntBits(v uint64, n int) []bool {
+	stop := -1
+	for i := n; i >= 0; i-- {
+		if v&(1<<uint(i)) != 0 {
+			stop = i
+			break
+		}
+	}
+	if stop < 0 {
+		return nil
+	}
+	r := make([]bool, 0, stop)
+	for i := stop - 1; i >= 0; i-- {
+		r = append(r, v&(1<<uint(i)) != 0)
+	}
+	return r
+}
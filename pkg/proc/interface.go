@@ -1,5 +1,7 @@
 package proc
 
+import "os"
+
 // Process represents the target of the debugger. This
 // target could be a system process, core file, etc.
 //
@@ -32,6 +34,30 @@ type ProcessInternal interface {
 	Restart(pos string) (Thread, error)
 	Detach(bool) error
 	ContinueOnce() (trapthread Thread, stopReason StopReason, err error)
+	// SetSignalPolicy changes how the signal sig is handled during
+	// ContinueOnce, see SignalPolicy.
+	SetSignalPolicy(sig int, policy SignalPolicy) error
+	// MemoryMap returns the memory map of the target process, or
+	// ErrMemoryMapNotSupported.
+	MemoryMap() ([]MemoryMapEntry, error)
+	// SetMemoryGuards sets the list of memory guards, accesses to guarded
+	// memory stop the target during ContinueOnce and are reported by setting
+	// the FaultAddr field of the faulting thread instead of being delivered
+	// to the target. Returns ErrMemoryGuardsNotSupported if the backend
+	// can not detect guard faults.
+	SetMemoryGuards(guards []*MemoryGuard) error
+	// EnableBranchTrace starts, or stops if enable is false, recording the
+	// branches executed by all threads of the target with Intel Processor
+	// Trace. Returns ErrBranchTraceNotSupported if the backend, or the CPU,
+	// can not record branches.
+	EnableBranchTrace(enable bool) error
+	// BranchTrace returns the Intel Processor Trace data recorded for
+	// thread tid since branch tracing was enabled.
+	BranchTrace(tid int) ([]byte, error)
+	// WriteMinidump writes a Windows minidump of the target process to out,
+	// including all of its memory if full is set. Returns
+	// ErrMinidumpNotSupported if the backend can not write minidumps.
+	WriteMinidump(out *os.File, full bool) error
 
 	WriteBreakpoint(addr uint64) (file string, line int, fn *Function, originalData []byte, err error)
 	EraseBreakpoint(*Breakpoint) error
@@ -0,0 +1,144 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// Itab describes the itab of an interface value: the pair of interface
+// type and concrete type it stores and the table of methods used to call
+// the methods of the interface.
+type Itab struct {
+	// Addr is the address of the runtime.itab struct, it is 0 for empty
+	// interfaces, which do not have an itab.
+	Addr          uint64
+	InterfaceType string
+	// ConcreteType is the type of the value stored in the interface, it is
+	// empty for nil interfaces.
+	ConcreteType string
+	// Methods lists the methods of the interface in the order they appear in
+	// the itab.
+	Methods []ItabMethod
+}
+
+// ItabMethod is an entry of the method table of an itab.
+type ItabMethod struct {
+	// Name is the name of the interface method.
+	Name string
+	// PC is the entry point of the implementation and Fn the function it
+	// belongs to.
+	PC uint64
+	Fn *Function
+}
+
+// InterfaceItab decodes the itab of v, which must be an interface (or a
+// pointer to one), resolving the entries of its method table to the
+// functions that implement the methods of the interface.
+func InterfaceItab(v *Variable) (*Itab, error) {
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	for v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+		if v.Unreadable != nil {
+			return nil, v.Unreadable
+		}
+	}
+	if v.Kind != reflect.Interface {
+		return nil, fmt.Errorf("%s (type %s) is not an interface", v.Name, v.TypeString())
+	}
+	r := &Itab{InterfaceType: v.TypeString()}
+
+	_type, data, isnil := v.readInterface()
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if isnil {
+		return r, nil
+	}
+	if data == nil {
+		return nil, errors.New("invalid interface type")
+	}
+	typ, _, err := runtimeTypeToDIE(_type, data.Addr)
+	if err != nil {
+		return nil, err
+	}
+	r.ConcreteType = typ.String()
+
+	var tab *Variable
+	ityp := resolveTypedef(&v.RealType.(*godwarf.InterfaceType).TypedefType).(*godwarf.StructType)
+	for _, f := range ityp.Field {
+		if f.Name == "tab" {
+			tab, _ = v.toField(f)
+		}
+	}
+	if tab == nil {
+		// empty interface
+		return r, nil
+	}
+	tab = tab.maybeDereference()
+	if tab.Unreadable != nil {
+		return nil, tab.Unreadable
+	}
+	r.Addr = tab.Addr
+
+	// The itab struct is runtime.itab before Go 1.22 and internal/abi.ITab
+	// afterwards, the interface type struct is runtime.interfacetype or
+	// internal/abi.InterfaceType.
+	inter, err := itabMember(tab, "inter", "Inter")
+	if err != nil {
+		return nil, err
+	}
+	inter = inter.maybeDereference()
+	methods, err := itabMember(inter, interfacetypeFieldMhdr, "Methods")
+	if err != nil {
+		return nil, err
+	}
+	methods.loadArrayValues(0, LoadConfig{false, 1, 0, 4096, -1, 0, false, 0})
+	if methods.Unreadable != nil {
+		return nil, methods.Unreadable
+	}
+	fun, err := itabMember(tab, "fun", "Fun")
+	if err != nil {
+		return nil, err
+	}
+
+	mds, err := loadModuleData(v.bi, v.mem)
+	if err != nil {
+		return nil, err
+	}
+	ptrSize := uint64(v.bi.Arch.PtrSize())
+	for i := range methods.Children {
+		var m ItabMethod
+		for _, f := range methods.Children[i].Children {
+			if f.Name == imethodFieldName || f.Name == "Name" {
+				nameoff, _ := constant.Int64Val(f.Value)
+				m.Name, _, _, err = resolveNameOff(v.bi, mds, inter.Addr, uint64(nameoff), v.mem)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		m.PC, err = readUintRaw(tab.mem, fun.Addr+uint64(i)*ptrSize, int64(ptrSize))
+		if err != nil {
+			return nil, err
+		}
+		m.Fn = v.bi.PCToFunc(m.PC)
+		r.Methods = append(r.Methods, m)
+	}
+	return r, nil
+}
+
+// itabMember returns the first field of v with one of the specified names.
+func itabMember(v *Variable, names ...string) (*Variable, error) {
+	for _, name := range names {
+		if f, err := v.structMember(name); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no member %s", v.TypeString(), names[0])
+}
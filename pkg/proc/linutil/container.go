@@ -0,0 +1,22 @@
+package linutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// ContainerRoot returns the path, valid in Delve's mount namespace, of the
+// root directory of process pid if it is running in a different mount
+// namespace (for example inside a container), or the empty string
+// otherwise.
+func ContainerRoot(pid int) string {
+	selfns, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return ""
+	}
+	ns, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+	if err != nil || ns == selfns {
+		return ""
+	}
+	return fmt.Sprintf("/proc/%d/root", pid)
+}
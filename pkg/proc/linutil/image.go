@@ -0,0 +1,247 @@
+package linutil
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// LoadedExecutable describes a Go executable that was not loaded by the
+// kernel, for example because it was started through a custom loader or
+// from a file that only exists in memory.
+type LoadedExecutable struct {
+	// Path is a file that contains the executable, it can be a temporary
+	// file if the executable could only be read from the memory of the
+	// process.
+	Path string
+	// Entry is the relocated entry point of the executable.
+	Entry uint64
+}
+
+// FindLoadedExecutable returns the Go executable running in process pid
+// if it is not the program executed by the kernel (/proc/<pid>/exe), which
+// is the case when the program was started by a custom loader that mapped
+// it in memory. Returns nil if /proc/<pid>/exe is a Go executable or no Go
+// executable could be found in memory.
+// The image of the executable is found by scanning the memory of the
+// process for ELF headers, root is the path of the root directory of the
+// process (see ContainerRoot).
+func FindLoadedExecutable(pid int, root string) (*LoadedExecutable, error) {
+	if isGoExecutable(fmt.Sprintf("/proc/%d/exe", pid)) {
+		return nil, nil
+	}
+	return findGoImage(pid, root)
+}
+
+// isGoExecutable returns true if path is an ELF file produced by the Go
+// linker.
+func isGoExecutable(path string) bool {
+	f, err := elf.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return f.Section(".gopclntab") != nil || f.Section(".note.go.buildid") != nil
+}
+
+// elfImage is an ELF image mapped in memory.
+type elfImage struct {
+	mapping proc.MemoryMapEntry // mapping containing the ELF header
+	hdr     elf.Header64
+	progs   []elf.Prog64
+	reloc   uint64 // difference between load addresses and virtual addresses
+}
+
+func findGoImage(pid int, root string) (*LoadedExecutable, error) {
+	maps, err := MemoryMap(pid)
+	if err != nil {
+		return nil, err
+	}
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Close()
+
+	for _, m := range maps {
+		if m.Offset != 0 || !m.Read {
+			continue
+		}
+		img := readELFImage(mem, m)
+		if img == nil || !img.isExecutable() || !img.hasGoNote(mem) {
+			continue
+		}
+		// A loader can read the whole file in memory before mapping its
+		// segments, the copy also starts with an ELF header but it is not
+		// what is being executed.
+		if !executable(maps, img.reloc+img.hdr.Entry) {
+			continue
+		}
+		path, err := img.file(pid, root, maps, mem)
+		if err != nil {
+			return nil, err
+		}
+		return &LoadedExecutable{Path: path, Entry: img.reloc + img.hdr.Entry}, nil
+	}
+	return nil, nil
+}
+
+// readELFImage reads the ELF header and the program headers at the start
+// of mapping m, returns nil if m does not contain a 64bit little endian
+// ELF image.
+func readELFImage(mem io.ReaderAt, m proc.MemoryMapEntry) *elfImage {
+	img := &elfImage{mapping: m}
+	buf := make([]byte, binary.Size(img.hdr))
+	if _, err := mem.ReadAt(buf, int64(m.Addr)); err != nil {
+		return nil
+	}
+	if !bytes.HasPrefix(buf, []byte(elf.ELFMAG)) || elf.Class(buf[elf.EI_CLASS]) != elf.ELFCLASS64 || elf.Data(buf[elf.EI_DATA]) != elf.ELFDATA2LSB {
+		return nil
+	}
+	binary.Read(bytes.NewReader(buf), binary.LittleEndian, &img.hdr)
+	if img.hdr.Phentsize != uint16(binary.Size(elf.Prog64{})) || img.hdr.Phoff+uint64(img.hdr.Phnum)*uint64(img.hdr.Phentsize) > m.Size {
+		return nil
+	}
+	img.progs = make([]elf.Prog64, img.hdr.Phnum)
+	buf = make([]byte, int(img.hdr.Phnum)*int(img.hdr.Phentsize))
+	if _, err := mem.ReadAt(buf, int64(m.Addr+img.hdr.Phoff)); err != nil {
+		return nil
+	}
+	binary.Read(bytes.NewReader(buf), binary.LittleEndian, img.progs)
+	for _, prog := range img.progs {
+		if elf.ProgType(prog.Type) == elf.PT_LOAD && prog.Off == 0 {
+			img.reloc = m.Addr - prog.Vaddr
+			return img
+		}
+	}
+	return nil
+}
+
+// isExecutable returns true if img is an executable, rather than a shared
+// library (Go libraries built with -buildmode=c-shared also contain a Go
+// build ID).
+func (img *elfImage) isExecutable() bool {
+	if elf.Type(img.hdr.Type) == elf.ET_EXEC {
+		return true
+	}
+	for _, prog := range img.progs {
+		if elf.ProgType(prog.Type) == elf.PT_INTERP {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGoNote returns true if img contains the Go build ID note.
+func (img *elfImage) hasGoNote(mem io.ReaderAt) bool {
+	const ntGoBuildID = 4
+	for _, prog := range img.progs {
+		if elf.ProgType(prog.Type) != elf.PT_NOTE || prog.Filesz > 1<<20 {
+			continue
+		}
+		buf := make([]byte, prog.Filesz)
+		if _, err := mem.ReadAt(buf, int64(img.reloc+prog.Vaddr)); err != nil {
+			continue
+		}
+		// Each note is: namesz, descsz, type, name and desc, with name and
+		// desc padded to 4 bytes.
+		for len(buf) >= 12 {
+			namesz := binary.LittleEndian.Uint32(buf[0:])
+			descsz := binary.LittleEndian.Uint32(buf[4:])
+			typ := binary.LittleEndian.Uint32(buf[8:])
+			buf = buf[12:]
+			n := (uint64(namesz)+3)&^3 + (uint64(descsz)+3)&^3
+			if n > uint64(len(buf)) {
+				break
+			}
+			if typ == ntGoBuildID && strings.TrimRight(string(buf[:namesz]), "\x00") == "Go" {
+				return true
+			}
+			buf = buf[n:]
+		}
+	}
+	return false
+}
+
+// executable returns true if addr belongs to an executable mapping.
+func executable(maps []proc.MemoryMapEntry, addr uint64) bool {
+	for _, m := range maps {
+		if addr >= m.Addr && addr < m.Addr+m.Size {
+			return m.Exec
+		}
+	}
+	return false
+}
+
+// file returns the path of a file containing img. It is, in order of
+// preference, the file mapped by the process (through
+// /proc/<pid>/map_files, which also works for deleted files and for memfd
+// files), a file descriptor of the process referring to the same file, or
+// a temporary file with a copy of the executable if the process still has
+// one in memory.
+func (img *elfImage) file(pid int, root string, maps []proc.MemoryMapEntry, mem io.ReaderAt) (string, error) {
+	m := img.mapping
+	if m.Filename != "" {
+		path := fmt.Sprintf("/proc/%d/map_files/%x-%x", pid, m.Addr, m.Addr+m.Size)
+		if f, err := os.Open(path); err == nil {
+			f.Close()
+			return path, nil
+		}
+		if !strings.HasSuffix(m.Filename, " (deleted)") && filepath.IsAbs(m.Filename) {
+			path := filepath.Join(root, m.Filename)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		fds, _ := filepath.Glob(fmt.Sprintf("/proc/%d/fd/*", pid))
+		for _, fd := range fds {
+			if dest, err := os.Readlink(fd); err == nil && dest == m.Filename {
+				return fd, nil
+			}
+		}
+	}
+
+	// Look for a copy of the whole file, it contains the section headers
+	// and debug sections that are not loaded in memory.
+	size := img.hdr.Shoff + uint64(img.hdr.Shnum)*uint64(img.hdr.Shentsize)
+	hdr := make([]byte, binary.Size(img.hdr))
+	if _, err := mem.ReadAt(hdr, int64(m.Addr)); err != nil {
+		return "", err
+	}
+	for _, cp := range maps {
+		if cp.Addr == m.Addr || !cp.Read || cp.Size < size {
+			continue
+		}
+		buf := make([]byte, len(hdr))
+		if _, err := mem.ReadAt(buf, int64(cp.Addr)); err != nil {
+			continue
+		}
+		if !bytes.Equal(buf, hdr) {
+			continue
+		}
+		buf = make([]byte, size)
+		if _, err := mem.ReadAt(buf, int64(cp.Addr)); err != nil {
+			continue
+		}
+		f, err := ioutil.TempFile("", "dlv-executable-")
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(buf)
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+			return "", err
+		}
+		return f.Name(), nil
+	}
+	return "", fmt.Errorf("could not find the file of the executable loaded at %#x", m.Addr)
+}
@@ -0,0 +1,34 @@
+package linutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFindGoImage(t *testing.T) {
+	// The test executable is loaded by the kernel, but it can be found in
+	// memory just like an executable mapped by a custom loader.
+	pid := os.Getpid()
+	exe, err := findGoImage(pid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exe == nil {
+		t.Fatal("executable not found")
+	}
+	auxv, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/auxv", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := EntryPointFromAuxv(auxv, 8); exe.Entry != entry {
+		t.Errorf("entry point %#x, expected %#x", exe.Entry, entry)
+	}
+	if !isGoExecutable(exe.Path) {
+		t.Errorf("%s is not a Go executable", exe.Path)
+	}
+	if exe, _ := FindLoadedExecutable(pid, ""); exe != nil {
+		t.Errorf("executable found for a program loaded by the kernel: %s", exe.Path)
+	}
+}
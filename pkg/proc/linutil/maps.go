@@ -0,0 +1,58 @@
+package linutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// MemoryMap returns the memory map of process pid, read from
+// /proc/<pid>/maps.
+func MemoryMap(pid int) ([]proc.MemoryMapEntry, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r []proc.MemoryMapEntry
+	scan := bufio.NewScanner(f)
+	for scan.Scan() {
+		// Each line has the format:
+		//	start-end perms offset dev inode [pathname]
+		fields := strings.Fields(scan.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		addrs := strings.SplitN(fields[0], "-", 2)
+		if len(addrs) != 2 || len(fields[1]) < 3 {
+			continue
+		}
+		start, err1 := strconv.ParseUint(addrs[0], 16, 64)
+		end, err2 := strconv.ParseUint(addrs[1], 16, 64)
+		offset, err3 := strconv.ParseUint(fields[2], 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("malformed memory map entry: %q", scan.Text())
+		}
+		entry := proc.MemoryMapEntry{
+			Addr:   start,
+			Size:   end - start,
+			Read:   fields[1][0] == 'r',
+			Write:  fields[1][1] == 'w',
+			Exec:   fields[1][2] == 'x',
+			Offset: offset,
+		}
+		if len(fields) > 5 {
+			entry.Filename = strings.Join(fields[5:], " ")
+		}
+		if entry.Filename == "[vvar]" {
+			// Reading [vvar] through ptrace fails
+			entry.Read = false
+		}
+		r = append(r, entry)
+	}
+	return r, scan.Err()
+}
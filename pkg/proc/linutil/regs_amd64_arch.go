@@ -1,6 +1,8 @@
 package linutil
 
 import (
+	"strings"
+
 	"golang.org/x/arch/x86/x86asm"
 
 	"github.com/go-delve/delve/pkg/proc"
@@ -285,6 +287,56 @@ func (r *AMD64Registers) Get(n int) (uint64, error) {
 	return 0, proc.ErrUnknownRegister
 }
 
+// SetReg changes the value of the register called name (as returned by
+// Slice) in r, it does not change the registers of the thread.
+func (r *AMD64Registers) SetReg(name string, value uint64) error {
+	var p *uint64
+	switch strings.ToLower(name) {
+	case "rip":
+		p = &r.Regs.Rip
+	case "rsp":
+		p = &r.Regs.Rsp
+	case "rax":
+		p = &r.Regs.Rax
+	case "rbx":
+		p = &r.Regs.Rbx
+	case "rcx":
+		p = &r.Regs.Rcx
+	case "rdx":
+		p = &r.Regs.Rdx
+	case "rdi":
+		p = &r.Regs.Rdi
+	case "rsi":
+		p = &r.Regs.Rsi
+	case "rbp":
+		p = &r.Regs.Rbp
+	case "r8":
+		p = &r.Regs.R8
+	case "r9":
+		p = &r.Regs.R9
+	case "r10":
+		p = &r.Regs.R10
+	case "r11":
+		p = &r.Regs.R11
+	case "r12":
+		p = &r.Regs.R12
+	case "r13":
+		p = &r.Regs.R13
+	case "r14":
+		p = &r.Regs.R14
+	case "r15":
+		p = &r.Regs.R15
+	case "orig_rax":
+		p = &r.Regs.Orig_rax
+	case "rflags":
+		p = &r.Regs.Eflags
+	default:
+		return proc.ErrUnknownRegister
+	}
+	*p = value
+	return nil
+}
+
 // Copy returns a copy of these registers that is guaranteed not to change.
 func (r *AMD64Registers) Copy() (proc.Registers, error) {
 	if r.loadFpRegs != nil {
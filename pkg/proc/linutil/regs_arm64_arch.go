@@ -2,6 +2,9 @@ package linutil
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
 	"golang.org/x/arch/arm64/arm64asm"
 
 	"github.com/go-delve/delve/pkg/proc"
@@ -122,6 +125,30 @@ func (r *ARM64Registers) Get(n int) (uint64, error) {
 	return 0, proc.ErrUnknownRegister
 }
 
+// SetReg changes the value of the register called name (as returned by
+// Slice) in r, it does not change the registers of the thread.
+func (r *ARM64Registers) SetReg(name string, value uint64) error {
+	name = strings.ToUpper(name)
+	switch name {
+	case "SP":
+		r.Regs.Sp = value
+	case "PC":
+		r.Regs.Pc = value
+	case "PSTATE":
+		r.Regs.Pstate = value
+	default:
+		if !strings.HasPrefix(name, "X") {
+			return proc.ErrUnknownRegister
+		}
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n < 0 || n >= len(r.Regs.Regs) {
+			return proc.ErrUnknownRegister
+		}
+		r.Regs.Regs[n] = value
+	}
+	return nil
+}
+
 // Copy returns a copy of these registers that is guaranteed not to change.
 func (r *ARM64Registers) Copy() (proc.Registers, error) {
 	if r.loadFpRegs != nil {
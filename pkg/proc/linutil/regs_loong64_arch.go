@@ -0,0 +1,165 @@
+package linutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// LOONG64Registers implements the proc.Registers interface for the
+// linux/loong64 core backend.
+type LOONG64Registers struct {
+	Regs   *LOONG64PtraceRegs // general-purpose registers
+	Fpregs []proc.Register    // formatted floating point registers
+
+	loadFpRegs func(*LOONG64Registers) error
+}
+
+func NewLOONG64Registers(regs *LOONG64PtraceRegs, loadFpRegs func(*LOONG64Registers) error) *LOONG64Registers {
+	return &LOONG64Registers{Regs: regs, loadFpRegs: loadFpRegs}
+}
+
+// LOONG64PtraceRegs is the struct used by the linux kernel to return the
+// general purpose registers for LOONG64 CPUs.
+// source: arch/loongarch/include/uapi/asm/ptrace.h (struct user_pt_regs)
+type LOONG64PtraceRegs struct {
+	Regs     [32]uint64
+	OrigA0   uint64
+	Era      uint64
+	Badv     uint64
+	Reserved [10]uint64
+}
+
+// Slice returns the registers as a list of (name, value) pairs.
+func (r *LOONG64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	out := make([]proc.Register, 0, len(r.Regs.Regs)+3+len(r.Fpregs))
+	for i, v := range r.Regs.Regs {
+		out = proc.AppendUint64Register(out, fmt.Sprintf("R%d", i), v)
+	}
+	out = proc.AppendUint64Register(out, "PC", r.Regs.Era)
+	out = proc.AppendUint64Register(out, "BADV", r.Regs.Badv)
+	out = proc.AppendUint64Register(out, "ORIG_A0", r.Regs.OrigA0)
+	var floatLoadError error
+	if floatingPoint {
+		if r.loadFpRegs != nil {
+			floatLoadError = r.loadFpRegs(r)
+			r.loadFpRegs = nil
+		}
+		out = append(out, r.Fpregs...)
+	}
+	return out, floatLoadError
+}
+
+// PC returns the value of the PC register (CSR.ERA).
+func (r *LOONG64Registers) PC() uint64 {
+	return r.Regs.Era
+}
+
+// SP returns the value of the SP register (R3).
+func (r *LOONG64Registers) SP() uint64 {
+	return r.Regs.Regs[3]
+}
+
+func (r *LOONG64Registers) BP() uint64 {
+	return r.Regs.Regs[22]
+}
+
+// TLS returns the address of the thread local storage memory segment.
+func (r *LOONG64Registers) TLS() uint64 {
+	return 0
+}
+
+// GAddr returns the address of the G variable if it is known, 0 and false
+// otherwise. Go stores it in R22.
+func (r *LOONG64Registers) GAddr() (uint64, bool) {
+	return r.Regs.Regs[22], true
+}
+
+// Get returns the value of the n-th general purpose register (which is
+// also its DWARF register number).
+func (r *LOONG64Registers) Get(n int) (uint64, error) {
+	if n >= 0 && n < len(r.Regs.Regs) {
+		return r.Regs.Regs[n], nil
+	}
+	return 0, proc.ErrUnknownRegister
+}
+
+// SetReg changes the value of the register called name (as returned by
+// Slice) in r, it does not change the registers of the thread.
+func (r *LOONG64Registers) SetReg(name string, value uint64) error {
+	name = strings.ToUpper(name)
+	switch name {
+	case "PC":
+		r.Regs.Era = value
+	case "BADV":
+		r.Regs.Badv = value
+	case "ORIG_A0":
+		r.Regs.OrigA0 = value
+	default:
+		if !strings.HasPrefix(name, "R") {
+			return proc.ErrUnknownRegister
+		}
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n < 0 || n >= len(r.Regs.Regs) {
+			return proc.ErrUnknownRegister
+		}
+		r.Regs.Regs[n] = value
+	}
+	return nil
+}
+
+// Copy returns a copy of these registers that is guaranteed not to change.
+func (r *LOONG64Registers) Copy() (proc.Registers, error) {
+	if r.loadFpRegs != nil {
+		err := r.loadFpRegs(r)
+		r.loadFpRegs = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rr LOONG64Registers
+	rr.Regs = &LOONG64PtraceRegs{}
+	*(rr.Regs) = *(r.Regs)
+	if r.Fpregs != nil {
+		rr.Fpregs = make([]proc.Register, len(r.Fpregs))
+		copy(rr.Fpregs, r.Fpregs)
+	}
+	return &rr, nil
+}
+
+// LOONG64PtraceFpRegs is the struct used by the linux kernel to return the
+// floating point registers for LOONG64 CPUs.
+// source: arch/loongarch/include/uapi/asm/ptrace.h (struct user_fp_state)
+type LOONG64PtraceFpRegs struct {
+	Fregs [32]uint64
+	Fcc   uint64
+	Fcsr  uint32
+}
+
+// LOONG64FpRegsFromBytes decodes a NT_FPREGSET note.
+func LOONG64FpRegsFromBytes(buf []byte) (*LOONG64PtraceFpRegs, error) {
+	var fpregs LOONG64PtraceFpRegs
+	if len(buf) < binary.Size(fpregs) {
+		return nil, fmt.Errorf("floating point register set too short (%d bytes)", len(buf))
+	}
+	for i := range fpregs.Fregs {
+		fpregs.Fregs[i] = binary.LittleEndian.Uint64(buf[i*8:])
+	}
+	fpregs.Fcc = binary.LittleEndian.Uint64(buf[32*8:])
+	fpregs.Fcsr = binary.LittleEndian.Uint32(buf[33*8:])
+	return &fpregs, nil
+}
+
+// Decode returns the floating point registers as a list of (name, value)
+// pairs.
+func (fpregs *LOONG64PtraceFpRegs) Decode() (regs []proc.Register) {
+	for i, v := range fpregs.Fregs {
+		regs = proc.AppendUint64Register(regs, fmt.Sprintf("F%d", i), v)
+	}
+	regs = proc.AppendUint64Register(regs, "FCC", fpregs.Fcc)
+	regs = proc.AppendUint64Register(regs, "FCSR", uint64(fpregs.Fcsr))
+	return
+}
@@ -58,3 +58,55 @@ func TestAMD64Get(t *testing.T) {
 		t.Fatalf("expected %#v, got %#v\n", val, rax)
 	}
 }
+
+func TestAMD64SetReg(t *testing.T) {
+	regs := AMD64Registers{Regs: &AMD64PtraceRegs{}}
+	for _, name := range []string{"Rdi", "rsi", "R8", "Rip", "Rsp"} {
+		if err := regs.SetReg(name, 0xdeadbeef); err != nil {
+			t.Fatalf("SetReg(%q): %v", name, err)
+		}
+	}
+	if regs.Regs.Rdi != 0xdeadbeef || regs.Regs.Rsi != 0xdeadbeef || regs.Regs.R8 != 0xdeadbeef || regs.PC() != 0xdeadbeef || regs.SP() != 0xdeadbeef {
+		t.Fatalf("wrong register values %#v", regs.Regs)
+	}
+	if err := regs.SetReg("Xmm0", 1); err == nil {
+		t.Fatal("SetReg(Xmm0) did not return an error")
+	}
+}
+
+func TestARM64SetReg(t *testing.T) {
+	regs := ARM64Registers{Regs: &ARM64PtraceRegs{}}
+	for _, name := range []string{"X0", "x7", "X30", "PC", "SP"} {
+		if err := regs.SetReg(name, 0xdeadbeef); err != nil {
+			t.Fatalf("SetReg(%q): %v", name, err)
+		}
+	}
+	if regs.Regs.Regs[0] != 0xdeadbeef || regs.Regs.Regs[7] != 0xdeadbeef || regs.Regs.Regs[30] != 0xdeadbeef || regs.PC() != 0xdeadbeef || regs.SP() != 0xdeadbeef {
+		t.Fatalf("wrong register values %#v", regs.Regs)
+	}
+	for _, name := range []string{"X31", "V0", "X"} {
+		if err := regs.SetReg(name, 1); err == nil {
+			t.Fatalf("SetReg(%q) did not return an error", name)
+		}
+	}
+}
+
+func TestLOONG64SetReg(t *testing.T) {
+	regs := LOONG64Registers{Regs: &LOONG64PtraceRegs{}}
+	for _, name := range []string{"R1", "r4", "R22", "PC", "R3"} {
+		if err := regs.SetReg(name, 0xdeadbeef); err != nil {
+			t.Fatalf("SetReg(%q): %v", name, err)
+		}
+	}
+	if regs.Regs.Regs[1] != 0xdeadbeef || regs.Regs.Regs[4] != 0xdeadbeef || regs.BP() != 0xdeadbeef || regs.PC() != 0xdeadbeef || regs.SP() != 0xdeadbeef {
+		t.Fatalf("wrong register values %#v", regs.Regs)
+	}
+	if v, err := regs.Get(4); err != nil || v != 0xdeadbeef {
+		t.Fatalf("Get(4) = %#x, %v", v, err)
+	}
+	for _, name := range []string{"R32", "F0", "R"} {
+		if err := regs.SetReg(name, 1); err == nil {
+			t.Fatalf("SetReg(%q) did not return an error", name)
+		}
+	}
+}
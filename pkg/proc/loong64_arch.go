@@ -0,0 +1,175 @@
+package proc
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/frame"
+	"github.com/go-delve/delve/pkg/dwarf/op"
+)
+
+// The mapping between hardware registers and DWARF registers is specified
+// in the LoongArch ELF psABI, section "DWARF Register Numbers": general
+// purpose registers R0-R31 are 0-31 and floating point registers F0-F31
+// are 32-63. The PC does not have a DWARF register number, we use 64.
+const (
+	loong64DwarfIPRegNum uint64 = 64
+	loong64DwarfSPRegNum uint64 = 3
+	loong64DwarfLRRegNum uint64 = 1
+	// R22 is the frame pointer in the psABI but Go uses it to store the
+	// current G and does not use frame pointers on loong64.
+	loong64DwarfBPRegNum uint64 = 22
+)
+
+// emLoongArch is the ELF machine type of LoongArch executables, it is
+// not defined by debug/elf in older versions of Go.
+const emLoongArch elf.Machine = 258
+
+// BREAK 0
+var loong64BreakInstruction = []byte{0x00, 0x00, 0x2a, 0x00}
+
+// LOONG64Arch returns an initialized LOONG64
+// struct.
+func LOONG64Arch(goos string) *Arch {
+	return &Arch{
+		Name:                             "loong64",
+		ptrSize:                          8,
+		maxInstructionLength:             4,
+		breakpointInstruction:            loong64BreakInstruction,
+		breakInstrMovesPC:                false,
+		derefTLS:                         false,
+		fixFrameUnwindContext:            loong64FixFrameUnwindContext,
+		switchStack:                      loong64SwitchStack,
+		regSize:                          loong64RegSize,
+		RegistersToDwarfRegisters:        loong64RegistersToDwarfRegisters,
+		addrAndStackRegsToDwarfRegisters: loong64AddrAndStackRegsToDwarfRegisters,
+		DwarfRegisterToString:            loong64DwarfRegisterToString,
+		isVectorRegister:                 func(string) bool { return false },
+		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
+		asmDecode:                        loong64AsmDecode,
+		usesLR:                           true,
+	}
+}
+
+func loong64FixFrameUnwindContext(fctxt *frame.FrameContext, pc uint64, bi *BinaryInfo) *frame.FrameContext {
+	if fctxt == nil {
+		// Without a frame descriptor entry, and without frame pointers, the
+		// best we can do is assume that we are at the entry point of a
+		// function: the return address is in the link register and the CFA is
+		// the stack pointer.
+		return &frame.FrameContext{
+			RetAddrReg: loong64DwarfIPRegNum,
+			Regs: map[uint64]frame.DWRule{
+				loong64DwarfIPRegNum: frame.DWRule{
+					Rule: frame.RuleRegister,
+					Reg:  loong64DwarfLRRegNum,
+				},
+				loong64DwarfSPRegNum: frame.DWRule{
+					Rule:   frame.RuleValOffset,
+					Offset: 0,
+				},
+			},
+			CFA: frame.DWRule{
+				Rule:   frame.RuleCFA,
+				Reg:    loong64DwarfSPRegNum,
+				Offset: 0,
+			},
+		}
+	}
+
+	// The value of the link register is only saved by functions that call
+	// other functions, leaf functions leave it unchanged.
+	if fctxt.Regs[loong64DwarfLRRegNum].Rule == frame.RuleUndefined {
+		fctxt.Regs[loong64DwarfLRRegNum] = frame.DWRule{
+			Rule:   frame.RuleFramePointer,
+			Reg:    loong64DwarfLRRegNum,
+			Offset: 0,
+		}
+	}
+
+	return fctxt
+}
+
+func loong64SwitchStack(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool {
+	if it.frame.Current.Fn == nil {
+		return false
+	}
+	switch it.frame.Current.Fn.Name {
+	case "runtime.goexit", "runtime.rt0_go", "runtime.mcall":
+		// Look for "top of stack" functions.
+		it.atend = true
+		return true
+	default:
+		if it.systemstack && it.top && it.g != nil && strings.HasPrefix(it.frame.Current.Fn.Name, "runtime.") && it.frame.Current.Fn.Name != "runtime.fatalthrow" {
+			// The runtime switches to the system stack in multiple places, see
+			// the comment in arm64SwitchStack.
+			it.switchToGoroutineStack()
+			return true
+		}
+	}
+	return false
+}
+
+func loong64RegSize(regnum uint64) int {
+	return 8 // general and floating point registers
+}
+
+var loong64NameToDwarf = func() map[string]int {
+	r := make(map[string]int)
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("r%d", i)] = i
+	}
+	r["pc"] = int(loong64DwarfIPRegNum)
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("f%d", i)] = i + 32
+	}
+	return r
+}()
+
+// loong64RegistersToDwarfRegisters converts regs to DWARF registers,
+// Registers.Get of loong64 registers takes DWARF register numbers.
+func loong64RegistersToDwarfRegisters(staticBase uint64, regs Registers) op.DwarfRegisters {
+	dregs := make([]*op.DwarfRegister, loong64DwarfIPRegNum+1)
+
+	for i := 0; i <= 31; i++ {
+		if v, err := regs.Get(i); err == nil {
+			dregs[i] = op.DwarfRegisterFromUint64(v)
+		}
+	}
+	dregs[loong64DwarfIPRegNum] = op.DwarfRegisterFromUint64(regs.PC())
+	dregs[loong64DwarfSPRegNum] = op.DwarfRegisterFromUint64(regs.SP())
+
+	dr := op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, loong64DwarfIPRegNum, loong64DwarfSPRegNum, loong64DwarfBPRegNum, loong64DwarfLRRegNum)
+	dr.SetLoadMoreCallback(loadMoreDwarfRegistersFromSliceFunc(dr, regs, loong64NameToDwarf))
+	return *dr
+}
+
+func loong64AddrAndStackRegsToDwarfRegisters(staticBase, pc, sp, bp, lr uint64) op.DwarfRegisters {
+	dregs := make([]*op.DwarfRegister, loong64DwarfIPRegNum+1)
+	dregs[loong64DwarfIPRegNum] = op.DwarfRegisterFromUint64(pc)
+	dregs[loong64DwarfSPRegNum] = op.DwarfRegisterFromUint64(sp)
+	dregs[loong64DwarfBPRegNum] = op.DwarfRegisterFromUint64(bp)
+	dregs[loong64DwarfLRRegNum] = op.DwarfRegisterFromUint64(lr)
+
+	return *op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, loong64DwarfIPRegNum, loong64DwarfSPRegNum, loong64DwarfBPRegNum, loong64DwarfLRRegNum)
+}
+
+func loong64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floatingPoint bool, repr string) {
+	switch {
+	case i <= 31:
+		name = fmt.Sprintf("R%d", i)
+	case i <= 63:
+		name = fmt.Sprintf("F%d", i-32)
+		floatingPoint = true
+	case i == int(loong64DwarfIPRegNum):
+		name = "PC"
+	default:
+		name = fmt.Sprintf("unknown%d", i)
+	}
+	if reg.Bytes != nil && len(reg.Bytes) > 8 {
+		return name, floatingPoint, fmt.Sprintf("%#x", reg.Bytes)
+	}
+	return name, floatingPoint, fmt.Sprintf("%#016x", reg.Uint64Val)
+}
@@ -0,0 +1,150 @@
+package proc
+
+import "fmt"
+
+// There is no LoongArch disassembler in golang.org/x/arch, loong64Inst
+// only decodes the instructions needed to classify calls, returns, jumps
+// and breakpoints, everything else is displayed as a data word.
+
+type loong64Op uint8
+
+const (
+	loong64OpOther loong64Op = iota
+	loong64OpB
+	loong64OpBL
+	loong64OpJIRL
+	loong64OpBREAK
+	loong64OpSYSCALL
+)
+
+type loong64Inst struct {
+	op     loong64Op
+	raw    uint32
+	rd, rj uint32
+	offs   int64 // branch offset in bytes, for B, BL and JIRL
+}
+
+func loong64Decode(mem []byte) (loong64Inst, error) {
+	if len(mem) < 4 {
+		return loong64Inst{}, fmt.Errorf("instruction too short")
+	}
+	raw := uint32(mem[0]) | uint32(mem[1])<<8 | uint32(mem[2])<<16 | uint32(mem[3])<<24
+	inst := loong64Inst{raw: raw, rd: raw & 0x1f, rj: (raw >> 5) & 0x1f}
+	switch raw >> 26 {
+	case 0x13: // JIRL rd, rj, offs16
+		inst.op = loong64OpJIRL
+		inst.offs = int64(int16(raw>>10)) << 2
+	case 0x14, 0x15: // B offs26, BL offs26
+		inst.op = loong64OpB
+		if raw>>26 == 0x15 {
+			inst.op = loong64OpBL
+		}
+		offs := (raw>>10)&0xffff | (raw&0x3ff)<<16
+		inst.offs = int64(int32(offs<<6)>>6) << 2
+	default:
+		switch raw >> 15 {
+		case 0x54:
+			inst.op = loong64OpBREAK
+		case 0x56:
+			inst.op = loong64OpSYSCALL
+		}
+	}
+	return inst, nil
+}
+
+func loong64AsmDecode(asmInst *AsmInstruction, mem []byte, regs Registers, memrw MemoryReadWriter, bi *BinaryInfo) error {
+	asmInst.Size = 4
+	asmInst.Bytes = mem[:asmInst.Size]
+
+	inst, err := loong64Decode(mem)
+	if err != nil {
+		asmInst.Inst = (*loong64ArchInst)(nil)
+		return err
+	}
+
+	asmInst.Inst = (*loong64ArchInst)(&inst)
+	asmInst.Kind = OtherInstruction
+
+	var dest uint64
+	switch inst.op {
+	case loong64OpBL:
+		asmInst.Kind = CallInstruction
+		dest = uint64(int64(asmInst.Loc.PC) + inst.offs)
+	case loong64OpB:
+		asmInst.Kind = JmpInstruction
+		dest = uint64(int64(asmInst.Loc.PC) + inst.offs)
+	case loong64OpJIRL:
+		switch {
+		case inst.rd == 0 && inst.rj == 1 && inst.offs == 0:
+			asmInst.Kind = RetInstruction
+			return nil
+		case inst.rd == 1:
+			asmInst.Kind = CallInstruction
+		case inst.rd == 0:
+			asmInst.Kind = JmpInstruction
+		}
+		if !asmInst.AtPC || regs == nil {
+			return nil
+		}
+		base, err := regs.Get(int(inst.rj))
+		if err != nil {
+			return nil
+		}
+		dest = uint64(int64(base) + inst.offs)
+	case loong64OpBREAK:
+		asmInst.Kind = HardBreakInstruction
+		return nil
+	default:
+		return nil
+	}
+
+	file, line, fn := bi.PCToLine(dest)
+	if fn == nil {
+		asmInst.DestLoc = &Location{PC: dest}
+	} else {
+		asmInst.DestLoc = &Location{PC: dest, File: file, Line: line, Fn: fn}
+	}
+	return nil
+}
+
+type loong64ArchInst loong64Inst
+
+func (inst *loong64ArchInst) Text(flavour AssemblyFlavour, pc uint64, symLookup func(uint64) (string, uint64)) string {
+	if inst == nil {
+		return "?"
+	}
+
+	target := func() string {
+		dest := uint64(int64(pc) + inst.offs)
+		if symLookup != nil {
+			if sym, _ := symLookup(dest); sym != "" {
+				return fmt.Sprintf("%s(SB)", sym)
+			}
+		}
+		return fmt.Sprintf("%#x", dest)
+	}
+
+	switch inst.op {
+	case loong64OpB:
+		return "JMP " + target()
+	case loong64OpBL:
+		return "CALL " + target()
+	case loong64OpJIRL:
+		if inst.rd == 0 && inst.rj == 1 && inst.offs == 0 {
+			return "RET"
+		}
+		return fmt.Sprintf("JIRL R%d, R%d, %d", inst.rd, inst.rj, inst.offs)
+	case loong64OpBREAK:
+		return fmt.Sprintf("BREAK %d", inst.raw&0x7fff)
+	case loong64OpSYSCALL:
+		return fmt.Sprintf("SYSCALL %d", inst.raw&0x7fff)
+	}
+	return fmt.Sprintf("WORD $%#08x", inst.raw)
+}
+
+func (inst *loong64ArchInst) OpcodeEquals(op uint64) bool {
+	if inst == nil {
+		return false
+	}
+	return uint64(inst.op) == op
+}
@@ -0,0 +1,148 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// MapFilter selects and orders the entries of a map loaded by FilterMap.
+type MapFilter struct {
+	// KeyRegexp, if not empty, selects the entries whose key matches it.
+	// Keys are matched against their value formatted as a string, which
+	// means that only maps with string, numeric or boolean keys can be
+	// filtered.
+	KeyRegexp string
+	// Sorted sorts the returned entries by key. Only maps with string or
+	// numeric keys can be sorted.
+	Sorted bool
+}
+
+type mapEntry struct {
+	key, val *Variable
+}
+
+// FilterMap evaluates expr, which must be a map, and loads the entries
+// selected by filter. At most cfg.MaxArrayValues entries are loaded, if
+// filter.Sorted is set these are the entries with the smallest keys.
+// The Len field of the returned variable is the total number of entries
+// of the map.
+//
+// All keys of the map are read to find the selected entries (or only the
+// keys in the first cfg.MaxMapBuckets buckets, if it is set), but only the
+// values of the returned entries are loaded.
+func (scope *EvalScope) FilterMap(expr string, filter MapFilter, cfg LoadConfig) (*Variable, error) {
+	var re *regexp.Regexp
+	if filter.KeyRegexp != "" {
+		var err error
+		re, err = regexp.Compile(filter.KeyRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key regexp: %v", err)
+		}
+	}
+
+	v, err := scope.EvalExpression(expr, loadSingleValue)
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind != reflect.Map {
+		return nil, fmt.Errorf("%s (type %s) is not a map", expr, v.TypeString())
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+
+	it := v.mapIterator()
+	if it == nil {
+		return nil, v.Unreadable
+	}
+	it.maxNumBuckets = uint64(cfg.MaxMapBuckets)
+
+	keycfg := loadSingleValue
+	keycfg.MaxStringLen = cfg.MaxStringLen
+
+	var entries []mapEntry
+	errcount := 0
+	for it.next() {
+		key := it.key()
+		if re != nil || filter.Sorted {
+			key.loadValue(keycfg)
+			if key.Unreadable != nil {
+				errcount++
+				if errcount > maxErrCount {
+					break
+				}
+				continue
+			}
+		}
+		if re != nil {
+			s, ok := mapKeyString(key)
+			if !ok {
+				return nil, fmt.Errorf("can not filter keys of type %s", key.TypeString())
+			}
+			if !re.MatchString(s) {
+				continue
+			}
+		}
+		if filter.Sorted {
+			switch key.Kind {
+			case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+				reflect.Float32, reflect.Float64:
+			default:
+				return nil, fmt.Errorf("can not sort keys of type %s", key.TypeString())
+			}
+		}
+		var val *Variable
+		if it.values.fieldType.Size() > 0 {
+			val = it.value()
+		} else {
+			val = v.newVariable("", it.values.Addr, it.values.fieldType, DereferenceMemory(v.mem))
+		}
+		entries = append(entries, mapEntry{key, val})
+		if !filter.Sorted && len(entries) >= cfg.MaxArrayValues {
+			break
+		}
+	}
+
+	if filter.Sorted {
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].key.Value, entries[j].key.Value
+			if a == nil || b == nil {
+				return false
+			}
+			return constant.Compare(a, token.LSS, b)
+		})
+	}
+	if len(entries) > cfg.MaxArrayValues {
+		entries = entries[:cfg.MaxArrayValues]
+	}
+
+	v.Children = make([]Variable, 0, 2*len(entries))
+	for _, entry := range entries {
+		entry.key.loaded = false
+		entry.key.loadValueInternal(1, cfg)
+		entry.val.loadValueInternal(1, cfg)
+		v.Children = append(v.Children, *entry.key, *entry.val)
+	}
+	return v, nil
+}
+
+// mapKeyString returns the value of key formatted as a string.
+func mapKeyString(key *Variable) (string, bool) {
+	if key.Value == nil {
+		return "", false
+	}
+	switch key.Kind {
+	case reflect.String:
+		return constant.StringVal(key.Value), true
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return key.Value.String(), true
+	}
+	return "", false
+}
@@ -26,6 +26,34 @@ type MemoryReadWriter interface {
 	WriteMemory(addr uint64, data []byte) (written int, err error)
 }
 
+// MemoryReadRequest is one of the reads performed by a batch read, N and
+// Err are set to the result of reading len(Buf) bytes at Addr.
+type MemoryReadRequest struct {
+	Addr uint64
+	Buf  []byte
+	N    int
+	Err  error
+}
+
+// MemoryBatchReader is implemented by memory readers that can read many
+// non-contiguous ranges of memory with a single operation.
+type MemoryBatchReader interface {
+	// ReadMemoryBatch performs all the reads in reqs.
+	ReadMemoryBatch(reqs []MemoryReadRequest)
+}
+
+// readMemoryBatch performs all the reads in reqs, with a single batch read
+// if mem supports it.
+func readMemoryBatch(mem MemoryReader, reqs []MemoryReadRequest) {
+	if bmem, ok := mem.(MemoryBatchReader); ok {
+		bmem.ReadMemoryBatch(reqs)
+		return
+	}
+	for i := range reqs {
+		reqs[i].N, reqs[i].Err = mem.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+	}
+}
+
 type memCache struct {
 	loaded    bool
 	cacheAddr uint64
@@ -53,6 +81,29 @@ func (m *memCache) ReadMemory(data []byte, addr uint64) (n int, err error) {
 	return m.mem.ReadMemory(data, addr)
 }
 
+// ReadMemoryBatch serves the requests contained in the cache from the
+// cache and forwards all the other requests to the underlying memory as a
+// single batch.
+func (m *memCache) ReadMemoryBatch(reqs []MemoryReadRequest) {
+	var rest []MemoryReadRequest
+	var restIdx []int
+	for i := range reqs {
+		if m.contains(reqs[i].Addr, len(reqs[i].Buf)) {
+			reqs[i].N, reqs[i].Err = m.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+			continue
+		}
+		rest = append(rest, reqs[i])
+		restIdx = append(restIdx, i)
+	}
+	if len(rest) == 0 {
+		return
+	}
+	readMemoryBatch(m.mem, rest)
+	for j, i := range restIdx {
+		reqs[i] = rest[j]
+	}
+}
+
 func (m *memCache) WriteMemory(addr uint64, data []byte) (written int, err error) {
 	return m.mem.WriteMemory(addr, data)
 }
@@ -0,0 +1,228 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// MemoryProtection is the protection of a range of memory.
+type MemoryProtection uint8
+
+const (
+	ProtRead MemoryProtection = 1 << iota
+	ProtWrite
+	ProtExec
+
+	ProtNone MemoryProtection = 0
+)
+
+func (prot MemoryProtection) String() string {
+	buf := []byte("---")
+	if prot&ProtRead != 0 {
+		buf[0] = 'r'
+	}
+	if prot&ProtWrite != 0 {
+		buf[1] = 'w'
+	}
+	if prot&ProtExec != 0 {
+		buf[2] = 'x'
+	}
+	return string(buf)
+}
+
+// MemoryGuard is a range of memory of the target process whose protection
+// was changed by GuardMemory. An access to the range that is not allowed
+// by Prot stops the target process with StopGuardFault.
+type MemoryGuard struct {
+	ID         int
+	Addr, Size uint64 // page aligned
+	Prot       MemoryProtection
+
+	// orig is the memory map of the range before it was guarded.
+	orig []MemoryMapEntry
+}
+
+// GuardFault describes an access to memory protected by a MemoryGuard.
+type GuardFault struct {
+	ThreadID int
+	Addr     uint64 // the faulting address
+	PC       uint64 // address of the faulting instruction
+	Guard    *MemoryGuard
+}
+
+// ErrMemoryGuardsNotSupported is returned by the SetMemoryGuards method of
+// backends that can not report faults on guarded memory.
+var ErrMemoryGuardsNotSupported = errors.New("memory guards are not supported by this backend")
+
+// GuardMemory changes the protection of the pages containing the memory
+// range [addr, addr+size) to prot, by calling mprotect in the target
+// process, accesses not allowed by prot will stop the target with
+// StopGuardFault instead of being delivered to it as SIGSEGV.
+//
+// When the target process is resumed after a guard fault the faulting
+// instruction is executed again, and faults again, until the guard is
+// removed with UnguardMemory. Accesses made by the kernel during a system
+// call do not fault, the system call fails with EFAULT instead.
+func (t *Target) GuardMemory(addr, size uint64, prot MemoryProtection) (*MemoryGuard, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	if ok, _ := t.Recorded(); ok {
+		return nil, errors.New("can not change the memory protection of a recording")
+	}
+	if size == 0 {
+		return nil, errors.New("empty memory range")
+	}
+	pageSize := uint64(os.Getpagesize())
+	start := addr &^ (pageSize - 1)
+	end := (addr + size + pageSize - 1) &^ (pageSize - 1)
+	for _, guard := range t.memoryGuards {
+		if start < guard.Addr+guard.Size && guard.Addr < end {
+			return nil, fmt.Errorf("memory range overlaps guard %d (%#x-%#x)", guard.ID, guard.Addr, guard.Addr+guard.Size)
+		}
+	}
+
+	mmap, err := t.proc.MemoryMap()
+	if err != nil {
+		return nil, err
+	}
+	var orig []MemoryMapEntry
+	next := start
+	for _, entry := range mmap {
+		if entry.Addr+entry.Size <= next || entry.Addr >= end {
+			continue
+		}
+		if entry.Addr > next {
+			break
+		}
+		region := entry
+		region.Addr = next
+		region.Size = entry.Addr + entry.Size - next
+		if region.Addr+region.Size > end {
+			region.Size = end - region.Addr
+		}
+		orig = append(orig, region)
+		next = region.Addr + region.Size
+		if next >= end {
+			break
+		}
+	}
+	if next < end {
+		return nil, fmt.Errorf("address %#x is not mapped", next)
+	}
+
+	guard := &MemoryGuard{ID: t.memoryGuardID + 1, Addr: start, Size: end - start, Prot: prot, orig: orig}
+	guards := append(t.MemoryGuards(), guard)
+	if err := t.proc.SetMemoryGuards(guards); err != nil {
+		return nil, err
+	}
+	if err := t.mprotect(start, end-start, prot); err != nil {
+		_ = t.proc.SetMemoryGuards(t.memoryGuards)
+		return nil, err
+	}
+	t.memoryGuardID++
+	t.memoryGuards = guards
+	return guard, nil
+}
+
+// UnguardMemory restores the protection of the memory guarded by the
+// guard with the given ID.
+func (t *Target) UnguardMemory(id int) error {
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	for i, guard := range t.memoryGuards {
+		if guard.ID != id {
+			continue
+		}
+		for _, region := range guard.orig {
+			var prot MemoryProtection
+			if region.Read {
+				prot |= ProtRead
+			}
+			if region.Write {
+				prot |= ProtWrite
+			}
+			if region.Exec {
+				prot |= ProtExec
+			}
+			if err := t.mprotect(region.Addr, region.Size, prot); err != nil {
+				return err
+			}
+		}
+		guards := make([]*MemoryGuard, 0, len(t.memoryGuards)-1)
+		guards = append(guards, t.memoryGuards[:i]...)
+		guards = append(guards, t.memoryGuards[i+1:]...)
+		t.memoryGuards = guards
+		return t.proc.SetMemoryGuards(guards)
+	}
+	return fmt.Errorf("no memory guard with ID %d", id)
+}
+
+// MemoryGuards returns the list of memory guards set with GuardMemory,
+// sorted by address.
+func (t *Target) MemoryGuards() []*MemoryGuard {
+	r := make([]*MemoryGuard, len(t.memoryGuards))
+	copy(r, t.memoryGuards)
+	sort.Slice(r, func(i, j int) bool { return r[i].Addr < r[j].Addr })
+	return r
+}
+
+// findMemoryGuard returns the memory guard containing addr.
+func (t *Target) findMemoryGuard(addr uint64) *MemoryGuard {
+	for _, guard := range t.memoryGuards {
+		if addr >= guard.Addr && addr < guard.Addr+guard.Size {
+			return guard
+		}
+	}
+	return nil
+}
+
+// collectGuardFaults sets t.GuardFault to the first guard fault received
+// by threads, preferring the current thread, and returns true if there was
+// one.
+func (t *Target) collectGuardFaults(threads []Thread) bool {
+	var fault *GuardFault
+	for _, th := range threads {
+		addr := th.Common().FaultAddr
+		if addr == 0 {
+			continue
+		}
+		th.Common().FaultAddr = 0
+		if fault != nil && th != t.CurrentThread() {
+			continue
+		}
+		fault = &GuardFault{ThreadID: th.ThreadID(), Addr: addr, Guard: t.findMemoryGuard(addr)}
+		if regs, err := th.Registers(); err == nil {
+			fault.PC = regs.PC()
+		}
+	}
+	t.GuardFault = fault
+	return fault != nil
+}
+
+// mprotect calls mprotect in the target process.
+func (t *Target) mprotect(addr, size uint64, prot MemoryProtection) error {
+	bi := t.BinInfo()
+	var num uint64
+	switch bi.GOOS + "/" + bi.Arch.Name {
+	case "linux/amd64":
+		num = 10
+	case "linux/arm64":
+		num = 226
+	default:
+		return ErrMemoryGuardsNotSupported
+	}
+	// The values of MemoryProtection are the same as the values of the PROT_*
+	// constants on linux.
+	r, err := t.RawSyscall(num, []uint64{addr, size, uint64(prot)})
+	if err != nil {
+		return fmt.Errorf("could not change memory protection: %v", err)
+	}
+	if r.Errno != 0 {
+		return fmt.Errorf("could not change memory protection: mprotect returned errno %d", r.Errno)
+	}
+	return nil
+}
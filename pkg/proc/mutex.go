@@ -0,0 +1,227 @@
+package proc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Values of the state field of sync.Mutex, see $GOROOT/src/sync/mutex.go.
+const (
+	mutexLocked   = 1 << iota // mutex is locked
+	mutexWoken                // a waiter has been woken
+	mutexStarving             // mutex is in starvation mode
+
+	rwmutexMaxReaders = 1 << 30
+)
+
+// MutexState describes the state of a sync.Mutex or sync.RWMutex variable.
+// The Go runtime does not record which goroutine holds a lock, only which
+// goroutines are blocked waiting for it.
+type MutexState struct {
+	// Locked is true if the mutex is locked, for a RWMutex it is true if a
+	// writer holds the lock or is waiting for the active readers to release
+	// it.
+	Locked bool
+	// Woken is true if a waiter has been woken and is trying to acquire the
+	// mutex.
+	Woken bool
+	// Starving is true if the mutex is in starvation mode.
+	Starving bool
+	// Waiters lists the goroutines blocked acquiring the mutex, for a
+	// RWMutex they are the writers waiting for another writer.
+	Waiters []*G
+
+	// The following fields are only set for sync.RWMutex.
+
+	// RWMutex is true if the variable is a sync.RWMutex.
+	RWMutex bool
+	// Readers is the number of readers holding the lock.
+	Readers int
+	// WriterPending is true if a writer is waiting for the active readers
+	// to release the lock.
+	WriterPending bool
+	// WriterWaiters lists the writers blocked waiting for the active
+	// readers to release the lock.
+	WriterWaiters []*G
+	// ReaderWaiters lists the readers blocked waiting for a writer to
+	// release the lock.
+	ReaderWaiters []*G
+}
+
+// MutexInfo decodes the state of v, which must be a sync.Mutex or a
+// sync.RWMutex (or a pointer to one of them), and looks up the goroutines
+// blocked on its semaphores in the runtime semaphore table.
+func MutexInfo(v *Variable) (*MutexState, error) {
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	for v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+		if v.Unreadable != nil {
+			return nil, v.Unreadable
+		}
+	}
+	switch v.RealType.Common().Name {
+	case "sync.Mutex":
+		state := &MutexState{}
+		if err := readMutex(v, state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	case "sync.RWMutex":
+		state := &MutexState{RWMutex: true}
+		if err := readRWMutex(v, state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	default:
+		return nil, fmt.Errorf("%s (type %s) is not a sync.Mutex or a sync.RWMutex", v.Name, v.TypeString())
+	}
+}
+
+// readMutex decodes the sync.Mutex v into state.
+func readMutex(v *Variable, state *MutexState) error {
+	if muv, err := v.structMember("mu"); err == nil {
+		// Go 1.24 and later: sync.Mutex wraps internal/sync.Mutex.
+		v = muv
+	}
+	statev, err := v.structMember("state")
+	if err != nil {
+		return err
+	}
+	mstate, err := loadUintValue(statev)
+	if err != nil {
+		return err
+	}
+	state.Locked = mstate&mutexLocked != 0
+	state.Woken = mstate&mutexWoken != 0
+	state.Starving = mstate&mutexStarving != 0
+	state.Waiters, err = semaWaiters(v, "sema")
+	return err
+}
+
+// readRWMutex decodes the sync.RWMutex v into state.
+func readRWMutex(v *Variable, state *MutexState) error {
+	wv, err := v.structMember("w")
+	if err != nil {
+		return err
+	}
+	if err := readMutex(wv, state); err != nil {
+		return err
+	}
+	readerCountv, err := v.structMember("readerCount")
+	if err != nil {
+		return err
+	}
+	readerCount, err := loadUintValue(readerCountv)
+	if err != nil {
+		return err
+	}
+	readers := int(int32(readerCount))
+	if readers < 0 {
+		state.WriterPending = true
+		readers += rwmutexMaxReaders
+	}
+	state.Readers = readers
+	state.WriterWaiters, err = semaWaiters(v, "writerSem")
+	if err != nil {
+		return err
+	}
+	state.ReaderWaiters, err = semaWaiters(v, "readerSem")
+	return err
+}
+
+// semaWaiters returns the goroutines blocked on the semaphore stored in
+// the field called name of v, by searching the runtime semaphore table,
+// see $GOROOT/src/runtime/sema.go.
+func semaWaiters(v *Variable, name string) ([]*G, error) {
+	semav, err := v.structMember(name)
+	if err != nil {
+		return nil, err
+	}
+	if semav.Unreadable != nil {
+		return nil, semav.Unreadable
+	}
+	addr := semav.Addr
+
+	scope := globalScope(v.bi, v.bi.Images[0], v.mem)
+	semtablev, err := scope.findGlobal("runtime", "semtable")
+	if err != nil {
+		return nil, err
+	}
+	if semtablev.Len <= 0 {
+		return nil, fmt.Errorf("could not read runtime.semtable")
+	}
+	entryv, err := semtablev.sliceAccess(int((addr >> 3) % uint64(semtablev.Len)))
+	if err != nil {
+		return nil, err
+	}
+	rootv, err := entryv.structMember("root")
+	if err != nil {
+		return nil, err
+	}
+	sudogv, err := rootv.structMember("treap")
+	if err != nil {
+		return nil, err
+	}
+
+	// Search the treap for the node waiting on addr, smaller addresses are
+	// stored in the prev subtree, larger addresses in the next subtree.
+	ptrSize := int64(v.bi.Arch.PtrSize())
+	for {
+		sudogv = sudogv.maybeDereference()
+		if sudogv.Unreadable != nil {
+			return nil, sudogv.Unreadable
+		}
+		if sudogv.Addr == 0 {
+			return nil, nil
+		}
+		elemv, err := sudogv.structMember("elem")
+		if err != nil {
+			return nil, err
+		}
+		elem, err := readUintRaw(elemv.mem, elemv.Addr, ptrSize)
+		if err != nil {
+			return nil, err
+		}
+		if elem == addr {
+			break
+		}
+		child := "next"
+		if addr < elem {
+			child = "prev"
+		}
+		sudogv, err = sudogv.structMember(child)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// All the goroutines waiting on addr are linked through waitlink.
+	var gs []*G
+	seen := make(map[uint64]bool)
+	for {
+		sudogv = sudogv.maybeDereference()
+		if sudogv.Unreadable != nil {
+			return nil, sudogv.Unreadable
+		}
+		if sudogv.Addr == 0 || seen[sudogv.Addr] {
+			break
+		}
+		seen[sudogv.Addr] = true
+		gv, err := sudogv.structMember("g")
+		if err != nil {
+			return nil, err
+		}
+		g, err := gv.parseG()
+		if err != nil {
+			return nil, err
+		}
+		gs = append(gs, g)
+		sudogv, err = sudogv.structMember("waitlink")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return gs, nil
+}
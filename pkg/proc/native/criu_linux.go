@@ -0,0 +1,272 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// Checkpoints of live processes are implemented using CRIU
+// (https://criu.org).
+// To create a checkpoint Delve detaches from the process, leaving it in
+// group-stop, dumps it to a directory with 'criu dump --leave-running' and
+// attaches to it again. Restarting from a checkpoint kills the process and
+// restores it with 'criu restore'.
+// The directories containing the images are not deleted when Delve exits,
+// they can be restored in a new debug session with 'dlv restore'.
+
+// criuCommand is the name of the CRIU executable.
+const criuCommand = "criu"
+
+// ErrCRIUNotFound is returned when checkpoints are requested but CRIU is
+// not installed.
+var ErrCRIUNotFound = errors.New("checkpoints of live processes require CRIU (https://criu.org)")
+
+// criuPidFile is the name of the file, inside the images directory, where
+// 'criu restore' writes the PID of the restored process.
+const criuPidFile = "restore.pid"
+
+func (dbp *nativeProcess) checkpoint(where string) (int, error) {
+	if _, err := exec.LookPath(criuCommand); err != nil {
+		return -1, ErrCRIUNotFound
+	}
+	if dbp.exited {
+		return -1, proc.ErrProcessExited{Pid: dbp.pid}
+	}
+	dir, err := ioutil.TempDir("", "dlv-checkpoint-")
+	if err != nil {
+		return -1, err
+	}
+
+	// Breakpoints are removed from memory so that restored processes do not
+	// contain them, they are written again after the process is restored.
+	for _, bp := range dbp.breakpoints.M {
+		if err := dbp.EraseBreakpoint(bp); err != nil {
+			os.RemoveAll(dir)
+			return -1, err
+		}
+	}
+
+	err = dbp.detachStopped()
+	if err == nil {
+		err = runCRIU(dir, "dump", "-t", strconv.Itoa(dbp.pid), "-o", "dump.log", "--shell-job", "--leave-running")
+	}
+	if err2 := dbp.reattach(); err2 != nil {
+		return -1, fmt.Errorf("could not attach to process after checkpoint: %v", err2)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return -1, err
+	}
+
+	id := 1
+	if n := len(dbp.checkpoints); n > 0 {
+		id = dbp.checkpoints[n-1].ID + 1
+	}
+	dbp.checkpoints = append(dbp.checkpoints, proc.Checkpoint{ID: id, When: dir, Where: where})
+	return id, nil
+}
+
+// detachStopped detaches from all threads of the process leaving it
+// stopped.
+func (dbp *nativeProcess) detachStopped() error {
+	var err error
+	dbp.execPtraceFunc(func() {
+		// Delivering SIGSTOP to the main thread puts the process in
+		// group-stop, the other threads enter it as soon as they are detached.
+		err = ptraceDetach(dbp.pid, int(sys.SIGSTOP))
+		for tid := range dbp.threads {
+			if err != nil {
+				return
+			}
+			if tid != dbp.pid {
+				err = ptraceDetach(tid, 0)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 100; i++ {
+		if status(dbp.pid, dbp.os.comm) == 'T' {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.New("could not stop process")
+}
+
+// reattach attaches again to the threads detached by detachStopped and
+// rewrites breakpoints.
+func (dbp *nativeProcess) reattach() error {
+	for tid := range dbp.threads {
+		if err := dbp.traceThread(tid, true); err != nil {
+			return err
+		}
+	}
+	dbp.resumeGroupStop()
+	return dbp.writeBreakpoints()
+}
+
+// resumeGroupStop ends the group-stop of a process that was checkpointed
+// or restored, its threads remain stopped by ptrace.
+func (dbp *nativeProcess) resumeGroupStop() {
+	_ = sys.Kill(dbp.pid, sys.SIGCONT)
+}
+
+// writeBreakpoints writes all breakpoints to memory.
+func (dbp *nativeProcess) writeBreakpoints() error {
+	for _, bp := range dbp.breakpoints.M {
+		if err := dbp.writeSoftwareBreakpoint(dbp.memthread, bp.Addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dbp *nativeProcess) listCheckpoints() ([]proc.Checkpoint, error) {
+	return dbp.checkpoints, nil
+}
+
+func (dbp *nativeProcess) clearCheckpoint(id int) error {
+	for i := range dbp.checkpoints {
+		if dbp.checkpoints[i].ID == id {
+			dir := dbp.checkpoints[i].When
+			dbp.checkpoints = append(dbp.checkpoints[:i], dbp.checkpoints[i+1:]...)
+			return os.RemoveAll(dir)
+		}
+	}
+	return fmt.Errorf("checkpoint c%d does not exist", id)
+}
+
+// restart kills the process and restores the checkpoint specified by pos.
+func (dbp *nativeProcess) restart(pos string) (proc.Thread, error) {
+	if !strings.HasPrefix(pos, "c") {
+		return nil, proc.ErrNotRecorded
+	}
+	id, err := strconv.Atoi(pos[1:])
+	if err != nil {
+		return nil, proc.ErrNotRecorded
+	}
+	dir := ""
+	for _, cp := range dbp.checkpoints {
+		if cp.ID == id {
+			dir = cp.When
+		}
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("checkpoint %s does not exist", pos)
+	}
+
+	if !dbp.exited {
+		if err := sys.Kill(dbp.pid, sys.SIGKILL); err != nil {
+			return nil, errors.New("could not deliver signal " + err.Error())
+		}
+		if err := dbp.waitKilled(); err != nil {
+			return nil, err
+		}
+	}
+	// postExit stopped the goroutine executing ptrace requests.
+	dbp.ptraceChan = make(chan func())
+	dbp.ptraceDoneChan = make(chan interface{})
+	go dbp.handlePtraceFuncs()
+	dbp.exited = false
+
+	if err := dbp.restore(dir); err != nil {
+		dbp.postExit()
+		return nil, err
+	}
+	for _, th := range dbp.threads {
+		if err := th.SetCurrentBreakpoint(false); err != nil {
+			return nil, err
+		}
+	}
+	return dbp.threads[dbp.pid], nil
+}
+
+// restore restores the process from the images in dir and attaches to it.
+func (dbp *nativeProcess) restore(dir string) error {
+	pid, err := criuRestore(dir)
+	if err != nil {
+		return err
+	}
+	dbp.pid = pid
+	// The restored process is a child of CRIU.
+	dbp.childProcess = false
+	dbp.threads = make(map[int]*nativeThread)
+	dbp.memthread = nil
+	dbp.os.seized = false
+	if err := dbp.seize(); err != nil {
+		return err
+	}
+	if err := dbp.updateThreadList(); err != nil {
+		return err
+	}
+	dbp.resumeGroupStop()
+	return dbp.writeBreakpoints()
+}
+
+// CheckpointsSupported returns true if checkpoints of live processes can
+// be created, i.e. if CRIU is installed.
+func CheckpointsSupported() bool {
+	_, err := exec.LookPath(criuCommand)
+	return err == nil
+}
+
+// Restore restores the process checkpointed in dir, by a previous debug
+// session, and attaches to it.
+func Restore(dir string, debugInfoDirs []string) (*proc.Target, error) {
+	if _, err := exec.LookPath(criuCommand); err != nil {
+		return nil, ErrCRIUNotFound
+	}
+	pid, err := criuRestore(dir)
+	if err != nil {
+		return nil, err
+	}
+	dbp := newProcess(pid)
+	dbp.checkpoints = []proc.Checkpoint{{ID: 1, When: dir}}
+	tgt, err := dbp.attach(debugInfoDirs)
+	if err != nil {
+		_ = sys.Kill(pid, sys.SIGKILL)
+		return nil, err
+	}
+	dbp.resumeGroupStop()
+	return tgt, nil
+}
+
+// criuRestore restores the process checkpointed in dir and returns its
+// PID.
+func criuRestore(dir string) (int, error) {
+	pidfile := filepath.Join(dir, criuPidFile)
+	os.Remove(pidfile)
+	if err := runCRIU(dir, "restore", "-o", "restore.log", "--shell-job", "--restore-detached", "--pidfile", pidfile); err != nil {
+		return 0, err
+	}
+	buf, err := ioutil.ReadFile(pidfile)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(buf)))
+}
+
+// runCRIU executes a CRIU command on the images directory dir.
+func runCRIU(dir, action string, args ...string) error {
+	cmd := exec.Command(criuCommand, append([]string{action, "-D", dir}, args...)...)
+	// Shell jobs must be restored on the terminal they were using.
+	cmd.Stdin = os.Stdin
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("criu %s failed: %v (see the log in %s)\n%s", action, err, dir, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
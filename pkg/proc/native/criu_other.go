@@ -0,0 +1,31 @@
+// +build !linux
+
+package native
+
+import (
+	"errors"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// ErrRestoreNotSupported is returned by Restore on operating systems other
+// than Linux.
+var ErrRestoreNotSupported = errors.New("restoring checkpoints is only supported on linux")
+
+func (dbp *nativeProcess) checkpoint(string) (int, error) { return -1, proc.ErrNotRecorded }
+
+func (dbp *nativeProcess) listCheckpoints() ([]proc.Checkpoint, error) {
+	return nil, proc.ErrNotRecorded
+}
+
+func (dbp *nativeProcess) clearCheckpoint(int) error { return proc.ErrNotRecorded }
+
+func (dbp *nativeProcess) restart(string) (proc.Thread, error) { return nil, proc.ErrNotRecorded }
+
+// CheckpointsSupported returns false.
+func CheckpointsSupported() bool { return false }
+
+// Restore returns ErrRestoreNotSupported.
+func Restore(string, []string) (*proc.Target, error) {
+	return nil, ErrRestoreNotSupported
+}
@@ -0,0 +1,26 @@
+package native
+
+import (
+	"encoding/binary"
+
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// The conversions used by the mach backend on darwin/arm64 are in this
+// file, which has no build constraints, so that they can be tested on
+// every platform.
+
+// neonStateVregsLen is the size of the vector registers of an
+// arm_neon_state64_t, they are followed by FPSR and FPCR.
+const neonStateVregsLen = 32 * 16
+
+// decodeNeonState converts an arm_neon_state64_t, as returned by
+// thread_get_state, into the floating point registers used by
+// linutil.ARM64Registers.
+func decodeNeonState(state []byte) linutil.ARM64PtraceFpRegs {
+	return linutil.ARM64PtraceFpRegs{
+		Vregs: state[:neonStateVregsLen],
+		Fpsr:  binary.LittleEndian.Uint32(state[neonStateVregsLen:]),
+		Fpcr:  binary.LittleEndian.Uint32(state[neonStateVregsLen+4:]),
+	}
+}
@@ -0,0 +1,65 @@
+package native
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+func TestMachARM64RegistersLayout(t *testing.T) {
+	// get_registers, in threads_darwin_arm64.c, copies arm_thread_state64_t
+	// into an array of uint64 that is used as a linutil.ARM64PtraceRegs:
+	// x0-x28, fp, lr, sp, pc and cpsr.
+	var regs linutil.ARM64PtraceRegs
+	for _, tc := range []struct {
+		name       string
+		off, index uintptr
+	}{
+		{"fp", unsafe.Offsetof(regs.Regs) + 29*8, 29},
+		{"lr", unsafe.Offsetof(regs.Regs) + 30*8, 30},
+		{"sp", unsafe.Offsetof(regs.Sp), 31},
+		{"pc", unsafe.Offsetof(regs.Pc), 32},
+		{"cpsr", unsafe.Offsetof(regs.Pstate), 33},
+	} {
+		if tc.off != tc.index*8 {
+			t.Errorf("%s at offset %d, expected %d", tc.name, tc.off, tc.index*8)
+		}
+	}
+	if sz := unsafe.Sizeof(regs); sz != 34*8 {
+		t.Errorf("wrong size %d", sz)
+	}
+}
+
+func TestDecodeNeonState(t *testing.T) {
+	// arm_neon_state64_t is 32 128 bit vector registers followed by FPSR and
+	// FPCR, padded to a multiple of 16 bytes.
+	state := make([]byte, neonStateVregsLen+16)
+	for i := 0; i < 32; i++ {
+		binary.LittleEndian.PutUint64(state[i*16:], uint64(i)+1)
+		binary.LittleEndian.PutUint64(state[i*16+8:], uint64(i)<<32)
+	}
+	binary.LittleEndian.PutUint32(state[neonStateVregsLen:], 0x8000000)
+	binary.LittleEndian.PutUint32(state[neonStateVregsLen+4:], 0x3000000)
+
+	fpregs := decodeNeonState(state)
+	if fpregs.Fpsr != 0x8000000 || fpregs.Fpcr != 0x3000000 {
+		t.Errorf("wrong fpsr %#x or fpcr %#x", fpregs.Fpsr, fpregs.Fpcr)
+	}
+	regs := fpregs.Decode()
+	if len(regs) != 32 {
+		t.Fatalf("wrong number of vector registers %d", len(regs))
+	}
+	for i, reg := range regs {
+		if reg.Name != fmt.Sprintf("V%d", i) {
+			t.Errorf("wrong name %s for register %d", reg.Name, i)
+		}
+		lo := binary.LittleEndian.Uint64(reg.Reg.Bytes)
+		hi := binary.LittleEndian.Uint64(reg.Reg.Bytes[8:])
+		if lo != uint64(i)+1 || hi != uint64(i)<<32 {
+			t.Errorf("wrong value of %s %#x %#x", reg.Name, hi, lo)
+		}
+	}
+}
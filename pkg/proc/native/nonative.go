@@ -1,16 +1,14 @@
-//+build darwin,!macnative
+//+build darwin,!macnative openbsd freebsd,!cgo
 
 package native
 
 import (
-	"errors"
+	"os"
 	"sync"
 
 	"github.com/go-delve/delve/pkg/proc"
 )
 
-var ErrNativeBackendDisabled = errors.New("native backend disabled during compilation")
-
 // Launch returns ErrNativeBackendDisabled.
 func Launch(_ []string, _ string, _ proc.LaunchFlags, _ []string, _ string, _ [3]string) (*proc.Target, error) {
 	return nil, ErrNativeBackendDisabled
@@ -75,6 +73,36 @@ func (dbp *nativeProcess) detach(kill bool) error {
 	panic(ErrNativeBackendDisabled)
 }
 
+// SetSignalPolicy returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) SetSignalPolicy(int, proc.SignalPolicy) error {
+	panic(ErrNativeBackendDisabled)
+}
+
+// SetMemoryGuards returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) SetMemoryGuards([]*proc.MemoryGuard) error {
+	panic(ErrNativeBackendDisabled)
+}
+
+// EnableBranchTrace returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) EnableBranchTrace(bool) error {
+	panic(ErrNativeBackendDisabled)
+}
+
+// BranchTrace returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) BranchTrace(int) ([]byte, error) {
+	panic(ErrNativeBackendDisabled)
+}
+
+// WriteMinidump returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) WriteMinidump(*os.File, bool) error {
+	panic(ErrNativeBackendDisabled)
+}
+
+// MemoryMap returns ErrNativeBackendDisabled.
+func (dbp *nativeProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	panic(ErrNativeBackendDisabled)
+}
+
 // EntryPoint returns the entry point for the process,
 // useful for PIEs.
 func (dbp *nativeProcess) EntryPoint() (uint64, error) {
@@ -1,6 +1,7 @@
 package native
 
 import (
+	"errors"
 	"os"
 	"runtime"
 	"sync"
@@ -8,6 +9,10 @@ import (
 	"github.com/go-delve/delve/pkg/proc"
 )
 
+// ErrNativeBackendDisabled is returned by Launch and Attach when the native
+// backend was not compiled in, on macOS it requires the macnative build tag.
+var ErrNativeBackendDisabled = errors.New("native backend disabled during compilation")
+
 // Process represents all of the information the debugger
 // is holding onto regarding the process we are debugging.
 type nativeProcess struct {
@@ -41,6 +46,13 @@ type nativeProcess struct {
 	// this process.
 	ctty *os.File
 
+	// signalPolicies maps signal numbers to the policy used to handle them,
+	// see proc.SignalPolicy.
+	signalPolicies map[int]proc.SignalPolicy
+
+	// checkpoints is the list of checkpoints created with Checkpoint.
+	checkpoints []proc.Checkpoint
+
 	exited, detached bool
 }
 
@@ -73,9 +85,9 @@ func (dbp *nativeProcess) BinInfo() *proc.BinaryInfo {
 // Recorded always returns false for the native proc backend.
 func (dbp *nativeProcess) Recorded() (bool, string) { return false, "" }
 
-// Restart will always return an error in the native proc backend, only for
-// recorded traces.
-func (dbp *nativeProcess) Restart(string) (proc.Thread, error) { return nil, proc.ErrNotRecorded }
+// Restart restores the process to the checkpoint specified by pos, only
+// supported on Linux (see checkpoint).
+func (dbp *nativeProcess) Restart(pos string) (proc.Thread, error) { return dbp.restart(pos) }
 
 // ChangeDirection will always return an error in the native proc backend, only for
 // recorded traces.
@@ -92,21 +104,23 @@ func (p *nativeProcess) GetDirection() proc.Direction { return proc.Forward }
 // When will always return an empty string and nil, not supported on native proc backend.
 func (dbp *nativeProcess) When() (string, error) { return "", nil }
 
-// Checkpoint will always return an error on the native proc backend,
-// only supported for recorded traces.
-func (dbp *nativeProcess) Checkpoint(string) (int, error) { return -1, proc.ErrNotRecorded }
+// Checkpoint saves the state of the process to disk, only supported on
+// Linux (see checkpoint).
+func (dbp *nativeProcess) Checkpoint(where string) (int, error) { return dbp.checkpoint(where) }
 
-// Checkpoints will always return an error on the native proc backend,
-// only supported for recorded traces.
-func (dbp *nativeProcess) Checkpoints() ([]proc.Checkpoint, error) { return nil, proc.ErrNotRecorded }
+// Checkpoints returns the list of checkpoints created with Checkpoint.
+func (dbp *nativeProcess) Checkpoints() ([]proc.Checkpoint, error) { return dbp.listCheckpoints() }
 
-// ClearCheckpoint will always return an error on the native proc backend,
-// only supported in recorded traces.
-func (dbp *nativeProcess) ClearCheckpoint(int) error { return proc.ErrNotRecorded }
+// ClearCheckpoint deletes a checkpoint created with Checkpoint.
+func (dbp *nativeProcess) ClearCheckpoint(id int) error { return dbp.clearCheckpoint(id) }
 
 // Detach from the process being debugged, optionally killing it.
 func (dbp *nativeProcess) Detach(kill bool) (err error) {
 	if dbp.exited {
+		if len(dbp.checkpoints) > 0 {
+			// See postExit.
+			dbp.bi.Close()
+		}
 		return nil
 	}
 	if kill && dbp.childProcess {
@@ -222,6 +236,23 @@ func (dbp *nativeProcess) EraseBreakpoint(bp *proc.Breakpoint) error {
 	return dbp.memthread.ClearBreakpoint(bp)
 }
 
+// setSignalPolicy changes how signal sig is handled, for the backends
+// whose trap loop reads signalPolicy.
+func (dbp *nativeProcess) setSignalPolicy(sig int, policy proc.SignalPolicy) {
+	if dbp.signalPolicies == nil {
+		dbp.signalPolicies = make(map[int]proc.SignalPolicy)
+	}
+	dbp.signalPolicies[sig] = policy
+}
+
+// signalPolicy returns the policy used to handle signal sig.
+func (dbp *nativeProcess) signalPolicy(sig int) proc.SignalPolicy {
+	if policy, ok := dbp.signalPolicies[sig]; ok {
+		return policy
+	}
+	return proc.DefaultSignalPolicy
+}
+
 // ContinueOnce will continue the target until it stops.
 // This could be the result of a breakpoint or signal.
 func (dbp *nativeProcess) ContinueOnce() (proc.Thread, proc.StopReason, error) {
@@ -315,7 +346,11 @@ func (dbp *nativeProcess) postExit() {
 	dbp.exited = true
 	close(dbp.ptraceChan)
 	close(dbp.ptraceDoneChan)
-	dbp.bi.Close()
+	if len(dbp.checkpoints) == 0 || dbp.detached {
+		// If the process exited it can still be restored from one of its
+		// checkpoints and the executable must be kept open.
+		dbp.bi.Close()
+	}
 	if dbp.ctty != nil {
 		dbp.ctty.Close()
 	}
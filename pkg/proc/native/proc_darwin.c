@@ -231,3 +231,17 @@ task_is_valid(task_t task) {
 	mach_msg_type_number_t count = TASK_BASIC_INFO_COUNT;
 	return task_info(task, TASK_BASIC_INFO, (task_info_t)&info, &count) == KERN_SUCCESS;
 }
+
+kern_return_t
+get_memory_region(task_t task, mach_vm_address_t *addr, mach_vm_size_t *size, vm_prot_t *prot) {
+	vm_region_basic_info_data_64_t info;
+	mach_msg_type_number_t count = VM_REGION_BASIC_INFO_COUNT_64;
+	mach_port_t objname;
+	kern_return_t kret;
+
+	kret = mach_vm_region((vm_map_t)task, addr, size, VM_REGION_BASIC_INFO_64, (vm_region_info_t)&info, &count, &objname);
+	if (kret == KERN_SUCCESS) {
+		*prot = info.protection;
+	}
+	return kret;
+}
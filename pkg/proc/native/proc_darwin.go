@@ -457,6 +457,54 @@ func (dbp *nativeProcess) detach(kill bool) error {
 	return ptraceDetach(dbp.pid, 0)
 }
 
+// SetSignalPolicy returns ErrSignalPolicyNotSupported, the trap loop of
+// this backend does not implement signal handling policies.
+func (dbp *nativeProcess) SetSignalPolicy(int, proc.SignalPolicy) error {
+	return proc.ErrSignalPolicyNotSupported
+}
+
+// SetMemoryGuards returns ErrMemoryGuardsNotSupported.
+func (dbp *nativeProcess) SetMemoryGuards([]*proc.MemoryGuard) error {
+	return proc.ErrMemoryGuardsNotSupported
+}
+
+// EnableBranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) EnableBranchTrace(bool) error {
+	return proc.ErrBranchTraceNotSupported
+}
+
+// BranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) BranchTrace(int) ([]byte, error) {
+	return nil, proc.ErrBranchTraceNotSupported
+}
+
+// WriteMinidump returns ErrMinidumpNotSupported.
+func (dbp *nativeProcess) WriteMinidump(*os.File, bool) error {
+	return proc.ErrMinidumpNotSupported
+}
+
+// MemoryMap returns the memory map of the target process.
+func (dbp *nativeProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	var r []proc.MemoryMapEntry
+	var addr C.mach_vm_address_t
+	for {
+		var size C.mach_vm_size_t
+		var prot C.vm_prot_t
+		if C.get_memory_region(dbp.os.task, &addr, &size, &prot) != C.KERN_SUCCESS {
+			break
+		}
+		r = append(r, proc.MemoryMapEntry{
+			Addr:  uint64(addr),
+			Size:  uint64(size),
+			Read:  prot&C.VM_PROT_READ != 0,
+			Write: prot&C.VM_PROT_WRITE != 0,
+			Exec:  prot&C.VM_PROT_EXECUTE != 0,
+		})
+		addr += C.mach_vm_address_t(size)
+	}
+	return r, nil
+}
+
 func (dbp *nativeProcess) EntryPoint() (uint64, error) {
 	//TODO(aarzilli): implement this
 	return 0, nil
@@ -54,3 +54,6 @@ get_task_for_pid(int pid);
 
 int
 task_is_valid(task_t task);
+
+kern_return_t
+get_memory_region(task_t task, mach_vm_address_t *addr, mach_vm_size_t *size, vm_prot_t *prot);
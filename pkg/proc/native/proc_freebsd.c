@@ -91,3 +91,34 @@ int get_entry_point(int pid) {
     procstat_freeauxv(ps, auxv);
     return (int)ep;
 }
+
+/*
+ * Returns the memory map of the process and stores the number of entries in
+ * cnt. Must be freed by the caller.
+ */
+struct memory_map_entry *get_memory_map(int pid, int *cnt) {
+	struct kinfo_vmentry *vmmap;
+	struct memory_map_entry *r;
+
+	*cnt = 0;
+	vmmap = kinfo_getvmmap(pid, cnt);
+	if (vmmap == NULL)
+		return (NULL);
+	r = calloc(*cnt, sizeof(struct memory_map_entry));
+	if (r != NULL) {
+		for (int i = 0; i < *cnt; i++) {
+			r[i].start = vmmap[i].kve_start;
+			r[i].end = vmmap[i].kve_end;
+			r[i].offset = vmmap[i].kve_offset;
+			if (vmmap[i].kve_protection & KVME_PROT_READ)
+				r[i].prot |= MEMORY_MAP_READ;
+			if (vmmap[i].kve_protection & KVME_PROT_WRITE)
+				r[i].prot |= MEMORY_MAP_WRITE;
+			if (vmmap[i].kve_protection & KVME_PROT_EXEC)
+				r[i].prot |= MEMORY_MAP_EXEC;
+			strlcpy(r[i].path, vmmap[i].kve_path, sizeof(r[i].path));
+		}
+	}
+	free(vmmap);
+	return (r);
+}
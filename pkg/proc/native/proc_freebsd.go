@@ -6,6 +6,7 @@ package native
 import "C"
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"os/signal"
 	"strings"
@@ -35,6 +36,8 @@ const (
 type osProcessDetails struct {
 	comm string
 	tid  int
+
+	delayedSignal int
 }
 
 // Launch creates and begins debugging a new process. First entry in
@@ -283,8 +286,19 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, halt bool) (*nativeThread, e
 			return th, nil
 		}
 
-		// TODO(dp) alert user about unexpected signals here.
-		if err := th.resumeWithSig(int(status.StopSignal())); err != nil {
+		sig := int(status.StopSignal())
+		policy := dbp.signalPolicy(sig)
+		if !policy.Pass {
+			sig = 0
+		}
+		if !halt && (policy.Stop || policy.Print) {
+			// The user asked to be notified of this signal, queue it to be
+			// delivered when we resume.
+			th.common.Signal = int(status.StopSignal())
+			dbp.os.delayedSignal = sig
+			return th, nil
+		}
+		if err := th.resumeWithSig(sig); err != nil {
 			if err == sys.ESRCH {
 				return nil, proc.ErrProcessExited{Pid: dbp.pid}
 			}
@@ -341,7 +355,9 @@ func (dbp *nativeProcess) resume() error {
 	}
 	// all threads are resumed
 	var err error
-	dbp.execPtraceFunc(func() { err = ptraceCont(dbp.pid, 0) })
+	sig := dbp.os.delayedSignal
+	dbp.os.delayedSignal = 0
+	dbp.execPtraceFunc(func() { err = ptraceCont(dbp.pid, sig) })
 	return err
 }
 
@@ -374,6 +390,55 @@ func (dbp *nativeProcess) EntryPoint() (uint64, error) {
 	return uint64(ep), err
 }
 
+// SetSignalPolicy changes how signal sig is handled.
+func (dbp *nativeProcess) SetSignalPolicy(sig int, policy proc.SignalPolicy) error {
+	dbp.setSignalPolicy(sig, policy)
+	return nil
+}
+
+// SetMemoryGuards returns ErrMemoryGuardsNotSupported.
+func (dbp *nativeProcess) SetMemoryGuards([]*proc.MemoryGuard) error {
+	return proc.ErrMemoryGuardsNotSupported
+}
+
+// EnableBranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) EnableBranchTrace(bool) error {
+	return proc.ErrBranchTraceNotSupported
+}
+
+// BranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) BranchTrace(int) ([]byte, error) {
+	return nil, proc.ErrBranchTraceNotSupported
+}
+
+// WriteMinidump returns ErrMinidumpNotSupported.
+func (dbp *nativeProcess) WriteMinidump(*os.File, bool) error {
+	return proc.ErrMinidumpNotSupported
+}
+
+// MemoryMap returns the memory map of the target process.
+func (dbp *nativeProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	var cnt C.int
+	entries, err := C.get_memory_map(C.int(dbp.pid), &cnt)
+	if entries == nil {
+		return nil, fmt.Errorf("could not read memory map: %v", err)
+	}
+	defer C.free(unsafe.Pointer(entries))
+	r := make([]proc.MemoryMapEntry, int(cnt))
+	for i, entry := range (*[1 << 20]C.struct_memory_map_entry)(unsafe.Pointer(entries))[:int(cnt):int(cnt)] {
+		r[i] = proc.MemoryMapEntry{
+			Addr:     uint64(entry.start),
+			Size:     uint64(entry.end - entry.start),
+			Read:     entry.prot&C.MEMORY_MAP_READ != 0,
+			Write:    entry.prot&C.MEMORY_MAP_WRITE != 0,
+			Exec:     entry.prot&C.MEMORY_MAP_EXEC != 0,
+			Filename: C.GoString(&entry.path[0]),
+			Offset:   uint64(entry.offset),
+		}
+	}
+	return r, nil
+}
+
 // Usedy by Detach
 func killProcess(pid int) error {
 	return sys.Kill(pid, sys.SIGINT)
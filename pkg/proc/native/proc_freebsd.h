@@ -1,4 +1,19 @@
+#include <stdint.h>
+
+#define MEMORY_MAP_READ 0x1
+#define MEMORY_MAP_WRITE 0x2
+#define MEMORY_MAP_EXEC 0x4
+
+struct memory_map_entry {
+	uint64_t start;
+	uint64_t end;
+	uint64_t offset;
+	int prot;
+	char path[1024];
+};
+
 char * find_command_name(int pid);
 char * find_executable(int pid);
 int find_status(int pid);
 int get_entry_point(int pid);
+struct memory_map_entry *get_memory_map(int pid, int *cnt);
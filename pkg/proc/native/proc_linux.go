@@ -45,6 +45,29 @@ const (
 // process details.
 type osProcessDetails struct {
 	comm string
+
+	// memoryGuards is the list of memory guards set with SetMemoryGuards.
+	memoryGuards []*proc.MemoryGuard
+
+	// seized is true if the threads of the process were attached with
+	// PTRACE_SEIZE, threads are then stopped with PTRACE_INTERRUPT instead
+	// of SIGSTOP.
+	seized bool
+
+	// processVmDisabled is true if process_vm_readv is not available, memory
+	// is then only read with PTRACE_PEEKDATA.
+	processVmDisabled bool
+
+	// branchTrace is true if branch tracing was enabled with
+	// EnableBranchTrace, branchTraces contains the Intel PT buffer of each
+	// thread and ptType the perf event type of Intel PT.
+	branchTrace  bool
+	branchTraces map[int]*ptBuffer
+	ptType       uint32
+
+	// entryPoint is the entry point of the executable if it was not loaded
+	// by the kernel, see linutil.FindLoadedExecutable.
+	entryPoint uint64
 }
 
 // Launch creates and begins debugging a new process. First entry in
@@ -132,19 +155,33 @@ func Launch(cmd []string, wd string, flags proc.LaunchFlags, debugInfoDirs []str
 // the DWARF information cannot be found in the binary, Delve will look
 // for external debug files in the directories passed in.
 func Attach(pid int, debugInfoDirs []string) (*proc.Target, error) {
-	dbp := newProcess(pid)
+	return newProcess(pid).attach(debugInfoDirs)
+}
 
-	var err error
-	dbp.execPtraceFunc(func() { err = ptraceAttach(dbp.pid) })
-	if err != nil {
+func (dbp *nativeProcess) attach(debugInfoDirs []string) (*proc.Target, error) {
+	if err := dbp.seize(); err != nil {
 		return nil, err
 	}
-	_, _, err = dbp.wait(dbp.pid, 0)
+
+	// Processes running in a container are attached using their PID in
+	// Delve's PID namespace, which is all ptrace and process_vm_readv need,
+	// but their shared libraries must be opened through /proc/<pid>/root.
+	dbp.bi.SysRoot = linutil.ContainerRoot(dbp.pid)
+
+	// Programs started by a custom loader, which mapped them in memory
+	// itself, are not the executable of the process.
+	path := findExecutable("", dbp.pid)
+	exe, err := linutil.FindLoadedExecutable(dbp.pid, dbp.bi.SysRoot)
 	if err != nil {
+		_ = dbp.Detach(false)
 		return nil, err
 	}
+	if exe != nil {
+		path = exe.Path
+		dbp.os.entryPoint = exe.Entry
+	}
 
-	tgt, err := dbp.initialize(findExecutable("", dbp.pid), debugInfoDirs)
+	tgt, err := dbp.initialize(path, debugInfoDirs)
 	if err != nil {
 		_ = dbp.Detach(false)
 		return nil, err
@@ -159,6 +196,29 @@ func Attach(pid int, debugInfoDirs []string) (*proc.Target, error) {
 	return tgt, nil
 }
 
+// seize attaches to the main thread of the process and waits for it to
+// stop.
+func (dbp *nativeProcess) seize() error {
+	var err error
+	dbp.execPtraceFunc(func() { err = ptraceSeize(dbp.pid) })
+	switch err {
+	case nil:
+		// Seized threads are stopped without sending them a SIGSTOP, which
+		// would otherwise put the whole thread group in group-stop and be
+		// visible to the target (and to its parent) after we detach.
+		dbp.os.seized = true
+		dbp.execPtraceFunc(func() { err = ptraceInterrupt(dbp.pid) })
+	case sys.EIO, sys.EINVAL:
+		// PTRACE_SEIZE is not supported by kernels before 3.4.
+		dbp.execPtraceFunc(func() { err = ptraceAttach(dbp.pid) })
+	}
+	if err != nil {
+		return err
+	}
+	_, _, err = dbp.wait(dbp.pid, 0)
+	return err
+}
+
 func initialize(dbp *nativeProcess) error {
 	comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", dbp.pid))
 	if err == nil {
@@ -197,6 +257,12 @@ func (dbp *nativeProcess) kill() error {
 	if err := sys.Kill(-dbp.pid, sys.SIGKILL); err != nil {
 		return errors.New("could not deliver signal " + err.Error())
 	}
+	return dbp.waitKilled()
+}
+
+// waitKilled waits for all threads of the process to be terminated by
+// SIGKILL.
+func (dbp *nativeProcess) waitKilled() error {
 	// wait for other threads first or the thread group leader (dbp.pid) will never exit.
 	for threadID := range dbp.threads {
 		if threadID != dbp.pid {
@@ -209,6 +275,7 @@ func (dbp *nativeProcess) kill() error {
 			return err
 		}
 		if wpid == dbp.pid && status != nil && status.Signaled() && status.Signal() == sys.SIGKILL {
+			dbp.closeBranchTraces()
 			dbp.postExit()
 			return err
 		}
@@ -227,48 +294,70 @@ func (dbp *nativeProcess) addThread(tid int, attach bool) (*nativeThread, error)
 		return thread, nil
 	}
 
+	if err := dbp.traceThread(tid, attach); err != nil {
+		return nil, err
+	}
+
+	dbp.threads[tid] = &nativeThread{
+		ID:  tid,
+		dbp: dbp,
+		os:  new(osSpecificDetails),
+	}
+	if dbp.memthread == nil {
+		dbp.memthread = dbp.threads[tid]
+	}
+	if dbp.os.branchTrace {
+		// Errors are reported by BranchTrace for this thread.
+		_ = dbp.openBranchTrace(tid)
+	}
+	return dbp.threads[tid], nil
+}
+
+// traceThread attaches to thread tid, if attach is true, and sets its
+// ptrace options.
+func (dbp *nativeProcess) traceThread(tid int, attach bool) error {
 	var err error
 	if attach {
-		dbp.execPtraceFunc(func() { err = sys.PtraceAttach(tid) })
+		if dbp.os.seized {
+			dbp.execPtraceFunc(func() {
+				err = ptraceSeize(tid)
+				if err == nil {
+					err = ptraceInterrupt(tid)
+				}
+			})
+		} else {
+			dbp.execPtraceFunc(func() { err = sys.PtraceAttach(tid) })
+		}
 		if err != nil && err != sys.EPERM {
 			// Do not return err if err == EPERM,
 			// we may already be tracing this thread due to
 			// PTRACE_O_TRACECLONE. We will surely blow up later
 			// if we truly don't have permissions.
-			return nil, fmt.Errorf("could not attach to new thread %d %s", tid, err)
+			return fmt.Errorf("could not attach to new thread %d %s", tid, err)
 		}
 		pid, status, err := dbp.waitFast(tid)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if status.Exited() {
-			return nil, fmt.Errorf("thread already exited %d", pid)
+			return fmt.Errorf("thread already exited %d", pid)
 		}
 	}
 
 	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
 	if err == syscall.ESRCH {
 		if _, _, err = dbp.waitFast(tid); err != nil {
-			return nil, fmt.Errorf("error while waiting after adding thread: %d %s", tid, err)
+			return fmt.Errorf("error while waiting after adding thread: %d %s", tid, err)
 		}
 		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
 		if err == syscall.ESRCH {
-			return nil, err
+			return err
 		}
 		if err != nil {
-			return nil, fmt.Errorf("could not set options for new traced thread %d %s", tid, err)
+			return fmt.Errorf("could not set options for new traced thread %d %s", tid, err)
 		}
 	}
-
-	dbp.threads[tid] = &nativeThread{
-		ID:  tid,
-		dbp: dbp,
-		os:  new(osSpecificDetails),
-	}
-	if dbp.memthread == nil {
-		dbp.memthread = dbp.threads[tid]
-	}
-	return dbp.threads[tid], nil
+	return nil
 }
 
 func (dbp *nativeProcess) updateThreadList() error {
@@ -328,6 +417,7 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, options trapWaitOptions) (*n
 		}
 		if status.Exited() {
 			if wpid == dbp.pid {
+				dbp.closeBranchTraces()
 				dbp.postExit()
 				return nil, proc.ErrProcessExited{Pid: wpid, Status: status.ExitStatus()}
 			}
@@ -379,7 +469,25 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, options trapWaitOptions) (*n
 			// Sometimes we get an unknown thread, ignore it?
 			continue
 		}
-		if (halt && status.StopSignal() == sys.SIGSTOP) || (status.StopSignal() == sys.SIGTRAP) {
+		if isEventStop(status) {
+			// Seized threads report stops caused by PTRACE_INTERRUPT, and
+			// group-stops, as PTRACE_EVENT_STOP.
+			if halt && th.os.running {
+				th.os.running = false
+				return th, nil
+			}
+			// Either a group-stop caused by a stop signal that was delivered
+			// to the target, which resuming the thread ignores like it does
+			// for threads attached with PTRACE_ATTACH, or a PTRACE_INTERRUPT
+			// sent while halting to a thread that had already stopped (for
+			// example on a breakpoint), which stays pending until the thread
+			// is resumed.
+			if err := th.resumeWithSig(0); err != nil && err != sys.ESRCH {
+				return nil, err
+			}
+			continue
+		}
+		if (halt && !dbp.os.seized && status.StopSignal() == sys.SIGSTOP) || (status.StopSignal() == sys.SIGTRAP) {
 			th.os.running = false
 			if status.StopSignal() == sys.SIGTRAP {
 				th.os.setbp = true
@@ -387,22 +495,52 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, options trapWaitOptions) (*n
 			return th, nil
 		}
 
-		// TODO(dp) alert user about unexpected signals here.
-		if halt && !th.os.running {
-			// We are trying to stop the process, queue this signal to be delivered
-			// to the thread when we resume.
-			// Do not do this for threads that were running because we sent them a
-			// STOP signal and we need to observe it so we don't mistakenly deliver
-			// it later.
-			th.os.delayedSignal = int(status.StopSignal())
+		sig := int(status.StopSignal())
+		if sig == int(sys.SIGSEGV) && len(dbp.os.memoryGuards) > 0 {
+			var addr uint64
+			var addrErr error
+			dbp.execPtraceFunc(func() { addr, addrErr = ptraceGetSigAddr(th.ID) })
+			if addrErr == nil && dbp.guarded(addr) {
+				// Do not deliver the signal, the faulting instruction will be
+				// executed again when the thread is resumed.
+				th.common.FaultAddr = addr
+				if !halt || !th.os.running {
+					th.os.delayedSignal = 0
+					th.os.running = false
+					return th, nil
+				}
+				// We sent a STOP signal to this thread, resume it so that we can
+				// observe it.
+				if err := th.resumeWithSig(0); err != nil && err != sys.ESRCH {
+					return nil, err
+				}
+				continue
+			}
+		}
+		policy := dbp.signalPolicy(sig)
+		if policy.Stop || policy.Print {
+			th.common.Signal = sig
+		}
+		if !policy.Pass {
+			sig = 0
+		}
+		if (halt && !th.os.running) || (!halt && (policy.Stop || policy.Print)) {
+			// We are trying to stop the process, or the user asked to be
+			// notified of this signal, queue it to be delivered to the thread
+			// when we resume.
+			// Do not do this for threads that were running while we were halting
+			// because we sent them a STOP signal and we need to observe it so we
+			// don't mistakenly deliver it later.
+			th.os.delayedSignal = sig
 			th.os.running = false
 			return th, nil
-		} else if err := th.resumeWithSig(int(status.StopSignal())); err != nil {
+		} else if err := th.resumeWithSig(sig); err != nil {
 			if err != sys.ESRCH {
 				return nil, err
 			}
 			// do the same thing we do if a thread quit
 			if wpid == dbp.pid {
+				dbp.closeBranchTraces()
 				dbp.postExit()
 				return nil, proc.ErrProcessExited{Pid: wpid, Status: status.ExitStatus()}
 			}
@@ -411,6 +549,12 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, options trapWaitOptions) (*n
 	}
 }
 
+// isEventStop returns true if status describes a PTRACE_EVENT_STOP, which
+// is only reported for threads attached with PTRACE_SEIZE.
+func isEventStop(status *sys.WaitStatus) bool {
+	return status.Stopped() && int(*status)>>16 == sys.PTRACE_EVENT_STOP
+}
+
 func status(pid int, comm string) rune {
 	f, err := os.Open(fmt.Sprintf("/proc/%d/stat", pid))
 	if err != nil {
@@ -621,13 +765,16 @@ func (dbp *nativeProcess) stop(trapthread *nativeThread) (*nativeThread, error)
 }
 
 func (dbp *nativeProcess) detach(kill bool) error {
+	dbp.closeBranchTraces()
 	for threadID := range dbp.threads {
 		err := ptraceDetach(threadID, 0)
 		if err != nil {
 			return err
 		}
 	}
-	if kill {
+	if kill || dbp.os.seized {
+		// Seized processes were never sent a SIGSTOP and are left in the
+		// state they were found in.
 		return nil
 	}
 	// For some reason the process will sometimes enter stopped state after a
@@ -644,6 +791,9 @@ func (dbp *nativeProcess) detach(kill bool) error {
 // EntryPoint will return the process entry point address, useful for
 // debugging PIEs.
 func (dbp *nativeProcess) EntryPoint() (uint64, error) {
+	if dbp.os.entryPoint != 0 {
+		return dbp.os.entryPoint, nil
+	}
 	auxvbuf, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/auxv", dbp.pid))
 	if err != nil {
 		return 0, fmt.Errorf("could not read auxiliary vector: %v", err)
@@ -652,6 +802,75 @@ func (dbp *nativeProcess) EntryPoint() (uint64, error) {
 	return linutil.EntryPointFromAuxv(auxvbuf, dbp.bi.Arch.PtrSize()), nil
 }
 
+// WriteBreakpoints writes all the breakpoints in reqs, their original data
+// is read with a single call to process_vm_readv and they are all written
+// by a single request to the goroutine executing ptrace calls.
+func (dbp *nativeProcess) WriteBreakpoints(reqs []proc.BreakpointWriteRequest) {
+	if dbp.exited {
+		for i := range reqs {
+			reqs[i].Err = proc.ErrProcessExited{Pid: dbp.pid}
+		}
+		return
+	}
+	bpsize := dbp.bi.Arch.BreakpointSize()
+	reads := make([]proc.MemoryReadRequest, len(reqs))
+	for i := range reqs {
+		reads[i] = proc.MemoryReadRequest{Addr: reqs[i].Addr, Buf: make([]byte, bpsize)}
+	}
+	dbp.memthread.ReadMemoryBatch(reads)
+
+	bpinstr := dbp.bi.Arch.BreakpointInstruction()
+	tid := dbp.memthread.ID
+	dbp.execPtraceFunc(func() {
+		for i := range reqs {
+			if reads[i].Err != nil {
+				reqs[i].Err = reads[i].Err
+				continue
+			}
+			if _, err := sys.PtracePokeData(tid, uintptr(reqs[i].Addr), bpinstr); err != nil {
+				reqs[i].Err = err
+				continue
+			}
+			reqs[i].OriginalData = reads[i].Buf
+		}
+	})
+}
+
+// SetSignalPolicy changes how signal sig is handled.
+func (dbp *nativeProcess) SetSignalPolicy(sig int, policy proc.SignalPolicy) error {
+	dbp.setSignalPolicy(sig, policy)
+	return nil
+}
+
+// SetMemoryGuards sets the list of memory guards, SIGSEGV signals caused
+// by accesses to guarded memory stop the target and are not delivered to
+// it.
+func (dbp *nativeProcess) SetMemoryGuards(guards []*proc.MemoryGuard) error {
+	dbp.os.memoryGuards = guards
+	return nil
+}
+
+// WriteMinidump returns ErrMinidumpNotSupported.
+func (dbp *nativeProcess) WriteMinidump(*os.File, bool) error {
+	return proc.ErrMinidumpNotSupported
+}
+
+// guarded returns true if addr belongs to a memory guard.
+func (dbp *nativeProcess) guarded(addr uint64) bool {
+	for _, guard := range dbp.os.memoryGuards {
+		if addr >= guard.Addr && addr < guard.Addr+guard.Size {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryMap returns the memory map of the target process, read from
+// /proc/<pid>/maps.
+func (dbp *nativeProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	return linutil.MemoryMap(dbp.pid)
+}
+
 func killProcess(pid int) error {
 	return sys.Kill(pid, sys.SIGINT)
 }
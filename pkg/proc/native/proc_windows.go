@@ -485,6 +485,67 @@ func (dbp *nativeProcess) EntryPoint() (uint64, error) {
 	return dbp.os.entryPoint, nil
 }
 
+// SetSignalPolicy returns ErrSignalPolicyNotSupported, the trap loop of
+// this backend does not implement signal handling policies.
+func (dbp *nativeProcess) SetSignalPolicy(int, proc.SignalPolicy) error {
+	return proc.ErrSignalPolicyNotSupported
+}
+
+// SetMemoryGuards returns ErrMemoryGuardsNotSupported.
+func (dbp *nativeProcess) SetMemoryGuards([]*proc.MemoryGuard) error {
+	return proc.ErrMemoryGuardsNotSupported
+}
+
+// EnableBranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) EnableBranchTrace(bool) error {
+	return proc.ErrBranchTraceNotSupported
+}
+
+// BranchTrace returns ErrBranchTraceNotSupported.
+func (dbp *nativeProcess) BranchTrace(int) ([]byte, error) {
+	return nil, proc.ErrBranchTraceNotSupported
+}
+
+// WriteMinidump writes a minidump of the target process to out using
+// MiniDumpWriteDump. If full is false only the private read-write memory
+// of the process (heap, stacks and data segments) is included.
+func (dbp *nativeProcess) WriteMinidump(out *os.File, full bool) error {
+	dumpType := uint32(_MiniDumpWithHandleData | _MiniDumpWithUnloadedModules | _MiniDumpWithFullMemoryInfo | _MiniDumpWithThreadInfo)
+	if full {
+		dumpType |= _MiniDumpWithFullMemory
+	} else {
+		dumpType |= _MiniDumpWithDataSegs | _MiniDumpWithPrivateReadWriteMemory
+	}
+	return _MiniDumpWriteDump(dbp.os.hProcess, uint32(dbp.pid), syscall.Handle(out.Fd()), dumpType, 0, 0, 0)
+}
+
+// MemoryMap returns the memory map of the target process, using
+// VirtualQueryEx. The names of mapped files are not reported.
+func (dbp *nativeProcess) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	var r []proc.MemoryMapEntry
+	var mbi _MEMORY_BASIC_INFORMATION
+	for addr := uintptr(0); ; {
+		if _VirtualQueryEx(dbp.os.hProcess, addr, &mbi, unsafe.Sizeof(mbi)) == 0 {
+			break
+		}
+		if mbi.State == _MEM_COMMIT && mbi.Protect&(_PAGE_NOACCESS|_PAGE_GUARD) == 0 {
+			r = append(r, proc.MemoryMapEntry{
+				Addr:  uint64(mbi.BaseAddress),
+				Size:  uint64(mbi.RegionSize),
+				Read:  true,
+				Write: mbi.Protect&(_PAGE_READWRITE|_PAGE_WRITECOPY|_PAGE_EXECUTE_READWRITE|_PAGE_EXECUTE_WRITECOPY) != 0,
+				Exec:  mbi.Protect&(_PAGE_EXECUTE|_PAGE_EXECUTE_READ|_PAGE_EXECUTE_READWRITE|_PAGE_EXECUTE_WRITECOPY) != 0,
+			})
+		}
+		next := mbi.BaseAddress + mbi.RegionSize
+		if next <= addr {
+			break
+		}
+		addr = next
+	}
+	return r, nil
+}
+
 func killProcess(pid int) error {
 	p, err := os.FindProcess(pid)
 	if err != nil {
@@ -0,0 +1,151 @@
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// Branch tracing uses Intel Processor Trace through the perf_event_open
+// interface. Every thread gets its own perf event, whose AUX area is
+// mapped read-only: the kernel then uses it as a ring buffer that is
+// overwritten continuously and always contains the most recent part of
+// the trace.
+
+// intelPTTypeFile contains the perf event type of the Intel PT PMU.
+const intelPTTypeFile = "/sys/bus/event_source/devices/intel_pt/type"
+
+const (
+	intelPTConfigPT        = 1 << 0  // pt: use the configuration bits below
+	intelPTConfigNoRetComp = 1 << 11 // noretcomp: trace every return with a TIP packet
+	intelPTConfigBranch    = 1 << 13 // branch: enable branch tracing
+
+	// intelPTAuxPages is the size, in pages, of the AUX area of each thread.
+	intelPTAuxPages = 128
+)
+
+// ptBuffer is the perf event recording the branches of a thread.
+type ptBuffer struct {
+	fd   int
+	base []byte // header page followed by the (unused) data area
+	aux  []byte
+}
+
+// EnableBranchTrace starts, or stops if enable is false, recording the
+// branches executed by all threads with Intel Processor Trace.
+func (dbp *nativeProcess) EnableBranchTrace(enable bool) error {
+	if !enable {
+		dbp.os.branchTrace = false
+		dbp.closeBranchTraces()
+		return nil
+	}
+	if dbp.os.branchTrace {
+		return nil
+	}
+	buf, err := ioutil.ReadFile(intelPTTypeFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return proc.ErrBranchTraceNotSupported
+		}
+		return err
+	}
+	typ, err := strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 32)
+	if err != nil {
+		return fmt.Errorf("could not read Intel PT event type: %v", err)
+	}
+	dbp.os.ptType = uint32(typ)
+	dbp.os.branchTraces = make(map[int]*ptBuffer)
+	for tid := range dbp.threads {
+		if err := dbp.openBranchTrace(tid); err != nil {
+			dbp.closeBranchTraces()
+			return err
+		}
+	}
+	dbp.os.branchTrace = true
+	return nil
+}
+
+// openBranchTrace starts recording the branches executed by thread tid.
+func (dbp *nativeProcess) openBranchTrace(tid int) error {
+	attr := sys.PerfEventAttr{
+		Type:   dbp.os.ptType,
+		Config: intelPTConfigPT | intelPTConfigNoRetComp | intelPTConfigBranch,
+		Bits:   sys.PerfBitDisabled | sys.PerfBitExcludeKernel | sys.PerfBitExcludeHv,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+	fd, err := sys.PerfEventOpen(&attr, tid, -1, -1, sys.PERF_FLAG_FD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("could not enable Intel PT for thread %d: %v", tid, err)
+	}
+	b := &ptBuffer{fd: fd}
+
+	pageSize := os.Getpagesize()
+	b.base, err = sys.Mmap(fd, 0, 2*pageSize, sys.PROT_READ|sys.PROT_WRITE, sys.MAP_SHARED)
+	if err != nil {
+		b.close()
+		return fmt.Errorf("could not map perf event buffer: %v", err)
+	}
+	hdr := (*sys.PerfEventMmapPage)(unsafe.Pointer(&b.base[0]))
+	hdr.Aux_offset = uint64(len(b.base))
+	hdr.Aux_size = uint64(intelPTAuxPages * pageSize)
+	b.aux, err = sys.Mmap(fd, int64(hdr.Aux_offset), int(hdr.Aux_size), sys.PROT_READ, sys.MAP_SHARED)
+	if err != nil {
+		b.close()
+		return fmt.Errorf("could not map Intel PT buffer: %v", err)
+	}
+	if err := sys.IoctlSetInt(fd, sys.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+		b.close()
+		return fmt.Errorf("could not enable Intel PT for thread %d: %v", tid, err)
+	}
+	dbp.os.branchTraces[tid] = b
+	return nil
+}
+
+func (b *ptBuffer) close() {
+	if b.aux != nil {
+		_ = sys.Munmap(b.aux)
+	}
+	if b.base != nil {
+		_ = sys.Munmap(b.base)
+	}
+	_ = sys.Close(b.fd)
+}
+
+// closeBranchTraces stops recording branches for all threads.
+func (dbp *nativeProcess) closeBranchTraces() {
+	for tid, b := range dbp.os.branchTraces {
+		b.close()
+		delete(dbp.os.branchTraces, tid)
+	}
+}
+
+// BranchTrace returns the contents of the Intel PT ring buffer of thread
+// tid, oldest data first. The trace is written to the buffer when the
+// thread is scheduled out, which always happens before it stops.
+func (dbp *nativeProcess) BranchTrace(tid int) ([]byte, error) {
+	if !dbp.os.branchTrace {
+		return nil, fmt.Errorf("branch tracing is not enabled")
+	}
+	b := dbp.os.branchTraces[tid]
+	if b == nil {
+		return nil, fmt.Errorf("branches of thread %d are not being recorded", tid)
+	}
+	hdr := (*sys.PerfEventMmapPage)(unsafe.Pointer(&b.base[0]))
+	// In overwrite mode Aux_head is the offset in the buffer where the
+	// next byte will be written, the part of the buffer following it
+	// contains older data or, if the buffer never wrapped, zeroes, which
+	// are skipped as PAD packets.
+	head := atomic.LoadUint64(&hdr.Aux_head) % uint64(len(b.aux))
+	r := make([]byte, 0, len(b.aux))
+	r = append(r, b.aux[head:]...)
+	r = append(r, b.aux[:head]...)
+	return r, nil
+}
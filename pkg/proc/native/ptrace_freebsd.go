@@ -5,16 +5,13 @@ package native
 //#include <sys/ptrace.h>
 //
 // #include <stdlib.h>
-// #include "ptrace_freebsd_amd64.h"
+// #include "ptrace_freebsd.h"
 import "C"
 
 import (
-	"syscall"
 	"unsafe"
 
 	sys "golang.org/x/sys/unix"
-
-	"github.com/go-delve/delve/pkg/proc/amd64util"
 )
 
 // ptraceAttach executes the sys.PtraceAttach call.
@@ -54,20 +51,6 @@ func ptraceGetLwpInfo(wpid int) (info sys.PtraceLwpInfoStruct, err error) {
 	return info, err
 }
 
-func ptraceGetRegset(id int) (regset amd64util.AMD64Xstate, err error) {
-	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETFPREGS, uintptr(id), uintptr(unsafe.Pointer(&regset.AMD64PtraceFpRegs)), 0, 0, 0)
-	if err == syscall.Errno(0) || err == syscall.ENODEV {
-		var xsave_len C.size_t
-		xsave, _ := C.ptrace_get_xsave(C.int(id), &xsave_len)
-		defer C.free(unsafe.Pointer(xsave))
-		if xsave != nil {
-			xsave_sl := C.GoBytes(unsafe.Pointer(xsave), C.int(xsave_len))
-			err = amd64util.AMD64XstateRead(xsave_sl, false, &regset)
-		}
-	}
-	return
-}
-
 // id may be a PID or an LWPID
 func ptraceReadData(id int, addr uintptr, data []byte) (n int, err error) {
 	return sys.PtraceIO(sys.PIOD_READ_D, id, addr, data, len(data))
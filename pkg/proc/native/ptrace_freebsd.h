@@ -0,0 +1,4 @@
+#include <stddef.h>
+
+int ptrace_get_lwp_list(int pid, int *tids, size_t len);
+int ptrace_get_num_lwps(int pid);
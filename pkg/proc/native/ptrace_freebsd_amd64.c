@@ -8,25 +8,6 @@
 
 #include "ptrace_freebsd_amd64.h"
 
-/* Returns the number of kernel threads associated with the traced process. */
-int ptrace_get_num_lwps(int pid) {
-	int ret;
-	errno = 0;
-	ret = ptrace(PT_GETNUMLWPS, (pid_t)pid, 0, 0);
-	return (ret);
-}
-
-/*
- * Fetches the list of LWPs for a given process into tids.  Returns the number
- * of LWP entries filled in. Sets errno on return.
- */
-int ptrace_get_lwp_list(int pid, int *tids, size_t len) {
-	int ret;
-	errno = 0;
-	ret = ptrace(PT_GETLWPLIST, (pid_t)pid, (caddr_t)tids, len);
-	return (ret);
-}
-
 /*
  * Returns a pointer to the X86 XSAVE data, or NULL on failure.  Returns the
  * length of the buffer in the len argument.  Must be freed when no longer in
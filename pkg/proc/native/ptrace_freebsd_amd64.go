@@ -0,0 +1,28 @@
+package native
+
+// #include <stdlib.h>
+// #include "ptrace_freebsd_amd64.h"
+import "C"
+
+import (
+	"syscall"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc/amd64util"
+)
+
+func ptraceGetRegset(id int) (regset amd64util.AMD64Xstate, err error) {
+	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETFPREGS, uintptr(id), uintptr(unsafe.Pointer(&regset.AMD64PtraceFpRegs)), 0, 0, 0)
+	if err == syscall.Errno(0) || err == syscall.ENODEV {
+		var xsave_len C.size_t
+		xsave, _ := C.ptrace_get_xsave(C.int(id), &xsave_len)
+		defer C.free(unsafe.Pointer(xsave))
+		if xsave != nil {
+			xsave_sl := C.GoBytes(unsafe.Pointer(xsave), C.int(xsave_len))
+			err = amd64util.AMD64XstateRead(xsave_sl, false, &regset)
+		}
+	}
+	return
+}
@@ -2,6 +2,7 @@ package native
 
 import (
 	"syscall"
+	"unsafe"
 
 	sys "golang.org/x/sys/unix"
 )
@@ -11,6 +12,37 @@ func ptraceAttach(pid int) error {
 	return sys.PtraceAttach(pid)
 }
 
+// ptraceSeize calls ptrace(PTRACE_SEIZE), setting PTRACE_O_TRACECLONE. The
+// thread is not stopped, use ptraceInterrupt to stop it.
+func ptraceSeize(tid int) error {
+	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_SEIZE, uintptr(tid), 0, uintptr(sys.PTRACE_O_TRACECLONE), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
+
+// ptraceInterrupt calls ptrace(PTRACE_INTERRUPT), stopping a seized thread
+// without sending it a signal.
+func ptraceInterrupt(tid int) error {
+	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_INTERRUPT, uintptr(tid), 0, 0, 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
+
+// processVmReadv calls process_vm_readv, reading the memory ranges
+// described by remote, a list of address and length pairs laid out like an
+// array of struct iovec, into the buffers described by local.
+func processVmReadv(tid int, local []sys.Iovec, remote []uintptr) (int, error) {
+	n, _, err := syscall.Syscall6(sys.SYS_PROCESS_VM_READV, uintptr(tid), uintptr(unsafe.Pointer(&local[0])), uintptr(len(local)), uintptr(unsafe.Pointer(&remote[0])), uintptr(len(remote)/2), 0)
+	if err != syscall.Errno(0) {
+		return 0, err
+	}
+	return int(n), nil
+}
+
 // ptraceDetach calls ptrace(PTRACE_DETACH).
 func ptraceDetach(tid, sig int) error {
 	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_DETACH, uintptr(tid), 1, uintptr(sig), 0, 0)
@@ -24,3 +56,20 @@ func ptraceDetach(tid, sig int) error {
 func ptraceCont(tid, sig int) error {
 	return sys.PtraceCont(tid, sig)
 }
+
+// ptraceGetSigAddr returns the si_addr field of the siginfo of the signal
+// that stopped the thread, the address of the memory access that caused a
+// SIGSEGV or SIGBUS.
+func ptraceGetSigAddr(tid int) (uint64, error) {
+	var siginfo [128]byte
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETSIGINFO, uintptr(tid), 0, uintptr(unsafe.Pointer(&siginfo[0])), 0, 0)
+	if err != syscall.Errno(0) {
+		return 0, err
+	}
+	// si_addr follows si_signo, si_errno and si_code, aligned to the size of
+	// a pointer.
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		return *(*uint64)(unsafe.Pointer(&siginfo[16])), nil
+	}
+	return uint64(*(*uint32)(unsafe.Pointer(&siginfo[12]))), nil
+}
@@ -0,0 +1,72 @@
+//+build darwin,macnative
+
+package native
+
+// #include "threads_darwin.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// The general purpose registers returned by get_registers have the same
+// layout as the ones returned by ptrace on linux/arm64, so we reuse the
+// linutil implementation of proc.Registers.
+
+// SetPC sets the PC register to the value specified by `pc`.
+func (thread *nativeThread) SetPC(pc uint64) error {
+	kret := C.set_pc(thread.os.threadAct, C.uint64_t(pc))
+	if kret != C.KERN_SUCCESS {
+		return fmt.Errorf("could not set pc")
+	}
+	return nil
+}
+
+// SetSP sets the SP register to the value specified by `sp`.
+func (thread *nativeThread) SetSP(sp uint64) error {
+	ir, err := registers(thread)
+	if err != nil {
+		return err
+	}
+	r := ir.(*linutil.ARM64Registers)
+	r.Regs.Sp = sp
+	kret := C.set_registers(C.mach_port_name_t(thread.os.threadAct), (*C.uint64_t)(unsafe.Pointer(r.Regs)))
+	if kret != C.KERN_SUCCESS {
+		return fmt.Errorf("could not set sp")
+	}
+	return nil
+}
+
+func (thread *nativeThread) SetDX(dx uint64) error {
+	return errors.New("not supported")
+}
+
+func registers(thread *nativeThread) (proc.Registers, error) {
+	var regs linutil.ARM64PtraceRegs
+	kret := C.get_registers(C.mach_port_name_t(thread.os.threadAct), (*C.uint64_t)(unsafe.Pointer(&regs)))
+	if kret != C.KERN_SUCCESS {
+		return nil, fmt.Errorf("could not get registers")
+	}
+	r := linutil.NewARM64Registers(&regs, func(r *linutil.ARM64Registers) error {
+		var floatLoadError error
+		r.Fpregs, r.Fpregset, floatLoadError = thread.fpRegisters()
+		return floatLoadError
+	})
+	return r, nil
+}
+
+// fpRegisters returns the floating point registers of the thread and the
+// raw arm_neon_state64_t they were read from.
+func (thread *nativeThread) fpRegisters() ([]proc.Register, []byte, error) {
+	fpregset := make([]byte, C.sizeof_arm_neon_state64_t)
+	kret := C.get_fpu_registers(C.mach_port_name_t(thread.os.threadAct), unsafe.Pointer(&fpregset[0]))
+	if kret != C.KERN_SUCCESS {
+		return nil, nil, fmt.Errorf("could not get floating point registers")
+	}
+	fpregs := decodeNeonState(fpregset)
+	return fpregs.Decode(), fpregset, nil
+}
@@ -0,0 +1,68 @@
+// +build cgo
+
+package native
+
+import (
+	"fmt"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/fbsdutil"
+)
+
+// SetPC sets PC to the value specified by 'pc'.
+func (thread *nativeThread) SetPC(pc uint64) error {
+	ir, err := registers(thread)
+	if err != nil {
+		return err
+	}
+	r := ir.(*fbsdutil.ARM64Registers)
+	r.Regs.Elr = pc
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(thread.ID, (*sys.Reg)(unsafe.Pointer(r.Regs))) })
+	return err
+}
+
+// SetSP sets SP to the value specified by 'sp'
+func (thread *nativeThread) SetSP(sp uint64) (err error) {
+	var ir proc.Registers
+	ir, err = registers(thread)
+	if err != nil {
+		return err
+	}
+	r := ir.(*fbsdutil.ARM64Registers)
+	r.Regs.Sp = sp
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(thread.ID, (*sys.Reg)(unsafe.Pointer(r.Regs))) })
+	return
+}
+
+func (thread *nativeThread) SetDX(dx uint64) (err error) {
+	return fmt.Errorf("not supported")
+}
+
+func registers(thread *nativeThread) (proc.Registers, error) {
+	var (
+		regs fbsdutil.ARM64PtraceRegs
+		err  error
+	)
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(thread.ID, (*sys.Reg)(unsafe.Pointer(&regs))) })
+	if err != nil {
+		return nil, err
+	}
+	r := fbsdutil.NewARM64Registers(&regs, func(r *fbsdutil.ARM64Registers) error {
+		var floatLoadError error
+		r.Fpregs, r.Fpregset, floatLoadError = thread.fpRegisters()
+		return floatLoadError
+	})
+	return r, nil
+}
+
+func (thread *nativeThread) fpRegisters() (regs []proc.Register, fpregs *fbsdutil.ARM64PtraceFpRegs, err error) {
+	fpregs = &fbsdutil.ARM64PtraceFpRegs{}
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceGetFpRegs(thread.ID, (*sys.FpReg)(unsafe.Pointer(fpregs))) })
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get floating point registers: %v", err.Error())
+	}
+	return fpregs.Decode(), fpregs, nil
+}
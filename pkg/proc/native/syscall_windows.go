@@ -93,8 +93,39 @@ const (
 	_EXCEPTION_SINGLE_STEP = 0x80000004
 
 	_EXCEPTION_MAXIMUM_PARAMETERS = 15
+
+	_MEM_COMMIT = 0x1000
+
+	_PAGE_NOACCESS          = 0x01
+	_PAGE_READONLY          = 0x02
+	_PAGE_READWRITE         = 0x04
+	_PAGE_WRITECOPY         = 0x08
+	_PAGE_EXECUTE           = 0x10
+	_PAGE_EXECUTE_READ      = 0x20
+	_PAGE_EXECUTE_READWRITE = 0x40
+	_PAGE_EXECUTE_WRITECOPY = 0x80
+	_PAGE_GUARD             = 0x100
+
+	_MiniDumpWithDataSegs               = 0x00000001
+	_MiniDumpWithFullMemory             = 0x00000002
+	_MiniDumpWithHandleData             = 0x00000004
+	_MiniDumpWithUnloadedModules        = 0x00000020
+	_MiniDumpWithPrivateReadWriteMemory = 0x00000200
+	_MiniDumpWithFullMemoryInfo         = 0x00000800
+	_MiniDumpWithThreadInfo             = 0x00001000
 )
 
+type _MEMORY_BASIC_INFORMATION struct {
+	BaseAddress       uintptr
+	AllocationBase    uintptr
+	AllocationProtect uint32
+	PartitionId       uint16
+	RegionSize        uintptr
+	State             uint32
+	Protect           uint32
+	Type              uint32
+}
+
 func _NT_SUCCESS(x _NTSTATUS) bool {
 	return x >= 0
 }
@@ -117,3 +148,5 @@ type _CONTEXT = winutil.CONTEXT
 //sys	_DebugActiveProcess(processid uint32) (err error) = kernel32.DebugActiveProcess
 //sys	_DebugActiveProcessStop(processid uint32) (err error) = kernel32.DebugActiveProcessStop
 //sys	_QueryFullProcessImageName(process syscall.Handle, flags uint32, exename *uint16, size *uint32) (err error) = kernel32.QueryFullProcessImageNameW
+//sys	_VirtualQueryEx(process syscall.Handle, addr uintptr, buffer *_MEMORY_BASIC_INFORMATION, length uintptr) (lengthOut uintptr) = kernel32.VirtualQueryEx
+//sys	_MiniDumpWriteDump(process syscall.Handle, pid uint32, file syscall.Handle, dumpType uint32, exceptionParam uintptr, userStreamParam uintptr, callbackParam uintptr) (err error) = dbghelp.MiniDumpWriteDump
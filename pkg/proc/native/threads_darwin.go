@@ -6,7 +6,6 @@ package native
 // #include "proc_darwin.h"
 import "C"
 import (
-	"errors"
 	"fmt"
 	"unsafe"
 
@@ -22,7 +21,6 @@ type waitStatus sys.WaitStatus
 // operating system / kernel.
 type osSpecificDetails struct {
 	threadAct C.thread_act_t
-	registers C.x86_thread_state64_t
 	exists    bool
 }
 
@@ -128,7 +126,3 @@ func (t *nativeThread) ReadMemory(buf []byte, addr uint64) (int, error) {
 	}
 	return len(buf), nil
 }
-
-func (t *nativeThread) restoreRegisters(sr proc.Registers) error {
-	return errors.New("not implemented")
-}
@@ -12,6 +12,20 @@ write_memory(task_t, mach_vm_address_t, void *, mach_msg_type_number_t);
 int
 read_memory(task_t, mach_vm_address_t, void *, mach_msg_type_number_t);
 
+kern_return_t
+resume_thread(thread_act_t);
+
+kern_return_t
+get_identity(mach_port_name_t, thread_identifier_info_data_t *);
+
+int
+thread_blocked(thread_act_t thread);
+
+int
+num_running_threads(task_t task);
+
+#if defined(__x86_64__)
+
 kern_return_t
 get_registers(mach_port_name_t, x86_thread_state64_t*);
 
@@ -19,25 +33,31 @@ kern_return_t
 get_fpu_registers(mach_port_name_t, x86_float_state64_t *);
 
 kern_return_t
-set_pc(thread_act_t, uint64_t);
+set_registers(mach_port_name_t, x86_thread_state64_t*);
+
+#elif defined(__arm64__)
 
+// regs holds X0-X28, FP, LR, SP, PC and CPSR, in this order.
 kern_return_t
-single_step(thread_act_t);
+get_registers(mach_port_name_t, uint64_t *regs);
 
 kern_return_t
-clear_trap_flag(thread_act_t);
+set_registers(mach_port_name_t, uint64_t *regs);
 
+// state points to an arm_neon_state64_t.
 kern_return_t
-resume_thread(thread_act_t);
+get_fpu_registers(mach_port_name_t, void *state);
 
 kern_return_t
-set_registers(mach_port_name_t, x86_thread_state64_t*);
+set_fpu_registers(mach_port_name_t, void *state);
+
+#endif
 
 kern_return_t
-get_identity(mach_port_name_t, thread_identifier_info_data_t *);
+set_pc(thread_act_t, uint64_t);
 
-int
-thread_blocked(thread_act_t thread);
+kern_return_t
+single_step(thread_act_t);
 
-int
-num_running_threads(task_t task);
+kern_return_t
+clear_trap_flag(thread_act_t);
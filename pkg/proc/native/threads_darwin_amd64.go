@@ -0,0 +1,13 @@
+//+build darwin,macnative
+
+package native
+
+import (
+	"errors"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+func (t *nativeThread) restoreRegisters(sr proc.Registers) error {
+	return errors.New("not implemented")
+}
@@ -0,0 +1,105 @@
+//+build darwin,macnative
+
+#include "threads_darwin.h"
+
+// Delve is built for arm64, not arm64e, so the fields of
+// arm_thread_state64_t are not opaque and can be accessed directly.
+
+kern_return_t
+get_registers(mach_port_name_t task, uint64_t *regs) {
+	kern_return_t kret;
+	arm_thread_state64_t state;
+	mach_msg_type_number_t stateCount = ARM_THREAD_STATE64_COUNT;
+
+	kret = thread_get_state(task, ARM_THREAD_STATE64, (thread_state_t)&state, &stateCount);
+	if (kret != KERN_SUCCESS) return kret;
+
+	for (int i = 0; i < 29; i++) {
+		regs[i] = state.__x[i];
+	}
+	regs[29] = state.__fp;
+	regs[30] = state.__lr;
+	regs[31] = state.__sp;
+	regs[32] = state.__pc;
+	regs[33] = state.__cpsr;
+	return KERN_SUCCESS;
+}
+
+kern_return_t
+set_registers(mach_port_name_t task, uint64_t *regs) {
+	kern_return_t kret;
+	arm_thread_state64_t state;
+	mach_msg_type_number_t stateCount = ARM_THREAD_STATE64_COUNT;
+
+	kret = thread_get_state(task, ARM_THREAD_STATE64, (thread_state_t)&state, &stateCount);
+	if (kret != KERN_SUCCESS) return kret;
+
+	for (int i = 0; i < 29; i++) {
+		state.__x[i] = regs[i];
+	}
+	state.__fp = regs[29];
+	state.__lr = regs[30];
+	state.__sp = regs[31];
+	state.__pc = regs[32];
+	state.__cpsr = (uint32_t)regs[33];
+
+	return thread_set_state(task, ARM_THREAD_STATE64, (thread_state_t)&state, stateCount);
+}
+
+kern_return_t
+get_fpu_registers(mach_port_name_t task, void *state) {
+	mach_msg_type_number_t stateCount = ARM_NEON_STATE64_COUNT;
+	return thread_get_state(task, ARM_NEON_STATE64, (thread_state_t)state, &stateCount);
+}
+
+kern_return_t
+set_fpu_registers(mach_port_name_t task, void *state) {
+	mach_msg_type_number_t stateCount = ARM_NEON_STATE64_COUNT;
+	return thread_set_state(task, ARM_NEON_STATE64, (thread_state_t)state, stateCount);
+}
+
+kern_return_t
+set_pc(thread_act_t task, uint64_t pc) {
+	kern_return_t kret;
+	arm_thread_state64_t state;
+	mach_msg_type_number_t stateCount = ARM_THREAD_STATE64_COUNT;
+
+	kret = thread_get_state(task, ARM_THREAD_STATE64, (thread_state_t)&state, &stateCount);
+	if (kret != KERN_SUCCESS) return kret;
+	state.__pc = pc;
+
+	return thread_set_state(task, ARM_THREAD_STATE64, (thread_state_t)&state, stateCount);
+}
+
+kern_return_t
+single_step(thread_act_t thread) {
+	kern_return_t kret;
+	arm_debug_state64_t state;
+	mach_msg_type_number_t count = ARM_DEBUG_STATE64_COUNT;
+
+	kret = thread_get_state(thread, ARM_DEBUG_STATE64, (thread_state_t)&state, &count);
+	if (kret != KERN_SUCCESS) return kret;
+
+	// Set the software step bit (SS) in MDSCR_EL1
+	state.__mdscr_el1 |= 1;
+
+	kret = thread_set_state(thread, ARM_DEBUG_STATE64, (thread_state_t)&state, count);
+	if (kret != KERN_SUCCESS) return kret;
+
+	return resume_thread(thread);
+}
+
+kern_return_t
+clear_trap_flag(thread_act_t thread) {
+	kern_return_t kret;
+	arm_debug_state64_t state;
+	mach_msg_type_number_t count = ARM_DEBUG_STATE64_COUNT;
+
+	kret = thread_get_state(thread, ARM_DEBUG_STATE64, (thread_state_t)&state, &count);
+	if (kret != KERN_SUCCESS) return kret;
+
+	// Clear the software step bit (SS) in MDSCR_EL1
+	state.__mdscr_el1 &= ~1ULL;
+
+	return thread_set_state(thread, ARM_DEBUG_STATE64, (thread_state_t)&state, count);
+}
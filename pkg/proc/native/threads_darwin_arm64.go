@@ -0,0 +1,28 @@
+//+build darwin,macnative
+
+package native
+
+// #include "threads_darwin.h"
+import "C"
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	sr := savedRegs.(*linutil.ARM64Registers)
+	kret := C.set_registers(C.mach_port_name_t(t.os.threadAct), (*C.uint64_t)(unsafe.Pointer(sr.Regs)))
+	if kret != C.KERN_SUCCESS {
+		return fmt.Errorf("could not restore registers")
+	}
+	if sr.Fpregset != nil {
+		kret = C.set_fpu_registers(C.mach_port_name_t(t.os.threadAct), unsafe.Pointer(&sr.Fpregset[0]))
+		if kret != C.KERN_SUCCESS {
+			return fmt.Errorf("could not restore floating point registers")
+		}
+	}
+	return nil
+}
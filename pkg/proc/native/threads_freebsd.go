@@ -4,9 +4,6 @@ package native
 import "C"
 import (
 	"fmt"
-	"github.com/go-delve/delve/pkg/proc/fbsdutil"
-	"syscall"
-	"unsafe"
 
 	sys "golang.org/x/sys/unix"
 
@@ -75,30 +72,6 @@ func (t *nativeThread) singleStep() (err error) {
 	return nil
 }
 
-func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
-	sr := savedRegs.(*fbsdutil.AMD64Registers)
-
-	var restoreRegistersErr error
-	t.dbp.execPtraceFunc(func() {
-		restoreRegistersErr = sys.PtraceSetRegs(t.ID, (*sys.Reg)(sr.Regs))
-		if restoreRegistersErr != nil {
-			return
-		}
-		if sr.Fpregset.Xsave != nil {
-			iov := sys.Iovec{Base: &sr.Fpregset.Xsave[0], Len: uint64(len(sr.Fpregset.Xsave))}
-			_, _, restoreRegistersErr = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGS, uintptr(t.ID), uintptr(unsafe.Pointer(&iov)), 0, 0, 0)
-			return
-		}
-
-		_, _, restoreRegistersErr = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETFPREGS, uintptr(t.ID), uintptr(unsafe.Pointer(&sr.Fpregset.AMD64PtraceFpRegs)), 0, 0, 0)
-		return
-	})
-	if restoreRegistersErr == syscall.Errno(0) {
-		restoreRegistersErr = nil
-	}
-	return restoreRegistersErr
-}
-
 func (t *nativeThread) WriteMemory(addr uint64, data []byte) (written int, err error) {
 	if t.dbp.exited {
 		return 0, proc.ErrProcessExited{Pid: t.dbp.pid}
@@ -0,0 +1,30 @@
+// +build cgo
+
+package native
+
+import (
+	"syscall"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/fbsdutil"
+)
+
+func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	sr := savedRegs.(*fbsdutil.ARM64Registers)
+
+	var restoreRegistersErr error
+	t.dbp.execPtraceFunc(func() {
+		restoreRegistersErr = sys.PtraceSetRegs(t.ID, (*sys.Reg)(unsafe.Pointer(sr.Regs)))
+		if restoreRegistersErr != nil || sr.Fpregset == nil {
+			return
+		}
+		_, _, restoreRegistersErr = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETFPREGS, uintptr(t.ID), uintptr(unsafe.Pointer(sr.Fpregset)), 0, 0, 0)
+	})
+	if restoreRegistersErr == syscall.Errno(0) {
+		restoreRegistersErr = nil
+	}
+	return restoreRegistersErr
+}
@@ -20,7 +20,11 @@ type osSpecificDetails struct {
 }
 
 func (t *nativeThread) stop() (err error) {
-	err = sys.Tgkill(t.dbp.pid, t.ID, sys.SIGSTOP)
+	if t.dbp.os.seized {
+		t.dbp.execPtraceFunc(func() { err = ptraceInterrupt(t.ID) })
+	} else {
+		err = sys.Tgkill(t.dbp.pid, t.ID, sys.SIGSTOP)
+	}
 	if err != nil {
 		err = fmt.Errorf("stop err %s on thread %d", err, t.ID)
 		return
@@ -96,9 +100,74 @@ func (t *nativeThread) ReadMemory(data []byte, addr uint64) (n int, err error) {
 	if len(data) == 0 {
 		return
 	}
-	n, _ = processVmRead(t.ID, uintptr(addr), data)
-	if n == 0 {
-		t.dbp.execPtraceFunc(func() { n, err = sys.PtracePeekData(t.ID, uintptr(addr), data) })
+	if !t.dbp.os.processVmDisabled {
+		n, err = processVmRead(t.ID, uintptr(addr), data)
+		t.checkProcessVm(err)
+	}
+	if n < len(data) {
+		// Read whatever process_vm_readv could not read one word at a time.
+		var m int
+		t.dbp.execPtraceFunc(func() { m, err = sys.PtracePeekData(t.ID, uintptr(addr)+uintptr(n), data[n:]) })
+		n += m
+	} else {
+		err = nil
 	}
 	return
 }
+
+// checkProcessVm disables process_vm_readv for the process if err means
+// that it is not available, either because the kernel is older than 3.2 or
+// because it is forbidden by a seccomp policy.
+func (t *nativeThread) checkProcessVm(err error) {
+	if err == sys.ENOSYS || err == sys.EPERM {
+		t.dbp.os.processVmDisabled = true
+	}
+}
+
+// maxIovecs is the maximum number of memory ranges that can be read with a
+// single call to process_vm_readv (IOV_MAX).
+const maxIovecs = 1024
+
+// ReadMemoryBatch performs all the reads in reqs, up to maxIovecs at a
+// time, with a single call to process_vm_readv.
+func (t *nativeThread) ReadMemoryBatch(reqs []proc.MemoryReadRequest) {
+	local := make([]sys.Iovec, 0, maxIovecs)
+	remote := make([]uintptr, 0, 2*maxIovecs)
+	for len(reqs) > 0 {
+		if t.dbp.exited || t.dbp.os.processVmDisabled {
+			for i := range reqs {
+				reqs[i].N, reqs[i].Err = t.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+			}
+			return
+		}
+		k := len(reqs)
+		if k > maxIovecs {
+			k = maxIovecs
+		}
+		local, remote = local[:0], remote[:0]
+		for i := range reqs[:k] {
+			var iov sys.Iovec
+			if len(reqs[i].Buf) > 0 {
+				iov.Base = &reqs[i].Buf[0]
+			}
+			iov.SetLen(len(reqs[i].Buf))
+			local = append(local, iov)
+			remote = append(remote, uintptr(reqs[i].Addr), uintptr(len(reqs[i].Buf)))
+		}
+		n, err := processVmReadv(t.ID, local, remote)
+		t.checkProcessVm(err)
+
+		// process_vm_readv stops at the first range that can not be read
+		// completely, read that one separately and continue with the next.
+		i := 0
+		for ; i < k && n >= len(reqs[i].Buf); i++ {
+			reqs[i].N, reqs[i].Err = len(reqs[i].Buf), nil
+			n -= len(reqs[i].Buf)
+		}
+		if i < k {
+			reqs[i].N, reqs[i].Err = t.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+			i++
+		}
+		reqs = reqs[i:]
+	}
+}
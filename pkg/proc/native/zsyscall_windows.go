@@ -12,6 +12,7 @@ var _ unsafe.Pointer
 var (
 	modntdll    = syscall.NewLazyDLL("ntdll.dll")
 	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+	moddbghelp  = syscall.NewLazyDLL("dbghelp.dll")
 
 	procNtQueryInformationThread   = modntdll.NewProc("NtQueryInformationThread")
 	dbgUiRemoteBreakin             = modntdll.NewProc("DbgUiRemoteBreakin")
@@ -27,6 +28,8 @@ var (
 	procDebugActiveProcess         = modkernel32.NewProc("DebugActiveProcess")
 	procDebugActiveProcessStop     = modkernel32.NewProc("DebugActiveProcessStop")
 	procQueryFullProcessImageNameW = modkernel32.NewProc("QueryFullProcessImageNameW")
+	procVirtualQueryEx             = modkernel32.NewProc("VirtualQueryEx")
+	procMiniDumpWriteDump          = moddbghelp.NewProc("MiniDumpWriteDump")
 )
 
 func _NtQueryInformationThread(threadHandle syscall.Handle, infoclass int32, info uintptr, infolen uint32, retlen *uint32) (status _NTSTATUS) {
@@ -180,3 +183,21 @@ func _QueryFullProcessImageName(process syscall.Handle, flags uint32, exename *u
 	}
 	return
 }
+
+func _VirtualQueryEx(process syscall.Handle, addr uintptr, buffer *_MEMORY_BASIC_INFORMATION, length uintptr) (lengthOut uintptr) {
+	r0, _, _ := syscall.Syscall6(procVirtualQueryEx.Addr(), 4, uintptr(process), uintptr(addr), uintptr(unsafe.Pointer(buffer)), uintptr(length), 0, 0)
+	lengthOut = uintptr(r0)
+	return
+}
+
+func _MiniDumpWriteDump(process syscall.Handle, pid uint32, file syscall.Handle, dumpType uint32, exceptionParam uintptr, userStreamParam uintptr, callbackParam uintptr) (err error) {
+	r1, _, e1 := syscall.Syscall9(procMiniDumpWriteDump.Addr(), 7, uintptr(process), uintptr(pid), uintptr(file), uintptr(dumpType), uintptr(exceptionParam), uintptr(userStreamParam), uintptr(callbackParam), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
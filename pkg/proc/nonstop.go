@@ -0,0 +1,85 @@
+package proc
+
+import "errors"
+
+// NonStopController is implemented by backends that can stop and resume
+// the threads of the target individually (non-stop mode).
+type NonStopController interface {
+	// NonStopSupported returns true if non-stop mode can be enabled.
+	NonStopSupported() bool
+	// SetNonStop enables or disables non-stop mode. While non-stop mode is
+	// enabled ContinueOnce can not be used.
+	SetNonStop(enabled bool) error
+	// ResumeThread resumes thread tid, leaving the other threads alone.
+	ResumeThread(tid int) error
+	// StopThread requests that thread tid is stopped, the stop is reported
+	// by WaitThreadStop.
+	StopThread(tid int) error
+	// WaitThreadStop waits until a thread stops and returns it.
+	WaitThreadStop() (Thread, error)
+}
+
+// ErrNonStopNotSupported is returned when non-stop mode is used with a
+// backend, or a stub, that does not support it.
+var ErrNonStopNotSupported = errors.New("non-stop mode is not supported by this backend")
+
+// NonStopSupported returns true if the threads of the target can be
+// stopped and resumed individually, see SetNonStop.
+func (t *Target) NonStopSupported() bool {
+	nsc, ok := t.proc.(NonStopController)
+	return ok && nsc.NonStopSupported()
+}
+
+func (t *Target) nonStopController() (NonStopController, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	nsc, ok := t.proc.(NonStopController)
+	if !ok || !nsc.NonStopSupported() {
+		return nil, ErrNonStopNotSupported
+	}
+	return nsc, nil
+}
+
+// SetNonStop enables or disables non-stop mode. While non-stop mode is
+// enabled threads are resumed with ResumeThread and stopped with
+// StopThread, their stops are reported by WaitThreadStop, and Continue can
+// not be used.
+func (t *Target) SetNonStop(enabled bool) error {
+	nsc, err := t.nonStopController()
+	if err != nil {
+		return err
+	}
+	return nsc.SetNonStop(enabled)
+}
+
+// ResumeThread resumes thread tid in non-stop mode.
+func (t *Target) ResumeThread(tid int) error {
+	nsc, err := t.nonStopController()
+	if err != nil {
+		return err
+	}
+	t.ClearAllGCache()
+	return nsc.ResumeThread(tid)
+}
+
+// StopThread requests that thread tid is stopped, in non-stop mode.
+func (t *Target) StopThread(tid int) error {
+	nsc, err := t.nonStopController()
+	if err != nil {
+		return err
+	}
+	return nsc.StopThread(tid)
+}
+
+// WaitThreadStop waits until a thread stops, in non-stop mode, and returns
+// it.
+func (t *Target) WaitThreadStop() (Thread, error) {
+	nsc, err := t.nonStopController()
+	if err != nil {
+		return nil, err
+	}
+	th, err := nsc.WaitThreadStop()
+	t.ClearAllGCache()
+	return th, err
+}
@@ -0,0 +1,226 @@
+package proc
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// maxObjectGraphNodes is the maximum number of nodes of the graph returned
+// by ObjectGraph.
+const maxObjectGraphNodes = 1000
+
+// ObjectGraph is a graph of the objects reachable from a set of root
+// variables and of the pointers between them.
+type ObjectGraph struct {
+	Nodes []ObjectGraphNode
+	Edges []ObjectGraphEdge
+	// Truncated is true if some objects were not added to the graph
+	// because it reached its maximum size.
+	Truncated bool
+}
+
+// ObjectGraphNode is an object of an ObjectGraph: either one of the root
+// variables or a memory area pointed to by a pointer, slice, map or
+// interface.
+type ObjectGraphNode struct {
+	Addr uint64
+	Type string
+	Size int64
+	// Root is the expression of the root variable, if this node is a root.
+	Root string
+}
+
+// ObjectGraphEdge is a pointer from the node with index From to the node
+// with index To.
+type ObjectGraphEdge struct {
+	From, To int
+	// Label is the path of the pointer inside the object it is stored in
+	// (for example ".next" or "[2].data").
+	Label string
+}
+
+type objectGraphKey struct {
+	addr uint64
+	typ  string
+}
+
+type objectGraphItem struct {
+	id      int
+	content *Variable
+	depth   int
+}
+
+type objectGraphBuilder struct {
+	g     *ObjectGraph
+	cfg   LoadConfig
+	ids   map[objectGraphKey]int
+	queue []objectGraphItem
+}
+
+// ObjectGraph evaluates exprs and returns the graph of the objects
+// reachable from their values.
+//
+// The graph has a node for each expression and for each memory area
+// reachable from them through pointers, slices, maps and interfaces, every
+// node is identified by its address and its type. Only the first
+// cfg.MaxArrayValues elements of arrays, slices and maps are followed and
+// objects more than cfg.MaxVariableRecurse pointers away from the roots are
+// not scanned.
+func (scope *EvalScope) ObjectGraph(exprs []string, cfg LoadConfig) (*ObjectGraph, error) {
+	b := &objectGraphBuilder{g: &ObjectGraph{}, cfg: cfg, ids: make(map[objectGraphKey]int)}
+	for _, expr := range exprs {
+		v, err := scope.EvalExpression(expr, loadSingleValue)
+		if err != nil {
+			return nil, err
+		}
+		if v.Unreadable != nil {
+			return nil, fmt.Errorf("%s: %v", expr, v.Unreadable)
+		}
+		// roots are always distinct nodes, even if they have the same address
+		b.g.Nodes = append(b.g.Nodes, ObjectGraphNode{Addr: v.Addr, Type: v.TypeString(), Size: v.RealType.Size(), Root: expr})
+		b.queue = append(b.queue, objectGraphItem{len(b.g.Nodes) - 1, v, 0})
+	}
+	for len(b.queue) > 0 {
+		item := b.queue[0]
+		b.queue = b.queue[1:]
+		b.scanContent(item.content, item.id, item.depth)
+	}
+	return b.g, nil
+}
+
+// node returns the index of the node for the object at addr, adding it to
+// the graph if needed. Returns -1 if the graph is full.
+func (b *objectGraphBuilder) node(addr uint64, typ string, size int64, content *Variable, depth int) int {
+	k := objectGraphKey{addr, typ}
+	if id, ok := b.ids[k]; ok {
+		return id
+	}
+	if len(b.g.Nodes) >= maxObjectGraphNodes {
+		b.g.Truncated = true
+		return -1
+	}
+	b.g.Nodes = append(b.g.Nodes, ObjectGraphNode{Addr: addr, Type: typ, Size: size})
+	id := len(b.g.Nodes) - 1
+	b.ids[k] = id
+	if depth <= b.cfg.MaxVariableRecurse {
+		b.queue = append(b.queue, objectGraphItem{id, content, depth})
+	}
+	return id
+}
+
+func (b *objectGraphBuilder) edge(from, to int, label string) {
+	if to < 0 {
+		return
+	}
+	b.g.Edges = append(b.g.Edges, ObjectGraphEdge{From: from, To: to, Label: label})
+}
+
+// scanContent scans the contents of the object of node from.
+func (b *objectGraphBuilder) scanContent(v *Variable, from, depth int) {
+	switch v.Kind {
+	case reflect.Slice:
+		b.scanElements(v, from, "", depth)
+	case reflect.Map:
+		b.scanMap(v, from, "", depth)
+	default:
+		b.scan(v, from, "", depth)
+	}
+}
+
+// scan looks for pointers inside v, which is stored inside the object of
+// node from at path label.
+func (b *objectGraphBuilder) scan(v *Variable, from int, label string, depth int) {
+	if v.Unreadable != nil {
+		return
+	}
+	switch v.Kind {
+	case reflect.Ptr:
+		pv := v.maybeDereference()
+		if pv.Addr == 0 || pv.Unreadable != nil {
+			return
+		}
+		b.edge(from, b.node(pv.Addr, pv.TypeString(), pv.RealType.Size(), pv, depth+1), label)
+
+	case reflect.Interface:
+		v.loadInterface(0, false, b.cfg)
+		if v.Unreadable != nil || len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			return
+		}
+		cv := &v.Children[0]
+		cv.OnlyAddr = false
+		if cv.Kind == reflect.Ptr {
+			// pointer shaped values are stored directly in the interface
+			b.scan(cv, from, label, depth)
+			return
+		}
+		b.edge(from, b.node(cv.Addr, cv.TypeString(), cv.RealType.Size(), cv, depth+1), label)
+
+	case reflect.Slice:
+		if v.Base == 0 {
+			return
+		}
+		typ := fmt.Sprintf("[%d]%s", v.Cap, v.fieldType.String())
+		b.edge(from, b.node(v.Base, typ, v.Cap*v.stride, v, depth+1), label)
+
+	case reflect.Map:
+		addr, err := readUintRaw(v.mem, v.Addr, int64(v.bi.Arch.PtrSize()))
+		if err != nil || addr == 0 {
+			return
+		}
+		b.edge(from, b.node(addr, v.TypeString(), 0, v, depth+1), label)
+
+	case reflect.Struct:
+		t := v.RealType.(*godwarf.StructType)
+		for _, field := range t.Field {
+			f, err := v.toField(field)
+			if err != nil {
+				continue
+			}
+			b.scan(f, from, label+"."+field.Name, depth)
+		}
+
+	case reflect.Array:
+		b.scanElements(v, from, label, depth)
+	}
+}
+
+func (b *objectGraphBuilder) scanElements(v *Variable, from int, label string, depth int) {
+	count := v.Len
+	if count > int64(b.cfg.MaxArrayValues) {
+		count = int64(b.cfg.MaxArrayValues)
+	}
+	for i := 0; i < int(count); i++ {
+		ev, err := v.sliceAccess(i)
+		if err != nil {
+			return
+		}
+		b.scan(ev, from, fmt.Sprintf("%s[%d]", label, i), depth)
+	}
+}
+
+func (b *objectGraphBuilder) scanMap(v *Variable, from int, label string, depth int) {
+	it := v.mapIterator()
+	if it == nil {
+		return
+	}
+	it.maxNumBuckets = uint64(b.cfg.MaxMapBuckets)
+	keycfg := loadSingleValue
+	keycfg.MaxStringLen = b.cfg.MaxStringLen
+	for count := 0; count < b.cfg.MaxArrayValues && it.next(); count++ {
+		key := it.key()
+		keylabel := "[?]"
+		key.loadValue(keycfg)
+		if ks, ok := mapKeyString(key); ok {
+			if key.Kind == reflect.String {
+				ks = fmt.Sprintf("%q", ks)
+			}
+			keylabel = "[" + ks + "]"
+		}
+		b.scan(key, from, fmt.Sprintf("%s.key%s", label, keylabel), depth)
+		if it.values.fieldType.Size() > 0 {
+			b.scan(it.value(), from, label+keylabel, depth)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package proc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+// FunctionPatch is a change to the code of a function made by
+// PatchFunctionReturn or PatchFunctionCode, it can be reverted with
+// UnpatchFunction.
+type FunctionPatch struct {
+	ID int
+	Fn *Function
+	// OriginalData is the code overwritten at the entry point of Fn.
+	OriginalData []byte
+	// CodeAddr is the address of the replacement code if it was written to
+	// memory allocated by the debugger, zero if it was written at the entry
+	// point of Fn.
+	CodeAddr uint64
+	// Desc is a description of the replacement code.
+	Desc string
+}
+
+// patchArena is memory allocated in the target process to hold the code
+// of function patches.
+type patchArena struct {
+	addr, size, used uint64
+}
+
+const patchArenaSize = 0x10000
+
+// ErrPatchAllocNotSupported is returned when the replacement code of a
+// patch does not fit in the patched function and memory can not be
+// allocated in the target process.
+var ErrPatchAllocNotSupported = errors.New("can not allocate memory in the target process on this operating system or architecture")
+
+// FunctionPatches returns the function patches currently applied, sorted
+// by ID.
+func (t *Target) FunctionPatches() []*FunctionPatch {
+	r := make([]*FunctionPatch, 0, len(t.patches))
+	for _, patch := range t.patches {
+		r = append(r, patch)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	return r
+}
+
+// PatchFunctionReturn changes the code of fn so that it immediately
+// returns vals, one for each result of fn, without executing its body.
+// Supported values are integer and boolean literals, nil, addresses (for
+// pointer, func, map and chan results) and quoted strings.
+// Results of other types (floating point numbers, structs, arrays) can
+// not be returned.
+func (t *Target) PatchFunctionReturn(fn *Function, vals []string) (*FunctionPatch, error) {
+	if err := t.checkPatchable(fn); err != nil {
+		return nil, err
+	}
+	bi := t.BinInfo()
+	_, formalArgs, err := funcCallArgs(fn, bi, true)
+	if err != nil {
+		return nil, err
+	}
+	var results []funcCallArg
+	for _, arg := range formalArgs {
+		if arg.isret {
+			results = append(results, arg)
+		}
+	}
+	if len(vals) != len(results) {
+		return nil, fmt.Errorf("wrong number of return values for %s: %d (expected %d)", fn.Name, len(vals), len(results))
+	}
+
+	var words []resultWord
+	for i, result := range results {
+		w, err := t.patchResultWords(result, vals[i])
+		if err != nil {
+			return nil, fmt.Errorf("result %d (%s %s): %v", i, result.name, result.typ.String(), err)
+		}
+		words = append(words, w...)
+	}
+
+	code, err := patchReturnCode(bi, regabiFunction(bi, fn), words)
+	if err != nil {
+		return nil, err
+	}
+	desc := "return"
+	if len(vals) > 0 {
+		desc += " " + strings.Join(vals, ", ")
+	}
+	if uint64(len(code)) <= fn.End-fn.Entry {
+		// Write the code in place, without allocating memory.
+		return t.applyPatch(fn, code, 0, desc)
+	}
+	return t.patchWithJump(fn, code, desc)
+}
+
+// PatchFunctionCode changes the entry point of fn to jump to code, which
+// is copied to memory allocated in the target process. The code must be
+// position independent machine code for the architecture of the target
+// that follows the calling convention of fn.
+func (t *Target) PatchFunctionCode(fn *Function, code []byte) (*FunctionPatch, error) {
+	if err := t.checkPatchable(fn); err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, errors.New("empty code")
+	}
+	return t.patchWithJump(fn, code, fmt.Sprintf("code (%d bytes)", len(code)))
+}
+
+// UnpatchFunction reverts the patch with the given ID.
+func (t *Target) UnpatchFunction(id int) error {
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	for addr, patch := range t.patches {
+		if patch.ID != id {
+			continue
+		}
+		if err := t.checkPatchRange(patch.Fn, uint64(len(patch.OriginalData))); err != nil {
+			return err
+		}
+		if _, err := t.Memory().WriteMemory(addr, patch.OriginalData); err != nil {
+			return err
+		}
+		delete(t.patches, addr)
+		return nil
+	}
+	return fmt.Errorf("no patch with ID %d", id)
+}
+
+// checkPatchable returns an error if the code of fn can not be patched.
+func (t *Target) checkPatchable(fn *Function) error {
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	if ok, _ := t.Recorded(); ok {
+		return errors.New("can not patch functions of a recording")
+	}
+	if fn == nil || fn.Entry == 0 || fn.End <= fn.Entry {
+		return errors.New("can not patch a function without code")
+	}
+	if patch := t.patches[fn.Entry]; patch != nil {
+		return fmt.Errorf("%s is already patched (patch %d)", fn.Name, patch.ID)
+	}
+	return nil
+}
+
+// checkPatchRange returns an error if the first n bytes of fn can not be
+// overwritten, because they contain a breakpoint or a thread is stopped
+// in the middle of them.
+func (t *Target) checkPatchRange(fn *Function, n uint64) error {
+	end := fn.Entry + n
+	for addr := range t.Breakpoints().M {
+		if addr >= fn.Entry && addr < end {
+			return fmt.Errorf("a breakpoint is set at %#x, clear it before patching or unpatching %s", addr, fn.Name)
+		}
+	}
+	for _, thread := range t.ThreadList() {
+		regs, err := thread.Registers()
+		if err != nil {
+			return err
+		}
+		if pc := regs.PC(); pc > fn.Entry && pc < end {
+			return fmt.Errorf("thread %d is stopped inside the code that would be overwritten", thread.ThreadID())
+		}
+	}
+	return nil
+}
+
+// applyPatch writes code at the entry point of fn and records the patch.
+func (t *Target) applyPatch(fn *Function, code []byte, codeAddr uint64, desc string) (*FunctionPatch, error) {
+	if err := t.checkPatchRange(fn, uint64(len(code))); err != nil {
+		return nil, err
+	}
+	mem := t.Memory()
+	orig := make([]byte, len(code))
+	if _, err := mem.ReadMemory(orig, fn.Entry); err != nil {
+		return nil, err
+	}
+	if _, err := mem.WriteMemory(fn.Entry, code); err != nil {
+		return nil, err
+	}
+	t.patchID++
+	patch := &FunctionPatch{ID: t.patchID, Fn: fn, OriginalData: orig, CodeAddr: codeAddr, Desc: desc}
+	t.patches[fn.Entry] = patch
+	return patch, nil
+}
+
+// patchWithJump copies code to memory allocated in the target and writes
+// a jump to it at the entry point of fn.
+func (t *Target) patchWithJump(fn *Function, code []byte, desc string) (*FunctionPatch, error) {
+	bi := t.BinInfo()
+	jmp := patchJumpCode(bi, 0)
+	if jmp == nil {
+		return nil, fmt.Errorf("can not patch functions on %s", bi.Arch.Name)
+	}
+	if uint64(len(jmp)) > fn.End-fn.Entry {
+		return nil, fmt.Errorf("%s is too small to be patched", fn.Name)
+	}
+	if err := t.checkPatchRange(fn, uint64(len(jmp))); err != nil {
+		return nil, err
+	}
+	codeAddr, err := t.patchAlloc(uint64(len(code)))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.Memory().WriteMemory(codeAddr, code); err != nil {
+		return nil, err
+	}
+	return t.applyPatch(fn, patchJumpCode(bi, codeAddr), codeAddr, desc)
+}
+
+// patchAlloc allocates size bytes of executable memory in the target
+// process, the memory is never freed.
+func (t *Target) patchAlloc(size uint64) (uint64, error) {
+	size = (size + 15) &^ 15
+	if size > patchArenaSize {
+		return 0, fmt.Errorf("patch too big (%d bytes)", size)
+	}
+	if t.patchArena.addr == 0 || t.patchArena.used+size > t.patchArena.size {
+		addr, err := t.mmapPatchArena()
+		if err != nil {
+			return 0, err
+		}
+		t.patchArena = patchArena{addr: addr, size: patchArenaSize}
+	}
+	addr := t.patchArena.addr + t.patchArena.used
+	t.patchArena.used += size
+	return addr, nil
+}
+
+// mmapPatchArena maps an anonymous, readable and executable, region of
+// memory in the target process by calling mmap with RawSyscall. The
+// debugger writes to it through the backend, it does not need to be
+// writable.
+func (t *Target) mmapPatchArena() (uint64, error) {
+	const (
+		protRead     = 0x1
+		protExec     = 0x4
+		mapPrivate   = 0x2
+		mapAnonLinux = 0x20
+		mapAnonBSD   = 0x1000
+	)
+	bi := t.BinInfo()
+	var num, mapAnon uint64
+	switch bi.GOOS + "/" + bi.Arch.Name {
+	case "linux/amd64":
+		num, mapAnon = 9, mapAnonLinux
+	case "linux/arm64":
+		num, mapAnon = 222, mapAnonLinux
+	case "freebsd/amd64":
+		num, mapAnon = 477, mapAnonBSD
+	case "darwin/amd64", "darwin/arm64":
+		num, mapAnon = 197, mapAnonBSD
+	default:
+		return 0, ErrPatchAllocNotSupported
+	}
+	r, err := t.RawSyscall(num, []uint64{0, patchArenaSize, protRead | protExec, mapPrivate | mapAnon, ^uint64(0), 0})
+	if err != nil {
+		return 0, fmt.Errorf("could not allocate memory in the target process: %v", err)
+	}
+	if r.Errno != 0 {
+		return 0, fmt.Errorf("could not allocate memory in the target process: mmap returned errno %d", r.Errno)
+	}
+	return r.R1, nil
+}
+
+// regabiFunction returns true if fn receives its arguments and returns its
+// results in registers.
+func regabiFunction(bi *BinaryInfo, fn *Function) bool {
+	if bi.Producer() == "" {
+		return false
+	}
+	switch bi.Arch.Name {
+	case "amd64":
+		return goversion.ProducerAfterOrEqual(bi.Producer(), 1, 17)
+	case "arm64":
+		return goversion.ProducerAfterOrEqual(bi.Producer(), 1, 18)
+	}
+	return false
+}
+
+// resultWord is a word of a result returned by a function patched by
+// PatchFunctionReturn.
+type resultWord struct {
+	val  uint64
+	off  int64 // offset from the start of the argument frame, for functions that return results on the stack
+	size int64
+}
+
+// patchResultWords converts val to the words stored in result.
+func (t *Target) patchResultWords(result funcCallArg, val string) ([]resultWord, error) {
+	ptrSize := int64(t.BinInfo().Arch.PtrSize())
+	word := func(v uint64, i, size int64) resultWord {
+		return resultWord{val: v, off: result.off + i*ptrSize, size: size}
+	}
+	zeroes := func(n int64) ([]resultWord, error) {
+		if val != "nil" {
+			return nil, errors.New("only nil can be returned")
+		}
+		r := make([]resultWord, n)
+		for i := range r {
+			r[i] = word(0, int64(i), ptrSize)
+		}
+		return r, nil
+	}
+
+	switch typ := resolveTypedef(result.typ).(type) {
+	case *godwarf.BoolType:
+		switch val {
+		case "true":
+			return []resultWord{word(1, 0, typ.Size())}, nil
+		case "false":
+			return []resultWord{word(0, 0, typ.Size())}, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", val)
+	case *godwarf.IntType, *godwarf.UintType:
+		n, err := strconv.ParseInt(val, 0, 64)
+		if err != nil {
+			un, uerr := strconv.ParseUint(val, 0, 64)
+			if uerr != nil {
+				return nil, fmt.Errorf("invalid integer %q", val)
+			}
+			n = int64(un)
+		}
+		v := uint64(n)
+		if size := typ.Size(); size < 8 {
+			v &= 1<<(8*uint(size)) - 1
+		}
+		return []resultWord{word(v, 0, typ.Size())}, nil
+	case *godwarf.PtrType, *godwarf.FuncType, *godwarf.MapType, *godwarf.ChanType:
+		if val == "nil" {
+			return []resultWord{word(0, 0, ptrSize)}, nil
+		}
+		v, err := strconv.ParseUint(val, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q", val)
+		}
+		return []resultWord{word(v, 0, ptrSize)}, nil
+	case *godwarf.StringType:
+		s, err := strconv.Unquote(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", val)
+		}
+		var addr uint64
+		if s != "" {
+			addr, err = t.patchAlloc(uint64(len(s)))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.Memory().WriteMemory(addr, []byte(s)); err != nil {
+				return nil, err
+			}
+		}
+		return []resultWord{word(addr, 0, ptrSize), word(uint64(len(s)), 1, ptrSize)}, nil
+	case *godwarf.InterfaceType:
+		return zeroes(2)
+	case *godwarf.SliceType:
+		return zeroes(3)
+	default:
+		return nil, errors.New("unsupported result type")
+	}
+}
+
+// Integer registers used to return results by the register based calling
+// convention, see $GOROOT/src/cmd/compile/abi-internal.md.
+var (
+	amd64ResultRegs = []byte{0, 3, 1, 7, 6, 8, 9, 10, 11} // RAX, RBX, RCX, RDI, RSI, R8, R9, R10, R11
+	arm64ResultRegs = 16                                  // X0 ... X15
+)
+
+// patchReturnCode returns the machine code of a function that returns
+// words, either in registers or on the stack depending on regabi.
+func patchReturnCode(bi *BinaryInfo, regabi bool, words []resultWord) ([]byte, error) {
+	var buf bytes.Buffer
+	put := func(v interface{}) {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	switch bi.Arch.Name {
+	case "amd64":
+		if regabi && len(words) > len(amd64ResultRegs) {
+			return nil, errors.New("too many results")
+		}
+		for i, w := range words {
+			if regabi {
+				// MOVQ $val, reg
+				reg := amd64ResultRegs[i]
+				rex := byte(0x48)
+				if reg >= 8 {
+					rex |= 0x01
+				}
+				buf.Write([]byte{rex, 0xb8 + reg&7})
+				put(w.val)
+				continue
+			}
+			// Results are stored after the return address.
+			disp := uint32(8 + w.off)
+			switch w.size {
+			case 1: // MOVB $val, disp(SP)
+				buf.Write([]byte{0xc6, 0x84, 0x24})
+				put(disp)
+				put(uint8(w.val))
+			case 2: // MOVW $val, disp(SP)
+				buf.Write([]byte{0x66, 0xc7, 0x84, 0x24})
+				put(disp)
+				put(uint16(w.val))
+			case 4: // MOVL $val, disp(SP)
+				buf.Write([]byte{0xc7, 0x84, 0x24})
+				put(disp)
+				put(uint32(w.val))
+			case 8: // MOVQ $val, AX; MOVQ AX, disp(SP)
+				buf.Write([]byte{0x48, 0xb8})
+				put(w.val)
+				buf.Write([]byte{0x48, 0x89, 0x84, 0x24})
+				put(disp)
+			default:
+				return nil, fmt.Errorf("unsupported result size %d", w.size)
+			}
+		}
+		buf.WriteByte(0xc3) // RET
+	case "arm64":
+		if !regabi {
+			return nil, errors.New("functions returning their results on the stack can not be patched on arm64")
+		}
+		if len(words) > arm64ResultRegs {
+			return nil, errors.New("too many results")
+		}
+		for i, w := range words {
+			// MOVZ $(val&0xffff), Ri followed by a MOVK for every other non-zero
+			// halfword of val.
+			put(0xd2800000 | uint32(w.val&0xffff)<<5 | uint32(i))
+			for hw := uint32(1); hw < 4; hw++ {
+				if imm := uint32(w.val>>(16*hw)) & 0xffff; imm != 0 {
+					put(0xf2800000 | hw<<21 | imm<<5 | uint32(i))
+				}
+			}
+		}
+		put(uint32(0xd65f03c0)) // RET
+	default:
+		return nil, fmt.Errorf("can not patch functions on %s", bi.Arch.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// patchJumpCode returns the machine code of an absolute jump to dest that
+// does not change any register, or nil if the architecture is not
+// supported.
+func patchJumpCode(bi *BinaryInfo, dest uint64) []byte {
+	var buf bytes.Buffer
+	switch bi.Arch.Name {
+	case "amd64":
+		// JMP *0(IP); followed by the destination address
+		buf.Write([]byte{0xff, 0x25, 0x00, 0x00, 0x00, 0x00})
+	case "arm64":
+		// LDR 8(PC), R16; BR (R16); followed by the destination address.
+		// R16 (IP0) is a scratch register that can be clobbered at any call.
+		binary.Write(&buf, binary.LittleEndian, []uint32{0x58000050, 0xd61f0200})
+	default:
+		return nil
+	}
+	binary.Write(&buf, binary.LittleEndian, dest)
+	return buf.Bytes()
+}
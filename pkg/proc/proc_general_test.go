@@ -118,3 +118,35 @@ func TestDwarfVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestVectorFormat(t *testing.T) {
+	reg := []byte{
+		0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x40, 0xff, 0xff, 0xff, 0xff, 0x01, 0x02, 0x03, 0x04,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	for _, tc := range []struct {
+		format string
+		reg    []byte
+		tgt    string
+	}{
+		{"f32x4", reg[:16], "{ 1 2 NaN 1.5399896e-36 }"},
+		{"i32x4", reg[:16], "{ 1065353216 1073741824 -1 67305985 }"},
+		{"u32x4", reg[:16], "{ 1065353216 1073741824 4294967295 67305985 }"},
+		{"i8x16", reg[:16], "{ 0 0 -128 63 0 0 0 64 -1 -1 -1 -1 1 2 3 4 }"},
+		{"i16x8", reg[:16], "{ 0 16256 0 16384 -1 -1 513 1027 }"},
+		{"u64x2", reg[:16], "{ 4611686019492741120 289077008695033855 }"},
+		{"f64x2", reg[16:], "{ 1 0 }"},
+		{"f64x2", reg, "{ 2.000000473111868 2.438074724342243e-289 | 1 0 }"},
+	} {
+		vf, err := ParseVectorFormat(tc.format)
+		assertNoError(err, t, tc.format)
+		if out := vf.Format(tc.reg); out != tc.tgt {
+			t.Errorf("%s: got %q expected %q", tc.format, out, tc.tgt)
+		}
+	}
+	for _, bad := range []string{"", "f", "f16x8", "i32x8", "x8x16", "i8x16a", "u128x1"} {
+		if _, err := ParseVectorFormat(bad); err == nil {
+			t.Errorf("no error for format %q", bad)
+		}
+	}
+}
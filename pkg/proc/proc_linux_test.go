@@ -1,11 +1,17 @@
 package proc_test
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/go-delve/delve/pkg/proc"
 	"github.com/go-delve/delve/pkg/proc/native"
 	protest "github.com/go-delve/delve/pkg/proc/test"
 )
@@ -37,3 +43,90 @@ func stripAndCopyDebugInfo(f protest.Fixture, t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// procState returns the state field of /proc/<pid>/stat.
+func procState(t *testing.T, pid int) string {
+	buf, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The state follows the name of the executable, in parenthesis.
+	fields := strings.Fields(string(buf[strings.LastIndex(string(buf), ")")+1:]))
+	return fields[0]
+}
+
+func TestAttachStoppedProcess(t *testing.T) {
+	// Attaching with PTRACE_SEIZE does not change the state of the target,
+	// a process that was stopped before attaching must still be stopped
+	// after detaching.
+	if testBackend != "native" {
+		t.Skip("only for the native backend")
+	}
+	fixture := protest.BuildFixture("loopprog", 0)
+	cmd := exec.Command(fixture.Path)
+	assertNoError(cmd.Start(), t, "starting fixture")
+	defer cmd.Process.Kill()
+	time.Sleep(500 * time.Millisecond)
+
+	assertNoError(syscall.Kill(cmd.Process.Pid, syscall.SIGSTOP), t, "SIGSTOP")
+	for i := 0; procState(t, cmd.Process.Pid) != "T"; i++ {
+		if i > 50 {
+			t.Fatal("fixture did not stop")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	p, err := native.Attach(cmd.Process.Pid, []string{})
+	assertNoError(err, t, "Attach")
+	assertNoError(p.Detach(false), t, "Detach")
+	time.Sleep(100 * time.Millisecond)
+
+	if state := procState(t, cmd.Process.Pid); state != "T" {
+		t.Errorf("wrong state after detach %q (expected \"T\")", state)
+	}
+}
+
+func TestCRIUCheckpoint(t *testing.T) {
+	if testBackend != "native" {
+		t.Skip("only for the native backend")
+	}
+	if _, err := exec.LookPath("criu"); err != nil {
+		t.Skip("criu not installed")
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("criu requires root")
+	}
+	withTestProcess("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.sleepytime")
+		bp := setFunctionBreakpoint(p, t, "main.sayhi")
+		assertNoError(p.Continue(), t, "Continue")
+		pc := currentPC(p, t)
+
+		cpid, err := p.Checkpoint("checkpoint1")
+		assertNoError(err, t, "Checkpoint")
+		defer p.ClearCheckpoint(cpid)
+		cps, err := p.Checkpoints()
+		assertNoError(err, t, "Checkpoints")
+		if len(cps) != 1 || cps[0].ID != cpid || cps[0].Where != "checkpoint1" {
+			t.Fatalf("wrong checkpoints %v", cps)
+		}
+
+		// The process continues normally after the checkpoint.
+		assertNoError(p.Continue(), t, "Continue")
+		if currentPC(p, t) != bp.Addr {
+			t.Fatal("did not stop at main.sayhi")
+		}
+		if _, exited := p.Continue().(proc.ErrProcessExited); !exited {
+			t.Fatal("process did not exit")
+		}
+
+		assertNoError(p.Restart(fmt.Sprintf("c%d", cpid)), t, "Restart")
+		if newpc := currentPC(p, t); newpc != pc {
+			t.Fatalf("wrong pc after restart %#x (expected %#x)", newpc, pc)
+		}
+		assertNoError(p.Continue(), t, "Continue")
+		if currentPC(p, t) != bp.Addr {
+			t.Fatal("did not stop at main.sayhi after restart")
+		}
+	})
+}
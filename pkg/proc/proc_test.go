@@ -2,6 +2,7 @@ package proc_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -26,12 +27,13 @@ import (
 	"github.com/go-delve/delve/pkg/goversion"
 	"github.com/go-delve/delve/pkg/logflags"
 	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/core"
 	"github.com/go-delve/delve/pkg/proc/gdbserial"
 	"github.com/go-delve/delve/pkg/proc/native"
 	protest "github.com/go-delve/delve/pkg/proc/test"
 )
 
-var normalLoadConfig = proc.LoadConfig{true, 1, 64, 64, -1, 0}
+var normalLoadConfig = proc.LoadConfig{true, 1, 64, 64, -1, 0, false, 0}
 var testBackend, buildMode string
 
 func init() {
@@ -2665,7 +2667,7 @@ func BenchmarkTrace(b *testing.B) {
 			assertNoError(p.Continue(), b, "Continue()")
 			s, err := proc.GoroutineScope(p.CurrentThread())
 			assertNoError(err, b, "Scope()")
-			_, err = s.FunctionArguments(proc.LoadConfig{false, 0, 64, 0, 3, 0})
+			_, err = s.FunctionArguments(proc.LoadConfig{false, 0, 64, 0, 3, 0, false, 0})
 			assertNoError(err, b, "FunctionArguments()")
 		}
 		b.StopTimer()
@@ -4522,6 +4524,35 @@ func TestCgoStacktrace2(t *testing.T) {
 	})
 }
 
+func TestCgoStacktraceThroughLibc(t *testing.T) {
+	// Stacktraces through C library functions without debug info should
+	// use .eh_frame to unwind and the symbol table to describe the frames.
+	skipUnlessOn(t, "linux only", "linux")
+	skipOn(t, "broken", "386")
+	protest.MustHaveCgo(t)
+	withTestProcess("cgolibcstack", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		frames, err := proc.ThreadStacktrace(p.CurrentThread(), 100)
+		assertNoError(err, t, "Stacktrace()")
+		logStacktrace(t, p.BinInfo(), frames)
+		if stacktraceCheck(t, []string{"C.compareints", "C.sortints", "main.main"}, frames) == nil {
+			t.Fatal("see previous loglines")
+		}
+		found := false
+		for _, frame := range frames {
+			if frame.Current.Fn != nil {
+				continue
+			}
+			if name, _ := p.BinInfo().PCToSymbol(frame.Current.PC); strings.Contains(name, "qsort") {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("qsort frame not found")
+		}
+	})
+}
+
 func TestIssue1656(t *testing.T) {
 	skipUnlessOn(t, "amd64 only", "amd64")
 	withTestProcess("issue1656/", t, func(p *proc.Target, fixture protest.Fixture) {
@@ -4979,3 +5010,1238 @@ func TestStepOutPreservesGoroutine(t *testing.T) {
 		}
 	})
 }
+
+func TestSignalPolicy(t *testing.T) {
+	skipUnlessOn(t, "linux only", "linux")
+	withTestProcess("sigusr1", t, func(p *proc.Target, fixture protest.Fixture) {
+		sigusr1, err := proc.SignalNumber("linux", "usr1")
+		assertNoError(err, t, "SignalNumber")
+		if err := p.SetSignalPolicy(sigusr1, proc.SignalPolicy{Stop: true, Print: true, Pass: true}); err != nil {
+			t.Fatalf("SetSignalPolicy: %v", err)
+		}
+
+		checkSignal := func() {
+			t.Helper()
+			if p.StopReason != proc.StopSignal {
+				t.Fatalf("wrong stop reason %v", p.StopReason)
+			}
+			if len(p.ReceivedSignals) != 1 || p.ReceivedSignals[0].Signal != sigusr1 {
+				t.Fatalf("wrong received signals %v", p.ReceivedSignals)
+			}
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		checkSignal()
+
+		// the second SIGUSR1 would kill the target process if it was delivered
+		assertNoError(p.SetSignalPolicy(sigusr1, proc.SignalPolicy{Stop: true, Print: true, Pass: false}), t, "SetSignalPolicy")
+		assertNoError(p.Continue(), t, "Continue()")
+		checkSignal()
+
+		err = p.Continue()
+		if exited, ok := err.(proc.ErrProcessExited); !ok || exited.Status != 0 {
+			t.Fatalf("expected process to exit normally: %v", err)
+		}
+
+		if err := p.SetSignalPolicy(9, proc.SignalPolicy{}); err == nil {
+			t.Fatal("changing the policy of SIGKILL should not be allowed")
+		}
+	})
+}
+
+func TestStepInline(t *testing.T) {
+	// When next stops at the beginning of an inlined call, which it only does
+	// with StopAtInlinedCalls set, the call site should be reported as the
+	// current location, step should then enter the inlined call without
+	// resuming the target.
+	if ver, _ := goversion.Parse(runtime.Version()); ver.Major >= 0 && !ver.AfterOrEqual(goversion.GoVersion{Major: 1, Minor: 10, Rev: -1}) {
+		// Versions of go before 1.10 do not have DWARF information for inlined calls
+		t.Skip("inlining not supported")
+	}
+	withTestProcessArgs("inlinestepinto", t, ".", []string{}, protest.EnableInlining, func(p *proc.Target, fixture protest.Fixture) {
+		p.StopAtInlinedCalls = true
+		logicalLine := func() int {
+			loc, err := proc.LogicalLocation(p.CurrentThread())
+			assertNoError(err, t, "LogicalLocation")
+			return loc.Line
+		}
+
+		setFileBreakpoint(p, t, fixture.Source, 13)
+		assertNoError(p.Continue(), t, "Continue()")
+		assertNoError(p.Next(), t, "Next()")
+
+		calls, err := p.InlineTree()
+		assertNoError(err, t, "InlineTree()")
+		if len(calls) == 0 {
+			t.Skip("the compiler emitted instructions for the call site before the inlined call")
+		}
+		if len(calls) != 1 || calls[0].Fn.Name != "main.incr" || calls[0].Line != 14 || calls[0].Entered {
+			t.Fatalf("wrong inline tree %#v", calls)
+		}
+		if l := logicalLine(); l != 14 {
+			t.Fatalf("wrong line after next %d, expected call site 14", l)
+		}
+		frames, err := proc.ThreadStacktrace(p.CurrentThread(), 10)
+		assertNoError(err, t, "ThreadStacktrace")
+		if frames[0].Inlined || frames[0].Call.Fn.Name != "main.main" || frames[0].Call.Line != 14 {
+			t.Fatalf("wrong topmost frame %s:%d %v", frames[0].Call.Fn.Name, frames[0].Call.Line, frames[0].Inlined)
+		}
+
+		pc := currentPC(p, t)
+		assertNoError(p.Step(), t, "Step()")
+		if currentPC(p, t) != pc {
+			t.Fatalf("target resumed while stepping into inlined call")
+		}
+		if l := logicalLine(); l != 8 {
+			t.Fatalf("wrong line after step %d, expected 8", l)
+		}
+		calls, err = p.InlineTree()
+		assertNoError(err, t, "InlineTree()")
+		if len(calls) != 1 || !calls[0].Entered {
+			t.Fatalf("wrong inline tree after step %#v", calls)
+		}
+		frames, err = proc.ThreadStacktrace(p.CurrentThread(), 10)
+		assertNoError(err, t, "ThreadStacktrace")
+		if !frames[0].Inlined || frames[0].Call.Fn.Name != "main.incr" {
+			t.Fatalf("wrong topmost frame %s %v", frames[0].Call.Fn.Name, frames[0].Inlined)
+		}
+
+		if err := p.StepInline(""); err == nil {
+			t.Fatalf("StepInline should fail when all inlined calls were entered")
+		}
+	})
+}
+
+func TestNextOverInlinedCall(t *testing.T) {
+	// Without StopAtInlinedCalls next steps over inlined calls.
+	if ver, _ := goversion.Parse(runtime.Version()); ver.Major >= 0 && !ver.AfterOrEqual(goversion.GoVersion{Major: 1, Minor: 10, Rev: -1}) {
+		// Versions of go before 1.10 do not have DWARF information for inlined calls
+		t.Skip("inlining not supported")
+	}
+	withTestProcessArgs("inlinestepinto", t, ".", []string{}, protest.EnableInlining, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 13)
+		assertNoError(p.Continue(), t, "Continue()")
+		assertNoError(p.Next(), t, "Next()")
+		if calls, _ := p.InlineTree(); len(calls) != 0 {
+			t.Fatalf("next stopped at the beginning of an inlined call %#v", calls)
+		}
+		loc, err := proc.LogicalLocation(p.CurrentThread())
+		assertNoError(err, t, "LogicalLocation")
+		if loc.Line == 8 {
+			t.Fatalf("next stepped into the inlined call")
+		}
+	})
+}
+
+func TestGCStatus(t *testing.T) {
+	withTestProcess("gcstatus", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+		status, err := p.GCStatus()
+		assertNoError(err, t, "GCStatus()")
+		if status.Phase != proc.GCOff || status.StopTheWorld {
+			t.Fatalf("unexpected GC status at main.main: %#v", status)
+		}
+		if status2, _ := p.GCStatus(); status2 != status {
+			t.Fatalf("GC status computed twice during the same stop")
+		}
+
+		// gcDrain is called during the mark phase, either by a background mark
+		// worker or by a goroutine doing a mark assist. The status cached at
+		// main.main must not be returned after the target is resumed.
+		setFunctionBreakpoint(p, t, "runtime.gcDrain")
+		assertNoError(p.Continue(), t, "Continue()")
+		status, err = p.GCStatus()
+		assertNoError(err, t, "GCStatus()")
+		t.Logf("%#v", status)
+		if status.Phase != proc.GCMark {
+			t.Fatalf("wrong GC phase %v", status.Phase)
+		}
+		g := p.SelectedGoroutine()
+		if startfn := g.StartLoc().Fn; startfn != nil && startfn.Name == "runtime.gcBgMarkWorker" {
+			found := false
+			for _, id := range status.Workers {
+				if id == g.ID {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("goroutine %d not listed as a GC worker", g.ID)
+			}
+		}
+	})
+}
+
+func TestTimers(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("timers", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		timers, now, err := p.Timers()
+		assertNoError(err, t, "Timers()")
+		t.Logf("now %d", now)
+		var foundTicker, foundAfterFunc bool
+		for _, timer := range timers {
+			t.Logf("%#v", timer)
+			if timer.Period == int64(30*time.Second) {
+				foundTicker = true
+			}
+			if timer.Callback == "main.wakeup" {
+				foundAfterFunc = true
+				if timer.Period != 0 {
+					t.Errorf("wrong period for AfterFunc timer: %d", timer.Period)
+				}
+			}
+		}
+		if !foundTicker {
+			t.Errorf("ticker not found")
+		}
+		if !foundAfterFunc {
+			t.Errorf("AfterFunc timer not found")
+		}
+		for i := 1; i < len(timers); i++ {
+			if timers[i-1].When > timers[i].When {
+				t.Errorf("timers not sorted")
+			}
+		}
+	})
+}
+
+func TestChannelWaiters(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("chanwaiters", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		checkWaiters := func(expr string, nrecv, nsend int, fnname string) {
+			t.Helper()
+			ch, err := scope.EvalVariable(expr, normalLoadConfig)
+			assertNoError(err, t, "EvalVariable("+expr+")")
+			waiters, err := proc.ChannelWaiters(ch)
+			assertNoError(err, t, "ChannelWaiters("+expr+")")
+			if len(waiters.Recv) != nrecv || len(waiters.Send) != nsend {
+				t.Fatalf("%s: wrong number of waiters, recv %d send %d", expr, len(waiters.Recv), len(waiters.Send))
+			}
+			for _, g := range append(waiters.Recv, waiters.Send...) {
+				if startfn := g.StartLoc().Fn; startfn == nil || startfn.Name != fnname {
+					t.Errorf("%s: goroutine %d started at %v", expr, g.ID, g.StartLoc())
+				}
+			}
+		}
+
+		checkWaiters("recvch", 3, 0, "main.receiver")
+		checkWaiters("sendch", 0, 2, "main.sender")
+
+		_, err = proc.ChannelWaiters(evalVariable(p, t, "main.main"))
+		if err == nil {
+			t.Fatalf("ChannelWaiters on a function did not return an error")
+		}
+	})
+}
+
+func TestMutexInfo(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("mutexwaiters", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		checkWaiters := func(name string, gs []*proc.G, n int, fnname string) {
+			t.Helper()
+			if len(gs) != n {
+				t.Fatalf("%s: wrong number of waiters %d (expected %d)", name, len(gs), n)
+			}
+			for _, g := range gs {
+				if startfn := g.StartLoc().Fn; startfn == nil || startfn.Name != fnname {
+					t.Errorf("%s: goroutine %d started at %v", name, g.ID, g.StartLoc())
+				}
+			}
+		}
+
+		state, err := proc.MutexInfo(evalVariable(p, t, "main.mu"))
+		assertNoError(err, t, "MutexInfo(main.mu)")
+		t.Logf("%#v", state)
+		if !state.Locked || state.RWMutex {
+			t.Fatalf("wrong state for main.mu: %#v", state)
+		}
+		checkWaiters("main.mu", state.Waiters, 2, "main.locker")
+
+		state, err = proc.MutexInfo(evalVariable(p, t, "&main.rwmu"))
+		assertNoError(err, t, "MutexInfo(&main.rwmu)")
+		t.Logf("%#v", state)
+		if !state.Locked || !state.RWMutex || state.Readers != 0 {
+			t.Fatalf("wrong state for main.rwmu: %#v", state)
+		}
+		checkWaiters("main.rwmu readers", state.ReaderWaiters, 3, "main.reader")
+
+		_, err = proc.MutexInfo(evalVariable(p, t, "main.main"))
+		if err == nil {
+			t.Fatalf("MutexInfo on a function did not return an error")
+		}
+	})
+}
+
+func TestDump(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("recordings can not be dumped")
+	}
+
+	testDump := func(t *testing.T, p *proc.Target, fixture protest.Fixture, flags proc.DumpFlags) {
+		t.Helper()
+		tempDir, err := ioutil.TempDir("", "")
+		assertNoError(err, t, "TempDir()")
+		defer os.RemoveAll(tempDir)
+		corePath := filepath.Join(tempDir, "test.core")
+		fh, err := os.Create(corePath)
+		assertNoError(err, t, "Create()")
+		err = p.Dump(fh, flags)
+		if err == proc.ErrMemoryMapNotSupported {
+			t.Skip("backend does not support MemoryMap")
+		}
+		if err == proc.ErrMinidumpNotSupported {
+			t.Skip("backend does not support minidumps")
+		}
+		assertNoError(err, t, "Dump()")
+
+		c, err := core.OpenCore(corePath, fixture.Path, []string{})
+		assertNoError(err, t, "OpenCore()")
+		defer c.Detach(false)
+
+		if len(c.ThreadList()) != len(p.ThreadList()) {
+			t.Errorf("wrong number of threads %d (expected %d)", len(c.ThreadList()), len(p.ThreadList()))
+		}
+		if c.CurrentThread().ThreadID() != p.CurrentThread().ThreadID() {
+			t.Errorf("wrong current thread %d (expected %d)", c.CurrentThread().ThreadID(), p.CurrentThread().ThreadID())
+		}
+
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo(p)")
+		cgs, _, err := proc.GoroutinesInfo(c, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo(c)")
+		if len(gs) != len(cgs) {
+			t.Errorf("wrong number of goroutines %d (expected %d)", len(cgs), len(gs))
+		}
+
+		for _, expr := range []string{"i1", "s1", "m1", "c1", "str1", "*p2"} {
+			v := evalVariable(p, t, expr)
+			cv := evalVariable(c, t, expr)
+			if vs, cvs := fmt.Sprintf("%v %v", v.Value, v.Children), fmt.Sprintf("%v %v", cv.Value, cv.Children); vs != cvs {
+				t.Errorf("%s: value mismatch\n\tprocess: %s\n\tcore: %s", expr, vs, cvs)
+			}
+		}
+	}
+
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		t.Run("full", func(t *testing.T) { testDump(t, p, fixture, 0) })
+		t.Run("selective", func(t *testing.T) { testDump(t, p, fixture, proc.DumpSelective) })
+		t.Run("minidump", func(t *testing.T) { testDump(t, p, fixture, proc.DumpMinidump) })
+		t.Run("minidump-selective", func(t *testing.T) { testDump(t, p, fixture, proc.DumpMinidump|proc.DumpSelective) })
+		t.Run("compressed", func(t *testing.T) { testDump(t, p, fixture, proc.DumpCompress) })
+		t.Run("sparse", func(t *testing.T) { testDump(t, p, fixture, proc.DumpSparse) })
+	})
+}
+
+func TestDumpMinidumpWindows(t *testing.T) {
+	skipUnlessOn(t, "windows only", "windows")
+	if testBackend != "native" {
+		t.Skip("minidumps are only written by the native backend")
+	}
+
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		tempDir, err := ioutil.TempDir("", "")
+		assertNoError(err, t, "TempDir()")
+		defer os.RemoveAll(tempDir)
+		corePath := filepath.Join(tempDir, "test.dmp")
+		fh, err := os.Create(corePath)
+		assertNoError(err, t, "Create()")
+		assertNoError(p.Dump(fh, proc.DumpMinidump), t, "Dump()")
+
+		c, err := core.OpenCore(corePath, fixture.Path, []string{})
+		assertNoError(err, t, "OpenCore()")
+		defer c.Detach(false)
+
+		threads := map[int]bool{}
+		for _, th := range p.ThreadList() {
+			threads[th.ThreadID()] = true
+		}
+		if len(c.ThreadList()) != len(threads) {
+			t.Errorf("wrong number of threads %d (expected %d)", len(c.ThreadList()), len(threads))
+		}
+		for _, th := range c.ThreadList() {
+			if !threads[th.ThreadID()] {
+				t.Errorf("unexpected thread %d in minidump", th.ThreadID())
+			}
+		}
+
+		v := evalVariable(c, t, "i1")
+		if n, _ := constant.Int64Val(v.Value); n != 1 {
+			t.Errorf("wrong value for i1 in minidump: %d (expected 1)", n)
+		}
+	})
+}
+
+func TestRawCall(t *testing.T) {
+	protest.MustHaveCgo(t)
+	skipOn(t, "not implemented", "386")
+	if testBackend == "rr" {
+		t.Skip("can not make raw calls on a recording")
+	}
+	withTestProcess("rawcall", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		pcBefore := currentPC(p, t)
+
+		fn := p.BinInfo().LookupFunc["rawcalladd"]
+		if fn == nil {
+			t.Fatal("could not find rawcalladd")
+		}
+		r, err := p.RawCall(fn.Entry, []uint64{1, 2, 3, 4, 5, 6, 7, 8})
+		assertNoError(err, t, "RawCall()")
+		if r.R1 != 204 {
+			t.Errorf("wrong return value %d (expected 204)", r.R1)
+		}
+		if pc := currentPC(p, t); pc != pcBefore {
+			t.Errorf("PC changed after RawCall: %#x (expected %#x)", pc, pcBefore)
+		}
+
+		if runtime.GOOS == "linux" {
+			num := uint64(39) // SYS_GETPID
+			if runtime.GOARCH == "arm64" {
+				num = 172
+			}
+			r, err := p.RawSyscall(num, nil)
+			assertNoError(err, t, "RawSyscall(getpid)")
+			if int(r.R1) != p.Pid() || r.Errno != 0 {
+				t.Errorf("wrong result for getpid: %#v (expected pid %d)", r, p.Pid())
+			}
+			r, err = p.RawSyscall(^uint64(0), nil)
+			assertNoError(err, t, "RawSyscall(-1)")
+			if r.Errno != 38 { // ENOSYS
+				t.Errorf("wrong errno for invalid system call: %#v", r)
+			}
+		}
+	})
+}
+
+func TestFunctionPatch(t *testing.T) {
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "windows")
+	if testBackend == "rr" {
+		t.Skip("can not patch functions of a recording")
+	}
+	withTestProcess("patchfunction", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		answer := p.BinInfo().LookupFunc["main.answer"]
+		greeting := p.BinInfo().LookupFunc["main.greeting"]
+		orig := make([]byte, 16)
+		_, err := p.Memory().ReadMemory(orig, answer.Entry)
+		assertNoError(err, t, "ReadMemory()")
+
+		_, err = p.PatchFunctionReturn(answer, []string{"1", "2"})
+		if err == nil {
+			t.Fatal("patch with the wrong number of results succeeded")
+		}
+		patch1, err := p.PatchFunctionReturn(answer, []string{"42"})
+		assertNoError(err, t, "PatchFunctionReturn(main.answer)")
+		_, err = p.PatchFunctionReturn(greeting, []string{`"patched"`, "true"})
+		assertNoError(err, t, "PatchFunctionReturn(main.greeting)")
+		if _, err := p.PatchFunctionReturn(answer, []string{"43"}); err == nil {
+			t.Fatal("second patch of main.answer succeeded")
+		}
+		if n := len(p.FunctionPatches()); n != 2 {
+			t.Fatalf("wrong number of patches %d (expected 2)", n)
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if a := evalVariable(p, t, "a"); constant.Compare(a.Value, token.NEQ, constant.MakeInt64(42)) {
+			t.Errorf("wrong value of a: %v (expected 42)", a.Value)
+		}
+		if s := evalVariable(p, t, "s"); constant.StringVal(s.Value) != "patched" {
+			t.Errorf("wrong value of s: %v (expected \"patched\")", s.Value)
+		}
+		if ok := evalVariable(p, t, "ok"); !constant.BoolVal(ok.Value) {
+			t.Errorf("wrong value of ok: %v (expected true)", ok.Value)
+		}
+
+		assertNoError(p.UnpatchFunction(patch1.ID), t, "UnpatchFunction()")
+		after := make([]byte, len(orig))
+		_, err = p.Memory().ReadMemory(after, answer.Entry)
+		assertNoError(err, t, "ReadMemory()")
+		if !bytes.Equal(orig, after) {
+			t.Errorf("code of main.answer not restored: %x (expected %x)", after, orig)
+		}
+		if n := len(p.FunctionPatches()); n != 1 {
+			t.Errorf("wrong number of patches %d (expected 1)", n)
+		}
+	})
+}
+
+func TestAdvanceToSafePoints(t *testing.T) {
+	withTestProcess("loopprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.loop")
+		assertNoError(p.Continue(), t, "Continue")
+		_, err := p.ClearBreakpoint(p.CurrentThread().Breakpoint().Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+		resumeChan := make(chan struct{}, 1)
+		go func() {
+			<-resumeChan
+			time.Sleep(100 * time.Millisecond)
+			p.RequestManualStop()
+		}()
+		p.ResumeNotify(resumeChan)
+		assertNoError(p.Continue(), t, "Continue")
+		if p.StopReason != proc.StopManual {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+
+		assertNoError(p.AdvanceToSafePoints(), t, "AdvanceToSafePoints")
+		pcs := make(map[int]uint64)
+		for _, thread := range p.ThreadList() {
+			loc, err := thread.Location()
+			assertNoError(err, t, "Location")
+			pcs[thread.ThreadID()] = loc.PC
+			if loc.Fn != nil && loc.Fn.Name == "main.loop" && loc.PC < loc.Fn.PrologueEndPC() {
+				t.Errorf("thread %d stopped in the prologue of main.loop at %#x", thread.ThreadID(), loc.PC)
+			}
+		}
+
+		// Threads already at a safe point should not move.
+		assertNoError(p.AdvanceToSafePoints(), t, "AdvanceToSafePoints")
+		for _, thread := range p.ThreadList() {
+			loc, err := thread.Location()
+			assertNoError(err, t, "Location")
+			if loc.PC != pcs[thread.ThreadID()] {
+				t.Errorf("thread %d moved from %#x to %#x", thread.ThreadID(), pcs[thread.ThreadID()], loc.PC)
+			}
+		}
+	})
+}
+
+func TestMemoryGuard(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("memory guards are only supported by the native backend on linux")
+	}
+	skipOn(t, "not implemented", "386")
+	withTestProcess("memguard", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		buf := evalVariable(p, t, "buf")
+		guard, err := p.GuardMemory(buf.Base, 16, proc.ProtRead)
+		assertNoError(err, t, "GuardMemory()")
+		if guard.Addr != buf.Base || guard.Size != uint64(os.Getpagesize()) {
+			t.Errorf("wrong guard range %#x-%#x (expected %#x-%#x)", guard.Addr, guard.Addr+guard.Size, buf.Base, buf.Base+uint64(os.Getpagesize()))
+		}
+		if _, err := p.GuardMemory(buf.Base+8, 1, proc.ProtNone); err == nil {
+			t.Error("overlapping guard was set")
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if p.StopReason != proc.StopGuardFault {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		if p.GuardFault.Addr != buf.Base+10 || p.GuardFault.Guard != guard {
+			t.Errorf("wrong guard fault %#v", p.GuardFault)
+		}
+		if loc, _ := p.CurrentThread().Location(); loc == nil || loc.Fn == nil || loc.Fn.Name != "main.scribble" {
+			t.Errorf("wrong location for guard fault %v", loc)
+		}
+
+		assertNoError(p.UnguardMemory(guard.ID), t, "UnguardMemory()")
+		if len(p.MemoryGuards()) != 0 {
+			t.Errorf("memory guards not removed: %v", p.MemoryGuards())
+		}
+		err = p.Continue()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			t.Fatalf("expected process to exit after removing the guard: %v", err)
+		}
+	})
+}
+
+func TestNextQueueBreakpoints(t *testing.T) {
+	skipOn(t, "broken", "freebsd")
+	protest.AllowRecording(t)
+	withTestProcess("parallel_next", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.sayhi")
+		assertNoError(p.Continue(), t, "Continue()")
+		p.QueueBreakpointsDuringNext = true
+		g := p.SelectedGoroutine()
+		for i := 0; i < 3; i++ {
+			assertNoError(p.Next(), t, "Next()")
+			if p.StopReason != proc.StopNextFinished {
+				t.Fatalf("next interrupted: %v", p.StopReason)
+			}
+			if p.SelectedGoroutine().ID != g.ID {
+				t.Fatalf("wrong goroutine after next %d (expected %d)", p.SelectedGoroutine().ID, g.ID)
+			}
+			for _, qbp := range p.QueuedBreakpoints {
+				t.Logf("queued: goroutine %d at %s:%d", qbp.GoroutineID, qbp.Location.File, qbp.Location.Line)
+				if qbp.GoroutineID == g.ID {
+					t.Errorf("breakpoint hit by the goroutine of the next operation was queued")
+				}
+				if qbp.Location.Fn == nil || qbp.Location.Fn.Name != "main.sayhi" {
+					t.Errorf("wrong location for queued breakpoint %v", qbp.Location)
+				}
+			}
+		}
+	})
+}
+
+func TestGoroutineCgoCall(t *testing.T) {
+	protest.MustHaveCgo(t)
+	skipOn(t, "C sleep not available", "windows")
+	withTestProcess("cgoblocked", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo()")
+		n := 0
+		for _, g := range gs {
+			switch g.CgoCall() {
+			case "":
+			case "blockinc":
+				n++
+			default:
+				t.Errorf("goroutine %d: wrong cgo call %q", g.ID, g.CgoCall())
+			}
+		}
+		if n != 4 {
+			t.Errorf("wrong number of goroutines in cgo calls %d (expected 4)", n)
+		}
+	})
+}
+
+func TestLoadArrayRange(t *testing.T) {
+	withTestProcess("largeslice", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		for _, tc := range []struct {
+			name       string
+			start, end int64
+			first      int64
+			n          int
+		}{
+			{"s", 500, 600, 500, 100},
+			{"s", 990, 1010, 990, 10},
+			{"s", 1000, 1000, 0, 0},
+			{"a", 190, 300, 380, 10},
+		} {
+			v := evalVariable(p, t, tc.name)
+			r, err := scope.LoadArrayRange(v.Addr, v.TypeString(), tc.start, tc.end, normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("LoadArrayRange(%s, %d, %d)", tc.name, tc.start, tc.end))
+			if r.Len != int64(tc.n) || len(r.Children) != tc.n {
+				t.Errorf("%s[%d:%d]: wrong number of elements: len %d children %d (expected %d)", tc.name, tc.start, tc.end, r.Len, len(r.Children), tc.n)
+				continue
+			}
+			if tc.n > 0 {
+				if n, _ := constant.Int64Val(r.Children[0].Value); n != tc.first {
+					t.Errorf("%s[%d:%d]: wrong first element %d (expected %d)", tc.name, tc.start, tc.end, n, tc.first)
+				}
+			}
+		}
+
+		v := evalVariable(p, t, "s")
+		_, err = scope.LoadArrayRange(v.Addr, v.TypeString(), 1001, 1002, normalLoadConfig)
+		if err == nil {
+			t.Errorf("no error for out of bounds range")
+		}
+	})
+}
+
+func TestFilterMap(t *testing.T) {
+	withTestProcess("bigmap", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		v, err := scope.FilterMap("m1", proc.MapFilter{KeyRegexp: "^key01", Sorted: true}, normalLoadConfig)
+		assertNoError(err, t, "FilterMap(m1)")
+		if v.Len != 1000 || len(v.Children) != 20 {
+			t.Fatalf("m1: wrong length %d or number of children %d", v.Len, len(v.Children))
+		}
+		for i := 0; i < 10; i++ {
+			key := constant.StringVal(v.Children[2*i].Value)
+			val, _ := constant.Int64Val(v.Children[2*i+1].Value)
+			if key != fmt.Sprintf("key%03d", 10+i) || val != int64(10+i) {
+				t.Errorf("m1: wrong entry %d: %s: %d", i, key, val)
+			}
+		}
+
+		cfg := normalLoadConfig
+		cfg.MaxArrayValues = 5
+		v, err = scope.FilterMap("m2", proc.MapFilter{KeyRegexp: "^5", Sorted: true}, cfg)
+		assertNoError(err, t, "FilterMap(m2)")
+		if len(v.Children) != 10 {
+			t.Fatalf("m2: wrong number of children %d", len(v.Children))
+		}
+		for i, tgt := range []int64{5, 50, 51, 52, 53} {
+			key, _ := constant.Int64Val(v.Children[2*i].Value)
+			val := constant.StringVal(v.Children[2*i+1].Value)
+			if key != tgt || val != fmt.Sprintf("value%d", tgt) {
+				t.Errorf("m2: wrong entry %d: %d: %s", i, key, val)
+			}
+		}
+
+		_, err = scope.FilterMap("m2", proc.MapFilter{KeyRegexp: "("}, normalLoadConfig)
+		if err == nil {
+			t.Errorf("no error for invalid regexp")
+		}
+	})
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("wellknowntypes", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"t1", "2021-03-04T05:06:07.0000008Z"},
+			{"t2", "2021-03-04T05:06:07+01:00"},
+			{"*n", "-123456789012345678901234567890"},
+			{"ip4", "192.168.0.1"},
+			{"ip6", "2001:db8::1"},
+			{"ipz", "invalid IP"},
+		} {
+			v := evalVariable(p, t, tc.expr)
+			if v.WellKnownValue != tc.tgt {
+				t.Errorf("%s: got %q expected %q", tc.expr, v.WellKnownValue, tc.tgt)
+			}
+		}
+
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+		cfg := normalLoadConfig
+		cfg.DisableWellKnownTypes = true
+		v, err := scope.EvalVariable("t1", cfg)
+		assertNoError(err, t, "EvalVariable(t1)")
+		if v.WellKnownValue != "" {
+			t.Errorf("well known value with DisableWellKnownTypes: %q", v.WellKnownValue)
+		}
+	})
+}
+
+func TestBytesView(t *testing.T) {
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+		cfg := normalLoadConfig
+		cfg.BytesView = proc.BytesViewHex | proc.BytesViewString
+		v, err := scope.EvalVariable("byteslice", cfg)
+		assertNoError(err, t, "EvalVariable(byteslice)")
+		if v.Bytes == nil {
+			t.Fatal("bytes view not loaded")
+		}
+		if len(v.Children) != 0 {
+			t.Errorf("children loaded with bytes view: %d", len(v.Children))
+		}
+		buf := []byte{116, 195, 168, 115, 116}
+		if v.Bytes.String != "tèst" || v.Bytes.Hex != hex.Dump(buf) || v.Bytes.Encoding != "utf-8" || v.Bytes.Count != 5 {
+			t.Errorf("wrong bytes view %#v", v.Bytes)
+		}
+	})
+}
+
+func TestSearchVariable(t *testing.T) {
+	withTestProcess("searchvar", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+		cfg := proc.LoadConfig{true, 10, 64, 64, -1, 0, false, 0}
+
+		for _, tc := range []struct {
+			value string
+			paths []string
+		}{
+			{"abc123", []string{`req.Header["X-Request-Id"][0]`, `req.Body.(*main.Payload).IDs[1]`}},
+			{"3", []string{"req.Retries"}},
+			{"notfound", nil},
+		} {
+			// req.Parent points back to req, the search must terminate
+			paths, err := scope.SearchVariable("req", tc.value, cfg)
+			assertNoError(err, t, fmt.Sprintf("SearchVariable(req, %q)", tc.value))
+			if !reflect.DeepEqual(paths, tc.paths) {
+				t.Errorf("SearchVariable(req, %q): got %q expected %q", tc.value, paths, tc.paths)
+			}
+		}
+
+		_, err = scope.SearchVariable("req.Retries", "3", cfg)
+		assertNoError(err, t, "SearchVariable(req.Retries)")
+	})
+}
+
+func TestObjectGraph(t *testing.T) {
+	withTestProcess("objgraph", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+		g, err := scope.ObjectGraph([]string{"cache"}, proc.LoadConfig{true, 10, 64, 64, -1, 0, false, 0})
+		assertNoError(err, t, "ObjectGraph(cache)")
+
+		edges := make(map[string]bool)
+		for _, e := range g.Edges {
+			from, to := g.Nodes[e.From], g.Nodes[e.To]
+			name := func(n proc.ObjectGraphNode) string {
+				if n.Root != "" {
+					return n.Root
+				}
+				return n.Type
+			}
+			edges[fmt.Sprintf("%s %s -> %s", name(from), e.Label, name(to))] = true
+		}
+		t.Logf("%v", edges)
+		for _, tgt := range []string{
+			"cache  -> main.Cache",
+			"main.Cache .Items -> map[string]*main.Node",
+			`map[string]*main.Node ["a"] -> main.Node`,
+			"main.Cache .Order -> [2]*main.Node",
+			"[2]*main.Node [0] -> main.Node",
+			"[2]*main.Node [1] -> main.Node",
+			"main.Node .Next -> main.Node",
+		} {
+			if !edges[tgt] {
+				t.Errorf("missing edge %q", tgt)
+			}
+		}
+
+		// a and b point to each other, there must be exactly one node for each
+		nodes := 0
+		for _, n := range g.Nodes {
+			if n.Type == "main.Node" {
+				nodes++
+			}
+		}
+		if nodes != 2 {
+			t.Errorf("expected 2 main.Node nodes, got %d", nodes)
+		}
+	})
+}
+
+func TestViewAs(t *testing.T) {
+	withTestProcess("viewas", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		checkHeader := func(v *proc.Variable) {
+			t.Helper()
+			if v.Kind != reflect.Struct || len(v.Children) != 3 {
+				t.Fatalf("wrong variable %s %d", v.TypeString(), len(v.Children))
+			}
+			if magic, _ := constant.Uint64Val(v.Children[0].Value); magic != 0xcafebabe {
+				t.Errorf("wrong Magic %#x", magic)
+			}
+			if name := constant.StringVal(v.Children[2].Value); name != "hdr" {
+				t.Errorf("wrong Name %q", name)
+			}
+		}
+
+		v, err := scope.ViewAs("p", "main.Header", normalLoadConfig)
+		assertNoError(err, t, "ViewAs(p, main.Header)")
+		checkHeader(v)
+
+		v, err = scope.ViewAs("addr", "*main.Header", normalLoadConfig)
+		assertNoError(err, t, "ViewAs(addr, *main.Header)")
+		if v.Kind != reflect.Ptr || len(v.Children) != 1 {
+			t.Fatalf("wrong variable %s", v.TypeString())
+		}
+		checkHeader(&v.Children[0])
+
+		v, err = scope.ViewAs("addr", "[1]main.Header", normalLoadConfig)
+		assertNoError(err, t, "ViewAs(addr, [1]main.Header)")
+		if v.Kind != reflect.Array || len(v.Children) != 1 {
+			t.Fatalf("wrong variable %s", v.TypeString())
+		}
+		checkHeader(&v.Children[0])
+
+		_, err = scope.ViewAs("keep.Name", "main.Header", normalLoadConfig)
+		if err == nil {
+			t.Errorf("no error viewing a string as an address")
+		}
+	})
+}
+
+func TestSyncMapAtomicValue(t *testing.T) {
+	withTestProcess("syncmap", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		m, err := scope.EvalVariable("m", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(m)")
+		if m.DecodedKind != reflect.Map || m.Len != 2 || len(m.Children) != 4 {
+			t.Fatalf("sync.Map not decoded: %v %d %d", m.DecodedKind, m.Len, len(m.Children))
+		}
+		entries := make(map[string]int64)
+		for i := 0; i < len(m.Children); i += 2 {
+			key, val := &m.Children[i], &m.Children[i+1]
+			if key.Kind != reflect.Interface || val.Kind != reflect.Interface {
+				t.Fatalf("wrong entry kinds %v %v", key.Kind, val.Kind)
+			}
+			n, _ := constant.Int64Val(val.Children[0].Value)
+			entries[constant.StringVal(key.Children[0].Value)] = n
+		}
+		if !reflect.DeepEqual(entries, map[string]int64{"a": 1, "c": 3}) {
+			t.Errorf("wrong entries %v", entries)
+		}
+
+		emptym, err := scope.EvalVariable("emptym", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(emptym)")
+		if emptym.DecodedKind != reflect.Map || emptym.Len != 0 {
+			t.Errorf("empty sync.Map not decoded: %v %d", emptym.DecodedKind, emptym.Len)
+		}
+
+		v, err := scope.EvalVariable("v", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(v)")
+		if v.DecodedKind != reflect.Interface || len(v.Children) != 1 {
+			t.Fatalf("atomic.Value not decoded: %v %d", v.DecodedKind, len(v.Children))
+		}
+		if typ := v.Children[0].TypeString(); typ != "*main.Config" {
+			t.Errorf("wrong stored type %s", typ)
+		}
+
+		cfg := normalLoadConfig
+		cfg.DisableWellKnownTypes = true
+		v, err = scope.EvalVariable("v", cfg)
+		assertNoError(err, t, "EvalVariable(v)")
+		if v.DecodedKind != reflect.Invalid {
+			t.Errorf("atomic.Value decoded with DisableWellKnownTypes")
+		}
+	})
+}
+
+func TestContextChain(t *testing.T) {
+	withTestProcess("contextchain", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		v, err := scope.EvalVariable("ctx", proc.LoadConfig{})
+		assertNoError(err, t, "EvalVariable(ctx)")
+		layers, err := proc.ContextChain(v, normalLoadConfig)
+		assertNoError(err, t, "ContextChain(ctx)")
+		for i, layer := range layers {
+			t.Logf("%d %s %#x deadline=%q canceled=%v", i, layer.Type, layer.Addr, layer.Deadline, layer.Canceled)
+		}
+		if len(layers) != 5 {
+			t.Fatalf("wrong number of layers %d", len(layers))
+		}
+
+		checkValue := func(layer proc.ContextLayer, key string) {
+			t.Helper()
+			if layer.Key == nil || layer.Value == nil {
+				t.Fatalf("no key/value for %s", layer.Type)
+			}
+			if got := constant.StringVal(layer.Key.Value); got != key {
+				t.Errorf("wrong key %q", got)
+			}
+		}
+		checkValue(layers[0], "user")
+		if n, _ := constant.Int64Val(layers[0].Value.Children[0].Value); n != 42 {
+			t.Errorf("wrong value %d", n)
+		}
+		if layers[1].Deadline == "" {
+			t.Errorf("no deadline for %s", layers[1].Type)
+		}
+		if !layers[1].Canceled {
+			t.Errorf("timerCtx not canceled")
+		}
+		if !layers[2].Canceled || layers[2].Err == nil {
+			t.Errorf("cancelCtx not canceled")
+		}
+		checkValue(layers[3], "reqid")
+		if layers[4].Key != nil || layers[4].Canceled {
+			t.Errorf("wrong root context %s", layers[4].Type)
+		}
+
+		v, err = scope.EvalVariable("notctx", proc.LoadConfig{})
+		assertNoError(err, t, "EvalVariable(notctx)")
+		if _, err := proc.ContextChain(v, normalLoadConfig); err == nil {
+			t.Errorf("no error for a variable that is not a context")
+		}
+	})
+}
+
+func TestChannelState(t *testing.T) {
+	withTestProcess("chanstate", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		ch := evalVariable(p, t, "ch")
+		if ch.Len != 3 || ch.Cap != 3 || len(ch.Children) != 4 {
+			t.Fatalf("wrong channel %d/%d %d", ch.Len, ch.Cap, len(ch.Children))
+		}
+		buf := ch.Children[0]
+		if buf.Name != "buffer" || len(buf.Children) != 3 {
+			t.Fatalf("wrong buffer %s %d", buf.Name, len(buf.Children))
+		}
+		for i, tgt := range []int64{2, 3, 4} {
+			if n, _ := constant.Int64Val(buf.Children[i].Value); n != tgt {
+				t.Errorf("wrong element %d: %d (expected %d)", i, n, tgt)
+			}
+		}
+		if constant.BoolVal(ch.Children[1].Value) {
+			t.Errorf("ch closed")
+		}
+
+		// The children of the channel can be evaluated by name.
+		if n, _ := constant.Int64Val(evalVariable(p, t, "ch.buffer[0]").Value); n != 2 {
+			t.Errorf("wrong value for ch.buffer[0]: %d", n)
+		}
+		if constant.BoolVal(evalVariable(p, t, "ch.closed").Value) {
+			t.Errorf("wrong value for ch.closed")
+		}
+		if sendq := evalVariable(p, t, "blocked.sendq"); sendq.Len != 2 {
+			t.Errorf("wrong length for blocked.sendq: %d", sendq.Len)
+		}
+
+		closedch := evalVariable(p, t, "closedch")
+		if !constant.BoolVal(closedch.Children[1].Value) {
+			t.Errorf("closedch not closed")
+		}
+		if len(closedch.Children[0].Children) != 1 || constant.StringVal(closedch.Children[0].Children[0].Value) != "a" {
+			t.Errorf("wrong buffer for closedch")
+		}
+
+		blocked := evalVariable(p, t, "blocked")
+		if recvq, sendq := blocked.Children[2], blocked.Children[3]; recvq.Len != 0 || sendq.Len != 2 {
+			t.Errorf("wrong waiters %d %d", recvq.Len, sendq.Len)
+		}
+	})
+}
+
+func TestInterfaceItab(t *testing.T) {
+	withTestProcess("itab", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		itab, err := proc.InterfaceItab(evalVariable(p, t, "sh"))
+		assertNoError(err, t, "InterfaceItab(sh)")
+		if itab.InterfaceType != "main.Shape" || itab.ConcreteType != "*main.Square" || itab.Addr == 0 {
+			t.Errorf("wrong itab %#v", itab)
+		}
+		tgt := map[string]string{"Area": "main.(*Square).Area", "Name": "main.(*Square).Name"}
+		if len(itab.Methods) != len(tgt) {
+			t.Fatalf("wrong number of methods %d", len(itab.Methods))
+		}
+		for _, m := range itab.Methods {
+			if m.Fn == nil || m.Fn.Name != tgt[m.Name] {
+				t.Errorf("wrong method %s %#x %v", m.Name, m.PC, m.Fn)
+			}
+		}
+
+		itab, err = proc.InterfaceItab(evalVariable(p, t, "nilsh"))
+		assertNoError(err, t, "InterfaceItab(nilsh)")
+		if itab.ConcreteType != "" || len(itab.Methods) != 0 {
+			t.Errorf("wrong itab for nil interface %#v", itab)
+		}
+
+		itab, err = proc.InterfaceItab(evalVariable(p, t, "e"))
+		assertNoError(err, t, "InterfaceItab(e)")
+		if itab.ConcreteType != "int" || itab.Addr != 0 {
+			t.Errorf("wrong itab for empty interface %#v", itab)
+		}
+	})
+}
+
+func TestShadowedScopes(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("testshadow", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		locals, err := scope.LocalVariables(normalLoadConfig)
+		assertNoError(err, t, "LocalVariables")
+		var outer, inner *proc.Variable
+		for _, v := range locals {
+			if v.Flags&proc.VariableShadowed != 0 {
+				outer = v
+			} else {
+				inner = v
+			}
+		}
+		if outer == nil || inner == nil {
+			t.Fatalf("shadowed variable not found")
+		}
+		t.Logf("outer: %d %d-%d", outer.DeclLine, outer.ScopeStartLine, outer.ScopeEndLine)
+		t.Logf("inner: %d %d-%d", inner.DeclLine, inner.ScopeStartLine, inner.ScopeEndLine)
+		if outer.DeclLine != 9 || inner.DeclLine != 11 {
+			t.Errorf("wrong declaration lines %d %d", outer.DeclLine, inner.DeclLine)
+		}
+		if inner.ScopeStartLine > 11 || inner.ScopeEndLine < 13 || inner.ScopeEndLine >= 15 {
+			t.Errorf("wrong scope for inner variable %d-%d", inner.ScopeStartLine, inner.ScopeEndLine)
+		}
+		if outer.ScopeStartLine > 9 || outer.ScopeEndLine < 15 {
+			t.Errorf("wrong scope for outer variable %d-%d", outer.ScopeStartLine, outer.ScopeEndLine)
+		}
+	})
+}
+
+func TestFindReferrers(t *testing.T) {
+	withTestProcess("referrers", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		target := evalVariable(p, t, "target")
+		addr := target.Children[0].Addr
+		refs, err := p.FindReferrers(addr)
+		assertNoError(err, t, "FindReferrers")
+		if refs.Addr != addr {
+			t.Errorf("wrong object address %#x (expected %#x)", refs.Addr, addr)
+		}
+
+		head := evalVariable(p, t, "head").Children[0].Addr
+		b := evalVariable(p, t, "b").Children[0].Addr
+		foundHead, foundBig, foundHolder := false, false, false
+		for _, ref := range refs.Referrers {
+			t.Logf("%#v", ref)
+			switch {
+			case ref.Addr == head:
+				foundHead = ref.Offset == 16
+			case ref.Addr <= b && b < ref.Addr+ref.Size:
+				foundBig = true
+				if goversion.VersionAfterOrEqual(runtime.Version(), 1, 22) && (ref.Type != "main.big" || ref.Field != ".ref") {
+					t.Errorf("wrong type or field for big object: %#v", ref)
+				}
+			case ref.Var == "main.holder":
+				foundHolder = ref.Field == ".p"
+			}
+		}
+		if !foundHead || !foundBig || !foundHolder {
+			t.Errorf("missing referrers head:%v big:%v holder:%v", foundHead, foundBig, foundHolder)
+		}
+	})
+}
+
+func TestHeapHistogram(t *testing.T) {
+	withTestProcess("heaphist", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		entries, err := p.HeapHistogram()
+		assertNoError(err, t, "HeapHistogram")
+		if len(entries) == 0 {
+			t.Fatal("empty histogram")
+		}
+		found := false
+		for i, e := range entries {
+			t.Logf("%d %d %s", e.Count, e.Bytes, e.Type)
+			if e.Count == 0 || e.Bytes < e.Count {
+				t.Errorf("wrong entry %#v", e)
+			}
+			if i > 0 && entries[i-1].Bytes < e.Bytes {
+				t.Errorf("histogram not sorted at %d", i)
+			}
+			if e.Type == "main.big" {
+				found = true
+				if e.Count < 10 {
+					t.Errorf("wrong count for main.big: %d", e.Count)
+				}
+			}
+		}
+		if goversion.VersionAfterOrEqual(runtime.Version(), 1, 22) && !found {
+			t.Errorf("main.big not found")
+		}
+	})
+}
+
+func TestGoroutineTree(t *testing.T) {
+	savedGodebug := os.Getenv("GODEBUG")
+	os.Setenv("GODEBUG", "tracebackancestors=100")
+	defer os.Setenv("GODEBUG", savedGodebug)
+	withTestProcess("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.testgoroutine")
+		assertNoError(p.Continue(), t, "Continue()")
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo")
+		nodes, err := proc.GoroutineTree(p, gs)
+		assertNoError(err, t, "GoroutineTree")
+
+		selg := p.SelectedGoroutine()
+		var found, mainFound bool
+		for i, n := range nodes {
+			t.Logf("goroutine %d parent %d", n.ID, n.ParentID)
+			if i > 0 && nodes[i-1].ID >= n.ID {
+				t.Errorf("nodes not sorted at %d", i)
+			}
+			switch n.ID {
+			case selg.ID:
+				found = true
+				if n.ParentID != 1 || n.Creation == nil || n.G == nil {
+					t.Errorf("wrong node for selected goroutine %#v", n)
+				}
+			case 1:
+				mainFound = n.G != nil
+			}
+		}
+		if !found || !mainFound {
+			t.Errorf("missing nodes selected:%v main:%v", found, mainFound)
+		}
+	})
+}
+
+func TestRecorders(t *testing.T) {
+	rec, ok := proc.LookupRecorder("rr")
+	if !ok {
+		t.Fatal("rr recorder not registered")
+	}
+	if caps := rec.Capabilities(); !caps.ReverseExecution || !caps.Checkpoints {
+		t.Errorf("wrong capabilities for rr: %#v", caps)
+	}
+	if _, ok := proc.LookupRecorder("native"); ok {
+		t.Error("native backend registered as a recorder")
+	}
+	found := false
+	for _, rec := range proc.Recorders() {
+		found = found || rec.Name() == "rr"
+	}
+	if !found {
+		t.Error("rr missing from the list of recorders")
+	}
+}
+
+func TestBatchBreakpoints(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("testprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		var bp1, bp2 *proc.Breakpoint
+		err := p.BatchBreakpoints(func() error {
+			bp1 = setFunctionBreakpoint(p, t, "main.helloworld")
+			bp2 = setFunctionBreakpoint(p, t, "main.sleepytime")
+			_, err := p.ClearBreakpoint(bp2.Addr)
+			return err
+		})
+		assertNoError(err, t, "BatchBreakpoints()")
+
+		if len(bp1.OriginalData) == 0 {
+			t.Fatal("breakpoint not written at the end of the batch")
+		}
+		data, err := dataAtAddr(p.Memory(), bp2.Addr)
+		assertNoError(err, t, "dataAtAddr")
+		if bytes.Equal(data, p.BinInfo().Arch.BreakpointInstruction()) {
+			t.Fatal("breakpoint cleared during the batch was written")
+		}
+		if countBreakpoints(p) != 1 {
+			t.Fatalf("wrong number of breakpoints %d", countBreakpoints(p))
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if bp1.TotalHitCount != 1 {
+			t.Fatalf("Breakpoint should be hit once, got %d\n", bp1.TotalHitCount)
+		}
+	})
+}
+
+func TestSignalPolicyNotSupported(t *testing.T) {
+	skipUnlessOn(t, "darwin only", "darwin")
+	if testBackend != "native" {
+		t.Skip("only the native backend ignores signal policies")
+	}
+	withTestProcess("sigusr1", t, func(p *proc.Target, fixture protest.Fixture) {
+		sigusr1, err := proc.SignalNumber("darwin", "usr1")
+		assertNoError(err, t, "SignalNumber")
+		if err := p.SetSignalPolicy(sigusr1, proc.SignalPolicy{Stop: true, Print: true, Pass: true}); err != proc.ErrSignalPolicyNotSupported {
+			t.Fatalf("expected ErrSignalPolicyNotSupported, got %v", err)
+		}
+		if len(p.SignalPolicies()) != 0 {
+			t.Fatalf("unsupported policy was recorded: %v", p.SignalPolicies())
+		}
+	})
+}
@@ -1,7 +1,12 @@
 package proc
 
 import (
+	"debug/elf"
+	"io/ioutil"
+	"os"
 	"testing"
+
+	"github.com/go-delve/delve/pkg/elfwriter"
 )
 
 func TestAlignAddr(t *testing.T) {
@@ -73,3 +78,144 @@ func TestAlignAddr(t *testing.T) {
 		c(example.align, example.in+0x10000, example.tgt+0x10000)
 	}
 }
+
+func TestBytesAsString(t *testing.T) {
+	for _, tc := range []struct {
+		in, out, encoding string
+	}{
+		{"hello\n", `hello\n`, "ascii"},
+		{"t\xc3\xa8st", "tèst", "utf-8"},
+		{"a\xffb\x00\\", `a\xffb\x00\\`, "binary"},
+	} {
+		if out := bytesAsString([]byte(tc.in)); out != tc.out {
+			t.Errorf("%q: got %q expected %q", tc.in, out, tc.out)
+		}
+		if encoding := bytesEncoding([]byte(tc.in)); encoding != tc.encoding {
+			t.Errorf("%q: got encoding %q expected %q", tc.in, encoding, tc.encoding)
+		}
+	}
+}
+
+func TestLoong64Decode(t *testing.T) {
+	for _, tc := range []struct {
+		raw  uint32
+		text string
+	}{
+		{0x4c000020, "RET"},
+		{0x54000800, "CALL 0x1008"},
+		{0x53ffffff, "JMP 0xffc"},
+		{0x4c000181, "JIRL R1, R12, 0"},
+		{0x002a0000, "BREAK 0"},
+		{0x002b0000, "SYSCALL 0"},
+		{0x02c02063, "WORD $0x02c02063"},
+	} {
+		mem := []byte{byte(tc.raw), byte(tc.raw >> 8), byte(tc.raw >> 16), byte(tc.raw >> 24)}
+		inst, err := loong64Decode(mem)
+		if err != nil {
+			t.Fatalf("%#x: %v", tc.raw, err)
+		}
+		if text := (*loong64ArchInst)(&inst).Text(GoFlavour, 0x1000, nil); text != tc.text {
+			t.Errorf("%#x: expected %q got %q", tc.raw, tc.text, text)
+		}
+	}
+}
+
+type sliceMemory struct {
+	addr uint64
+	data []byte
+}
+
+func (mem *sliceMemory) ReadMemory(buf []byte, addr uint64) (int, error) {
+	return copy(buf, mem.data[addr-mem.addr:]), nil
+}
+
+func TestDumpMemorySparse(t *testing.T) {
+	const addr = 0x10000
+	mem := &sliceMemory{addr, make([]byte, 4*dumpPageSize)}
+	mem.data[0] = 1
+	mem.data[3*dumpPageSize] = 1
+
+	for _, tc := range []struct {
+		sparse bool
+		progs  [][3]uint64 // vaddr, filesz, memsz
+	}{
+		{false, [][3]uint64{{addr, 4 * dumpPageSize, 4 * dumpPageSize}}},
+		{true, [][3]uint64{{addr, dumpPageSize, 3 * dumpPageSize}, {addr + 3*dumpPageSize, dumpPageSize, dumpPageSize}}},
+	} {
+		fh, err := ioutil.TempFile("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := elfwriter.New(fh, &elf.FileHeader{Class: elf.ELFCLASS64, Data: elf.ELFDATA2LSB, Type: elf.ET_CORE})
+		dumpMemory(w, mem, dumpRange{addr, uint64(len(mem.data)), elf.PF_R}, tc.sparse)
+		fh.Close()
+		os.Remove(fh.Name())
+		if w.Err != nil {
+			t.Fatal(w.Err)
+		}
+		var progs [][3]uint64
+		for _, prog := range w.Progs {
+			progs = append(progs, [3]uint64{prog.Vaddr, prog.Filesz, prog.Memsz})
+		}
+		if len(progs) != len(tc.progs) {
+			t.Fatalf("sparse=%v: wrong segments %#x (expected %#x)", tc.sparse, progs, tc.progs)
+		}
+		for i := range progs {
+			if progs[i] != tc.progs[i] {
+				t.Errorf("sparse=%v: wrong segments %#x (expected %#x)", tc.sparse, progs, tc.progs)
+				break
+			}
+		}
+	}
+}
+
+type batchMemory struct {
+	sliceMemory
+	batches int
+}
+
+func (mem *batchMemory) ReadMemoryBatch(reqs []MemoryReadRequest) {
+	mem.batches++
+	for i := range reqs {
+		reqs[i].N, reqs[i].Err = mem.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+	}
+}
+
+func (mem *batchMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	return copy(mem.data[addr-mem.addr:], data), nil
+}
+
+func TestReadMemoryBatch(t *testing.T) {
+	const addr = 0x1000
+	mem := &batchMemory{sliceMemory: sliceMemory{addr, make([]byte, 0x100)}}
+	for i := range mem.data {
+		mem.data[i] = byte(i)
+	}
+
+	cache := cacheMemory(mem, addr, 0x10)
+	reqs := []MemoryReadRequest{
+		{Addr: addr + 0x8, Buf: make([]byte, 4)},
+		{Addr: addr + 0x80, Buf: make([]byte, 4)},
+		{Addr: addr + 0xc0, Buf: make([]byte, 4)},
+	}
+	readMemoryBatch(cache, reqs)
+	if mem.batches != 1 {
+		t.Errorf("wrong number of batch reads %d (expected 1)", mem.batches)
+	}
+	for _, req := range reqs {
+		if req.Err != nil || req.N != len(req.Buf) {
+			t.Fatalf("%#x: read failed %d %v", req.Addr, req.N, req.Err)
+		}
+		if req.Buf[0] != byte(req.Addr-addr) {
+			t.Errorf("%#x: wrong data %x", req.Addr, req.Buf)
+		}
+	}
+
+	// Memory readers that do not support batch reads are read one request
+	// at a time.
+	reqs = []MemoryReadRequest{{Addr: addr + 0x20, Buf: make([]byte, 2)}}
+	readMemoryBatch(&mem.sliceMemory, reqs)
+	if reqs[0].N != 2 || reqs[0].Buf[0] != 0x20 {
+		t.Errorf("wrong read %#v", reqs[0])
+	}
+}
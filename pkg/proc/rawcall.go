@@ -0,0 +1,402 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRawCallNotSupported is returned by RawCall and RawSyscall when the
+// backend can not change arbitrary registers of a thread or the
+// architecture and operating system of the target are not supported.
+var ErrRawCallNotSupported = errors.New("raw calls are not supported on this backend, architecture or operating system")
+
+// RawCallResult is the result of a call made with RawCall or RawSyscall.
+type RawCallResult struct {
+	// R1 and R2 are the values of the return registers after the call (RAX
+	// and RDX on amd64, X0 and X1 on arm64).
+	R1, R2 uint64
+	// Errno is the error number returned by a system call, it is zero if
+	// the system call succeeded and for calls made with RawCall.
+	Errno uint64
+}
+
+// registerSetter is implemented by the Registers of backends that can
+// change arbitrary registers of a thread, the modified registers are
+// written back to the thread with RestoreRegisters.
+type registerSetter interface {
+	SetReg(name string, value uint64) error
+}
+
+const (
+	// rawCallStackGap is the number of bytes left untouched below the stack
+	// pointer of the thread used for a raw call, enough to cover the amd64
+	// red zone.
+	rawCallStackGap = 256
+	// maxRawSyscallArgs is the maximum number of arguments of a system call.
+	maxRawSyscallArgs = 6
+)
+
+// rawCallConv describes the calling convention used by RawCall and
+// RawSyscall.
+type rawCallConv struct {
+	// argRegs are the registers used to pass the first arguments.
+	argRegs []string
+	// stackArgsOff is the offset, from the stack pointer at function entry
+	// (after the return address has been pushed), of the first argument
+	// passed on the stack.
+	stackArgsOff uint64
+	// pushRet is true if the return address is pushed on the stack, if it
+	// is false it is stored in linkReg.
+	pushRet bool
+	linkReg string
+	// retRegs are the registers containing the return values.
+	retRegs [2]string
+	// numReg is the register containing the system call number, only used
+	// by RawSyscall.
+	numReg string
+}
+
+// rawCallConvFor returns the C calling convention for the target.
+func rawCallConvFor(bi *BinaryInfo) (*rawCallConv, error) {
+	switch bi.Arch.Name {
+	case "amd64":
+		if bi.GOOS == "windows" {
+			// The callee can use 32 bytes above the return address (the shadow
+			// space) to spill the arguments passed in registers.
+			return &rawCallConv{argRegs: []string{"Rcx", "Rdx", "R8", "R9"}, stackArgsOff: 8 + 32, pushRet: true, retRegs: [2]string{"Rax", "Rdx"}}, nil
+		}
+		return &rawCallConv{argRegs: []string{"Rdi", "Rsi", "Rdx", "Rcx", "R8", "R9"}, stackArgsOff: 8, pushRet: true, retRegs: [2]string{"Rax", "Rdx"}}, nil
+	case "arm64":
+		return &rawCallConv{argRegs: []string{"X0", "X1", "X2", "X3", "X4", "X5", "X6", "X7"}, linkReg: "X30", retRegs: [2]string{"X0", "X1"}}, nil
+	case "loong64":
+		return &rawCallConv{argRegs: []string{"R4", "R5", "R6", "R7", "R8", "R9", "R10", "R11"}, linkReg: "R1", retRegs: [2]string{"R4", "R5"}}, nil
+	default:
+		return nil, ErrRawCallNotSupported
+	}
+}
+
+// rawSyscallConvFor returns the system call convention for the target
+// and the instruction used to make a system call.
+func rawSyscallConvFor(bi *BinaryInfo) (*rawCallConv, []byte, error) {
+	switch {
+	case bi.Arch.Name == "amd64" && (bi.GOOS == "linux" || bi.GOOS == "freebsd" || bi.GOOS == "darwin"):
+		return &rawCallConv{argRegs: []string{"Rdi", "Rsi", "Rdx", "R10", "R8", "R9"}, retRegs: [2]string{"Rax", "Rdx"}, numReg: "Rax"}, []byte{0x0f, 0x05}, nil // SYSCALL
+	case bi.Arch.Name == "arm64" && bi.GOOS == "linux":
+		return &rawCallConv{argRegs: []string{"X0", "X1", "X2", "X3", "X4", "X5"}, retRegs: [2]string{"X0", "X1"}, numReg: "X8"}, []byte{0x01, 0x00, 0x00, 0xd4}, nil // SVC #0
+	case bi.Arch.Name == "arm64" && bi.GOOS == "darwin":
+		return &rawCallConv{argRegs: []string{"X0", "X1", "X2", "X3", "X4", "X5"}, retRegs: [2]string{"X0", "X1"}, numReg: "X16"}, []byte{0x01, 0x10, 0x00, 0xd4}, nil // SVC #0x80
+	case bi.Arch.Name == "loong64" && bi.GOOS == "linux":
+		return &rawCallConv{argRegs: []string{"R4", "R5", "R6", "R7", "R8", "R9"}, retRegs: [2]string{"R4", "R5"}, numReg: "R11"}, []byte{0x00, 0x00, 0x2b, 0x00}, nil // SYSCALL 0
+	default:
+		return nil, nil, ErrRawCallNotSupported
+	}
+}
+
+// RawCall calls the function at addr on the current thread and returns
+// the contents of the return registers after it returns.
+// The arguments are passed, without any type checking, in the registers and
+// stack slots prescribed by the C calling convention of the target
+// platform, which is not the calling convention used by Go functions.
+//
+// This is unsafe: the called function runs on the stack of the current
+// thread, below its stack pointer, all other threads are resumed while
+// it runs and breakpoints hit during the call are ignored. If the call
+// is interrupted by a manual stop or if the target receives a signal the
+// registers of the thread are restored, abandoning the call in whatever
+// state it was.
+// Use EvalExpressionWithCalls to call Go functions.
+func (t *Target) RawCall(addr uint64, args []uint64) (*RawCallResult, error) {
+	cc, err := rawCallConvFor(t.BinInfo())
+	if err != nil {
+		return nil, err
+	}
+	entryPoint, err := t.EntryPoint()
+	if err != nil {
+		return nil, err
+	}
+	// Nothing jumps back to the entry point of the executable once the
+	// program is running, we use it as the return address of the call.
+	regs, err := t.rawCall(cc, addr, entryPoint, 0, args)
+	if err != nil {
+		return nil, err
+	}
+	r := &RawCallResult{}
+	r.R1, _ = registerByName(regs, cc.retRegs[0])
+	r.R2, _ = registerByName(regs, cc.retRegs[1])
+	return r, nil
+}
+
+// RawSyscall executes system call number num with args on the current
+// thread, see RawCall for the dangers of doing this.
+// System calls are not supported on windows.
+func (t *Target) RawSyscall(num uint64, args []uint64) (*RawCallResult, error) {
+	bi := t.BinInfo()
+	cc, instr, err := rawSyscallConvFor(bi)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > maxRawSyscallArgs {
+		return nil, fmt.Errorf("too many arguments for a system call (%d, maximum %d)", len(args), maxRawSyscallArgs)
+	}
+	if bi.GOOS == "darwin" && bi.Arch.Name == "amd64" {
+		num |= 0x2000000 // SYSCALL_CLASS_UNIX
+	}
+	entryPoint, err := t.EntryPoint()
+	if err != nil {
+		return nil, err
+	}
+
+	// Temporarily replace the instructions at the entry point of the
+	// executable with a system call instruction, followed by the breakpoint
+	// that stops the thread once the system call returns.
+	bpaddr := entryPoint + uint64(len(instr))
+	for addr := range t.Breakpoints().M {
+		if addr >= entryPoint && addr < bpaddr {
+			return nil, fmt.Errorf("can not make a system call with a breakpoint set at %#x", addr)
+		}
+	}
+	mem := t.CurrentThread().ProcessMemory()
+	orig := make([]byte, len(instr))
+	if _, err := mem.ReadMemory(orig, entryPoint); err != nil {
+		return nil, err
+	}
+	if _, err := mem.WriteMemory(entryPoint, instr); err != nil {
+		return nil, err
+	}
+	defer mem.WriteMemory(entryPoint, orig)
+
+	regs, err := t.rawCall(cc, entryPoint, bpaddr, num, args)
+	if err != nil {
+		return nil, err
+	}
+	r := &RawCallResult{}
+	r.R1, _ = registerByName(regs, cc.retRegs[0])
+	r.R2, _ = registerByName(regs, cc.retRegs[1])
+	switch bi.GOOS {
+	case "linux":
+		if int64(r.R1) < 0 && int64(r.R1) >= -4095 {
+			r.Errno = -r.R1
+		}
+	default:
+		// BSD-derived kernels report errors with the carry flag.
+		var carry bool
+		if bi.Arch.Name == "amd64" {
+			flags, _ := registerByName(regs, "Rflags")
+			carry = flags&(1<<0) != 0
+		} else {
+			cpsr, _ := registerByName(regs, "Cpsr")
+			carry = cpsr&(1<<29) != 0
+		}
+		if carry {
+			r.Errno = r.R1
+		}
+	}
+	return r, nil
+}
+
+// rawCall runs the current thread from pc, with args stored according to
+// cc, until it reaches retaddr, which is also used as the return address
+// of the call if cc pushes one or has a link register.
+// Returns the registers of the thread at that point, after which the
+// registers of the thread are restored to their original value.
+// If cc.numReg is set its register is set to num.
+func (t *Target) rawCall(cc *rawCallConv, pc, retaddr, num uint64, args []uint64) (Registers, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	if ok, _ := t.Recorded(); ok {
+		return nil, errors.New("can not make raw calls on a recording")
+	}
+	if t.Breakpoints().HasInternalBreakpoints() {
+		return nil, errors.New("can not make a raw call while nexting")
+	}
+	if len(t.fncallForG) > 0 {
+		return nil, errors.New("can not make a raw call while a function call is in progress")
+	}
+
+	thread := t.CurrentThread()
+	bi := t.BinInfo()
+	ptrSize := uint64(bi.Arch.PtrSize())
+	regs, err := thread.Registers()
+	if err != nil {
+		return nil, err
+	}
+	savedRegs, err := regs.Copy()
+	if err != nil {
+		return nil, err
+	}
+	callRegs, err := regs.Copy()
+	if err != nil {
+		return nil, err
+	}
+	setter, ok := callRegs.(registerSetter)
+	if !ok {
+		return nil, ErrRawCallNotSupported
+	}
+
+	regArgs, stackArgs := args, []uint64(nil)
+	if len(args) > len(cc.argRegs) {
+		regArgs, stackArgs = args[:len(cc.argRegs)], args[len(cc.argRegs):]
+	}
+	for i, arg := range regArgs {
+		if err := setter.SetReg(cc.argRegs[i], arg); err != nil {
+			return nil, fmt.Errorf("could not set %s: %v", cc.argRegs[i], err)
+		}
+	}
+	if cc.numReg != "" {
+		if err := setter.SetReg(cc.numReg, num); err != nil {
+			return nil, fmt.Errorf("could not set %s: %v", cc.numReg, err)
+		}
+	}
+
+	// Lay out the stack so that it is 16 byte aligned before the return
+	// address is pushed.
+	mem := thread.ProcessMemory()
+	sp := regs.SP() - rawCallStackGap
+	argsOff := cc.stackArgsOff
+	if cc.pushRet {
+		argsOff -= ptrSize
+	}
+	sp = (sp - argsOff - uint64(len(stackArgs))*ptrSize) &^ 0xf
+	for i, arg := range stackArgs {
+		if err := writePointer(bi, mem, sp+argsOff+uint64(i)*ptrSize, arg); err != nil {
+			return nil, err
+		}
+	}
+	if cc.pushRet {
+		sp -= ptrSize
+		if err := writePointer(bi, mem, sp, retaddr); err != nil {
+			return nil, err
+		}
+	} else if cc.linkReg != "" {
+		if err := setter.SetReg(cc.linkReg, retaddr); err != nil {
+			return nil, fmt.Errorf("could not set %s: %v", cc.linkReg, err)
+		}
+	}
+
+	for _, reg := range []struct {
+		amd64, arm64, loong64 string
+		val                   uint64
+	}{{"Rsp", "SP", "R3", sp}, {"Rip", "PC", "PC", pc}} {
+		name := reg.amd64
+		switch bi.Arch.Name {
+		case "arm64":
+			name = reg.arm64
+		case "loong64":
+			name = reg.loong64
+		}
+		if err := setter.SetReg(name, reg.val); err != nil {
+			return nil, fmt.Errorf("could not set %s: %v", name, err)
+		}
+	}
+	// If the thread was stopped inside a system call the linux kernel would
+	// restart it when the thread is resumed, unless orig_rax is -1.
+	setter.SetReg("Orig_rax", ^uint64(0))
+
+	bp, err := t.SetBreakpoint(retaddr, RawCallBreakpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedGoroutine, stopReason := t.selectedGoroutine, t.StopReason
+	restore := func() error {
+		if err := t.clearRawCallBreakpoint(bp); err != nil {
+			return err
+		}
+		if err := thread.RestoreRegisters(savedRegs); err != nil {
+			return err
+		}
+		t.ClearAllGCache()
+		if err := t.SwitchThread(thread.ThreadID()); err != nil {
+			return err
+		}
+		t.selectedGoroutine, t.StopReason = selectedGoroutine, stopReason
+		return nil
+	}
+
+	if err := thread.RestoreRegisters(callRegs); err != nil {
+		restore()
+		return nil, err
+	}
+
+	for {
+		err := t.Continue()
+		if _, exited := err.(ErrProcessExited); exited {
+			return nil, err
+		}
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		curthread := t.CurrentThread()
+		if curthread.ThreadID() == thread.ThreadID() {
+			if loc, _ := curthread.Location(); loc != nil && loc.PC == retaddr {
+				break
+			}
+		}
+		switch {
+		case t.StopReason == StopManual:
+			restore()
+			return nil, errors.New("raw call interrupted by a manual stop")
+		case t.StopReason == StopSignal:
+			restore()
+			return nil, errors.New("raw call interrupted by a signal")
+		case t.Breakpoints().M[retaddr] == nil || t.Breakpoints().M[retaddr].Kind&RawCallBreakpoint == 0:
+			// The breakpoint was deleted by Continue (for example because the
+			// target panicked).
+			restore()
+			return nil, fmt.Errorf("raw call interrupted (stop reason %v)", t.StopReason)
+		}
+		// Breakpoints hit by other threads during the call are ignored.
+	}
+
+	retRegs, err := thread.Registers()
+	if err == nil {
+		retRegs, err = retRegs.Copy()
+	}
+	if rerr := restore(); err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return retRegs, nil
+}
+
+// clearRawCallBreakpoint removes the RawCallBreakpoint kind from bp, if
+// Continue did not already delete it.
+func (t *Target) clearRawCallBreakpoint(bp *Breakpoint) error {
+	if t.Breakpoints().M[bp.Addr] != bp || bp.Kind&RawCallBreakpoint == 0 {
+		return nil
+	}
+	bp.Kind &^= RawCallBreakpoint
+	if bp.Kind != 0 {
+		return nil
+	}
+	if err := t.proc.EraseBreakpoint(bp); err != nil {
+		return err
+	}
+	for _, thread := range t.ThreadList() {
+		if thread.Breakpoint().Breakpoint == bp {
+			thread.Breakpoint().Clear()
+		}
+	}
+	delete(t.Breakpoints().M, bp.Addr)
+	return nil
+}
+
+// registerByName returns the value of the register called name, as
+// returned by Registers.Slice, ignoring case.
+func registerByName(regs Registers, name string) (uint64, error) {
+	regslice, err := regs.Slice(false)
+	if err != nil {
+		return 0, err
+	}
+	for _, reg := range regslice {
+		if strings.EqualFold(reg.Name, name) {
+			return reg.Reg.Uint64Val, nil
+		}
+	}
+	return 0, ErrUnknownRegister
+}
@@ -0,0 +1,78 @@
+package proc
+
+import (
+	"sort"
+	"sync"
+)
+
+// Recorder is a record and replay engine: it records the execution of a
+// program to a trace directory and replays the trace as a Target whose
+// Recorded method returns true.
+// Recorders are registered with RegisterRecorder by the package
+// implementing them and are selected by name, as a backend.
+type Recorder interface {
+	// Name returns the name of the recorder, which is also the name of the
+	// backend using it.
+	Name() string
+	// Available returns an error if the recorder is not installed.
+	Available() error
+	// Capabilities returns the features supported by recordings made with
+	// this recorder.
+	Capabilities() RecorderCapabilities
+	// RecordAsync configures the recorder to record the execution of cmd.
+	// Returns a run function, which records the program and returns the
+	// trace directory, and a stop function, which prematurely terminates
+	// the recording.
+	RecordAsync(cmd []string, wd string, quiet bool, redirects [3]string) (run func() (string, error), stop func() error, err error)
+	// Replay replays the trace in tracedir, if deleteOnDetach is set the
+	// trace is deleted when the target is detached.
+	Replay(tracedir string, quiet, deleteOnDetach bool, debugInfoDirs []string) (*Target, error)
+}
+
+// RecorderCapabilities describes the features supported by the recordings
+// of a Recorder.
+type RecorderCapabilities struct {
+	// ReverseExecution is true if recordings can be executed backwards.
+	ReverseExecution bool
+	// Checkpoints is true if checkpoints can be created in recordings.
+	Checkpoints bool
+	// RestartFromEvent is true if recordings can be restarted from an
+	// arbitrary event number.
+	RestartFromEvent bool
+}
+
+var (
+	recordersMu sync.Mutex
+	recorders   = map[string]Recorder{}
+)
+
+// RegisterRecorder makes a recorder available as a backend. It panics if
+// a recorder with the same name is already registered.
+func RegisterRecorder(r Recorder) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	if _, dup := recorders[r.Name()]; dup {
+		panic("recorder " + r.Name() + " registered twice")
+	}
+	recorders[r.Name()] = r
+}
+
+// LookupRecorder returns the recorder with the given name.
+func LookupRecorder(name string) (Recorder, bool) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	r, ok := recorders[name]
+	return r, ok
+}
+
+// Recorders returns all registered recorders, sorted by name.
+func Recorders() []Recorder {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	r := make([]Recorder, 0, len(recorders))
+	for _, rec := range recorders {
+		r = append(r, rec)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name() < r[j].Name() })
+	return r
+}
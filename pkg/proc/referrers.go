@@ -0,0 +1,224 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/dwarf/op"
+)
+
+// maxReferrers is the maximum number of referrers returned by
+// FindReferrers.
+const maxReferrers = 100
+
+// Referrers is the result of FindReferrers.
+type Referrers struct {
+	// Addr and Size are the address and size of the object containing the
+	// searched address, if it is a heap object, otherwise Addr is the
+	// searched address and Size is 1.
+	Addr, Size uint64
+	Referrers  []Referrer
+	// Truncated is true if the search stopped after finding maxReferrers
+	// referrers.
+	Truncated bool
+}
+
+// Referrer is an object containing a pointer to the object searched by
+// FindReferrers.
+type Referrer struct {
+	Addr, Size uint64
+	// Offset is the offset of the pointer inside the object.
+	Offset uint64
+	// Type is the type of the object, if it is known, and Field is the path
+	// of the field at Offset (for example ".next" or "[2].data").
+	Type  string
+	Field string
+	// Var is the name of the package variable, if the object is a package
+	// variable.
+	Var string
+	// GoroutineID is the ID of the goroutine, if the object is the stack of
+	// a goroutine.
+	GoroutineID int
+}
+
+type referrerSearch struct {
+	*heapTypeReader
+	spans  []heapSpan
+	lo, hi uint64 // pointers in [lo, hi) point to the searched object
+	res    *Referrers
+}
+
+// FindReferrers scans the heap, the data and bss sections of all Go
+// modules and the stacks of all goroutines for pointers to the object
+// containing addr, and returns the objects that contain them.
+//
+// Like selective core dumps memory is scanned conservatively, any word
+// equal to the address of a byte of the object is considered a pointer to
+// it; spans of the heap that can not contain pointers (noscan spans) and
+// free heap objects are skipped.
+// The type of heap objects is only known for objects with a malloc header,
+// i.e. objects larger than 512 bytes allocated by Go 1.22 or later.
+func (t *Target) FindReferrers(addr uint64) (*Referrers, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	bi := t.BinInfo()
+	mem := t.Memory()
+	s := &referrerSearch{
+		heapTypeReader: newHeapTypeReader(bi, mem),
+		spans:          readHeapSpans(bi, mem),
+		res:            &Referrers{Addr: addr, Size: 1},
+	}
+	if len(s.spans) == 0 {
+		return nil, errors.New("could not read the heap spans of the target process")
+	}
+	if span := s.spanOf(addr); span != nil && span.elemsize > 0 && span.state == mSpanInUse {
+		s.res.Addr = span.base + (addr-span.base)/span.elemsize*span.elemsize
+		s.res.Size = span.elemsize
+	}
+	s.lo, s.hi = s.res.Addr, s.res.Addr+s.res.Size
+
+	for i := range s.spans {
+		span := &s.spans[i]
+		if span.state != mSpanInUse || span.elemsize == 0 || span.spanclass&1 != 0 {
+			continue
+		}
+		s.scan(span.base, span.size, func(p uint64) {
+			s.heapReferrer(span, p)
+		})
+		if s.res.Truncated {
+			return s.res, nil
+		}
+	}
+
+	forEachModuleDataSection(bi, mem, func(start, end uint64, scan bool) {
+		if !scan || s.res.Truncated {
+			return
+		}
+		s.scan(start, end-start, s.globalReferrer)
+	})
+	if s.res.Truncated {
+		return s.res, nil
+	}
+
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		if g.stack.hi <= g.stack.lo {
+			continue
+		}
+		g := g
+		s.scan(g.stack.lo, g.stack.hi-g.stack.lo, func(p uint64) {
+			s.add(Referrer{Addr: g.stack.lo, Size: g.stack.hi - g.stack.lo, Offset: p - g.stack.lo, GoroutineID: g.ID})
+		})
+		if s.res.Truncated {
+			break
+		}
+	}
+	return s.res, nil
+}
+
+func (s *referrerSearch) spanOf(addr uint64) *heapSpan {
+	i := sort.Search(len(s.spans), func(i int) bool { return s.spans[i].base+s.spans[i].size > addr })
+	if i < len(s.spans) && s.spans[i].base <= addr {
+		return &s.spans[i]
+	}
+	return nil
+}
+
+func (s *referrerSearch) add(r Referrer) {
+	if len(s.res.Referrers) >= maxReferrers {
+		s.res.Truncated = true
+		return
+	}
+	s.res.Referrers = append(s.res.Referrers, r)
+}
+
+// scan calls fn with the address of every word of [addr, addr+size) that
+// points to the searched object.
+func (s *referrerSearch) scan(addr, size uint64, fn func(p uint64)) {
+	buf := make([]byte, dumpChunkSize)
+	end := addr + size
+	for addr < end && !s.res.Truncated {
+		sz := uint64(len(buf))
+		if end-addr < sz {
+			sz = end - addr
+		}
+		n, _ := s.mem.ReadMemory(buf[:sz], addr)
+		for off := uint64(0); off+s.ptrSize <= uint64(n); off += s.ptrSize {
+			var p uint64
+			if s.ptrSize == 4 {
+				p = uint64(binary.LittleEndian.Uint32(buf[off:]))
+			} else {
+				p = binary.LittleEndian.Uint64(buf[off:])
+			}
+			if p >= s.lo && p < s.hi {
+				fn(addr + off)
+			}
+		}
+		addr += sz
+	}
+}
+
+// heapReferrer adds the heap object of span containing the pointer at p.
+func (s *referrerSearch) heapReferrer(span *heapSpan, p uint64) {
+	idx := (p - span.base) / span.elemsize
+	if !span.isAllocated(span.readAllocBits(s.mem), idx) {
+		return
+	}
+	r := Referrer{Addr: span.base + idx*span.elemsize, Size: span.elemsize}
+	r.Offset = p - r.Addr
+	if typ, n, hdrsize := s.objectType(span, r.Addr); typ != nil && r.Offset >= hdrsize {
+		off := int64(r.Offset - hdrsize)
+		r.Type = heapObjectTypeString(typ, n)
+		if n > 1 {
+			r.Field = fmt.Sprintf("[%d]", off/typ.Size()) + fieldPathAtOffset(typ, off%typ.Size())
+		} else {
+			r.Field = fieldPathAtOffset(typ, off)
+		}
+	}
+	s.add(r)
+}
+
+// globalReferrer adds the package variable containing the pointer at p,
+// or the word at p if it isn't part of a package variable.
+func (s *referrerSearch) globalReferrer(p uint64) {
+	r := Referrer{Addr: p, Size: s.ptrSize}
+	pvs := s.bi.packageVars
+	i := sort.Search(len(pvs), func(i int) bool { return pvs[i].addr > p }) - 1
+	if i >= 0 {
+		pv := &pvs[i]
+		reader := pv.cu.image.dwarfReader
+		reader.Seek(pv.offset)
+		if entry, err := reader.Next(); err == nil {
+			if v, err := extractVarInfoFromEntry(s.bi, pv.cu.image, op.DwarfRegisters{StaticBase: pv.cu.image.StaticBase}, s.mem, godwarf.EntryToTree(entry)); err == nil && v.Addr == pv.addr && p < v.Addr+uint64(v.RealType.Size()) {
+				r = Referrer{Addr: v.Addr, Size: uint64(v.RealType.Size()), Offset: p - v.Addr, Type: v.TypeString(), Var: pv.name}
+				r.Field = fieldPathAtOffset(v.RealType, int64(r.Offset))
+			}
+		}
+	}
+	s.add(r)
+}
+
+// fieldPathAtOffset returns the path of the field of typ at offset off, for
+// example ".next" or "[2].data".
+func fieldPathAtOffset(typ godwarf.Type, off int64) string {
+	switch t := resolveTypedef(typ).(type) {
+	case *godwarf.StructType:
+		for _, field := range t.Field {
+			if off >= field.ByteOffset && off < field.ByteOffset+field.Type.Size() {
+				return "." + field.Name + fieldPathAtOffset(field.Type, off-field.ByteOffset)
+			}
+		}
+	case *godwarf.ArrayType:
+		if sz := t.Type.Size(); sz > 0 {
+			return fmt.Sprintf("[%d]", off/sz) + fieldPathAtOffset(t.Type, off%sz)
+		}
+	}
+	return ""
+}
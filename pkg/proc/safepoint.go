@@ -0,0 +1,70 @@
+package proc
+
+// maxSafePointSteps is the maximum number of instructions executed by
+// AdvanceToSafePoints on a single thread.
+const maxSafePointSteps = 1000
+
+// AdvanceToSafePoints single steps every thread that is executing Go code
+// outside of the runtime until it reaches a safe point, where a function
+// call can be injected and the heap can be inspected.
+// This is meant to be called after a manual stop request, which can stop
+// threads at arbitrary instructions.
+//
+// A thread is considered at a safe point when it is stopped at a statement
+// boundary after the prologue of its function. Threads stopped at a
+// breakpoint or executing runtime, assembly or C code are left where they
+// are, as are threads that don't reach a safe point within
+// maxSafePointSteps instructions, for example because they called into
+// the runtime.
+// Other threads are not resumed while a thread is advanced.
+func (t *Target) AdvanceToSafePoints() error {
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	if ok, _ := t.Recorded(); ok {
+		return nil
+	}
+	defer func() {
+		t.ClearAllGCache()
+		if curth := t.CurrentThread(); curth != nil {
+			t.selectedGoroutine, _ = GetG(curth)
+		}
+	}()
+
+	stmts := make(map[*Function]map[uint64]bool)
+	safePoint := func(thread Thread) (safe, abandon bool) {
+		loc, err := thread.Location()
+		if err != nil || loc.Fn == nil || loc.Fn.cu == nil || loc.Fn.PackageName() == "runtime" {
+			return false, true
+		}
+		fn := loc.Fn
+		if stmts[fn] == nil {
+			stmts[fn] = make(map[uint64]bool)
+			pcs, _ := fn.cu.lineInfo.AllPCsBetween(fn.Entry, fn.End-1, "", -1)
+			for _, pc := range pcs {
+				stmts[fn][pc] = true
+			}
+		}
+		if len(stmts[fn]) == 0 {
+			// no line table for this function, probably assembly
+			return false, true
+		}
+		return loc.PC >= fn.PrologueEndPC() && stmts[fn][loc.PC], false
+	}
+
+	for _, thread := range t.ThreadList() {
+		if thread.Breakpoint().Breakpoint != nil {
+			continue
+		}
+		for i := 0; i < maxSafePointSteps; i++ {
+			safe, abandon := safePoint(thread)
+			if safe || abandon {
+				break
+			}
+			if err := thread.StepInstruction(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package proc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// maxSearchResults is the maximum number of paths returned by
+// SearchVariable.
+const maxSearchResults = 100
+
+// SearchVariable evaluates expr and searches the values reachable from it
+// for strings, numbers and booleans equal to value, returning the
+// expressions that can be used to access them (for example
+// `req.Header["X-Request-Id"][0]`).
+//
+// Scalars are compared against their value formatted as a string, strings
+// are compared against their contents.
+//
+// The search follows pointers, interfaces, slices and maps, cfg limits it
+// the same way it limits loading a variable: cfg.MaxVariableRecurse is the
+// maximum depth of the search (dereferencing a pointer does not count),
+// cfg.MaxArrayValues the maximum number of elements of each array, slice
+// or map that will be searched and cfg.MaxStringLen the maximum number of
+// bytes of each string that will be read. Values reachable through more
+// than one path are only searched once.
+// At most maxSearchResults paths are returned.
+func (scope *EvalScope) SearchVariable(expr, value string, cfg LoadConfig) ([]string, error) {
+	evalcfg := loadSingleValue
+	evalcfg.MaxStringLen = cfg.MaxStringLen
+	v, err := scope.EvalExpression(expr, evalcfg)
+	if err != nil {
+		return nil, err
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	s := &variableSearch{value: value, cfg: cfg, visited: make(map[searchVisitKey]bool)}
+	s.search(v, expr, 0)
+	return s.paths, nil
+}
+
+type searchVisitKey struct {
+	addr uint64
+	typ  string
+}
+
+type variableSearch struct {
+	value   string
+	cfg     LoadConfig
+	visited map[searchVisitKey]bool
+	paths   []string
+}
+
+func (s *variableSearch) done() bool {
+	return len(s.paths) >= maxSearchResults
+}
+
+// visit returns true if v was already searched.
+func (s *variableSearch) visit(v *Variable) bool {
+	k := searchVisitKey{v.Addr, v.DwarfType.String()}
+	if s.visited[k] {
+		return true
+	}
+	s.visited[k] = true
+	return false
+}
+
+func (s *variableSearch) search(v *Variable, path string, depth int) {
+	if s.done() || v.Unreadable != nil || depth > s.cfg.MaxVariableRecurse {
+		return
+	}
+
+	switch v.Kind {
+	case reflect.Ptr:
+		if v.Addr == 0 && len(v.Children) == 1 {
+			// fake pointer variable constructed by casting an integer to a pointer type
+			s.search(&v.Children[0], "(*"+path+")", depth)
+			return
+		}
+		pv := v.maybeDereference()
+		if pv.Addr == 0 || pv.Unreadable != nil || s.visit(pv) {
+			return
+		}
+		if pv.Kind == reflect.Struct {
+			// field selectors dereference pointers automatically
+			s.search(pv, path, depth)
+		} else {
+			s.search(pv, "(*"+path+")", depth)
+		}
+
+	case reflect.Interface:
+		v.loadInterface(depth, false, s.cfg)
+		if v.Unreadable != nil || len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			return
+		}
+		cv := &v.Children[0]
+		cv.OnlyAddr = false
+		if cv.Kind != reflect.Ptr && s.visit(cv) {
+			return
+		}
+		s.search(cv, fmt.Sprintf("%s.(%s)", path, cv.TypeString()), depth+1)
+
+	case reflect.Struct:
+		t := v.RealType.(*godwarf.StructType)
+		for _, field := range t.Field {
+			if s.done() {
+				return
+			}
+			f, err := v.toField(field)
+			if err != nil {
+				continue
+			}
+			s.search(f, path+"."+field.Name, depth+1)
+		}
+
+	case reflect.Array, reflect.Slice:
+		count := v.Len
+		if count > int64(s.cfg.MaxArrayValues) {
+			count = int64(s.cfg.MaxArrayValues)
+		}
+		for i := 0; i < int(count); i++ {
+			if s.done() {
+				return
+			}
+			ev, err := v.sliceAccess(i)
+			if err != nil {
+				return
+			}
+			s.search(ev, fmt.Sprintf("%s[%d]", path, i), depth+1)
+		}
+
+	case reflect.Map:
+		it := v.mapIterator()
+		if it == nil {
+			return
+		}
+		it.maxNumBuckets = uint64(s.cfg.MaxMapBuckets)
+		keycfg := loadSingleValue
+		keycfg.MaxStringLen = s.cfg.MaxStringLen
+		for count := 0; count < s.cfg.MaxArrayValues && it.next(); count++ {
+			if s.done() {
+				return
+			}
+			key := it.key()
+			key.loadValue(keycfg)
+			ks, ok := mapKeyString(key)
+			if !ok || key.Unreadable != nil {
+				continue
+			}
+			if key.Kind == reflect.String {
+				ks = strconv.Quote(ks)
+			}
+			s.search(it.value(), fmt.Sprintf("%s[%s]", path, ks), depth+1)
+		}
+
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		cfg := loadSingleValue
+		cfg.MaxStringLen = s.cfg.MaxStringLen
+		v.loadValue(cfg)
+		if v.Unreadable != nil || (v.Kind == reflect.String && v.Len != int64(len(s.value))) {
+			return
+		}
+		if vs, ok := mapKeyString(v); ok && vs == s.value {
+			s.paths = append(s.paths, path)
+		}
+	}
+}
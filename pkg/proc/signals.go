@@ -0,0 +1,174 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SignalPolicy describes how the debugger handles a signal received by the
+// target process.
+type SignalPolicy struct {
+	// Stop is true if the target process should be stopped, and control
+	// returned to the user, when the signal is received.
+	Stop bool
+	// Print is true if the reception of the signal should be reported to
+	// the user, even if the target process isn't stopped.
+	Print bool
+	// Pass is true if the signal should be delivered to the target process.
+	Pass bool
+}
+
+// DefaultSignalPolicy is the policy used for signals that were not
+// configured with SetSignalPolicy: the signal is silently delivered to
+// the target process.
+var DefaultSignalPolicy = SignalPolicy{Pass: true}
+
+// ReceivedSignal describes a signal received by a thread of the target
+// process.
+type ReceivedSignal struct {
+	ThreadID int
+	Signal   int
+}
+
+// ErrSignalPolicyNotAllowed is returned by SetSignalPolicy for signals
+// whose handling can not be changed because the debugger uses them.
+type ErrSignalPolicyNotAllowed struct {
+	Signal string
+}
+
+func (err ErrSignalPolicyNotAllowed) Error() string {
+	return fmt.Sprintf("can not change the handling of %s, it is used by the debugger", err.Signal)
+}
+
+// ErrSignalPolicyNotSupported is returned by SetSignalPolicy when the
+// backend can not change how signals are handled.
+var ErrSignalPolicyNotSupported = errors.New("signal handling policies are not supported by this backend")
+
+// SetSignalPolicy changes the way the signal sig is handled.
+func (t *Target) SetSignalPolicy(sig int, policy SignalPolicy) error {
+	if t.BinInfo().GOOS == "windows" {
+		return fmt.Errorf("signals are not supported on windows")
+	}
+	switch SignalName(t.BinInfo().GOOS, sig) {
+	case "SIGTRAP", "SIGSTOP", "SIGKILL":
+		return ErrSignalPolicyNotAllowed{SignalName(t.BinInfo().GOOS, sig)}
+	}
+	if err := t.proc.SetSignalPolicy(sig, policy); err != nil {
+		return err
+	}
+	if t.signalPolicies == nil {
+		t.signalPolicies = make(map[int]SignalPolicy)
+	}
+	t.signalPolicies[sig] = policy
+	return nil
+}
+
+// SignalPolicies returns the signal handling policies that were changed
+// with SetSignalPolicy. Signals that are not in the returned map are
+// handled using DefaultSignalPolicy.
+func (t *Target) SignalPolicies() map[int]SignalPolicy {
+	r := make(map[int]SignalPolicy, len(t.signalPolicies))
+	for sig, policy := range t.signalPolicies {
+		r[sig] = policy
+	}
+	return r
+}
+
+func (t *Target) signalPolicy(sig int) SignalPolicy {
+	if policy, ok := t.signalPolicies[sig]; ok {
+		return policy
+	}
+	return DefaultSignalPolicy
+}
+
+// collectSignals appends the signals received by threads to
+// t.ReceivedSignals and returns true if at least one signal was received
+// and, in that case, if any of them requires the target to stop.
+func (t *Target) collectSignals(threads []Thread) (received, stop bool) {
+	for _, th := range threads {
+		sig := th.Common().Signal
+		if sig == 0 {
+			continue
+		}
+		th.Common().Signal = 0
+		received = true
+		t.ReceivedSignals = append(t.ReceivedSignals, ReceivedSignal{ThreadID: th.ThreadID(), Signal: sig})
+		if t.signalPolicy(sig).Stop {
+			stop = true
+		}
+	}
+	return received, stop
+}
+
+var linuxSignals = []string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP",
+	6: "SIGABRT", 7: "SIGBUS", 8: "SIGFPE", 9: "SIGKILL", 10: "SIGUSR1",
+	11: "SIGSEGV", 12: "SIGUSR2", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM",
+	16: "SIGSTKFLT", 17: "SIGCHLD", 18: "SIGCONT", 19: "SIGSTOP", 20: "SIGTSTP",
+	21: "SIGTTIN", 22: "SIGTTOU", 23: "SIGURG", 24: "SIGXCPU", 25: "SIGXFSZ",
+	26: "SIGVTALRM", 27: "SIGPROF", 28: "SIGWINCH", 29: "SIGIO", 30: "SIGPWR",
+	31: "SIGSYS",
+}
+
+var bsdSignals = []string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP",
+	6: "SIGABRT", 7: "SIGEMT", 8: "SIGFPE", 9: "SIGKILL", 10: "SIGBUS",
+	11: "SIGSEGV", 12: "SIGSYS", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM",
+	16: "SIGURG", 17: "SIGSTOP", 18: "SIGTSTP", 19: "SIGCONT", 20: "SIGCHLD",
+	21: "SIGTTIN", 22: "SIGTTOU", 23: "SIGIO", 24: "SIGXCPU", 25: "SIGXFSZ",
+	26: "SIGVTALRM", 27: "SIGPROF", 28: "SIGWINCH", 29: "SIGINFO", 30: "SIGUSR1",
+	31: "SIGUSR2",
+}
+
+func signalTable(goos string) []string {
+	switch goos {
+	case "darwin", "freebsd", "netbsd", "openbsd":
+		return bsdSignals
+	default:
+		return linuxSignals
+	}
+}
+
+// SignalName returns the name of signal sig on the operating system goos.
+func SignalName(goos string, sig int) string {
+	tbl := signalTable(goos)
+	if sig > 0 && sig < len(tbl) {
+		return tbl[sig]
+	}
+	return fmt.Sprintf("signal %d", sig)
+}
+
+// SignalNumber returns the number of the signal called name on the
+// operating system goos. The name can be specified with or without the
+// SIG prefix, in any case, or as a number.
+func SignalNumber(goos string, name string) (int, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid signal number %d", n)
+		}
+		return n, nil
+	}
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, signame := range signalTable(goos) {
+		if signame == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
+
+// SortedSignals returns the signals of m sorted by number.
+func SortedSignals(m map[int]SignalPolicy) []int {
+	r := make([]int, 0, len(m))
+	for sig := range m {
+		r = append(r, sig)
+	}
+	sort.Ints(r)
+	return r
+}
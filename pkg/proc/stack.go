@@ -7,6 +7,7 @@ import (
 	"go/constant"
 
 	"github.com/go-delve/delve/pkg/dwarf/frame"
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	"github.com/go-delve/delve/pkg/dwarf/op"
 	"github.com/go-delve/delve/pkg/dwarf/reader"
 )
@@ -101,6 +102,7 @@ func ThreadStacktrace(thread Thread, depth int) ([]Stackframe, error) {
 		}
 		so := thread.BinInfo().PCToImage(regs.PC())
 		it := newStackIterator(thread.BinInfo(), thread.ProcessMemory(), thread.BinInfo().Arch.RegistersToDwarfRegisters(so.StaticBase, regs), 0, nil, -1, nil, 0)
+		it.skipInlinedCalls = thread.Common().skippedInlinedCalls
 		return it.stacktrace(depth)
 	}
 	return g.Stacktrace(depth, 0)
@@ -119,10 +121,14 @@ func (g *G) stackIterator(opts StacktraceOptions) (*stackIterator, error) {
 			return nil, err
 		}
 		so := bi.PCToImage(regs.PC())
-		return newStackIterator(
+		it := newStackIterator(
 			bi, g.variable.mem,
 			bi.Arch.RegistersToDwarfRegisters(so.StaticBase, regs),
-			g.stack.hi, stkbar, g.stkbarPos, g, opts), nil
+			g.stack.hi, stkbar, g.stkbarPos, g, opts)
+		if opts&StacktraceG == 0 {
+			it.skipInlinedCalls = g.Thread.Common().skippedInlinedCalls
+		}
+		return it, nil
 	}
 	so := g.variable.bi.PCToImage(g.PC)
 	return newStackIterator(
@@ -196,6 +202,8 @@ type stackIterator struct {
 	g0_sched_sp_loaded bool   // g0_sched_sp was loaded from g0
 
 	opts StacktraceOptions
+
+	skipInlinedCalls int // number of inlined calls beginning at the PC of the topmost frame whose frames are hidden
 }
 
 type savedLR struct {
@@ -268,7 +276,7 @@ func (it *stackIterator) switchToGoroutineStack() {
 	it.pc = it.g.PC
 	it.regs.Reg(it.regs.SPRegNum).Uint64Val = it.g.SP
 	it.regs.AddReg(it.regs.BPRegNum, op.DwarfRegisterFromUint64(it.g.BP))
-	if it.bi.Arch.Name == "arm64" {
+	if it.bi.Arch.usesLR {
 		it.regs.Reg(it.regs.LRRegNum).Uint64Val = it.g.LR
 	}
 }
@@ -360,8 +368,10 @@ func (it *stackIterator) appendInlineCalls(frames []Stackframe, frame Stackframe
 	}
 
 	callpc := frame.Call.PC
+	skip := it.skipInlinedCalls
 	if len(frames) > 0 {
 		callpc--
+		skip = 0
 	}
 
 	dwarfTree, err := frame.Call.Fn.cu.image.getDwarfTree(frame.Call.Fn.offset)
@@ -381,6 +391,18 @@ func (it *stackIterator) appendInlineCalls(frames []Stackframe, frame Stackframe
 			break
 		}
 
+		if skip > 0 && inlinedCallStartsAt(entry, callpc) {
+			// The user did not step into this inlined call yet, hide its frame
+			// and use the call site as the current location.
+			skip--
+			frame.Call.File = frame.Current.Fn.cu.lineInfo.FileNames[fileidx-1].Path
+			frame.Call.Line = int(line)
+			frame.Current.File = frame.Call.File
+			frame.Current.Line = frame.Call.Line
+			continue
+		}
+		skip = 0
+
 		inlfn := &Function{Name: fnname, Entry: frame.Call.Fn.Entry, End: frame.Call.Fn.End, offset: entry.Offset, cu: frame.Call.Fn.cu}
 		frames = append(frames, Stackframe{
 			Current: frame.Current,
@@ -407,6 +429,64 @@ func (it *stackIterator) appendInlineCalls(frames []Stackframe, frame Stackframe
 	return append(frames, frame)
 }
 
+// inlinedCallsStartingAt returns the inlined calls, from the innermost to
+// the outermost, that begin exactly at pc, and the function containing them.
+func inlinedCallsStartingAt(bi *BinaryInfo, pc uint64) (*Function, []*godwarf.Tree) {
+	fn := bi.PCToFunc(pc)
+	if fn == nil || fn.cu.lineInfo == nil {
+		return nil, nil
+	}
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return nil, nil
+	}
+	var calls []*godwarf.Tree
+	for _, entry := range reader.InlineStack(dwarfTree, pc) {
+		if !inlinedCallStartsAt(entry, pc) {
+			break
+		}
+		calls = append(calls, entry)
+	}
+	return fn, calls
+}
+
+func inlinedCallStartsAt(entry *godwarf.Tree, pc uint64) bool {
+	return len(entry.Ranges) > 0 && entry.Ranges[0][0] == pc
+}
+
+// inlinedCallSite returns the file and line of the call site of the
+// inlined call entry, made by fn.
+func inlinedCallSite(fn *Function, entry *godwarf.Tree) (string, int, bool) {
+	fileidx, okfileidx := entry.Val(dwarf.AttrCallFile).(int64)
+	line, okline := entry.Val(dwarf.AttrCallLine).(int64)
+	if !okfileidx || !okline || fn.cu.lineInfo == nil || fileidx-1 < 0 || fileidx-1 >= int64(len(fn.cu.lineInfo.FileNames)) {
+		return "", 0, false
+	}
+	return fn.cu.lineInfo.FileNames[fileidx-1].Path, int(line), true
+}
+
+// LogicalLocation returns the location of thread. If the user did not step
+// into some of the inlined calls beginning at the current PC (see
+// Target.StepInline) the call site of the outermost of them is returned
+// instead of the location of the current PC.
+func LogicalLocation(thread Thread) (*Location, error) {
+	loc, err := thread.Location()
+	skip := thread.Common().skippedInlinedCalls
+	if err != nil || skip == 0 {
+		return loc, err
+	}
+	fn, calls := inlinedCallsStartingAt(thread.BinInfo(), loc.PC)
+	if skip > len(calls) {
+		skip = len(calls)
+	}
+	if skip > 0 {
+		if file, line, ok := inlinedCallSite(fn, calls[skip-1]); ok {
+			loc.File, loc.Line = file, line
+		}
+	}
+	return loc, nil
+}
+
 // advanceRegs calculates the DwarfRegisters for a next stack frame
 // (corresponding to it.pc).
 //
@@ -449,7 +529,12 @@ func (it *stackIterator) advanceRegs() (callFrameRegs op.DwarfRegisters, ret uin
 		reg, err := it.executeFrameRegRule(i, regRule, it.regs.CFA)
 		callFrameRegs.AddReg(i, reg)
 		if i == framectx.RetAddrReg {
-			if reg == nil {
+			if regRule.Rule == frame.RuleUndefined {
+				// The return address is explicitly marked as undefined, this is how
+				// the outermost frame is marked in .eh_frame (for example _start or
+				// clone in C libraries).
+				ret = 0
+			} else if reg == nil {
 				if err == nil {
 					err = fmt.Errorf("Undefined return address at %#x", it.pc)
 				}
@@ -461,7 +546,7 @@ func (it *stackIterator) advanceRegs() (callFrameRegs op.DwarfRegisters, ret uin
 		}
 	}
 
-	if it.bi.Arch.Name == "arm64" {
+	if it.bi.Arch.usesLR {
 		if ret == 0 && it.regs.Reg(it.regs.LRRegNum) != nil {
 			ret = it.regs.Reg(it.regs.LRRegNum).Uint64Val
 		}
@@ -605,7 +690,7 @@ func (g *G) readDefers(frames []Stackframe) {
 }
 
 func (d *Defer) load() {
-	d.variable.loadValue(LoadConfig{false, 1, 0, 0, -1, 0})
+	d.variable.loadValue(LoadConfig{false, 1, 0, 0, -1, 0, false, 0})
 	if d.variable.Unreadable != nil {
 		d.Unreadable = d.variable.Unreadable
 		return
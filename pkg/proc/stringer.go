@@ -0,0 +1,147 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+// StringerOptions configures CallStringer.
+type StringerOptions struct {
+	// Timeout is the maximum duration of the call, after which the target
+	// is stopped. Zero means no timeout.
+	Timeout time.Duration
+	// MaxAlloc is the maximum number of bytes by which the live heap of the
+	// target can grow during the call, if the limit is exceeded the
+	// returned string is discarded. Zero means no limit.
+	MaxAlloc uint64
+	// MaxStringLen is the maximum number of bytes of the returned string
+	// that will be loaded.
+	MaxStringLen int
+}
+
+// ErrStringerInProgress is returned by CallStringer when the target stopped
+// before the String or Error method returned, because of a timeout, a
+// breakpoint or a manual stop request. The call is still in progress and
+// will complete when the target is resumed.
+var ErrStringerInProgress = errors.New("the target stopped before the String or Error method returned, the call will complete when the target is resumed")
+
+// CallStringer evaluates expr in the topmost frame of g and, if its value
+// implements error or fmt.Stringer, calls its Error or String method (in
+// this order, like package fmt does) and returns the result.
+//
+// The call is made like the 'call' command does, with the escape check
+// enabled, and the same restrictions apply: it is not possible with core
+// files and recordings and g must be running on a thread.
+// If the call does not return within opts.Timeout the target is stopped
+// and ErrStringerInProgress is returned. The growth of the live heap of the
+// target is measured after the call, which can not be undone, and the
+// result is discarded if it exceeds opts.MaxAlloc.
+func CallStringer(t *Target, g *G, expr string, opts StringerOptions) (string, error) {
+	if !t.SupportsFunctionCalls() {
+		return "", errFuncCallUnsupportedBackend
+	}
+	if g == nil {
+		return "", errNoGoroutine
+	}
+	if g.Status != Grunning || g.Thread == nil {
+		return "", errGoroutineNotRunning
+	}
+	scope, err := GoroutineScope(g.Thread)
+	if err != nil {
+		return "", err
+	}
+	method := ""
+	for _, m := range []string{"Error", "String"} {
+		fnv, err := scope.EvalExpression(fmt.Sprintf("(%s).%s", expr, m), loadSingleValue)
+		if err == nil && fnv.Kind == reflect.Func {
+			method = m
+			break
+		}
+	}
+	if method == "" {
+		return "", fmt.Errorf("%s does not have a String or Error method", expr)
+	}
+
+	heapLive0, heapLiveErr := readHeapLive(t)
+
+	var timedOut int32
+	if opts.Timeout > 0 {
+		timer := time.AfterFunc(opts.Timeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			t.RequestManualStop()
+		})
+		defer timer.Stop()
+	}
+
+	cfg := loadSingleValue
+	cfg.MaxStringLen = opts.MaxStringLen
+	thread := g.Thread
+	err = EvalExpressionWithCalls(t, g, fmt.Sprintf("(%s).%s()", expr, method), cfg, true)
+	if err != nil {
+		return "", err
+	}
+	if callinj := t.fncallForG[g.ID]; callinj != nil && callinj.continueCompleted != nil {
+		if atomic.LoadInt32(&timedOut) != 0 {
+			return "", fmt.Errorf("%s method timed out after %v: %w", method, opts.Timeout, ErrStringerInProgress)
+		}
+		return "", ErrStringerInProgress
+	}
+	// the return value must not be reported as the result of a call by the
+	// 'call' command
+	thread.Common().CallReturn = false
+	retvals := thread.Common().ReturnValues(cfg)
+	if len(retvals) != 1 {
+		return "", fmt.Errorf("%s method returned %d values", method, len(retvals))
+	}
+	ret := retvals[0]
+	if ret.Name == "~panic" {
+		return "", fmt.Errorf("%s method panicked", method)
+	}
+	if ret.Unreadable != nil {
+		return "", ret.Unreadable
+	}
+	if ret.Kind != reflect.String {
+		return "", fmt.Errorf("%s method returned a value of type %s", method, ret.TypeString())
+	}
+
+	if opts.MaxAlloc > 0 && heapLiveErr == nil {
+		if heapLive1, err := readHeapLive(t); err == nil && heapLive1 > heapLive0 && heapLive1-heapLive0 > opts.MaxAlloc {
+			return "", fmt.Errorf("%s method allocated %d bytes, more than the limit of %d", method, heapLive1-heapLive0, opts.MaxAlloc)
+		}
+	}
+
+	return constant.StringVal(ret.Value), nil
+}
+
+// readHeapLive returns the number of bytes of the live heap of the target,
+// as tracked by the garbage collector.
+func readHeapLive(t *Target) (uint64, error) {
+	bi := t.BinInfo()
+	scope := globalScope(bi, bi.Images[0], t.Memory())
+	if producer := bi.Producer(); producer != "" && goversion.ProducerAfterOrEqual(producer, 1, 18) {
+		gcc, err := scope.findGlobal("runtime", "gcController")
+		if err != nil {
+			return 0, err
+		}
+		heapLive, err := gcc.structMember("heapLive")
+		if err != nil {
+			return 0, err
+		}
+		return loadUintValue(unwrapAtomic(heapLive))
+	}
+	memstats, err := scope.findGlobal("runtime", "memstats")
+	if err != nil {
+		return 0, err
+	}
+	heapLive, err := memstats.structMember("heap_live")
+	if err != nil {
+		return 0, err
+	}
+	return loadUintValue(heapLive)
+}
@@ -0,0 +1,320 @@
+package proc
+
+import (
+	"errors"
+	"go/constant"
+	"reflect"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// loadDecodedStruct replaces the children of struct variables of some
+// types that are used as containers, and whose fields are not useful to
+// the user, with their logical contents:
+//
+//   - sync.Map is presented as a map (see DecodedKind) of its live entries
+//   - sync/atomic.Value is presented as the interface value it stores
+//   - the maps and channels of TinyGo programs are presented as a map and
+//     a channel, see loadTinyGoMap and loadTinyGoChan
+//
+// Returns false if v isn't one of those types or it could not be decoded,
+// in which case its fields should be loaded normally.
+func (v *Variable) loadDecodedStruct(recurseLevel int, cfg LoadConfig) bool {
+	if v.Unreadable != nil || v.DwarfType == nil {
+		return false
+	}
+	switch v.DwarfType.Common().Name {
+	case "sync.Map":
+		entries, err := v.syncMapEntries()
+		if err != nil {
+			return false
+		}
+		v.DecodedKind = reflect.Map
+		v.Base = v.Addr
+		v.Len = int64(len(entries) / 2)
+		v.Children = make([]Variable, 0, len(entries))
+		for i := 0; i+1 < len(entries) && i/2 < cfg.MaxArrayValues; i += 2 {
+			entries[i].loadValueInternal(recurseLevel+1, cfg)
+			entries[i+1].loadValueInternal(recurseLevel+1, cfg)
+			v.Children = append(v.Children, *entries[i], *entries[i+1])
+		}
+		return true
+
+	case "sync/atomic.Value":
+		iface, err := v.structMember("v")
+		if err != nil || iface.Kind != reflect.Interface {
+			return false
+		}
+		iface.loadInterface(recurseLevel, true, cfg)
+		if iface.Unreadable != nil {
+			return false
+		}
+		v.DecodedKind = reflect.Interface
+		v.Children = iface.Children
+		return true
+
+	case "runtime.hashmap":
+		return v.bi.isTinyGo() && v.loadTinyGoMap(recurseLevel, cfg) == nil
+
+	case "runtime.channel":
+		return v.bi.isTinyGo() && v.loadTinyGoChan(recurseLevel, cfg) == nil
+	}
+	return false
+}
+
+// syncMapEntries returns the keys and values of the live entries of
+// sync.Map v, as a list of key/value pairs. The keys and values are not
+// loaded.
+func (v *Variable) syncMapEntries() ([]*Variable, error) {
+	if m, err := v.structMember("m"); err == nil {
+		// Go 1.24 and later, sync.Map is a wrapper around
+		// internal/sync.HashTrieMap.
+		return m.hashTrieMapEntries()
+	}
+
+	// Before Go 1.24, entries are stored in the map read.m, a
+	// map[any]*entry. If read.amended is set some of the entries are only in
+	// dirty, which in this case contains all the entries of read.m as well.
+	read, err := v.structMember("read")
+	if err != nil {
+		return nil, err
+	}
+	switch read.DwarfType.Common().Name {
+	case "sync/atomic.Value":
+		// before Go 1.20 read is an atomic.Value containing a readOnly struct
+		read, err = read.structMember("v")
+		if err != nil {
+			return nil, err
+		}
+		read.loadInterface(0, false, loadSingleValue)
+		if read.Unreadable != nil || len(read.Children) == 0 {
+			return nil, errors.New("malformed sync.Map")
+		}
+		read = &read.Children[0]
+	default:
+		read, err = read.atomicPointerTarget()
+		if err != nil {
+			return nil, err
+		}
+	}
+	m, err := v.structMember("dirty")
+	if err != nil {
+		return nil, err
+	}
+	if read.Addr != 0 {
+		amended, err := read.wellKnownField("amended")
+		if err != nil {
+			return nil, err
+		}
+		if !constant.BoolVal(amended) {
+			m, err = read.structMember("m")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var expunged uint64
+	for _, pkgvar := range v.bi.packageVars {
+		if pkgvar.name == "sync.expunged" {
+			expunged, _ = readUintRaw(v.mem, pkgvar.addr, int64(v.bi.Arch.PtrSize()))
+			break
+		}
+	}
+
+	it := m.mapIterator()
+	if it == nil {
+		if m.Unreadable != nil {
+			return nil, m.Unreadable
+		}
+		return nil, nil
+	}
+	var entries []*Variable
+	errcount := 0
+	for it.next() {
+		key := it.key()
+		e := it.value().maybeDereference()
+		if e.Addr == 0 || e.Unreadable != nil {
+			continue
+		}
+		val, err := e.syncMapEntryValue(expunged)
+		if err != nil {
+			errcount++
+			if errcount > maxErrCount {
+				return nil, err
+			}
+			continue
+		}
+		if val != nil {
+			entries = append(entries, key, val)
+		}
+	}
+	return entries, nil
+}
+
+// syncMapEntryValue returns the value stored in e, a *sync.entry, or nil if
+// the entry was deleted.
+func (e *Variable) syncMapEntryValue(expunged uint64) (*Variable, error) {
+	p, err := e.structMember("p")
+	if err != nil {
+		return nil, err
+	}
+	var val *Variable
+	if p.Kind == reflect.Struct {
+		// Go 1.19 and later, p is an atomic.Pointer[any]
+		val, err = p.atomicPointerTarget()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		addr, err := readUintRaw(p.mem, p.Addr, int64(p.bi.Arch.PtrSize()))
+		if err != nil {
+			return nil, err
+		}
+		typ, err := p.bi.findType("interface {}")
+		if err != nil {
+			return nil, err
+		}
+		val = p.newVariable("", addr, typ, DereferenceMemory(p.mem))
+	}
+	if val.Addr == 0 || val.Addr == expunged {
+		return nil, nil
+	}
+	return val, nil
+}
+
+// atomicPointerTarget returns the variable pointed to by v, a
+// sync/atomic.Pointer[T]. The type T is recovered from the _ [0]*T field
+// of atomic.Pointer.
+func (v *Variable) atomicPointerTarget() (*Variable, error) {
+	st, ok := v.RealType.(*godwarf.StructType)
+	if !ok {
+		return nil, errors.New("not an atomic.Pointer")
+	}
+	var typ godwarf.Type
+	for _, field := range st.Field {
+		if at, isarr := resolveTypedef(field.Type).(*godwarf.ArrayType); isarr && at.Count == 0 {
+			if pt, isptr := resolveTypedef(at.Type).(*godwarf.PtrType); isptr {
+				typ = pt.Type
+				break
+			}
+		}
+	}
+	if typ == nil {
+		return nil, errors.New("not an atomic.Pointer")
+	}
+	p, err := v.structMember("v")
+	if err != nil {
+		return nil, err
+	}
+	addr, err := readUintRaw(p.mem, p.Addr, int64(v.bi.Arch.PtrSize()))
+	if err != nil {
+		return nil, err
+	}
+	return v.newVariable("", addr, typ, DereferenceMemory(v.mem)), nil
+}
+
+// hashTrieMapEntries returns the keys and values of the entries of v, an
+// internal/sync.HashTrieMap, as a list of key/value pairs.
+//
+// A HashTrieMap is a tree of indirect nodes, each with an array of children
+// that are either indirect nodes or entries, each entry can have a list of
+// overflow entries with the same hash.
+func (v *Variable) hashTrieMapEntries() ([]*Variable, error) {
+	root, err := v.structMember("root")
+	if err != nil {
+		return nil, err
+	}
+	root, err = root.atomicPointerTarget()
+	if err != nil {
+		return nil, err
+	}
+	if root.Addr == 0 {
+		// not initialized
+		return nil, nil
+	}
+	children, err := root.structMember("children")
+	if err != nil {
+		return nil, err
+	}
+	// The type of children is [N]atomic.Pointer[node[K, V]], the type of
+	// entries can be derived from the name of the node type.
+	if children.Kind != reflect.Array || children.Len == 0 {
+		return nil, errors.New("malformed HashTrieMap")
+	}
+	child0, _ := children.sliceAccess(0)
+	node, err := child0.atomicPointerTarget()
+	if err != nil {
+		return nil, err
+	}
+	nodeTypeName := node.DwarfType.Common().Name
+	i := strings.Index(nodeTypeName, ".node[")
+	if i < 0 {
+		return nil, errors.New("malformed HashTrieMap")
+	}
+	entryType, err := v.bi.findType(nodeTypeName[:i] + ".entry[" + nodeTypeName[i+len(".node["):])
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Variable
+	var walk func(ind *Variable, depth int) error
+	walk = func(ind *Variable, depth int) error {
+		if depth > 64 {
+			return errors.New("HashTrieMap too deep")
+		}
+		children, err := ind.structMember("children")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < int(children.Len); i++ {
+			child, err := children.sliceAccess(i)
+			if err != nil {
+				return err
+			}
+			n, err := child.atomicPointerTarget()
+			if err != nil {
+				return err
+			}
+			if n.Addr == 0 {
+				continue
+			}
+			isEntry, err := n.wellKnownField("isEntry")
+			if err != nil {
+				return err
+			}
+			if !constant.BoolVal(isEntry) {
+				n = v.newVariable("", n.Addr, ind.DwarfType, DereferenceMemory(v.mem))
+				if err := walk(n, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+			for e := v.newVariable("", n.Addr, entryType, DereferenceMemory(v.mem)); e.Addr != 0; {
+				key, err := e.structMember("key")
+				if err != nil {
+					return err
+				}
+				val, err := e.structMember("value")
+				if err != nil {
+					return err
+				}
+				entries = append(entries, key, val)
+				overflow, err := e.structMember("overflow")
+				if err != nil {
+					return err
+				}
+				e, err = overflow.atomicPointerTarget()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root, 0); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
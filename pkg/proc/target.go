@@ -58,6 +58,67 @@ type Target struct {
 	// have read and parsed from the targets memory.
 	// This must be cleared whenever the target is resumed.
 	gcache goroutineCache
+
+	// gcStatus caches the result of GCStatus until the target process is
+	// resumed, it is cleared by ClearAllGCache.
+	gcStatus *GCStatus
+
+	// signalPolicies contains the signal handling policies set with
+	// SetSignalPolicy.
+	signalPolicies map[int]SignalPolicy
+
+	// nextStart is the position where the last next, step or stepout
+	// operation started, see hideInlinedCalls.
+	nextStart fileLine
+
+	// StopAtInlinedCalls is true if next should also stop at the beginning
+	// of the inlined calls made by the current function on other lines, so
+	// that they can be entered with StepInline. By default next steps over
+	// inlined calls like it does with normal calls.
+	StopAtInlinedCalls bool
+
+	// ReceivedSignals is the list of signals, that the user asked to be
+	// notified of, received by the target process during the last call to
+	// Continue.
+	ReceivedSignals []ReceivedSignal
+
+	// patches contains the function patches currently applied, indexed by
+	// the entry point of the patched function, see PatchFunctionReturn.
+	patches    map[uint64]*FunctionPatch
+	patchID    int
+	patchArena patchArena
+
+	// memoryGuards contains the memory guards set with GuardMemory.
+	memoryGuards  []*MemoryGuard
+	memoryGuardID int
+
+	// GuardFault is the access to guarded memory that stopped the target
+	// process, if StopReason is StopGuardFault.
+	GuardFault *GuardFault
+
+	// QueueBreakpointsDuringNext is true if breakpoints hit by other
+	// goroutines while a next, step or stepout operation is in progress
+	// should not interrupt it. The hits are recorded in QueuedBreakpoints
+	// and the target process is resumed.
+	QueueBreakpointsDuringNext bool
+	// QueuedBreakpoints is the list of breakpoint hits queued during the
+	// last call to Continue, see QueueBreakpointsDuringNext.
+	QueuedBreakpoints []QueuedBreakpoint
+
+	// batchingBreakpoints is true during BatchBreakpoints, the breakpoints
+	// created by SetBreakpoint are then appended to pendingBreakpoints and
+	// written to memory when the batch ends.
+	batchingBreakpoints bool
+	pendingBreakpoints  []*Breakpoint
+}
+
+// QueuedBreakpoint is a breakpoint hit by a goroutine while a next
+// operation on a different goroutine was in progress.
+type QueuedBreakpoint struct {
+	ThreadID    int
+	GoroutineID int
+	Breakpoint  *Breakpoint
+	Location    Location
 }
 
 // ErrProcessExited indicates that the process has exited and contains both
@@ -97,6 +158,10 @@ func (sr StopReason) String() string {
 		return "next finished"
 	case StopCallReturned:
 		return "call returned"
+	case StopSignal:
+		return "signal"
+	case StopGuardFault:
+		return "guard fault"
 	default:
 		return ""
 	}
@@ -112,6 +177,8 @@ const (
 	StopManual                         // A manual stop was requested
 	StopNextFinished                   // The next/step/stepout command terminated
 	StopCallReturned                   // An injected call completed
+	StopSignal                         // The target process received a signal that should stop it, see SignalPolicy
+	StopGuardFault                     // The target process accessed memory protected by a MemoryGuard
 )
 
 // NewTargetConfig contains the configuration for a new Target object,
@@ -157,6 +224,7 @@ func NewTarget(p Process, currentThread Thread, cfg NewTargetConfig) (*Target, e
 		Process:       p,
 		proc:          p.(ProcessInternal),
 		fncallForG:    make(map[int]*callInjection),
+		patches:       make(map[uint64]*FunctionPatch),
 		StopReason:    cfg.StopReason,
 		currentThread: currentThread,
 	}
@@ -164,8 +232,11 @@ func NewTarget(p Process, currentThread Thread, cfg NewTargetConfig) (*Target, e
 	g, _ := GetG(currentThread)
 	t.selectedGoroutine = g
 
-	t.createUnrecoveredPanicBreakpoint()
-	t.createFatalThrowBreakpoint()
+	_ = t.BatchBreakpoints(func() error {
+		t.createUnrecoveredPanicBreakpoint()
+		t.createFatalThrowBreakpoint()
+		return nil
+	})
 
 	t.gcache.init(p.BinInfo())
 
@@ -187,10 +258,18 @@ func (t *Target) SupportsFunctionCalls() bool {
 	return t.Process.BinInfo().Arch.Name == "amd64"
 }
 
-// ClearAllGCache clears the internal Goroutine cache.
+// CanCallFunctions returns true if the backend supports calling functions
+// and the target was compiled with a version of Go that supports it.
+func (t *Target) CanCallFunctions() bool {
+	return t.SupportsFunctionCalls() && t.BinInfo().LookupFunc[debugCallFunctionName] != nil
+}
+
+// ClearAllGCache clears the internal Goroutine cache, and the cached
+// status of the garbage collector.
 // This should be called anytime the target process executes instructions.
 func (t *Target) ClearAllGCache() {
 	t.gcache.Clear()
+	t.gcStatus = nil
 	for _, thread := range t.ThreadList() {
 		thread.Common().g = nil
 	}
@@ -265,6 +344,16 @@ func (t *Target) Detach(kill bool) error {
 				}
 			}
 		}
+		for _, patch := range t.FunctionPatches() {
+			if err := t.UnpatchFunction(patch.ID); err != nil {
+				return err
+			}
+		}
+		for _, guard := range t.MemoryGuards() {
+			if err := t.UnguardMemory(guard.ID); err != nil {
+				return err
+			}
+		}
 	}
 	t.StopReason = StopUnknown
 	return t.proc.Detach(kill)
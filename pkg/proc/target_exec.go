@@ -2,6 +2,7 @@ package proc
 
 import (
 	"bytes"
+	"debug/dwarf"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/go-delve/delve/pkg/astutil"
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	"github.com/go-delve/delve/pkg/dwarf/reader"
 )
 
@@ -52,7 +54,13 @@ func (dbp *Target) Continue() error {
 	for _, thread := range dbp.ThreadList() {
 		thread.Common().CallReturn = false
 		thread.Common().returnValues = nil
+		thread.Common().Signal = 0
+		thread.Common().FaultAddr = 0
+		thread.Common().skippedInlinedCalls = 0
 	}
+	dbp.ReceivedSignals = nil
+	dbp.GuardFault = nil
+	dbp.QueuedBreakpoints = nil
 	dbp.CheckAndClearManualStopRequest()
 	defer func() {
 		// Make sure we clear internal breakpoints if we simultaneously receive a
@@ -104,9 +112,30 @@ func (dbp *Target) Continue() error {
 			return callErr
 		}
 
+		if dbp.collectGuardFaults(threads) {
+			dbp.StopReason = StopGuardFault
+			dbp.ClearInternalBreakpoints()
+			if err := dbp.SwitchThread(dbp.GuardFault.ThreadID); err != nil {
+				return err
+			}
+			return conditionErrors(threads)
+		}
+
 		curthread := dbp.CurrentThread()
 		curbp := curthread.Breakpoint()
 
+		if received, stop := dbp.collectSignals(threads); received && !curbp.Active && !callInjectionDone {
+			if stop {
+				dbp.StopReason = StopSignal
+				dbp.ClearInternalBreakpoints()
+				return conditionErrors(threads)
+			}
+			// the user only asked to be notified of this signal, the target
+			// process will stop at a later time and ReceivedSignals will be
+			// reported then.
+			continue
+		}
+
 		switch {
 		case curbp.Breakpoint == nil:
 			// runtime.Breakpoint, manual stop or debugCallV1-related stop
@@ -184,6 +213,7 @@ func (dbp *Target) Continue() error {
 					return err
 				}
 				dbp.StopReason = StopNextFinished
+				dbp.hideInlinedCalls(curthread)
 				return conditionErrors(threads)
 			}
 		case curbp.Active:
@@ -191,6 +221,16 @@ func (dbp *Target) Continue() error {
 			if err != nil {
 				return err
 			}
+			if !onNextGoroutine && dbp.QueueBreakpointsDuringNext && dbp.Breakpoints().HasInternalBreakpoints() && curbp.Name != UnrecoveredPanic {
+				queued, err := dbp.queueBreakpoints(threads)
+				if err != nil {
+					return err
+				}
+				if queued {
+					// resume the target to let the next operation complete
+					continue
+				}
+			}
 			if onNextGoroutine {
 				err := dbp.ClearInternalBreakpoints()
 				if err != nil {
@@ -214,6 +254,41 @@ func (dbp *Target) Continue() error {
 	}
 }
 
+// queueBreakpoints appends the user breakpoints hit by threads that are
+// not running the goroutine of the next operation in progress to
+// dbp.QueuedBreakpoints. Returns false, without queuing any breakpoint, if
+// the goroutine of the next operation hit a breakpoint or a breakpoint
+// condition could not be evaluated.
+func (dbp *Target) queueBreakpoints(threads []Thread) (bool, error) {
+	var queued []QueuedBreakpoint
+	for _, th := range threads {
+		bp := th.Breakpoint()
+		if bp.Breakpoint == nil || !bp.Active || bp.Internal {
+			continue
+		}
+		if bp.CondError != nil || bp.Name == UnrecoveredPanic {
+			return false, nil
+		}
+		onNextGoroutine, err := onNextGoroutine(th, dbp.Breakpoints())
+		if err != nil {
+			return false, err
+		}
+		if onNextGoroutine {
+			return false, nil
+		}
+		qbp := QueuedBreakpoint{ThreadID: th.ThreadID(), Breakpoint: bp.Breakpoint}
+		if g, _ := GetG(th); g != nil {
+			qbp.GoroutineID = g.ID
+		}
+		if loc, err := th.Location(); err == nil {
+			qbp.Location = *loc
+		}
+		queued = append(queued, qbp)
+	}
+	dbp.QueuedBreakpoints = append(dbp.QueuedBreakpoints, queued...)
+	return len(queued) > 0, nil
+}
+
 func conditionErrors(threads []Thread) error {
 	var condErr error
 	for _, th := range threads {
@@ -290,6 +365,12 @@ func (dbp *Target) Step() (err error) {
 		return fmt.Errorf("next while nexting")
 	}
 
+	if th := dbp.inlineThread(); th != nil && th.Common().skippedInlinedCalls > 0 && dbp.GetDirection() == Forward {
+		// We are stopped at the call site of an inlined call, step into it
+		// without resuming the target.
+		return dbp.StepInline("")
+	}
+
 	if err = next(dbp, true, false); err != nil {
 		_ = dbp.ClearInternalBreakpoints()
 		return err
@@ -303,6 +384,129 @@ func (dbp *Target) Step() (err error) {
 	return dbp.Continue()
 }
 
+// InlinedCallStart describes an inlined call beginning at the current PC
+// of the selected goroutine.
+type InlinedCallStart struct {
+	Fn *Function // the inlined function
+	// File and Line are the position of the call site.
+	File string
+	Line int
+	// Entered is true if the user stepped into the inlined call, otherwise
+	// the call site is used as the current location.
+	Entered bool
+}
+
+// InlineTree returns the inlined calls beginning at the current PC of the
+// selected goroutine, from the outermost to the innermost.
+func (dbp *Target) InlineTree() ([]InlinedCallStart, error) {
+	th := dbp.inlineThread()
+	if th == nil {
+		return nil, nil
+	}
+	regs, err := th.Registers()
+	if err != nil {
+		return nil, err
+	}
+	fn, calls := inlinedCallsStartingAt(dbp.BinInfo(), regs.PC())
+	skip := th.Common().skippedInlinedCalls
+	r := make([]InlinedCallStart, 0, len(calls))
+	for i := len(calls) - 1; i >= 0; i-- {
+		fnname, _ := calls[i].Val(dwarf.AttrName).(string)
+		file, line, _ := inlinedCallSite(fn, calls[i])
+		r = append(r, InlinedCallStart{
+			Fn:      &Function{Name: fnname, Entry: fn.Entry, End: fn.End, offset: calls[i].Offset, cu: fn.cu},
+			File:    file,
+			Line:    line,
+			Entered: i >= skip,
+		})
+	}
+	return r, nil
+}
+
+// StepInline steps into the inlined call to fnname beginning at the current
+// PC of the selected goroutine, and all the inlined calls containing it,
+// without resuming the target process.
+// If fnname is the empty string the outermost inlined call that the user
+// did not step into yet is entered.
+func (dbp *Target) StepInline(fnname string) error {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	th := dbp.inlineThread()
+	if th == nil {
+		return errors.New("no inlined call to step into")
+	}
+	regs, err := th.Registers()
+	if err != nil {
+		return err
+	}
+	_, calls := inlinedCallsStartingAt(dbp.BinInfo(), regs.PC())
+	skip := th.Common().skippedInlinedCalls
+	if skip > len(calls) {
+		skip = len(calls)
+	}
+	if skip == 0 {
+		return errors.New("no inlined call to step into")
+	}
+	newskip := skip - 1
+	if fnname != "" {
+		newskip = -1
+		for i := 0; i < skip; i++ {
+			if name, _ := calls[i].Val(dwarf.AttrName).(string); name == fnname {
+				newskip = i
+				break
+			}
+		}
+		if newskip < 0 {
+			return fmt.Errorf("no inlined call to %s begins at the current location", fnname)
+		}
+	}
+	dbp.setSkippedInlinedCalls(th, newskip)
+	dbp.StopReason = StopNextFinished
+	return nil
+}
+
+// inlineThread returns the thread running the selected goroutine, or nil
+// if the selected goroutine is parked.
+func (dbp *Target) inlineThread() Thread {
+	if g := dbp.SelectedGoroutine(); g != nil {
+		return g.Thread
+	}
+	return dbp.CurrentThread()
+}
+
+// hideInlinedCalls hides the frames of the inlined calls beginning at the
+// current PC of thread, the call site of the outermost one will be used as
+// the current location until the user steps into them.
+// Inlined calls made from the line where the last next, step or stepout
+// operation started, and the calls containing them, are not hidden: the
+// user was on their call site already.
+func (dbp *Target) hideInlinedCalls(thread Thread) {
+	regs, err := thread.Registers()
+	if err != nil {
+		return
+	}
+	fn, calls := inlinedCallsStartingAt(dbp.BinInfo(), regs.PC())
+	skip := len(calls)
+	for i := range calls {
+		if file, line, ok := inlinedCallSite(fn, calls[i]); ok && file == dbp.nextStart.file && line == dbp.nextStart.line {
+			skip = i
+			break
+		}
+	}
+	dbp.setSkippedInlinedCalls(thread, skip)
+}
+
+func (dbp *Target) setSkippedInlinedCalls(thread Thread, n int) {
+	thread.Common().skippedInlinedCalls = n
+	thread.Common().g = nil
+	if g := dbp.selectedGoroutine; g != nil && g.Thread == thread {
+		if loc, err := LogicalLocation(thread); err == nil {
+			g.CurrentLoc = *loc
+		}
+	}
+}
+
 // sameGoroutineCondition returns an expression that evaluates to true when
 // the current goroutine is g.
 func sameGoroutineCondition(g *G) ast.Expr {
@@ -351,6 +555,8 @@ func (dbp *Target) StepOut() error {
 		return dbp.Continue()
 	}
 
+	dbp.nextStart = fileLine{topframe.Call.File, topframe.Call.Line}
+
 	sameGCond := sameGoroutineCondition(selg)
 
 	if backward {
@@ -477,6 +683,8 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 		panic("next called with inlinedStepOut but topframe was not inlined")
 	}
 
+	dbp.nextStart = fileLine{topframe.Call.File, topframe.Call.Line}
+
 	success := false
 	defer func() {
 		if !success {
@@ -573,6 +781,11 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 		if err != nil {
 			return err
 		}
+		if dbp.StopAtInlinedCalls {
+			// Stop at the beginning of inlined calls made on other lines, their
+			// call site is a line of the current function.
+			pcs = append(pcs, inlinedCallStartPCs(frame)...)
+		}
 	}
 
 	if !csource {
@@ -715,6 +928,35 @@ func removeInlinedCalls(pcs []uint64, topframe Stackframe) ([]uint64, error) {
 	return pcs, nil
 }
 
+// inlinedCallStartPCs returns the address of the first instruction of each
+// inlined call made directly by the function of frame, except for calls
+// made on the current line.
+func inlinedCallStartPCs(frame Stackframe) []uint64 {
+	fn := frame.Call.Fn
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return nil
+	}
+	var pcs []uint64
+	var visit func(*godwarf.Tree)
+	visit = func(n *godwarf.Tree) {
+		for _, child := range n.Children {
+			switch child.Tag {
+			case dwarf.TagLexDwarfBlock:
+				visit(child)
+			case dwarf.TagInlinedSubroutine:
+				file, line, ok := inlinedCallSite(fn, child)
+				if !ok || (file == frame.Call.File && line == frame.Call.Line) || len(child.Ranges) == 0 {
+					continue
+				}
+				pcs = append(pcs, child.Ranges[0][0])
+			}
+		}
+	}
+	visit(dwarfTree)
+	return pcs
+}
+
 func removePCsBetween(pcs []uint64, start, end uint64) []uint64 {
 	out := pcs[:0]
 	for _, pc := range pcs {
@@ -51,7 +51,13 @@ type Location struct {
 type CommonThread struct {
 	CallReturn   bool // returnValues are the return values of a call injection
 	returnValues []*Variable
-	g            *G // cached g for this thread
+	g            *G     // cached g for this thread
+	Signal       int    // signal received by this thread that should be reported to the user, see SignalPolicy
+	FaultAddr    uint64 // address of an access to guarded memory made by this thread, see GuardMemory
+
+	// skippedInlinedCalls is the number of inlined calls, beginning at the
+	// current PC, that the user did not step into yet, see Target.StepInline.
+	skippedInlinedCalls int
 }
 
 // ReturnValues reads the return values from the function executing on
@@ -0,0 +1,205 @@
+package proc
+
+import (
+	"errors"
+	"go/constant"
+	"reflect"
+	"sort"
+)
+
+// Timer describes a pending runtime timer of the target process, for
+// example one created by time.NewTimer, time.NewTicker or time.AfterFunc.
+type Timer struct {
+	// Addr is the address of the runtime.timer struct.
+	Addr uint64
+	// P is the ID of the P whose heap contains the timer or -1 if the
+	// target uses the global timer buckets of Go 1.13 and earlier.
+	P int
+	// When is the value of the runtime monotonic clock (see
+	// runtime.nanotime) when the timer will fire.
+	When int64
+	// Period is the interval between successive firings of the timer, zero
+	// for timers that only fire once.
+	Period int64
+	// Callback is the name of the function called when the timer fires.
+	// For timers created by time.AfterFunc this is the function passed to
+	// AfterFunc rather than time.goFunc.
+	Callback string
+}
+
+// Timers returns the timers pending in the target process, sorted by the
+// time at which they will fire, and an approximation of the current value
+// of the runtime monotonic clock, or zero if it could not be determined.
+func (t *Target) Timers() ([]Timer, int64, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, 0, err
+	}
+	scope := globalScope(t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+
+	var timers []Timer
+	var err error
+	if allpv, err2 := scope.findGlobal("runtime", "allp"); err2 == nil {
+		timers, err = timersFromAllp(allpv)
+	} else if bucketsv, err2 := scope.findGlobal("runtime", "timers"); err2 == nil {
+		timers, err = timersFromBuckets(bucketsv)
+	} else {
+		return nil, 0, errors.New("could not find timers in the target process")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].When < timers[j].When })
+
+	return timers, t.Nanotime(), nil
+}
+
+// Nanotime returns an approximation of the current value of the runtime
+// monotonic clock of the target (see runtime.nanotime), or zero if it could
+// not be determined.
+func (t *Target) Nanotime() int64 {
+	// The runtime does not store the current time anywhere, the last time
+	// the network poller ran is a good enough approximation.
+	scope := globalScope(t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+	schedv, err := scope.findGlobal("runtime", "sched")
+	if err != nil {
+		return 0
+	}
+	lastpollv, err := schedv.structMember("lastpoll")
+	if err != nil {
+		return 0
+	}
+	lastpoll, _ := loadUintValue(lastpollv)
+	return int64(lastpoll)
+}
+
+// timersFromAllp reads the timer heaps of all the Ps in allpv (the value of
+// runtime.allp), used by Go 1.14 and later.
+func timersFromAllp(allpv *Variable) ([]Timer, error) {
+	var timers []Timer
+	for i := int64(0); i < allpv.Len; i++ {
+		pv, err := allpv.sliceAccess(int(i))
+		if err != nil {
+			return nil, err
+		}
+		pv = pv.maybeDereference()
+		if pv.Addr == 0 {
+			continue
+		}
+		pid := int(i)
+		if idv, err := pv.structMember("id"); err == nil {
+			if id, err := loadUintValue(idv); err == nil {
+				pid = int(id)
+			}
+		}
+		heapv, err := pv.structMember("timers")
+		if err != nil {
+			return nil, err
+		}
+		if heapv.Kind == reflect.Struct {
+			// Go 1.23 and later: p.timers is a struct containing the heap of
+			// timerWhen structs.
+			heapv, err = heapv.structMember("heap")
+			if err != nil {
+				return nil, err
+			}
+		}
+		ts, err := timersFromHeap(heapv, pid)
+		if err != nil {
+			return nil, err
+		}
+		timers = append(timers, ts...)
+	}
+	return timers, nil
+}
+
+// timersFromBuckets reads the timers of the global array of timer buckets
+// bucketsv (the value of runtime.timers), used by Go 1.13 and earlier.
+func timersFromBuckets(bucketsv *Variable) ([]Timer, error) {
+	var timers []Timer
+	for i := int64(0); i < bucketsv.Len; i++ {
+		bucketv, err := bucketsv.sliceAccess(int(i))
+		if err != nil {
+			return nil, err
+		}
+		heapv, err := bucketv.structMember("t")
+		if err != nil {
+			return nil, err
+		}
+		ts, err := timersFromHeap(heapv, -1)
+		if err != nil {
+			return nil, err
+		}
+		timers = append(timers, ts...)
+	}
+	return timers, nil
+}
+
+// timersFromHeap reads the timers contained in heapv, which is either a
+// slice of pointers to runtime.timer or a slice of runtime.timerWhen.
+func timersFromHeap(heapv *Variable, pid int) ([]Timer, error) {
+	if heapv.Unreadable != nil {
+		return nil, heapv.Unreadable
+	}
+	timers := make([]Timer, 0, heapv.Len)
+	for i := int64(0); i < heapv.Len; i++ {
+		tv, err := heapv.sliceAccess(int(i))
+		if err != nil {
+			return nil, err
+		}
+		if tv.Kind == reflect.Struct {
+			tv, err = tv.structMember("timer")
+			if err != nil {
+				return nil, err
+			}
+		}
+		tv = tv.maybeDereference()
+		if tv.Unreadable != nil {
+			return nil, tv.Unreadable
+		}
+		if tv.Addr == 0 {
+			continue
+		}
+		timers = append(timers, readTimer(tv, pid))
+	}
+	return timers, nil
+}
+
+// readTimer reads the runtime.timer struct tv.
+func readTimer(tv *Variable, pid int) Timer {
+	timer := Timer{Addr: tv.Addr, P: pid}
+	if whenv, err := tv.structMember("when"); err == nil {
+		when, _ := loadUintValue(whenv)
+		timer.When = int64(when)
+	}
+	if periodv, err := tv.structMember("period"); err == nil {
+		period, _ := loadUintValue(periodv)
+		timer.Period = int64(period)
+	}
+	fv, err := tv.structMember("f")
+	if err != nil {
+		return timer
+	}
+	fv.loadValue(loadSingleValue)
+	if fv.Unreadable != nil || fv.Value == nil {
+		return timer
+	}
+	timer.Callback = constant.StringVal(fv.Value)
+	if timer.Callback != "time.goFunc" {
+		return timer
+	}
+	// Timers created by time.AfterFunc call time.goFunc with the function
+	// passed to AfterFunc as argument.
+	argv, err := tv.structMember("arg")
+	if err != nil {
+		return timer
+	}
+	argv.loadValue(loadFullValue)
+	if argv.Unreadable != nil || len(argv.Children) == 0 {
+		return timer
+	}
+	if datav := &argv.Children[0]; datav.Kind == reflect.Func && datav.Unreadable == nil && datav.Value != nil {
+		timer.Callback = constant.StringVal(datav.Value)
+	}
+	return timer
+}
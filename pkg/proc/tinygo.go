@@ -0,0 +1,303 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+// Support for programs compiled with TinyGo.
+//
+// TinyGo uses a different runtime than the gc toolchain and the debug
+// information it emits, produced by LLVM, does not describe Go types fully:
+//
+//   - goroutines are internal/task.Task objects, scheduled cooperatively,
+//     instead of runtime.g structs and there is no list of all goroutines
+//   - maps are pointers to runtime.hashmap and channels are pointers to
+//     runtime.channel, the types of keys, values and elements are not
+//     recorded
+//   - strings and slices are structs without DW_AT_go_kind (see
+//     godwarf.tinyGoStructType)
+//
+// The functions in this file provide degraded support for them.
+
+// ErrTinyGoUnsupportedArch is returned when the registers of a parked
+// TinyGo goroutine can not be read on the target architecture.
+var ErrTinyGoUnsupportedArch = errors.New("can not read parked TinyGo goroutines on this architecture")
+
+// maxTinyGoTasks is the maximum number of tasks read from each TinyGo
+// scheduler queue, protects against loops in corrupted queues.
+const maxTinyGoTasks = 100000
+
+// tinyGoBucketSize is the number of entries in a bucket of a TinyGo map.
+const tinyGoBucketSize = 8
+
+// isTinyGo returns true if the executable was compiled with TinyGo.
+func (bi *BinaryInfo) isTinyGo() bool {
+	return goversion.IsTinyGo(bi.Producer())
+}
+
+// tinyGoSwitchFrame describes the frame saved by tinygo_swapTask when a
+// task is parked: the offsets of the saved frame pointer and return
+// address from the saved stack pointer and the size of the frame.
+// See calleeSavedRegs in TinyGo's internal/task package.
+type tinyGoSwitchFrame struct {
+	bpOff, pcOff, size uint64
+}
+
+var tinyGoSwitchFrames = map[string]tinyGoSwitchFrame{
+	// r15, r14, r13, r12, rbp, rbx, return address
+	"amd64": {bpOff: 4 * 8, pcOff: 6 * 8, size: 7 * 8},
+	// x19...x28, x29 (fp), x30 (lr), d8...d15
+	"arm64": {bpOff: 10 * 8, pcOff: 11 * 8, size: 20 * 8},
+}
+
+// tinyGoGlobal returns the global variable with the specified fully
+// qualified name. TinyGo does not emit DW_AT_go_package_name, therefore
+// findGlobal can not be used.
+func tinyGoGlobal(bi *BinaryInfo, mem MemoryReadWriter, name string) (*Variable, error) {
+	v, err := globalScope(bi, bi.Images[0], mem).findGlobalInternal(name)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("could not find %s", name)
+	}
+	return v, nil
+}
+
+// tinyGoGetG returns the goroutine executing on thread, i.e. the task in
+// internal/task.currentTask.
+func tinyGoGetG(thread Thread) (*G, error) {
+	cur, err := tinyGoGlobal(thread.BinInfo(), thread.ProcessMemory(), "internal/task.currentTask")
+	if err != nil {
+		return nil, err
+	}
+	task := cur.maybeDereference()
+	if task.Unreadable != nil {
+		return nil, task.Unreadable
+	}
+	if task.Addr == 0 {
+		// Executing on the system stack, for example inside the scheduler.
+		return nil, ErrNoGoroutine{tid: thread.ThreadID()}
+	}
+	g := task.parseTinyGoTask()
+	g.Status = Grunning
+	g.Thread = thread
+	if loc, err := LogicalLocation(thread); err == nil {
+		g.CurrentLoc = *loc
+	}
+	thread.Common().g = g
+	return g, nil
+}
+
+// parseTinyGoTask returns the goroutine described by v, an
+// internal/task.Task. TinyGo goroutines do not have an ID, the address of
+// the task is used instead.
+// If the goroutine isn't running on a thread its registers are read from
+// the frame saved on its stack when it was parked.
+func (v *Variable) parseTinyGoTask() *G {
+	g := &G{ID: int(v.Addr), variable: v}
+	v.Name = "runtime.curg"
+
+	frame, ok := tinyGoSwitchFrames[v.bi.Arch.Name]
+	if !ok {
+		g.Unreadable = ErrTinyGoUnsupportedArch
+		return g
+	}
+	state, err := v.structMember("state")
+	if err != nil {
+		g.Unreadable = err
+		return g
+	}
+	spval, err := state.wellKnownField("sp")
+	if err != nil {
+		g.Unreadable = err
+		return g
+	}
+	sp, _ := constant.Uint64Val(spval)
+	if sp == 0 {
+		return g
+	}
+	ptrSize := int64(v.bi.Arch.PtrSize())
+	mem := cacheMemory(v.mem, sp, int(frame.size))
+	g.BP, _ = readUintRaw(mem, sp+frame.bpOff, ptrSize)
+	g.PC, _ = readUintRaw(mem, sp+frame.pcOff, ptrSize)
+	g.SP = sp + frame.size
+	f, l, fn := v.bi.PCToLine(g.PC)
+	g.CurrentLoc = Location{PC: g.PC, File: f, Line: l, Fn: fn}
+	return g
+}
+
+// tinyGoGoroutinesInfo implements GoroutinesInfo for TinyGo programs.
+// TinyGo does not keep a list of all goroutines, the goroutines returned
+// are the ones running on a thread and the ones in the run and sleep
+// queues of the scheduler. Goroutines blocked on channels or mutexes are
+// not returned.
+func tinyGoGoroutinesInfo(dbp *Target, start, count int) ([]*G, int, error) {
+	var allg []*G
+	seen := make(map[uint64]bool)
+	for _, th := range dbp.ThreadList() {
+		g, _ := GetG(th)
+		if g != nil && !seen[uint64(g.ID)] {
+			seen[uint64(g.ID)] = true
+			allg = append(allg, g)
+		}
+	}
+
+	bi := dbp.BinInfo()
+	mem := dbp.Memory()
+	readQueue := func(head *Variable, status uint64) {
+		for i := 0; i < maxTinyGoTasks; i++ {
+			task := head.maybeDereference()
+			if task.Unreadable != nil || task.Addr == 0 || seen[task.Addr] {
+				return
+			}
+			seen[task.Addr] = true
+			g := task.parseTinyGoTask()
+			g.Status = status
+			allg = append(allg, g)
+			dbp.gcache.addGoroutine(g)
+			var err error
+			head, err = task.structMember("Next")
+			if err != nil {
+				return
+			}
+		}
+	}
+	if runqueue, err := tinyGoGlobal(bi, mem, "runtime.runqueue"); err == nil {
+		if head, err := runqueue.structMember("head"); err == nil {
+			readQueue(head, Grunnable)
+		}
+	}
+	if sleepQueue, err := tinyGoGlobal(bi, mem, "runtime.sleepQueue"); err == nil {
+		readQueue(sleepQueue, Gwaiting)
+	}
+
+	if start == 0 {
+		dbp.gcache.allGCache = allg
+	}
+	if start >= len(allg) {
+		return nil, -1, nil
+	}
+	allg = allg[start:]
+	if count != 0 && len(allg) > count {
+		return allg[:count], start + count, nil
+	}
+	return allg, -1, nil
+}
+
+// loadTinyGoMap presents v, a TinyGo runtime.hashmap, as a map (see
+// DecodedKind). Since the types of keys and values are not recorded in the
+// debug information they are presented as arrays of bytes.
+func (v *Variable) loadTinyGoMap(recurseLevel int, cfg LoadConfig) error {
+	field := func(name string) (uint64, error) {
+		val, err := v.wellKnownField(name)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := constant.Uint64Val(val)
+		return n, nil
+	}
+	var buckets, count, keySize, valueSize, bucketBits uint64
+	for _, f := range []struct {
+		name string
+		dst  *uint64
+	}{{"buckets", &buckets}, {"count", &count}, {"keySize", &keySize}, {"valueSize", &valueSize}, {"bucketBits", &bucketBits}} {
+		var err error
+		*f.dst, err = field(f.name)
+		if err != nil {
+			return err
+		}
+	}
+	keyType, err := v.bi.findArrayType(int(keySize), "uint8")
+	if err != nil {
+		return err
+	}
+	valueType, err := v.bi.findArrayType(int(valueSize), "uint8")
+	if err != nil {
+		return err
+	}
+	if bucketBits >= 32 {
+		return errors.New("malformed TinyGo map")
+	}
+
+	// A bucket is a [8]uint8 array of tophash values, followed by a pointer
+	// to the next bucket, the 8 keys and the 8 values. Empty slots have a
+	// tophash of 0.
+	ptrSize := uint64(v.bi.Arch.PtrSize())
+	keysOff := tinyGoBucketSize + ptrSize
+	valuesOff := keysOff + tinyGoBucketSize*keySize
+	bucketSize := valuesOff + tinyGoBucketSize*valueSize
+
+	var children []Variable
+	mem := DereferenceMemory(v.mem)
+	nbuckets := uint64(1) << bucketBits
+	errcount := 0
+	seen := make(map[uint64]bool)
+bucketsLoop:
+	for i := uint64(0); buckets != 0 && i < nbuckets; i++ {
+		for bucket := buckets + i*bucketSize; bucket != 0 && !seen[bucket]; {
+			seen[bucket] = true
+			bmem := cacheMemory(mem, bucket, int(bucketSize))
+			tophash := make([]byte, tinyGoBucketSize)
+			if _, err := bmem.ReadMemory(tophash, bucket); err != nil {
+				errcount++
+				if errcount > maxErrCount {
+					return err
+				}
+				break
+			}
+			for j := uint64(0); j < tinyGoBucketSize; j++ {
+				if tophash[j] == 0 {
+					continue
+				}
+				if len(children)/2 >= cfg.MaxArrayValues {
+					break bucketsLoop
+				}
+				key := v.newVariable("", bucket+keysOff+j*keySize, keyType, bmem)
+				val := v.newVariable("", bucket+valuesOff+j*valueSize, valueType, bmem)
+				key.loadValueInternal(recurseLevel+1, cfg)
+				val.loadValueInternal(recurseLevel+1, cfg)
+				children = append(children, *key, *val)
+			}
+			bucket, _ = readUintRaw(bmem, bucket+tinyGoBucketSize, int64(ptrSize))
+		}
+	}
+
+	v.DecodedKind = reflect.Map
+	v.Base = v.Addr
+	v.Len = int64(count)
+	v.Children = children
+	return nil
+}
+
+// loadTinyGoChan loads the fields of v, a TinyGo runtime.channel, and
+// presents it as a channel (see DecodedKind) with the number of buffered
+// elements as its length and the size of the buffer as its capacity.
+func (v *Variable) loadTinyGoChan(recurseLevel int, cfg LoadConfig) error {
+	bufUsed, err := v.wellKnownField("bufUsed")
+	if err != nil {
+		return err
+	}
+	bufSize, err := v.wellKnownField("bufSize")
+	if err != nil {
+		return err
+	}
+	t := v.RealType.(*godwarf.StructType)
+	v.Children = make([]Variable, 0, len(t.Field))
+	for _, field := range t.Field {
+		f, _ := v.toField(field)
+		f.Name = field.Name
+		f.loadValueInternal(recurseLevel+1, cfg)
+		v.Children = append(v.Children, *f)
+	}
+	v.DecodedKind = reflect.Chan
+	v.Len, _ = constant.Int64Val(bufUsed)
+	v.Cap, _ = constant.Int64Val(bufSize)
+	return nil
+}
@@ -122,8 +122,29 @@ type Variable struct {
 	loaded     bool
 	Unreadable error
 
+	// WellKnownValue is a human readable representation of the value of
+	// variables of some well known types, for example the RFC3339
+	// representation of a time.Time, see loadWellKnownValue.
+	WellKnownValue string
+
+	// Bytes contains alternative representations of the contents of slices
+	// and arrays of bytes, if requested with LoadConfig.BytesView.
+	Bytes *BytesInfo
+
+	// DecodedKind, if it is not reflect.Invalid, means that the Children of
+	// this struct variable are not its fields but its logical contents, as
+	// decoded by loadDecodedStruct. For sync.Map it is reflect.Map and
+	// Children are key/value pairs, for sync/atomic.Value it is
+	// reflect.Interface and Children contains the stored value.
+	DecodedKind reflect.Kind
+
 	LocationExpr *locationExpr // location expression
 	DeclLine     int64         // line number of this variable's declaration
+
+	// ScopeStartLine and ScopeEndLine are the lines of the first and last
+	// instructions of the lexical block in which this variable is declared,
+	// they are only set for local variables.
+	ScopeStartLine, ScopeEndLine int
 }
 
 // LoadConfig controls how variables are loaded from the targets memory.
@@ -167,11 +188,21 @@ type LoadConfig struct {
 	// sparse map is in scope, but evaluating a single variable will still work
 	// correctly, even if the variable in question is a very sparse map.
 	MaxMapBuckets int
+
+	// DisableWellKnownTypes disables the computation of WellKnownValue for
+	// variables of well known types (time.Time, math/big.Int, etc) and the
+	// decoding of the contents of sync.Map and sync/atomic.Value variables.
+	DisableWellKnownTypes bool
+
+	// BytesView, if not zero, selects alternative representations of the
+	// contents of slices and arrays of bytes, which are stored in
+	// Variable.Bytes instead of loading their elements as children.
+	BytesView BytesView
 }
 
-var loadSingleValue = LoadConfig{false, 0, 64, 0, 0, 0}
-var loadFullValue = LoadConfig{true, 1, 64, 64, -1, 0}
-var loadFullValueLongerStrings = LoadConfig{true, 1, 1024 * 1024, 64, -1, 0}
+var loadSingleValue = LoadConfig{false, 0, 64, 0, 0, 0, false, 0}
+var loadFullValue = LoadConfig{true, 1, 64, 64, -1, 0, false, 0}
+var loadFullValueLongerStrings = LoadConfig{true, 1, 1024 * 1024, 64, -1, 0, false, 0}
 
 // G status, from: src/runtime/runtime2.go
 const (
@@ -242,6 +273,9 @@ func GetG(thread Thread) (*G, error) {
 	if thread.Common().g != nil {
 		return thread.Common().g, nil
 	}
+	if thread.BinInfo().isTinyGo() {
+		return tinyGoGetG(thread)
+	}
 	if loc, _ := thread.Location(); loc != nil && loc.Fn != nil && loc.Fn.Name == "runtime.clone" {
 		// When threads are executing runtime.clone the value of TLS is unreliable.
 		return nil, nil
@@ -281,7 +315,7 @@ func GetG(thread Thread) (*G, error) {
 		g.SystemStack = true
 	}
 	g.Thread = thread
-	if loc, err := thread.Location(); err == nil {
+	if loc, err := LogicalLocation(thread); err == nil {
 		g.CurrentLoc = *loc
 	}
 	thread.Common().g = g
@@ -305,6 +339,9 @@ func GoroutinesInfo(dbp *Target, start, count int) ([]*G, int, error) {
 			return dbp.gcache.allGCache, -1, nil
 		}
 	}
+	if dbp.BinInfo().isTinyGo() {
+		return tinyGoGoroutinesInfo(dbp, start, count)
+	}
 
 	var (
 		threadg = map[int]*G{}
@@ -339,7 +376,7 @@ func GoroutinesInfo(dbp *Target, start, count int) ([]*G, int, error) {
 			continue
 		}
 		if thg, allocated := threadg[g.ID]; allocated {
-			loc, err := thg.Thread.Location()
+			loc, err := LogicalLocation(thg.Thread)
 			if err != nil {
 				return nil, -1, err
 			}
@@ -522,6 +559,50 @@ func (g *G) StartLoc() Location {
 	return Location{PC: g.StartPC, File: f, Line: l, Fn: fn}
 }
 
+// System returns true if g is a system goroutine, a goroutine started by
+// the runtime for its own purposes, see isSystemGoroutine in
+// $GOROOT/src/runtime/traceback.go.
+func (g *G) System() bool {
+	loc := g.StartLoc()
+	if loc.Fn == nil {
+		return false
+	}
+	switch loc.Fn.Name {
+	case "runtime.main", "runtime.handleAsyncEvent":
+		return false
+	}
+	return strings.HasPrefix(loc.Fn.Name, "runtime.")
+}
+
+// CgoCall returns the name of the C function called by the goroutine if
+// it is executing a cgo call, the empty string otherwise.
+// The name is read from the _Cfunc_ wrapper generated by cgo, which is
+// found by unwinding the stack from the registers saved in the G struct by
+// runtime.entersyscall, since the thread running the goroutine, if any, is
+// executing C code.
+func (g *G) CgoCall() string {
+	if g.Status != Gsyscall || g.variable == nil {
+		return ""
+	}
+	gsched := *g
+	gsched.Thread = nil
+	it, err := gsched.stackIterator(StacktraceSimple)
+	if err != nil {
+		return ""
+	}
+	const cfuncPrefix = "._Cfunc_"
+	for count := 0; it.Next() && count < maxGoroutineUserCurrentDepth; count++ {
+		fn := it.Frame().Call.Fn
+		if fn == nil {
+			continue
+		}
+		if i := strings.Index(fn.Name, cfuncPrefix); i >= 0 {
+			return fn.Name[i+len(cfuncPrefix):]
+		}
+	}
+	return ""
+}
+
 func (g *G) Labels() map[string]string {
 	if g.labels != nil {
 		return *g.labels
@@ -1020,7 +1101,7 @@ func (g *G) stkbar() ([]savedLR, error) {
 	if g.stkbarVar == nil { // stack barriers were removed in Go 1.9
 		return nil, nil
 	}
-	g.stkbarVar.loadValue(LoadConfig{false, 1, 0, int(g.stkbarVar.Len), 3, 0})
+	g.stkbarVar.loadValue(LoadConfig{false, 1, 0, int(g.stkbarVar.Len), 3, 0, false, 0})
 	if g.stkbarVar.Unreadable != nil {
 		return nil, fmt.Errorf("unreadable stkbar: %v", g.stkbarVar.Unreadable)
 	}
@@ -1224,13 +1305,7 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		}
 
 	case reflect.Chan:
-		sv := v.clone()
-		sv.RealType = resolveTypedef(&(sv.RealType.(*godwarf.ChanType).TypedefType))
-		sv = sv.maybeDereference()
-		sv.loadValueInternal(0, loadFullValue)
-		v.Children = sv.Children
-		v.Len = sv.Len
-		v.Base = sv.Addr
+		v.loadChanState(recurseLevel, cfg)
 
 	case reflect.Map:
 		if recurseLevel <= cfg.MaxVariableRecurse {
@@ -1263,6 +1338,9 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		v.mem = cacheMemory(v.mem, v.Addr, int(v.RealType.Size()))
 		t := v.RealType.(*godwarf.StructType)
 		v.Len = int64(len(t.Field))
+		if !cfg.DisableWellKnownTypes && recurseLevel <= cfg.MaxVariableRecurse && v.loadDecodedStruct(recurseLevel, cfg) {
+			break
+		}
 		// Recursively call extractValue to grab
 		// the value of all the members of the struct.
 		if recurseLevel <= cfg.MaxVariableRecurse {
@@ -1316,6 +1394,13 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 	default:
 		v.Unreadable = fmt.Errorf("unknown or unsupported kind: \"%s\"", v.Kind.String())
 	}
+
+	if !cfg.DisableWellKnownTypes {
+		switch v.Kind {
+		case reflect.Struct, reflect.Array:
+			v.loadWellKnownValue()
+		}
+	}
 }
 
 // convertToEface converts srcv into an "interface {}" and writes it to
@@ -1436,9 +1521,10 @@ func readCStringValue(mem MemoryReadWriter, addr uint64, cfg LoadConfig) (string
 }
 
 const (
-	sliceArrayFieldName = "array"
-	sliceLenFieldName   = "len"
-	sliceCapFieldName   = "cap"
+	sliceArrayFieldName       = "array"
+	sliceLenFieldName         = "len"
+	sliceCapFieldName         = "cap"
+	tinyGoSliceArrayFieldName = "ptr"
 )
 
 func (v *Variable) loadSliceInfo(t *godwarf.SliceType) {
@@ -1447,7 +1533,7 @@ func (v *Variable) loadSliceInfo(t *godwarf.SliceType) {
 	var err error
 	for _, f := range t.Field {
 		switch f.Name {
-		case sliceArrayFieldName:
+		case sliceArrayFieldName, tinyGoSliceArrayFieldName:
 			var base uint64
 			base, err = readUintRaw(v.mem, uint64(int64(v.Addr)+f.ByteOffset), f.Type.Size())
 			if err == nil {
@@ -1553,6 +1639,16 @@ func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 		count = int64(cfg.MaxArrayValues)
 	}
 
+	if cfg.BytesView != 0 && isByteType(v.fieldType) {
+		v.loadBytes(count, cfg.BytesView)
+		return
+	}
+
+	v.loadArrayElements(count, recurseLevel, cfg)
+}
+
+// loadArrayElements loads the first count elements of the slice or array v.
+func (v *Variable) loadArrayElements(count int64, recurseLevel int, cfg LoadConfig) {
 	if v.stride < maxArrayStridePrefetch {
 		v.mem = cacheMemory(v.mem, v.Base, int(v.stride*count))
 	}
@@ -1564,8 +1660,14 @@ func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 		mem = DereferenceMemory(mem)
 	}
 
+	pointeeMem := v.prefetchPointees(mem, count, recurseLevel, cfg)
+
 	for i := int64(0); i < count; i++ {
-		fieldvar := v.newVariable("", uint64(int64(v.Base)+(i*v.stride)), v.fieldType, mem)
+		fieldmem := mem
+		if pointeeMem != nil && pointeeMem[i] != nil {
+			fieldmem = pointeeMem[i]
+		}
+		fieldvar := v.newVariable("", uint64(int64(v.Base)+(i*v.stride)), v.fieldType, fieldmem)
 		fieldvar.loadValueInternal(recurseLevel+1, cfg)
 
 		if fieldvar.Unreadable != nil {
@@ -1579,6 +1681,53 @@ func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 	}
 }
 
+// prefetchPointees reads, with a single batch read, the objects pointed to
+// by the first count elements of v if they are pointers that will be
+// followed when loading v. Returns, for each element, a memory that serves
+// the object it points to from the prefetched data, or nil if nothing was
+// prefetched.
+func (v *Variable) prefetchPointees(mem MemoryReadWriter, count int64, recurseLevel int, cfg LoadConfig) []MemoryReadWriter {
+	if !cacheEnabled || !cfg.FollowPointers || recurseLevel+1 > cfg.MaxVariableRecurse || count < 2 {
+		return nil
+	}
+	if _, isComposite := mem.(*compositeMemory); isComposite {
+		// The elements must be read from a different memory than the one
+		// their pointers point to.
+		return nil
+	}
+	ptrtyp, ok := resolveTypedef(v.fieldType).(*godwarf.PtrType)
+	if !ok {
+		return nil
+	}
+	sz := resolveTypedef(ptrtyp.Type).Size()
+	if sz <= 0 || sz > maxArrayStridePrefetch {
+		return nil
+	}
+
+	reqs := make([]MemoryReadRequest, 0, count)
+	idx := make([]int64, 0, count)
+	for i := int64(0); i < count; i++ {
+		ptrval, err := readUintRaw(mem, uint64(int64(v.Base)+(i*v.stride)), ptrtyp.ByteSize)
+		if err != nil || ptrval == 0 {
+			continue
+		}
+		reqs = append(reqs, MemoryReadRequest{Addr: ptrval, Buf: make([]byte, sz)})
+		idx = append(idx, i)
+	}
+	if len(reqs) < 2 {
+		return nil
+	}
+	readMemoryBatch(mem, reqs)
+
+	r := make([]MemoryReadWriter, count)
+	for j := range reqs {
+		if reqs[j].Err == nil && reqs[j].N == len(reqs[j].Buf) {
+			r[idx[j]] = &memCache{true, reqs[j].Addr, reqs[j].Buf, mem}
+		}
+	}
+	return r
+}
+
 func (v *Variable) readComplex(size int64) {
 	var fs int64
 	switch size {
@@ -1739,7 +1888,7 @@ func (v *Variable) writeEmptyInterface(typeAddr uint64, data *Variable) error {
 func (v *Variable) writeSlice(len, cap int64, base uint64) error {
 	for _, f := range v.RealType.(*godwarf.SliceType).Field {
 		switch f.Name {
-		case sliceArrayFieldName:
+		case sliceArrayFieldName, tinyGoSliceArrayFieldName:
 			arrv, _ := v.toField(f)
 			if err := arrv.writeUint(uint64(base), arrv.RealType.Size()); err != nil {
 				return err
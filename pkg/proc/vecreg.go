@@ -0,0 +1,96 @@
+package proc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// VectorFormat describes how the contents of a vector register should be
+// split into lanes and formatted, see ParseVectorFormat.
+type VectorFormat struct {
+	kind byte // 'i' for signed integers, 'u' for unsigned integers, 'f' for floats
+	size int  // size of a lane in bytes
+}
+
+// ParseVectorFormat parses a vector register format, the format is a
+// lane type followed by the number of lanes of a 128 bit register:
+// i8x16, i16x8, i32x4 and i64x2 for signed integers, u8x16, u16x8, u32x4
+// and u64x2 for unsigned integers and f32x4 and f64x2 for floating point
+// numbers.
+func ParseVectorFormat(s string) (VectorFormat, error) {
+	bad := fmt.Errorf("unknown vector format %q", s)
+	if len(s) < 2 {
+		return VectorFormat{}, bad
+	}
+	var bits, lanes int
+	if _, err := fmt.Sscanf(s[1:], "%dx%d", &bits, &lanes); err != nil || s[1:] != fmt.Sprintf("%dx%d", bits, lanes) {
+		return VectorFormat{}, bad
+	}
+	if bits*lanes != 128 {
+		return VectorFormat{}, bad
+	}
+	switch s[0] {
+	case 'i', 'u':
+		if bits != 8 && bits != 16 && bits != 32 && bits != 64 {
+			return VectorFormat{}, bad
+		}
+	case 'f':
+		if bits != 32 && bits != 64 {
+			return VectorFormat{}, bad
+		}
+	default:
+		return VectorFormat{}, bad
+	}
+	return VectorFormat{kind: s[0], size: bits / 8}, nil
+}
+
+// Format formats the little endian contents of a vector register, lanes
+// are printed starting with the lowest one. Registers larger than 128 bits
+// are formatted as a sequence of 128 bit groups.
+func (vf VectorFormat) Format(b []byte) string {
+	var out bytes.Buffer
+	out.WriteString("{")
+	for i := 0; i+vf.size <= len(b); i += vf.size {
+		if i > 0 && i%16 == 0 {
+			out.WriteString(" |")
+		}
+		out.WriteString(" ")
+		lane := b[i : i+vf.size]
+		var u uint64
+		switch vf.size {
+		case 1:
+			u = uint64(lane[0])
+		case 2:
+			u = uint64(binary.LittleEndian.Uint16(lane))
+		case 4:
+			u = uint64(binary.LittleEndian.Uint32(lane))
+		case 8:
+			u = binary.LittleEndian.Uint64(lane)
+		}
+		switch vf.kind {
+		case 'u':
+			out.WriteString(strconv.FormatUint(u, 10))
+		case 'i':
+			// sign extend the lane
+			shift := uint(64 - 8*vf.size)
+			out.WriteString(strconv.FormatInt(int64(u<<shift)>>shift, 10))
+		case 'f':
+			if vf.size == 4 {
+				out.WriteString(strconv.FormatFloat(float64(math.Float32frombits(uint32(u))), 'g', -1, 32))
+			} else {
+				out.WriteString(strconv.FormatFloat(math.Float64frombits(u), 'g', -1, 64))
+			}
+		}
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+// IsVectorRegister returns true if name is the name of a SIMD register
+// of the architecture.
+func (a *Arch) IsVectorRegister(name string) bool {
+	return a.isVectorRegister != nil && a.isVectorRegister(name)
+}
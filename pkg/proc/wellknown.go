@@ -0,0 +1,258 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// maxBigIntWords is the maximum number of words of a math/big.Int that
+// will be read to produce its well known value.
+const maxBigIntWords = 64
+
+// loadWellKnownValue sets v.WellKnownValue for variables of some
+// frequently inspected types whose internal representation is hard to
+// read: time.Time, math/big.Int, net/netip.Addr and UUIDs (any [16]byte
+// type called UUID in a package called uuid).
+func (v *Variable) loadWellKnownValue() {
+	if v.Unreadable != nil || v.DwarfType == nil {
+		return
+	}
+	var s string
+	var err error
+	switch typename := v.DwarfType.Common().Name; typename {
+	case "time.Time":
+		s, err = v.wellKnownTime()
+	case "math/big.Int":
+		s, err = v.wellKnownBigInt()
+	case "net/netip.Addr":
+		s, err = v.wellKnownNetipAddr()
+	default:
+		if typename == "uuid.UUID" || strings.HasSuffix(typename, "/uuid.UUID") {
+			s, err = v.wellKnownUUID()
+		}
+	}
+	if err == nil {
+		v.WellKnownValue = s
+	}
+}
+
+// wellKnownField returns the value of the integer, boolean or pointer
+// field name of struct variable v.
+func (v *Variable) wellKnownField(name string) (constant.Value, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.Kind == reflect.Ptr || f.Kind == reflect.UnsafePointer {
+		addr, err := readUintRaw(f.mem, f.Addr, int64(f.bi.Arch.PtrSize()))
+		return constant.MakeUint64(addr), err
+	}
+	f.loadValue(loadSingleValue)
+	if f.Unreadable != nil {
+		return nil, f.Unreadable
+	}
+	if f.Value == nil {
+		return nil, fmt.Errorf("field %s of %s is not a scalar", name, v.DwarfType.Common().Name)
+	}
+	return f.Value, nil
+}
+
+func (v *Variable) wellKnownTime() (string, error) {
+	const (
+		secondsPerDay        = 86400
+		wallToInternal int64 = (1884*365 + 1884/4 - 1884/100 + 1884/400) * secondsPerDay
+		unixToInternal int64 = (1969*365 + 1969/4 - 1969/100 + 1969/400) * secondsPerDay
+		hasMonotonic         = 1 << 63
+		nsecMask             = 1<<30 - 1
+		nsecShift            = 30
+	)
+	wallv, err := v.wellKnownField("wall")
+	if err != nil {
+		return "", err
+	}
+	extv, err := v.wellKnownField("ext")
+	if err != nil {
+		return "", err
+	}
+	wall, _ := constant.Uint64Val(wallv)
+	ext, _ := constant.Int64Val(extv)
+
+	var sec int64
+	if wall&hasMonotonic != 0 {
+		sec = wallToInternal + int64(wall<<1>>(nsecShift+1))
+	} else {
+		sec = ext
+	}
+	unix := sec - unixToInternal
+	t := time.Unix(unix, int64(wall&nsecMask)).UTC()
+
+	// Use the zone cached in the location, if it applies to t, the time is
+	// displayed in UTC otherwise.
+	loc, err := v.structMember("loc")
+	if err != nil {
+		return "", err
+	}
+	if loc = loc.maybeDereference(); loc.Addr != 0 && loc.Unreadable == nil {
+		cacheStart, err1 := loc.wellKnownField("cacheStart")
+		cacheEnd, err2 := loc.wellKnownField("cacheEnd")
+		zone, err3 := loc.structMember("cacheZone")
+		if err1 == nil && err2 == nil && err3 == nil {
+			start, _ := constant.Int64Val(cacheStart)
+			end, _ := constant.Int64Val(cacheEnd)
+			if zone = zone.maybeDereference(); zone.Addr != 0 && start <= unix && unix < end {
+				offset, err1 := zone.wellKnownField("offset")
+				name, err2 := zone.structMember("name")
+				if err1 == nil && err2 == nil {
+					name.loadValue(loadSingleValue)
+					off, _ := constant.Int64Val(offset)
+					if name.Unreadable == nil {
+						t = t.In(time.FixedZone(constant.StringVal(name.Value), int(off)))
+					}
+				}
+			}
+		}
+	}
+
+	return t.Format(time.RFC3339Nano), nil
+}
+
+func (v *Variable) wellKnownBigInt() (string, error) {
+	negv, err := v.wellKnownField("neg")
+	if err != nil {
+		return "", err
+	}
+	abs, err := v.structMember("abs")
+	if err != nil {
+		return "", err
+	}
+	if abs.Kind != reflect.Slice {
+		return "", errors.New("malformed math/big.Int")
+	}
+	if abs.Len > maxBigIntWords {
+		return "", errors.New("too large")
+	}
+	abs.loadValue(LoadConfig{false, 0, 0, maxBigIntWords, 0, 0, true, 0})
+	if abs.Unreadable != nil {
+		return "", abs.Unreadable
+	}
+	wordBits := uint(8 * v.bi.Arch.PtrSize())
+	n := new(big.Int)
+	for i := len(abs.Children) - 1; i >= 0; i-- {
+		if abs.Children[i].Unreadable != nil {
+			return "", abs.Children[i].Unreadable
+		}
+		w, _ := constant.Uint64Val(abs.Children[i].Value)
+		n.Lsh(n, wordBits)
+		n.Or(n, new(big.Int).SetUint64(w))
+	}
+	if constant.BoolVal(negv) {
+		n.Neg(n)
+	}
+	return n.String(), nil
+}
+
+func (v *Variable) wellKnownNetipAddr() (string, error) {
+	addr, err := v.structMember("addr")
+	if err != nil {
+		return "", err
+	}
+	hiv, err := addr.wellKnownField("hi")
+	if err != nil {
+		return "", err
+	}
+	lov, err := addr.wellKnownField("lo")
+	if err != nil {
+		return "", err
+	}
+	hi, _ := constant.Uint64Val(hiv)
+	lo, _ := constant.Uint64Val(lov)
+
+	z, err := v.structMember("z")
+	if err != nil {
+		return "", err
+	}
+	var isV6 bool
+	var zone string
+	if _, ok := z.RealType.(*godwarf.StructType); ok {
+		// Go 1.23 and later, z is a unique.Handle[addrDetail] and z0 is the
+		// zero handle.
+		p, err := z.structMember("value")
+		if err != nil {
+			return "", err
+		}
+		detail := p.maybeDereference()
+		if detail.Addr == 0 {
+			return "invalid IP", nil
+		}
+		isV6v, err := detail.wellKnownField("isV6")
+		if err != nil {
+			return "", err
+		}
+		isV6 = constant.BoolVal(isV6v)
+		zonev, err := detail.structMember("zoneV6")
+		if err != nil {
+			return "", err
+		}
+		zonev.loadValue(loadSingleValue)
+		if zonev.Unreadable != nil {
+			return "", zonev.Unreadable
+		}
+		zone = constant.StringVal(zonev.Value)
+	} else {
+		// Before Go 1.23 z is a *intern.Value, equal to z0 (nil), z4 or
+		// z6noz, or an interned zone name for IPv6 addresses with a zone
+		// (which is not displayed).
+		zaddr, err := readUintRaw(z.mem, z.Addr, int64(v.bi.Arch.PtrSize()))
+		if err != nil {
+			return "", err
+		}
+		if zaddr == 0 {
+			return "invalid IP", nil
+		}
+		isV6 = true
+		for _, pkgvar := range v.bi.packageVars {
+			if pkgvar.name != "net/netip.z4" {
+				continue
+			}
+			z4, err := readUintRaw(v.mem, pkgvar.addr, int64(v.bi.Arch.PtrSize()))
+			if err != nil {
+				return "", err
+			}
+			isV6 = zaddr != z4
+			break
+		}
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	for i := 0; i < 8; i++ {
+		ip[i] = byte(hi >> uint(56-8*i))
+		ip[i+8] = byte(lo >> uint(56-8*i))
+	}
+	if !isV6 {
+		return ip[12:].String(), nil
+	}
+	if zone != "" {
+		return ip.String() + "%" + zone, nil
+	}
+	return ip.String(), nil
+}
+
+func (v *Variable) wellKnownUUID() (string, error) {
+	t, ok := v.RealType.(*godwarf.ArrayType)
+	if !ok || t.Count != 16 || t.Type.Size() != 1 {
+		return "", errors.New("not a UUID")
+	}
+	buf := make([]byte, 16)
+	if _, err := v.mem.ReadMemory(buf, v.Addr); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:]), nil
+}
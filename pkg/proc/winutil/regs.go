@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/arch/x86/x86asm"
@@ -322,6 +323,57 @@ func (r *AMD64Registers) Get(n int) (uint64, error) {
 	return 0, proc.ErrUnknownRegister
 }
 
+// SetReg changes the value of the register called name (as returned by
+// Slice) in r and in r.Context, it does not change the registers of the
+// thread.
+func (r *AMD64Registers) SetReg(name string, value uint64) error {
+	var p, cp *uint64
+	switch strings.ToLower(name) {
+	case "rip":
+		p, cp = &r.rip, &r.Context.Rip
+	case "rsp":
+		p, cp = &r.rsp, &r.Context.Rsp
+	case "rax":
+		p, cp = &r.rax, &r.Context.Rax
+	case "rbx":
+		p, cp = &r.rbx, &r.Context.Rbx
+	case "rcx":
+		p, cp = &r.rcx, &r.Context.Rcx
+	case "rdx":
+		p, cp = &r.rdx, &r.Context.Rdx
+	case "rdi":
+		p, cp = &r.rdi, &r.Context.Rdi
+	case "rsi":
+		p, cp = &r.rsi, &r.Context.Rsi
+	case "rbp":
+		p, cp = &r.rbp, &r.Context.Rbp
+	case "r8":
+		p, cp = &r.r8, &r.Context.R8
+	case "r9":
+		p, cp = &r.r9, &r.Context.R9
+	case "r10":
+		p, cp = &r.r10, &r.Context.R10
+	case "r11":
+		p, cp = &r.r11, &r.Context.R11
+	case "r12":
+		p, cp = &r.r12, &r.Context.R12
+	case "r13":
+		p, cp = &r.r13, &r.Context.R13
+	case "r14":
+		p, cp = &r.r14, &r.Context.R14
+	case "r15":
+		p, cp = &r.r15, &r.Context.R15
+	case "rflags":
+		r.eflags = value
+		r.Context.EFlags = uint32(value)
+		return nil
+	default:
+		return proc.ErrUnknownRegister
+	}
+	*p, *cp = value, value
+	return nil
+}
+
 // Copy returns a copy of these registers that is guaranteed not to change.
 func (r *AMD64Registers) Copy() (proc.Registers, error) {
 	var rr AMD64Registers
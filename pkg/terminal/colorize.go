@@ -0,0 +1,124 @@
+package terminal
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+)
+
+// sourceStyle is the style of a token of a source listing.
+type sourceStyle uint8
+
+const (
+	normalStyle sourceStyle = iota
+	keywordStyle
+	stringStyle
+	numberStyle
+	commentStyle
+)
+
+// colorizeSource returns the lines of the Go source code src, with the
+// escape sequences in styles inserted around keywords, literals and
+// comments. Every line ends with the reset escape sequence if it contains
+// a styled token, tokens spanning multiple lines (comments and raw
+// strings) are styled again at the start of each line.
+func colorizeSource(src []byte, styles map[sourceStyle]string) []string {
+	type span struct {
+		start, end int
+		style      sourceStyle
+	}
+
+	fset := token.NewFileSet()
+	f := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(f, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var spans []span
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		style := tokenStyle(tok)
+		if style == normalStyle || styles[style] == "" {
+			continue
+		}
+		start := f.Offset(pos)
+		// The literal of raw strings and comments does not contain carriage
+		// returns, find their end in src.
+		end := start + len(lit)
+		switch {
+		case bytes.HasPrefix(src[start:], []byte("`")):
+			end = closingIndex(src, start+1, "`")
+		case bytes.HasPrefix(src[start:], []byte("/*")):
+			end = closingIndex(src, start+2, "*/")
+		case bytes.HasPrefix(src[start:], []byte("//")):
+			end = start + bytes.IndexByte(append(src[start:len(src):len(src)], '\n'), '\n')
+		}
+		spans = append(spans, span{start, end, style})
+	}
+
+	var r []string
+	var buf bytes.Buffer
+	cur, next := -1, 0
+	for i := 0; i <= len(src); i++ {
+		if cur >= 0 && i >= spans[cur].end {
+			buf.WriteString(terminalResetEscapeCode)
+			cur = -1
+		}
+		if cur < 0 && next < len(spans) && spans[next].start == i {
+			cur = next
+			next++
+			buf.WriteString(styles[spans[cur].style])
+		}
+		if i == len(src) {
+			break
+		}
+		switch {
+		case src[i] == '\r' && i+1 < len(src) && src[i+1] == '\n':
+			// dropped, like bufio.ScanLines does
+		case src[i] == '\n':
+			if cur >= 0 {
+				buf.WriteString(terminalResetEscapeCode)
+			}
+			r = append(r, buf.String())
+			buf.Reset()
+			if cur >= 0 && i+1 < spans[cur].end {
+				buf.WriteString(styles[spans[cur].style])
+			}
+		default:
+			buf.WriteByte(src[i])
+		}
+	}
+	if buf.Len() > 0 {
+		r = append(r, buf.String())
+	}
+	return r
+}
+
+// closingIndex returns the offset of the end of the first occurrence of
+// delim in src after start, or len(src).
+func closingIndex(src []byte, start int, delim string) int {
+	if start > len(src) {
+		return len(src)
+	}
+	i := bytes.Index(src[start:], []byte(delim))
+	if i < 0 {
+		return len(src)
+	}
+	return start + i + len(delim)
+}
+
+func tokenStyle(tok token.Token) sourceStyle {
+	switch {
+	case tok.IsKeyword():
+		return keywordStyle
+	case tok == token.STRING || tok == token.CHAR:
+		return stringStyle
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return numberStyle
+	case tok == token.COMMENT:
+		return commentStyle
+	}
+	return normalStyle
+}
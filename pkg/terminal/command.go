@@ -5,11 +5,15 @@ package terminal
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/parser"
 	"go/scanner"
+	"go/token"
 	"io"
+	"io/ioutil"
 	"math"
 	"os"
 	"os/exec"
@@ -23,6 +27,7 @@ import (
 	"time"
 
 	"github.com/cosiner/argv"
+	"github.com/go-delve/delve/pkg/config"
 	"github.com/go-delve/delve/pkg/locspec"
 	"github.com/go-delve/delve/service"
 	"github.com/go-delve/delve/service/api"
@@ -44,6 +49,9 @@ type callContext struct {
 	Prefix     cmdPrefix
 	Scope      api.EvalScope
 	Breakpoint *api.Breakpoint
+	// Format is the format letter written after the name of the command, as
+	// in 'display/x', see displayFormats.
+	Format string
 }
 
 func (ctx *callContext) scoped() bool {
@@ -65,6 +73,8 @@ type command struct {
 	builtinAliases  []string
 	group           commandGroup
 	allowedPrefixes cmdPrefix
+	allowFormat     bool
+	userDefined     bool
 	helpMsg         string
 	cmdFn           cmdfunc
 }
@@ -135,11 +145,13 @@ For recorded targets the command takes the following forms:
 
 	restart					resets ot the start of the recording
 	restart [checkpoint]			resets the recording to the given checkpoint
+	restart [event]				resets the recording to the given rr event number
 	restart -r [newargv...]	[redirects...]	re-records the target process
 	
 For live targets the command takes the following forms:
 
 	restart [newargv...] [redirects...]	restarts the process
+	restart [checkpoint]			restores the process to the given checkpoint (see 'help checkpoint')
 
 If newargv is omitted the process is restarted (or re-recorded) with the same argument vector.
 If -noargs is specified instead, the argument vector is cleared.
@@ -152,19 +164,27 @@ A list of file redirections can be specified after the new argument list to over
 `},
 		{aliases: []string{"rebuild"}, group: runCmds, cmdFn: c.rebuild, allowedPrefixes: revPrefix, helpMsg: "Rebuild the target executable and restarts it. It does not work if the executable was not built by delve."},
 		{aliases: []string{"continue", "c"}, group: runCmds, cmdFn: c.cont, allowedPrefixes: revPrefix, helpMsg: "Run until breakpoint or program termination."},
-		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: "Single step through program."},
+		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: `Single step through program.
+
+	step [<function>]
+
+When the current location is the call site of one or more inlined calls, step enters the outermost one without resuming the program. If a function is specified the inlined call to that function, and all the inlined calls containing it, are entered instead.`},
 		{aliases: []string{"step-instruction", "si"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepInstruction, helpMsg: "Single step a single cpu instruction."},
 		{aliases: []string{"next", "n"}, group: runCmds, cmdFn: c.next, allowedPrefixes: revPrefix, helpMsg: `Step over to next source line.
 
 	 next [count]
 
 Optional [count] argument allows you to skip multiple lines.
+
+If delve was started with --stop-at-inlined-calls next also stops at the beginning of inlined calls, showing their call site as the current location, use step to enter them.
 `},
 		{aliases: []string{"stepout", "so"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepout, helpMsg: "Step out of the current function."},
 		{aliases: []string{"call"}, group: runCmds, cmdFn: c.call, helpMsg: `Resumes process, injecting a function call (EXPERIMENTAL!!!)
 	
-	call [-unsafe] <function call expression>
+	call [-unsafe] [-timeout <duration>] <function call expression>
 	
+If the call does not return within the specified duration, for example 5s, the target is stopped and the call is cancelled: the goroutine continues from where it was when the call started once the called function returns, and its return values are discarded.
+
 Current limitations:
 - only pointers to stack-allocated objects can be passed as argument.
 - only some automatic type conversions are supported.
@@ -176,6 +196,46 @@ Current limitations:
   point.
 - calling a function will resume execution of all goroutines.
 - only supported on linux's native backend.
+`},
+		{aliases: []string{"rawcall"}, group: runCmds, cmdFn: rawCall, helpMsg: `Calls a function or executes a system call without any type checking (UNSAFE!!!)
+
+	rawcall -unsafe <function name or address> [<arg1> ... <argN>]
+	rawcall -unsafe -syscall <system call number> [<arg1> ... <argN>]
+
+Arguments are integers, they are passed to the function in the registers and stack slots prescribed by the C calling convention of the target platform (or by its system call convention). The values of the return registers (RAX and RDX on amd64, X0 and X1 on arm64) are printed after the call returns, for system calls the error number is also printed.
+
+This is meant for functions that can not be called with the call command (C functions, runtime internals) and is only supported on amd64 and arm64. The call runs on the current thread, below its stack pointer, and resumes execution of all goroutines, breakpoints hit while the call runs are ignored. A raw call can easily corrupt or crash the target process, the -unsafe flag must always be specified. System calls are not supported on windows.
+`},
+		{aliases: []string{"patch"}, group: dataCmds, cmdFn: patch, helpMsg: `Replaces the code of a function.
+
+	patch <function> return [<value1> ... <valueN>]
+	patch <function> code <hex bytes>
+	patch
+
+The first form changes the function so that it immediately returns the specified values, one for each of its results, without executing its body. Values can be integers, true, false, nil, addresses (for pointer, func, map and chan results) and quoted strings, interfaces and slices can only be nil.
+
+The second form copies the specified machine code to memory allocated in the target process and changes the entry point of the function to jump to it, the code must be position independent and follow the calling convention of the function.
+
+Without arguments the list of applied patches is printed. Patched functions can be restored with the unpatch command. Allocating memory for a patch resumes the current thread, see rawcall. Only supported on amd64 and arm64.
+`},
+		{aliases: []string{"unpatch"}, group: dataCmds, cmdFn: unpatch, helpMsg: `Restores the code of a function changed by the patch command.
+
+	unpatch <patch id>
+	unpatch -all
+`},
+		{aliases: []string{"guard"}, group: dataCmds, cmdFn: guard, helpMsg: `Changes the protection of a range of memory to catch accesses to it.
+
+	guard [-none] <address> <size>
+	guard
+
+Makes the pages containing the specified memory range read-only (or, with -none, inaccessible). When the target process accesses the range in a way that is not allowed it stops, reporting the thread and the instruction that made the access, instead of receiving a SIGSEGV. The faulting instruction is executed again, and faults again, every time the target is resumed until the guard is removed with the unguard command.
+
+Memory written by the kernel during a system call does not fault, the system call fails instead. Changing the protection resumes the current thread to call mprotect, see rawcall. Without arguments the list of memory guards is printed. Only supported by the native backend on linux.
+`},
+		{aliases: []string{"unguard"}, group: dataCmds, cmdFn: unguard, helpMsg: `Restores the protection of memory changed by the guard command.
+
+	unguard <guard id>
+	unguard -all
 `},
 		{aliases: []string{"threads"}, group: goroutineCmds, cmdFn: threads, helpMsg: "Print out info for every traced thread."},
 		{aliases: []string{"thread", "tr"}, group: goroutineCmds, cmdFn: thread, helpMsg: `Switch to the specified thread.
@@ -191,7 +251,8 @@ Current limitations:
 If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted.`},
 		{aliases: []string{"goroutines", "grs"}, group: goroutineCmds, cmdFn: goroutines, helpMsg: `List program goroutines.
 
-	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)|-s (start location)] [-t (stack trace)] [-l (labels)]
+	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)|-s (start location)] [-t (stack trace)] [-l (labels)] [-tree]
+	goroutines [flags] [-with|-without (curloc|userloc|goloc|startloc) <substring>] [-with|-without label <key>[=<value>]] [-with|-without (running|user)] [-group (curloc|userloc|goloc|startloc|running|user|label <key>)] [-filter <expression>]
 
 Print out info for every goroutine. The flag controls what information is shown along with each goroutine:
 
@@ -201,8 +262,26 @@ Print out info for every goroutine. The flag controls what information is shown
 	-s	displays location of the start function
 	-t	displays goroutine's stacktrace
 	-l	displays goroutine's labels
+	-tree	displays goroutines as a tree, each goroutine below the goroutine that created it
+
+If no flag is specified the default is -u.
+
+The -with and -without options, which can be specified multiple times, only list the goroutines that have, or do not have, the specified property:
+
+	curloc, userloc, goloc, startloc	the runtime, user, go statement or start location contains the specified substring, locations are formatted as "file:line in function"
+	label	the goroutine has the specified label, or the label has the specified value
+	running	the goroutine is running on a thread
+	user	the goroutine is a user goroutine, not a goroutine started by the runtime
 
-If no flag is specified the default is -u.`},
+The -group option divides the goroutines in groups by the value of the specified property and prints the first 5 goroutines of each group, for example:
+
+	goroutines -with user -group userloc
+
+The -filter option, which must be the last one, only lists the goroutines for which the rest of the command line, a filter expression, is true. Filter expressions use the syntax of Go expressions and can refer to the properties id, curloc, userloc, goloc, startloc, fn (the function of userloc), pkg (the package of fn), label["key"], running, user, waitreason and waitfor (for how long the goroutine has been blocked, only known for goroutines that were blocked when the last garbage collection started), and call the functions contains, hasprefix, hassuffix and matches (regular expression match). Strings compared with waitfor are parsed as durations, for example:
+
+	goroutines -filter pkg == "example.com/mypkg" && waitreason == "chan receive" && waitfor > "1m"
+
+The -tree flag requires the program to be run with GODEBUG=tracebackancestors=N, where N is the number of ancestors recorded for each goroutine. Ancestors that have exited are also displayed. With -t the stack of the parent goroutine at the time it created each goroutine is displayed, instead of the current stack trace of the goroutine.`},
 		{aliases: []string{"goroutine", "gr"}, group: goroutineCmds, allowedPrefixes: onPrefix, cmdFn: c.goroutine, helpMsg: `Shows or changes current goroutine
 
 	goroutine
@@ -215,12 +294,91 @@ Called with more arguments it will execute a command on the specified goroutine.
 		{aliases: []string{"breakpoints", "bp"}, group: breakCmds, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
 		{aliases: []string{"print", "p"}, group: dataCmds, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print <expression>
+	[goroutine <n>] [frame <m>] print [-stringer] <expression>
 
-See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions.`},
+See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions.
+
+With -stringer, if the value of the expression implements error or fmt.Stringer, its Error or String method is called, by injecting a function call in the target like the 'call' command does, and the result is printed instead of the value. The call is limited to 1 second and to 1MB of memory allocated by the target. If the call is not possible (for example because the target is a core file) or fails the value is printed as usual, followed by the reason. Calls can only be made in the topmost frame of a running goroutine.`},
 		{aliases: []string{"whatis"}, group: dataCmds, cmdFn: whatisCommand, helpMsg: `Prints type of an expression.
 
 	whatis <expression>`},
+		{aliases: []string{"search"}, group: dataCmds, cmdFn: searchVariable, helpMsg: `Searches the values reachable from an expression.
+
+	[goroutine <n>] [frame <m>] search [-depth <n>] <value> <expression>
+
+Evaluates <expression> and searches the values reachable from it, following pointers, interfaces, slices and maps, for strings, numbers and booleans equal to <value>, then prints the expressions that can be used to access them. Strings must be quoted:
+
+	search "abc123" req
+	search 42 cache.entries
+
+The -depth option sets the maximum depth of the search (default 10).`},
+		{aliases: []string{"dump-var"}, group: dataCmds, cmdFn: dumpVariable, helpMsg: `Exports the value of an expression as JSON or as a Go composite literal.
+
+	[goroutine <n>] [frame <m>] dump-var [-format json|go] [-depth <n>] <expression> [> <output file>]
+
+Evaluates <expression> and serializes its value. If an output file is specified the value is written to it, otherwise it is printed.
+
+The format is chosen with the -format option, if it isn't specified files ending in .go are written as Go composite literals and everything else as JSON. The -depth option sets how deep nested values will be loaded (default 10), values past this depth, like other values that could not be loaded, are written as null in JSON and annotated with a comment in Go.`},
+		{aliases: []string{"objgraph"}, group: dataCmds, cmdFn: objectGraph, helpMsg: `Exports the graph of the objects reachable from one or more variables in graphviz DOT format.
+
+	[goroutine <n>] [frame <m>] objgraph [-depth <n>] <expression>... [> <output file>]
+
+Evaluates the expressions, separated by spaces, and walks the objects reachable from their values through pointers, slices, maps and interfaces. Every object is a node of the graph, labeled with its type, address and size, and every pointer between two objects is an edge labeled with the path of the pointer inside the object that contains it. Objects reachable from more than one place appear only once, which makes it easy to spot shared and cyclic structures.
+
+The -depth option sets the maximum number of pointers followed from the roots (default 10). At most 100 elements of every array, slice or map are scanned.
+
+If an output file is specified the graph is written to it, otherwise it is printed. The output can be rendered with, for example:
+
+	dot -Tsvg -o graph.svg graph.dot`},
+		{aliases: []string{"view"}, group: dataCmds, cmdFn: viewAs, helpMsg: `Interprets the memory at an address as a value of the specified type.
+
+	[goroutine <n>] [frame <m>] view <expression> as <type>
+
+Evaluates <expression>, which must be a pointer, an unsafe.Pointer or an integer, and prints the value of type <type> stored at the address it evaluates to. If <type> is a pointer type the address is printed as a pointer of that type instead. This is useful to inspect memory obtained through unsafe code or foreign allocators:
+
+	view ptr as main.Header
+	view 0xc000012345 as *main.Header
+
+<type> can be any type known to the target program, types can also be specified with their full package path (for example *github.com/org/pkg.T).`},
+		{aliases: []string{"chan"}, group: dataCmds, cmdFn: chanWaiters, helpMsg: `Lists the goroutines blocked on a channel.
+
+	[goroutine <n>] [frame <m>] chan <expression>
+
+Evaluates <expression>, which must be a channel, and lists the goroutines waiting to receive from it and the goroutines waiting to send to it. Goroutines blocked in a select statement are listed for every channel they are waiting on.`},
+		{aliases: []string{"mutex"}, group: dataCmds, cmdFn: mutexInfo, helpMsg: `Prints the state of a mutex.
+
+	[goroutine <n>] [frame <m>] mutex <expression>
+
+Evaluates <expression>, which must be a sync.Mutex or a sync.RWMutex, prints whether it is locked and lists the goroutines blocked on it. For a sync.RWMutex the number of readers holding the lock is also printed. The Go runtime does not record which goroutine holds a lock, goroutines that acquired it can not be listed.`},
+		{aliases: []string{"ctxchain"}, group: dataCmds, cmdFn: contextChain, helpMsg: `Prints the chain of contexts wrapped by a context.
+
+	[goroutine <n>] [frame <m>] ctxchain <expression>
+
+Evaluates <expression>, which must be a context.Context, and prints every context it wraps, starting with its value and ending with the root context. For each context its type and address are printed, as well as the key and value stored by contexts created with context.WithValue, the deadline of contexts created with context.WithDeadline or context.WithTimeout and, if the context was canceled, the error returned by its Err method.
+
+Contexts of types not defined by the context package are only followed if they embed a context.Context.`},
+		{aliases: []string{"itab"}, group: dataCmds, cmdFn: interfaceItab, helpMsg: `Prints the itab of an interface.
+
+	[goroutine <n>] [frame <m>] itab <expression>
+
+Evaluates <expression>, which must be an interface, and prints its interface type, the concrete type of the value it stores and its method table, with every method resolved to the function that implements it. This shows which implementation is called when a method of the interface is invoked.
+
+Empty interfaces (interface{} and any) do not have an itab, only their concrete type is printed.`},
+		{aliases: []string{"referrers"}, group: dataCmds, cmdFn: referrers, helpMsg: `Prints the objects containing pointers to an object.
+
+	[goroutine <n>] [frame <m>] referrers <address>
+	[goroutine <n>] [frame <m>] referrers <expression>
+
+Scans the heap, the package variables and the stacks of all goroutines for pointers to the heap object containing <address> and prints every object containing one, with the offset of the pointer inside it. If <expression> evaluates to a pointer the object it points to is searched, otherwise the object containing the value of <expression>.
+
+Memory is scanned conservatively, any word with the value of an address inside the object is reported, whether it is a pointer or not. The type and the field containing the pointer are printed for package variables and, for programs built with Go 1.22 or later, for heap objects larger than 512 bytes.`},
+		{aliases: []string{"heaphist"}, group: dataCmds, cmdFn: heapHistogram, helpMsg: `Prints a histogram of the objects in the heap.
+
+	heaphist [<n>]
+
+Walks the spans of the heap and prints the number of allocated objects of each type and the memory they use, starting with the types using the most memory. Only the first <n> types are printed (default 20), use 0 to print all of them. Works with both live processes and core files.
+
+The type of heap objects is only known for objects larger than 512 bytes allocated by programs built with Go 1.22 or later, other objects are grouped by size class and reported as '<unknown, N bytes>', objects that can not contain pointers are reported as '<unknown noscan, N bytes>'. Objects that are unreachable but have not been swept yet are counted as allocated.`},
 		{aliases: []string{"set"}, group: dataCmds, cmdFn: setVar, helpMsg: `Changes the value of a variable.
 
 	[goroutine <n>] [frame <m>] set <variable> = <value>
@@ -245,24 +403,34 @@ If regex is specified only the types matching it will be returned.`},
 
 	[goroutine <n>] [frame <m>] args [-v] [<regex>]
 
+The name of arguments whose value changed since the previous stop will be prefixed by an asterisk.
+
 If regex is specified only function arguments with a name matching it will be returned. If -v is specified more information about each function argument will be shown.`},
 		{aliases: []string{"locals"}, allowedPrefixes: onPrefix | deferredPrefix, group: dataCmds, cmdFn: locals, helpMsg: `Print local variables.
 
 	[goroutine <n>] [frame <m>] locals [-v] [<regex>]
 
-The name of variables that are shadowed in the current scope will be shown in parenthesis.
+The name of variables that are shadowed in the current scope will be shown in parenthesis, every instance of a shadowed name is followed by its declaration line and by the lines of the lexical block where it is declared.
+
+The name of variables whose value changed since the previous stop will be prefixed by an asterisk.
 
 If regex is specified only local variables with a name matching it will be returned. If -v is specified more information about each local variable will be shown.`},
 		{aliases: []string{"vars"}, cmdFn: vars, group: dataCmds, helpMsg: `Print package variables.
 
 	vars [-v] [<regex>]
 
+The name of variables whose value changed since the previous stop will be prefixed by an asterisk.
+
 If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable will be shown.`},
 		{aliases: []string{"regs"}, cmdFn: regs, group: dataCmds, helpMsg: `Print contents of CPU registers.
 
-	regs [-a]
+	regs [-a] [-vec <format>]
 
-Argument -a shows more registers.`},
+Argument -a shows more registers.
+
+Argument -vec formats the contents of vector registers (the XMM registers on amd64 and 386, the V registers on arm64) as lanes of the specified type, it implies -a. The format is one of i8x16, i16x8, i32x4, i64x2 for signed integers, u8x16, u16x8, u32x4, u64x2 for unsigned integers and f32x4, f64x2 for floating point numbers. For example:
+
+	regs -vec f32x4`},
 		{aliases: []string{"exit", "quit", "q"}, cmdFn: exitCommand, helpMsg: `Exit the debugger.
 		
 	exit [-c]
@@ -270,16 +438,19 @@ Argument -a shows more registers.`},
 When connected to a headless instance started with the --accept-multiclient, pass -c to resume the execution of the target process before disconnecting.`},
 		{aliases: []string{"list", "ls", "l"}, cmdFn: listCommand, helpMsg: `Show source code.
 
-	[goroutine <n>] [frame <m>] list [<linespec>]
+	[goroutine <n>] [frame <m>] list [-n <count>] [<linespec>]
 
-Show source around current point or provided linespec.
+Show source around current point or provided linespec. The -n option sets the number of lines shown above and below it, the default is the value of source-list-line-count in the configuration.
+
+Go code is shown with syntax highlighting, which can be configured with the source-list-*-color options or disabled with disable-syntax-highlight, and lines where a breakpoint is set are marked with '*'.
 
 For example:
 
 	frame 1 list 69
 	list testvariables.go:10000
 	list main.main:30
-	list 40`},
+	list 40
+	list -n 20 main.main`},
 		{aliases: []string{"stack", "bt"}, allowedPrefixes: onPrefix, group: stackCmds, cmdFn: stackCommand, helpMsg: `Print stack trace.
 
 	[goroutine <n>] [frame <m>] stack [<depth>] [-full] [-offsets] [-defer] [-a <n>] [-adepth <depth>] [-mode <mode>]
@@ -293,6 +464,11 @@ For example:
 			normal	- attempts to automatically switch between cgo frames and go frames
 			simple	- disables automatic switch between cgo and go
 			fromg	- starts from the registers stored in the runtime.g struct
+
+	[goroutine <n>] stack [<depth>] -save <file> [-all]
+	[goroutine <n>] stack [<depth>] -diff <file> [-all]
+
+With -save the stack is saved to <file>, with -diff it is compared with the stack saved in <file>: frames that are only in the saved stack are prefixed by '-', frames that are only in the current stack by '+', followed by the outermost frames common to both. With -all the stacks of all goroutines are saved or compared, matching goroutines by their ID.
 `},
 		{aliases: []string{"frame"},
 			group: stackCmds,
@@ -333,6 +509,15 @@ Move the current frame down by <m>. The second form runs the command on the give
 	deferred <n> <command>
 
 Executes the specified command (print, args, locals) in the context of the n-th deferred call in the current frame.`},
+		{aliases: []string{"branches"}, group: stackCmds, cmdFn: branches, helpMsg: `Print the branches executed by the current thread before it stopped.
+
+	branches on
+	branches off
+	branches [<count>]
+
+The first form starts recording the branches executed by all threads, using Intel Processor Trace, the second form stops recording. The third form prints the last <count> (default 20) branches executed by the current thread, oldest first: the last one leads to the instruction where the thread is stopped.
+
+Only the most recent part of the history of each thread is kept. Only supported by the native backend on linux/amd64, on CPUs that implement Intel Processor Trace.`},
 		{aliases: []string{"source"}, cmdFn: c.sourceCommand, helpMsg: `Executes a file containing a list of delve commands
 
 	source <path>
@@ -373,14 +558,68 @@ Saves the configuration file to disk, overwriting the current configuration file
 Changes the value of a configuration parameter.
 
 	config substitute-path <from> <to>
+	config substitute-path -add <from> <to>
 	config substitute-path <from>
 
 Adds or removes a path substitution rule.
 
+	config substitute-path -list
+	config substitute-path -clear
+
+Lists or removes all the path substitution rules.
+
+	config substitute-path -guess [-apply]
+
+Proposes path substitution rules for the source files of the target that can not be found, by searching the current directory, GOROOT, GOPATH and the module cache for the last part of their path. With -apply the rules are also added.
+
 	config alias <command> <alias>
 	config alias <alias>
 
 Defines <alias> as an alias to <command> or removes an alias.`},
+		{aliases: []string{"def"}, cmdFn: defCommand, helpMsg: `Defines a command.
+
+	def <name> = <command>
+
+Defines the command <name>, which executes <command>.
+
+	def <name>
+	<command>
+	...
+	end
+
+Defines the command <name>, which executes each of the commands that follow, until 'end'.
+
+	def -d <name>
+
+Removes a command defined with def.
+
+	def
+
+Lists the defined commands.
+
+In the definition of a command $1 to $9 are replaced with the corresponding argument of the command, $* with all arguments and the following variables with their value in the selected frame:
+
+	%file		source file
+	%line		line number
+	%pc		program counter
+	%function	function name
+	%goroutine	goroutine ID
+
+For example:
+
+	def bphere = break %file:%line
+	def pp = print -stringer $1
+
+Commands are saved in the configuration file, in the commands section, by 'config -save'.`},
+
+		{aliases: []string{"history"}, cmdFn: historyCommand, helpMsg: `Prints the command history.
+
+	history [-s <string>] [<n>]
+	history -c
+
+Prints the last <n> commands of the history, or all of them, -s only prints commands containing <string>. With -c the history is cleared.
+
+The history is saved across sessions in the file specified by the history-file option of the configuration, commands matching one of the regular expressions of the history-exclude option are not saved. Ctrl-R searches the history while entering a command.`},
 
 		{aliases: []string{"edit", "ed"}, cmdFn: edit, helpMsg: `Open where you are in $DELVE_EDITOR or $EDITOR
 
@@ -389,9 +628,45 @@ Defines <alias> as an alias to <command> or removes an alias.`},
 If locspec is omitted edit will open the current source file in the editor, otherwise it will open the specified location.`},
 		{aliases: []string{"libraries"}, cmdFn: libraries, helpMsg: `List loaded dynamic libraries`},
 
+		{aliases: []string{"handle"}, cmdFn: handleSignal, helpMsg: `Changes how signals received by the target process are handled.
+
+	handle [<signal> [stop|nostop] [print|noprint] [pass|nopass]]
+
+The signal can be specified by name (SIGUSR1 or USR1) or number. The keywords are:
+
+	stop	the target process stops when it receives the signal, implies print
+	nostop	the target process does not stop when it receives the signal
+	print	the user is notified when the signal is received
+	noprint	the user is not notified when the signal is received, implies nostop
+	pass	the signal is delivered to the target process
+	nopass	the signal is discarded
+
+Without keywords the current policy for the signal is printed, without arguments all signals whose handling was changed are listed. By default signals are delivered to the target process without stopping it or notifying the user. The handling of SIGTRAP, SIGSTOP and SIGKILL can not be changed.`},
+
+		{aliases: []string{"timers"}, group: dataCmds, cmdFn: timers, helpMsg: `Lists the timers pending in the target process.
+
+	timers
+
+Lists the pending runtime timers (created, for example, by time.NewTimer, time.NewTicker or time.AfterFunc) sorted by the time at which they will fire. For each timer the P holding it, its address, how long until it fires, its period and the function called when it fires are printed. For timers created by time.AfterFunc the function passed to AfterFunc is printed. The time until a timer fires is approximate since it is computed using the last time the network poller ran.`},
+
+		{aliases: []string{"dump"}, cmdFn: dump, helpMsg: `Creates a core dump from the current process state.
+
+	dump [-selective] [-minidump] [-compress] [-sparse] <output file>
+
+The core dump is always written in ELF, even on systems (windows, macOS) where this is not customary. On every target, including linux/amd64, threads and registers are written as Delve-specific notes, so the core file can only be read back by Delve, with 'dlv core'.
+
+With -selective only the stacks of all threads and goroutines, the global variables of the target and the memory reachable from them are written, producing a much smaller file. Values that are only reachable through pointers hidden from a conservative scan (for example pointers stored as uintptr and modified) may be missing from a selective dump.
+
+With -minidump a Windows minidump is written instead of an ELF core file, using MiniDumpWriteDump. This is only supported by the native backend on windows. A minidump includes all the memory of the target, or only its heap, stacks and data segments when -selective is also specified. Minidumps can be opened with 'dlv core' or with other Windows debuggers.
+
+With -compress the output file is compressed with gzip, 'dlv core' decompresses it automatically.
+
+With -sparse pages of memory that only contain zeroes, for example untouched parts of the heap, are omitted from the core file and read back as zeroes.`},
+
 		{aliases: []string{"examinemem", "x"}, group: dataCmds, cmdFn: examineMemoryCmd, helpMsg: `Examine memory:
 
 	examinemem [-fmt <format>] [-count|-len <count>] [-size <size>] <address>
+	examinemem -t <type> [<count>] <address>
 
 Format represents the data format and the value is one of this list (default hex): bin(binary), oct(octal), dec(decimal), hex(hexadecimal), addr(address).
 Length is the number of bytes (default 1) and must be less than or equal to 1000.
@@ -399,16 +674,77 @@ Address is the memory location of the target to examine. Please note '-len' is d
 
 For example:
 
-    x -fmt hex -count 20 -size 1 0xc00008af38`},
+    x -fmt hex -count 20 -size 1 0xc00008af38
+
+With -t the memory is interpreted as <count> (default 1) consecutive values of type <type> and each of them is printed with its address, struct values are printed with the names of their fields. <type> can be any type known to the target program, also specified with its full package path. For example:
 
-		{aliases: []string{"display"}, group: dataCmds, cmdFn: display, helpMsg: `Print value of an expression every time the program stops.
+    x -t main.Header 4 0xc000123000`},
 
-	display -a <expression>
+		{aliases: []string{"display"}, group: dataCmds, allowFormat: true, cmdFn: display, helpMsg: `Print value of an expression every time the program stops.
+
+	display[/<format>] [-a] <expression>
 	display -d <number>
 
-The '-a' option adds an expression to the list of expression printed every time the program stops. The '-d' option removes the specified expression from the list.
+The '-a' option, or no option at all, adds an expression to the list of expression printed every time the program stops. The '-d' option removes the specified expression from the list, like the undisplay command.
+
+The format, if specified, changes how the integers contained in the value of the expression are printed:
+
+	x	hexadecimal
+	o	octal
+	t	binary
+	d	decimal
+	c	character
+
+For example:
+
+	display/x len(buf)
+
+If display is called without arguments it will print the value of all expression in the list, with their number.`},
+		{aliases: []string{"undisplay"}, group: dataCmds, cmdFn: undisplay, helpMsg: `Removes expressions added with the display command.
+
+	undisplay <number>...`},
+
+		{aliases: []string{"watchexpr"}, group: dataCmds, cmdFn: watchExpr, helpMsg: `Manage watch expressions stored by the debugger.
+
+	watchexpr -a <expression>
+	watchexpr -d <id>
+
+Watch expressions are similar to the expressions added with the display command, but they are stored by the debugger and evaluated in the current frame of the selected goroutine every time the program stops, their values are printed after the current location and marked with (changed) if they are different from the previous stop. Watch expressions are shared by all clients connected to the same headless instance.
+
+The '-a' option adds a watch expression, the '-d' option removes the watch expression with the specified ID. If watchexpr is called without arguments it will print the value of all watch expressions.`},
+
+		{aliases: []string{"session"}, cmdFn: session, helpMsg: `Saves or restores breakpoints, watch expressions and substitute-path rules.
+
+	session save <file>
+	session load <file>
+
+'session save' writes the breakpoints, including their conditions, the watch expressions and the substitute-path rules of the current session to a JSON file. 'session load' restores them, for example after starting a new instance of the program, breakpoints are set using their file and line. The same file can be passed to the --init-state command line option.`},
+
+		{aliases: []string{"hook"}, cmdFn: hook, helpMsg: `Manages scripts run by the server when the target stops or exits.
+
+	hook add [-resume] [-bp <breakpoint>] (stop|exit) <script file>
+	hook list
+	hook remove <id>
+
+'hook add' sends the starlark script in the specified file to the server, which will call its main function, with the event as argument, every time the target stops or exits, even after this client disconnects (see --accept-multiclient). With -bp the script only runs when the target stops at the breakpoint with the specified name or ID, or at any breakpoint if it is '*'. With -resume the target is resumed after the script runs, for example:
 
-If display is called without arguments it will print the value of all expression in the list.`},
+	hook add -bp * -resume stop record.star
+
+Scripts can use all the builtins described in Documentation/cli/starlark.md except dlv_command. 'hook list' shows the hooks, with how many times they ran and the output of their last run.`},
+
+		{aliases: []string{"check", "checkpoint"}, cmdFn: checkpoint, helpMsg: `Creates a checkpoint at the current position.
+
+	checkpoint [note]
+
+The "note" is arbitrary text that can be used to identify the checkpoint, if it is not specified it defaults to the current filename:line position.
+
+Checkpoints of live processes are only supported by the native backend on Linux and require CRIU (https://criu.org) to be installed. The state of the process is saved to a directory, shown by the checkpoints command, that is not deleted when Delve exits and can be restored later with 'dlv restore'.`},
+
+		{aliases: []string{"checkpoints"}, cmdFn: checkpoints, helpMsg: "Print out info for existing checkpoints."},
+
+		{aliases: []string{"clear-checkpoint", "clearcheck"}, cmdFn: clearCheckpoint, helpMsg: `Deletes checkpoint.
+
+	clear-checkpoint <id>`},
 	}
 
 	addrecorded := client == nil
@@ -430,25 +766,14 @@ If display is called without arguments it will print the value of all expression
 				helpMsg: "Run backwards until breakpoint or program termination.",
 			},
 			command{
-				aliases: []string{"check", "checkpoint"},
-				cmdFn:   checkpoint,
-				helpMsg: `Creates a checkpoint at the current position.
-
-	checkpoint [note]
+				aliases: []string{"run-to-event"},
+				group:   runCmds,
+				cmdFn:   runToEvent,
+				helpMsg: `Resets the recording to the start of the given rr event.
 
-The "note" is arbitrary text that can be used to identify the checkpoint, if it is not specified it defaults to the current filename:line position.`,
-			},
-			command{
-				aliases: []string{"checkpoints"},
-				cmdFn:   checkpoints,
-				helpMsg: "Print out info for existing checkpoints.",
-			},
-			command{
-				aliases: []string{"clear-checkpoint", "clearcheck"},
-				cmdFn:   clearCheckpoint,
-				helpMsg: `Deletes checkpoint.
+	run-to-event <event>
 
-	clear-checkpoint <id>`,
+The current event number is displayed every time the target stops.`,
 			},
 			command{
 				aliases: []string{"rev"},
@@ -505,9 +830,24 @@ func (c *Commands) CallWithContext(cmdstr string, t *Term, ctx callContext) erro
 	if len(vals) > 1 {
 		args = strings.TrimSpace(vals[1])
 	}
+	if i := strings.Index(cmdname, "/"); i > 0 {
+		cmdname, ctx.Format = cmdname[:i], cmdname[i+1:]
+		if !c.allowsFormat(cmdname) {
+			return fmt.Errorf("command %s does not accept a format", cmdname)
+		}
+	}
 	return c.Find(cmdname, ctx.Prefix)(t, ctx, args)
 }
 
+func (c *Commands) allowsFormat(cmdstr string) bool {
+	for _, v := range c.cmds {
+		if v.match(cmdstr) {
+			return v.allowFormat
+		}
+	}
+	return false
+}
+
 // Call takes a command to execute.
 func (c *Commands) Call(cmdstr string, t *Term) error {
 	ctx := callContext{Prefix: noPrefix, Scope: api.EvalScope{GoroutineID: -1, Frame: c.frame, DeferredCall: 0}}
@@ -582,6 +922,7 @@ func (c *Commands) help(t *Term, ctx callContext, args string) error {
 
 	fmt.Println()
 	fmt.Println("Type help followed by a command for full documentation.")
+	fmt.Println("The output of a command can be filtered with '|> grep <regexp>', '|> head <n>', '|> tail <n>' and '|> wc'.")
 	return nil
 }
 
@@ -660,6 +1001,7 @@ type printGoroutinesFlags uint8
 const (
 	printGoroutinesStack printGoroutinesFlags = 1 << iota
 	printGoroutinesLabels
+	printGoroutinesTree
 )
 
 func printGoroutines(t *Term, gs []*api.Goroutine, fgl formatGoroutineLoc, flags printGoroutinesFlags, state *api.DebuggerState) error {
@@ -683,46 +1025,196 @@ func printGoroutines(t *Term, gs []*api.Goroutine, fgl formatGoroutineLoc, flags
 	return nil
 }
 
-func goroutines(t *Term, ctx callContext, argstr string) error {
-	args := strings.Split(argstr, " ")
-	var fgl = fglUserCurrent
-	var flags printGoroutinesFlags
+// printGoroutineTree prints the tree of goroutines, each goroutine is
+// printed below the goroutine that created it.
+func printGoroutineTree(t *Term, fgl formatGoroutineLoc, flags printGoroutinesFlags, state *api.DebuggerState) error {
+	depth := 0
+	if flags&printGoroutinesStack != 0 {
+		depth = 10
+	}
+	nodes, err := t.client.GoroutineTree(depth)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]bool, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = true
+	}
+	children := make(map[int][]api.GoroutineTreeNode)
+	for _, n := range nodes {
+		parent := n.ParentID
+		if !byID[parent] {
+			parent = 0
+		}
+		children[parent] = append(children[parent], n)
+	}
+	var printNode func(n api.GoroutineTreeNode, indent string)
+	printNode = func(n api.GoroutineTreeNode, indent string) {
+		prefix := "  "
+		if state.SelectedGoroutine != nil && n.ID == state.SelectedGoroutine.ID {
+			prefix = "* "
+		}
+		if n.Goroutine != nil {
+			fmt.Printf("%s%sGoroutine %s\n", indent, prefix, t.formatGoroutine(n.Goroutine, fgl))
+		} else {
+			fmt.Printf("%s%sGoroutine %d (exited)\n", indent, prefix, n.ID)
+		}
+		if n.Goroutine != nil && flags&printGoroutinesLabels != 0 {
+			writeGoroutineLabels(os.Stdout, n.Goroutine, indent+"\t")
+		}
+		if flags&printGoroutinesStack != 0 {
+			if n.Unreadable != "" {
+				fmt.Printf("%s\t%s\n", indent, n.Unreadable)
+			} else if len(n.CreationStack) > 0 {
+				fmt.Printf("%s\tcreated by goroutine %d at:\n", indent, n.ParentID)
+				printStack(t, os.Stdout, n.CreationStack, indent+"\t", false)
+			}
+		}
+		for _, child := range children[n.ID] {
+			printNode(child, indent+"    ")
+		}
+	}
+	for _, n := range children[0] {
+		printNode(n, "")
+	}
+	fmt.Printf("[%d goroutines]\n", len(nodes))
+	return nil
+}
 
-	switch len(args) {
-	case 0:
-		// nothing to do
-	case 1, 2:
-		for _, arg := range args {
-			switch arg {
-			case "-u":
-				fgl = fglUserCurrent
-			case "-r":
-				fgl = fglRuntimeCurrent
-			case "-g":
-				fgl = fglGo
-			case "-s":
-				fgl = fglStart
-			case "-t":
-				flags |= printGoroutinesStack
-			case "-l":
-				flags |= printGoroutinesLabels
-			case "":
-				// nothing to do
-			default:
-				return fmt.Errorf("wrong argument: '%s'", arg)
+// goroutineFields are the names of the goroutine fields accepted by the
+// -with, -without and -group options of the goroutines command.
+var goroutineFields = map[string]api.GoroutineField{
+	"curloc":   api.GoroutineCurrentLoc,
+	"userloc":  api.GoroutineUserLoc,
+	"goloc":    api.GoroutineGoLoc,
+	"startloc": api.GoroutineStartLoc,
+	"label":    api.GoroutineLabel,
+	"running":  api.GoroutineRunning,
+	"user":     api.GoroutineUser,
+}
+
+const (
+	// maxGroupMembers is the number of goroutines printed for each group by
+	// 'goroutines -group'.
+	maxGroupMembers = 5
+	// maxGoroutineGroups is the maximum number of groups printed by
+	// 'goroutines -group'.
+	maxGoroutineGroups = 50
+)
+
+func parseGoroutinesArgs(argstr string) (fgl formatGoroutineLoc, flags printGoroutinesFlags, filters []api.ListGoroutinesFilter, group api.GoroutineGroupingOptions, err error) {
+	fgl = fglUserCurrent
+	args := strings.Fields(argstr)
+	// The argument of -filter is the rest of the command line, since
+	// expressions can contain spaces.
+	for rest := strings.TrimSpace(argstr); rest != ""; {
+		var arg string
+		if i := strings.IndexAny(rest, " \t"); i >= 0 {
+			arg, rest = rest[:i], strings.TrimSpace(rest[i:])
+		} else {
+			arg, rest = rest, ""
+		}
+		if arg == "-filter" {
+			if rest == "" {
+				err = errors.New("not enough arguments to -filter")
+				return
 			}
+			args = strings.Fields(strings.TrimSuffix(strings.TrimSpace(argstr), rest))
+			args = args[:len(args)-1]
+			filters = append(filters, api.ListGoroutinesFilter{Kind: api.GoroutineExpr, Arg: rest})
+			break
+		}
+	}
+	// fieldArg parses the argument of a -with, -without or -group option,
+	// location fields and labels take an additional argument.
+	fieldArg := func(i int, opt string) (api.GoroutineField, string, int, error) {
+		if i+1 >= len(args) {
+			return 0, "", i, fmt.Errorf("not enough arguments to %s", opt)
+		}
+		i++
+		kind, ok := goroutineFields[args[i]]
+		if !ok {
+			return 0, "", i, fmt.Errorf("unknown goroutine property %q for %s", args[i], opt)
+		}
+		switch kind {
+		case api.GoroutineRunning, api.GoroutineUser:
+			return kind, "", i, nil
+		}
+		if opt == "-group" && kind != api.GoroutineLabel {
+			return kind, "", i, nil
+		}
+		if i+1 >= len(args) {
+			return 0, "", i, fmt.Errorf("not enough arguments to %s %s", opt, args[i])
+		}
+		i++
+		return kind, args[i], i, nil
+	}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-u":
+			fgl = fglUserCurrent
+		case "-r":
+			fgl = fglRuntimeCurrent
+		case "-g":
+			fgl = fglGo
+		case "-s":
+			fgl = fglStart
+		case "-t":
+			flags |= printGoroutinesStack
+		case "-l":
+			flags |= printGoroutinesLabels
+		case "-tree":
+			flags |= printGoroutinesTree
+		case "-with", "-without":
+			var filter api.ListGoroutinesFilter
+			filter.Kind, filter.Arg, i, err = fieldArg(i, arg)
+			if err != nil {
+				return
+			}
+			filter.Negated = arg == "-without"
+			filters = append(filters, filter)
+		case "-group":
+			if group.GroupBy != api.GoroutineFieldNone {
+				err = errors.New("-group specified more than once")
+				return
+			}
+			group.GroupBy, group.GroupByKey, i, err = fieldArg(i, arg)
+			if err != nil {
+				return
+			}
+			group.MaxGroupMembers = maxGroupMembers
+			group.MaxGroups = maxGoroutineGroups
+		default:
+			err = fmt.Errorf("wrong argument: '%s'", arg)
+			return
 		}
-	default:
-		return fmt.Errorf("too many arguments")
+	}
+	if flags&printGoroutinesTree != 0 && (len(filters) > 0 || group.GroupBy != api.GoroutineFieldNone) {
+		err = errors.New("-tree can not be used with -with, -without, -group or -filter")
+	}
+	return
+}
+
+func goroutines(t *Term, ctx callContext, argstr string) error {
+	fgl, flags, filters, group, err := parseGoroutinesArgs(argstr)
+	if err != nil {
+		return err
 	}
 	state, err := t.client.GetState()
 	if err != nil {
 		return err
 	}
+	if flags&printGoroutinesTree != 0 {
+		return printGoroutineTree(t, fgl, flags, state)
+	}
+	if group.GroupBy != api.GoroutineFieldNone {
+		return printGoroutineGroups(t, fgl, flags, filters, &group, state)
+	}
 	var (
-		start = 0
-		gslen = 0
-		gs    []*api.Goroutine
+		start  = 0
+		gslen  = 0
+		cgolen = 0
+		gs     []*api.Goroutine
 	)
 	t.longCommandStart()
 	for start >= 0 {
@@ -730,7 +1222,7 @@ func goroutines(t *Term, ctx callContext, argstr string) error {
 			fmt.Printf("interrupted\n")
 			return nil
 		}
-		gs, start, err = t.client.ListGoroutines(start, goroutineBatchSize)
+		gs, _, start, _, err = t.client.ListGoroutinesWithFilter(start, goroutineBatchSize, filters, nil)
 		if err != nil {
 			return err
 		}
@@ -740,11 +1232,46 @@ func goroutines(t *Term, ctx callContext, argstr string) error {
 			return err
 		}
 		gslen += len(gs)
+		for _, g := range gs {
+			if g.CgoCall != "" {
+				cgolen++
+			}
+		}
+	}
+	if cgolen > 0 {
+		fmt.Printf("[%d goroutines, %d in cgo calls]\n", gslen, cgolen)
+		return nil
 	}
 	fmt.Printf("[%d goroutines]\n", gslen)
 	return nil
 }
 
+// printGoroutineGroups prints the goroutines matching filters divided in
+// groups, at most maxGroupMembers goroutines are printed for each group.
+func printGoroutineGroups(t *Term, fgl formatGoroutineLoc, flags printGoroutinesFlags, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions, state *api.DebuggerState) error {
+	gs, groups, _, tooManyGroups, err := t.client.ListGoroutinesWithFilter(0, 0, filters, group)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for i, grp := range groups {
+		fmt.Printf("Goroutine group %d: %s [%d goroutines]\n", i, grp.Name, grp.Total)
+		err := printGoroutines(t, gs[grp.Offset:][:grp.Count], fgl, flags, state)
+		if err != nil {
+			return err
+		}
+		if grp.Total > grp.Count {
+			fmt.Printf("\t...%d more goroutines\n", grp.Total-grp.Count)
+		}
+		total += grp.Total
+	}
+	if tooManyGroups {
+		fmt.Printf("Only the first %d groups were printed\n", len(groups))
+	}
+	fmt.Printf("[%d goroutines in %d groups]\n", total, len(groups))
+	return nil
+}
+
 func selectedGID(state *api.DebuggerState) int {
 	if state.SelectedGoroutine == nil {
 		return 0
@@ -931,50 +1458,18 @@ func (t *Term) formatGoroutine(g *api.Goroutine, fgl formatGoroutineLoc) string
 	}
 
 	if (g.Status == api.GoroutineWaiting || g.Status == api.GoroutineSyscall) && g.WaitReason != 0 {
-		var wr string
-		if g.WaitReason > 0 && g.WaitReason < int64(len(waitReasonStrings)) {
-			wr = waitReasonStrings[g.WaitReason]
-		} else {
-			wr = fmt.Sprintf("unknown wait reason %d", g.WaitReason)
-		}
-		fmt.Fprintf(buf, " [%s", wr)
+		fmt.Fprintf(buf, " [%s", api.WaitReasonString(g.WaitReason))
 		if g.WaitSince > 0 {
 			fmt.Fprintf(buf, " %s", time.Since(time.Unix(0, g.WaitSince)).String())
 		}
 		fmt.Fprintf(buf, "]")
 	}
 
-	return buf.String()
-}
+	if g.CgoCall != "" {
+		fmt.Fprintf(buf, " [cgo call C.%s]", g.CgoCall)
+	}
 
-var waitReasonStrings = [...]string{
-	"",
-	"GC assist marking",
-	"IO wait",
-	"chan receive (nil chan)",
-	"chan send (nil chan)",
-	"dumping heap",
-	"garbage collection",
-	"garbage collection scan",
-	"panicwait",
-	"select",
-	"select (no cases)",
-	"GC assist wait",
-	"GC sweep wait",
-	"GC scavenge wait",
-	"chan receive",
-	"chan send",
-	"finalizer wait",
-	"force gc (idle)",
-	"semacquire",
-	"sleep",
-	"sync.Cond.Wait",
-	"timer goroutine (idle)",
-	"trace reader (blocked)",
-	"wait for GC cycle",
-	"GC worker (idle)",
-	"preempted",
-	"debug call",
+	return buf.String()
 }
 
 func writeGoroutineLong(t *Term, w io.Writer, g *api.Goroutine, prefix string) {
@@ -1055,6 +1550,12 @@ func restartRecorded(t *Term, ctx callContext, args string) error {
 		return err
 	}
 
+	return printRecordingPosition(t)
+}
+
+// printRecordingPosition prints the current position of a recording after
+// it was moved by restart or run-to-event.
+func printRecordingPosition(t *Term) error {
 	state, err := t.client.GetState()
 	if err != nil {
 		return err
@@ -1075,6 +1576,13 @@ func parseOptionalCount(arg string) (int64, error) {
 }
 
 func restartLive(t *Term, ctx callContext, args string) error {
+	if isCheckpoint(t, args) {
+		if err := restartIntl(t, false, args, false, nil, [3]string{}); err != nil {
+			return err
+		}
+		return printRecordingPosition(t)
+	}
+
 	resetArgs, newArgv, newRedirects, err := parseNewArgv(args)
 	if err != nil {
 		return err
@@ -1088,6 +1596,27 @@ func restartLive(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+// isCheckpoint returns true if args is the ID of an existing checkpoint.
+func isCheckpoint(t *Term, args string) bool {
+	if len(args) < 2 || args[0] != 'c' {
+		return false
+	}
+	id, err := strconv.Atoi(args[1:])
+	if err != nil {
+		return false
+	}
+	cps, err := t.client.ListCheckpoints()
+	if err != nil {
+		return false
+	}
+	for _, cp := range cps {
+		if cp.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func restartIntl(t *Term, rerecord bool, restartPos string, resetArgs bool, newArgv []string, newRedirects [3]string) error {
 	discarded, err := t.client.RestartFrom(rerecord, restartPos, resetArgs, newArgv, newRedirects, false)
 	if err != nil {
@@ -1189,17 +1718,28 @@ func (c *Commands) cont(t *Term, ctx callContext, args string) error {
 	}
 	defer t.onStop()
 	c.frame = 0
-	stateChan := t.client.Continue()
-	var state *api.DebuggerState
-	for state = range stateChan {
-		if state.Err != nil {
-			printcontextNoState(t)
-			return state.Err
+	for {
+		stateChan := t.client.Continue()
+		var state *api.DebuggerState
+		propagation := false
+		for state = range stateChan {
+			if state.Err != nil {
+				printcontextNoState(t)
+				return state.Err
+			}
+			// The failure of a subtest is propagated to its parents, the
+			// target already stopped for the first call of Fail.
+			if _, propagation, _ = t.atTestFailure(state); propagation {
+				continue
+			}
+			printcontext(t, state)
 		}
-		printcontext(t, state)
+		if propagation {
+			continue
+		}
+		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+		return nil
 	}
-	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
-	return nil
 }
 
 func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string, shouldPrintFile bool) error {
@@ -1254,6 +1794,12 @@ func (c *Commands) step(t *Term, ctx callContext, args string) error {
 	if ctx.Prefix == revPrefix {
 		stepfn = t.client.ReverseStep
 	}
+	if fnname := strings.TrimSpace(args); fnname != "" {
+		if ctx.Prefix == revPrefix {
+			return errors.New("can not reverse step into an inlined call")
+		}
+		stepfn = func() (*api.DebuggerState, error) { return t.client.StepInline(fnname) }
+	}
 	state, err := exitedToError(stepfn())
 	if err != nil {
 		printcontextNoState(t)
@@ -1366,12 +1912,29 @@ func (c *Commands) call(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	const unsafePrefix = "-unsafe "
+	const timeoutPrefix = "-timeout "
 	unsafe := false
-	if strings.HasPrefix(args, unsafePrefix) {
-		unsafe = true
-		args = args[len(unsafePrefix):]
+	var timeout time.Duration
+	for {
+		if strings.HasPrefix(args, unsafePrefix) {
+			unsafe = true
+			args = strings.TrimSpace(args[len(unsafePrefix):])
+		} else if strings.HasPrefix(args, timeoutPrefix) {
+			v := strings.SplitN(strings.TrimSpace(args[len(timeoutPrefix):]), " ", 2)
+			var err error
+			timeout, err = time.ParseDuration(v[0])
+			if err != nil {
+				return fmt.Errorf("wrong timeout: %v", err)
+			}
+			if len(v) < 2 {
+				return errors.New("not enough arguments")
+			}
+			args = strings.TrimSpace(v[1])
+		} else {
+			break
+		}
 	}
-	state, err := exitedToError(t.client.Call(ctx.Scope.GoroutineID, args, unsafe))
+	state, err := exitedToError(t.client.CallWithTimeout(ctx.Scope.GoroutineID, args, unsafe, timeout))
 	c.frame = 0
 	if err != nil {
 		printcontextNoState(t)
@@ -1617,6 +2180,8 @@ func examineMemoryCmd(t *Term, ctx callContext, args string) error {
 	priFmt := byte('x')
 	count := 1
 	size := 1
+	typ := ""
+	fmtOrSize := false
 
 	for i := 0; i < len(v); i++ {
 		switch v[i] {
@@ -1639,6 +2204,7 @@ func examineMemoryCmd(t *Term, ctx callContext, args string) error {
 			if !ok {
 				return fmt.Errorf("%q is not a valid format", v[i])
 			}
+			fmtOrSize = true
 		case "-count", "-len":
 			i++
 			if i >= len(v) {
@@ -1659,7 +2225,22 @@ func examineMemoryCmd(t *Term, ctx callContext, args string) error {
 			if err != nil || size <= 0 || size > 8 {
 				return fmt.Errorf("size must be a positive integer (<=8)")
 			}
+			fmtOrSize = true
+		case "-t":
+			i++
+			if i >= len(v) {
+				return fmt.Errorf("expected argument after -t")
+			}
+			typ = v[i]
 		default:
+			if typ != "" && i == len(v)-2 {
+				var err error
+				count, err = strconv.Atoi(v[i])
+				if err != nil || count <= 0 {
+					return fmt.Errorf("count must be a positive integer")
+				}
+				continue
+			}
 			if i != len(v)-1 {
 				return fmt.Errorf("unknown option %q", v[i])
 			}
@@ -1671,6 +2252,16 @@ func examineMemoryCmd(t *Term, ctx callContext, args string) error {
 		}
 	}
 
+	if typ != "" {
+		if fmtOrSize {
+			return fmt.Errorf("-t can not be used with -fmt or -size")
+		}
+		if address == 0 {
+			return fmt.Errorf("no address specified")
+		}
+		return examineTypedMemory(t, ctx, address, typ, count)
+	}
+
 	// TODO, maybe configured by user.
 	if count*size > 1000 {
 		return fmt.Errorf("read memory range (count*size) must be less than or equal to 1000 bytes")
@@ -1688,6 +2279,29 @@ func examineMemoryCmd(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+// examineTypedMemory prints count consecutive values of type typ stored at
+// address.
+func examineTypedMemory(t *Term, ctx callContext, address uint64, typ string, count int) error {
+	if count > 1 {
+		typ = fmt.Sprintf("[%d]%s", count, typ)
+	}
+	val, err := t.client.ViewAs(ctx.Scope, fmt.Sprintf("%#x", address), typ, t.loadConfig())
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		fmt.Printf("%#x: %s\n", val.Addr, val.MultilineString(""))
+		return nil
+	}
+	for i := range val.Children {
+		fmt.Printf("%#x: %s\n", val.Children[i].Addr, val.Children[i].MultilineString(""))
+	}
+	if n := val.Len - int64(len(val.Children)); n > 0 {
+		fmt.Printf("...+%d more\n", n)
+	}
+	return nil
+}
+
 func printVar(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -1696,6 +2310,9 @@ func printVar(t *Term, ctx callContext, args string) error {
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
+	if strings.HasPrefix(args, "-stringer ") {
+		return printVarStringer(t, ctx, strings.TrimSpace(strings.TrimPrefix(args, "-stringer ")))
+	}
 	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
 	if err != nil {
 		return err
@@ -1705,209 +2322,691 @@ func printVar(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
-func whatisCommand(t *Term, ctx callContext, args string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("not enough arguments")
+func printVarStringer(t *Term, ctx callContext, expr string) error {
+	if ctx.Prefix == deferredPrefix {
+		return errors.New("-stringer can not be used with deferred calls")
 	}
-	val, err := t.client.EvalVariable(ctx.Scope, args, ShortLoadConfig)
+	opts := api.StringerOptions{Timeout: time.Second, MaxAlloc: 1024 * 1024}
+	val, stringerErr, err := t.client.EvalVariableStringer(ctx.Scope, expr, t.loadConfig(), opts)
 	if err != nil {
 		return err
 	}
-	if val.Type != "" {
-		fmt.Println(val.Type)
-	}
-	if val.RealType != val.Type {
-		fmt.Printf("Real type: %s\n", val.RealType)
-	}
-	if val.Kind == reflect.Interface && len(val.Children) > 0 {
-		fmt.Printf("Concrete type: %s\n", val.Children[0].Type)
-	}
-	if t.conf.ShowLocationExpr && val.LocationExpr != "" {
-		fmt.Printf("location: %s\n", val.LocationExpr)
+	if stringerErr == "" {
+		fmt.Println(val.StringerValue)
+		return nil
 	}
+	fmt.Println(val.MultilineString(""))
+	fmt.Printf("(could not call String or Error method: %s)\n", stringerErr)
 	return nil
 }
 
-func setVar(t *Term, ctx callContext, args string) error {
-	// HACK: in go '=' is not an operator, we detect the error and try to recover from it by splitting the input string
-	_, err := parser.ParseExpr(args)
-	if err == nil {
-		return fmt.Errorf("syntax error '=' not found")
+func chanWaiters(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
 	}
-
-	el, ok := err.(scanner.ErrorList)
-	if !ok || el[0].Msg != "expected '==', found '='" {
+	recv, send, err := t.client.ChanWaiters(ctx.Scope, args)
+	if err != nil {
 		return err
 	}
-
-	lexpr := args[:el[0].Pos.Offset]
-	rexpr := args[el[0].Pos.Offset+1:]
-	return t.client.SetVariable(ctx.Scope, lexpr, rexpr)
-}
-
-func printFilteredVariables(varType string, vars []api.Variable, filter string, cfg api.LoadConfig) error {
-	reg, err := regexp.Compile(filter)
+	state, err := t.client.GetState()
 	if err != nil {
 		return err
 	}
-	match := false
-	for _, v := range vars {
-		if reg == nil || reg.Match([]byte(v.Name)) {
-			match = true
-			name := v.Name
-			if v.Flags&api.VariableShadowed != 0 {
-				name = "(" + name + ")"
-			}
-			if cfg == ShortLoadConfig {
-				fmt.Printf("%s = %s\n", name, v.SinglelineString())
-			} else {
-				fmt.Printf("%s = %s\n", name, v.MultilineString(""))
-			}
+	printWaiters := func(what string, gs []*api.Goroutine) error {
+		if len(gs) == 0 {
+			fmt.Printf("No goroutines waiting to %s.\n", what)
+			return nil
 		}
+		fmt.Printf("Goroutines waiting to %s:\n", what)
+		return printGoroutines(t, gs, fglUserCurrent, 0, state)
 	}
-	if !match {
-		fmt.Printf("(no %s)\n", varType)
+	if err := printWaiters("receive", recv); err != nil {
+		return err
 	}
-	return nil
+	return printWaiters("send", send)
 }
 
-func printSortedStrings(v []string, err error) error {
+func searchVariable(t *Term, ctx callContext, args string) error {
+	depth := 10
+	if strings.HasPrefix(args, "-depth ") {
+		v := split2PartsBySpace(strings.TrimPrefix(args, "-depth "))
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid depth %q", v[0])
+		}
+		depth = n
+		args = ""
+		if len(v) > 1 {
+			args = v[1]
+		}
+	}
+	value, expr, err := parseSearchValue(args)
 	if err != nil {
 		return err
 	}
-	sort.Strings(v)
-	for _, d := range v {
-		fmt.Println(d)
+	if expr == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	cfg := api.LoadConfig{FollowPointers: true, MaxVariableRecurse: depth, MaxStringLen: 1024, MaxArrayValues: 100, MaxStructFields: -1}
+	paths, err := t.client.SearchVariable(ctx.Scope, expr, value, cfg)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Println("Not found.")
+		return nil
+	}
+	for _, path := range paths {
+		fmt.Println(path)
 	}
 	return nil
 }
 
-func sources(t *Term, ctx callContext, args string) error {
-	return printSortedStrings(t.client.ListSources(args))
-}
-
-func funcs(t *Term, ctx callContext, args string) error {
-	return printSortedStrings(t.client.ListFunctions(args))
-}
-
-func types(t *Term, ctx callContext, args string) error {
-	return printSortedStrings(t.client.ListTypes(args))
+// parseSearchValue splits the arguments of the search command into the
+// value to search for, which is either a quoted Go string or a single
+// word, and the expression to search.
+func parseSearchValue(args string) (value, expr string, err error) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return "", "", fmt.Errorf("not enough arguments")
+	}
+	if args[0] != '"' && args[0] != '`' {
+		v := split2PartsBySpace(args)
+		if len(v) < 2 {
+			return v[0], "", nil
+		}
+		return v[0], v[1], nil
+	}
+	var sc scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(args))
+	sc.Init(file, []byte(args), nil, 0)
+	pos, tok, lit := sc.Scan()
+	if tok != token.STRING || sc.ErrorCount > 0 {
+		return "", "", fmt.Errorf("malformed string %s", args)
+	}
+	value, err = strconv.Unquote(lit)
+	if err != nil {
+		return "", "", fmt.Errorf("malformed string %s: %v", lit, err)
+	}
+	return value, strings.TrimSpace(args[file.Offset(pos)+len(lit):]), nil
 }
 
-func parseVarArguments(args string, t *Term) (filter string, cfg api.LoadConfig) {
-	if v := split2PartsBySpace(args); len(v) >= 1 && v[0] == "-v" {
-		if len(v) == 2 {
-			return v[1], t.loadConfig()
-		} else {
-			return "", t.loadConfig()
+func dumpVariable(t *Term, ctx callContext, args string) error {
+	format := ""
+	depth := 10
+	for {
+		v := split2PartsBySpace(args)
+		if len(v) < 2 {
+			break
+		}
+		switch v[0] {
+		case "-format":
+			w := split2PartsBySpace(v[1])
+			format = w[0]
+			args = ""
+			if len(w) > 1 {
+				args = w[1]
+			}
+			continue
+		case "-depth":
+			w := split2PartsBySpace(v[1])
+			n, err := strconv.Atoi(w[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid depth %q", w[0])
+			}
+			depth = n
+			args = ""
+			if len(w) > 1 {
+				args = w[1]
+			}
+			continue
 		}
+		break
 	}
-	return args, ShortLoadConfig
-}
 
-func args(t *Term, ctx callContext, args string) error {
-	filter, cfg := parseVarArguments(args, t)
-	if ctx.Prefix == onPrefix {
-		if filter != "" {
-			return fmt.Errorf("filter not supported on breakpoint")
+	expr, outfile := args, ""
+	if i := strings.LastIndex(args, ">"); i >= 0 {
+		expr, outfile = strings.TrimSpace(args[:i]), strings.TrimSpace(args[i+1:])
+		if outfile == "" {
+			return fmt.Errorf("no output file specified")
 		}
-		ctx.Breakpoint.LoadArgs = &cfg
-		return nil
 	}
-	vars, err := t.client.ListFunctionArgs(ctx.Scope, cfg)
+	if expr == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	if format == "" {
+		format = api.ExportFormatJSON
+		if strings.HasSuffix(outfile, ".go") {
+			format = api.ExportFormatGo
+		}
+	}
+
+	cfg := api.LoadConfig{FollowPointers: true, MaxVariableRecurse: depth, MaxStringLen: 4096, MaxArrayValues: 1000, MaxStructFields: -1}
+	data, err := t.client.ExportVariable(ctx.Scope, expr, format, cfg)
 	if err != nil {
 		return err
 	}
-	return printFilteredVariables("args", vars, filter, cfg)
+	if outfile == "" {
+		fmt.Print(data)
+		return nil
+	}
+	return ioutil.WriteFile(outfile, []byte(data), 0644)
 }
 
-func locals(t *Term, ctx callContext, args string) error {
-	filter, cfg := parseVarArguments(args, t)
-	if ctx.Prefix == onPrefix {
-		if filter != "" {
-			return fmt.Errorf("filter not supported on breakpoint")
+func objectGraph(t *Term, ctx callContext, args string) error {
+	depth := 10
+	if strings.HasPrefix(args, "-depth ") {
+		v := split2PartsBySpace(strings.TrimPrefix(args, "-depth "))
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid depth %q", v[0])
 		}
-		ctx.Breakpoint.LoadLocals = &cfg
+		depth = n
+		args = ""
+		if len(v) > 1 {
+			args = v[1]
+		}
+	}
+	outfile := ""
+	if i := strings.LastIndex(args, ">"); i >= 0 {
+		args, outfile = args[:i], strings.TrimSpace(args[i+1:])
+		if outfile == "" {
+			return fmt.Errorf("no output file specified")
+		}
+	}
+	exprs := strings.Fields(args)
+	if len(exprs) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+
+	cfg := api.LoadConfig{FollowPointers: true, MaxVariableRecurse: depth, MaxStringLen: 64, MaxArrayValues: 100, MaxStructFields: -1}
+	g, err := t.client.ObjectGraph(ctx.Scope, exprs, cfg)
+	if err != nil {
+		return err
+	}
+	if outfile == "" {
+		writeObjectGraphDOT(os.Stdout, g)
 		return nil
 	}
-	locals, err := t.client.ListLocalVariables(ctx.Scope, cfg)
+	f, err := os.Create(outfile)
 	if err != nil {
 		return err
 	}
-	return printFilteredVariables("locals", locals, filter, cfg)
+	writeObjectGraphDOT(f, g)
+	return f.Close()
 }
 
-func vars(t *Term, ctx callContext, args string) error {
-	filter, cfg := parseVarArguments(args, t)
-	vars, err := t.client.ListPackageVariables(filter, cfg)
-	if err != nil {
-		return err
+// writeObjectGraphDOT writes g to w in graphviz DOT format.
+func writeObjectGraphDOT(w io.Writer, g *api.ObjectGraph) {
+	fmt.Fprintf(w, "digraph objects {\n")
+	fmt.Fprintf(w, "\tnode [shape=box, fontname=monospace];\n")
+	if g.Truncated {
+		fmt.Fprintf(w, "\t// graph truncated, some objects are missing\n")
 	}
-	return printFilteredVariables("vars", vars, filter, cfg)
+	for i, n := range g.Nodes {
+		if n.Root != "" {
+			fmt.Fprintf(w, "\tn%d [label=%q, style=bold];\n", i, fmt.Sprintf("%s\n%s", n.Root, n.Type))
+			continue
+		}
+		label := fmt.Sprintf("%s\n%#x", n.Type, n.Addr)
+		if n.Size > 0 {
+			label += fmt.Sprintf(" (%d bytes)", n.Size)
+		}
+		fmt.Fprintf(w, "\tn%d [label=%q];\n", i, label)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "\tn%d -> n%d [label=%q];\n", e.From, e.To, e.Label)
+	}
+	fmt.Fprintf(w, "}\n")
 }
 
-func regs(t *Term, ctx callContext, args string) error {
-	includeFp := false
-	if args == "-a" {
-		includeFp = true
+func viewAs(t *Term, ctx callContext, args string) error {
+	i := strings.LastIndex(args, " as ")
+	if i < 0 {
+		return fmt.Errorf("wrong number of arguments: view <expression> as <type>")
 	}
-	var regs api.Registers
-	var err error
-	if ctx.Scope.GoroutineID < 0 && ctx.Scope.Frame == 0 {
-		regs, err = t.client.ListThreadRegisters(0, includeFp)
-	} else {
-		regs, err = t.client.ListScopeRegisters(ctx.Scope, includeFp)
+	expr, typ := strings.TrimSpace(args[:i]), strings.TrimSpace(args[i+len(" as "):])
+	if expr == "" || typ == "" {
+		return fmt.Errorf("wrong number of arguments: view <expression> as <type>")
 	}
+	val, err := t.client.ViewAs(ctx.Scope, expr, typ, t.loadConfig())
 	if err != nil {
 		return err
 	}
-	fmt.Println(regs)
+	fmt.Println(val.MultilineString(""))
 	return nil
 }
 
-func stackCommand(t *Term, ctx callContext, args string) error {
-	sa, err := parseStackArgs(args)
+func mutexInfo(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	ms, err := t.client.MutexInfo(ctx.Scope, args)
 	if err != nil {
 		return err
 	}
-	if ctx.Prefix == onPrefix {
-		ctx.Breakpoint.Stacktrace = sa.depth
-		return nil
+	state, err := t.client.GetState()
+	if err != nil {
+		return err
 	}
-	var cfg *api.LoadConfig
-	if sa.full {
-		cfg = &ShortLoadConfig
+	var flags []string
+	if ms.Woken {
+		flags = append(flags, "woken")
 	}
-	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, sa.depth, sa.opts, cfg)
+	if ms.Starving {
+		flags = append(flags, "starving")
+	}
+	locked := "unlocked"
+	if ms.Locked {
+		locked = "locked"
+	}
+	if len(flags) > 0 {
+		locked += " (" + strings.Join(flags, ", ") + ")"
+	}
+	fmt.Printf("Mutex is %s\n", locked)
+	if ms.RWMutex {
+		fmt.Printf("Readers holding the lock: %d\n", ms.Readers)
+		if ms.WriterPending {
+			fmt.Println("A writer is waiting for the readers to release the lock")
+		}
+	}
+	printWaiters := func(what string, gs []*api.Goroutine) error {
+		if len(gs) == 0 {
+			return nil
+		}
+		fmt.Printf("%s:\n", what)
+		return printGoroutines(t, gs, fglUserCurrent, 0, state)
+	}
+	if err := printWaiters("Goroutines waiting to acquire the lock", ms.Waiters); err != nil {
+		return err
+	}
+	if err := printWaiters("Writers waiting for the readers", ms.WriterWaiters); err != nil {
+		return err
+	}
+	return printWaiters("Readers waiting for the writer", ms.ReaderWaiters)
+}
+
+func contextChain(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	layers, err := t.client.ContextChain(ctx.Scope, args, t.loadConfig())
 	if err != nil {
 		return err
 	}
-	printStack(t, os.Stdout, stack, "", sa.offsets)
-	if sa.ancestors > 0 {
-		ancestors, err := t.client.Ancestors(ctx.Scope.GoroutineID, sa.ancestors, sa.ancestorDepth)
-		if err != nil {
-			return err
+	for i, layer := range layers {
+		fmt.Printf("[%d] %s at %#x\n", i, layer.Type, layer.Addr)
+		if layer.Key != nil {
+			fmt.Printf("\tkey: %s\n", layer.Key.SinglelineString())
 		}
-		for _, ancestor := range ancestors {
-			fmt.Printf("Created by Goroutine %d:\n", ancestor.ID)
-			if ancestor.Unreadable != "" {
-				fmt.Printf("\t%s\n", ancestor.Unreadable)
-				continue
-			}
-			printStack(t, os.Stdout, ancestor.Stack, "\t", false)
+		if layer.Value != nil {
+			fmt.Printf("\tvalue: %s\n", layer.Value.SinglelineString())
+		}
+		if layer.Deadline != "" {
+			fmt.Printf("\tdeadline: %s\n", layer.Deadline)
+		}
+		if layer.Canceled {
+			fmt.Printf("\tcanceled: %s\n", layer.Err.SinglelineString())
 		}
 	}
 	return nil
 }
 
-type stackArgs struct {
-	depth   int
-	full    bool
-	offsets bool
-	opts    api.StacktraceOptions
-
-	ancestors     int
+func referrers(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	addr, err := strconv.ParseUint(args, 0, 64)
+	if err != nil {
+		v, err := t.client.EvalVariable(ctx.Scope, args, ShortLoadConfig)
+		if err != nil {
+			return err
+		}
+		addr = v.Addr
+		if v.Kind == reflect.Ptr && len(v.Children) > 0 {
+			addr = v.Children[0].Addr
+		}
+		if addr == 0 {
+			return fmt.Errorf("%s has no address", args)
+		}
+	}
+	refs, err := t.client.FindReferrers(addr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Object at %#x (%d bytes) referenced by:\n", refs.Addr, refs.Size)
+	for _, ref := range refs.Referrers {
+		switch {
+		case ref.Var != "":
+			fmt.Printf("\tpackage variable %s%s (%s) at %#x\n", ref.Var, ref.Field, ref.Type, ref.Addr+ref.Offset)
+		case ref.GoroutineID != 0:
+			fmt.Printf("\tstack of goroutine %d at %#x\n", ref.GoroutineID, ref.Addr+ref.Offset)
+		case ref.Type != "":
+			fmt.Printf("\t%#x (%s, %d bytes) field %s at offset %#x\n", ref.Addr, ref.Type, ref.Size, ref.Field, ref.Offset)
+		default:
+			fmt.Printf("\t%#x (%d bytes) at offset %#x\n", ref.Addr, ref.Size, ref.Offset)
+		}
+	}
+	if refs.Truncated {
+		fmt.Printf("\t...(more referrers omitted)\n")
+	}
+	return nil
+}
+
+func heapHistogram(t *Term, ctx callContext, args string) error {
+	n := 20
+	if args != "" {
+		var err error
+		n, err = strconv.Atoi(args)
+		if err != nil || n < 0 {
+			return fmt.Errorf("wrong argument %q, expected a number", args)
+		}
+	}
+	entries, err := t.client.HeapHistogram()
+	if err != nil {
+		return err
+	}
+	var count, bytes uint64
+	for _, e := range entries {
+		count += e.Count
+		bytes += e.Bytes
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Count\tBytes\t Type\n")
+	for i, e := range entries {
+		if n > 0 && i >= n {
+			break
+		}
+		fmt.Fprintf(w, "%d\t%d\t %s\n", e.Count, e.Bytes, e.Type)
+	}
+	fmt.Fprintf(w, "%d\t%d\t total\n", count, bytes)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if n > 0 && len(entries) > n {
+		fmt.Printf("(%d more types)\n", len(entries)-n)
+	}
+	return nil
+}
+
+func interfaceItab(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	itab, err := t.client.InterfaceItab(ctx.Scope, args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Interface type: %s\n", itab.InterfaceType)
+	if itab.ConcreteType == "" {
+		fmt.Println("Concrete type: nil")
+		return nil
+	}
+	fmt.Printf("Concrete type: %s\n", itab.ConcreteType)
+	if itab.Addr == 0 {
+		return nil
+	}
+	fmt.Printf("Itab: %#x\n", itab.Addr)
+	for _, m := range itab.Methods {
+		fnname := "?"
+		if m.Function != nil {
+			fnname = m.Function.Name()
+		}
+		fmt.Printf("\t%s => %s (%#x)\n", m.Name, fnname, m.PC)
+	}
+	return nil
+}
+
+func whatisCommand(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	val, err := t.client.EvalVariable(ctx.Scope, args, ShortLoadConfig)
+	if err != nil {
+		return err
+	}
+	if val.Type != "" {
+		fmt.Println(val.Type)
+	}
+	if val.RealType != val.Type {
+		fmt.Printf("Real type: %s\n", val.RealType)
+	}
+	if val.Kind == reflect.Interface && len(val.Children) > 0 {
+		fmt.Printf("Concrete type: %s\n", val.Children[0].Type)
+	}
+	if t.conf.ShowLocationExpr && val.LocationExpr != "" {
+		fmt.Printf("location: %s\n", val.LocationExpr)
+	}
+	return nil
+}
+
+func setVar(t *Term, ctx callContext, args string) error {
+	// HACK: in go '=' is not an operator, we detect the error and try to recover from it by splitting the input string
+	_, err := parser.ParseExpr(args)
+	if err == nil {
+		return fmt.Errorf("syntax error '=' not found")
+	}
+
+	el, ok := err.(scanner.ErrorList)
+	if !ok || el[0].Msg != "expected '==', found '='" {
+		return err
+	}
+
+	lexpr := args[:el[0].Pos.Offset]
+	rexpr := args[el[0].Pos.Offset+1:]
+	return t.client.SetVariable(ctx.Scope, lexpr, rexpr)
+}
+
+func printFilteredVariables(varType string, vars []api.Variable, filter string, cfg api.LoadConfig) error {
+	reg, err := regexp.Compile(filter)
+	if err != nil {
+		return err
+	}
+	shadowed := map[string]bool{}
+	for _, v := range vars {
+		if v.Flags&api.VariableShadowed != 0 {
+			shadowed[v.Name] = true
+		}
+	}
+	match := false
+	for _, v := range vars {
+		if reg == nil || reg.Match([]byte(v.Name)) {
+			match = true
+			name := v.Name
+			if v.Flags&api.VariableShadowed != 0 {
+				name = "(" + name + ")"
+			}
+			if v.Changed {
+				name = "*" + name
+			}
+			// disambiguate the instances of shadowed variables
+			declInfo := ""
+			if shadowed[v.Name] && v.DeclLine > 0 {
+				declInfo = fmt.Sprintf(" // declared at line %d", v.DeclLine)
+				if v.ScopeStartLine > 0 {
+					declInfo += fmt.Sprintf(", scope lines %d-%d", v.ScopeStartLine, v.ScopeEndLine)
+				}
+			}
+			if cfg == ShortLoadConfig {
+				fmt.Printf("%s = %s%s\n", name, v.SinglelineString(), declInfo)
+			} else {
+				fmt.Printf("%s = %s%s\n", name, v.MultilineString(""), declInfo)
+			}
+		}
+	}
+	if !match {
+		fmt.Printf("(no %s)\n", varType)
+	}
+	return nil
+}
+
+func printSortedStrings(v []string, err error) error {
+	if err != nil {
+		return err
+	}
+	sort.Strings(v)
+	for _, d := range v {
+		fmt.Println(d)
+	}
+	return nil
+}
+
+func sources(t *Term, ctx callContext, args string) error {
+	return printSortedStrings(t.client.ListSources(args))
+}
+
+func funcs(t *Term, ctx callContext, args string) error {
+	return printSortedStrings(t.client.ListFunctions(args))
+}
+
+func types(t *Term, ctx callContext, args string) error {
+	return printSortedStrings(t.client.ListTypes(args))
+}
+
+func parseVarArguments(args string, t *Term) (filter string, cfg api.LoadConfig) {
+	if v := split2PartsBySpace(args); len(v) >= 1 && v[0] == "-v" {
+		if len(v) == 2 {
+			return v[1], t.loadConfig()
+		} else {
+			return "", t.loadConfig()
+		}
+	}
+	return args, ShortLoadConfig
+}
+
+func args(t *Term, ctx callContext, args string) error {
+	filter, cfg := parseVarArguments(args, t)
+	if ctx.Prefix == onPrefix {
+		if filter != "" {
+			return fmt.Errorf("filter not supported on breakpoint")
+		}
+		ctx.Breakpoint.LoadArgs = &cfg
+		return nil
+	}
+	vars, err := t.client.ListFunctionArgs(ctx.Scope, cfg)
+	if err != nil {
+		return err
+	}
+	return printFilteredVariables("args", vars, filter, cfg)
+}
+
+func locals(t *Term, ctx callContext, args string) error {
+	filter, cfg := parseVarArguments(args, t)
+	if ctx.Prefix == onPrefix {
+		if filter != "" {
+			return fmt.Errorf("filter not supported on breakpoint")
+		}
+		ctx.Breakpoint.LoadLocals = &cfg
+		return nil
+	}
+	locals, err := t.client.ListLocalVariables(ctx.Scope, cfg)
+	if err != nil {
+		return err
+	}
+	return printFilteredVariables("locals", locals, filter, cfg)
+}
+
+func vars(t *Term, ctx callContext, args string) error {
+	filter, cfg := parseVarArguments(args, t)
+	vars, err := t.client.ListPackageVariables(filter, cfg)
+	if err != nil {
+		return err
+	}
+	return printFilteredVariables("vars", vars, filter, cfg)
+}
+
+func regs(t *Term, ctx callContext, args string) error {
+	includeFp := false
+	vectorFormat := ""
+	v := strings.Fields(args)
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case "-a":
+			includeFp = true
+		case "-vec":
+			if i+1 >= len(v) {
+				return errors.New("-vec must be followed by a vector format")
+			}
+			i++
+			vectorFormat = v[i]
+			includeFp = true
+		default:
+			return fmt.Errorf("unknown argument %q", v[i])
+		}
+	}
+	var regs api.Registers
+	var err error
+	switch {
+	case vectorFormat != "":
+		var scope *api.EvalScope
+		if ctx.Scope.GoroutineID >= 0 || ctx.Scope.Frame != 0 {
+			scope = &ctx.Scope
+		}
+		regs, err = t.client.ListRegistersWithFormat(0, scope, includeFp, vectorFormat)
+	case ctx.Scope.GoroutineID < 0 && ctx.Scope.Frame == 0:
+		regs, err = t.client.ListThreadRegisters(0, includeFp)
+	default:
+		regs, err = t.client.ListScopeRegisters(ctx.Scope, includeFp)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(regs)
+	return nil
+}
+
+func stackCommand(t *Term, ctx callContext, args string) error {
+	sa, err := parseStackArgs(args)
+	if err != nil {
+		return err
+	}
+	if ctx.Prefix == onPrefix {
+		ctx.Breakpoint.Stacktrace = sa.depth
+		return nil
+	}
+	if sa.save != "" || sa.diff != "" {
+		return stackSaveOrDiff(t, ctx, sa)
+	}
+	var cfg *api.LoadConfig
+	if sa.full {
+		cfg = &ShortLoadConfig
+	}
+	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, sa.depth, sa.opts, cfg)
+	if err != nil {
+		return err
+	}
+	printStack(t, os.Stdout, stack, "", sa.offsets)
+	if sa.ancestors > 0 {
+		ancestors, err := t.client.Ancestors(ctx.Scope.GoroutineID, sa.ancestors, sa.ancestorDepth)
+		if err != nil {
+			return err
+		}
+		for _, ancestor := range ancestors {
+			fmt.Printf("Created by Goroutine %d:\n", ancestor.ID)
+			if ancestor.Unreadable != "" {
+				fmt.Printf("\t%s\n", ancestor.Unreadable)
+				continue
+			}
+			printStack(t, os.Stdout, ancestor.Stack, "\t", false)
+		}
+	}
+	return nil
+}
+
+type stackArgs struct {
+	depth   int
+	full    bool
+	offsets bool
+	opts    api.StacktraceOptions
+
+	ancestors     int
 	ancestorDepth int
+
+	// save and diff are the files of 'stack -save' and 'stack -diff', all
+	// selects all goroutines.
+	save, diff string
+	all        bool
 }
 
 func parseStackArgs(argstr string) (stackArgs, error) {
@@ -1966,6 +3065,19 @@ func parseStackArgs(argstr string) (stackArgs, error) {
 					return stackArgs{}, err
 				}
 				r.ancestorDepth = n
+			case "-save", "-diff":
+				name := args[i]
+				i++
+				if i >= len(args) {
+					return stackArgs{}, fmt.Errorf("expected file name after %s", name)
+				}
+				if name == "-save" {
+					r.save = args[i]
+				} else {
+					r.diff = args[i]
+				}
+			case "-all":
+				r.all = true
 			default:
 				n, err := strconv.Atoi(args[i])
 				if err != nil {
@@ -1978,6 +3090,12 @@ func parseStackArgs(argstr string) (stackArgs, error) {
 	if r.ancestors > 0 && r.ancestorDepth == 0 {
 		r.ancestorDepth = r.depth
 	}
+	if r.save != "" && r.diff != "" {
+		return stackArgs{}, errors.New("-save and -diff can not be used together")
+	}
+	if r.all && r.save == "" && r.diff == "" {
+		return stackArgs{}, errors.New("-all can only be used with -save or -diff")
+	}
 	return r, nil
 }
 
@@ -2039,11 +3157,24 @@ func getLocation(t *Term, ctx callContext, args string, showContext bool) (file
 }
 
 func listCommand(t *Term, ctx callContext, args string) error {
+	lineCount := t.conf.GetSourceListLineCount()
+	if strings.HasPrefix(args, "-n ") {
+		v := strings.SplitN(strings.TrimSpace(args[len("-n "):]), " ", 2)
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid number of lines %q", v[0])
+		}
+		lineCount = n
+		args = ""
+		if len(v) > 1 {
+			args = strings.TrimSpace(v[1])
+		}
+	}
 	file, lineno, showarrow, err := getLocation(t, ctx, args, true)
 	if err != nil {
 		return err
 	}
-	return printfile(t, file, lineno, showarrow)
+	return printfileContext(t, file, lineno, showarrow, lineCount)
 }
 
 func (c *Commands) sourceCommand(t *Term, ctx callContext, args string) error {
@@ -2077,72 +3208,455 @@ func disassCommand(t *Term, ctx callContext, args string) error {
 		rest = argv[1]
 	}
 
-	flavor := api.IntelFlavour
-	if t.conf != nil && t.conf.DisassembleFlavor != nil {
-		switch *t.conf.DisassembleFlavor {
-		case "go":
-			flavor = api.GoFlavour
-		case "gnu":
-			flavor = api.GNUFlavour
-		default:
-			flavor = api.IntelFlavour
+	flavor := api.IntelFlavour
+	if t.conf != nil && t.conf.DisassembleFlavor != nil {
+		switch *t.conf.DisassembleFlavor {
+		case "go":
+			flavor = api.GoFlavour
+		case "gnu":
+			flavor = api.GNUFlavour
+		default:
+			flavor = api.IntelFlavour
+		}
+	}
+
+	var disasm api.AsmInstructions
+	var disasmErr error
+
+	switch cmd {
+	case "":
+		locs, err := t.client.FindLocation(ctx.Scope, "+0", true, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
+	case "-a":
+		v := split2PartsBySpace(rest)
+		if len(v) != 2 {
+			return disasmUsageError
+		}
+		startpc, err := strconv.ParseInt(v[0], 0, 64)
+		if err != nil {
+			return fmt.Errorf("wrong argument: %q is not a number", v[0])
+		}
+		endpc, err := strconv.ParseInt(v[1], 0, 64)
+		if err != nil {
+			return fmt.Errorf("wrong argument: %q is not a number", v[1])
+		}
+		disasm, disasmErr = t.client.DisassembleRange(ctx.Scope, uint64(startpc), uint64(endpc), flavor)
+	case "-l":
+		locs, err := t.client.FindLocation(ctx.Scope, rest, true, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		if len(locs) != 1 {
+			return errors.New("expression specifies multiple locations")
+		}
+		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
+	default:
+		return disasmUsageError
+	}
+
+	if disasmErr != nil {
+		return disasmErr
+	}
+
+	disasmPrint(disasm, os.Stdout)
+
+	return nil
+}
+
+func libraries(t *Term, ctx callContext, args string) error {
+	libs, err := t.client.ListDynamicLibraries()
+	if err != nil {
+		return err
+	}
+	d := digits(len(libs))
+	for i := range libs {
+		fmt.Printf("%"+strconv.Itoa(d)+"d. %#x %s\n", i, libs[i].Address, libs[i].Path)
+	}
+	return nil
+}
+
+func handleSignal(t *Term, ctx callContext, args string) error {
+	v := strings.Fields(args)
+	policies, err := t.client.ListSignalPolicies()
+	if err != nil {
+		return err
+	}
+	if len(v) == 0 {
+		if len(policies) == 0 {
+			fmt.Println("All signals are delivered to the target process without stopping it.")
+			return nil
+		}
+		printSignalPolicies(policies)
+		return nil
+	}
+
+	policy := api.SignalPolicy{Pass: true}
+	for _, p := range policies {
+		if strconv.Itoa(p.Signal) == v[0] || strings.EqualFold(p.Name, v[0]) || strings.EqualFold(p.Name, "SIG"+v[0]) {
+			policy = p
+			break
+		}
+	}
+
+	for _, kw := range v[1:] {
+		switch kw {
+		case "stop":
+			policy.Stop, policy.Print = true, true
+		case "nostop":
+			policy.Stop = false
+		case "print":
+			policy.Print = true
+		case "noprint":
+			policy.Print, policy.Stop = false, false
+		case "pass":
+			policy.Pass = true
+		case "nopass":
+			policy.Pass = false
+		default:
+			return fmt.Errorf("unknown keyword %q", kw)
+		}
+	}
+
+	if len(v) == 1 {
+		if policy.Name == "" {
+			policy.Name = v[0]
+		}
+	} else {
+		policy, err = t.client.SetSignalPolicy(v[0], policy.Stop, policy.Print, policy.Pass)
+		if err != nil {
+			return err
+		}
+	}
+	printSignalPolicies([]api.SignalPolicy{policy})
+	return nil
+}
+
+func printSignalPolicies(policies []api.SignalPolicy) {
+	yesno := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Signal\tStop\tPrint\tPass")
+	for _, p := range policies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, yesno(p.Stop), yesno(p.Print), yesno(p.Pass))
+	}
+	w.Flush()
+}
+
+func timers(t *Term, ctx callContext, args string) error {
+	timers, now, err := t.client.ListTimers()
+	if err != nil {
+		return err
+	}
+	if len(timers) == 0 {
+		fmt.Println("No pending timers.")
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "P\tAddress\tFires in\tPeriod\tCallback")
+	for _, timer := range timers {
+		p := "-"
+		if timer.P >= 0 {
+			p = strconv.Itoa(timer.P)
+		}
+		when := fmt.Sprintf("when=%d", timer.When)
+		if now != 0 {
+			when = time.Duration(timer.When - now).String()
+		}
+		period := "-"
+		if timer.Period != 0 {
+			period = time.Duration(timer.Period).String()
+		}
+		fmt.Fprintf(w, "%s\t%#x\t%s\t%s\t%s\n", p, timer.Addr, when, period, timer.Callback)
+	}
+	return w.Flush()
+}
+
+func dump(t *Term, ctx callContext, args string) error {
+	var opts api.DumpOptions
+	flags := []struct {
+		name string
+		p    *bool
+	}{
+		{"-selective", &opts.Selective},
+		{"-minidump", &opts.Minidump},
+		{"-compress", &opts.Compress},
+		{"-sparse", &opts.Sparse},
+	}
+	for found := true; found; {
+		found = false
+		for _, flag := range flags {
+			if strings.HasPrefix(args, flag.name) {
+				*flag.p = true
+				args = strings.TrimSpace(args[len(flag.name):])
+				found = true
+			}
+		}
+	}
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	if err := t.client.Dump(args, opts); err != nil {
+		return err
+	}
+	if opts.Minidump {
+		fmt.Printf("Minidump written to %s\n", args)
+		return nil
+	}
+	fmt.Printf("Core dump written to %s\n", args)
+	return nil
+}
+
+func rawCall(t *Term, ctx callContext, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 || fields[0] != "-unsafe" {
+		return errors.New("raw calls can corrupt the target process, use 'rawcall -unsafe' to make one")
+	}
+	fields = fields[1:]
+	syscall := false
+	if len(fields) > 0 && fields[0] == "-syscall" {
+		syscall = true
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return errors.New("not enough arguments")
+	}
+
+	parseArg := func(s string) (uint64, error) {
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return uint64(n), nil
+		}
+		return strconv.ParseUint(s, 0, 64)
+	}
+
+	addr, err := parseArg(fields[0])
+	if err != nil {
+		if syscall {
+			return fmt.Errorf("invalid system call number %q", fields[0])
+		}
+		locs, err := t.client.FindLocation(ctx.Scope, fields[0], true, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		if len(locs) != 1 {
+			return fmt.Errorf("%q is ambiguous", fields[0])
+		}
+		addr = locs[0].PC
+		if locs[0].Function != nil {
+			addr = locs[0].Function.Value
+		}
+	}
+	callArgs := make([]uint64, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		arg, err := parseArg(field)
+		if err != nil {
+			return fmt.Errorf("invalid argument %q: %v", field, err)
+		}
+		callArgs = append(callArgs, arg)
+	}
+
+	r, err := t.client.RawCall(addr, callArgs, syscall)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("r1 = %#x (%d)\nr2 = %#x (%d)\n", r.R1, int64(r.R1), r.R2, int64(r.R2))
+	if syscall {
+		fmt.Printf("errno = %d\n", r.Errno)
+	}
+	return nil
+}
+
+func patch(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		patches, err := t.client.ListFunctionPatches()
+		if err != nil {
+			return err
+		}
+		for _, patch := range patches {
+			fmt.Printf("Patch %d at %#x for %s: %s\n", patch.ID, patch.Addr, patch.Function, patch.Desc)
+		}
+		return nil
+	}
+	v := split2PartsBySpace(args)
+	if len(v) < 2 {
+		return errors.New("not enough arguments")
+	}
+	fn := v[0]
+	v = split2PartsBySpace(v[1])
+	var vals []string
+	var code []byte
+	switch v[0] {
+	case "return":
+		if len(v) > 1 {
+			var err error
+			vals, err = splitPatchValues(v[1])
+			if err != nil {
+				return err
+			}
+		}
+	case "code":
+		if len(v) < 2 {
+			return errors.New("not enough arguments")
+		}
+		var err error
+		code, err = hex.DecodeString(strings.Join(strings.Fields(v[1]), ""))
+		if err != nil {
+			return fmt.Errorf("invalid code: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown patch kind %q, expected return or code", v[0])
+	}
+	patch, err := t.client.PatchFunction(fn, vals, code)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Patch %d at %#x for %s: %s\n", patch.ID, patch.Addr, patch.Function, patch.Desc)
+	return nil
+}
+
+// splitPatchValues splits the return values of the patch command at
+// spaces, double quoted strings can contain spaces.
+func splitPatchValues(s string) ([]string, error) {
+	var r []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return r, nil
+		}
+		if s[0] != '"' {
+			v := split2PartsBySpace(s)
+			r = append(r, v[0])
+			if len(v) < 2 {
+				return r, nil
+			}
+			s = v[1]
+			continue
+		}
+		end := -1
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				end = i + 1
+				break
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %s", s)
 		}
+		r = append(r, s[:end])
+		s = s[end:]
 	}
+}
 
-	var disasm api.AsmInstructions
-	var disasmErr error
-
-	switch cmd {
-	case "":
-		locs, err := t.client.FindLocation(ctx.Scope, "+0", true, t.substitutePathRules())
+func unpatch(t *Term, ctx callContext, args string) error {
+	if args == "-all" {
+		patches, err := t.client.ListFunctionPatches()
 		if err != nil {
 			return err
 		}
-		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
-	case "-a":
-		v := split2PartsBySpace(rest)
-		if len(v) != 2 {
-			return disasmUsageError
+		for _, patch := range patches {
+			if err := t.client.UnpatchFunction(patch.ID); err != nil {
+				return err
+			}
 		}
-		startpc, err := strconv.ParseInt(v[0], 0, 64)
+		return nil
+	}
+	id, err := strconv.Atoi(args)
+	if err != nil {
+		return fmt.Errorf("invalid patch id %q", args)
+	}
+	return t.client.UnpatchFunction(id)
+}
+
+func guard(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		guards, err := t.client.ListMemoryGuards()
 		if err != nil {
-			return fmt.Errorf("wrong argument: %q is not a number", v[0])
+			return err
 		}
-		endpc, err := strconv.ParseInt(v[1], 0, 64)
-		if err != nil {
-			return fmt.Errorf("wrong argument: %q is not a number", v[1])
+		for _, guard := range guards {
+			fmt.Printf("Guard %d at %#x-%#x %s\n", guard.ID, guard.Addr, guard.Addr+guard.Size, guard.Prot)
 		}
-		disasm, disasmErr = t.client.DisassembleRange(ctx.Scope, uint64(startpc), uint64(endpc), flavor)
-	case "-l":
-		locs, err := t.client.FindLocation(ctx.Scope, rest, true, t.substitutePathRules())
+		return nil
+	}
+	v := strings.Fields(args)
+	prot := "r"
+	if v[0] == "-none" {
+		prot = "none"
+		v = v[1:]
+	}
+	if len(v) != 2 {
+		return errors.New("wrong number of arguments")
+	}
+	addr, err := strconv.ParseUint(v[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address %q", v[0])
+	}
+	size, err := strconv.ParseUint(v[1], 0, 64)
+	if err != nil || size == 0 {
+		return fmt.Errorf("invalid size %q", v[1])
+	}
+	guard, err := t.client.GuardMemory(addr, size, prot)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Guard %d at %#x-%#x %s\n", guard.ID, guard.Addr, guard.Addr+guard.Size, guard.Prot)
+	return nil
+}
+
+func unguard(t *Term, ctx callContext, args string) error {
+	if args == "-all" {
+		guards, err := t.client.ListMemoryGuards()
 		if err != nil {
 			return err
 		}
-		if len(locs) != 1 {
-			return errors.New("expression specifies multiple locations")
+		for _, guard := range guards {
+			if err := t.client.UnguardMemory(guard.ID); err != nil {
+				return err
+			}
 		}
-		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
-	default:
-		return disasmUsageError
+		return nil
 	}
-
-	if disasmErr != nil {
-		return disasmErr
+	id, err := strconv.Atoi(args)
+	if err != nil {
+		return fmt.Errorf("invalid guard id %q", args)
 	}
-
-	disasmPrint(disasm, os.Stdout)
-
-	return nil
+	return t.client.UnguardMemory(id)
 }
 
-func libraries(t *Term, ctx callContext, args string) error {
-	libs, err := t.client.ListDynamicLibraries()
+func branches(t *Term, ctx callContext, args string) error {
+	switch args {
+	case "on":
+		return t.client.EnableBranchTrace(true)
+	case "off":
+		return t.client.EnableBranchTrace(false)
+	}
+	count := 20
+	if args != "" {
+		var err error
+		count, err = strconv.Atoi(args)
+		if err != nil || count <= 0 {
+			return fmt.Errorf("invalid count %q", args)
+		}
+	}
+	branches, err := t.client.BranchHistory(0, count)
 	if err != nil {
 		return err
 	}
-	d := digits(len(libs))
-	for i := range libs {
-		fmt.Printf("%"+strconv.Itoa(d)+"d. %#x %s\n", i, libs[i].Address, libs[i].Path)
+	d := digits(len(branches) - 1)
+	s := strings.Repeat(" ", d+2)
+	for i, branch := range branches {
+		fmt.Printf("%*d  0x%016x in %s at %s:%d\n", d, i, branch.From.PC, branch.From.Function.Name(), t.formatPath(branch.From.File), branch.From.Line)
+		fmt.Printf("%s-> 0x%016x in %s at %s:%d\n", s, branch.To.PC, branch.To.Function.Name(), t.formatPath(branch.To.File), branch.To.Line)
 	}
 	return nil
 }
@@ -2215,6 +3729,24 @@ func printStack(t *Term, out io.Writer, stack []api.Stackframe, ind string, offs
 }
 
 func printcontext(t *Term, state *api.DebuggerState) {
+	for _, sig := range state.ReceivedSignals {
+		fmt.Printf("Thread %d received signal %s\n", sig.ThreadID, sig.Name)
+	}
+
+	for _, qbp := range state.QueuedBreakpoints {
+		name := strconv.Itoa(qbp.BreakpointID)
+		if qbp.BreakpointName != "" {
+			name = qbp.BreakpointName
+		}
+		fmt.Printf("Goroutine %d hit breakpoint %s at %s:%d (queued)\n", qbp.GoroutineID, name, t.formatPath(qbp.Location.File), qbp.Location.Line)
+	}
+
+	if fault := state.GuardFault; fault != nil {
+		fmt.Printf("Thread %d accessed guarded memory at %#x (guard %d), the instruction at %#x will fault again until the guard is removed\n", fault.ThreadID, fault.Addr, fault.GuardID, fault.PC)
+	}
+
+	printGCStatus(state.GC)
+
 	for i := range state.Threads {
 		if (state.CurrentThread != nil) && (state.Threads[i].ID == state.CurrentThread.ID) {
 			continue
@@ -2253,9 +3785,54 @@ func printcontext(t *Term, state *api.DebuggerState) {
 
 	printcontextThread(t, th)
 
+	for _, call := range state.InlinedCalls {
+		if !call.Entered {
+			fmt.Printf("Inlined call to %s, use 'step %s' to enter it.\n", call.Function.Name(), call.Function.Name())
+		}
+	}
+
 	if state.When != "" {
 		fmt.Println(state.When)
 	}
+
+	printWatches(state.Watches)
+}
+
+// printWatches prints the values of the watch expressions.
+func printWatches(watches []api.Watch) {
+	for _, w := range watches {
+		if w.Err != "" {
+			fmt.Printf("watch %d: %s = error %s\n", w.ID, w.Expr, w.Err)
+			continue
+		}
+		changed := ""
+		if w.Value.Changed {
+			changed = " (changed)"
+		}
+		fmt.Printf("watch %d: %s = %s%s\n", w.ID, w.Expr, w.Value.SinglelineString(), changed)
+	}
+}
+
+// printGCStatus prints a description of the state of the garbage
+// collector, if a GC cycle or a stop the world is in progress.
+func printGCStatus(gc *api.GCStatus) {
+	if gc == nil || (gc.Phase == "off" && !gc.StopTheWorld) {
+		return
+	}
+	if gc.Phase != "off" {
+		fmt.Printf("GC cycle %d in progress (%s phase)", gc.NumGC+1, gc.Phase)
+		if len(gc.Workers) > 0 {
+			workers := make([]string, len(gc.Workers))
+			for i := range gc.Workers {
+				workers[i] = strconv.Itoa(gc.Workers[i])
+			}
+			fmt.Printf(", GC worker goroutines: %s", strings.Join(workers, " "))
+		}
+		fmt.Println()
+	}
+	if gc.StopTheWorld {
+		fmt.Println("The world is stopped by the runtime")
+	}
 }
 
 func printcontextLocation(t *Term, loc api.Location) {
@@ -2398,6 +3975,14 @@ func printBreakpointInfo(t *Term, th *api.Thread, tracepointOnNewline bool) {
 }
 
 func printTracepoint(t *Term, th *api.Thread, bpname string, fn *api.Function, args string, hasReturnValue bool) {
+	if t.TraceSummary {
+		t.recordTraceSummary(th)
+		return
+	}
+	if t.TraceJSON {
+		printTracepointJSON(t, th)
+		return
+	}
 	if th.Breakpoint.Tracepoint {
 		fmt.Fprintf(os.Stderr, "> goroutine(%d): %s%s(%s)", th.GoroutineID, bpname, fn.Name(), args)
 		if !hasReturnValue {
@@ -2420,52 +4005,89 @@ func printTracepoint(t *Term, th *api.Thread, bpname string, fn *api.Function, a
 	}
 }
 
+// openSourceFile returns the contents of the source file filename, which
+// is read from the server if it can not be found locally, and its
+// modification time.
+func openSourceFile(t *Term, filename string) ([]byte, time.Time, error) {
+	file, err := os.Open(t.substitutePath(filename))
+	if err == nil {
+		defer file.Close()
+		fi, err := file.Stat()
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		buf, err := ioutil.ReadAll(file)
+		return buf, fi.ModTime(), err
+	}
+	// When connected to a headless instance running on a different machine
+	// the file may only exist there.
+	remote, err2 := t.client.GetSourceFile(filename)
+	if err2 != nil {
+		return nil, time.Time{}, err
+	}
+	return remote.Content, remote.ModTime, nil
+}
+
 func printfile(t *Term, filename string, line int, showArrow bool) error {
+	return printfileContext(t, filename, line, showArrow, t.conf.GetSourceListLineCount())
+}
+
+// printfileContext prints lineCount lines of filename above and below
+// line. Lines with a breakpoint are marked with '*' and, unless disabled
+// by the configuration, Go code is syntax highlighted.
+func printfileContext(t *Term, filename string, line int, showArrow bool, lineCount int) error {
 	if filename == "" {
 		return nil
 	}
-	file, err := os.Open(t.substitutePath(filename))
+	src, modTime, err := openSourceFile(t, filename)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	fi, _ := file.Stat()
 	lastModExe := t.client.LastModified()
-	if fi.ModTime().After(lastModExe) {
+	if modTime.After(lastModExe) {
 		fmt.Println("Warning: listing may not match stale executable")
 	}
 
-	lineCount := t.conf.GetSourceListLineCount()
+	var lines []string
+	if !t.dumb && !t.conf.DisableSyntaxHighlight && filepath.Ext(filename) == ".go" {
+		lines = colorizeSource(src, t.sourceStyles())
+	} else {
+		buf := bufio.NewScanner(bytes.NewReader(src))
+		for buf.Scan() {
+			lines = append(lines, buf.Text())
+		}
+	}
 
-	buf := bufio.NewScanner(file)
-	l := line
-	for i := 1; i < l-lineCount; i++ {
-		if !buf.Scan() {
-			return nil
+	bplines := map[int]bool{}
+	if bps, err := t.client.ListBreakpoints(); err == nil {
+		for _, bp := range bps {
+			if bp.ID >= 0 && bp.File == filename {
+				bplines[bp.Line] = true
+			}
 		}
 	}
 
-	s := l - lineCount
+	s := line - lineCount
 	if s < 1 {
 		s = 1
 	}
 
-	for i := s; i <= l+lineCount; i++ {
-		if !buf.Scan() {
-			return nil
-		}
-
+	for i := s; i <= line+lineCount && i <= len(lines); i++ {
 		var prefix string
 		if showArrow {
 			prefix = "  "
-			if i == l {
+			if i == line {
 				prefix = "=>"
 			}
 		}
+		mark := " "
+		if bplines[i] {
+			mark = "*"
+		}
 
-		prefix = fmt.Sprintf("%s%4d:\t", prefix, i)
-		t.Println(prefix, buf.Text())
+		prefix = fmt.Sprintf("%s%s%4d:\t", prefix, mark, i)
+		t.Println(prefix, lines[i-1])
 	}
 	return nil
 }
@@ -2549,6 +4171,26 @@ func (c *Commands) executeFile(t *Term, name string) error {
 			continue
 		}
 
+		if strings.HasPrefix(line, "def ") && !strings.Contains(line, "=") && !strings.HasPrefix(line, "def -d ") {
+			// multi-line definition
+			start := lineno
+			var body []string
+			for scanner.Scan() {
+				lineno++
+				l := strings.TrimSpace(scanner.Text())
+				if l == "end" {
+					break
+				}
+				if l != "" {
+					body = append(body, l)
+				}
+			}
+			if err := t.defineCommand(strings.TrimSpace(line[len("def "):]), strings.Join(body, "\n")); err != nil {
+				fmt.Printf("%s:%d: %v\n", name, start, err)
+			}
+			continue
+		}
+
 		if err := c.Call(line, t); err != nil {
 			if _, isExitRequest := err.(ExitRequestError); isExitRequest {
 				return err
@@ -2625,22 +4267,90 @@ func clearCheckpoint(t *Term, ctx callContext, args string) error {
 	return t.client.ClearCheckpoint(id)
 }
 
+func runToEvent(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return errors.New("not enough arguments to run-to-event")
+	}
+	event, err := strconv.ParseUint(args, 10, 64)
+	if err != nil {
+		return errors.New("run-to-event argument must be an event number")
+	}
+	if err := t.client.RunToEvent(event); err != nil {
+		return err
+	}
+	return printRecordingPosition(t)
+}
+
 func display(t *Term, ctx callContext, args string) error {
 	const (
 		addOption = "-a "
 		delOption = "-d "
 	)
+	if ctx.Format != "" {
+		if _, ok := displayFormats[ctx.Format]; !ok {
+			return fmt.Errorf("unknown format %q", ctx.Format)
+		}
+	}
 	switch {
 	case args == "":
 		t.printDisplays()
 
+	case strings.HasPrefix(args, delOption):
+		return undisplay(t, ctx, args[len(delOption):])
+
+	default:
+		if strings.HasPrefix(args, addOption) {
+			args = strings.TrimSpace(args[len(addOption):])
+		}
+		if args == "" || args == "-a" {
+			return fmt.Errorf("not enough arguments")
+		}
+		t.addDisplay(args, ctx.Format)
+		t.printDisplay(len(t.displays) - 1)
+	}
+	return nil
+}
+
+func undisplay(t *Term, ctx callContext, args string) error {
+	v := strings.Fields(args)
+	if len(v) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	for _, arg := range v {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", arg)
+		}
+		if err := t.removeDisplay(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func watchExpr(t *Term, ctx callContext, args string) error {
+	const (
+		addOption = "-a "
+		delOption = "-d "
+	)
+	switch {
+	case args == "":
+		state, err := t.client.GetState()
+		if err != nil {
+			return err
+		}
+		printWatches(state.Watches)
+
 	case strings.HasPrefix(args, addOption):
 		args = strings.TrimSpace(args[len(addOption):])
 		if args == "" {
 			return fmt.Errorf("not enough arguments")
 		}
-		t.addDisplay(args)
-		t.printDisplay(len(t.displays) - 1)
+		w, err := t.client.AddWatch(args, ShortLoadConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Watch %d added: %s\n", w.ID, w.Expr)
 
 	case strings.HasPrefix(args, delOption):
 		args = strings.TrimSpace(args[len(delOption):])
@@ -2648,7 +4358,7 @@ func display(t *Term, ctx callContext, args string) error {
 		if err != nil {
 			return fmt.Errorf("%q is not a number", args)
 		}
-		return t.removeDisplay(n)
+		return t.client.RemoveWatch(n)
 
 	default:
 		return fmt.Errorf("wrong arguments")
@@ -2656,6 +4366,146 @@ func display(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func session(t *Term, ctx callContext, args string) error {
+	v := strings.SplitN(args, " ", 2)
+	if len(v) != 2 || strings.TrimSpace(v[1]) == "" {
+		return errors.New("wrong number of arguments")
+	}
+	path := strings.TrimSpace(v[1])
+	switch v[0] {
+	case "save":
+		s, err := t.client.SaveSession(t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		buf, err := json.MarshalIndent(s, "", "\t")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, buf, 0644)
+
+	case "load":
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var s api.Session
+		if err := json.Unmarshal(buf, &s); err != nil {
+			return fmt.Errorf("could not parse %s: %v", path, err)
+		}
+		discarded, err := t.client.LoadSession(&s)
+		if err != nil {
+			return err
+		}
+		for i := range discarded {
+			fmt.Printf("Discarded %s at %s: %v\n", formatBreakpointName(discarded[i].Breakpoint, false), t.formatBreakpointLocation(discarded[i].Breakpoint), discarded[i].Reason)
+		}
+		if t.conf != nil && len(s.SubstitutePath) > 0 {
+		rulesLoop:
+			for _, r := range s.SubstitutePath {
+				for i := range t.conf.SubstitutePath {
+					if t.conf.SubstitutePath[i].From == r[0] {
+						t.conf.SubstitutePath[i].To = r[1]
+						continue rulesLoop
+					}
+				}
+				t.conf.SubstitutePath = append(t.conf.SubstitutePath, config.SubstitutePathRule{From: r[0], To: r[1]})
+			}
+			t.substitutePathRulesCache = nil
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown subcommand %q", v[0])
+	}
+}
+
+func hook(t *Term, ctx callContext, args string) error {
+	v := strings.Fields(args)
+	if len(v) == 0 {
+		v = []string{"list"}
+	}
+	switch v[0] {
+	case "add":
+		var h api.EventHook
+		v = v[1:]
+	flagsLoop:
+		for len(v) > 0 {
+			switch v[0] {
+			case "-resume":
+				h.Resume = true
+				v = v[1:]
+			case "-bp":
+				if len(v) < 2 {
+					return errors.New("not enough arguments to -bp")
+				}
+				h.Breakpoint = v[1]
+				v = v[2:]
+			default:
+				break flagsLoop
+			}
+		}
+		if len(v) != 2 {
+			return errors.New("wrong number of arguments")
+		}
+		switch v[0] {
+		case "stop":
+			h.On = api.EventStopped
+		case "exit":
+			h.On = api.EventExited
+		default:
+			return fmt.Errorf("unknown event %q", v[0])
+		}
+		buf, err := ioutil.ReadFile(v[1])
+		if err != nil {
+			return err
+		}
+		h.Script = string(buf)
+		hp, err := t.client.AddEventHook(h)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Hook %d added\n", hp.ID)
+		return nil
+
+	case "list":
+		hooks, err := t.client.ListEventHooks()
+		if err != nil {
+			return err
+		}
+		for _, h := range hooks {
+			fmt.Printf("Hook %d on %s", h.ID, h.On)
+			if h.Breakpoint != "" {
+				fmt.Printf(" at breakpoint %s", h.Breakpoint)
+			}
+			if h.Resume {
+				fmt.Printf(", resumes")
+			}
+			fmt.Printf(", ran %d times\n", h.Runs)
+			if h.Output != "" {
+				fmt.Printf("\t%s\n", strings.Replace(strings.TrimRight(h.Output, "\n"), "\n", "\n\t", -1))
+			}
+			if h.Err != "" {
+				fmt.Printf("\terror: %s\n", h.Err)
+			}
+		}
+		return nil
+
+	case "remove":
+		if len(v) != 2 {
+			return errors.New("wrong number of arguments")
+		}
+		id, err := strconv.Atoi(v[1])
+		if err != nil {
+			return fmt.Errorf("invalid hook ID %q", v[1])
+		}
+		return t.client.RemoveEventHook(id)
+
+	default:
+		return fmt.Errorf("unknown subcommand %q", v[0])
+	}
+}
+
 func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 	thing := "breakpoint"
 	if bp.Tracepoint {
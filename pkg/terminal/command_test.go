@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -699,6 +701,12 @@ func TestListCmd(t *testing.T) {
 		}
 		listIsAt(t, term, "list testvariables.go:1", -1, 1, 6)
 		listIsAt(t, term, "list testvariables.go:10000", -1, 0, 0)
+		listIsAt(t, term, "list -n 2", 27, 25, 29)
+		listIsAt(t, term, "list -n 2 69", 69, 67, 71)
+		term.MustExec("break testvariables.go:69")
+		if out := term.MustExec("list -n 0 69"); !strings.Contains(out, "*  69:") {
+			t.Fatalf("breakpoint not marked: %q", out)
+		}
 	})
 }
 
@@ -875,6 +883,80 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func TestUserCommands(t *testing.T) {
+	var term Term
+	term.conf = &config.Config{}
+	term.cmds = DebugCommands(nil)
+
+	if err := term.cmds.Call("def setlen = config max-string-len $1", &term); err != nil {
+		t.Fatal(err)
+	}
+	if findCmdName(term.cmds, "setlen", noPrefix) != "setlen" {
+		t.Fatalf("user command not found")
+	}
+	if err := term.cmds.Call("setlen 12", &term); err != nil {
+		t.Fatal(err)
+	}
+	if term.conf.MaxStringLen == nil || *term.conf.MaxStringLen != 12 {
+		t.Fatalf("user command not executed: %v", term.conf.MaxStringLen)
+	}
+	if err := term.cmds.Call("setlen", &term); err == nil || err.Error() != "setlen: missing argument $1" {
+		t.Fatalf("unexpected error for missing argument: %v", err)
+	}
+
+	if err := term.defineCommand("setboth", "setlen $1\nconfig max-array-values $2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := term.cmds.Call("setboth 3 4", &term); err != nil {
+		t.Fatal(err)
+	}
+	if *term.conf.MaxStringLen != 3 || term.conf.MaxArrayValues == nil || *term.conf.MaxArrayValues != 4 {
+		t.Fatalf("user command not executed: %d %v", *term.conf.MaxStringLen, term.conf.MaxArrayValues)
+	}
+
+	if err := term.cmds.Call("def print = config max-string-len 1", &term); err == nil {
+		t.Fatalf("redefined builtin command")
+	}
+	if err := term.defineCommand("loop", "loop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := term.cmds.Call("loop", &term); err == nil {
+		t.Fatalf("no error for recursive command")
+	}
+
+	if err := term.cmds.Call("def -d setlen", &term); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := term.conf.Commands["setlen"]; ok || findCmdName(term.cmds, "setlen", noPrefix) != "" {
+		t.Fatalf("user command not removed")
+	}
+}
+
+func TestExpandUserCommand(t *testing.T) {
+	vars := map[string]string{"file": "/a/main.go", "line": "10"}
+	testCases := []struct {
+		body string
+		args []string
+		tgt  string
+	}{
+		{"break %file:%line", nil, "break /a/main.go:10"},
+		{"print $1 + $2", []string{"a", "b"}, "print a + b"},
+		{"call f($*)", []string{"1,", "2"}, "call f(1, 2)"},
+		{`print fmt.Sprintf("%d", x)`, nil, `print fmt.Sprintf("%d", x)`},
+		{"print $$1 %%line", []string{"a"}, "print $1 %line"},
+	}
+	for _, tc := range testCases {
+		out, err := expandUserCommand(tc.body, tc.args, vars)
+		if err != nil {
+			t.Errorf("%q: %v", tc.body, err)
+			continue
+		}
+		if out != tc.tgt {
+			t.Errorf("%q: got %q expected %q", tc.body, out, tc.tgt)
+		}
+	}
+}
+
 func TestDisassembleAutogenerated(t *testing.T) {
 	// Executing the 'disassemble' command on autogenerated code should work correctly
 
@@ -1075,6 +1157,37 @@ func TestExamineMemoryCmd(t *testing.T) {
 	})
 }
 
+func TestExamineTypedMemoryCmd(t *testing.T) {
+	withTestTerminal("examinememory", t, func(term *FakeTerminal) {
+		term.MustExec("break examinememory.go:19")
+		term.MustExec("continue")
+
+		addressStr := strings.TrimSpace(term.MustExec("p bspUintptr"))
+		address, err := strconv.ParseInt(addressStr, 0, 64)
+		if err != nil {
+			t.Fatalf("could convert %s into int64, err %s", addressStr, err)
+		}
+
+		res := term.MustExec("x -t uint8 " + addressStr)
+		if tgt := fmt.Sprintf("%#x: 10\n", address); res != tgt {
+			t.Errorf("wrong output %q (expected %q)", res, tgt)
+		}
+
+		res = term.MustExec("x -t uint16 2 " + addressStr)
+		t.Logf("the result of examining memory \n%s", res)
+		for _, tgt := range []string{fmt.Sprintf("%#x: 2826\n", address), fmt.Sprintf("%#x: 3340\n", address+2)} {
+			if !strings.Contains(res, tgt) {
+				t.Errorf("expected line %q", tgt)
+			}
+		}
+
+		_, err = term.Exec("x -t uint16 -size 2 " + addressStr)
+		if err == nil {
+			t.Errorf("no error using -t with -size")
+		}
+	})
+}
+
 func TestPrintOnTracepoint(t *testing.T) {
 	withTestTerminal("increment", t, func(term *FakeTerminal) {
 		term.MustExec("trace main.Increment")
@@ -1140,3 +1253,175 @@ func TestParseNewArgv(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSearchValue(t *testing.T) {
+	for _, tc := range []struct {
+		in, value, expr string
+	}{
+		{`"abc123" req`, "abc123", "req"},
+		{`"a \"b\" c" m["k"]`, `a "b" c`, `m["k"]`},
+		{"`raw string` x.y", "raw string", "x.y"},
+		{"42 cache.entries", "42", "cache.entries"},
+		{"true", "true", ""},
+	} {
+		value, expr, err := parseSearchValue(tc.in)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if value != tc.value || expr != tc.expr {
+			t.Errorf("%q: got %q %q expected %q %q", tc.in, value, expr, tc.value, tc.expr)
+		}
+	}
+	if _, _, err := parseSearchValue(`"unterminated req`); err == nil {
+		t.Errorf("no error for unterminated string")
+	}
+}
+
+func TestParseGoroutinesArgs(t *testing.T) {
+	fgl, flags, filters, group, err := parseGoroutinesArgs("-s -t -with userloc main.go -without label k=v -with running -group label k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fgl != fglStart || flags != printGoroutinesStack {
+		t.Errorf("wrong flags %v %v", fgl, flags)
+	}
+	wantFilters := []api.ListGoroutinesFilter{
+		{Kind: api.GoroutineUserLoc, Arg: "main.go"},
+		{Kind: api.GoroutineLabel, Negated: true, Arg: "k=v"},
+		{Kind: api.GoroutineRunning},
+	}
+	if !reflect.DeepEqual(filters, wantFilters) {
+		t.Errorf("wrong filters %#v", filters)
+	}
+	if group.GroupBy != api.GoroutineLabel || group.GroupByKey != "k" || group.MaxGroupMembers != maxGroupMembers {
+		t.Errorf("wrong grouping %#v", group)
+	}
+
+	fgl, flags, filters, _, err = parseGoroutinesArgs("-r -t -filter waitreason == \"chan receive\" && waitfor > \"1m\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fgl != fglRuntimeCurrent || flags != printGoroutinesStack {
+		t.Errorf("wrong flags %v %v", fgl, flags)
+	}
+	wantFilters = []api.ListGoroutinesFilter{{Kind: api.GoroutineExpr, Arg: `waitreason == "chan receive" && waitfor > "1m"`}}
+	if !reflect.DeepEqual(filters, wantFilters) {
+		t.Errorf("wrong filters %#v", filters)
+	}
+
+	for _, args := range []string{"-with", "-with userloc", "-with foo", "-group label", "-group user -group running", "-tree -with user", "-filter", "-tree -filter user"} {
+		if _, _, _, _, err := parseGoroutinesArgs(args); err == nil {
+			t.Errorf("%q: no error", args)
+		}
+	}
+}
+
+func TestWriteObjectGraphDOT(t *testing.T) {
+	g := &api.ObjectGraph{
+		Nodes: []api.ObjectGraphNode{
+			{Addr: 0xc000010000, Type: "*main.Node", Size: 8, Root: "a"},
+			{Addr: 0xc000020000, Type: "main.Node", Size: 24},
+		},
+		Edges: []api.ObjectGraphEdge{
+			{From: 0, To: 1},
+			{From: 1, To: 1, Label: ".Next"},
+		},
+	}
+	var buf bytes.Buffer
+	writeObjectGraphDOT(&buf, g)
+	tgt := `digraph objects {
+	node [shape=box, fontname=monospace];
+	n0 [label="a\n*main.Node", style=bold];
+	n1 [label="main.Node\n0xc000020000 (24 bytes)"];
+	n0 -> n1 [label=""];
+	n1 -> n1 [label=".Next"];
+}
+`
+	if out := buf.String(); out != tgt {
+		t.Errorf("wrong output:\n%s\nexpected:\n%s", out, tgt)
+	}
+}
+
+func TestWatchExpr(t *testing.T) {
+	withTestTerminal("loopprog", t, func(term *FakeTerminal) {
+		term.MustExec("break loopprog.go:8")
+		term.MustExec("continue")
+		term.AssertExec("watchexpr -a i", "Watch 1 added: i\n")
+		out := term.MustExec("continue")
+		if !strings.Contains(out, "watch 1: i = 1 (changed)\n") {
+			t.Errorf("wrong output after continue: %q", out)
+		}
+		term.AssertExec("watchexpr", "watch 1: i = 1 (changed)\n")
+		term.AssertExecError("watchexpr -a i", `expression "i" is already watched by watch 1`)
+		term.MustExec("watchexpr -d 1")
+		out = term.MustExec("continue")
+		if strings.Contains(out, "watch 1") {
+			t.Errorf("removed watch printed: %q", out)
+		}
+	})
+}
+
+func TestCompleteExpr(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		testCases := []struct {
+			line string
+			tgt  []string
+		}{
+			{"print as1.", []string{"print as1.A", "print as1.B"}},
+			{"p c1.p", []string{"p c1.pb"}},
+			{"p c1.pb.", []string{"p c1.pb.a"}},
+			{`print zsvmap["te`, []string{`print zsvmap["testkey"]`}},
+			{"print m2[", []string{"print m2[1]"}},
+			{"print m1[", nil},
+			{"whatis as", []string{"whatis as1"}},
+			{"print 1 + mai", []string{"print 1 + main.main"}},
+			{"list as", nil},
+		}
+		for _, tc := range testCases {
+			c := term.completeExpr(tc.line)
+			if !reflect.DeepEqual(c, tc.tgt) && !(len(c) == 0 && len(tc.tgt) == 0) {
+				t.Errorf("%q: got %q expected %q", tc.line, c, tc.tgt)
+			}
+		}
+	})
+}
+
+func TestDisplay(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.AssertExec("display as1.A", "0: as1.A = 1\n")
+		term.AssertExec("display/x -a len(s2)", "1: /x len(s2) = 0x8\n")
+		term.AssertExec("display", "0: as1.A = 1\n1: /x len(s2) = 0x8\n")
+		term.MustExec("undisplay 0")
+		term.AssertExec("display", "1: /x len(s2) = 0x8\n")
+		term.AssertExecError("undisplay 0", "0 is out of range")
+		term.AssertExecError("display/y as1", `unknown format "y"`)
+		term.AssertExecError("print/x as1", "command print does not accept a format")
+	})
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.conf.OnStop = []string{"config max-string-len 7"}
+		term.conf.OnExit = []string{"config max-array-values 3"}
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		if term.conf.MaxStringLen == nil || *term.conf.MaxStringLen != 7 {
+			t.Fatalf("on-stop hook not executed: %v", term.conf.MaxStringLen)
+		}
+		if term.conf.MaxArrayValues != nil {
+			t.Fatalf("on-exit hook executed on stop")
+		}
+		term.Exec("continue")
+		if term.conf.MaxArrayValues == nil || *term.conf.MaxArrayValues != 3 {
+			t.Fatalf("on-exit hook not executed: %v", term.conf.MaxArrayValues)
+		}
+		*term.conf.MaxArrayValues = 1
+		term.Exec("continue")
+		if *term.conf.MaxArrayValues != 1 {
+			t.Fatalf("on-exit hook executed twice")
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package terminal
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// completionMaxMapKeys is the maximum number of entries of a map for its
+// keys to be completed.
+const completionMaxMapKeys = 64
+
+// completionLoadConfig is the configuration used to load the value of the
+// expression whose fields or keys are being completed.
+var completionLoadConfig = api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: completionMaxMapKeys, MaxStructFields: -1}
+
+// exprCompletionCmds are the commands whose argument is completed as an
+// expression by completeExpr.
+var exprCompletionCmds = []string{"print", "whatis", "set", "call", "display"}
+
+// completeExpr returns the completions of line, if it is one of
+// exprCompletionCmds followed by a partial expression. The last operand of
+// the expression is completed with:
+//   - the fields of a struct, after 'expr.'
+//   - the keys of a small map, after 'expr['
+//   - package variables and functions, after 'pkg.'
+//   - local variables, function arguments, package variables and functions
+//     otherwise.
+func (t *Term) completeExpr(line string) []string {
+	fields := strings.SplitN(line, " ", 2)
+	if len(fields) != 2 || !t.isExprCompletionCmd(strings.SplitN(fields[0], "/", 2)[0]) {
+		return nil
+	}
+	argStart := len(fields[0]) + 1
+	start, base, partial, isKey := splitCompletionExpr(line[argStart:])
+	prefix := line[:argStart+start]
+	scope := api.EvalScope{GoroutineID: -1, Frame: t.cmds.frame}
+
+	var c []string
+	switch {
+	case isKey:
+		v, err := t.client.EvalVariable(scope, base, completionLoadConfig)
+		if err != nil {
+			return nil
+		}
+		for _, key := range completionMapKeys(v) {
+			if strings.HasPrefix(key, partial) {
+				c = append(c, prefix+key+"]")
+			}
+		}
+
+	case base != "":
+		if v, err := t.client.EvalVariable(scope, base, completionLoadConfig); err == nil {
+			for _, field := range completionFields(v) {
+				if strings.HasPrefix(field, partial) {
+					c = append(c, prefix+base+"."+field)
+				}
+			}
+			break
+		}
+		// base is not a variable, it could be the name of a package.
+		for _, name := range t.completePackageSymbols(base + "." + partial) {
+			c = append(c, prefix+name)
+		}
+
+	case partial != "":
+		for _, name := range t.completionLocals(scope) {
+			if strings.HasPrefix(name, partial) {
+				c = append(c, prefix+name)
+			}
+		}
+		for _, name := range t.completePackageSymbols(partial) {
+			c = append(c, prefix+name)
+		}
+	}
+
+	sort.Strings(c)
+	r := c[:0]
+	for i := range c {
+		if i == 0 || c[i] != c[i-1] {
+			r = append(r, c[i])
+		}
+	}
+	return r
+}
+
+func (t *Term) isExprCompletionCmd(cmdname string) bool {
+	for _, cmd := range t.cmds.cmds {
+		if !cmd.match(cmdname) {
+			continue
+		}
+		for _, name := range exprCompletionCmds {
+			if cmd.aliases[0] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// completionLocals returns the names of the local variables and arguments
+// of the function of scope.
+func (t *Term) completionLocals(scope api.EvalScope) []string {
+	var r []string
+	args, _ := t.client.ListFunctionArgs(scope, api.LoadConfig{})
+	locals, _ := t.client.ListLocalVariables(scope, api.LoadConfig{})
+	for _, v := range append(args, locals...) {
+		r = append(r, v.Name)
+	}
+	return r
+}
+
+// completePackageSymbols returns the package variables and functions whose
+// name, without the path of their package, starts with partial.
+func (t *Term) completePackageSymbols(partial string) []string {
+	filter := "(^|/)" + regexp.QuoteMeta(partial)
+	rx := regexp.MustCompile(filter)
+	var names []string
+	vars, _ := t.client.ListPackageVariables(filter, api.LoadConfig{})
+	for _, v := range vars {
+		names = append(names, v.Name)
+	}
+	funcs, _ := t.client.ListFunctions(filter)
+	names = append(names, funcs...)
+
+	var r []string
+	for _, name := range names {
+		loc := rx.FindStringIndex(name)
+		if loc == nil {
+			continue
+		}
+		if name[loc[0]] == '/' {
+			loc[0]++
+		}
+		name = name[loc[0]:]
+		if strings.ContainsAny(name, "/(*") {
+			// methods and closures can not be referenced directly
+			continue
+		}
+		r = append(r, name)
+	}
+	return r
+}
+
+// completionFields returns the names of the fields of v, which can be a
+// struct or a pointer or interface containing a struct.
+func completionFields(v *api.Variable) []string {
+	for (v.Kind == reflect.Ptr || v.Kind == reflect.Interface) && len(v.Children) == 1 {
+		v = &v.Children[0]
+	}
+	if v.Kind != reflect.Struct {
+		return nil
+	}
+	r := make([]string, 0, len(v.Children))
+	for i := range v.Children {
+		r = append(r, v.Children[i].Name)
+	}
+	return r
+}
+
+// completionMapKeys returns the keys of map v, formatted as Go expressions,
+// if all of them were loaded.
+func completionMapKeys(v *api.Variable) []string {
+	if v.Kind != reflect.Map || v.Len > completionMaxMapKeys || int64(len(v.Children)/2) != v.Len {
+		return nil
+	}
+	r := make([]string, 0, v.Len)
+	for i := 0; i < len(v.Children); i += 2 {
+		key := &v.Children[i]
+		switch key.Kind {
+		case reflect.String:
+			r = append(r, strconv.Quote(key.Value))
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64:
+			r = append(r, key.Value)
+		}
+	}
+	return r
+}
+
+// splitCompletionExpr finds the operand of expression s that ends at the
+// end of s. Start is the offset of the operand in s. If the operand is a
+// partial map key, after '[', isKey is true and base is the expression of
+// the map. Otherwise, if the operand contains a selector, base is the
+// expression before the last '.'. Partial is the rest of the operand.
+func splitCompletionExpr(s string) (start int, base, partial string, isKey bool) {
+	var stack []int
+	inString := false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case inString:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '[' || ch == '(':
+			stack = append(stack, start)
+			start = i + 1
+		case ch == ']' || ch == ')':
+			if len(stack) > 0 {
+				start = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case ch == '_' || ch == '.' || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9') || ch >= 0x80:
+			// part of an operand
+		default:
+			start = i + 1
+		}
+	}
+
+	operand := s[start:]
+	if start > 0 && s[start-1] == '[' && len(stack) > 0 {
+		return start, s[stack[len(stack)-1] : start-1], operand, true
+	}
+	if inString {
+		return start, "", "", false
+	}
+	if dot := strings.LastIndex(operand, "."); dot >= 0 {
+		return start, operand[:dot], operand[dot+1:], false
+	}
+	return start, "", operand, false
+}
@@ -2,8 +2,11 @@ package terminal
 
 import (
 	"fmt"
+	"go/build"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -155,6 +158,24 @@ func configureSet(t *Term, args string) error {
 
 func configureSetSubstitutePath(t *Term, rest string) error {
 	argv := config.SplitQuotedFields(rest, '"')
+	// The rules are cached by substitutePathRules.
+	defer func() { t.substitutePathRulesCache = nil }()
+	if len(argv) > 0 {
+		switch argv[0] {
+		case "-list":
+			return configureListSubstitutePath(t)
+		case "-clear":
+			t.conf.SubstitutePath = nil
+			return nil
+		case "-add":
+			if len(argv) != 3 {
+				return fmt.Errorf("wrong number of arguments to \"config substitute-path -add\"")
+			}
+			argv = argv[1:]
+		case "-guess":
+			return configureGuessSubstitutePath(t, len(argv) == 2 && argv[1] == "-apply")
+		}
+	}
 	switch len(argv) {
 	case 1: // delete substitute-path rule
 		for i := range t.conf.SubstitutePath {
@@ -179,6 +200,130 @@ func configureSetSubstitutePath(t *Term, rest string) error {
 	return nil
 }
 
+func configureListSubstitutePath(t *Term) error {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, ' ', 0)
+	for _, r := range t.conf.SubstitutePath {
+		fmt.Fprintf(w, "%q\t=> %q\n", r.From, r.To)
+	}
+	return w.Flush()
+}
+
+// configureGuessSubstitutePath prints the rules proposed by
+// guessSubstitutePath for the source files of the target, if apply is true
+// they are also added to the configuration.
+func configureGuessSubstitutePath(t *Term, apply bool) error {
+	sources, err := t.client.ListSources("")
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, source := range sources {
+		if _, err := os.Stat(t.substitutePath(source)); err != nil {
+			missing = append(missing, source)
+		}
+	}
+	guesses := guessSubstitutePath(missing, localSourceRoots(), func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	if len(guesses) == 0 {
+		fmt.Println("No substitute-path rules found")
+		return nil
+	}
+	for _, g := range guesses {
+		fmt.Printf("config substitute-path %q %q\t# %d files\n", g.rule.From, g.rule.To, g.files)
+		if apply {
+			t.conf.SubstitutePath = append(t.conf.SubstitutePath, g.rule)
+		}
+	}
+	return nil
+}
+
+// localSourceRoots returns the directories where source files of the
+// target could be found: the current directory, GOROOT, the GOPATH
+// directories and the module cache.
+func localSourceRoots() []string {
+	var roots []string
+	if wd, err := os.Getwd(); err == nil {
+		roots = append(roots, wd)
+	}
+	roots = append(roots, build.Default.GOROOT)
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	roots = append(roots, gopath...)
+	if modcache := os.Getenv("GOMODCACHE"); modcache != "" {
+		roots = append(roots, modcache)
+	} else if len(gopath) > 0 {
+		roots = append(roots, filepath.Join(gopath[0], "pkg", "mod"))
+	}
+	return roots
+}
+
+// substitutePathGuess is a rule proposed by guessSubstitutePath and the
+// number of files it applies to.
+type substitutePathGuess struct {
+	rule  config.SubstitutePathRule
+	files int
+}
+
+// guessSubstitutePath proposes substitute-path rules for sources, the
+// source files that can not be found locally. For the directory of each
+// source file the longest suffix of its path that exists in one of roots
+// is searched, the rest of the path is replaced by that root. The root of
+// the first directory, the current directory, is also used for files that
+// are directly inside of it. Rules are returned ordered by the number of
+// files they apply to.
+func guessSubstitutePath(sources []string, roots []string, exists func(string) bool) []substitutePathGuess {
+	counts := map[config.SubstitutePathRule]int{}
+	dirRules := map[string]*config.SubstitutePathRule{}
+	for _, source := range sources {
+		source = filepath.ToSlash(source)
+		slash := strings.LastIndex(source, "/")
+		if slash < 0 {
+			continue
+		}
+		dir := source[:slash]
+		if rule, ok := dirRules[dir]; ok {
+			if rule != nil {
+				counts[*rule]++
+			}
+			continue
+		}
+		dirRules[dir] = nil
+		v := strings.Split(strings.TrimLeft(source, "/"), "/")
+	suffixLoop:
+		for i := 1; i < len(v); i++ {
+			suffix := strings.Join(v[i:], "/")
+			for j, root := range roots {
+				if root == "" || (i == len(v)-1 && j != 0) {
+					continue
+				}
+				if exists(filepath.Join(root, filepath.FromSlash(suffix))) {
+					from := strings.TrimSuffix(strings.TrimSuffix(source, suffix), "/")
+					rule := config.SubstitutePathRule{From: filepath.FromSlash(from), To: root}
+					dirRules[dir] = &rule
+					counts[rule]++
+					break suffixLoop
+				}
+			}
+		}
+	}
+
+	var r []substitutePathGuess
+	for rule, n := range counts {
+		if rule.From != rule.To && rule.From != "" {
+			r = append(r, substitutePathGuess{rule, n})
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].files != r[j].files {
+			return r[i].files > r[j].files
+		}
+		return r[i].rule.From < r[j].rule.From
+	})
+	return r
+}
+
 func configureSetAlias(t *Term, rest string) error {
 	argv := config.SplitQuotedFields(rest, '"')
 	switch len(argv) {
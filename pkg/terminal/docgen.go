@@ -31,7 +31,9 @@ func (commands *Commands) WriteMarkdown(w io.Writer) {
 	fmt.Fprint(w, "If `$XDG_CONFIG_HOME` is set, then configuration and command history files are located in `$XDG_CONFIG_HOME/dlv`. ")
 	fmt.Fprint(w, "Otherwise, they are located in `$HOME/.config/dlv` on Linux and `$HOME/.dlv` on other systems.\n\n")
 	fmt.Fprint(w, "The configuration file `config.yml` contains all the configurable options and their default values. ")
-	fmt.Fprint(w, "The command history is stored in `.dbg_history`.\n\n")
+	fmt.Fprint(w, "The command history is stored in `.dbg_history`, unless a different file is specified by the `history-file` option, and can be searched with Ctrl-R or the `history` command.\n\n")
+	fmt.Fprint(w, "The output of commands that does not fit in the terminal, for example the output of `goroutines`, is shown with the program specified by `$PAGER` or, if it is not set, with an internal pager, unless the `disable-pager` option is set.\n\n")
+	fmt.Fprint(w, "The output of a command can be filtered by following it with `|>` and one or more of `grep [-v] [-i] <regexp>`, `head [<n>]`, `tail [<n>]` and `wc`, separated by `|>`, for example `goroutines |> grep main |> head 5`.\n\n")
 
 	fmt.Fprint(w, "# Commands\n")
 
@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/peterh/liner"
+
+	"github.com/go-delve/delve/pkg/config"
+)
+
+// historyFilePath returns the path of the history file, the history-file
+// option of the configuration or .dbg_history in the configuration
+// directory.
+func (t *Term) historyFilePath() (string, error) {
+	if t.conf.HistoryFile != "" {
+		return t.conf.HistoryFile, nil
+	}
+	return config.GetConfigFilePath(historyFile)
+}
+
+// loadHistory opens the history file and loads the commands of previous
+// sessions into the line editor.
+func (t *Term) loadHistory() {
+	for _, expr := range t.conf.HistoryExclude {
+		rx, err := regexp.Compile(expr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid history exclusion %q: %v\n", expr, err)
+			continue
+		}
+		t.historyExclude = append(t.historyExclude, rx)
+	}
+
+	fullHistoryFile, err := t.historyFilePath()
+	if err != nil {
+		fmt.Printf("Unable to load history file: %v.", err)
+		return
+	}
+
+	t.historyFile, err = os.OpenFile(fullHistoryFile, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		fmt.Printf("Unable to open history file: %v. History will not be saved for this session.", err)
+		return
+	}
+	s := bufio.NewScanner(t.historyFile)
+	for s.Scan() {
+		if l := s.Text(); l != "" && !t.historyExcluded(l) {
+			t.history = append(t.history, l)
+		}
+	}
+	if err := s.Err(); err != nil {
+		fmt.Printf("Unable to read history file: %v", err)
+	}
+	if len(t.history) > liner.HistoryLimit {
+		t.history = t.history[len(t.history)-liner.HistoryLimit:]
+	}
+	if _, err := t.line.ReadHistory(strings.NewReader(strings.Join(t.history, "\n"))); err != nil {
+		fmt.Printf("Unable to read history file: %v", err)
+	}
+}
+
+// appendHistory adds l to the history, unless it matches one of the
+// history-exclude expressions of the configuration.
+func (t *Term) appendHistory(l string) {
+	if t.historyExcluded(l) {
+		return
+	}
+	t.line.AppendHistory(l)
+	if len(t.history) > 0 && t.history[len(t.history)-1] == l {
+		return
+	}
+	t.history = append(t.history, l)
+	if len(t.history) > liner.HistoryLimit {
+		t.history = t.history[1:]
+	}
+}
+
+func (t *Term) historyExcluded(l string) bool {
+	for _, rx := range t.historyExclude {
+		if rx.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveHistory replaces the contents of the history file with the history
+// of the session and closes it.
+func (t *Term) saveHistory() {
+	if t.historyFile == nil {
+		return
+	}
+	_, err := t.historyFile.Seek(0, io.SeekStart)
+	if err == nil {
+		err = t.historyFile.Truncate(0)
+	}
+	if err == nil {
+		_, err = t.line.WriteHistory(t.historyFile)
+	}
+	if err != nil {
+		fmt.Println("readline history error:", err)
+	}
+	if err := t.historyFile.Close(); err != nil {
+		fmt.Printf("error closing history file: %s\n", err)
+	}
+	t.historyFile = nil
+}
+
+func historyCommand(t *Term, ctx callContext, args string) error {
+	var pattern string
+	n := len(t.history)
+	v := strings.Fields(args)
+	for len(v) > 0 {
+		switch {
+		case v[0] == "-s" && len(v) > 1:
+			pattern = v[1]
+			v = v[2:]
+		case v[0] == "-c":
+			t.history = nil
+			t.line.ClearHistory()
+			return nil
+		default:
+			var err error
+			n, err = strconv.Atoi(v[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid argument %q", v[0])
+			}
+			v = v[1:]
+		}
+	}
+
+	var entries []int
+	for i := len(t.history) - 1; i >= 0 && len(entries) < n; i-- {
+		if strings.Contains(t.history[i], pattern) {
+			entries = append(entries, i)
+		}
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Printf("%5d  %s\n", entries[i]+1, t.history[entries[i]])
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	isatty "github.com/mattn/go-isatty"
+)
+
+// pagedCommands are the commands whose output is shown with the pager, if
+// it does not fit in the terminal.
+var pagedCommands = []string{"goroutines", "threads", "print", "locals", "args", "vars", "stack", "funcs", "types", "sources", "breakpoints", "disassemble", "examinemem", "regs"}
+
+// callPaged executes cmdstr, after applying its output filters, see
+// splitOutputFilters. If the command is one of pagedCommands its output is
+// shown with the pager, see page.
+func (t *Term) callPaged(cmdstr string) error {
+	cmdstr, filters, err := splitOutputFilters(cmdstr)
+	if err != nil {
+		return err
+	}
+	paged := t.pagerEnabled(cmdstr)
+	if len(filters) == 0 && !paged {
+		return t.cmds.Call(cmdstr, t)
+	}
+	out, err := t.captureOutput(func() error {
+		return t.cmds.Call(cmdstr, t)
+	})
+	for _, filter := range filters {
+		out = filter(out)
+	}
+	if paged {
+		t.page(out)
+	} else {
+		os.Stdout.Write(out)
+	}
+	return err
+}
+
+// captureOutput calls fn and returns what it wrote to stdout.
+func (t *Term) captureOutput(fn func() error) ([]byte, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fn()
+	}
+	done := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		r.Close()
+		done <- out
+	}()
+	stdout, termStdout := os.Stdout, t.stdout
+	os.Stdout, t.stdout = w, w
+	err = fn()
+	os.Stdout, t.stdout = stdout, termStdout
+	w.Close()
+	return <-done, err
+}
+
+func (t *Term) pagerEnabled(cmdstr string) bool {
+	if t.dumb || t.conf.DisablePager || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return false
+	}
+	name := strings.SplitN(strings.SplitN(strings.TrimSpace(cmdstr), " ", 2)[0], "/", 2)[0]
+	for _, cmd := range t.cmds.cmds {
+		if !cmd.match(name) {
+			continue
+		}
+		for _, paged := range pagedCommands {
+			if cmd.aliases[0] == paged {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// page writes out to stdout, if it is longer than the terminal it is shown
+// with the program specified by $PAGER or, if it is not set, with the
+// internal pager.
+func (t *Term) page(out []byte) {
+	rows, _, err := getTerminalSize()
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if err != nil || rows < 2 || len(lines) < rows {
+		os.Stdout.Write(out)
+		return
+	}
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 {
+		cmd := exec.Command(pager[0], pager[1:]...)
+		cmd.Stdin = bytes.NewReader(out)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err == nil {
+			return
+		}
+	}
+	runPager(os.Stdout, lines, rows-1, t.line.Prompt)
+}
+
+// runPager writes lines to w, pageLen lines at a time. After every page
+// prompt is called to read a command:
+//
+//	<enter>	shows the next page
+//	b		shows the previous page
+//	<n>		shows the page starting at line n
+//	/<pattern>	shows the page starting at the next line containing pattern
+//	n		repeats the last search
+//	q		stops the pager
+func runPager(w io.Writer, lines []string, pageLen int, prompt func(string) (string, error)) {
+	top := 0
+	pattern := ""
+	show := true
+	for {
+		end := top + pageLen
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if show {
+			for _, line := range lines[top:end] {
+				fmt.Fprintln(w, line)
+			}
+			if end >= len(lines) {
+				return
+			}
+		}
+		show = true
+		cmd, err := prompt(fmt.Sprintf("--More-- (%d%%) ", end*100/len(lines)))
+		if err != nil {
+			return
+		}
+		cmd = strings.TrimSpace(cmd)
+		switch {
+		case cmd == "":
+			top = end
+		case cmd == "q":
+			return
+		case cmd == "b":
+			top -= pageLen
+			if top < 0 {
+				top = 0
+			}
+		case strings.HasPrefix(cmd, "/") || cmd == "n":
+			if cmd != "n" {
+				pattern = cmd[1:]
+			}
+			found := -1
+			if pattern != "" {
+				for i := top + 1; i < len(lines); i++ {
+					if strings.Contains(lines[i], pattern) {
+						found = i
+						break
+					}
+				}
+			}
+			if found < 0 {
+				fmt.Fprintln(w, "Pattern not found")
+				show = false
+				break
+			}
+			top = found
+		default:
+			n, err := strconv.Atoi(cmd)
+			if err != nil || n < 1 || n > len(lines) {
+				fmt.Fprintln(w, "Commands: <enter> next page, b previous page, <n> go to line n, /<pattern> search, n repeat search, q quit")
+				show = false
+				break
+			}
+			top = n - 1
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/config"
+)
+
+// outputFilter transforms the output of a command.
+type outputFilter func(out []byte) []byte
+
+// outputFilters are the filters that can follow a command after '|>'.
+var outputFilters = map[string]func(args []string) (outputFilter, error){
+	"grep": grepFilter,
+	"head": func(args []string) (outputFilter, error) { return lineCountFilter("head", args, true) },
+	"tail": func(args []string) (outputFilter, error) { return lineCountFilter("tail", args, false) },
+	"wc":   wcFilter,
+}
+
+// splitOutputFilters splits cmdstr into the command and the filters of its
+// output, specified as 'command |> filter args... |> filter args...'. A
+// '|' followed by '>' is not valid in expressions, unlike a single '|',
+// therefore the arguments of a command are never mistaken for a filter.
+// Occurrences of '|>' inside string literals are ignored.
+func splitOutputFilters(cmdstr string) (string, []outputFilter, error) {
+	var parts []string
+	inString := byte(0)
+	start := 0
+	for i := 0; i < len(cmdstr); i++ {
+		ch := cmdstr[i]
+		switch {
+		case inString != 0:
+			if ch == '\\' && inString == '"' {
+				i++
+			} else if ch == inString {
+				inString = 0
+			}
+		case ch == '"' || ch == '`' || ch == '\'':
+			inString = ch
+		case ch == '|' && i+1 < len(cmdstr) && cmdstr[i+1] == '>':
+			parts = append(parts, cmdstr[start:i])
+			i++
+			start = i + 1
+		}
+	}
+	parts = append(parts, cmdstr[start:])
+
+	var filters []outputFilter
+	for _, part := range parts[1:] {
+		args := config.SplitQuotedFields(strings.TrimSpace(part), '"')
+		if len(args) == 0 {
+			return "", nil, errors.New("missing output filter after '|>'")
+		}
+		newFilter, ok := outputFilters[args[0]]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown output filter %q", args[0])
+		}
+		filter, err := newFilter(args[1:])
+		if err != nil {
+			return "", nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return strings.TrimSpace(parts[0]), filters, nil
+}
+
+func splitOutputLines(out []byte) [][]byte {
+	if len(out) == 0 {
+		return nil
+	}
+	return bytes.SplitAfter(bytes.TrimSuffix(out, []byte("\n")), []byte("\n"))
+}
+
+func joinOutputLines(lines [][]byte) []byte {
+	out := bytes.Join(lines, nil)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// grepFilter returns a filter that selects the lines matching a regular
+// expression: grep [-v] [-i] <regexp>
+func grepFilter(args []string) (outputFilter, error) {
+	invert, ignoreCase := false, false
+options:
+	for len(args) > 0 {
+		switch args[0] {
+		case "-v":
+			invert = true
+		case "-i":
+			ignoreCase = true
+		default:
+			break options
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: grep [-v] [-i] <regexp>")
+	}
+	expr := args[0]
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	rx, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("grep: %v", err)
+	}
+	return func(out []byte) []byte {
+		var r [][]byte
+		for _, line := range splitOutputLines(out) {
+			if rx.Match(line) != invert {
+				r = append(r, line)
+			}
+		}
+		return joinOutputLines(r)
+	}, nil
+}
+
+// lineCountFilter returns a filter that selects the first, or the last,
+// lines of the output: head [<n>] and tail [<n>].
+func lineCountFilter(name string, args []string, first bool) (outputFilter, error) {
+	n := 10
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		n, err = strconv.Atoi(strings.TrimPrefix(args[0], "-"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: invalid number of lines %q", name, args[0])
+		}
+	default:
+		return nil, fmt.Errorf("usage: %s [<n>]", name)
+	}
+	return func(out []byte) []byte {
+		lines := splitOutputLines(out)
+		if len(lines) <= n {
+			return out
+		}
+		if first {
+			return joinOutputLines(lines[:n])
+		}
+		return joinOutputLines(lines[len(lines)-n:])
+	}, nil
+}
+
+// wcFilter returns a filter that counts the lines of the output: wc [-l]
+func wcFilter(args []string) (outputFilter, error) {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "-l") {
+		return nil, fmt.Errorf("usage: wc [-l]")
+	}
+	return func(out []byte) []byte {
+		return []byte(fmt.Sprintf("%d\n", len(splitOutputLines(out))))
+	}, nil
+}
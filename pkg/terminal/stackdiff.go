@@ -0,0 +1,174 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// stackSnapshot is the file written by 'stack -save' and read by
+// 'stack -diff'.
+type stackSnapshot struct {
+	Goroutines []stackSnapshotGoroutine `json:"goroutines"`
+}
+
+type stackSnapshotGoroutine struct {
+	ID     int                  `json:"id"`
+	Frames []stackSnapshotFrame `json:"frames"`
+}
+
+type stackSnapshotFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f stackSnapshotFrame) String() string {
+	return fmt.Sprintf("%s at %s:%d", f.Function, f.File, f.Line)
+}
+
+// takeStackSnapshot returns the stack of the goroutine of ctx or, if all is
+// set, of all goroutines.
+func takeStackSnapshot(t *Term, ctx callContext, sa stackArgs) (*stackSnapshot, error) {
+	var gids []int
+	if sa.all {
+		gs, _, err := t.client.ListGoroutines(0, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range gs {
+			gids = append(gids, g.ID)
+		}
+	} else {
+		gid := ctx.Scope.GoroutineID
+		if gid < 0 {
+			state, err := t.client.GetState()
+			if err != nil {
+				return nil, err
+			}
+			if state.SelectedGoroutine != nil {
+				gid = state.SelectedGoroutine.ID
+			}
+		}
+		gids = []int{gid}
+	}
+
+	snapshot := &stackSnapshot{}
+	for _, gid := range gids {
+		stack, err := t.client.Stacktrace(gid, sa.depth, sa.opts, nil)
+		if err != nil {
+			if sa.all {
+				continue
+			}
+			return nil, err
+		}
+		g := stackSnapshotGoroutine{ID: gid}
+		for _, frame := range stack {
+			sf := stackSnapshotFrame{File: frame.File, Line: frame.Line}
+			if frame.Function != nil {
+				sf.Function = frame.Function.Name()
+			}
+			g.Frames = append(g.Frames, sf)
+		}
+		snapshot.Goroutines = append(snapshot.Goroutines, g)
+	}
+	return snapshot, nil
+}
+
+// stackSaveOrDiff implements 'stack -save <file>' and 'stack -diff <file>'.
+func stackSaveOrDiff(t *Term, ctx callContext, sa stackArgs) error {
+	cur, err := takeStackSnapshot(t, ctx, sa)
+	if err != nil {
+		return err
+	}
+	if sa.save != "" {
+		buf, err := json.MarshalIndent(cur, "", "\t")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(sa.save, buf, 0644)
+	}
+
+	buf, err := ioutil.ReadFile(sa.diff)
+	if err != nil {
+		return err
+	}
+	var saved stackSnapshot
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		return fmt.Errorf("could not read %s: %v", sa.diff, err)
+	}
+	if !sa.all {
+		// The stack of the current goroutine is compared with the first
+		// stack of the snapshot, whatever its goroutine.
+		if len(saved.Goroutines) == 0 {
+			return fmt.Errorf("no stacks in %s", sa.diff)
+		}
+		printStackDiff(t, os.Stdout, saved.Goroutines[0].Frames, cur.Goroutines[0].Frames)
+		return nil
+	}
+
+	savedByID := make(map[int][]stackSnapshotFrame)
+	for _, g := range saved.Goroutines {
+		savedByID[g.ID] = g.Frames
+	}
+	same := 0
+	for _, g := range cur.Goroutines {
+		frames, ok := savedByID[g.ID]
+		delete(savedByID, g.ID)
+		switch {
+		case !ok:
+			fmt.Printf("Goroutine %d: not in %s\n", g.ID, sa.diff)
+		case stackDiffIndex(frames, g.Frames) == 0 && len(frames) == len(g.Frames):
+			same++
+		default:
+			fmt.Printf("Goroutine %d:\n", g.ID)
+			printStackDiff(t, os.Stdout, frames, g.Frames)
+		}
+	}
+	for _, g := range saved.Goroutines {
+		if _, ok := savedByID[g.ID]; ok {
+			fmt.Printf("Goroutine %d: only in %s\n", g.ID, sa.diff)
+		}
+	}
+	fmt.Printf("%d goroutines with the same stack\n", same)
+	return nil
+}
+
+// stackDiffIndex returns the number of frames, starting from the top of
+// the stacks, before the outermost frames common to both stacks.
+func stackDiffIndex(saved, cur []stackSnapshotFrame) int {
+	n := 0
+	for n < len(saved) && n < len(cur) && saved[len(saved)-1-n] == cur[len(cur)-1-n] {
+		n++
+	}
+	return len(cur) - n
+}
+
+// printStackDiff prints the frames of saved that are not in cur, prefixed
+// by '-', and the frames of cur that are not in saved, prefixed by '+',
+// followed by the outermost frames that are the same in both stacks.
+func printStackDiff(t *Term, w io.Writer, saved, cur []stackSnapshotFrame) {
+	k := stackDiffIndex(saved, cur)
+	common := len(cur) - k
+	if k == 0 && len(saved) == len(cur) {
+		fmt.Fprintln(w, "Stacks are the same")
+	}
+	line := func(color int, prefix string, i int, frame stackSnapshotFrame) {
+		s := fmt.Sprintf("%s%4d  %v", prefix, i, frame)
+		if !t.dumb && color != 0 {
+			s = fmt.Sprintf(terminalHighlightEscapeCode, color) + s + terminalResetEscapeCode
+		}
+		fmt.Fprintln(w, s)
+	}
+	for i := 0; i < len(saved)-common; i++ {
+		line(ansiRed, "-", i, saved[i])
+	}
+	for i := 0; i < k; i++ {
+		line(ansiGreen, "+", i, cur[i])
+	}
+	for i := k; i < len(cur); i++ {
+		line(0, " ", i, cur[i])
+	}
+}
@@ -649,6 +649,10 @@ func unmarshalStarlarkValueIntl(val starlark.Value, dst reflect.Value, path stri
 	case starlark.Float:
 		dst.SetFloat(float64(val))
 	case starlark.String:
+		if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(val))
+			break
+		}
 		dst.SetString(string(val))
 	case *starlark.List:
 		if dst.Kind() != reflect.Slice {
@@ -660,6 +664,7 @@ func unmarshalStarlarkValueIntl(val starlark.Value, dst reflect.Value, path stri
 			if err != nil {
 				return err
 			}
+			dst.Set(reflect.Append(dst, cur.Elem()))
 		}
 	case *starlark.Dict:
 		if dst.Kind() != reflect.Struct {
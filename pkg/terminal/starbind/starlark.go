@@ -3,7 +3,9 @@ package starbind
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"runtime"
 	"strings"
 	"sync"
@@ -54,13 +56,14 @@ type Env struct {
 	contextMu sync.Mutex
 	thread    *starlark.Thread
 	cancelfn  context.CancelFunc
+	out       io.Writer
 
 	ctx Context
 }
 
 // New creates a new starlark binding environment.
 func New(ctx Context) *Env {
-	env := &Env{}
+	env := &Env{out: os.Stdout}
 
 	env.ctx = ctx
 
@@ -175,6 +178,12 @@ func (env *Env) exportGlobals(globals starlark.StringDict) error {
 	return nil
 }
 
+// SetOutput sets the destination of the output of the print builtin, by
+// default standard output.
+func (env *Env) SetOutput(w io.Writer) {
+	env.out = w
+}
+
 // Cancel cancels the execution of a currently running script or function.
 func (env *Env) Cancel() {
 	if env == nil {
@@ -193,7 +202,7 @@ func (env *Env) Cancel() {
 
 func (env *Env) newThread() *starlark.Thread {
 	thread := &starlark.Thread{
-		Print: func(_ *starlark.Thread, msg string) { fmt.Println(msg) },
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(env.out, msg) },
 	}
 	env.contextMu.Lock()
 	var ctx context.Context
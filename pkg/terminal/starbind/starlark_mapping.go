@@ -12,6 +12,77 @@ import (
 func (env *Env) starlarkPredeclare() starlark.StringDict {
 	r := starlark.StringDict{}
 
+	r["add_event_hook"] = starlark.NewBuiltin("add_event_hook", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.AddEventHookIn
+		var rpcRet rpc2.AddEventHookOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Hook, "Hook")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Hook":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Hook, "Hook")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("AddEventHook", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["add_watch"] = starlark.NewBuiltin("add_watch", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.AddWatchIn
+		var rpcRet rpc2.AddWatchOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("AddWatch", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
 	r["amend_breakpoint"] = starlark.NewBuiltin("amend_breakpoint", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
@@ -100,6 +171,74 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
+	r["branch_history"] = starlark.NewBuiltin("branch_history", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.BranchHistoryIn
+		var rpcRet rpc2.BranchHistoryOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.ThreadID, "ThreadID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Count, "Count")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "ThreadID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ThreadID, "ThreadID")
+			case "Count":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Count, "Count")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("BranchHistory", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["cancel_call"] = starlark.NewBuiltin("cancel_call", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.CancelCallIn
+		var rpcRet rpc2.CancelCallOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.GoroutineID, "GoroutineID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "GoroutineID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.GoroutineID, "GoroutineID")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("CancelCall", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
 	r["cancel_next"] = starlark.NewBuiltin("cancel_next", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
@@ -112,6 +251,46 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
+	r["chan_waiters"] = starlark.NewBuiltin("chan_waiters", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ChanWaitersIn
+		var rpcRet rpc2.ChanWaitersOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ChanWaiters", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
 	r["checkpoint"] = starlark.NewBuiltin("checkpoint", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
@@ -255,6 +434,12 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
+		if len(args) > 6 && args[6] != starlark.None {
+			err := unmarshalStarlarkValue(args[6], &rpcArgs.CallTimeout, "CallTimeout")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
@@ -270,6 +455,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
 			case "UnsafeCall":
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.UnsafeCall, "UnsafeCall")
+			case "CallTimeout":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.CallTimeout, "CallTimeout")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -283,6 +470,57 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
+	r["context_chain"] = starlark.NewBuiltin("context_chain", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ContextChainIn
+		var rpcRet rpc2.ContextChainOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ContextChain", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
 	r["create_breakpoint"] = starlark.NewBuiltin("create_breakpoint", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
@@ -399,44 +637,55 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["eval"] = starlark.NewBuiltin("eval", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["dump"] = starlark.NewBuiltin("dump", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.EvalIn
-		var rpcRet rpc2.EvalOut
+		var rpcArgs rpc2.DumpIn
+		var rpcRet rpc2.DumpOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Destination, "Destination")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			rpcArgs.Scope = env.ctx.Scope()
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Selective, "Selective")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
 		if len(args) > 2 && args[2] != starlark.None {
-			err := unmarshalStarlarkValue(args[2], &rpcArgs.Cfg, "Cfg")
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Minidump, "Minidump")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Compress, "Compress")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 4 && args[4] != starlark.None {
+			err := unmarshalStarlarkValue(args[4], &rpcArgs.Sparse, "Sparse")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			cfg := env.ctx.LoadConfig()
-			rpcArgs.Cfg = &cfg
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Scope":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
-			case "Expr":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
-			case "Cfg":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Destination":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Destination, "Destination")
+			case "Selective":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Selective, "Selective")
+			case "Minidump":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Minidump, "Minidump")
+			case "Compress":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Compress, "Compress")
+			case "Sparse":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Sparse, "Sparse")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -444,26 +693,20 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("Eval", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("Dump", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["examine_memory"] = starlark.NewBuiltin("examine_memory", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["enable_branch_trace"] = starlark.NewBuiltin("enable_branch_trace", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ExamineMemoryIn
-		var rpcRet rpc2.ExaminedMemoryOut
+		var rpcArgs rpc2.EnableBranchTraceIn
+		var rpcRet rpc2.EnableBranchTraceOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Address, "Address")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		}
-		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Length, "Length")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Enable, "Enable")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -471,10 +714,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Address":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Address, "Address")
-			case "Length":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Length, "Length")
+			case "Enable":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Enable, "Enable")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -482,18 +723,18 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ExamineMemory", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("EnableBranchTrace", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["find_location"] = starlark.NewBuiltin("find_location", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["eval"] = starlark.NewBuiltin("eval", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.FindLocationIn
-		var rpcRet rpc2.FindLocationOut
+		var rpcArgs rpc2.EvalIn
+		var rpcRet rpc2.EvalOut
 		if len(args) > 0 && args[0] != starlark.None {
 			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
 			if err != nil {
@@ -503,19 +744,22 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 			rpcArgs.Scope = env.ctx.Scope()
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Loc, "Loc")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
 		if len(args) > 2 && args[2] != starlark.None {
-			err := unmarshalStarlarkValue(args[2], &rpcArgs.IncludeNonExecutableLines, "IncludeNonExecutableLines")
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Cfg, "Cfg")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
 		}
 		if len(args) > 3 && args[3] != starlark.None {
-			err := unmarshalStarlarkValue(args[3], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Stringer, "Stringer")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -525,12 +769,12 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 			switch kv[0].(starlark.String) {
 			case "Scope":
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
-			case "Loc":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Loc, "Loc")
-			case "IncludeNonExecutableLines":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeNonExecutableLines, "IncludeNonExecutableLines")
-			case "SubstitutePathRules":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Stringer":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Stringer, "Stringer")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -538,29 +782,50 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("FindLocation", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("Eval", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["function_return_locations"] = starlark.NewBuiltin("function_return_locations", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["eval_many"] = starlark.NewBuiltin("eval_many", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.FunctionReturnLocationsIn
-		var rpcRet rpc2.FunctionReturnLocationsOut
+		var rpcArgs rpc2.EvalManyIn
+		var rpcRet rpc2.EvalManyOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.FnName, "FnName")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Exprs, "Exprs")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Cfg, "Cfg")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "FnName":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.FnName, "FnName")
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Exprs":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Exprs, "Exprs")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -568,26 +833,26 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("FunctionReturnLocations", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("EvalMany", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["get_breakpoint"] = starlark.NewBuiltin("get_breakpoint", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["examine_memory"] = starlark.NewBuiltin("examine_memory", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.GetBreakpointIn
-		var rpcRet rpc2.GetBreakpointOut
+		var rpcArgs rpc2.ExamineMemoryIn
+		var rpcRet rpc2.ExaminedMemoryOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Address, "Address")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Name, "Name")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Length, "Length")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -595,10 +860,10 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Id":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
-			case "Name":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Name, "Name")
+			case "Address":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Address, "Address")
+			case "Length":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Length, "Length")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -606,29 +871,58 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("GetBreakpoint", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("ExamineMemory", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["get_thread"] = starlark.NewBuiltin("get_thread", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["export_variable"] = starlark.NewBuiltin("export_variable", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.GetThreadIn
-		var rpcRet rpc2.GetThreadOut
+		var rpcArgs rpc2.ExportVariableIn
+		var rpcRet rpc2.ExportVariableOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Format, "Format")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Id":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Format":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Format, "Format")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -636,78 +930,85 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("GetThread", &rpcArgs, &rpcRet)
-		if err != nil {
-			return starlark.None, err
-		}
-		return env.interfaceToStarlarkValue(rpcRet), nil
-	})
-	r["is_multiclient"] = starlark.NewBuiltin("is_multiclient", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-		if err := isCancelled(thread); err != nil {
-			return starlark.None, decorateError(thread, err)
-		}
-		var rpcArgs rpc2.IsMulticlientIn
-		var rpcRet rpc2.IsMulticlientOut
-		err := env.ctx.Client().CallAPI("IsMulticlient", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("ExportVariable", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["last_modified"] = starlark.NewBuiltin("last_modified", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["filter_map"] = starlark.NewBuiltin("filter_map", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.LastModifiedIn
-		var rpcRet rpc2.LastModifiedOut
-		err := env.ctx.Client().CallAPI("LastModified", &rpcArgs, &rpcRet)
-		if err != nil {
-			return starlark.None, err
+		var rpcArgs rpc2.FilterMapIn
+		var rpcRet rpc2.FilterMapOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
 		}
-		return env.interfaceToStarlarkValue(rpcRet), nil
-	})
-	r["breakpoints"] = starlark.NewBuiltin("breakpoints", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-		if err := isCancelled(thread); err != nil {
-			return starlark.None, decorateError(thread, err)
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
 		}
-		var rpcArgs rpc2.ListBreakpointsIn
-		var rpcRet rpc2.ListBreakpointsOut
-		err := env.ctx.Client().CallAPI("ListBreakpoints", &rpcArgs, &rpcRet)
-		if err != nil {
-			return starlark.None, err
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.KeyRegexp, "KeyRegexp")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
 		}
-		return env.interfaceToStarlarkValue(rpcRet), nil
-	})
-	r["checkpoints"] = starlark.NewBuiltin("checkpoints", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-		if err := isCancelled(thread); err != nil {
-			return starlark.None, decorateError(thread, err)
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Sorted, "Sorted")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
 		}
-		var rpcArgs rpc2.ListCheckpointsIn
-		var rpcRet rpc2.ListCheckpointsOut
-		err := env.ctx.Client().CallAPI("ListCheckpoints", &rpcArgs, &rpcRet)
-		if err != nil {
-			return starlark.None, err
+		if len(args) > 4 && args[4] != starlark.None {
+			err := unmarshalStarlarkValue(args[4], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
 		}
-		return env.interfaceToStarlarkValue(rpcRet), nil
-	})
-	r["dynamic_libraries"] = starlark.NewBuiltin("dynamic_libraries", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-		if err := isCancelled(thread); err != nil {
-			return starlark.None, decorateError(thread, err)
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "KeyRegexp":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.KeyRegexp, "KeyRegexp")
+			case "Sorted":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Sorted, "Sorted")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
 		}
-		var rpcArgs rpc2.ListDynamicLibrariesIn
-		var rpcRet rpc2.ListDynamicLibrariesOut
-		err := env.ctx.Client().CallAPI("ListDynamicLibraries", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("FilterMap", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["function_args"] = starlark.NewBuiltin("function_args", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["find_location"] = starlark.NewBuiltin("find_location", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListFunctionArgsIn
-		var rpcRet rpc2.ListFunctionArgsOut
+		var rpcArgs rpc2.FindLocationIn
+		var rpcRet rpc2.FindLocationOut
 		if len(args) > 0 && args[0] != starlark.None {
 			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
 			if err != nil {
@@ -717,20 +1018,34 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 			rpcArgs.Scope = env.ctx.Scope()
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Loc, "Loc")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.IncludeNonExecutableLines, "IncludeNonExecutableLines")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			rpcArgs.Cfg = env.ctx.LoadConfig()
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
 			case "Scope":
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
-			case "Cfg":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Loc":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Loc, "Loc")
+			case "IncludeNonExecutableLines":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeNonExecutableLines, "IncludeNonExecutableLines")
+			case "SubstitutePathRules":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -738,20 +1053,20 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListFunctionArgs", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("FindLocation", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["functions"] = starlark.NewBuiltin("functions", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["find_referrers"] = starlark.NewBuiltin("find_referrers", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListFunctionsIn
-		var rpcRet rpc2.ListFunctionsOut
+		var rpcArgs rpc2.FindReferrersIn
+		var rpcRet rpc2.FindReferrersOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Addr, "Addr")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -759,8 +1074,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Filter":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			case "Addr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Addr, "Addr")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -768,26 +1083,20 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListFunctions", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("FindReferrers", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["goroutines"] = starlark.NewBuiltin("goroutines", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["function_return_locations"] = starlark.NewBuiltin("function_return_locations", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListGoroutinesIn
-		var rpcRet rpc2.ListGoroutinesOut
+		var rpcArgs rpc2.FunctionReturnLocationsIn
+		var rpcRet rpc2.FunctionReturnLocationsOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Start, "Start")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		}
-		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Count, "Count")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.FnName, "FnName")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -795,10 +1104,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Start":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Start, "Start")
-			case "Count":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Count, "Count")
+			case "FnName":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.FnName, "FnName")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -806,41 +1113,37 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListGoroutines", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("FunctionReturnLocations", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["local_vars"] = starlark.NewBuiltin("local_vars", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["get_breakpoint"] = starlark.NewBuiltin("get_breakpoint", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListLocalVarsIn
-		var rpcRet rpc2.ListLocalVarsOut
-		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+		var rpcArgs rpc2.GetBreakpointIn
+		var rpcRet rpc2.GetBreakpointOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			rpcArgs.Scope = env.ctx.Scope()
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Name, "Name")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			rpcArgs.Cfg = env.ctx.LoadConfig()
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Scope":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
-			case "Cfg":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Id":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
+			case "Name":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Name, "Name")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -848,39 +1151,41 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListLocalVars", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("GetBreakpoint", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["package_vars"] = starlark.NewBuiltin("package_vars", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["get_capabilities"] = starlark.NewBuiltin("get_capabilities", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListPackageVarsIn
-		var rpcRet rpc2.ListPackageVarsOut
-		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		var rpcArgs rpc2.GetCapabilitiesIn
+		var rpcRet rpc2.GetCapabilitiesOut
+		err := env.ctx.Client().CallAPI("GetCapabilities", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
 		}
-		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["get_source_file"] = starlark.NewBuiltin("get_source_file", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.GetSourceFileIn
+		var rpcRet rpc2.GetSourceFileOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Path, "Path")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
-		} else {
-			rpcArgs.Cfg = env.ctx.LoadConfig()
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Filter":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
-			case "Cfg":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Path":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Path, "Path")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -888,20 +1193,20 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListPackageVars", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("GetSourceFile", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["packages_build_info"] = starlark.NewBuiltin("packages_build_info", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["get_thread"] = starlark.NewBuiltin("get_thread", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListPackagesBuildInfoIn
-		var rpcRet rpc2.ListPackagesBuildInfoOut
+		var rpcArgs rpc2.GetThreadIn
+		var rpcRet rpc2.GetThreadOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.IncludeFiles, "IncludeFiles")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -909,8 +1214,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "IncludeFiles":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeFiles, "IncludeFiles")
+			case "Id":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -918,48 +1223,29 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListPackagesBuildInfo", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("GetThread", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["registers"] = starlark.NewBuiltin("registers", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["goroutine_tree"] = starlark.NewBuiltin("goroutine_tree", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListRegistersIn
-		var rpcRet rpc2.ListRegistersOut
+		var rpcArgs rpc2.GoroutineTreeIn
+		var rpcRet rpc2.GoroutineTreeOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.ThreadID, "ThreadID")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Depth, "Depth")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.IncludeFp, "IncludeFp")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		}
-		if len(args) > 2 && args[2] != starlark.None {
-			err := unmarshalStarlarkValue(args[2], &rpcArgs.Scope, "Scope")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		} else {
-			scope := env.ctx.Scope()
-			rpcArgs.Scope = &scope
-		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "ThreadID":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ThreadID, "ThreadID")
-			case "IncludeFp":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeFp, "IncludeFp")
-			case "Scope":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Depth":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Depth, "Depth")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -967,20 +1253,32 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListRegisters", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("GoroutineTree", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["sources"] = starlark.NewBuiltin("sources", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["guard_memory"] = starlark.NewBuiltin("guard_memory", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListSourcesIn
-		var rpcRet rpc2.ListSourcesOut
+		var rpcArgs rpc2.GuardMemoryIn
+		var rpcRet rpc2.GuardMemoryOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Addr, "Addr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Size, "Size")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Prot, "Prot")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -988,8 +1286,12 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Filter":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			case "Addr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Addr, "Addr")
+			case "Size":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Size, "Size")
+			case "Prot":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Prot, "Prot")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -997,32 +1299,40 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListSources", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("GuardMemory", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["threads"] = starlark.NewBuiltin("threads", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["heap_histogram"] = starlark.NewBuiltin("heap_histogram", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListThreadsIn
-		var rpcRet rpc2.ListThreadsOut
-		err := env.ctx.Client().CallAPI("ListThreads", &rpcArgs, &rpcRet)
+		var rpcArgs rpc2.HeapHistogramIn
+		var rpcRet rpc2.HeapHistogramOut
+		err := env.ctx.Client().CallAPI("HeapHistogram", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["types"] = starlark.NewBuiltin("types", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["interface_itab"] = starlark.NewBuiltin("interface_itab", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ListTypesIn
-		var rpcRet rpc2.ListTypesOut
+		var rpcArgs rpc2.InterfaceItabIn
+		var rpcRet rpc2.InterfaceItabOut
 		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
@@ -1030,8 +1340,10 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
-			case "Filter":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -1039,112 +1351,90 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("ListTypes", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("InterfaceItab", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["process_pid"] = starlark.NewBuiltin("process_pid", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["is_multiclient"] = starlark.NewBuiltin("is_multiclient", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.ProcessPidIn
-		var rpcRet rpc2.ProcessPidOut
-		err := env.ctx.Client().CallAPI("ProcessPid", &rpcArgs, &rpcRet)
+		var rpcArgs rpc2.IsMulticlientIn
+		var rpcRet rpc2.IsMulticlientOut
+		err := env.ctx.Client().CallAPI("IsMulticlient", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["recorded"] = starlark.NewBuiltin("recorded", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["last_modified"] = starlark.NewBuiltin("last_modified", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.RecordedIn
-		var rpcRet rpc2.RecordedOut
-		err := env.ctx.Client().CallAPI("Recorded", &rpcArgs, &rpcRet)
+		var rpcArgs rpc2.LastModifiedIn
+		var rpcRet rpc2.LastModifiedOut
+		err := env.ctx.Client().CallAPI("LastModified", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["restart"] = starlark.NewBuiltin("restart", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["breakpoints"] = starlark.NewBuiltin("breakpoints", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.RestartIn
-		var rpcRet rpc2.RestartOut
-		if len(args) > 0 && args[0] != starlark.None {
-			err := unmarshalStarlarkValue(args[0], &rpcArgs.Position, "Position")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		}
-		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.ResetArgs, "ResetArgs")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		var rpcArgs rpc2.ListBreakpointsIn
+		var rpcRet rpc2.ListBreakpointsOut
+		err := env.ctx.Client().CallAPI("ListBreakpoints", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
 		}
-		if len(args) > 2 && args[2] != starlark.None {
-			err := unmarshalStarlarkValue(args[2], &rpcArgs.NewArgs, "NewArgs")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["checkpoints"] = starlark.NewBuiltin("checkpoints", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
 		}
-		if len(args) > 3 && args[3] != starlark.None {
-			err := unmarshalStarlarkValue(args[3], &rpcArgs.Rerecord, "Rerecord")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		var rpcArgs rpc2.ListCheckpointsIn
+		var rpcRet rpc2.ListCheckpointsOut
+		err := env.ctx.Client().CallAPI("ListCheckpoints", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
 		}
-		if len(args) > 4 && args[4] != starlark.None {
-			err := unmarshalStarlarkValue(args[4], &rpcArgs.Rebuild, "Rebuild")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["dynamic_libraries"] = starlark.NewBuiltin("dynamic_libraries", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
 		}
-		if len(args) > 5 && args[5] != starlark.None {
-			err := unmarshalStarlarkValue(args[5], &rpcArgs.NewRedirects, "NewRedirects")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		var rpcArgs rpc2.ListDynamicLibrariesIn
+		var rpcRet rpc2.ListDynamicLibrariesOut
+		err := env.ctx.Client().CallAPI("ListDynamicLibraries", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
 		}
-		for _, kv := range kwargs {
-			var err error
-			switch kv[0].(starlark.String) {
-			case "Position":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Position, "Position")
-			case "ResetArgs":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ResetArgs, "ResetArgs")
-			case "NewArgs":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.NewArgs, "NewArgs")
-			case "Rerecord":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Rerecord, "Rerecord")
-			case "Rebuild":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Rebuild, "Rebuild")
-			case "NewRedirects":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.NewRedirects, "NewRedirects")
-			default:
-				err = fmt.Errorf("unknown argument %q", kv[0])
-			}
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["event_hooks"] = starlark.NewBuiltin("event_hooks", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
 		}
-		err := env.ctx.Client().CallAPI("Restart", &rpcArgs, &rpcRet)
+		var rpcArgs rpc2.ListEventHooksIn
+		var rpcRet rpc2.ListEventHooksOut
+		err := env.ctx.Client().CallAPI("ListEventHooks", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["set_expr"] = starlark.NewBuiltin("set_expr", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["function_args"] = starlark.NewBuiltin("function_args", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
-		var rpcArgs rpc2.SetIn
-		var rpcRet rpc2.SetOut
+		var rpcArgs rpc2.ListFunctionArgsIn
+		var rpcRet rpc2.ListFunctionArgsOut
 		if len(args) > 0 && args[0] != starlark.None {
 			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
 			if err != nil {
@@ -1154,26 +1444,20 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 			rpcArgs.Scope = env.ctx.Scope()
 		}
 		if len(args) > 1 && args[1] != starlark.None {
-			err := unmarshalStarlarkValue(args[1], &rpcArgs.Symbol, "Symbol")
-			if err != nil {
-				return starlark.None, decorateError(thread, err)
-			}
-		}
-		if len(args) > 2 && args[2] != starlark.None {
-			err := unmarshalStarlarkValue(args[2], &rpcArgs.Value, "Value")
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
 			if err != nil {
 				return starlark.None, decorateError(thread, err)
 			}
+		} else {
+			rpcArgs.Cfg = env.ctx.LoadConfig()
 		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
 			case "Scope":
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
-			case "Symbol":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Symbol, "Symbol")
-			case "Value":
-				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Value, "Value")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -1181,13 +1465,1176 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
-		err := env.ctx.Client().CallAPI("Set", &rpcArgs, &rpcRet)
+		err := env.ctx.Client().CallAPI("ListFunctionArgs", &rpcArgs, &rpcRet)
 		if err != nil {
 			return starlark.None, err
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
-	r["stacktrace"] = starlark.NewBuiltin("stacktrace", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	r["function_patches"] = starlark.NewBuiltin("function_patches", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListFunctionPatchesIn
+		var rpcRet rpc2.ListFunctionPatchesOut
+		err := env.ctx.Client().CallAPI("ListFunctionPatches", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["functions"] = starlark.NewBuiltin("functions", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListFunctionsIn
+		var rpcRet rpc2.ListFunctionsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Filter":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListFunctions", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["goroutines"] = starlark.NewBuiltin("goroutines", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListGoroutinesIn
+		var rpcRet rpc2.ListGoroutinesOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Start, "Start")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Count, "Count")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Filters, "Filters")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.GoroutineGroupingOptions, "GoroutineGroupingOptions")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Start":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Start, "Start")
+			case "Count":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Count, "Count")
+			case "Filters":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filters, "Filters")
+			case "GoroutineGroupingOptions":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.GoroutineGroupingOptions, "GoroutineGroupingOptions")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListGoroutines", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["local_vars"] = starlark.NewBuiltin("local_vars", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListLocalVarsIn
+		var rpcRet rpc2.ListLocalVarsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Cfg = env.ctx.LoadConfig()
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListLocalVars", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["memory_guards"] = starlark.NewBuiltin("memory_guards", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListMemoryGuardsIn
+		var rpcRet rpc2.ListMemoryGuardsOut
+		err := env.ctx.Client().CallAPI("ListMemoryGuards", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["package_vars"] = starlark.NewBuiltin("package_vars", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListPackageVarsIn
+		var rpcRet rpc2.ListPackageVarsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Cfg = env.ctx.LoadConfig()
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Filter":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListPackageVars", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["packages_build_info"] = starlark.NewBuiltin("packages_build_info", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListPackagesBuildInfoIn
+		var rpcRet rpc2.ListPackagesBuildInfoOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.IncludeFiles, "IncludeFiles")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "IncludeFiles":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeFiles, "IncludeFiles")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListPackagesBuildInfo", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["recorders"] = starlark.NewBuiltin("recorders", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListRecordersIn
+		var rpcRet rpc2.ListRecordersOut
+		err := env.ctx.Client().CallAPI("ListRecorders", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["registers"] = starlark.NewBuiltin("registers", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListRegistersIn
+		var rpcRet rpc2.ListRegistersOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.ThreadID, "ThreadID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.IncludeFp, "IncludeFp")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			scope := env.ctx.Scope()
+			rpcArgs.Scope = &scope
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.VectorFormat, "VectorFormat")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "ThreadID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ThreadID, "ThreadID")
+			case "IncludeFp":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.IncludeFp, "IncludeFp")
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "VectorFormat":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.VectorFormat, "VectorFormat")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListRegisters", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["signal_policies"] = starlark.NewBuiltin("signal_policies", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListSignalPoliciesIn
+		var rpcRet rpc2.ListSignalPoliciesOut
+		err := env.ctx.Client().CallAPI("ListSignalPolicies", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["sources"] = starlark.NewBuiltin("sources", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListSourcesIn
+		var rpcRet rpc2.ListSourcesOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Filter":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListSources", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["threads"] = starlark.NewBuiltin("threads", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListThreadsIn
+		var rpcRet rpc2.ListThreadsOut
+		err := env.ctx.Client().CallAPI("ListThreads", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["timers"] = starlark.NewBuiltin("timers", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListTimersIn
+		var rpcRet rpc2.ListTimersOut
+		err := env.ctx.Client().CallAPI("ListTimers", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["types"] = starlark.NewBuiltin("types", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListTypesIn
+		var rpcRet rpc2.ListTypesOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Filter, "Filter")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Filter":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Filter, "Filter")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ListTypes", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["watches"] = starlark.NewBuiltin("watches", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ListWatchesIn
+		var rpcRet rpc2.ListWatchesOut
+		err := env.ctx.Client().CallAPI("ListWatches", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["load_array_range"] = starlark.NewBuiltin("load_array_range", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.LoadArrayRangeIn
+		var rpcRet rpc2.LoadArrayRangeOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Addr, "Addr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Type, "Type")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Start, "Start")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 4 && args[4] != starlark.None {
+			err := unmarshalStarlarkValue(args[4], &rpcArgs.End, "End")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 5 && args[5] != starlark.None {
+			err := unmarshalStarlarkValue(args[5], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Addr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Addr, "Addr")
+			case "Type":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Type, "Type")
+			case "Start":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Start, "Start")
+			case "End":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.End, "End")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("LoadArrayRange", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["load_session"] = starlark.NewBuiltin("load_session", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.LoadSessionIn
+		var rpcRet rpc2.LoadSessionOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Session, "Session")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Session":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Session, "Session")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("LoadSession", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["load_string_chunk"] = starlark.NewBuiltin("load_string_chunk", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.LoadStringChunkIn
+		var rpcRet rpc2.LoadStringChunkOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Addr, "Addr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Length, "Length")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Addr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Addr, "Addr")
+			case "Length":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Length, "Length")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("LoadStringChunk", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["mutex_info"] = starlark.NewBuiltin("mutex_info", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.MutexInfoIn
+		var rpcRet rpc2.MutexInfoOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("MutexInfo", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["object_graph"] = starlark.NewBuiltin("object_graph", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ObjectGraphIn
+		var rpcRet rpc2.ObjectGraphOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Exprs, "Exprs")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Exprs":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Exprs, "Exprs")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ObjectGraph", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["patch_function"] = starlark.NewBuiltin("patch_function", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.PatchFunctionIn
+		var rpcRet rpc2.PatchFunctionOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Function, "Function")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Return, "Return")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Code, "Code")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Function":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Function, "Function")
+			case "Return":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Return, "Return")
+			case "Code":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Code, "Code")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("PatchFunction", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["process_pid"] = starlark.NewBuiltin("process_pid", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ProcessPidIn
+		var rpcRet rpc2.ProcessPidOut
+		err := env.ctx.Client().CallAPI("ProcessPid", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["raw_call"] = starlark.NewBuiltin("raw_call", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RawCallIn
+		var rpcRet rpc2.RawCallOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Unsafe, "Unsafe")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Addr, "Addr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Args, "Args")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Syscall, "Syscall")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Unsafe":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Unsafe, "Unsafe")
+			case "Addr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Addr, "Addr")
+			case "Args":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Args, "Args")
+			case "Syscall":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Syscall, "Syscall")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("RawCall", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["recorded"] = starlark.NewBuiltin("recorded", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RecordedIn
+		var rpcRet rpc2.RecordedOut
+		err := env.ctx.Client().CallAPI("Recorded", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["remove_event_hook"] = starlark.NewBuiltin("remove_event_hook", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RemoveEventHookIn
+		var rpcRet rpc2.RemoveEventHookOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Id":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("RemoveEventHook", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["remove_watch"] = starlark.NewBuiltin("remove_watch", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RemoveWatchIn
+		var rpcRet rpc2.RemoveWatchOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.ID, "ID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "ID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ID, "ID")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("RemoveWatch", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["restart"] = starlark.NewBuiltin("restart", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RestartIn
+		var rpcRet rpc2.RestartOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Position, "Position")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.ResetArgs, "ResetArgs")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.NewArgs, "NewArgs")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Rerecord, "Rerecord")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 4 && args[4] != starlark.None {
+			err := unmarshalStarlarkValue(args[4], &rpcArgs.Rebuild, "Rebuild")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 5 && args[5] != starlark.None {
+			err := unmarshalStarlarkValue(args[5], &rpcArgs.NewRedirects, "NewRedirects")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Position":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Position, "Position")
+			case "ResetArgs":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ResetArgs, "ResetArgs")
+			case "NewArgs":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.NewArgs, "NewArgs")
+			case "Rerecord":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Rerecord, "Rerecord")
+			case "Rebuild":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Rebuild, "Rebuild")
+			case "NewRedirects":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.NewRedirects, "NewRedirects")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("Restart", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["run_to_event"] = starlark.NewBuiltin("run_to_event", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.RunToEventIn
+		var rpcRet rpc2.RunToEventOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Event, "Event")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Event":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Event, "Event")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("RunToEvent", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["save_session"] = starlark.NewBuiltin("save_session", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.SaveSessionIn
+		var rpcRet rpc2.SaveSessionOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "SubstitutePathRules":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.SubstitutePathRules, "SubstitutePathRules")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("SaveSession", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["search_variable"] = starlark.NewBuiltin("search_variable", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.SearchVariableIn
+		var rpcRet rpc2.SearchVariableOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Value, "Value")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Value":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Value, "Value")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("SearchVariable", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["set_expr"] = starlark.NewBuiltin("set_expr", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.SetIn
+		var rpcRet rpc2.SetOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Symbol, "Symbol")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Value, "Value")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Symbol":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Symbol, "Symbol")
+			case "Value":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Value, "Value")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("Set", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["set_signal_policy"] = starlark.NewBuiltin("set_signal_policy", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.SetSignalPolicyIn
+		var rpcRet rpc2.SetSignalPolicyOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Signal, "Signal")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Stop, "Stop")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Print, "Print")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Pass, "Pass")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Signal":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Signal, "Signal")
+			case "Stop":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Stop, "Stop")
+			case "Print":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Print, "Print")
+			case "Pass":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Pass, "Pass")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("SetSignalPolicy", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["stackframe_details"] = starlark.NewBuiltin("stackframe_details", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.StackframeDetailsIn
+		var rpcRet rpc2.StackframeDetailsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Id, "Id")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Frame, "Frame")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Opts, "Opts")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Id":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Id, "Id")
+			case "Frame":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Frame, "Frame")
+			case "Opts":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Opts, "Opts")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("StackframeDetails", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["stacktrace"] = starlark.NewBuiltin("stacktrace", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		if err := isCancelled(thread); err != nil {
 			return starlark.None, decorateError(thread, err)
 		}
@@ -1229,6 +2676,12 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				return starlark.None, decorateError(thread, err)
 			}
 		}
+		if len(args) > 6 && args[6] != starlark.None {
+			err := unmarshalStarlarkValue(args[6], &rpcArgs.Start, "Start")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
 		for _, kv := range kwargs {
 			var err error
 			switch kv[0].(starlark.String) {
@@ -1244,6 +2697,8 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Opts, "Opts")
 			case "Cfg":
 				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			case "Start":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Start, "Start")
 			default:
 				err = fmt.Errorf("unknown argument %q", kv[0])
 			}
@@ -1287,5 +2742,200 @@ func (env *Env) starlarkPredeclare() starlark.StringDict {
 		}
 		return env.interfaceToStarlarkValue(rpcRet), nil
 	})
+	r["unguard_memory"] = starlark.NewBuiltin("unguard_memory", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.UnguardMemoryIn
+		var rpcRet rpc2.UnguardMemoryOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.ID, "ID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "ID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ID, "ID")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("UnguardMemory", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["unpatch_function"] = starlark.NewBuiltin("unpatch_function", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.UnpatchFunctionIn
+		var rpcRet rpc2.UnpatchFunctionOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.ID, "ID")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "ID":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.ID, "ID")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("UnpatchFunction", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["view_as"] = starlark.NewBuiltin("view_as", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.ViewAsIn
+		var rpcRet rpc2.ViewAsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Scope, "Scope")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			rpcArgs.Scope = env.ctx.Scope()
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Expr, "Expr")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 2 && args[2] != starlark.None {
+			err := unmarshalStarlarkValue(args[2], &rpcArgs.Type, "Type")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 3 && args[3] != starlark.None {
+			err := unmarshalStarlarkValue(args[3], &rpcArgs.Cfg, "Cfg")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		} else {
+			cfg := env.ctx.LoadConfig()
+			rpcArgs.Cfg = &cfg
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Scope":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Scope, "Scope")
+			case "Expr":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Expr, "Expr")
+			case "Type":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Type, "Type")
+			case "Cfg":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Cfg, "Cfg")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("ViewAs", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["wait_events"] = starlark.NewBuiltin("wait_events", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.WaitEventsIn
+		var rpcRet rpc2.WaitEventsOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.After, "After")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Timeout, "Timeout")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "After":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.After, "After")
+			case "Timeout":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Timeout, "Timeout")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("WaitEvents", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
+	r["write_memory"] = starlark.NewBuiltin("write_memory", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := isCancelled(thread); err != nil {
+			return starlark.None, decorateError(thread, err)
+		}
+		var rpcArgs rpc2.WriteMemoryIn
+		var rpcRet rpc2.WriteMemoryOut
+		if len(args) > 0 && args[0] != starlark.None {
+			err := unmarshalStarlarkValue(args[0], &rpcArgs.Address, "Address")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		if len(args) > 1 && args[1] != starlark.None {
+			err := unmarshalStarlarkValue(args[1], &rpcArgs.Data, "Data")
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		for _, kv := range kwargs {
+			var err error
+			switch kv[0].(starlark.String) {
+			case "Address":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Address, "Address")
+			case "Data":
+				err = unmarshalStarlarkValue(kv[1], &rpcArgs.Data, "Data")
+			default:
+				err = fmt.Errorf("unknown argument %q", kv[0])
+			}
+			if err != nil {
+				return starlark.None, decorateError(thread, err)
+			}
+		}
+		err := env.ctx.Client().CallAPI("WriteMemory", &rpcArgs, &rpcRet)
+		if err != nil {
+			return starlark.None, err
+		}
+		return env.interfaceToStarlarkValue(rpcRet), nil
+	})
 	return r
 }
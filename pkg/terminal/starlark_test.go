@@ -162,3 +162,20 @@ func TestStarlarkVariable(t *testing.T) {
 		}
 	})
 }
+
+func TestStarlarkWriteMemoryEvents(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.MustExecStarlark(`addr = eval(None, "uintptr(&as1.B)").Variable.Value; write_memory(addr, [7, 0, 0, 0, 0, 0, 0, 0])`)
+		if out := strings.TrimSpace(term.MustExecStarlark(`print(eval(None, "as1.B").Variable.Value)`)); out != "7" {
+			t.Errorf("expected 7 after write_memory, got %q", out)
+		}
+		term.MustExecStarlark(`addr = eval(None, "uintptr(&as1.B)").Variable.Value; write_memory(addr, "\x01")`)
+		if out := strings.TrimSpace(term.MustExecStarlark(`print(eval(None, "as1.B").Variable.Value)`)); out != "1" {
+			t.Errorf("expected 1 after write_memory, got %q", out)
+		}
+		if out := strings.TrimSpace(term.MustExecStarlark(`print(wait_events(After=0, Timeout=1).Events[-1].Kind)`)); out != "stopped" {
+			t.Errorf("expected stopped event, got %q", out)
+		}
+	})
+}
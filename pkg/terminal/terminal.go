@@ -6,6 +6,9 @@ import (
 	"net/rpc"
 	"os"
 	"os/signal"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -54,9 +57,41 @@ type Term struct {
 	dumb     bool
 	stdout   io.Writer
 	InitFile string
-	displays []string
+	displays []displayEntry
+
+	// TraceJSON prints tracepoint hits as JSON objects, see
+	// printTracepointJSON.
+	TraceJSON bool
+	// BreakOnTestFailure sets a breakpoint that stops the target when a
+	// test fails and selects the frame of the failing test, see
+	// selectFailedTestFrame.
+	BreakOnTestFailure bool
+	// TraceSummary records tracepoint hits, instead of printing them, to
+	// print statistics about the traced functions with PrintTraceSummary.
+	TraceSummary bool
+	// TUI shows the source, disassembly, local variables, goroutines and
+	// breakpoints in panes at the top of the screen, see tuiStart.
+	TUI bool
+	// traceCalls are the traced calls that have not returned yet, by
+	// goroutine.
+	traceCalls map[int][]traceCall
+	// traceSummary are the statistics of the traced functions, by name.
+	traceSummary map[string]*traceFuncSummary
 
 	historyFile *os.File
+	// history is the command history, the same as the one of the line
+	// editor, see appendHistory.
+	history        []string
+	historyExclude []*regexp.Regexp
+
+	// userCommandDepth is the number of user defined commands being
+	// executed, see runUserCommand.
+	userCommandDepth int
+
+	// inHook is set while the commands of a lifecycle hook are executed,
+	// exitHookDone after the on-exit hook ran, see runHook.
+	inHook       bool
+	exitHookDone bool
 
 	starlarkEnv *starbind.Env
 
@@ -93,12 +128,11 @@ func New(client service.Client, conf *config.Config) *Term {
 		w = getColorableWriter()
 	}
 
-	if (conf.SourceListLineColor > ansiWhite &&
-		conf.SourceListLineColor < ansiBrBlack) ||
-		conf.SourceListLineColor < ansiBlack ||
-		conf.SourceListLineColor > ansiBrWhite {
-		conf.SourceListLineColor = ansiBlue
-	}
+	validateColor(&conf.SourceListLineColor, ansiBlue)
+	validateColor(&conf.SourceListKeywordColor, ansiYellow)
+	validateColor(&conf.SourceListStringColor, ansiGreen)
+	validateColor(&conf.SourceListNumberColor, ansiCyan)
+	validateColor(&conf.SourceListCommentColor, ansiBrBlack)
 
 	t := &Term{
 		client: client,
@@ -115,6 +149,12 @@ func New(client service.Client, conf *config.Config) *Term {
 		client.SetReturnValuesLoadConfig(&lcfg)
 	}
 
+	for name := range conf.Commands {
+		if err := cmds.addUserCommand(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not define command: %v\n", err)
+		}
+	}
+
 	t.starlarkEnv = starbind.New(starlarkContext{t})
 	return t
 }
@@ -194,6 +234,9 @@ func (t *Term) Run() (int, error) {
 			}
 			return
 		}
+		if c := t.completeExpr(line); c != nil {
+			return c
+		}
 		for _, cmd := range t.cmds.cmds {
 			for _, alias := range cmd.aliases {
 				if strings.HasPrefix(alias, strings.ToLower(line)) {
@@ -204,21 +247,21 @@ func (t *Term) Run() (int, error) {
 		return
 	})
 
-	fullHistoryFile, err := config.GetConfigFilePath(historyFile)
-	if err != nil {
-		fmt.Printf("Unable to load history file: %v.", err)
-	}
+	t.loadHistory()
 
-	t.historyFile, err = os.OpenFile(fullHistoryFile, os.O_RDWR|os.O_CREATE, 0600)
-	if err != nil {
-		fmt.Printf("Unable to open history file: %v. History will not be saved for this session.", err)
-	}
-	if _, err := t.line.ReadHistory(t.historyFile); err != nil {
-		fmt.Printf("Unable to read history file: %v", err)
+	if t.TUI {
+		if err := t.tuiStart(); err != nil {
+			return 1, err
+		}
+		defer t.tuiStop()
 	}
 
 	fmt.Println("Type 'help' for list of commands.")
 
+	if t.BreakOnTestFailure {
+		t.setTestFailBreakpoint()
+	}
+
 	if t.InitFile != "" {
 		err := t.cmds.executeFile(t, t.InitFile)
 		if err != nil {
@@ -229,6 +272,14 @@ func (t *Term) Run() (int, error) {
 		}
 	}
 
+	if t.conf != nil {
+		if err := t.runHook("on-start", t.conf.OnStart); err != nil {
+			if _, ok := err.(ExitRequestError); ok {
+				return t.handleExit()
+			}
+		}
+	}
+
 	var lastCmd string
 
 	// Ensure that the target process is neither running nor recording by
@@ -236,6 +287,9 @@ func (t *Term) Run() (int, error) {
 	_, _ = t.client.GetState()
 
 	for {
+		if t.TUI {
+			t.tuiRedraw()
+		}
 		cmdstr, err := t.promptForInput()
 		if err != nil {
 			if err == io.EOF {
@@ -251,7 +305,7 @@ func (t *Term) Run() (int, error) {
 
 		lastCmd = cmdstr
 
-		if err := t.cmds.Call(cmdstr, t); err != nil {
+		if err := t.callPaged(cmdstr); err != nil {
 			if _, ok := err.(ExitRequestError); ok {
 				return t.handleExit()
 			}
@@ -273,6 +327,25 @@ func (t *Term) Run() (int, error) {
 	}
 }
 
+// validateColor replaces *color with def if it is not a valid foreground
+// color.
+func validateColor(color *int, def int) {
+	if (*color > ansiWhite && *color < ansiBrBlack) || *color < ansiBlack || *color > ansiBrWhite {
+		*color = def
+	}
+}
+
+// sourceStyles returns the escape sequences used to highlight the syntax
+// of source listings, see colorizeSource.
+func (t *Term) sourceStyles() map[sourceStyle]string {
+	return map[sourceStyle]string{
+		keywordStyle: fmt.Sprintf(terminalHighlightEscapeCode, t.conf.SourceListKeywordColor),
+		stringStyle:  fmt.Sprintf(terminalHighlightEscapeCode, t.conf.SourceListStringColor),
+		numberStyle:  fmt.Sprintf(terminalHighlightEscapeCode, t.conf.SourceListNumberColor),
+		commentStyle: fmt.Sprintf(terminalHighlightEscapeCode, t.conf.SourceListCommentColor),
+	}
+}
+
 // Println prints a line to the terminal.
 func (t *Term) Println(prefix, str string) {
 	if !t.dumb {
@@ -330,7 +403,7 @@ func (t *Term) promptForInput() (string, error) {
 
 	l = strings.TrimSuffix(l, "\n")
 	if l != "" {
-		t.line.AppendHistory(l)
+		t.appendHistory(l)
 	}
 
 	return l, nil
@@ -353,14 +426,7 @@ func yesno(line *liner.State, question string) (bool, error) {
 }
 
 func (t *Term) handleExit() (int, error) {
-	if t.historyFile != nil {
-		if _, err := t.line.WriteHistory(t.historyFile); err != nil {
-			fmt.Println("readline history error:", err)
-		}
-		if err := t.historyFile.Close(); err != nil {
-			fmt.Printf("error closing history file: %s\n", err)
-		}
-	}
+	t.saveHistory()
 
 	t.quittingMutex.Lock()
 	quitting := t.quitting
@@ -369,6 +435,11 @@ func (t *Term) handleExit() (int, error) {
 		return 0, nil
 	}
 
+	if t.client.IsObserver() {
+		// Observers can not detach or kill the target.
+		return 0, t.client.Disconnect(false)
+	}
+
 	s, err := t.client.GetState()
 	if err != nil {
 		if isErrProcessExited(err) {
@@ -437,17 +508,36 @@ func (t *Term) loadConfig() api.LoadConfig {
 	if t.conf != nil && t.conf.MaxVariableRecurse != nil {
 		r.MaxVariableRecurse = *t.conf.MaxVariableRecurse
 	}
+	if t.conf != nil {
+		r.DisableWellKnownTypes = t.conf.DisableWellKnownTypes
+	}
+	if t.conf != nil && t.conf.BytesView != nil {
+		switch *t.conf.BytesView {
+		case "string":
+			r.BytesView = api.BytesViewString
+		case "hex":
+			r.BytesView = api.BytesViewHex
+		case "both":
+			r.BytesView = api.BytesViewString | api.BytesViewHex
+		}
+	}
 
 	return r
 }
 
+// displayEntry is an expression added with the display command.
+type displayEntry struct {
+	expr   string
+	format string
+}
+
 func (t *Term) removeDisplay(n int) error {
-	if n < 0 || n >= len(t.displays) {
+	if n < 0 || n >= len(t.displays) || t.displays[n].expr == "" {
 		return fmt.Errorf("%d is out of range", n)
 	}
-	t.displays[n] = ""
+	t.displays[n] = displayEntry{}
 	for i := len(t.displays) - 1; i >= 0; i-- {
-		if t.displays[i] != "" {
+		if t.displays[i].expr != "" {
 			t.displays = t.displays[:i+1]
 			return nil
 		}
@@ -456,33 +546,109 @@ func (t *Term) removeDisplay(n int) error {
 	return nil
 }
 
-func (t *Term) addDisplay(expr string) {
-	t.displays = append(t.displays, expr)
+func (t *Term) addDisplay(expr, format string) {
+	t.displays = append(t.displays, displayEntry{expr: expr, format: format})
 }
 
 func (t *Term) printDisplay(i int) {
-	expr := t.displays[i]
-	val, err := t.client.EvalVariable(api.EvalScope{GoroutineID: -1}, expr, ShortLoadConfig)
+	d := t.displays[i]
+	name := d.expr
+	if d.format != "" {
+		name = "/" + d.format + " " + name
+	}
+	val, err := t.client.EvalVariable(api.EvalScope{GoroutineID: -1}, d.expr, ShortLoadConfig)
 	if err != nil {
 		if isErrProcessExited(err) {
 			return
 		}
-		fmt.Printf("%d: %s = error %v\n", i, expr, err)
+		fmt.Printf("%d: %s = error %v\n", i, name, err)
 		return
 	}
-	fmt.Printf("%d: %s = %s\n", i, val.Name, val.SinglelineString())
+	if d.format != "" {
+		formatIntegers(val, displayFormats[d.format])
+	}
+	fmt.Printf("%d: %s = %s\n", i, name, val.SinglelineString())
 }
 
 func (t *Term) printDisplays() {
 	for i := range t.displays {
-		if t.displays[i] != "" {
+		if t.displays[i].expr != "" {
 			t.printDisplay(i)
 		}
 	}
 }
 
+// displayFormats maps the formats of the display command to the verbs
+// used to print integers.
+var displayFormats = map[string]string{
+	"x": "%#x",
+	"o": "%#o",
+	"t": "%#b",
+	"d": "%d",
+	"c": "%q",
+}
+
+// formatIntegers rewrites the values of v, and of its children, that are
+// integers with the fmt verb.
+func formatIntegers(v *api.Variable, verb string) {
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			v.Value = fmt.Sprintf(verb, n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, err := strconv.ParseUint(v.Value, 10, 64); err == nil {
+			v.Value = fmt.Sprintf(verb, n)
+		}
+	}
+	for i := range v.Children {
+		formatIntegers(&v.Children[i], verb)
+	}
+}
+
 func (t *Term) onStop() {
+	if t.BreakOnTestFailure {
+		t.selectFailedTestFrame()
+	}
 	t.printDisplays()
+	if t.conf == nil || (len(t.conf.OnStop) == 0 && len(t.conf.OnExit) == 0) {
+		return
+	}
+	state, err := t.client.GetStateNonBlocking()
+	if (err == nil && state.Exited) || isErrProcessExited(err) {
+		if !t.exitHookDone {
+			t.exitHookDone = true
+			t.runHook("on-exit", t.conf.OnExit)
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+	t.exitHookDone = false
+	t.runHook("on-stop", t.conf.OnStop)
+}
+
+// runHook executes cmds, the commands of the lifecycle hook name, printing
+// their errors. Commands that resume the target do not run hooks again.
+// ExitRequestError is returned if one of the commands is 'exit'.
+func (t *Term) runHook(name string, cmds []string) error {
+	if t.inHook {
+		return nil
+	}
+	t.inHook = true
+	defer func() { t.inHook = false }()
+	for _, cmdstr := range cmds {
+		err := t.cmds.Call(cmdstr, t)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(ExitRequestError); ok {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", name, cmdstr, err)
+	}
+	return nil
 }
 
 func (t *Term) longCommandCancel() {
@@ -5,6 +5,8 @@ package terminal
 import (
 	"io"
 	"os"
+
+	"golang.org/x/sys/unix"
 )
 
 // getColorableWriter simply returns stdout on
@@ -12,3 +14,13 @@ import (
 func getColorableWriter() io.Writer {
 	return os.Stdout
 }
+
+// getTerminalSize returns the number of rows and columns of the terminal
+// connected to standard output.
+func getTerminalSize() (rows, cols int, err error) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Row), int(ws.Col), nil
+}
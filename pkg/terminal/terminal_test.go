@@ -1,12 +1,23 @@
 package terminal
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/rpc"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/peterh/liner"
 
 	"github.com/go-delve/delve/pkg/config"
+	"github.com/go-delve/delve/service/api"
 )
 
 type tRule struct {
@@ -105,3 +116,330 @@ func TestIsErrProcessExited(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderTUI(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	left := []tuiPane{{title: "Source", lines: lines, focus: 50}, {title: "Disassembly", lines: []string{"\tMOVQ"}}}
+	right := []tuiPane{{title: "Variables", lines: []string{"a very long line that does not fit in the pane"}}, {title: "Goroutines"}, {title: "Breakpoints", lines: lines, focus: 99}}
+	out := renderTUI(left, right, 11, 60)
+	if len(out) != 11 {
+		t.Fatalf("wrong number of lines %d", len(out))
+	}
+	for i, line := range out {
+		line = strings.Replace(strings.Replace(line, "\x1b[7m", "", -1), "\x1b[0m", "", -1)
+		if n := utf8.RuneCountInString(line); n != 60 {
+			t.Errorf("line %d has %d characters: %q", i, n, line)
+		}
+		out[i] = line
+	}
+	for _, tc := range []struct {
+		line  int
+		left  string
+		right string
+	}{
+		{0, "Source", "Variables"},
+		{1, "line 48", "a very long line that"},
+		{3, "line 50", "Goroutines"},
+		{5, "Disassembly", ""},
+		{6, "    MOVQ", "Breakpoints"},
+		{7, "", "line 96"},
+		{10, "", "line 99"},
+	} {
+		fields := strings.Split(out[tc.line], "│")
+		if len(fields) != 2 {
+			t.Fatalf("line %d: no separator in %q", tc.line, out[tc.line])
+		}
+		if !strings.HasPrefix(fields[0], tc.left) || !strings.HasPrefix(fields[1], tc.right) {
+			t.Errorf("line %d: got %q expected %q and %q", tc.line, out[tc.line], tc.left, tc.right)
+		}
+	}
+}
+
+func TestSplitCompletionExpr(t *testing.T) {
+	testCases := []struct {
+		in      string
+		start   int
+		base    string
+		partial string
+		isKey   bool
+	}{
+		{"", 0, "", "", false},
+		{"re", 0, "", "re", false},
+		{"req.Hea", 0, "req", "Hea", false},
+		{"a.b.c", 0, "a.b", "c", false},
+		{"x + req.He", 4, "req", "He", false},
+		{"(*p).f", 0, "(*p)", "f", false},
+		{"s[1].f", 0, "s[1]", "f", false},
+		{"len(req.H", 4, "req", "H", false},
+		{"m[", 2, "m", "", true},
+		{`a.m["fo`, 4, "a.m", `"fo`, true},
+		{`m["a]b"].x`, 0, `m["a]b"]`, "x", false},
+		{`x == "ab`, 5, "", "", false},
+	}
+	for _, tc := range testCases {
+		start, base, partial, isKey := splitCompletionExpr(tc.in)
+		if start != tc.start || base != tc.base || partial != tc.partial || isKey != tc.isKey {
+			t.Errorf("%q: got %d %q %q %v expected %d %q %q %v", tc.in, start, base, partial, isKey, tc.start, tc.base, tc.partial, tc.isKey)
+		}
+	}
+}
+
+func TestFormatIntegers(t *testing.T) {
+	v := &api.Variable{Kind: reflect.Struct, Children: []api.Variable{
+		{Name: "A", Kind: reflect.Int, Value: "-26"},
+		{Name: "B", Kind: reflect.Uint8, Value: "97"},
+		{Name: "C", Kind: reflect.String, Value: "10"},
+	}}
+	for _, tc := range []struct {
+		format string
+		tgt    [3]string
+	}{
+		{"x", [3]string{"-0x1a", "0x61", "10"}},
+		{"o", [3]string{"-032", "0141", "10"}},
+		{"t", [3]string{"-0b11010", "0b1100001", "10"}},
+		{"c", [3]string{"'\uFFFD'", "'a'", "10"}},
+	} {
+		v2 := *v
+		v2.Children = append([]api.Variable(nil), v.Children...)
+		formatIntegers(&v2, displayFormats[tc.format])
+		for i := range tc.tgt {
+			if v2.Children[i].Value != tc.tgt[i] {
+				t.Errorf("%s: field %d got %q expected %q", tc.format, i, v2.Children[i].Value, tc.tgt[i])
+			}
+		}
+	}
+}
+
+func TestColorizeSource(t *testing.T) {
+	styles := map[sourceStyle]string{keywordStyle: "<k>", stringStyle: "<s>", numberStyle: "<n>", commentStyle: "<c>"}
+	src := "package main\r\n\nfunc f() { // f\n\ts := `a\nb` + \"c\"\n\t/* x\n\ty */ return 12\n}"
+	expected := []string{
+		"<k>package\x1b[0m main",
+		"",
+		"<k>func\x1b[0m f() { <c>// f\x1b[0m",
+		"\ts := <s>`a\x1b[0m",
+		"<s>b`\x1b[0m + <s>\"c\"\x1b[0m",
+		"\t<c>/* x\x1b[0m",
+		"<c>\ty */\x1b[0m <k>return\x1b[0m <n>12\x1b[0m",
+		"}",
+	}
+	lines := colorizeSource([]byte(src), styles)
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), lines)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: expected %q got %q", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dlv-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	histfile := filepath.Join(dir, "history")
+	if err := ioutil.WriteFile(histfile, []byte("print a\nprint password\nlocals\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &Term{conf: &config.Config{HistoryFile: histfile, HistoryExclude: []string{"password"}}, line: liner.NewLiner()}
+	defer term.line.Close()
+	term.loadHistory()
+	term.appendHistory("print b")
+	term.appendHistory("set password = 1")
+	term.appendHistory("print b")
+	if expected := []string{"print a", "locals", "print b"}; !reflect.DeepEqual(term.history, expected) {
+		t.Fatalf("expected history %q, got %q", expected, term.history)
+	}
+	term.saveHistory()
+
+	buf, err := ioutil.ReadFile(histfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "print a\nlocals\nprint b\n"; string(buf) != expected {
+		t.Fatalf("expected history file %q, got %q", expected, buf)
+	}
+}
+
+func TestTraceSummary(t *testing.T) {
+	term := &Term{}
+	hit := func(gid int, fn, caller string, ret bool) {
+		th := &api.Thread{GoroutineID: gid, Function: &api.Function{Name_: fn}, Breakpoint: &api.Breakpoint{Tracepoint: !ret, TraceReturn: ret}}
+		if !ret {
+			th.BreakpointInfo = &api.BreakpointInfo{Stacktrace: []api.Stackframe{{}, {Location: api.Location{Function: &api.Function{Name_: caller}}}}}
+		}
+		term.recordTraceSummary(th)
+	}
+	hit(1, "main.f", "main.main", false)
+	hit(1, "main.g", "main.f", false)
+	hit(1, "main.g", "", true)
+	hit(1, "main.f", "", true)
+	hit(2, "main.g", "main.h", false)
+	hit(2, "main.g", "", true)
+	hit(1, "main.g", "main.main", false)
+
+	f, g := term.traceSummary["main.f"], term.traceSummary["main.g"]
+	if f.calls != 1 || f.returns != 1 || !reflect.DeepEqual(f.callers, map[string]int{"main.main": 1}) {
+		t.Errorf("wrong summary for main.f: %#v", f)
+	}
+	if g.calls != 3 || g.returns != 2 || !reflect.DeepEqual(g.callers, map[string]int{"main.f": 1, "main.h": 1, "main.main": 1}) {
+		t.Errorf("wrong summary for main.g: %#v", g)
+	}
+	if g.min > g.max || g.total < g.max {
+		t.Errorf("wrong durations for main.g: %#v", g)
+	}
+
+	var buf bytes.Buffer
+	term.PrintTraceSummary(&buf)
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 8 || !strings.HasPrefix(lines[1], "main.g ") || !strings.HasPrefix(lines[2], "    called by main.f ") || !strings.HasPrefix(lines[5], "main.f ") {
+		t.Errorf("wrong summary output:\n%s", buf.String())
+	}
+}
+
+func TestRunPager(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	cmds := []string{"", "b", "/nothing", "/line 8", "q"}
+	var buf bytes.Buffer
+	runPager(&buf, lines, 3, func(p string) (string, error) {
+		fmt.Fprintln(&buf, p)
+		cmd := cmds[0]
+		cmds = cmds[1:]
+		return cmd, nil
+	})
+	// The pager returns after the last page, without reading the remaining
+	// commands.
+	expected := "line 1\nline 2\nline 3\n--More-- (30%) \nline 4\nline 5\nline 6\n--More-- (60%) \nline 1\nline 2\nline 3\n--More-- (30%) \nPattern not found\n--More-- (30%) \nline 8\nline 9\nline 10\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+	if len(cmds) != 1 {
+		t.Fatalf("wrong number of commands read: %d", 5-len(cmds))
+	}
+}
+
+func TestOutputFilters(t *testing.T) {
+	out := []byte("  Goroutine 1 - main.main\n  Goroutine 2 - runtime.gopark\n* Goroutine 3 - main.worker\n  Goroutine 4 - runtime.gopark\n")
+	testCases := []struct {
+		cmdstr, cmd, out string
+	}{
+		{"goroutines", "goroutines", string(out)},
+		{"goroutines |> grep main", "goroutines", "  Goroutine 1 - main.main\n* Goroutine 3 - main.worker\n"},
+		{"goroutines|>grep -v -i GOPARK |> wc", "goroutines", "2\n"},
+		{`goroutines |> grep "- runtime" |> tail 1`, "goroutines", "  Goroutine 4 - runtime.gopark\n"},
+		{"goroutines |> head 1", "goroutines", "  Goroutine 1 - main.main\n"},
+		{`print "a |> grep" |> head`, `print "a |> grep"`, string(out)},
+		{"print a || grep", "print a || grep", string(out)},
+		{"print a | b", "print a | b", string(out)},
+		{"print l | head", "print l | head", string(out)},
+		{"print l|wc", "print l|wc", string(out)},
+	}
+	for _, tc := range testCases {
+		cmd, filters, err := splitOutputFilters(tc.cmdstr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.cmdstr, err)
+			continue
+		}
+		r := out
+		for _, filter := range filters {
+			r = filter(r)
+		}
+		if cmd != tc.cmd || string(r) != tc.out {
+			t.Errorf("%s: got %q %q, expected %q %q", tc.cmdstr, cmd, r, tc.cmd, tc.out)
+		}
+	}
+	for _, cmdstr := range []string{"goroutines |> grep", "goroutines |> head x", "goroutines |> grep (", "goroutines |>", "goroutines |> less"} {
+		if _, _, err := splitOutputFilters(cmdstr); err == nil {
+			t.Errorf("%s: no error", cmdstr)
+		}
+	}
+}
+
+func TestGuessSubstitutePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses unix paths")
+	}
+	local := map[string]bool{
+		"/home/me/app/main.go":                             true,
+		"/home/me/app/pkg/util/util.go":                    true,
+		"/opt/go/src/runtime/proc.go":                      true,
+		"/opt/go/src/fmt/print.go":                         true,
+		"/home/me/go/pkg/mod/github.com/x/y@v1.0.0/y.go":   true,
+		"/home/me/go/pkg/mod/github.com/x/y@v1.0.0/z/z.go": true,
+	}
+	sources := []string{
+		"/build/app/main.go",
+		"/build/app/pkg/util/util.go",
+		"/usr/local/go/src/runtime/proc.go",
+		"/usr/local/go/src/runtime/stack.go",
+		"/usr/local/go/src/fmt/print.go",
+		"/root/go/pkg/mod/github.com/x/y@v1.0.0/y.go",
+		"/root/go/pkg/mod/github.com/x/y@v1.0.0/z/z.go",
+		"/nowhere/foo.go",
+	}
+	guesses := guessSubstitutePath(sources, []string{"/home/me/app", "/opt/go", "/home/me/go"}, func(path string) bool { return local[path] })
+	expected := []substitutePathGuess{
+		{config.SubstitutePathRule{From: "/usr/local/go", To: "/opt/go"}, 3},
+		{config.SubstitutePathRule{From: "/build/app", To: "/home/me/app"}, 2},
+		{config.SubstitutePathRule{From: "/root/go", To: "/home/me/go"}, 2},
+	}
+	if !reflect.DeepEqual(guesses, expected) {
+		t.Fatalf("expected %v got %v", expected, guesses)
+	}
+}
+
+func TestConfigureSubstitutePath(t *testing.T) {
+	term := &Term{conf: &config.Config{}}
+	for _, cmd := range []string{"-add /a /b", "/c /d", "/a /e"} {
+		if err := configureSetSubstitutePath(term, cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if expected := (config.SubstitutePathRules{{From: "/a", To: "/e"}, {From: "/c", To: "/d"}}); !reflect.DeepEqual(term.conf.SubstitutePath, expected) {
+		t.Fatalf("expected %v got %v", expected, term.conf.SubstitutePath)
+	}
+	if res := term.substitutePath("/a/f.go"); res != "/e/f.go" {
+		t.Fatalf("wrong substitution %q", res)
+	}
+	if err := configureSetSubstitutePath(term, "-clear"); err != nil {
+		t.Fatal(err)
+	}
+	if len(term.conf.SubstitutePath) != 0 || term.substitutePath("/a/f.go") != "/a/f.go" {
+		t.Fatalf("rules not cleared: %v", term.conf.SubstitutePath)
+	}
+}
+
+func TestPrintStackDiff(t *testing.T) {
+	frame := func(fn string, line int) stackSnapshotFrame {
+		return stackSnapshotFrame{Function: fn, File: "main.go", Line: line}
+	}
+	saved := []stackSnapshotFrame{frame("main.f", 10), frame("main.g", 20), frame("main.main", 30)}
+	cur := []stackSnapshotFrame{frame("main.h", 40), frame("main.g", 21), frame("main.main", 30)}
+	var buf bytes.Buffer
+	printStackDiff(&Term{dumb: true}, &buf, saved, cur)
+	expected := `-   0  main.f at main.go:10
+-   1  main.g at main.go:20
++   0  main.h at main.go:40
++   1  main.g at main.go:21
+    2  main.main at main.go:30
+`
+	if buf.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	printStackDiff(&Term{dumb: true}, &buf, cur, cur)
+	if !strings.HasPrefix(buf.String(), "Stacks are the same\n") {
+		t.Fatalf("unexpected output for identical stacks:\n%s", buf.String())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/mattn/go-colorable"
+	"golang.org/x/sys/windows"
 )
 
 // getColorableWriter will return a writer that is capable
@@ -33,3 +34,13 @@ func getColorableWriter() io.Writer {
 	}
 	return colorable.NewColorableStdout()
 }
+
+// getTerminalSize returns the number of rows and columns of the console
+// window connected to standard output.
+func getTerminalSize() (rows, cols int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Bottom-info.Window.Top) + 1, int(info.Window.Right-info.Window.Left) + 1, nil
+}
@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+const (
+	// testFailBreakpointName is the name of the breakpoint set on
+	// testFailFunction by BreakOnTestFailure.
+	testFailBreakpointName = "testfailed"
+	testFailFunction       = "testing.(*common).Fail"
+	// testFailMaxDepth is the maximum depth searched for the frame of the
+	// failing test.
+	testFailMaxDepth = 20
+)
+
+// setTestFailBreakpoint sets the breakpoint used to stop when a test fails.
+func (t *Term) setTestFailBreakpoint() {
+	_, err := t.client.CreateBreakpoint(&api.Breakpoint{Name: testFailBreakpointName, FunctionName: testFailFunction, Line: -1})
+	if err != nil && !strings.Contains(err.Error(), "Breakpoint exists") {
+		fmt.Fprintf(os.Stderr, "Could not set breakpoint on test failures: %v\n", err)
+	}
+}
+
+// atTestFailure returns true if the current goroutine of state is stopped
+// because a test failed. When a subtest fails the parent tests are marked
+// as failed too, by calling Fail again, if propagation is true this is one
+// of those calls.
+func (t *Term) atTestFailure(state *api.DebuggerState) (failed, propagation bool, stack []api.Stackframe) {
+	if !t.BreakOnTestFailure || state == nil || state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.Name != testFailBreakpointName {
+		return false, false, nil
+	}
+	stack, err := t.client.Stacktrace(-1, testFailMaxDepth, 0, nil)
+	if err != nil {
+		return true, false, nil
+	}
+	return true, len(stack) > 1 && stack[1].Function != nil && stack[1].Function.Name() == testFailFunction, stack
+}
+
+// selectFailedTestFrame selects the first frame of the current goroutine
+// that is not part of the testing package, after a test failure.
+func (t *Term) selectFailedTestFrame() {
+	state, err := t.client.GetStateNonBlocking()
+	if err != nil {
+		return
+	}
+	failed, _, stack := t.atTestFailure(state)
+	if !failed {
+		return
+	}
+	for i, frame := range stack {
+		if frame.Function == nil || strings.HasPrefix(frame.Function.Name(), "testing.") {
+			continue
+		}
+		t.cmds.frame = i
+		fmt.Printf("Test failed, frame %d: %s:%d (PC: %x)\n", i, t.formatPath(frame.File), frame.Line, frame.PC)
+		printfile(t, frame.File, frame.Line, true)
+		return
+	}
+}
@@ -0,0 +1,126 @@
+package terminal
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// traceEvent is a tracepoint hit, as printed by 'dlv trace --format json'.
+type traceEvent struct {
+	// Time is when the debugger observed the hit.
+	Time time.Time `json:"time"`
+	// Kind is "call" for the entry of a function and "return" for its exit.
+	Kind      string `json:"kind"`
+	Goroutine int    `json:"goroutine"`
+	Function  string `json:"function"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+
+	Args         []traceValue `json:"args,omitempty"`
+	ReturnValues []traceValue `json:"returnValues,omitempty"`
+
+	// Duration is the time, in nanoseconds, between the call and the return
+	// of the function, only set for "return" events.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	Stack []traceFrame `json:"stack,omitempty"`
+}
+
+type traceValue struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type traceFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// traceCall is a function call that has not returned yet.
+type traceCall struct {
+	fn    string
+	start time.Time
+}
+
+// traceCallStart records the start of a call of fn by goroutine gid.
+func (t *Term) traceCallStart(gid int, fn string, start time.Time) {
+	if t.traceCalls == nil {
+		t.traceCalls = make(map[int][]traceCall)
+	}
+	t.traceCalls[gid] = append(t.traceCalls[gid], traceCall{fn: fn, start: start})
+}
+
+// traceCallEnd returns the duration of the last call of fn by goroutine
+// gid, which returned at end.
+func (t *Term) traceCallEnd(gid int, fn string, end time.Time) (time.Duration, bool) {
+	calls := t.traceCalls[gid]
+	// Calls that did not return normally, because they panicked, are
+	// discarded.
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].fn != fn {
+			continue
+		}
+		d := end.Sub(calls[i].start)
+		if i == 0 {
+			delete(t.traceCalls, gid)
+		} else {
+			t.traceCalls[gid] = calls[:i]
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func traceValues(vars []api.Variable, flag api.VariableFlags) []traceValue {
+	var r []traceValue
+	for _, v := range vars {
+		if v.Flags&flag == 0 {
+			continue
+		}
+		r = append(r, traceValue{Name: v.Name, Type: v.Type, Value: v.SinglelineString()})
+	}
+	return r
+}
+
+// printTracepointJSON prints the tracepoint hit of th to stderr as a JSON
+// object on a single line.
+func printTracepointJSON(t *Term, th *api.Thread) {
+	ev := traceEvent{
+		Time:      time.Now(),
+		Goroutine: th.GoroutineID,
+		File:      t.formatPath(th.File),
+		Line:      th.Line,
+	}
+	if th.Function != nil {
+		ev.Function = th.Function.Name()
+	}
+
+	if th.Breakpoint.Tracepoint {
+		ev.Kind = "call"
+		if th.BreakpointInfo != nil {
+			ev.Args = traceValues(th.BreakpointInfo.Arguments, api.VariableArgument)
+		}
+		t.traceCallStart(th.GoroutineID, ev.Function, ev.Time)
+	} else {
+		ev.Kind = "return"
+		ev.ReturnValues = traceValues(th.ReturnValues, api.VariableReturnArgument)
+		ev.Duration, _ = t.traceCallEnd(th.GoroutineID, ev.Function, ev.Time)
+	}
+
+	if th.BreakpointInfo != nil {
+		for _, frame := range th.BreakpointInfo.Stacktrace {
+			tf := traceFrame{File: t.formatPath(frame.File), Line: frame.Line}
+			if frame.Function != nil {
+				tf.Function = frame.Function.Name()
+			}
+			ev.Stack = append(ev.Stack, tf)
+		}
+	}
+
+	json.NewEncoder(os.Stderr).Encode(ev)
+}
@@ -0,0 +1,107 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// traceFuncSummary are the statistics of the calls of a traced function,
+// see recordTraceSummary.
+type traceFuncSummary struct {
+	fn    string
+	calls int
+	// returns is the number of calls whose duration is known, total, min
+	// and max are computed over them.
+	returns         int
+	total, min, max time.Duration
+	// callers counts the calls by calling function.
+	callers map[string]int
+}
+
+// recordTraceSummary adds the tracepoint hit of th to the statistics
+// printed by PrintTraceSummary.
+func (t *Term) recordTraceSummary(th *api.Thread) {
+	fn := ""
+	if th.Function != nil {
+		fn = th.Function.Name()
+	}
+	if t.traceSummary == nil {
+		t.traceSummary = make(map[string]*traceFuncSummary)
+	}
+	s := t.traceSummary[fn]
+	if s == nil {
+		s = &traceFuncSummary{fn: fn, callers: make(map[string]int)}
+		t.traceSummary[fn] = s
+	}
+
+	now := time.Now()
+	if th.Breakpoint.Tracepoint {
+		s.calls++
+		caller := "?"
+		if th.BreakpointInfo != nil && len(th.BreakpointInfo.Stacktrace) > 1 && th.BreakpointInfo.Stacktrace[1].Function != nil {
+			caller = th.BreakpointInfo.Stacktrace[1].Function.Name()
+		}
+		s.callers[caller]++
+		t.traceCallStart(th.GoroutineID, fn, now)
+		return
+	}
+
+	d, ok := t.traceCallEnd(th.GoroutineID, fn, now)
+	if !ok {
+		return
+	}
+	if s.returns == 0 || d < s.min {
+		s.min = d
+	}
+	if d > s.max {
+		s.max = d
+	}
+	s.total += d
+	s.returns++
+}
+
+// PrintTraceSummary prints, for each traced function, the number of calls,
+// the minimum, average and maximum duration of the calls and the number of
+// calls made by each caller. The functions that were called most are
+// printed first.
+func (t *Term) PrintTraceSummary(w io.Writer) {
+	summaries := make([]*traceFuncSummary, 0, len(t.traceSummary))
+	for _, s := range t.traceSummary {
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].calls != summaries[j].calls {
+			return summaries[i].calls > summaries[j].calls
+		}
+		return summaries[i].fn < summaries[j].fn
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Function\tCalls\tMin\tAvg\tMax\n")
+	for _, s := range summaries {
+		if s.returns > 0 {
+			fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%v\n", s.fn, s.calls, s.min, s.total/time.Duration(s.returns), s.max)
+		} else {
+			fmt.Fprintf(tw, "%s\t%d\t-\t-\t-\n", s.fn, s.calls)
+		}
+		callers := make([]string, 0, len(s.callers))
+		for caller := range s.callers {
+			callers = append(callers, caller)
+		}
+		sort.Slice(callers, func(i, j int) bool {
+			if s.callers[callers[i]] != s.callers[callers[j]] {
+				return s.callers[callers[i]] > s.callers[callers[j]]
+			}
+			return callers[i] < callers[j]
+		})
+		for _, caller := range callers {
+			fmt.Fprintf(tw, "    called by %s\t%d\t\t\t\n", caller, s.callers[caller])
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,218 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	isatty "github.com/mattn/go-isatty"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+const (
+	// tuiMinRows and tuiMinCols are the minimum size of the terminal needed
+	// to draw the panes of the TUI, on smaller terminals the TUI is
+	// suspended.
+	tuiMinRows = 20
+	tuiMinCols = 60
+	// tuiMaxGoroutines is the maximum number of goroutines listed by the
+	// goroutines pane.
+	tuiMaxGoroutines = 100
+)
+
+// tuiPane is a pane of the TUI.
+type tuiPane struct {
+	title string
+	lines []string
+	// focus is the index of the line that must be visible, it is kept in the
+	// middle of the pane.
+	focus int
+}
+
+// tuiStart switches the terminal to the full-screen TUI, where the top of
+// the screen is occupied by panes showing the source, disassembly, local
+// variables, goroutines and breakpoints, refreshed before every prompt, and
+// commands are entered in the bottom part.
+func (t *Term) tuiStart() error {
+	if t.dumb || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return errors.New("the TUI requires a terminal")
+	}
+	fmt.Fprint(t.stdout, "\x1b[?1049h\x1b[2J")
+	return nil
+}
+
+// tuiStop restores the terminal to the state it was before tuiStart.
+func (t *Term) tuiStop() {
+	fmt.Fprint(t.stdout, "\x1b[r\x1b[?1049l")
+}
+
+// tuiRedraw redraws the panes of the TUI and moves the cursor to the last
+// line of the screen.
+func (t *Term) tuiRedraw() {
+	rows, cols, err := getTerminalSize()
+	if err != nil || rows < tuiMinRows || cols < tuiMinCols {
+		// Let commands use the whole screen.
+		fmt.Fprint(t.stdout, "\x1b[r")
+		return
+	}
+	paneRows := rows * 2 / 3
+	left, right := t.tuiPanes()
+	var buf bytes.Buffer
+	// The commands and their output scroll below the panes.
+	fmt.Fprintf(&buf, "\x1b[%d;%dr", paneRows+1, rows)
+	for i, line := range renderTUI(left, right, paneRows, cols) {
+		fmt.Fprintf(&buf, "\x1b[%d;1H\x1b[2K%s", i+1, line)
+	}
+	fmt.Fprintf(&buf, "\x1b[%d;1H", rows)
+	t.stdout.Write(buf.Bytes())
+}
+
+// tuiPanes returns the contents of the panes of the TUI, the source and
+// disassembly panes go in the left column, the others in the right column.
+func (t *Term) tuiPanes() (left, right []tuiPane) {
+	src := tuiPane{title: "Source"}
+	disasm := tuiPane{title: "Disassembly"}
+	vars := tuiPane{title: "Variables"}
+	grs := tuiPane{title: "Goroutines"}
+	bps := tuiPane{title: "Breakpoints"}
+	left, right = []tuiPane{src, disasm}, []tuiPane{vars, grs, bps}
+
+	if bpsl, err := t.client.ListBreakpoints(); err == nil {
+		for _, bp := range bpsl {
+			if bp.ID < 0 {
+				continue
+			}
+			right[2].lines = append(right[2].lines, fmt.Sprintf("%d %s %s (%d)", bp.ID, formatBreakpointName(bp, false), t.formatBreakpointLocation(bp), bp.TotalHitCount))
+		}
+	}
+
+	state, err := t.client.GetStateNonBlocking()
+	switch {
+	case err != nil:
+		left[0].lines = []string{err.Error()}
+		return
+	case state.Running:
+		left[0].lines = []string{"running"}
+		return
+	case state.Exited:
+		left[0].lines = []string{fmt.Sprintf("Process exited with status %d", state.ExitStatus)}
+		return
+	}
+
+	var curg int
+	if state.SelectedGoroutine != nil {
+		curg = state.SelectedGoroutine.ID
+	}
+	if gs, _, err := t.client.ListGoroutines(0, tuiMaxGoroutines); err == nil {
+		for _, g := range gs {
+			prefix := "  "
+			if g.ID == curg {
+				prefix = "* "
+				right[1].focus = len(right[1].lines)
+			}
+			loc := g.UserCurrentLoc
+			right[1].lines = append(right[1].lines, fmt.Sprintf("%s%d %s:%d %s", prefix, g.ID, t.formatPath(loc.File), loc.Line, loc.Function.Name()))
+		}
+	}
+
+	if state.CurrentThread == nil {
+		return
+	}
+	th := state.CurrentThread
+	scope := api.EvalScope{GoroutineID: -1}
+
+	left[0].title = fmt.Sprintf("Source: %s", t.formatPath(th.File))
+	if src, _, err := openSourceFile(t, th.File); err == nil {
+		s := bufio.NewScanner(bytes.NewReader(src))
+		for n := 1; s.Scan(); n++ {
+			arrow := "  "
+			if n == th.Line {
+				arrow = "=>"
+				left[0].focus = len(left[0].lines)
+			}
+			left[0].lines = append(left[0].lines, fmt.Sprintf("%s%5d: %s", arrow, n, s.Text()))
+		}
+	} else {
+		left[0].lines = []string{err.Error()}
+	}
+
+	if text, err := t.client.DisassemblePC(scope, th.PC, api.IntelFlavour); err == nil {
+		for _, inst := range text {
+			arrow := "  "
+			if inst.AtPC {
+				arrow = "=>"
+				left[1].focus = len(left[1].lines)
+			}
+			left[1].lines = append(left[1].lines, fmt.Sprintf("%s %#x  %s", arrow, inst.Loc.PC, inst.Text))
+		}
+	}
+
+	args, _ := t.client.ListFunctionArgs(scope, ShortLoadConfig)
+	locals, _ := t.client.ListLocalVariables(scope, ShortLoadConfig)
+	for _, v := range append(args, locals...) {
+		right[0].lines = append(right[0].lines, fmt.Sprintf("%s = %s", v.Name, v.SinglelineString()))
+	}
+	return
+}
+
+// renderTUI returns the lines of the top rows of the screen, which is
+// cols characters wide, showing the panes in two columns.
+func renderTUI(left, right []tuiPane, rows, cols int) []string {
+	lw := cols * 3 / 5
+	l := renderTUIColumn(left, rows, lw)
+	r := renderTUIColumn(right, rows, cols-lw-1)
+	for i := range l {
+		l[i] += "│" + r[i]
+	}
+	return l
+}
+
+// renderTUIColumn returns the lines of a column of panes, rows tall and
+// width characters wide, dividing the rows evenly between the panes.
+func renderTUIColumn(panes []tuiPane, rows, width int) []string {
+	r := make([]string, 0, rows)
+	for i, pane := range panes {
+		h := rows / len(panes)
+		if i == len(panes)-1 {
+			h = rows - len(r)
+		}
+		if h <= 0 {
+			continue
+		}
+		// The title is shown in reverse video.
+		r = append(r, "\x1b[7m"+tuiLine(pane.title, width)+"\x1b[0m")
+		h--
+		start := pane.focus - h/2
+		if start > len(pane.lines)-h {
+			start = len(pane.lines) - h
+		}
+		if start < 0 {
+			start = 0
+		}
+		for j := 0; j < h; j++ {
+			line := ""
+			if start+j < len(pane.lines) {
+				line = pane.lines[start+j]
+			}
+			r = append(r, tuiLine(line, width))
+		}
+	}
+	return r
+}
+
+// tuiLine expands the tabs of s and truncates or pads it to exactly width
+// characters.
+func tuiLine(s string, width int) string {
+	s = strings.Replace(s, "\t", "    ", -1)
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		rs := []rune(s)
+		return string(rs[:width])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
@@ -0,0 +1,228 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/config"
+	"github.com/go-delve/delve/service/api"
+)
+
+// maxUserCommandDepth is the maximum number of user defined commands that
+// can be nested, to stop commands that call themselves.
+const maxUserCommandDepth = 16
+
+func defCommand(t *Term, ctx callContext, args string) error {
+	switch {
+	case args == "":
+		return listUserCommands(t)
+
+	case strings.HasPrefix(args, "-d "):
+		name := strings.TrimSpace(args[len("-d "):])
+		if _, ok := t.conf.Commands[name]; !ok {
+			return fmt.Errorf("%q is not a user defined command", name)
+		}
+		delete(t.conf.Commands, name)
+		t.cmds.removeUserCommand(name)
+		return nil
+	}
+
+	if eq := strings.Index(args, "="); eq >= 0 {
+		return t.defineCommand(strings.TrimSpace(args[:eq]), strings.TrimSpace(args[eq+1:]))
+	}
+
+	// Multi-line definition, read until 'end'.
+	var body []string
+	for {
+		line, err := t.line.Prompt("> ")
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "end" {
+			break
+		}
+		if line != "" {
+			body = append(body, line)
+		}
+	}
+	return t.defineCommand(args, strings.Join(body, "\n"))
+}
+
+func listUserCommands(t *Term) error {
+	names := make([]string, 0, len(t.conf.Commands))
+	for name := range t.conf.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		body := t.conf.Commands[name]
+		if !strings.Contains(body, "\n") {
+			fmt.Printf("def %s = %s\n", name, body)
+			continue
+		}
+		fmt.Printf("def %s\n", name)
+		for _, line := range strings.Split(body, "\n") {
+			fmt.Printf("\t%s\n", line)
+		}
+		fmt.Println("end")
+	}
+	return nil
+}
+
+// defineCommand defines the user command name, each line of body is
+// executed as a command, see expandUserCommand.
+func (t *Term) defineCommand(name, body string) error {
+	if name == "" || strings.ContainsAny(name, " \t/") {
+		return fmt.Errorf("invalid command name %q", name)
+	}
+	if body == "" {
+		return errors.New("empty command definition")
+	}
+	if err := t.cmds.addUserCommand(name); err != nil {
+		return err
+	}
+	if t.conf.Commands == nil {
+		t.conf.Commands = make(map[string]string)
+	}
+	t.conf.Commands[name] = body
+	return nil
+}
+
+// addUserCommand adds a command that runs the user defined command name,
+// whose definition is stored in the configuration.
+func (c *Commands) addUserCommand(name string) error {
+	for _, cmd := range c.cmds {
+		if cmd.match(name) {
+			if cmd.userDefined {
+				return nil
+			}
+			return fmt.Errorf("%q is already a command", name)
+		}
+	}
+	c.cmds = append(c.cmds, command{
+		aliases:     []string{name},
+		userDefined: true,
+		cmdFn: func(t *Term, ctx callContext, args string) error {
+			return t.runUserCommand(ctx, name, args)
+		},
+		helpMsg: "User defined command, see 'def'.",
+	})
+	return nil
+}
+
+func (c *Commands) removeUserCommand(name string) {
+	for i := range c.cmds {
+		if c.cmds[i].userDefined && c.cmds[i].match(name) {
+			c.cmds = append(c.cmds[:i], c.cmds[i+1:]...)
+			return
+		}
+	}
+}
+
+// runUserCommand runs the lines of the user defined command name.
+func (t *Term) runUserCommand(ctx callContext, name, args string) error {
+	body, ok := t.conf.Commands[name]
+	if !ok {
+		return fmt.Errorf("%q is not a user defined command", name)
+	}
+	if t.userCommandDepth >= maxUserCommandDepth {
+		return errors.New("too many nested user defined commands")
+	}
+	var vars map[string]string
+	if strings.Contains(body, "%") {
+		vars = t.userCommandVars(ctx.Scope)
+	}
+	body, err := expandUserCommand(body, config.SplitQuotedFields(args, '"'), vars)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	t.userCommandDepth++
+	defer func() { t.userCommandDepth-- }()
+	for _, line := range strings.Split(body, "\n") {
+		if err := t.cmds.CallWithContext(line, t, callContext{Prefix: noPrefix, Scope: ctx.Scope}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userCommandVars returns the values of the %-variables of user defined
+// commands for the frame of scope.
+func (t *Term) userCommandVars(scope api.EvalScope) map[string]string {
+	vars := map[string]string{}
+	frames, err := t.client.Stacktrace(scope.GoroutineID, scope.Frame, 0, nil)
+	if err == nil && scope.Frame < len(frames) {
+		frame := frames[scope.Frame]
+		vars["file"] = frame.File
+		vars["line"] = strconv.Itoa(frame.Line)
+		vars["pc"] = fmt.Sprintf("%#x", frame.PC)
+		if frame.Function != nil {
+			vars["function"] = frame.Function.Name()
+		}
+	}
+	if scope.GoroutineID >= 0 {
+		vars["goroutine"] = strconv.Itoa(scope.GoroutineID)
+	} else if state, err := t.client.GetStateNonBlocking(); err == nil && state.SelectedGoroutine != nil {
+		vars["goroutine"] = strconv.Itoa(state.SelectedGoroutine.ID)
+	}
+	return vars
+}
+
+// expandUserCommand substitutes, in the body of a user defined command,
+// $1 to $9 with the corresponding argument, $* with all the arguments, %name
+// with the value of vars[name] and $$ and %% with $ and %. Other uses of $
+// and % are left unchanged.
+func expandUserCommand(body string, args []string, vars map[string]string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		if (ch != '$' && ch != '%') || i+1 >= len(body) {
+			buf.WriteByte(ch)
+			continue
+		}
+		if body[i+1] == ch {
+			buf.WriteByte(ch)
+			i++
+			continue
+		}
+		if ch == '$' {
+			switch next := body[i+1]; {
+			case next == '*':
+				buf.WriteString(strings.Join(args, " "))
+			case '1' <= next && next <= '9':
+				n := int(next - '1')
+				if n >= len(args) {
+					return "", fmt.Errorf("missing argument $%c", next)
+				}
+				buf.WriteString(args[n])
+			default:
+				buf.WriteByte(ch)
+				continue
+			}
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(body) && ('a' <= body[j] && body[j] <= 'z') {
+			j++
+		}
+		name := body[i+1 : j]
+		if name == "" {
+			buf.WriteByte(ch)
+			continue
+		}
+		v, ok := vars[name]
+		if !ok {
+			// not a variable, for example a verb of a format string
+			buf.WriteByte(ch)
+			continue
+		}
+		buf.WriteString(v)
+		i = j - 1
+	}
+	return buf.String(), nil
+}
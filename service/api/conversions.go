@@ -82,7 +82,7 @@ func ConvertThread(th proc.Thread) *Thread {
 		gid      int
 	)
 
-	loc, err := th.Location()
+	loc, err := proc.LogicalLocation(th)
 	if err == nil {
 		pc = loc.PC
 		file = loc.File
@@ -149,6 +149,12 @@ func convertFloatValue(v *proc.Variable, sz int) string {
 
 // ConvertVar converts from proc.Variable to api.Variable.
 func ConvertVar(v *proc.Variable) *Variable {
+	return PrettyPrinters(nil).ConvertVar(v)
+}
+
+// ConvertVar converts from proc.Variable to api.Variable, using the pretty
+// printers in pps for the values of the variable and its children.
+func (pps PrettyPrinters) ConvertVar(v *proc.Variable) *Variable {
 	r := Variable{
 		Addr:     v.Addr,
 		OnlyAddr: v.OnlyAddr,
@@ -159,8 +165,12 @@ func ConvertVar(v *proc.Variable) *Variable {
 		Flags:    VariableFlags(v.Flags),
 		Base:     v.Base,
 
-		LocationExpr: v.LocationExpr.String(),
-		DeclLine:     v.DeclLine,
+		LocationExpr:   v.LocationExpr.String(),
+		DeclLine:       v.DeclLine,
+		ScopeStartLine: v.ScopeStartLine,
+		ScopeEndLine:   v.ScopeEndLine,
+
+		WellKnownValue: v.WellKnownValue,
 	}
 
 	r.Type = PrettyTypeName(v.DwarfType)
@@ -170,8 +180,16 @@ func ConvertVar(v *proc.Variable) *Variable {
 		r.Unreadable = v.Unreadable.Error()
 	}
 
+	if v.Bytes != nil {
+		r.Bytes = &BytesInfo{Hex: v.Bytes.Hex, String: v.Bytes.String, Encoding: v.Bytes.Encoding, Count: v.Bytes.Count}
+	}
+
 	r.Value = VariableValueAsString(v)
 
+	if v.DecodedKind != reflect.Invalid {
+		r.Kind = v.DecodedKind
+	}
+
 	switch v.Kind {
 	case reflect.Complex64:
 		r.Children = make([]Variable, 2)
@@ -219,13 +237,38 @@ func ConvertVar(v *proc.Variable) *Variable {
 		r.Children = make([]Variable, len(v.Children))
 
 		for i := range v.Children {
-			r.Children[i] = *ConvertVar(&v.Children[i])
+			r.Children[i] = *pps.ConvertVar(&v.Children[i])
+		}
+	}
+
+	if pp := pps[r.Type]; pp != nil && r.Unreadable == "" {
+		if s, ok := pp(&r); ok {
+			r.Value = s
 		}
 	}
 
 	return &r
 }
 
+// PrettyPrinter returns the string representation of the value of a
+// variable, if it returns false the variable is displayed normally.
+type PrettyPrinter func(v *Variable) (string, bool)
+
+// PrettyPrinters maps type names to the pretty printer used for variables
+// of that type. The value returned by a pretty printer is used as the Value
+// of the Variable returned by ConvertVar and as the return value of
+// VariableValueAsString.
+type PrettyPrinters map[string]PrettyPrinter
+
+// VariableValueAsString returns the value of v as a string, using the
+// pretty printer in pps for its type, if any.
+func (pps PrettyPrinters) VariableValueAsString(v *proc.Variable) string {
+	if pps[PrettyTypeName(v.DwarfType)] != nil {
+		return pps.ConvertVar(v).Value
+	}
+	return VariableValueAsString(v)
+}
+
 func VariableValueAsString(v *proc.Variable) string {
 	if v.Value == nil {
 		return ""
@@ -248,12 +291,18 @@ func VariableValueAsString(v *proc.Variable) string {
 
 // ConvertVars converts from []*proc.Variable to []api.Variable.
 func ConvertVars(pv []*proc.Variable) []Variable {
+	return PrettyPrinters(nil).ConvertVars(pv)
+}
+
+// ConvertVars converts from []*proc.Variable to []api.Variable, using the
+// pretty printers in pps.
+func (pps PrettyPrinters) ConvertVars(pv []*proc.Variable) []Variable {
 	if pv == nil {
 		return nil
 	}
 	vars := make([]Variable, 0, len(pv))
 	for _, v := range pv {
-		vars = append(vars, *ConvertVar(v))
+		vars = append(vars, *pps.ConvertVar(v))
 	}
 	return vars
 }
@@ -299,6 +348,7 @@ func ConvertGoroutine(g *proc.G) *Goroutine {
 		WaitReason:     g.WaitReason,
 		Labels:         g.Labels(),
 		Status:         g.Status,
+		CgoCall:        g.CgoCall(),
 	}
 }
 
@@ -350,6 +400,9 @@ func LoadConfigToProc(cfg *LoadConfig) *proc.LoadConfig {
 		MaxArrayValues:     cfg.MaxArrayValues,
 		MaxStructFields:    cfg.MaxStructFields,
 		MaxMapBuckets:      0, // MaxMapBuckets is set internally by pkg/proc, read its documentation for an explanation.
+
+		DisableWellKnownTypes: cfg.DisableWellKnownTypes,
+		BytesView:             proc.BytesView(cfg.BytesView),
 	}
 }
 
@@ -364,6 +417,9 @@ func LoadConfigFromProc(cfg *proc.LoadConfig) *LoadConfig {
 		MaxStringLen:       cfg.MaxStringLen,
 		MaxArrayValues:     cfg.MaxArrayValues,
 		MaxStructFields:    cfg.MaxStructFields,
+
+		DisableWellKnownTypes: cfg.DisableWellKnownTypes,
+		BytesView:             BytesView(cfg.BytesView),
 	}
 }
 
@@ -421,3 +477,239 @@ func ConvertRegisters(in *op.DwarfRegisters, dwarfRegisterToString func(int, *op
 func ConvertImage(image *proc.Image) Image {
 	return Image{Path: image.Path, Address: image.StaticBase}
 }
+
+// ConvertSignalPolicy converts the policy used for signal sig on the
+// operating system goos to its API representation.
+func ConvertSignalPolicy(goos string, sig int, policy proc.SignalPolicy) SignalPolicy {
+	return SignalPolicy{
+		Signal: sig,
+		Name:   proc.SignalName(goos, sig),
+		Stop:   policy.Stop,
+		Print:  policy.Print,
+		Pass:   policy.Pass,
+	}
+}
+
+// ConvertReceivedSignals converts a list of signals received by the target
+// process running on the operating system goos.
+func ConvertReceivedSignals(goos string, sigs []proc.ReceivedSignal) []ReceivedSignal {
+	if len(sigs) == 0 {
+		return nil
+	}
+	r := make([]ReceivedSignal, len(sigs))
+	for i := range sigs {
+		r[i] = ReceivedSignal{ThreadID: sigs[i].ThreadID, Signal: sigs[i].Signal, Name: proc.SignalName(goos, sigs[i].Signal)}
+	}
+	return r
+}
+
+// ConvertQueuedBreakpoints converts a list of proc.QueuedBreakpoint.
+func ConvertQueuedBreakpoints(qbps []proc.QueuedBreakpoint) []QueuedBreakpoint {
+	if len(qbps) == 0 {
+		return nil
+	}
+	r := make([]QueuedBreakpoint, len(qbps))
+	for i := range qbps {
+		loc := ConvertLocation(qbps[i].Location)
+		r[i] = QueuedBreakpoint{
+			ThreadID:       qbps[i].ThreadID,
+			GoroutineID:    qbps[i].GoroutineID,
+			BreakpointID:   qbps[i].Breakpoint.LogicalID,
+			BreakpointName: qbps[i].Breakpoint.Name,
+			Location:       &loc,
+		}
+	}
+	return r
+}
+
+// ConvertInlinedCalls converts a list of inlined calls returned by
+// proc.(*Target).InlineTree.
+func ConvertInlinedCalls(calls []proc.InlinedCallStart) []InlinedCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	r := make([]InlinedCall, len(calls))
+	for i := range calls {
+		r[i] = InlinedCall{Function: ConvertFunction(calls[i].Fn), File: calls[i].File, Line: calls[i].Line, Entered: calls[i].Entered}
+	}
+	return r
+}
+
+// ConvertGCStatus converts a proc.GCStatus into an api.GCStatus.
+func ConvertGCStatus(status *proc.GCStatus) *GCStatus {
+	if status == nil {
+		return nil
+	}
+	return &GCStatus{
+		Phase:        status.Phase.String(),
+		NumGC:        status.NumGC,
+		StopTheWorld: status.StopTheWorld,
+		Workers:      append([]int(nil), status.Workers...),
+	}
+}
+
+// ConvertTimers converts a slice of proc.Timer to a slice of Timer.
+func ConvertTimers(timers []proc.Timer) []Timer {
+	r := make([]Timer, len(timers))
+	for i, timer := range timers {
+		r[i] = Timer{
+			Addr:     timer.Addr,
+			P:        timer.P,
+			When:     timer.When,
+			Period:   timer.Period,
+			Callback: timer.Callback,
+		}
+	}
+	return r
+}
+
+// ConvertMutexState converts a proc.MutexState to a MutexState.
+func ConvertMutexState(state *proc.MutexState) *MutexState {
+	return &MutexState{
+		Locked:        state.Locked,
+		Woken:         state.Woken,
+		Starving:      state.Starving,
+		Waiters:       ConvertGoroutines(state.Waiters),
+		RWMutex:       state.RWMutex,
+		Readers:       state.Readers,
+		WriterPending: state.WriterPending,
+		WriterWaiters: ConvertGoroutines(state.WriterWaiters),
+		ReaderWaiters: ConvertGoroutines(state.ReaderWaiters),
+	}
+}
+
+// ConvertRawCallResult converts a proc.RawCallResult to a RawCallResult.
+func ConvertRawCallResult(r *proc.RawCallResult) *RawCallResult {
+	return &RawCallResult{R1: r.R1, R2: r.R2, Errno: r.Errno}
+}
+
+// ConvertFunctionPatch converts a proc.FunctionPatch to a FunctionPatch.
+func ConvertFunctionPatch(patch *proc.FunctionPatch) *FunctionPatch {
+	return &FunctionPatch{
+		ID:       patch.ID,
+		Function: patch.Fn.Name,
+		Addr:     patch.Fn.Entry,
+		CodeAddr: patch.CodeAddr,
+		Desc:     patch.Desc,
+	}
+}
+
+// ConvertMemoryGuard converts a proc.MemoryGuard to a MemoryGuard.
+func ConvertMemoryGuard(guard *proc.MemoryGuard) *MemoryGuard {
+	return &MemoryGuard{ID: guard.ID, Addr: guard.Addr, Size: guard.Size, Prot: guard.Prot.String()}
+}
+
+// ConvertRecorder converts a proc.Recorder to a Recorder.
+func ConvertRecorder(rec proc.Recorder) Recorder {
+	caps := rec.Capabilities()
+	r := Recorder{
+		Name:             rec.Name(),
+		Available:        true,
+		ReverseExecution: caps.ReverseExecution,
+		Checkpoints:      caps.Checkpoints,
+		RestartFromEvent: caps.RestartFromEvent,
+	}
+	if err := rec.Available(); err != nil {
+		r.Available = false
+		r.Err = err.Error()
+	}
+	return r
+}
+
+// ConvertGuardFault converts a proc.GuardFault to a GuardFault.
+func ConvertGuardFault(fault *proc.GuardFault) *GuardFault {
+	if fault == nil {
+		return nil
+	}
+	r := &GuardFault{ThreadID: fault.ThreadID, Addr: fault.Addr, PC: fault.PC}
+	if fault.Guard != nil {
+		r.GuardID = fault.Guard.ID
+	}
+	return r
+}
+
+// ConvertObjectGraph converts a proc.ObjectGraph to an ObjectGraph.
+func ConvertObjectGraph(g *proc.ObjectGraph) *ObjectGraph {
+	r := &ObjectGraph{
+		Nodes:     make([]ObjectGraphNode, len(g.Nodes)),
+		Edges:     make([]ObjectGraphEdge, len(g.Edges)),
+		Truncated: g.Truncated,
+	}
+	for i, n := range g.Nodes {
+		r.Nodes[i] = ObjectGraphNode{Addr: n.Addr, Type: n.Type, Size: n.Size, Root: n.Root}
+	}
+	for i, e := range g.Edges {
+		r.Edges[i] = ObjectGraphEdge{From: e.From, To: e.To, Label: e.Label}
+	}
+	return r
+}
+
+// ConvertContextChain converts a list of proc.ContextLayer to a list of
+// ContextLayer.
+func ConvertContextChain(layers []proc.ContextLayer) []ContextLayer {
+	r := make([]ContextLayer, len(layers))
+	for i, layer := range layers {
+		r[i] = ContextLayer{
+			Type:     layer.Type,
+			Addr:     layer.Addr,
+			Deadline: layer.Deadline,
+			Canceled: layer.Canceled,
+		}
+		if layer.Key != nil {
+			r[i].Key = ConvertVar(layer.Key)
+		}
+		if layer.Value != nil {
+			r[i].Value = ConvertVar(layer.Value)
+		}
+		if layer.Err != nil {
+			r[i].Err = ConvertVar(layer.Err)
+		}
+	}
+	return r
+}
+
+// ConvertItab converts a proc.Itab to an Itab.
+func ConvertItab(itab *proc.Itab) *Itab {
+	r := &Itab{
+		Addr:          itab.Addr,
+		InterfaceType: itab.InterfaceType,
+		ConcreteType:  itab.ConcreteType,
+		Methods:       make([]ItabMethod, len(itab.Methods)),
+	}
+	for i, m := range itab.Methods {
+		r.Methods[i] = ItabMethod{Name: m.Name, PC: m.PC, Function: ConvertFunction(m.Fn)}
+	}
+	return r
+}
+
+// ConvertReferrers converts a proc.Referrers to Referrers.
+func ConvertReferrers(refs *proc.Referrers) *Referrers {
+	r := &Referrers{
+		Addr:      refs.Addr,
+		Size:      refs.Size,
+		Referrers: make([]Referrer, len(refs.Referrers)),
+		Truncated: refs.Truncated,
+	}
+	for i, ref := range refs.Referrers {
+		r.Referrers[i] = Referrer{
+			Addr:        ref.Addr,
+			Size:        ref.Size,
+			Offset:      ref.Offset,
+			Type:        ref.Type,
+			Field:       ref.Field,
+			Var:         ref.Var,
+			GoroutineID: ref.GoroutineID,
+		}
+	}
+	return r
+}
+
+// ConvertHeapHistogram converts a list of proc.HeapHistogramEntry to a
+// list of HeapHistogramEntry.
+func ConvertHeapHistogram(entries []proc.HeapHistogramEntry) []HeapHistogramEntry {
+	r := make([]HeapHistogramEntry, len(entries))
+	for i, e := range entries {
+		r[i] = HeapHistogramEntry{Type: e.Type, Count: e.Count, Bytes: e.Bytes}
+	}
+	return r
+}
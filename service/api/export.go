@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats accepted by ExportVariable.
+const (
+	ExportFormatJSON = "json"
+	ExportFormatGo   = "go"
+)
+
+// ExportVariable serializes v as a JSON document (format ExportFormatJSON)
+// or as a Go composite literal (format ExportFormatGo).
+//
+// Only the parts of v that were loaded are exported: values that were not
+// loaded because of the limits of the LoadConfig used are exported as null
+// in JSON and annotated with a comment in Go.
+// Unreadable values, channels, functions and unsafe pointers can not be
+// represented faithfully in either format and are exported as strings in
+// JSON and as comments in Go.
+func ExportVariable(v *Variable, format string) (string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case ExportFormatJSON:
+		v.writeJSONTo(&buf, "")
+	case ExportFormatGo:
+		v.writeGoLiteralTo(&buf, "", false)
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
+
+func writeJSONString(buf io.Writer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+func (v *Variable) writeJSONTo(buf io.Writer, indent string) {
+	if v.Unreadable != "" {
+		writeJSONString(buf, "(unreadable "+v.Unreadable+")")
+		return
+	}
+	if v.WellKnownValue != "" {
+		writeJSONString(buf, v.WellKnownValue)
+		return
+	}
+	switch v.Kind {
+	case reflect.Bool:
+		fmt.Fprint(buf, v.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprint(buf, v.Value)
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(v.Value, 64); err != nil {
+			// NaN and infinities
+			writeJSONString(buf, v.Value)
+			return
+		}
+		fmt.Fprint(buf, v.Value)
+	case reflect.String:
+		writeJSONString(buf, v.Value)
+	case reflect.Ptr, reflect.Interface:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 || v.Children[0].OnlyAddr {
+			fmt.Fprint(buf, "null")
+			return
+		}
+		v.Children[0].writeJSONTo(buf, indent)
+	case reflect.Struct:
+		if len(v.Children) == 0 && v.Len > 0 {
+			fmt.Fprint(buf, "null")
+			return
+		}
+		writeJSONObject(buf, indent, len(v.Children), func(i int) (string, *Variable) {
+			return v.Children[i].Name, &v.Children[i]
+		})
+	case reflect.Map:
+		if v.Base == 0 && v.Len == 0 {
+			fmt.Fprint(buf, "null")
+			return
+		}
+		n := len(v.Children) / 2
+		stringKeys := true
+		for i := 0; i < n; i++ {
+			if v.Children[2*i].Kind != reflect.String {
+				stringKeys = false
+				break
+			}
+		}
+		if stringKeys {
+			writeJSONObject(buf, indent, n, func(i int) (string, *Variable) {
+				return v.Children[2*i].Value, &v.Children[2*i+1]
+			})
+			return
+		}
+		// maps with non-string keys are exported as a list of key/value pairs
+		writeJSONArray(buf, indent, n, func(buf io.Writer, i int, indent string) {
+			entry := []Variable{v.Children[2*i], v.Children[2*i+1]}
+			entry[0].Name, entry[1].Name = "key", "value"
+			writeJSONObject(buf, indent, 2, func(i int) (string, *Variable) {
+				return entry[i].Name, &entry[i]
+			})
+		})
+	case reflect.Array, reflect.Slice:
+		if v.Kind == reflect.Slice && v.Base == 0 {
+			fmt.Fprint(buf, "null")
+			return
+		}
+		writeJSONArray(buf, indent, len(v.Children), func(buf io.Writer, i int, indent string) {
+			v.Children[i].writeJSONTo(buf, indent)
+		})
+	default:
+		// complex numbers, channels, functions and unsafe pointers
+		writeJSONString(buf, v.SinglelineString())
+	}
+}
+
+func writeJSONObject(buf io.Writer, indent string, n int, field func(i int) (string, *Variable)) {
+	if n == 0 {
+		fmt.Fprint(buf, "{}")
+		return
+	}
+	fmt.Fprint(buf, "{\n")
+	for i := 0; i < n; i++ {
+		name, fv := field(i)
+		fmt.Fprintf(buf, "%s\t", indent)
+		writeJSONString(buf, name)
+		fmt.Fprint(buf, ": ")
+		fv.writeJSONTo(buf, indent+"\t")
+		if i != n-1 {
+			fmt.Fprint(buf, ",")
+		}
+		fmt.Fprint(buf, "\n")
+	}
+	fmt.Fprintf(buf, "%s}", indent)
+}
+
+func writeJSONArray(buf io.Writer, indent string, n int, elem func(buf io.Writer, i int, indent string)) {
+	if n == 0 {
+		fmt.Fprint(buf, "[]")
+		return
+	}
+	fmt.Fprint(buf, "[\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(buf, "%s\t", indent)
+		elem(buf, i, indent+"\t")
+		if i != n-1 {
+			fmt.Fprint(buf, ",")
+		}
+		fmt.Fprint(buf, "\n")
+	}
+	fmt.Fprintf(buf, "%s]", indent)
+}
+
+var importPathRegexp = regexp.MustCompile(`[\w.\-]+(?:/[\w.\-]+)*/(\w+\.)`)
+
+// goTypeName converts a type name, as returned by delve, into the name
+// of the same type in Go source, by removing the import paths of packages.
+func goTypeName(typ string) string {
+	return importPathRegexp.ReplaceAllString(typ, "$1")
+}
+
+// writeGoLiteralTo writes v as a Go expression. If typed is set scalars are
+// converted explicitly to their type, this is necessary for values stored
+// inside interfaces.
+func (v *Variable) writeGoLiteralTo(buf io.Writer, indent string, typed bool) {
+	if v.Unreadable != "" {
+		fmt.Fprintf(buf, "nil /* unreadable %s */", strings.Replace(v.Unreadable, "*/", "* /", -1))
+		return
+	}
+	typ := goTypeName(v.Type)
+	switch v.Kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		val := v.Value
+		switch v.Kind {
+		case reflect.String:
+			val = strconv.Quote(v.Value)
+			if v.Len > int64(len(v.Value)) {
+				val += fmt.Sprintf(" /* %d more bytes */", v.Len-int64(len(v.Value)))
+			}
+		case reflect.Float32, reflect.Float64:
+			switch val {
+			case "+Inf":
+				val = "math.Inf(+1)"
+			case "-Inf":
+				val = "math.Inf(-1)"
+			case "NaN":
+				val = "math.NaN()"
+			}
+		}
+		if typed {
+			fmt.Fprintf(buf, "%s(%s)", typ, val)
+		} else {
+			fmt.Fprint(buf, val)
+		}
+	case reflect.Ptr:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			fmt.Fprint(buf, "nil")
+			return
+		}
+		if v.Children[0].OnlyAddr {
+			fmt.Fprintf(buf, "nil /* %#x not loaded */", v.Children[0].Addr)
+			return
+		}
+		switch v.Children[0].Kind {
+		case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+			fmt.Fprint(buf, "&")
+			v.Children[0].writeGoLiteralTo(buf, indent, false)
+		default:
+			fmt.Fprintf(buf, "func() %s { v := ", typ)
+			v.Children[0].writeGoLiteralTo(buf, indent, true)
+			fmt.Fprint(buf, "; return &v }()")
+		}
+	case reflect.Interface:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			fmt.Fprint(buf, "nil")
+			return
+		}
+		if v.Children[0].OnlyAddr {
+			fmt.Fprintf(buf, "nil /* %s not loaded */", goTypeName(v.Children[0].Type))
+			return
+		}
+		v.Children[0].writeGoLiteralTo(buf, indent, true)
+	case reflect.Struct:
+		if len(v.Children) == 0 && v.Len > 0 {
+			fmt.Fprintf(buf, "%s{ /* not loaded */ }", typ)
+			return
+		}
+		if v.WellKnownValue != "" {
+			fmt.Fprintf(buf, "/* %s */ ", v.WellKnownValue)
+		}
+		fmt.Fprintf(buf, "%s{", typ)
+		for i := range v.Children {
+			fmt.Fprintf(buf, "\n%s\t%s: ", indent, v.Children[i].Name)
+			v.Children[i].writeGoLiteralTo(buf, indent+"\t", false)
+			fmt.Fprint(buf, ",")
+		}
+		if v.Len > int64(len(v.Children)) {
+			fmt.Fprintf(buf, "\n%s\t// %d more fields", indent, v.Len-int64(len(v.Children)))
+		}
+		if len(v.Children) > 0 {
+			fmt.Fprintf(buf, "\n%s", indent)
+		}
+		fmt.Fprint(buf, "}")
+	case reflect.Array, reflect.Slice:
+		if v.Kind == reflect.Slice && v.Base == 0 {
+			fmt.Fprint(buf, "nil")
+			return
+		}
+		fmt.Fprintf(buf, "%s{", typ)
+		for i := range v.Children {
+			fmt.Fprintf(buf, "\n%s\t", indent)
+			v.Children[i].writeGoLiteralTo(buf, indent+"\t", false)
+			fmt.Fprint(buf, ",")
+		}
+		if v.Len > int64(len(v.Children)) {
+			fmt.Fprintf(buf, "\n%s\t// %d more elements", indent, v.Len-int64(len(v.Children)))
+		}
+		if len(v.Children) > 0 || v.Len > 0 {
+			fmt.Fprintf(buf, "\n%s", indent)
+		}
+		fmt.Fprint(buf, "}")
+	case reflect.Map:
+		if v.Base == 0 && v.Len == 0 {
+			fmt.Fprint(buf, "nil")
+			return
+		}
+		fmt.Fprintf(buf, "%s{", typ)
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			fmt.Fprintf(buf, "\n%s\t", indent)
+			v.Children[i].writeGoLiteralTo(buf, indent+"\t", false)
+			fmt.Fprint(buf, ": ")
+			v.Children[i+1].writeGoLiteralTo(buf, indent+"\t", false)
+			fmt.Fprint(buf, ",")
+		}
+		if n := v.Len - int64(len(v.Children)/2); n > 0 {
+			fmt.Fprintf(buf, "\n%s\t// %d more entries", indent, n)
+		}
+		if v.Len > 0 {
+			fmt.Fprintf(buf, "\n%s", indent)
+		}
+		fmt.Fprint(buf, "}")
+	default:
+		// channels, functions and unsafe pointers
+		fmt.Fprintf(buf, "nil /* %s */", strings.Replace(v.SinglelineString(), "*/", "* /", -1))
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExportVariable(t *testing.T) {
+	v := &Variable{
+		Name: "req",
+		Type: "*github.com/example/server.Request",
+		Kind: reflect.Ptr,
+		Children: []Variable{{
+			Addr: 0xc000010000,
+			Type: "github.com/example/server.Request",
+			Kind: reflect.Struct,
+			Len:  4,
+			Children: []Variable{
+				{Name: "Method", Type: "string", Kind: reflect.String, Value: "GET", Len: 3},
+				{Name: "Header", Type: "map[string][]string", Kind: reflect.Map, Len: 1, Base: 0xc000020000, Children: []Variable{
+					{Type: "string", Kind: reflect.String, Value: "X-Id", Len: 4},
+					{Type: "[]string", Kind: reflect.Slice, Len: 1, Cap: 1, Base: 0xc000030000, Children: []Variable{
+						{Type: "string", Kind: reflect.String, Value: "abc123", Len: 6},
+					}},
+				}},
+				{Name: "Body", Type: "interface {}", Kind: reflect.Interface, Children: []Variable{
+					{Addr: 0xc000040000, Type: "int64", Kind: reflect.Int64, Value: "42"},
+				}},
+				{Name: "Parent", Type: "*github.com/example/server.Request", Kind: reflect.Ptr, Children: []Variable{
+					{Addr: 0, Type: "github.com/example/server.Request", Kind: reflect.Struct},
+				}},
+			},
+		}},
+	}
+
+	out, err := ExportVariable(v, ExportFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	tgt := map[string]interface{}{
+		"Method": "GET",
+		"Header": map[string]interface{}{"X-Id": []interface{}{"abc123"}},
+		"Body":   42.0,
+		"Parent": nil,
+	}
+	if !reflect.DeepEqual(got, tgt) {
+		t.Errorf("wrong JSON export: %s", out)
+	}
+
+	out, err = ExportVariable(v, ExportFormatGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgtGo := `&server.Request{
+	Method: "GET",
+	Header: map[string][]string{
+		"X-Id": []string{
+			"abc123",
+		},
+	},
+	Body: int64(42),
+	Parent: nil,
+}
+`
+	if out != tgtGo {
+		t.Errorf("wrong Go export:\n%s\nexpected:\n%s", out, tgtGo)
+	}
+
+	if _, err := ExportVariable(v, "xml"); err == nil {
+		t.Errorf("no error for unknown format")
+	}
+}
@@ -46,6 +46,26 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 		return
 	}
 
+	switch v.Kind {
+	case reflect.Slice, reflect.Array, reflect.Chan, reflect.Struct, reflect.Map:
+		if v.Value != "" {
+			// value returned by a pretty printer
+			if includeType {
+				fmt.Fprintf(buf, "%s ", v.Type)
+			}
+			buf.Write([]byte(v.Value))
+			return
+		}
+		if v.WellKnownValue != "" {
+			if includeType {
+				fmt.Fprintf(buf, "%s(%s)", v.Type, v.WellKnownValue)
+			} else {
+				buf.Write([]byte(v.WellKnownValue))
+			}
+			return
+		}
+	}
+
 	switch v.Kind {
 	case reflect.Slice:
 		v.writeSliceTo(buf, newlines, includeType, indent)
@@ -73,14 +93,17 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 	case reflect.String:
 		v.writeStringTo(buf)
 	case reflect.Chan:
-		if newlines {
-			v.writeStructTo(buf, newlines, includeType, indent)
+		if len(v.Children) == 0 {
+			fmt.Fprintf(buf, "%s nil", v.Type)
+		} else if newlines {
+			// the children of a channel are its buffer, closed flag and wait
+			// queues, they are printed like the fields of a struct.
+			cv := *v
+			cv.Type = fmt.Sprintf("%s %d/%d", v.Type, v.Len, v.Cap)
+			cv.Len = int64(len(v.Children))
+			cv.writeStructTo(buf, newlines, includeType, indent)
 		} else {
-			if len(v.Children) == 0 {
-				fmt.Fprintf(buf, "%s nil", v.Type)
-			} else {
-				fmt.Fprintf(buf, "%s %s/%s", v.Type, v.Children[0].Value, v.Children[1].Value)
-			}
+			fmt.Fprintf(buf, "%s %d/%d", v.Type, v.Len, v.Cap)
 		}
 	case reflect.Struct:
 		v.writeStructTo(buf, newlines, includeType, indent)
@@ -327,6 +350,10 @@ func (v *Variable) shouldNewlineStruct(newlines bool) bool {
 }
 
 func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent string) {
+	if v.Bytes != nil {
+		v.writeBytesTo(buf, newlines, indent)
+		return
+	}
 	nl := v.shouldNewlineArray(newlines)
 	fmt.Fprint(buf, "[")
 
@@ -440,3 +467,58 @@ func byteArrayToUInt64(buf []byte, isLittleEndian bool) uint64 {
 	}
 	return n
 }
+
+// writeBytesTo writes the alternative representations of a slice or array
+// of bytes loaded with LoadConfig.BytesView.
+func (v *Variable) writeBytesTo(buf io.Writer, newlines bool, indent string) {
+	more := ""
+	if v.Bytes.Count < v.Len {
+		more = fmt.Sprintf("...+%d more", v.Len-v.Bytes.Count)
+	}
+	if v.Bytes.String != "" || v.Bytes.Hex == "" {
+		fmt.Fprintf(buf, "\"%s\"", v.Bytes.String)
+		if v.Bytes.Hex == "" {
+			fmt.Fprint(buf, more)
+			return
+		}
+		if !newlines {
+			fmt.Fprint(buf, " ")
+		}
+	}
+	if !newlines {
+		fmt.Fprintf(buf, "[% x]%s", hexdumpBytes(v.Bytes.Hex), more)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(v.Bytes.Hex, "\n"), "\n") {
+		fmt.Fprintf(buf, "\n%s%s%s", indent, indentString, line)
+	}
+	if more != "" {
+		fmt.Fprintf(buf, "\n%s%s%s", indent, indentString, more)
+	}
+}
+
+// hexdumpBytes returns the bytes contained in a hexdump produced by
+// encoding/hex.Dump.
+func hexdumpBytes(dump string) []byte {
+	var r []byte
+	for _, line := range strings.Split(dump, "\n") {
+		// each line is: offset, two spaces, up to 16 bytes in hex (with an
+		// extra space after the 8th), two spaces, the bytes as characters
+		// enclosed in '|'.
+		if i := strings.Index(line, "|"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			b, err := strconv.ParseUint(field, 16, 8)
+			if err != nil {
+				break
+			}
+			r = append(r, byte(b))
+		}
+	}
+	return r
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/hex"
 	"fmt"
 	"math"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -57,3 +59,24 @@ func Test_byteArrayToUInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestPrettyBytes(t *testing.T) {
+	buf := []byte("t\xc3\xa8st")
+	v := &Variable{Kind: reflect.Slice, Type: "[]uint8", Len: 7, Cap: 8, Base: 0xc000010000}
+
+	tests := []struct {
+		bytes BytesInfo
+		tgt   string
+	}{
+		{BytesInfo{String: "tèst", Count: 5}, `[]uint8 len: 7, cap: 8, "tèst"...+2 more`},
+		{BytesInfo{Hex: hex.Dump(buf), Count: 5}, `[]uint8 len: 7, cap: 8, [74 c3 a8 73 74]...+2 more`},
+		{BytesInfo{String: "tèst", Hex: hex.Dump(buf), Count: 5}, `[]uint8 len: 7, cap: 8, "tèst" [74 c3 a8 73 74]...+2 more`},
+	}
+	for _, tc := range tests {
+		bytes := tc.bytes
+		v.Bytes = &bytes
+		if out := v.SinglelineString(); out != tc.tgt {
+			t.Errorf("got %q expected %q", out, tc.tgt)
+		}
+	}
+}
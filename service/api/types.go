@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 	"unicode"
 
 	"github.com/go-delve/delve/pkg/proc"
@@ -40,6 +41,25 @@ type DebuggerState struct {
 	ExitStatus int  `json:"exitStatus"`
 	// When contains a description of the current position in a recording
 	When string
+	// ReceivedSignals lists the signals, that the user asked to be notified
+	// of, received by the target process since it was last resumed.
+	ReceivedSignals []ReceivedSignal `json:"receivedSignals,omitempty"`
+	// InlinedCalls lists the inlined calls beginning at the current location
+	// of the selected goroutine, from the outermost to the innermost.
+	InlinedCalls []InlinedCall `json:"inlinedCalls,omitempty"`
+	// GC describes the state of the garbage collector of the target process,
+	// nil if it could not be determined.
+	GC *GCStatus `json:"gc,omitempty"`
+	// GuardFault describes the access to guarded memory that stopped the
+	// target process, if any.
+	GuardFault *GuardFault `json:"guardFault,omitempty"`
+	// QueuedBreakpoints lists the breakpoints hit by other goroutines while
+	// a next, step or stepout operation was in progress, that did not
+	// interrupt it.
+	QueuedBreakpoints []QueuedBreakpoint `json:"queuedBreakpoints,omitempty"`
+	// Watches contains the values of the watch expressions (see
+	// RPCServer.AddWatch) in the current frame of the selected goroutine.
+	Watches []Watch `json:"watches,omitempty"`
 	// Filled by RPCClient.Continue, indicates an error
 	Err error `json:"-"`
 }
@@ -258,6 +278,15 @@ type Variable struct {
 	// Function variables will store the name of the function in this field
 	Value string `json:"value"`
 
+	// Human readable representation of the value of variables of some well
+	// known types (time.Time, math/big.Int, net/netip.Addr and UUIDs), for
+	// example the RFC3339 representation of a time.Time.
+	WellKnownValue string `json:"wellKnownValue,omitempty"`
+
+	// Alternative representations of the contents of slices and arrays of
+	// bytes, set instead of Children if requested with LoadConfig.BytesView.
+	Bytes *BytesInfo `json:"bytes,omitempty"`
+
 	// Number of elements in an array or a slice, number of keys for a map, number of struct members for a struct, length of strings
 	Len int64 `json:"len"`
 	// Cap value for slices
@@ -279,10 +308,34 @@ type Variable struct {
 	// Unreadable addresses will have this field set
 	Unreadable string `json:"unreadable"`
 
+	// Changed is true if the value of this variable, or of one of its
+	// children, changed since the previous time it was returned during an
+	// earlier stop of the target.
+	Changed bool `json:"changed,omitempty"`
+
+	// StringerValue is the result of calling the String or Error method
+	// of this variable, it is only set if it was requested (see
+	// RPCServer.Eval) and the call succeeded.
+	StringerValue string `json:"stringerValue,omitempty"`
+
 	// LocationExpr describes the location expression of this variable's address
 	LocationExpr string
 	// DeclLine is the line number of this variable's declaration
 	DeclLine int64
+	// ScopeStartLine and ScopeEndLine are the first and last lines of the
+	// lexical block in which this variable is declared, they are only set
+	// for local variables.
+	ScopeStartLine, ScopeEndLine int
+}
+
+// StringerOptions configures the call of the String or Error method of an
+// evaluated variable, see RPCServer.Eval.
+type StringerOptions struct {
+	// Timeout is the maximum duration of the call, zero means no timeout.
+	Timeout time.Duration `json:"timeout"`
+	// MaxAlloc is the maximum growth in bytes of the live heap of the
+	// target during the call, zero means no limit.
+	MaxAlloc uint64 `json:"maxAlloc"`
 }
 
 // LoadConfig describes how to load values from target's memory
@@ -297,6 +350,44 @@ type LoadConfig struct {
 	MaxArrayValues int
 	// MaxStructFields is the maximum number of fields read from a struct, -1 will read all fields.
 	MaxStructFields int
+	// DisableWellKnownTypes disables the computation of WellKnownValue for
+	// variables of well known types and the decoding of the contents of
+	// sync.Map and sync/atomic.Value variables.
+	DisableWellKnownTypes bool
+	// BytesView, if not zero, selects alternative representations of the
+	// contents of slices and arrays of bytes, returned in Variable.Bytes
+	// instead of Children.
+	BytesView BytesView
+}
+
+// BytesView is the type of the BytesView field of LoadConfig.
+// Tracks proc.BytesView
+type BytesView uint8
+
+const (
+	// BytesViewHex requests a hexdump of the contents of slices and arrays
+	// of bytes.
+	BytesViewHex BytesView = 1 << iota
+
+	// BytesViewString requests the contents of slices and arrays of bytes
+	// as a string.
+	BytesViewString
+)
+
+// BytesInfo contains alternative representations of the contents of a
+// slice or array of bytes.
+type BytesInfo struct {
+	// Hex is a hexdump of the contents, in the same format as 'hexdump -C'.
+	Hex string `json:"hex,omitempty"`
+	// String is the contents as a string, with invalid UTF-8 sequences and
+	// non printable characters escaped.
+	String string `json:"string,omitempty"`
+	// Encoding is the encoding detected for the contents, one of "ascii",
+	// "utf-8" or "binary".
+	Encoding string `json:"encoding"`
+	// Count is the number of bytes read, it can be less than the length of
+	// the slice or array.
+	Count int64 `json:"count"`
 }
 
 // Goroutine represents the information relevant to Delve from the runtime's
@@ -320,6 +411,48 @@ type Goroutine struct {
 	Unreadable string `json:"unreadable"`
 	// Goroutine's pprof labels
 	Labels map[string]string `json:"labels,omitempty"`
+	// CgoCall is the name of the C function called by the goroutine, if it
+	// is executing a cgo call.
+	CgoCall string `json:"cgoCall,omitempty"`
+}
+
+var waitReasonStrings = [...]string{
+	"",
+	"GC assist marking",
+	"IO wait",
+	"chan receive (nil chan)",
+	"chan send (nil chan)",
+	"dumping heap",
+	"garbage collection",
+	"garbage collection scan",
+	"panicwait",
+	"select",
+	"select (no cases)",
+	"GC assist wait",
+	"GC sweep wait",
+	"GC scavenge wait",
+	"chan receive",
+	"chan send",
+	"finalizer wait",
+	"force gc (idle)",
+	"semacquire",
+	"sleep",
+	"sync.Cond.Wait",
+	"timer goroutine (idle)",
+	"trace reader (blocked)",
+	"wait for GC cycle",
+	"GC worker (idle)",
+	"preempted",
+	"debug call",
+}
+
+// WaitReasonString returns a description of the wait reason wr of a
+// goroutine, see Goroutine.WaitReason.
+func WaitReasonString(wr int64) string {
+	if wr > 0 && wr < int64(len(waitReasonStrings)) {
+		return waitReasonStrings[wr]
+	}
+	return fmt.Sprintf("unknown wait reason %d", wr)
 }
 
 const (
@@ -327,6 +460,85 @@ const (
 	GoroutineSyscall = proc.Gsyscall
 )
 
+// GoroutineField is a property of a goroutine that can be used to filter
+// and group goroutines, see ListGoroutinesFilter and
+// GoroutineGroupingOptions.
+type GoroutineField uint8
+
+const (
+	GoroutineFieldNone  GoroutineField = iota
+	GoroutineCurrentLoc                // the goroutine's CurrentLoc
+	GoroutineUserLoc                   // the goroutine's UserCurrentLoc
+	GoroutineGoLoc                     // the goroutine's GoStatementLoc
+	GoroutineStartLoc                  // the goroutine's StartLoc
+	GoroutineLabel                     // the goroutine's label
+	GoroutineRunning                   // the goroutine is running on a thread
+	GoroutineUser                      // the goroutine is a user goroutine
+	GoroutineExpr                      // the goroutine satisfies a filter expression
+)
+
+// ListGoroutinesFilter describes a filtering condition for the
+// ListGoroutines API call.
+type ListGoroutinesFilter struct {
+	Kind    GoroutineField
+	Negated bool
+	// Arg is the argument of the filter: for location fields it must be a
+	// substring of the location, formatted as "file:line in function", for
+	// GoroutineLabel it is either the name of a label, which must be set,
+	// or key=value, for GoroutineExpr it is a filter expression.
+	//
+	// Filter expressions use the syntax of Go expressions, they can refer
+	// to the following properties of the goroutine:
+	//
+	//	id          the goroutine ID
+	//	curloc      the current location, formatted as "file:line in function"
+	//	userloc     the current location, excluding runtime frames
+	//	goloc       the location of the go statement that started it
+	//	startloc    the location of its start function
+	//	fn          the function of userloc
+	//	pkg         the package of the function of userloc
+	//	label["k"]  the value of label k, or "" if it is not set
+	//	running     true if it is running on a thread
+	//	user        true if it is a user goroutine
+	//	waitreason  why it is blocked, for example "chan receive", or ""
+	//	waitfor     for how long it has been blocked, approximately
+	//
+	// and use the functions contains, hasprefix, hassuffix and matches
+	// (regular expression match). A string compared with waitfor is parsed
+	// as a duration, for example:
+	//
+	//	pkg == "example.com/mypkg" && waitreason == "chan receive" && waitfor > "1m"
+	//
+	// The value of waitfor is only known for goroutines that were blocked
+	// when the last garbage collection started, it is zero for the others.
+	Arg string
+}
+
+// GoroutineGroupingOptions describes how goroutines returned by the
+// ListGoroutines API call should be grouped.
+type GoroutineGroupingOptions struct {
+	// GroupBy is the field used to group goroutines, goroutines are not
+	// grouped if it is GoroutineFieldNone.
+	GroupBy GoroutineField
+	// GroupByKey is the name of the label used when GroupBy is
+	// GoroutineLabel.
+	GroupByKey string
+	// MaxGroupMembers is the maximum number of goroutines returned for each
+	// group, zero means no limit.
+	MaxGroupMembers int
+	// MaxGroups is the maximum number of groups returned, zero means no
+	// limit.
+	MaxGroups int
+}
+
+// GoroutineGroup is a group of goroutines returned by ListGoroutines.
+type GoroutineGroup struct {
+	Name   string // name of this group
+	Offset int    // start offset in the list of goroutines of this group
+	Count  int    // number of goroutines that belong to this group in the list of goroutines
+	Total  int    // total number of goroutines that belong to this group
+}
+
 // DebuggerCommand is a command which changes the debugger's execution state.
 type DebuggerCommand struct {
 	// Name is the command to run.
@@ -340,7 +552,8 @@ type DebuggerCommand struct {
 	// When ReturnInfoLoadConfig is not nil it will be used to load the value
 	// of any return variables.
 	ReturnInfoLoadConfig *LoadConfig
-	// Expr is the expression argument for a Call command
+	// Expr is the expression argument for a Call command, or the name of the
+	// inlined function for a StepInline command.
 	Expr string `json:"expr,omitempty"`
 
 	// UnsafeCall disables parameter escape checking for function calls.
@@ -357,6 +570,10 @@ type DebuggerCommand struct {
 	// violate the rules about stack objects you can disable this safety check
 	// by setting UnsafeCall to true.
 	UnsafeCall bool `json:"unsafeCall,omitempty"`
+	// CallTimeout is the maximum duration of a Call command, after which the
+	// target is stopped and the call is cancelled. Zero means no timeout.
+	// See also RPCServer.CancelCall.
+	CallTimeout time.Duration `json:"callTimeout,omitempty"`
 }
 
 // BreakpointInfo contains informations about the current breakpoint
@@ -387,6 +604,9 @@ const (
 	Step = "step"
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep = "reverseStep"
+	// StepInline steps into the inlined call, to the function specified by
+	// Expr, beginning at the current location without resuming the target.
+	StepInline = "stepInline"
 	// StepOut continues to the return address of the current function
 	StepOut = "stepOut"
 	// ReverseStepOut continues backward to the calle rof the current function.
@@ -465,6 +685,63 @@ type SetAPIVersionIn struct {
 type SetAPIVersionOut struct {
 }
 
+// SetObserverIn is the input for SetObserver.
+type SetObserverIn struct {
+}
+
+// SetObserverOut is the output for SetObserver.
+type SetObserverOut struct {
+}
+
+// AuthenticateIn is the input for Authenticate.
+type AuthenticateIn struct {
+	Token string
+}
+
+// AuthenticateOut is the output for Authenticate.
+type AuthenticateOut struct {
+	// Deny lists the operations that the connection is not allowed to
+	// perform.
+	Deny []string
+}
+
+// Capabilities describes the features supported by the target and the
+// backend used to debug it, so that clients can disable the ones that
+// would fail.
+type Capabilities struct {
+	Backend string // backend currently in use
+	// Core is true if the target is a core file, which can not be resumed
+	// or modified.
+	Core bool
+	// Recorded is true if the target is a recording, in which case
+	// ReverseExecution is also true.
+	Recorded         bool
+	ReverseExecution bool
+	// ReadOnly is true for observer connections, which can not change the
+	// state of the target.
+	ReadOnly bool
+
+	Restart       bool // the target can be restarted
+	Checkpoints   bool // checkpoints can be created
+	FunctionCalls bool // functions can be called while evaluating expressions
+	// FunctionPatching is true if PatchFunction can be used.
+	FunctionPatching bool
+	MemoryGuards     bool
+	BranchTrace      bool
+	Minidump         bool // the target can be dumped as a Windows minidump
+	// NonStop is true if the threads of the target can be stopped and
+	// resumed individually.
+	NonStop bool
+	// Stdin is true if the output of the target is being captured and its
+	// standard input can be written with WriteStdin.
+	Stdin bool
+
+	// Watchpoints and FollowFork are always false, no backend supports them
+	// yet.
+	Watchpoints bool
+	FollowFork  bool
+}
+
 // Register holds information on a CPU register.
 type Register struct {
 	Name        string
@@ -505,12 +782,134 @@ type Checkpoint struct {
 	Where string
 }
 
+// SignalPolicy describes how the debugger handles a signal received by
+// the target process.
+type SignalPolicy struct {
+	Signal int
+	Name   string
+	// Stop is true if the target process should stop when it receives the signal.
+	Stop bool
+	// Print is true if the user should be notified when the signal is received.
+	Print bool
+	// Pass is true if the signal should be delivered to the target process.
+	Pass bool
+}
+
+// ReceivedSignal is a signal received by a thread of the target process.
+type ReceivedSignal struct {
+	ThreadID int
+	Signal   int
+	Name     string
+}
+
+// QueuedBreakpoint is a breakpoint hit by a goroutine that did not
+// interrupt a next, step or stepout operation on a different goroutine.
+type QueuedBreakpoint struct {
+	ThreadID       int       `json:"threadID"`
+	GoroutineID    int       `json:"goroutineID"`
+	BreakpointID   int       `json:"breakpointID"`
+	BreakpointName string    `json:"breakpointName,omitempty"`
+	Location       *Location `json:"location"`
+}
+
+// InlinedCall is an inlined call beginning at the current location of a
+// goroutine.
+type InlinedCall struct {
+	Function *Function `json:"function,omitempty"`
+	// File and Line are the position of the call site.
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Entered is true if the user stepped into the inlined call, otherwise
+	// the call site is reported as the current location.
+	Entered bool `json:"entered"`
+}
+
+// GCStatus describes the state of the garbage collector of the target
+// process.
+type GCStatus struct {
+	// Phase is the current phase of the garbage collector: "off", "mark" or
+	// "mark termination".
+	Phase string `json:"phase"`
+	// NumGC is the number of completed GC cycles.
+	NumGC uint64 `json:"numGC"`
+	// StopTheWorld is true if the runtime is stopping, or has stopped, the
+	// world.
+	StopTheWorld bool `json:"stopTheWorld"`
+	// Workers lists the IDs of the GC background mark worker goroutines
+	// currently running on a thread.
+	Workers []int `json:"workers,omitempty"`
+}
+
 // Image represents a loaded shared object (go plugin or shared library)
 type Image struct {
 	Path    string
 	Address uint64
 }
 
+// EventKind is the kind of an Event.
+type EventKind string
+
+const (
+	// EventStopped is generated when the target stops after being resumed,
+	// State contains the state of the target.
+	EventStopped EventKind = "stopped"
+	// EventExited is generated when the target exits, ExitStatus contains
+	// its exit status.
+	EventExited EventKind = "exited"
+	// EventImagesLoaded is generated when the target loads new shared
+	// objects or plugins, they are listed in Images.
+	EventImagesLoaded EventKind = "imagesLoaded"
+	// EventOutput is generated when the target writes to its standard output
+	// or standard error and the debugger is capturing them, Output contains
+	// the data written and Stream is either "stdout" or "stderr".
+	EventOutput EventKind = "output"
+)
+
+// Event is a change of state of the target, reported to all clients.
+type Event struct {
+	// ID identifies the event, events are numbered consecutively.
+	ID   uint64    `json:"id"`
+	Kind EventKind `json:"kind"`
+
+	State      *DebuggerState `json:"state,omitempty"`
+	ExitStatus int            `json:"exitStatus"`
+	Images     []Image        `json:"images,omitempty"`
+	Stream     string         `json:"stream,omitempty"`
+	Output     string         `json:"output,omitempty"`
+}
+
+// EventHook is a starlark script that the server runs every time an event
+// happens, see RPCServer.AddEventHook.
+type EventHook struct {
+	ID int `json:"id"`
+	// On is the kind of event that runs the hook, either EventStopped or
+	// EventExited.
+	On EventKind `json:"on"`
+	// Breakpoint, if set, restricts an EventStopped hook to the stops caused
+	// by the breakpoint with this name or ID, "*" matches every breakpoint.
+	Breakpoint string `json:"breakpoint,omitempty"`
+	// Script is the source of the starlark script, it must define a
+	// function main taking one argument, the Event that triggered the hook.
+	Script string `json:"script"`
+	// Resume, for EventStopped hooks, resumes the target after running the
+	// script.
+	Resume bool `json:"resume,omitempty"`
+
+	// Runs is the number of times the hook ran.
+	Runs int `json:"runs"`
+	// Output is what the script printed the last time it ran.
+	Output string `json:"output,omitempty"`
+	// Err is the error returned by the script the last time it ran.
+	Err string `json:"err,omitempty"`
+}
+
+// EvalResult is the result of evaluating one of the expressions of a batch,
+// either Variable or Err is set.
+type EvalResult struct {
+	Variable *Variable `json:"variable,omitempty"`
+	Err      string    `json:"err,omitempty"`
+}
+
 // Ancestor represents a goroutine ancestor
 type Ancestor struct {
 	ID    int64
@@ -519,6 +918,22 @@ type Ancestor struct {
 	Unreadable string
 }
 
+// GoroutineTreeNode is a goroutine of the tree of goroutines returned by
+// RPCServer.GoroutineTree.
+type GoroutineTreeNode struct {
+	ID int `json:"id"`
+	// ParentID is the ID of the goroutine that created this goroutine, 0 if
+	// it is not known.
+	ParentID int `json:"parentID"`
+	// Goroutine describes the goroutine, it is nil if the goroutine has
+	// exited.
+	Goroutine *Goroutine `json:"goroutine,omitempty"`
+	// CreationStack is the stack of the parent goroutine when it created
+	// this goroutine.
+	CreationStack []Stackframe `json:"creationStack,omitempty"`
+	Unreadable    string       `json:"unreadable,omitempty"`
+}
+
 // StacktraceOptions is the type of the Opts field of StacktraceIn that
 // configures the stacktrace.
 // Tracks proc.StacktraceOptions
@@ -544,3 +959,279 @@ type PackageBuildInfo struct {
 	DirectoryPath string
 	Files         []string
 }
+
+// Timer describes a pending runtime timer of the target process.
+type Timer struct {
+	// Addr is the address of the runtime.timer struct.
+	Addr uint64 `json:"addr"`
+	// P is the ID of the P whose heap contains the timer, -1 if the target
+	// process was built with Go 1.13 or earlier.
+	P int `json:"p"`
+	// When is the value of the runtime monotonic clock when the timer will
+	// fire.
+	When int64 `json:"when"`
+	// Period is the interval between successive firings of the timer, zero
+	// for timers that only fire once.
+	Period int64 `json:"period"`
+	// Callback is the name of the function called when the timer fires.
+	Callback string `json:"callback"`
+}
+
+// RawCallResult is the result of a raw function call or system call.
+type RawCallResult struct {
+	// R1 and R2 are the values of the return registers after the call (RAX
+	// and RDX on amd64, X0 and X1 on arm64).
+	R1 uint64 `json:"r1"`
+	R2 uint64 `json:"r2"`
+	// Errno is the error number returned by a system call, zero if it
+	// succeeded.
+	Errno uint64 `json:"errno,omitempty"`
+}
+
+// FunctionPatch describes a change to the code of a function of the
+// target process.
+type FunctionPatch struct {
+	ID int `json:"id"`
+	// Function is the name of the patched function and Addr its entry
+	// point.
+	Function string `json:"function"`
+	Addr     uint64 `json:"addr"`
+	// CodeAddr is the address of the replacement code if it was written to
+	// memory allocated by the debugger, zero if it was written at the entry
+	// point of the function.
+	CodeAddr uint64 `json:"codeAddr,omitempty"`
+	// Desc describes the replacement code.
+	Desc string `json:"desc"`
+}
+
+// MemoryGuard describes a range of memory of the target process whose
+// protection was changed to catch unwanted accesses.
+type MemoryGuard struct {
+	ID   int    `json:"id"`
+	Addr uint64 `json:"addr"`
+	Size uint64 `json:"size"`
+	// Prot is the protection of the range, in the format used by
+	// /proc/<pid>/maps ("r--" for read-only memory).
+	Prot string `json:"prot"`
+}
+
+// Recorder describes a record and replay engine that can be used as a
+// backend, and the features supported by its recordings.
+type Recorder struct {
+	Name string `json:"name"`
+	// Available is false if the recorder can not be used, Err contains the
+	// reason.
+	Available bool   `json:"available"`
+	Err       string `json:"err,omitempty"`
+
+	ReverseExecution bool `json:"reverseExecution"`
+	Checkpoints      bool `json:"checkpoints"`
+	RestartFromEvent bool `json:"restartFromEvent"`
+}
+
+// Branch is a change in the flow of execution of a thread, recorded with
+// Intel Processor Trace.
+type Branch struct {
+	From Location `json:"from"`
+	To   Location `json:"to"`
+}
+
+// GuardFault describes an access to memory protected by a MemoryGuard.
+type GuardFault struct {
+	ThreadID int `json:"threadID"`
+	// Addr is the address that was accessed and PC the address of the
+	// faulting instruction.
+	Addr uint64 `json:"addr"`
+	PC   uint64 `json:"pc"`
+	// GuardID is the ID of the guard containing Addr.
+	GuardID int `json:"guardID"`
+}
+
+// MutexState describes the state of a sync.Mutex or sync.RWMutex variable.
+type MutexState struct {
+	// Locked is true if the mutex is locked, for a RWMutex it is true if a
+	// writer holds the lock or is waiting for the active readers to release
+	// it.
+	Locked bool `json:"locked"`
+	// Woken is true if a waiter has been woken and is trying to acquire the
+	// mutex.
+	Woken bool `json:"woken"`
+	// Starving is true if the mutex is in starvation mode.
+	Starving bool `json:"starving"`
+	// Waiters lists the goroutines blocked acquiring the mutex, for a
+	// RWMutex they are the writers waiting for another writer.
+	Waiters []*Goroutine `json:"waiters,omitempty"`
+
+	// RWMutex is true if the variable is a sync.RWMutex, the following
+	// fields are only set for RWMutex variables.
+	RWMutex bool `json:"rwMutex"`
+	// Readers is the number of readers holding the lock.
+	Readers int `json:"readers,omitempty"`
+	// WriterPending is true if a writer is waiting for the active readers
+	// to release the lock.
+	WriterPending bool `json:"writerPending,omitempty"`
+	// WriterWaiters lists the writers blocked waiting for the active
+	// readers to release the lock.
+	WriterWaiters []*Goroutine `json:"writerWaiters,omitempty"`
+	// ReaderWaiters lists the readers blocked waiting for a writer to
+	// release the lock.
+	ReaderWaiters []*Goroutine `json:"readerWaiters,omitempty"`
+}
+
+// ContextLayer describes one of the contexts wrapped by a context.Context.
+type ContextLayer struct {
+	// Type is the concrete type of the context.
+	Type string `json:"type"`
+	Addr uint64 `json:"addr"`
+	// Key and Value are the key/value pair stored by a context created
+	// with context.WithValue.
+	Key   *Variable `json:"key,omitempty"`
+	Value *Variable `json:"value,omitempty"`
+	// Deadline is the deadline of the context, formatted as RFC3339, if
+	// it was created with context.WithDeadline or context.WithTimeout.
+	Deadline string `json:"deadline,omitempty"`
+	// Canceled is true if the context was canceled, Err is the error
+	// returned by its Err method.
+	Canceled bool      `json:"canceled"`
+	Err      *Variable `json:"err,omitempty"`
+}
+
+// Itab describes the itab of an interface value.
+type Itab struct {
+	// Addr is the address of the runtime.itab struct, it is 0 for empty
+	// interfaces.
+	Addr          uint64 `json:"addr"`
+	InterfaceType string `json:"interfaceType"`
+	// ConcreteType is the type of the value stored in the interface, it is
+	// empty for nil interfaces.
+	ConcreteType string       `json:"concreteType"`
+	Methods      []ItabMethod `json:"methods,omitempty"`
+}
+
+// ItabMethod is an entry of the method table of an itab.
+type ItabMethod struct {
+	// Name is the name of the interface method.
+	Name string `json:"name"`
+	// PC is the entry point of the implementation and Function the function
+	// it belongs to.
+	PC       uint64    `json:"pc"`
+	Function *Function `json:"function,omitempty"`
+}
+
+// Watch is an expression evaluated by the debugger every time the target
+// process stops.
+type Watch struct {
+	ID   int    `json:"id"`
+	Expr string `json:"expr"`
+	// Value is the value of Expr during the current stop, its Changed
+	// field is set if the value is different from the previous stop.
+	Value *Variable `json:"value,omitempty"`
+	// Err is the error that occurred evaluating Expr, if any.
+	Err string `json:"err,omitempty"`
+}
+
+// Referrers is the list of objects containing pointers to the object at
+// Addr, see RPCServer.FindReferrers.
+type Referrers struct {
+	Addr      uint64     `json:"addr"`
+	Size      uint64     `json:"size"`
+	Referrers []Referrer `json:"referrers"`
+	// Truncated is true if the search stopped before scanning all memory
+	// because it found too many referrers.
+	Truncated bool `json:"truncated"`
+}
+
+// Referrer is an object containing a pointer to the searched object.
+type Referrer struct {
+	Addr uint64 `json:"addr"`
+	Size uint64 `json:"size"`
+	// Offset is the offset of the pointer inside the object.
+	Offset uint64 `json:"offset"`
+	// Type is the type of the object, if known, and Field the path of the
+	// field at Offset.
+	Type  string `json:"type,omitempty"`
+	Field string `json:"field,omitempty"`
+	// Var is the name of the package variable, if the object is a package
+	// variable.
+	Var string `json:"var,omitempty"`
+	// GoroutineID is the ID of the goroutine, if the object is the stack
+	// of a goroutine.
+	GoroutineID int `json:"goroutineID,omitempty"`
+}
+
+// HeapHistogramEntry is the number of heap objects of a type and the
+// memory they use, see RPCServer.HeapHistogram.
+type HeapHistogramEntry struct {
+	Type  string `json:"type"`
+	Count uint64 `json:"count"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// ObjectGraph is a graph of the objects reachable from a set of root
+// variables and of the pointers between them.
+type ObjectGraph struct {
+	Nodes []ObjectGraphNode `json:"nodes"`
+	Edges []ObjectGraphEdge `json:"edges"`
+	// Truncated is true if the graph reached its maximum size and some
+	// objects were omitted.
+	Truncated bool `json:"truncated"`
+}
+
+// ObjectGraphNode is an object of an ObjectGraph.
+type ObjectGraphNode struct {
+	Addr uint64 `json:"addr"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+	// Root is the expression of the root variable, if this node is a root.
+	Root string `json:"root,omitempty"`
+}
+
+// ObjectGraphEdge is a pointer from the node with index From to the node
+// with index To, stored at path Label inside the object of node From.
+type ObjectGraphEdge struct {
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Label string `json:"label"`
+}
+
+// SourceFile is the content of a source file of the target.
+type SourceFile struct {
+	// Path is the path of the file as recorded in the debug info.
+	Path string `json:"path"`
+	// HostPath is the path the file was read from on the machine running
+	// the debugger, after path substitution.
+	HostPath string    `json:"hostPath"`
+	Content  []byte    `json:"content"`
+	ModTime  time.Time `json:"modTime"`
+	// SHA256 is the hex encoded SHA-256 checksum of Content.
+	SHA256 string `json:"sha256"`
+}
+
+// Session is the state of a debug session, that can be restored against a
+// new instance of the target, see RPCServer.SaveSession.
+type Session struct {
+	Breakpoints []*Breakpoint  `json:"breakpoints"`
+	Watches     []SessionWatch `json:"watches,omitempty"`
+	// SubstitutePath are the source path substitution rules of the client
+	// that saved the session, they are not used by the debugger.
+	SubstitutePath [][2]string `json:"substitutePath,omitempty"`
+}
+
+// SessionWatch is a watch expression saved in a Session.
+type SessionWatch struct {
+	Expr string     `json:"expr"`
+	Cfg  LoadConfig `json:"cfg"`
+}
+
+// DumpOptions describes how a core file of the target is written.
+type DumpOptions struct {
+	// Selective restricts the dump to the stacks and global variables of the
+	// target and the memory reachable from them.
+	Selective bool `json:"selective"`
+	// Minidump writes a Windows minidump instead of an ELF core file.
+	Minidump bool `json:"minidump"`
+	// Compress compresses the output file with gzip.
+	Compress bool `json:"compress"`
+	// Sparse omits pages that only contain zeroes from the output file.
+	Sparse bool `json:"sparse"`
+}
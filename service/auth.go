@@ -0,0 +1,120 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Operations that an AuthPolicy can deny to a token.
+const (
+	// OpSet is changing the value of variables and writing memory.
+	OpSet = "set"
+	// OpCall is executing code inside the target: calling functions,
+	// raw calls, function patches and memory guards.
+	OpCall = "call"
+	// OpDetach is detaching from the target, or killing it.
+	OpDetach = "detach"
+	// OpExec is restarting the target, which can rebuild it and start it
+	// with different arguments, and adding event hooks, which run scripts
+	// on the server.
+	OpExec = "exec"
+	// OpDump is writing core files and checkpoints on the machine running
+	// the server.
+	OpDump = "dump"
+)
+
+var authOperations = []string{OpSet, OpCall, OpDetach, OpExec, OpDump}
+
+// ErrNotAuthenticated is returned to clients that make a request before
+// authenticating, when the server has an authentication policy.
+var ErrNotAuthenticated = errors.New("authentication required")
+
+// AuthPolicy describes the tokens that clients of a headless server must
+// present when they connect and what each of them is allowed to do.
+type AuthPolicy struct {
+	Tokens []*AuthToken `json:"tokens"`
+}
+
+// AuthToken is a token clients can authenticate with.
+type AuthToken struct {
+	Token string `json:"token"`
+	// Deny lists the operations that clients authenticated with this token
+	// can not perform, see OpSet, OpCall, OpDetach, OpExec and OpDump.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// LoadAuthPolicy reads an authentication policy from the JSON file at
+// path, for example:
+//
+//	{
+//		"tokens": [
+//			{ "token": "s3cr3t" },
+//			{ "token": "0th3r", "deny": ["set", "call", "detach", "exec", "dump"] }
+//		]
+//	}
+func LoadAuthPolicy(path string) (*AuthPolicy, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &AuthPolicy{}
+	if err := json.Unmarshal(buf, policy); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return policy, nil
+}
+
+func (policy *AuthPolicy) validate() error {
+	if len(policy.Tokens) == 0 {
+		return errors.New("no tokens specified")
+	}
+	for i, t := range policy.Tokens {
+		if t.Token == "" {
+			return fmt.Errorf("token %d is empty", i)
+		}
+		for _, op := range t.Deny {
+			if !validAuthOperation(op) {
+				return fmt.Errorf("unknown operation %q, must be one of %v", op, authOperations)
+			}
+		}
+	}
+	return nil
+}
+
+func validAuthOperation(op string) bool {
+	for _, op2 := range authOperations {
+		if op == op2 {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the token of the policy equal to token, or nil.
+func (policy *AuthPolicy) Lookup(token string) *AuthToken {
+	var found *AuthToken
+	for _, t := range policy.Tokens {
+		// Compare every token in constant time so that the time it takes to
+		// answer does not leak how much of a token was guessed.
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 && found == nil {
+			found = t
+		}
+	}
+	return found
+}
+
+// Denies returns true if clients authenticated with t can not perform op.
+func (t *AuthToken) Denies(op string) bool {
+	for _, op2 := range t.Deny {
+		if op == op2 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAuthPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delve-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	load := func(contents string) (*AuthPolicy, error) {
+		path := filepath.Join(dir, "policy.json")
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return LoadAuthPolicy(path)
+	}
+
+	policy, err := load(`{"tokens": [{"token": "admin"}, {"token": "viewer", "deny": ["set", "call", "exec"]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Lookup("nope") != nil || policy.Lookup("") != nil || policy.Lookup("admi") != nil {
+		t.Errorf("unknown token accepted")
+	}
+	admin, viewer := policy.Lookup("admin"), policy.Lookup("viewer")
+	if admin == nil || viewer == nil {
+		t.Fatalf("tokens not found: %v %v", admin, viewer)
+	}
+	for _, op := range authOperations {
+		if admin.Denies(op) {
+			t.Errorf("admin can not %s", op)
+		}
+	}
+	for _, tc := range []struct {
+		op     string
+		denied bool
+	}{{OpSet, true}, {OpCall, true}, {OpExec, true}, {OpDetach, false}, {OpDump, false}} {
+		if viewer.Denies(tc.op) != tc.denied {
+			t.Errorf("viewer: wrong result for %s, expected denied=%v", tc.op, tc.denied)
+		}
+	}
+
+	for _, tc := range []struct {
+		contents, err string
+	}{
+		{`{"tokens": []}`, "no tokens"},
+		{`{"tokens": [{"token": ""}]}`, "empty"},
+		{`{"tokens": [{"token": "a", "deny": ["launch"]}]}`, "unknown operation"},
+		{`{"tokens": `, "could not parse"},
+	} {
+		_, err := load(tc.contents)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.contents, tc.err, err)
+		}
+	}
+}
@@ -42,12 +42,22 @@ type Client interface {
 	Step() (*api.DebuggerState, error)
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep() (*api.DebuggerState, error)
+	// StepInline steps into the inlined call to fnname beginning at the
+	// current location, without resuming the target process. If fnname is
+	// empty the outermost inlined call not entered yet is used.
+	StepInline(fnname string) (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function.
 	StepOut() (*api.DebuggerState, error)
 	// ReverseStepOut continues backward to the calle rof the current function.
 	ReverseStepOut() (*api.DebuggerState, error)
 	// Call resumes process execution while making a function call.
 	Call(goroutineID int, expr string, unsafe bool) (*api.DebuggerState, error)
+	// CallWithTimeout is like Call but the call is cancelled if it does not
+	// return within timeout.
+	CallWithTimeout(goroutineID int, expr string, unsafe bool, timeout time.Duration) (*api.DebuggerState, error)
+	// CancelCall cancels the function call running in goroutineID, or any
+	// function call if goroutineID is zero.
+	CancelCall(goroutineID int) error
 
 	// SingleStep will step a single cpu instruction.
 	StepInstruction() (*api.DebuggerState, error)
@@ -87,12 +97,40 @@ type Client interface {
 	ListPackageVariables(filter string, cfg api.LoadConfig) ([]api.Variable, error)
 	// EvalVariable returns a variable in the context of the current thread.
 	EvalVariable(scope api.EvalScope, symbol string, cfg api.LoadConfig) (*api.Variable, error)
+	// EvalVariableStringer is like EvalVariable but also calls the String or
+	// Error method of the variable, stringerErr is the reason why the call
+	// failed.
+	EvalVariableStringer(scope api.EvalScope, symbol string, cfg api.LoadConfig, opts api.StringerOptions) (v *api.Variable, stringerErr string, err error)
+	// EvalVariables evaluates all exprs in the same scope with a single
+	// call, expressions that can not be evaluated have their error reported
+	// in the Err field of their result.
+	EvalVariables(scope api.EvalScope, exprs []string, cfg api.LoadConfig) ([]api.EvalResult, error)
+	// LoadArrayRange returns elements [start, end) of the slice or array of
+	// type typ at address addr.
+	LoadArrayRange(scope api.EvalScope, addr uint64, typ string, start, end int64, cfg api.LoadConfig) (*api.Variable, error)
+	// FilterMap evaluates expr, which must be a map, and returns the entries
+	// whose key matches keyRegexp, sorted by key if sorted is true.
+	FilterMap(scope api.EvalScope, expr, keyRegexp string, sorted bool, cfg api.LoadConfig) (*api.Variable, error)
+	// SearchVariable evaluates expr and returns the paths of the values
+	// reachable from it that are equal to value.
+	SearchVariable(scope api.EvalScope, expr, value string, cfg api.LoadConfig) ([]string, error)
+	// ExportVariable evaluates expr and serializes its value as JSON (format
+	// "json") or as a Go composite literal (format "go").
+	ExportVariable(scope api.EvalScope, expr, format string, cfg api.LoadConfig) (string, error)
+	// ObjectGraph returns the graph of the objects reachable from the
+	// values of exprs.
+	ObjectGraph(scope api.EvalScope, exprs []string, cfg api.LoadConfig) (*api.ObjectGraph, error)
+	// ViewAs returns a variable of type typ at the address expr evaluates to.
+	ViewAs(scope api.EvalScope, expr, typ string, cfg api.LoadConfig) (*api.Variable, error)
 
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
 
 	// ListSources lists all source files in the process matching filter.
 	ListSources(filter string) ([]string, error)
+	// GetSourceFile returns the contents of a source file, read on the
+	// machine running the debugger.
+	GetSourceFile(path string) (*api.SourceFile, error)
 	// ListFunctions lists all functions in the process matching filter.
 	ListFunctions(filter string) ([]string, error)
 	// ListTypes lists all types in the process matching filter.
@@ -105,16 +143,32 @@ type Client interface {
 	ListThreadRegisters(threadID int, includeFp bool) (api.Registers, error)
 	// ListScopeRegisters lists registers and their values, for the given scope.
 	ListScopeRegisters(scope api.EvalScope, includeFp bool) (api.Registers, error)
+	// ListRegistersWithFormat lists registers and their values, for the
+	// given thread or, if scope is not nil, for the given scope. Vector
+	// registers are formatted using vectorFormat (for example "f32x4").
+	ListRegistersWithFormat(threadID int, scope *api.EvalScope, includeFp bool, vectorFormat string) (api.Registers, error)
 
 	// ListGoroutines lists all goroutines.
 	ListGoroutines(start, count int) ([]*api.Goroutine, int, error)
+	// ListGoroutinesWithFilter lists goroutines matching the filters, grouped
+	// as specified by group, see RPCServer.ListGoroutines.
+	ListGoroutinesWithFilter(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions) ([]*api.Goroutine, []api.GoroutineGroup, int, bool, error)
 
 	// Returns stacktrace
 	Stacktrace(goroutineID int, depth int, opts api.StacktraceOptions, cfg *api.LoadConfig) ([]api.Stackframe, error)
+	// StacktraceRange returns frames start to start+depth of the stacktrace.
+	StacktraceRange(goroutineID int, start, depth int, opts api.StacktraceOptions, cfg *api.LoadConfig) ([]api.Stackframe, error)
+	// StackframeDetails returns a single frame of the stacktrace with its
+	// local variables and function arguments.
+	StackframeDetails(goroutineID int, frame int, opts api.StacktraceOptions, cfg *api.LoadConfig) (*api.Stackframe, error)
 
 	// Returns ancestor stacktraces
 	Ancestors(goroutineID int, numAncestors int, depth int) ([]api.Ancestor, error)
 
+	// GoroutineTree returns the tree of goroutines and of their ancestors,
+	// with the stack of each parent when it created its child.
+	GoroutineTree(depth int) ([]api.GoroutineTreeNode, error)
+
 	// Returns whether we attached to a running process or not
 	AttachedToExistingProcess() bool
 
@@ -141,12 +195,35 @@ type Client interface {
 	Recorded() bool
 	// TraceDirectory returns the path to the trace directory for a recording.
 	TraceDirectory() (string, error)
+	// ListRecorders returns the record and replay engines supported by the
+	// server and their capabilities.
+	ListRecorders() ([]api.Recorder, error)
 	// Checkpoint sets a checkpoint at the current position.
 	Checkpoint(where string) (checkpointID int, err error)
 	// ListCheckpoints gets all checkpoints.
 	ListCheckpoints() ([]api.Checkpoint, error)
 	// ClearCheckpoint removes a checkpoint
 	ClearCheckpoint(id int) error
+	// RunToEvent resets the recording to the start of the specified rr event.
+	RunToEvent(event uint64) error
+
+	// WaitEvents returns the events that happened after the event with ID
+	// after, waiting up to timeout for one to happen. Events are reported
+	// to all clients, regardless of which client resumed the target.
+	WaitEvents(after uint64, timeout time.Duration) ([]api.Event, error)
+
+	// AddEventHook registers a starlark script that the server runs every
+	// time an event happens, even after the client disconnects.
+	AddEventHook(hook api.EventHook) (*api.EventHook, error)
+	// RemoveEventHook removes an event hook.
+	RemoveEventHook(id int) error
+	// ListEventHooks returns the event hooks.
+	ListEventHooks() ([]api.EventHook, error)
+
+	// WriteStdin writes data to the standard input of the target, if eof is
+	// set the standard input is closed afterwards. Only available if the
+	// server is capturing the output of the target.
+	WriteStdin(data string, eof bool) error
 
 	// SetReturnValuesLoadConfig sets the load configuration for return values.
 	SetReturnValuesLoadConfig(*api.LoadConfig)
@@ -154,6 +231,16 @@ type Client interface {
 	// IsMulticlien returns true if the headless instance is multiclient.
 	IsMulticlient() bool
 
+	// GetCapabilities returns the features supported by the target and the
+	// backend.
+	GetCapabilities() (*api.Capabilities, error)
+
+	// SetObserver turns the connection into an observer connection, which
+	// can inspect the target but not change its state.
+	SetObserver() error
+	// IsObserver returns true if the connection is an observer connection.
+	IsObserver() bool
+
 	// ListDynamicLibraries returns a list of loaded dynamic libraries.
 	ListDynamicLibraries() ([]api.Image, error)
 
@@ -162,9 +249,99 @@ type Client interface {
 	// This function will return an error if it reads less than `length` bytes.
 	ExamineMemory(address uint64, length int) ([]byte, bool, error)
 
+	// WriteMemory writes data, at most 1000 bytes, to the memory of the
+	// target at address.
+	WriteMemory(address uint64, data []byte) error
+
 	// StopRecording stops a recording if one is in progress.
 	StopRecording() error
 
+	// SetSignalPolicy changes how the signal sig, specified by name or
+	// number, is handled.
+	SetSignalPolicy(sig string, stop, print, pass bool) (api.SignalPolicy, error)
+	// ListSignalPolicies lists the signals whose handling was changed.
+	ListSignalPolicies() ([]api.SignalPolicy, error)
+
+	// ListTimers lists the timers pending in the target process and an
+	// approximation of the current value of its monotonic clock.
+	ListTimers() ([]api.Timer, int64, error)
+
+	// ChanWaiters returns the goroutines waiting to receive from, or send
+	// to, the channel expr.
+	ChanWaiters(scope api.EvalScope, expr string) (recv, send []*api.Goroutine, err error)
+
+	// MutexInfo returns the state of the sync.Mutex or sync.RWMutex expr.
+	MutexInfo(scope api.EvalScope, expr string) (*api.MutexState, error)
+
+	// ContextChain returns the chain of contexts wrapped by the
+	// context.Context expr.
+	ContextChain(scope api.EvalScope, expr string, cfg api.LoadConfig) ([]api.ContextLayer, error)
+
+	// InterfaceItab returns the itab of the interface expr.
+	InterfaceItab(scope api.EvalScope, expr string) (*api.Itab, error)
+
+	// AddWatch adds a watch expression, watch expressions are evaluated
+	// every time the target stops and returned in api.DebuggerState.
+	AddWatch(expr string, cfg api.LoadConfig) (*api.Watch, error)
+	// RemoveWatch removes the watch expression with the given ID.
+	RemoveWatch(id int) error
+	// ListWatches lists all watch expressions.
+	ListWatches() ([]api.Watch, error)
+
+	// SaveSession returns the breakpoints and watch expressions of the debug
+	// session, along with substitutePathRules.
+	SaveSession(substitutePathRules [][2]string) (*api.Session, error)
+	// LoadSession restores a session returned by SaveSession.
+	LoadSession(session *api.Session) ([]api.DiscardedBreakpoint, error)
+
+	// FindReferrers returns the objects containing pointers to the object
+	// at addr.
+	FindReferrers(addr uint64) (*api.Referrers, error)
+
+	// HeapHistogram returns the number of heap objects of each type and
+	// the memory they use.
+	HeapHistogram() ([]api.HeapHistogramEntry, error)
+
+	// LoadStringChunk reads length bytes of the contents of a string or
+	// []byte starting at addr, used to load strings truncated by the
+	// LoadConfig incrementally.
+	LoadStringChunk(addr uint64, length int) ([]byte, error)
+
+	// Dump writes a core file of the target process to dest, as described
+	// by opts.
+	Dump(dest string, opts api.DumpOptions) error
+
+	// RawCall calls the function at addr on the current thread, or executes
+	// system call number addr if syscall is true, passing args without any
+	// type checking. This can corrupt or crash the target process.
+	RawCall(addr uint64, args []uint64, syscall bool) (*api.RawCallResult, error)
+
+	// PatchFunction changes the code of the function fn so that it returns
+	// vals, if code is empty, or jumps to code.
+	PatchFunction(fn string, vals []string, code []byte) (*api.FunctionPatch, error)
+	// UnpatchFunction reverts the function patch with the given ID.
+	UnpatchFunction(id int) error
+	// ListFunctionPatches returns the list of function patches currently applied.
+	ListFunctionPatches() ([]*api.FunctionPatch, error)
+
+	// GuardMemory changes the protection of the memory range [addr,
+	// addr+size) to prot ("r" or "none"), accesses not allowed by prot stop
+	// the target process.
+	GuardMemory(addr, size uint64, prot string) (*api.MemoryGuard, error)
+	// UnguardMemory restores the protection of the memory guarded by the
+	// guard with the given ID.
+	UnguardMemory(id int) error
+	// ListMemoryGuards returns the list of memory guards.
+	ListMemoryGuards() ([]*api.MemoryGuard, error)
+
+	// EnableBranchTrace starts, or stops if enable is false, recording the
+	// branches executed by the target with Intel Processor Trace.
+	EnableBranchTrace(enable bool) error
+	// BranchHistory returns the last count branches executed by the thread
+	// with the given ID, or by the current thread if threadID is 0, oldest
+	// first.
+	BranchHistory(threadID, count int) ([]api.Branch, error)
+
 	// Disconnect closes the connection to the server without sending a Detach request first.
 	// If cont is true a continue command will be sent instead.
 	Disconnect(cont bool) error
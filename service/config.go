@@ -36,4 +36,21 @@ type Config struct {
 
 	// DisconnectChan will be closed by the server when the client disconnects
 	DisconnectChan chan<- struct{}
+
+	// AuthPolicy, if set, requires clients to authenticate with one of its
+	// tokens before doing anything else and restricts what they can do.
+	AuthPolicy *AuthPolicy
+
+	// MetricsListener, if set, is used to serve /metrics, in the Prometheus
+	// text format, and /healthz.
+	MetricsListener net.Listener
+
+	// RESTListener, if set, is used to serve an HTTP+JSON interface to a
+	// subset of the API, described by the OpenAPI document served at
+	// /openapi.json.
+	RESTListener net.Listener
+
+	// RESTOrigins are the origins of the web pages allowed to send requests
+	// to RESTListener, see OriginAllowed.
+	RESTOrigins []string
 }
@@ -165,6 +165,11 @@ func (c *Client) ExpectStoppedEvent(t *testing.T) *dap.StoppedEvent {
 	return c.expectReadProtocolMessage(t).(*dap.StoppedEvent)
 }
 
+func (c *Client) ExpectModuleEvent(t *testing.T) *dap.ModuleEvent {
+	t.Helper()
+	return c.expectReadProtocolMessage(t).(*dap.ModuleEvent)
+}
+
 func (c *Client) ExpectOutputEvent(t *testing.T) *dap.OutputEvent {
 	t.Helper()
 	return c.expectReadProtocolMessage(t).(*dap.OutputEvent)
@@ -300,6 +305,11 @@ func (c *Client) ExpectModulesResponse(t *testing.T) *dap.ModulesResponse {
 	return c.expectReadProtocolMessage(t).(*dap.ModulesResponse)
 }
 
+func (c *Client) ExpectSourceResponse(t *testing.T) *dap.SourceResponse {
+	t.Helper()
+	return c.expectReadProtocolMessage(t).(*dap.SourceResponse)
+}
+
 // InitializeRequest sends an 'initialize' request.
 func (c *Client) InitializeRequest() {
 	request := &dap.InitializeRequest{Request: *c.newRequest("initialize")}
@@ -391,6 +401,15 @@ func (c *Client) SetExceptionBreakpointsRequest() {
 	c.send(request)
 }
 
+// SetExceptionBreakpointsRequestWithArgs sends a 'setExceptionBreakpoints'
+// request with the given filters and exception options.
+func (c *Client) SetExceptionBreakpointsRequestWithArgs(filters []string, options []dap.ExceptionOptions) {
+	request := &dap.SetExceptionBreakpointsRequest{Request: *c.newRequest("setExceptionBreakpoints")}
+	request.Arguments.Filters = filters
+	request.Arguments.ExceptionOptions = options
+	c.send(request)
+}
+
 // ConfigurationDoneRequest sends a 'configurationDone' request.
 func (c *Client) ConfigurationDoneRequest() {
 	request := &dap.ConfigurationDoneRequest{Request: *c.newRequest("configurationDone")}
@@ -418,6 +437,22 @@ func (c *Client) StepInRequest(thread int) {
 	c.send(request)
 }
 
+// NextInstructionRequest sends a 'next' request with granularity 'instruction'.
+func (c *Client) NextInstructionRequest(thread int) {
+	request := &dap.NextRequest{Request: *c.newRequest("next")}
+	request.Arguments.ThreadId = thread
+	request.Arguments.Granularity = "instruction"
+	c.send(request)
+}
+
+// StepInInstructionRequest sends a 'stepIn' request with granularity 'instruction'.
+func (c *Client) StepInInstructionRequest(thread int) {
+	request := &dap.StepInRequest{Request: *c.newRequest("stepIn")}
+	request.Arguments.ThreadId = thread
+	request.Arguments.Granularity = "instruction"
+	c.send(request)
+}
+
 // StepOutRequest sends a 'stepOut' request.
 func (c *Client) StepOutRequest(thread int) {
 	request := &dap.NextRequest{Request: *c.newRequest("stepOut")}
@@ -506,9 +541,11 @@ func (c *Client) SetExpressionRequest() {
 	c.send(&dap.SetExpressionRequest{Request: *c.newRequest("setExpression")})
 }
 
-// SourceRequest sends a 'source' request.
-func (c *Client) SourceRequest() {
-	c.send(&dap.SourceRequest{Request: *c.newRequest("source")})
+// SourceRequest sends a 'source' request for the file at path.
+func (c *Client) SourceRequest(path string) {
+	request := &dap.SourceRequest{Request: *c.newRequest("source")}
+	request.Arguments.Source.Path = path
+	c.send(request)
 }
 
 // TerminateThreadsRequest sends a 'terminateThreads' request.
@@ -566,8 +603,13 @@ func (c *Client) ReadMemoryRequest() {
 }
 
 // DisassembleRequest sends a 'disassemble' request.
-func (c *Client) DisassembleRequest() {
-	c.send(&dap.DisassembleRequest{Request: *c.newRequest("disassemble")})
+func (c *Client) DisassembleRequest(memoryReference string, instructionOffset, instructionCount int) {
+	request := &dap.DisassembleRequest{Request: *c.newRequest("disassemble")}
+	request.Arguments.MemoryReference = memoryReference
+	request.Arguments.InstructionOffset = instructionOffset
+	request.Arguments.InstructionCount = instructionCount
+	request.Arguments.ResolveSymbols = true
+	c.send(request)
 }
 
 // CancelRequest sends a 'cancel' request.
@@ -12,6 +12,7 @@ const (
 	// values below are inspired the original vscode-go debug adaptor.
 	FailedToLaunch             = 3000
 	FailedToAttach             = 3001
+	FailedToRestart            = 3002
 	UnableToSetBreakpoints     = 2002
 	UnableToDisplayThreads     = 2003
 	UnableToProduceStackTrace  = 2004
@@ -20,5 +21,8 @@ const (
 	UnableToListGlobals        = 2007
 	UnableToLookupVariable     = 2008
 	UnableToEvaluateExpression = 2009
+	UnableToDisassemble        = 2010
+	UnableToListModules        = 2011
+	UnableToDisplaySource      = 2012
 	// Add more codes as we support more requests
 )
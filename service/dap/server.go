@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-delve/delve/pkg/gobuild"
@@ -67,6 +68,17 @@ type Server struct {
 	variableHandles *variablesHandlesMap
 	// args tracks special settings for handling debug session requests.
 	args launchAttachArgs
+	// exceptionFilters are the exception breakpoint filters enabled by the
+	// client, see exceptionBreakpointFilters.
+	exceptionFilters map[string]bool
+	// exceptionConditions maps exception breakpoint filters to the names
+	// that the panic value must match for the filter to stop the program.
+	// Set from the exception options of 'setExceptionBreakpoints' requests.
+	exceptionConditions map[string]*dap.ExceptionPathSegment
+	// reportedModules contains the paths of the images already reported to
+	// the client, it is nil until the client sends its first 'modules'
+	// request. See sendModuleEvents.
+	reportedModules map[string]bool
 }
 
 // launchAttachArgs captures arguments from launch/attach request that
@@ -103,6 +115,7 @@ func NewServer(config *service.Config) *Server {
 		stackFrameHandles: newHandlesMap(),
 		variableHandles:   newVariablesHandlesMap(),
 		args:              defaultArgs,
+		exceptionFilters:  defaultExceptionFilters(),
 	}
 }
 
@@ -326,9 +339,7 @@ func (s *Server) handleRequest(request dap.Message) {
 		s.onSetExpressionRequest(request)
 	case *dap.SourceRequest:
 		// Required
-		// This does not make sense in the context of Go as
-		// the source cannot be a string eval'ed at runtime.
-		s.sendUnsupportedErrorResponse(request.Request)
+		s.onSourceRequest(request)
 	case *dap.ThreadsRequest:
 		// Required
 		s.onThreadsRequest(request)
@@ -378,8 +389,7 @@ func (s *Server) handleRequest(request dap.Message) {
 		s.sendUnsupportedErrorResponse(request.Request)
 	case *dap.ModulesRequest:
 		// Optional (capability ‘supportsModulesRequest’)
-		// TODO: does this request make sense for delve?
-		s.sendUnsupportedErrorResponse(request.Request)
+		s.onModulesRequest(request)
 	default:
 		// This is a DAP message that go-dap has a struct for, so
 		// decoding succeeded, but this function does not know how
@@ -400,18 +410,22 @@ func (s *Server) onInitializeRequest(request *dap.InitializeRequest) {
 	response.Body.SupportsConfigurationDoneRequest = true
 	response.Body.SupportsConditionalBreakpoints = true
 	response.Body.SupportsDelayedStackTraceLoading = true
+	response.Body.SupportsSteppingGranularity = true
+	response.Body.ExceptionBreakpointFilters = exceptionBreakpointFilters
+	response.Body.SupportsExceptionOptions = true
+	response.Body.SupportsModulesRequest = true
 	response.Body.SupportTerminateDebuggee = true
 	// TODO(polina): support this to match vscode-go functionality
 	response.Body.SupportsSetVariable = false
 	// TODO(polina): support these requests in addition to vscode-go feature parity
 	response.Body.SupportsTerminateRequest = false
-	response.Body.SupportsRestartRequest = false
+	response.Body.SupportsRestartRequest = true
 	response.Body.SupportsFunctionBreakpoints = false
 	response.Body.SupportsStepBack = false
 	response.Body.SupportsSetExpression = false
 	response.Body.SupportsLoadedSourcesRequest = false
 	response.Body.SupportsReadMemoryRequest = false
-	response.Body.SupportsDisassembleRequest = false
+	response.Body.SupportsDisassembleRequest = true
 	response.Body.SupportsCancelRequest = false
 	s.send(response)
 }
@@ -461,8 +475,10 @@ func (s *Server) onLaunchRequest(request *dap.LaunchRequest) {
 		switch mode {
 		case "debug":
 			err = gobuild.GoBuild(debugname, []string{program}, buildFlags)
+			s.config.Debugger.ExecuteKind = debugger.ExecutingGeneratedFile
 		case "test":
 			err = gobuild.GoTestBuild(debugname, []string{program}, buildFlags)
+			s.config.Debugger.ExecuteKind = debugger.ExecutingGeneratedTest
 		}
 		if err != nil {
 			s.sendErrorResponse(request.Request,
@@ -470,6 +486,9 @@ func (s *Server) onLaunchRequest(request *dap.LaunchRequest) {
 				fmt.Sprintf("Build error: %s", err.Error()))
 			return
 		}
+		// Remembered by the debugger to rebuild the program on restart.
+		s.config.Debugger.Packages = []string{program}
+		s.config.Debugger.BuildFlags = buildFlags
 		program = debugname
 		s.binaryToRemove = debugname
 	}
@@ -573,7 +592,7 @@ func (s *Server) onSetBreakpointsRequest(request *dap.SetBreakpointsRequest) {
 	existing := s.debugger.Breakpoints()
 	for _, bp := range existing {
 		// Skip special breakpoints such as for panic.
-		if bp.ID < 0 {
+		if bp.ID < 0 || bp.Name == panicBreakpointName {
 			continue
 		}
 		// Skip other source files.
@@ -605,12 +624,174 @@ func (s *Server) onSetBreakpointsRequest(request *dap.SetBreakpointsRequest) {
 	s.send(response)
 }
 
+// Exception breakpoint filters supported by the server.
+const (
+	panicFilter       = "panic"
+	unrecoveredFilter = "unrecovered"
+	fatalFilter       = "fatal"
+	runtimeFilter     = "runtime"
+)
+
+// exceptionBreakpointFilters is the list of exception breakpoint filters
+// advertised to the client in the 'initialize' response.
+var exceptionBreakpointFilters = []dap.ExceptionBreakpointsFilter{
+	{Filter: panicFilter, Label: "All panics"},
+	{Filter: unrecoveredFilter, Label: "Unrecovered panics", Default: true},
+	{Filter: fatalFilter, Label: "Fatal errors", Default: true},
+	{Filter: runtimeFilter, Label: "Runtime errors"},
+}
+
+func defaultExceptionFilters() map[string]bool {
+	filters := make(map[string]bool)
+	for _, f := range exceptionBreakpointFilters {
+		filters[f.Filter] = f.Default
+	}
+	return filters
+}
+
+// panicBreakpointName is the name of the breakpoint on runtime.gopanic used
+// to stop on every panic, it is only set while the 'panic' or 'runtime'
+// filters are enabled.
+const panicBreakpointName = "dapPanic"
+
+// onSetExceptionBreakpointsRequest handles 'setExceptionBreakpoints' requests.
+// The 'unrecovered' and 'fatal' filters correspond to the unrecovered-panic
+// and fatal-throw breakpoints that proc sets on every target, stops on them
+// are skipped when the filters are disabled. The 'panic' and 'runtime'
+// filters need a breakpoint on runtime.gopanic.
+//
+// Exception options can restrict the panics that stop the program: the
+// first segment of their path selects the filters they apply to and the
+// second one lists names matched against the dynamic type of the panic
+// value or, as substrings, against its value. A break mode of 'never'
+// disables the selected filters.
 func (s *Server) onSetExceptionBreakpointsRequest(request *dap.SetExceptionBreakpointsRequest) {
-	// Unlike what DAP documentation claims, this request is always sent
-	// even though we specified no filters at initialization. Handle as no-op.
+	if s.debugger == nil {
+		s.sendErrorResponse(request.Request, UnableToSetBreakpoints, "Unable to set exception breakpoints", "debugger is nil")
+		return
+	}
+
+	filters := make(map[string]bool)
+	for _, f := range request.Arguments.Filters {
+		filters[f] = true
+	}
+	conditions := make(map[string]*dap.ExceptionPathSegment)
+	for i := range request.Arguments.ExceptionOptions {
+		opt := &request.Arguments.ExceptionOptions[i]
+		for _, f := range exceptionBreakpointFilters {
+			if len(opt.Path) > 0 && !pathSegmentMatches(&opt.Path[0], f.Filter) {
+				continue
+			}
+			if opt.BreakMode == "never" {
+				filters[f.Filter] = false
+				continue
+			}
+			filters[f.Filter] = true
+			if len(opt.Path) > 1 {
+				conditions[f.Filter] = &opt.Path[1]
+			}
+		}
+	}
+
+	panicBp := s.debugger.FindBreakpointByName(panicBreakpointName)
+	switch {
+	case (filters[panicFilter] || filters[runtimeFilter]) && panicBp == nil:
+		_, err := s.debugger.CreateBreakpoint(&api.Breakpoint{Name: panicBreakpointName, FunctionName: "runtime.gopanic"})
+		if err != nil {
+			s.sendErrorResponse(request.Request, UnableToSetBreakpoints, "Unable to set exception breakpoints", err.Error())
+			return
+		}
+	case !filters[panicFilter] && !filters[runtimeFilter] && panicBp != nil:
+		if _, err := s.debugger.ClearBreakpoint(panicBp); err != nil {
+			s.sendErrorResponse(request.Request, UnableToSetBreakpoints, "Unable to set exception breakpoints", err.Error())
+			return
+		}
+	}
+
+	s.exceptionFilters = filters
+	s.exceptionConditions = conditions
 	s.send(&dap.SetExceptionBreakpointsResponse{Response: *newResponse(request.Request)})
 }
 
+// pathSegmentMatches returns true if name is selected by seg.
+func pathSegmentMatches(seg *dap.ExceptionPathSegment, name string) bool {
+	for _, n := range seg.Names {
+		if n == name {
+			return !seg.Negate
+		}
+	}
+	return seg.Negate
+}
+
+// panicValue evaluates expr, the value of a panic, on the current goroutine
+// and returns the dynamic type and a description of the value. Returns
+// false if the value could not be read.
+func (s *Server) panicValue(expr string) (typ, val string, ok bool) {
+	cfg := proc.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	v, err := s.debugger.EvalVariableInScope(-1, 0, 0, expr, cfg)
+	if err != nil || v.Unreadable != nil {
+		return "", "", false
+	}
+	if v.Kind == reflect.Interface {
+		if len(v.Children) == 0 || v.Children[0].Kind == reflect.Invalid {
+			return "", "nil", true
+		}
+		v = &v.Children[0]
+	}
+	return v.TypeString(), s.debugger.PrettyPrinters().ConvertVar(v).SinglelineString(), true
+}
+
+// exceptionStop checks whether the current thread is stopped at one of the
+// exception breakpoints and, if so, whether the program should stop
+// according to the enabled filters. Also returns the description of the
+// panic value, if any.
+func (s *Server) exceptionStop(state *api.DebuggerState) (stop bool, text string) {
+	if state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil {
+		return true, ""
+	}
+	var filters []string
+	var expr string
+	switch state.CurrentThread.Breakpoint.Name {
+	case proc.FatalThrow:
+		return s.exceptionFilters[fatalFilter], ""
+	case proc.UnrecoveredPanic:
+		filters, expr = []string{unrecoveredFilter}, "runtime.curg._panic.arg"
+	case panicBreakpointName:
+		filters, expr = []string{panicFilter, runtimeFilter}, "e"
+	default:
+		return true, ""
+	}
+	typ, val, ok := s.panicValue(expr)
+	for _, f := range filters {
+		if !s.exceptionFilters[f] {
+			continue
+		}
+		if !ok {
+			// Err on the side of stopping if the value can not be read.
+			return true, ""
+		}
+		// Values that implement runtime.Error are all defined by the
+		// runtime package.
+		if f == runtimeFilter && !strings.HasPrefix(typ, "runtime.") && !strings.HasPrefix(typ, "*runtime.") {
+			continue
+		}
+		if cond := s.exceptionConditions[f]; cond != nil {
+			match := false
+			for _, name := range cond.Names {
+				if name == typ || strings.Contains(val, name) {
+					match = true
+					break
+				}
+			}
+			if match == cond.Negate {
+				continue
+			}
+		}
+		return true, val
+	}
+	return false, ""
+}
+
 func (s *Server) onConfigurationDoneRequest(request *dap.ConfigurationDoneRequest) {
 	if s.args.stopOnEntry {
 		e := &dap.StoppedEvent{
@@ -740,7 +921,7 @@ func (s *Server) onNextRequest(request *dap.NextRequest) {
 	// This ignores threadId argument to match the original vscode-go implementation.
 	// TODO(polina): use SwitchGoroutine to change the current goroutine.
 	s.send(&dap.NextResponse{Response: *newResponse(request.Request)})
-	s.doCommand(api.Next)
+	s.doCommand(stepCommand(api.Next, request.Arguments.Granularity))
 }
 
 // onStepInRequest handles 'stepIn' request
@@ -749,18 +930,32 @@ func (s *Server) onStepInRequest(request *dap.StepInRequest) {
 	// This ignores threadId argument to match the original vscode-go implementation.
 	// TODO(polina): use SwitchGoroutine to change the current goroutine.
 	s.send(&dap.StepInResponse{Response: *newResponse(request.Request)})
-	s.doCommand(api.Step)
+	s.doCommand(stepCommand(api.Step, request.Arguments.Granularity))
 }
 
 // onStepOutRequest handles 'stepOut' request
 // This is a mandatory request to support.
 func (s *Server) onStepOutRequest(request *dap.StepOutRequest) {
 	// This ignores threadId argument to match the original vscode-go implementation.
+	// Granularity is also ignored: stepping out of the current function at
+	// instruction granularity stops at the same place as stepping out at
+	// statement granularity, the return address.
 	// TODO(polina): use SwitchGoroutine to change the current goroutine.
 	s.send(&dap.StepOutResponse{Response: *newResponse(request.Request)})
 	s.doCommand(api.StepOut)
 }
 
+// stepCommand returns the debugger command that implements a next or
+// stepIn request with the given stepping granularity. Stepping at
+// 'instruction' granularity executes a single instruction, stepping over
+// calls is not supported at this granularity.
+func stepCommand(command string, granularity dap.SteppingGranularity) string {
+	if granularity == "instruction" {
+		return api.StepInstruction
+	}
+	return command
+}
+
 // onPauseRequest sends a not-yet-implemented error response.
 // This is a mandatory request to support.
 func (s *Server) onPauseRequest(request *dap.PauseRequest) { // TODO V0
@@ -788,7 +983,7 @@ func (s *Server) onStackTraceRequest(request *dap.StackTraceRequest) {
 	for i, frame := range frames {
 		loc := &frame.Call
 		uniqueStackFrameID := s.stackFrameHandles.create(stackFrame{goroutineID, i})
-		stackFrames[i] = dap.StackFrame{Id: uniqueStackFrameID, Line: loc.Line, Name: fnName(loc)}
+		stackFrames[i] = dap.StackFrame{Id: uniqueStackFrameID, Line: loc.Line, Name: fnName(loc), InstructionPointerReference: fmt.Sprintf("%#x", loc.PC)}
 		if loc.File != "<autogenerated>" {
 			stackFrames[i].Source = dap.Source{Name: filepath.Base(loc.File), Path: loc.File}
 		}
@@ -909,7 +1104,11 @@ func (s *Server) onVariablesRequest(request *dap.VariablesRequest) {
 	// TODO(polina): check and handle if variable loaded incompletely
 	// https://github.com/go-delve/delve/blob/master/Documentation/api/ClientHowto.md#looking-into-variables
 
-	switch v.Kind {
+	kind := v.Kind
+	if v.DecodedKind != reflect.Invalid {
+		kind = v.DecodedKind
+	}
+	switch kind {
 	case reflect.Map:
 		for i := 0; i < len(v.Children); i += 2 {
 			// A map will have twice as many children as there are key-value elements.
@@ -1043,7 +1242,11 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 		return
 	}
 	typeName := api.PrettyTypeName(v.DwarfType)
-	switch v.Kind {
+	kind := v.Kind
+	if v.DecodedKind != reflect.Invalid {
+		kind = v.DecodedKind
+	}
+	switch kind {
 	case reflect.UnsafePointer:
 		if len(v.Children) == 0 {
 			value = "unsafe.Pointer(nil)"
@@ -1063,6 +1266,9 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 		}
 	case reflect.Array:
 		value = "<" + typeName + ">"
+		if v.WellKnownValue != "" {
+			value = fmt.Sprintf("%s(%s)", typeName, v.WellKnownValue)
+		}
 		if len(v.Children) > 0 {
 			variablesReference = maybeCreateVariableHandle(v)
 		}
@@ -1141,9 +1347,11 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 		}
 		fallthrough
 	default: // Struct, complex, scalar
-		vvalue := api.VariableValueAsString(v)
+		vvalue := s.debugger.PrettyPrinters().VariableValueAsString(v)
 		if vvalue != "" {
 			value = vvalue
+		} else if v.WellKnownValue != "" {
+			value = fmt.Sprintf("%s(%s)", typeName, v.WellKnownValue)
 		} else {
 			value = "<" + typeName + ">"
 		}
@@ -1281,10 +1489,42 @@ func (s *Server) onTerminateRequest(request *dap.TerminateRequest) {
 	s.sendNotYetImplementedErrorResponse(request.Request)
 }
 
-// onRestartRequest sends a not-yet-implemented error response
-// Capability 'supportsRestartRequest' is not set in 'initialize' response.
+// onRestartRequest handles 'restart' requests.
+// Capability 'supportsRestartRequest' is set in 'initialize' response.
+// Programs launched in 'debug' or 'test' mode are rebuilt before being
+// restarted. The debugger restores all breakpoints in the new process,
+// the ones that can no longer be set are reported to the client as output.
+// Restarting attached processes is not supported.
 func (s *Server) onRestartRequest(request *dap.RestartRequest) {
-	s.sendNotYetImplementedErrorResponse(request.Request)
+	if s.debugger == nil {
+		s.sendErrorResponse(request.Request, FailedToRestart, "Failed to restart", "debugger is nil")
+		return
+	}
+	kind := s.config.Debugger.ExecuteKind
+	rebuild := kind == debugger.ExecutingGeneratedFile || kind == debugger.ExecutingGeneratedTest
+	discarded, err := s.debugger.Restart(false, "", false, nil, [3]string{}, rebuild)
+	if err != nil {
+		s.sendErrorResponse(request.Request, FailedToRestart, "Failed to restart", err.Error())
+		return
+	}
+	s.resetHandlesForStop()
+	for _, dbp := range discarded {
+		s.send(&dap.OutputEvent{
+			Event: *newEvent("output"),
+			Body: dap.OutputEventBody{
+				Output:   fmt.Sprintf("Discarded breakpoint at %s:%d: %s\n", dbp.Breakpoint.File, dbp.Breakpoint.Line, dbp.Reason),
+				Category: "console",
+			}})
+	}
+	s.send(&dap.RestartResponse{Response: *newResponse(request.Request)})
+	if s.args.stopOnEntry {
+		s.send(&dap.StoppedEvent{
+			Event: *newEvent("stopped"),
+			Body:  dap.StoppedEventBody{Reason: "entry", ThreadId: 1, AllThreadsStopped: true},
+		})
+		return
+	}
+	s.doCommand(api.Continue)
 }
 
 // onSetFunctionBreakpointsRequest sends a not-yet-implemented error response.
@@ -1329,10 +1569,183 @@ func (s *Server) onReadMemoryRequest(request *dap.ReadMemoryRequest) {
 	s.sendNotYetImplementedErrorResponse(request.Request)
 }
 
-// onDisassembleRequest sends a not-yet-implemented error response.
-// Capability 'supportsDisassembleRequest' is not set 'initialize' response.
+// onDisassembleRequest handles 'disassemble' requests.
+// Capability 'supportsDisassembleRequest' is set in 'initialize' response.
+// The memory reference is the instructionPointerReference of a stack frame,
+// instructions are disassembled one function at a time, starting from the
+// function containing the referenced address and moving to its neighbours
+// until enough instructions are collected. Addresses that do not belong to
+// any function are reported as invalid instructions, as required by the
+// specification which expects exactly instructionCount instructions.
 func (s *Server) onDisassembleRequest(request *dap.DisassembleRequest) {
-	s.sendNotYetImplementedErrorResponse(request.Request)
+	if s.debugger == nil {
+		s.sendErrorResponse(request.Request, UnableToDisassemble, "Unable to disassemble", "debugger is nil")
+		return
+	}
+	addr, err := strconv.ParseUint(request.Arguments.MemoryReference, 0, 64)
+	if err != nil {
+		s.sendErrorResponse(request.Request, UnableToDisassemble, "Unable to disassemble", fmt.Sprintf("invalid memory reference %q", request.Arguments.MemoryReference))
+		return
+	}
+	addr = uint64(int64(addr) + int64(request.Arguments.Offset))
+
+	insts, err := s.debugger.Disassemble(-1, addr, 0)
+	if err != nil {
+		s.sendErrorResponse(request.Request, UnableToDisassemble, "Unable to disassemble", err.Error())
+		return
+	}
+	idx := 0
+	for idx < len(insts)-1 && insts[idx].Loc.PC+uint64(insts[idx].Size) <= addr {
+		idx++
+	}
+
+	// Extend insts backwards and forwards with the neighbouring functions
+	// until the requested range is covered or no more functions are found.
+	start := idx + request.Arguments.InstructionOffset
+	for start < 0 {
+		prev, err := s.debugger.Disassemble(-1, insts[0].Loc.PC-1, 0)
+		if err != nil || len(prev) == 0 {
+			break
+		}
+		insts = append(prev, insts...)
+		start += len(prev)
+	}
+	end := start + request.Arguments.InstructionCount
+	for end > len(insts) {
+		last := insts[len(insts)-1]
+		next, err := s.debugger.Disassemble(-1, last.Loc.PC+uint64(last.Size), 0)
+		if err != nil || len(next) == 0 {
+			break
+		}
+		insts = append(insts, next...)
+	}
+
+	response := &dap.DisassembleResponse{Response: *newResponse(request.Request)}
+	response.Body.Instructions = make([]dap.DisassembledInstruction, request.Arguments.InstructionCount)
+	for i := range response.Body.Instructions {
+		j := start + i
+		if j < 0 || j >= len(insts) {
+			// Invalid instructions are given one byte each, their addresses
+			// only need to be distinct from the ones of valid instructions.
+			var pc uint64
+			if j < 0 {
+				pc = insts[0].Loc.PC - uint64(-j)
+			} else {
+				last := insts[len(insts)-1]
+				pc = last.Loc.PC + uint64(last.Size) + uint64(j-len(insts))
+			}
+			response.Body.Instructions[i] = dap.DisassembledInstruction{Address: fmt.Sprintf("%#x", pc), Instruction: "(bad)"}
+			continue
+		}
+		inst := &insts[j]
+		dinst := &response.Body.Instructions[i]
+		dinst.Address = fmt.Sprintf("%#x", inst.Loc.PC)
+		dinst.InstructionBytes = fmt.Sprintf("%x", inst.Bytes)
+		dinst.Instruction = s.debugger.AsmInstructionText(inst, proc.GoFlavour)
+		if inst.Loc.File != "" && inst.Loc.File != "<autogenerated>" {
+			dinst.Location = dap.Source{Name: filepath.Base(inst.Loc.File), Path: inst.Loc.File}
+			dinst.Line = inst.Loc.Line
+		}
+		if request.Arguments.ResolveSymbols && inst.Loc.Fn != nil && inst.Loc.Fn.Entry == inst.Loc.PC {
+			dinst.Symbol = inst.Loc.Fn.Name
+		}
+	}
+	s.send(response)
+}
+
+// onModulesRequest handles 'modules' requests.
+// Capability 'supportsModulesRequest' is set in 'initialize' response.
+// The executable file is the first module, followed by the shared objects
+// and plugins loaded by the target. After this request the client also
+// receives a module event for every image loaded later.
+func (s *Server) onModulesRequest(request *dap.ModulesRequest) {
+	if s.debugger == nil {
+		s.sendErrorResponse(request.Request, UnableToListModules, "Unable to list modules", "debugger is nil")
+		return
+	}
+	images := s.debugger.ListImages()
+	if s.reportedModules == nil {
+		s.reportedModules = make(map[string]bool)
+	}
+	modules := make([]dap.Module, len(images))
+	for i, image := range images {
+		modules[i] = convertImage(i, image)
+		s.reportedModules[image.Path] = true
+	}
+	if request.Arguments.StartModule > 0 {
+		modules = modules[min(request.Arguments.StartModule, len(modules)):]
+	}
+	if request.Arguments.ModuleCount > 0 {
+		modules = modules[:min(request.Arguments.ModuleCount, len(modules))]
+	}
+	response := &dap.ModulesResponse{
+		Response: *newResponse(request.Request),
+		Body:     dap.ModulesResponseBody{Modules: modules, TotalModules: len(images)},
+	}
+	s.send(response)
+}
+
+// onSourceRequest handles 'source' requests.
+// Go sources can not be generated at runtime, so sourceReference is never
+// used, but clients that do not share a filesystem with the debugger can
+// use this request to retrieve the contents of source.path.
+func (s *Server) onSourceRequest(request *dap.SourceRequest) {
+	if s.debugger == nil {
+		s.sendErrorResponse(request.Request, UnableToDisplaySource, "Unable to display source", "debugger is nil")
+		return
+	}
+	path := request.Arguments.Source.Path
+	if path == "" {
+		s.sendErrorResponse(request.Request, UnableToDisplaySource, "Unable to display source", "source.path not specified")
+		return
+	}
+	f, err := s.debugger.SourceFile(path)
+	if err != nil {
+		s.sendErrorResponse(request.Request, UnableToDisplaySource, "Unable to display source", err.Error())
+		return
+	}
+	response := &dap.SourceResponse{
+		Response: *newResponse(request.Request),
+		Body:     dap.SourceResponseBody{Content: string(f.Content), MimeType: "text/x-go"},
+	}
+	s.send(response)
+}
+
+// convertImage converts the i-th image of the target to a DAP module.
+// The build ID of the image is reported as its version.
+func convertImage(i int, image *proc.Image) dap.Module {
+	module := dap.Module{
+		Id:         i,
+		Name:       filepath.Base(image.Path),
+		Path:       image.Path,
+		IsUserCode: i == 0,
+		Version:    image.BuildID,
+	}
+	if err := image.LoadError(); err != nil {
+		module.SymbolStatus = fmt.Sprintf("Symbols not loaded: %v", err)
+	} else {
+		module.SymbolStatus = "Symbols loaded"
+	}
+	return module
+}
+
+// sendModuleEvents sends a 'new' module event for each image of the target
+// that has not been reported to the client yet. Clients that never sent a
+// 'modules' request are not interested in modules and get no events.
+func (s *Server) sendModuleEvents() {
+	if s.reportedModules == nil {
+		return
+	}
+	for i, image := range s.debugger.ListImages() {
+		if s.reportedModules[image.Path] {
+			continue
+		}
+		s.reportedModules[image.Path] = true
+		s.send(&dap.ModuleEvent{
+			Event: *newEvent("module"),
+			Body:  dap.ModuleEventBody{Reason: "new", Module: convertImage(i, image)},
+		})
+	}
 }
 
 // onCancelRequest sends a not-yet-implemented error response.
@@ -1426,6 +1839,17 @@ func (s *Server) doCommand(command string) {
 	}
 
 	state, err := s.debugger.Command(&api.DebuggerCommand{Name: command})
+	panicText := ""
+	for err == nil && !state.Exited {
+		// Stops at exception breakpoints that the client is not interested
+		// in are skipped.
+		var stop bool
+		stop, panicText = s.exceptionStop(state)
+		if stop {
+			break
+		}
+		state, err = s.debugger.Command(&api.DebuggerCommand{Name: api.Continue})
+	}
 	if _, isexited := err.(proc.ErrProcessExited); isexited || err == nil && state.Exited {
 		e := &dap.TerminatedEvent{Event: *newEvent("terminated")}
 		s.send(e)
@@ -1433,6 +1857,7 @@ func (s *Server) doCommand(command string) {
 	}
 
 	s.resetHandlesForStop()
+	s.sendModuleEvents()
 	stopped := &dap.StoppedEvent{Event: *newEvent("stopped")}
 	stopped.Body.AllThreadsStopped = true
 
@@ -1447,12 +1872,17 @@ func (s *Server) doCommand(command string) {
 		default:
 			stopped.Body.Reason = "breakpoint"
 		}
+		// Stepping a single instruction does not change the stop reason.
+		if command == api.StepInstruction {
+			stopped.Body.Reason = "step"
+		}
 		if state.CurrentThread.Breakpoint != nil {
 			switch state.CurrentThread.Breakpoint.Name {
 			case proc.FatalThrow:
 				stopped.Body.Reason = "fatal error"
-			case proc.UnrecoveredPanic:
+			case proc.UnrecoveredPanic, panicBreakpointName:
 				stopped.Body.Reason = "panic"
+				stopped.Body.Text = panicText
 			}
 		}
 		s.send(stopped)
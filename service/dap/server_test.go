@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"flag"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
@@ -1090,11 +1091,21 @@ func TestScopesAndVariablesRequests2(t *testing.T) {
 					if ref > 0 {
 						client.VariablesRequest(ref)
 						ch1 := client.ExpectVariablesResponse(t)
-						expectChildren(t, ch1, "ch1", 11)
-						expectVarExact(t, ch1, 0, "qcount", "ch1.qcount", "4", noChildren)
-						expectVarExact(t, ch1, 10, "lock", "ch1.lock", "<runtime.mutex>", hasChildren)
-						validateEvaluateName(t, client, ch1, 0)
-						validateEvaluateName(t, client, ch1, 10)
+						expectChildren(t, ch1, "ch1", 4)
+						expectVarExact(t, ch1, 0, "buffer", "ch1.buffer", "<[4]int>", hasChildren)
+						expectVarExact(t, ch1, 1, "closed", "ch1.closed", "false", noChildren)
+						expectVarExact(t, ch1, 2, "recvq", "ch1.recvq", "<[0]int64>", noChildren)
+						expectVarExact(t, ch1, 3, "sendq", "ch1.sendq", "<[0]int64>", noChildren)
+						for i := 0; i < 4; i++ {
+							validateEvaluateName(t, client, ch1, i)
+						}
+						client.VariablesRequest(ch1.Body.Variables[0].VariablesReference)
+						buffer := client.ExpectVariablesResponse(t)
+						expectChildren(t, buffer, "buffer", 4)
+						expectVarExact(t, buffer, 0, "[0]", "ch1.buffer[0]", "1", noChildren)
+						expectVarExact(t, buffer, 3, "[3]", "ch1.buffer[3]", "2", noChildren)
+						validateEvaluateName(t, client, buffer, 0)
+						validateEvaluateName(t, client, buffer, 3)
 					}
 					expectVarExact(t, locals, -1, "chnil", "chnil", "nil <chan int>", noChildren)
 					// reflect.Kind == Func
@@ -1793,6 +1804,157 @@ func TestNextAndStep(t *testing.T) {
 	})
 }
 
+func TestNextAndStepInstruction(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{ // Stop at line 8
+				execute: func() {
+					pc := func() string {
+						t.Helper()
+						client.StackTraceRequest(1, 0, 1)
+						st := client.ExpectStackTraceResponse(t)
+						return st.Body.StackFrames[0].InstructionPointerReference
+					}
+					expectStep := func(prevPC string) string {
+						t.Helper()
+						se := client.ExpectStoppedEvent(t)
+						if se.Body.Reason != "step" || se.Body.ThreadId != 1 {
+							t.Errorf("got %#v, want Reason=\"step\", ThreadId=1", se)
+						}
+						cur := pc()
+						if cur == prevPC {
+							t.Errorf("pc did not change after stepping one instruction: %s", cur)
+						}
+						return cur
+					}
+
+					cur := pc()
+
+					client.NextInstructionRequest(1)
+					client.ExpectNextResponse(t)
+					cur = expectStep(cur)
+
+					client.StepInInstructionRequest(1)
+					client.ExpectStepInResponse(t)
+					expectStep(cur)
+				},
+				disconnect: true,
+			}})
+	})
+}
+
+func TestModulesRequest(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{ // Stop at line 8
+				execute: func() {
+					client.ModulesRequest()
+					got := client.ExpectModulesResponse(t)
+					if len(got.Body.Modules) < 1 || got.Body.TotalModules != len(got.Body.Modules) {
+						t.Fatalf("got %#v, want at least one module and TotalModules=len(Modules)", got)
+					}
+					exe := got.Body.Modules[0]
+					if exe.Path != fixture.Path || exe.Name != filepath.Base(fixture.Path) || !exe.IsUserCode || exe.SymbolStatus != "Symbols loaded" {
+						t.Errorf("got %#v, want Path=%q IsUserCode=true SymbolStatus=\"Symbols loaded\"", exe, fixture.Path)
+					}
+					if runtime.GOOS == "linux" && exe.Version == "" {
+						t.Errorf("got %#v, want build ID as the version", exe)
+					}
+				},
+				disconnect: true,
+			}})
+	})
+}
+
+func TestSourceRequest(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{ // Stop at line 8
+				execute: func() {
+					client.SourceRequest(filepath.ToSlash(fixture.Source))
+					got := client.ExpectSourceResponse(t)
+					want, err := ioutil.ReadFile(fixture.Source)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got.Body.Content != string(want) {
+						t.Errorf("got %q, want contents of %s", got.Body.Content, fixture.Source)
+					}
+
+					// Only the sources of the target can be read.
+					client.SourceRequest(filepath.Join(filepath.Dir(fixture.Source), "notasource.go"))
+					er := client.ExpectErrorResponse(t)
+					if er.Body.Error.Id != UnableToDisplaySource {
+						t.Errorf("got %#v, want Id=%d", er, UnableToDisplaySource)
+					}
+				},
+				disconnect: true,
+			}})
+	})
+}
+
+func TestDisassembleRequest(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{ // Stop at line 8
+				execute: func() {
+					client.StackTraceRequest(1, 0, 1)
+					st := client.ExpectStackTraceResponse(t)
+					if len(st.Body.StackFrames) != 1 || st.Body.StackFrames[0].InstructionPointerReference == "" {
+						t.Fatalf("got %#v, want one frame with an instructionPointerReference", st)
+					}
+					pc := st.Body.StackFrames[0].InstructionPointerReference
+
+					client.DisassembleRequest(pc, -5, 10)
+					got := client.ExpectDisassembleResponse(t)
+					if len(got.Body.Instructions) != 10 {
+						t.Fatalf("got %d instructions, want 10", len(got.Body.Instructions))
+					}
+					inst := got.Body.Instructions[5]
+					if inst.Address != pc || inst.Line != 8 || inst.Location.Path != fixture.Source {
+						t.Errorf("got %#v, want Address=%s Line=8 Location.Path=%s", inst, pc, fixture.Source)
+					}
+					for i := 1; i < len(got.Body.Instructions); i++ {
+						if got.Body.Instructions[i].Address == got.Body.Instructions[i-1].Address {
+							t.Errorf("duplicate address %s", got.Body.Instructions[i].Address)
+						}
+					}
+
+					client.DisassembleRequest("not an address", 0, 10)
+					er := client.ExpectErrorResponse(t)
+					if er.Body.Error.Id != UnableToDisassemble {
+						t.Errorf("got %#v, want Id=%d", er, UnableToDisassemble)
+					}
+				},
+				disconnect: true,
+			}})
+	})
+}
+
 func TestBadAccess(t *testing.T) {
 	if runtime.GOOS != "darwin" || testBackend != "lldb" {
 		t.Skip("not applicable")
@@ -1872,6 +2034,64 @@ func TestPanicBreakpointOnContinue(t *testing.T) {
 	})
 }
 
+func TestExceptionFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []string
+		options  []dap.ExceptionOptions
+		wantStop bool
+	}{
+		{"all panics", []string{"panic"}, nil, true},
+		{"no filters", nil, nil, false},
+		{"runtime errors", []string{"runtime"}, nil, false},
+		{"matching condition", nil, []dap.ExceptionOptions{{
+			Path:      []dap.ExceptionPathSegment{{Names: []string{"panic"}}, {Names: []string{"BOOM"}}},
+			BreakMode: "always",
+		}}, true},
+		{"negated condition", nil, []dap.ExceptionOptions{{
+			Path:      []dap.ExceptionPathSegment{{Names: []string{"panic"}}, {Names: []string{"string"}, Negate: true}},
+			BreakMode: "always",
+		}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runTest(t, "panic", func(client *daptest.Client, fixture protest.Fixture) {
+				client.InitializeRequest()
+				initResp := client.ExpectInitializeResponse(t)
+				if len(initResp.Body.ExceptionBreakpointFilters) != 4 || !initResp.Body.SupportsExceptionOptions {
+					t.Errorf("got %#v, want 4 exception filters and SupportsExceptionOptions=true", initResp.Body)
+				}
+
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+				client.ExpectInitializedEvent(t)
+				client.ExpectLaunchResponse(t)
+
+				client.SetExceptionBreakpointsRequestWithArgs(tc.filters, tc.options)
+				client.ExpectSetExceptionBreakpointsResponse(t)
+
+				client.ConfigurationDoneRequest()
+				client.ExpectConfigurationDoneResponse(t)
+
+				if tc.wantStop {
+					se := client.ExpectStoppedEvent(t)
+					if se.Body.Reason != "panic" || !strings.Contains(se.Body.Text, "BOOM!") {
+						t.Errorf("\ngot  %#v\nwant Reason=\"panic\" Text containing \"BOOM!\"", se)
+					}
+					handleStop(t, client, 1, "runtime.gopanic", -1)
+					client.ContinueRequest(1)
+					client.ExpectContinueResponse(t)
+				}
+				// The unrecovered panic filter is not enabled, so the program
+				// runs to completion.
+				client.ExpectTerminatedEvent(t)
+
+				client.DisconnectRequestWithKillOption(true)
+				client.ExpectDisconnectResponse(t)
+			})
+		})
+	}
+}
+
 func TestPanicBreakpointOnNext(t *testing.T) {
 	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 14) {
 		// In Go 1.13, 'next' will step into the defer in the runtime
@@ -2051,6 +2271,35 @@ func TestLaunchDebugRequest(t *testing.T) {
 	})
 }
 
+func TestRestartRequest(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequestWithArgs(map[string]interface{}{
+					"mode": "debug", "program": fixture.Source})
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{ // Stop at line 8
+				execute: func() {
+					handleStop(t, client, 1, "main.Increment", 8)
+
+					// The program is rebuilt and the breakpoint at line 8
+					// is restored in the new process.
+					client.RestartRequest()
+					client.ExpectRestartResponse(t)
+					se := client.ExpectStoppedEvent(t)
+					if se.Body.Reason != "breakpoint" {
+						t.Errorf("got %#v, want Reason=\"breakpoint\"", se)
+					}
+					handleStop(t, client, 1, "main.Increment", 8)
+				},
+				disconnect: true,
+			}})
+	})
+}
+
 func TestLaunchTestRequest(t *testing.T) {
 	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
 		runDebugSession(t, client, "launch", func() {
@@ -2151,9 +2400,6 @@ func TestUnupportedCommandResponses(t *testing.T) {
 		client.GotoRequest()
 		expectUnsupportedCommand("goto")
 
-		client.SourceRequest()
-		expectUnsupportedCommand("source")
-
 		client.TerminateThreadsRequest()
 		expectUnsupportedCommand("terminateThreads")
 
@@ -2177,9 +2423,6 @@ func TestUnupportedCommandResponses(t *testing.T) {
 
 		client.BreakpointLocationsRequest()
 		expectUnsupportedCommand("breakpointLocations")
-
-		client.ModulesRequest()
-		expectUnsupportedCommand("modules")
 	})
 }
 
@@ -2217,9 +2460,6 @@ func TestOptionalNotYetImplementedResponses(t *testing.T) {
 		client.TerminateRequest()
 		expectNotYetImplemented("terminate")
 
-		client.RestartRequest()
-		expectNotYetImplemented("restart")
-
 		client.SetFunctionBreakpointsRequest()
 		expectNotYetImplemented("setFunctionBreakpoints")
 
@@ -2241,9 +2481,6 @@ func TestOptionalNotYetImplementedResponses(t *testing.T) {
 		client.ReadMemoryRequest()
 		expectNotYetImplemented("readMemory")
 
-		client.DisassembleRequest()
-		expectNotYetImplemented("disassemble")
-
 		client.CancelRequest()
 		expectNotYetImplemented("cancel")
 	})
@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// injectedCall is a function call injected by the Call command.
+type injectedCall struct {
+	goid int
+	// cancelled is the reason the call was cancelled, if it was.
+	cancelled string
+}
+
+// callFunction injects the function call of command in goroutine g.
+// If the call does not return within command.CallTimeout, or if it is
+// cancelled with CancelCall, the target is stopped and the call is
+// cancelled, see proc.(*Target).CancelFunctionCall. In that case the
+// second return value describes why.
+// Must be called with targetMutex held.
+func (d *Debugger) callFunction(g *proc.G, command *api.DebuggerCommand) (err, callErr error) {
+	if g == nil {
+		return proc.EvalExpressionWithCalls(d.target, g, command.Expr, *api.LoadConfigToProc(command.ReturnInfoLoadConfig), !command.UnsafeCall), nil
+	}
+
+	call := &injectedCall{goid: g.ID}
+	d.callMutex.Lock()
+	d.call = call
+	d.callMutex.Unlock()
+	defer func() {
+		d.callMutex.Lock()
+		d.call = nil
+		d.callMutex.Unlock()
+	}()
+
+	if command.CallTimeout > 0 {
+		timer := time.AfterFunc(command.CallTimeout, func() {
+			d.cancelCall(call, fmt.Sprintf("timed out after %v", command.CallTimeout))
+		})
+		defer timer.Stop()
+	}
+
+	err = proc.EvalExpressionWithCalls(d.target, g, command.Expr, *api.LoadConfigToProc(command.ReturnInfoLoadConfig), !command.UnsafeCall)
+	if err != nil {
+		return err, nil
+	}
+
+	d.callMutex.Lock()
+	cancelled := call.cancelled
+	d.callMutex.Unlock()
+	if cancelled == "" {
+		return nil, nil
+	}
+	if err := d.target.CancelFunctionCall(g); err != nil {
+		// the call completed before the target stopped
+		return nil, nil
+	}
+	d.log.Debugf("function call %s cancelled: %s", command.Expr, cancelled)
+	return nil, fmt.Errorf("function call %s cancelled: %s", command.Expr, cancelled)
+}
+
+// cancelCall marks call as cancelled and stops the target, if call is
+// still in progress.
+func (d *Debugger) cancelCall(call *injectedCall, reason string) {
+	d.callMutex.Lock()
+	defer d.callMutex.Unlock()
+	if d.call != call || call.cancelled != "" {
+		return
+	}
+	call.cancelled = reason
+	d.recordMutex.Lock()
+	if d.stopRecording == nil {
+		d.target.RequestManualStop()
+	}
+	d.recordMutex.Unlock()
+}
+
+// CancelCall cancels the function call injected in goroutine goid, or in
+// any goroutine if goid is zero. If the Call command is still running the
+// target is stopped. Otherwise the call was interrupted by a breakpoint, or
+// by a manual stop, and it is cancelled when the target is resumed, see
+// proc.(*Target).CancelFunctionCall. In this case, if goid is zero, the call
+// injected in the selected goroutine is cancelled.
+func (d *Debugger) CancelCall(goid int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+
+	d.callMutex.Lock()
+	call := d.call
+	d.callMutex.Unlock()
+	if call != nil && (goid == 0 || goid == call.goid) {
+		d.cancelCall(call, "requested by the client")
+		return nil
+	}
+
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	g := d.target.SelectedGoroutine()
+	if goid > 0 {
+		var err error
+		g, err = proc.FindGoroutine(d.target, goid)
+		if err != nil {
+			return err
+		}
+	}
+	if g == nil {
+		return errors.New("no function call in progress")
+	}
+	return d.target.CancelFunctionCall(g)
+}
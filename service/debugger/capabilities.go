@@ -0,0 +1,56 @@
+package debugger
+
+import (
+	"runtime"
+
+	"github.com/go-delve/delve/pkg/proc/native"
+	"github.com/go-delve/delve/service/api"
+)
+
+// Capabilities returns the features supported by the target and by the
+// backend in use.
+func (d *Debugger) Capabilities() *api.Capabilities {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	c := &api.Capabilities{
+		Backend:  d.backendName(),
+		ReadOnly: d.observer,
+	}
+	c.Core = c.Backend == "core"
+	if c.Core {
+		return c
+	}
+
+	recorded, _ := d.target.Recorded()
+	bi := d.target.BinInfo()
+	osarch := bi.GOOS + "/" + bi.Arch.Name
+
+	c.Recorded = recorded
+	c.ReverseExecution = recorded
+	c.Restart = recorded || d.canRestart()
+	c.Checkpoints = recorded || (c.Backend == "native" && native.CheckpointsSupported())
+	c.FunctionCalls = d.target.CanCallFunctions()
+
+	if !recorded {
+		switch osarch {
+		case "linux/amd64", "linux/arm64", "freebsd/amd64", "darwin/amd64", "darwin/arm64":
+			c.FunctionPatching = true
+		}
+		// Memory guards and branch tracing are only implemented by the native
+		// backend on linux, see proc.(*Target).mprotect and
+		// proc.(*Target).EnableBranchTrace.
+		if c.Backend == "native" && runtime.GOOS == "linux" {
+			c.MemoryGuards = osarch == "linux/amd64" || osarch == "linux/arm64"
+			c.BranchTrace = bi.Arch.Name == "amd64"
+		}
+		c.Minidump = c.Backend == "native" && runtime.GOOS == "windows"
+		c.NonStop = d.target.NonStopSupported()
+	}
+
+	d.outputMutex.Lock()
+	c.Stdin = d.output != nil
+	d.outputMutex.Unlock()
+
+	return c
+}
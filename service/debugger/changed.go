@@ -0,0 +1,162 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// valueHistory records the values of the variables returned to clients,
+// so that variables whose value changed since the previous stop can be
+// marked as such.
+type valueHistory struct {
+	mu     sync.Mutex
+	stop   int // incremented every time the target is resumed
+	values map[string]*reportedValue
+}
+
+type reportedValue struct {
+	old    string // value reported during a previous stop
+	hasOld bool
+	cur    string // value reported during the last stop
+	stop   int    // stop during which cur was reported
+}
+
+// resumed must be called every time the target is resumed. Values that
+// were not reported during the stop that just ended are forgotten, so that
+// only the variables that are being looked at are remembered.
+func (h *valueHistory) resumed() {
+	h.mu.Lock()
+	for path, rv := range h.values {
+		if rv.stop != h.stop {
+			delete(h.values, path)
+		}
+	}
+	h.stop++
+	h.mu.Unlock()
+}
+
+// reset forgets all values.
+func (h *valueHistory) reset() {
+	h.mu.Lock()
+	h.values = nil
+	h.mu.Unlock()
+}
+
+// changed records that the variable at path has value val and returns true
+// if the last value reported for it during a previous stop was different.
+func (h *valueHistory) changed(path, val string) bool {
+	if h.values == nil {
+		h.values = make(map[string]*reportedValue)
+	}
+	rv := h.values[path]
+	if rv == nil {
+		rv = &reportedValue{stop: h.stop}
+		h.values[path] = rv
+	} else if rv.stop != h.stop {
+		rv.old, rv.hasOld = rv.cur, true
+		rv.stop = h.stop
+	}
+	rv.cur = val
+	return rv.hasOld && rv.old != val
+}
+
+// mark sets the Changed field of v and its children.
+func (h *valueHistory) mark(path string, v *api.Variable) bool {
+	changed := h.changed(path, variableSummary(v))
+	switch v.Kind {
+	case reflect.Map:
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			key := &v.Children[i]
+			if h.mark(fmt.Sprintf("%s[%s]", path, key.SinglelineString()), &v.Children[i+1]) {
+				changed = true
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		for i := range v.Children {
+			if h.mark(fmt.Sprintf("%s[%d]", path, i), &v.Children[i]) {
+				changed = true
+			}
+		}
+	case reflect.Ptr, reflect.Interface:
+		for i := range v.Children {
+			if h.mark(path+".*", &v.Children[i]) {
+				changed = true
+			}
+		}
+	default:
+		for i := range v.Children {
+			if h.mark(path+"."+v.Children[i].Name, &v.Children[i]) {
+				changed = true
+			}
+		}
+	}
+	v.Changed = changed
+	return changed
+}
+
+// variableSummary returns a string that changes when the value of v,
+// excluding its children, changes.
+func variableSummary(v *api.Variable) string {
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(v.Children) == 0 {
+			return "nil"
+		}
+		return fmt.Sprintf("%#x", v.Children[0].Addr)
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Chan:
+		return fmt.Sprintf("%s len=%d base=%#x", v.Value, v.Len, v.Base)
+	case reflect.Interface:
+		if len(v.Children) == 0 {
+			return "nil"
+		}
+		return v.Children[0].Type
+	}
+	return v.Value + v.Unreadable
+}
+
+// MarkChangedVariables sets the Changed field of the variables in vars,
+// which were returned by LocalVariables, FunctionArguments or
+// EvalVariableInScope for the given scope, and of their children, if
+// their value changed since they were returned during the previous stop.
+// If the variables are package variables goid should be -1 and frame
+// should be -1.
+// Variables are identified by their name, which for variables returned
+// by EvalVariableInScope should be the evaluated expression.
+// If the scope can not be found the variables are not marked.
+func (d *Debugger) MarkChangedVariables(goid, frame, deferredCall int, vars []api.Variable) {
+	prefix := "package "
+	if frame >= 0 {
+		d.targetMutex.Lock()
+		s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+		if err == nil && goid == -1 {
+			if g := d.target.SelectedGoroutine(); g != nil {
+				goid = g.ID
+			}
+		}
+		d.targetMutex.Unlock()
+		if err != nil {
+			return
+		}
+		fnname := "?"
+		if s.Fn != nil {
+			fnname = s.Fn.Name
+		}
+		// The frame is identified by its CFA, rather than by its index,
+		// which changes when functions are called or return, so that the
+		// frames of a recursive function are told apart.
+		prefix = fmt.Sprintf("goroutine %d %s frame %#x ", goid, fnname, s.Regs.CFA)
+		if deferredCall > 0 {
+			prefix = fmt.Sprintf("goroutine %d %s frame %#x defer %d ", goid, fnname, s.Regs.CFA, deferredCall)
+		}
+	}
+
+	d.history.mu.Lock()
+	defer d.history.mu.Unlock()
+	for i := range vars {
+		d.history.mark(prefix+vars[i].Name, &vars[i])
+	}
+}
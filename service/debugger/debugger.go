@@ -2,15 +2,20 @@ package debugger
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
 	"debug/dwarf"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/parser"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -48,6 +53,15 @@ var (
 // functionality needed by clients, but not needed in
 // lower lever packages such as proc.
 type Debugger struct {
+	*debuggerCore
+
+	// observer is true if this Debugger can only be used to inspect the
+	// target, see Observer.
+	observer bool
+}
+
+// debuggerCore is the state of a Debugger, shared with its observers.
+type debuggerCore struct {
 	config *Config
 	// arguments to launch a new process.
 	processArgs []string
@@ -62,6 +76,38 @@ type Debugger struct {
 
 	stopRecording func() error
 	recordMutex   sync.Mutex
+
+	// prettyPrinters are the pretty printers loaded from
+	// Config.PrettyPrinters, see PrettyPrinters.
+	prettyPrinters api.PrettyPrinters
+
+	// history records the values of variables returned to clients, see
+	// MarkChangedVariables.
+	history valueHistory
+
+	// watches are the expressions evaluated every time the target stops,
+	// see AddWatch. Protected by targetMutex.
+	watches     []watchExpr
+	lastWatchID int
+
+	// events records the changes of state of the target, see WaitEvents.
+	events eventLog
+
+	// output captures the standard input and output of the target, see
+	// Config.CaptureOutput.
+	output      *outputCapture
+	outputMutex sync.Mutex
+
+	// call is the function call being injected by Command, see CancelCall.
+	call      *injectedCall
+	callMutex sync.Mutex
+
+	// metrics is the state of the debugger reported by Metrics.
+	metrics metricsCache
+
+	// hooks are the scripts run by the server when events happen, see
+	// AddEventHook.
+	hooks eventHooks
 }
 
 type ExecuteKind int
@@ -87,9 +133,22 @@ type Config struct {
 	// attach.
 	AttachPid int
 
+	// AttachStub is the address of a gdbserial stub that is already attached
+	// to the process with pid AttachPid. If it is set the debugger connects
+	// to the stub instead of attaching to the process, see gdbserial.Remote.
+	AttachStub string
+
+	// StubTLSConfig, if not nil, is used to secure the connection to
+	// AttachStub with TLS.
+	StubTLSConfig *tls.Config
+
 	// CoreFile specifies the path to the core dump to open.
 	CoreFile string
 
+	// CheckpointDir is the directory containing the images of a checkpoint,
+	// created with CRIU by a previous debug session, to restore.
+	CheckpointDir string
+
 	// Backend specifies the debugger backend.
 	Backend string
 
@@ -100,6 +159,11 @@ type Config struct {
 	// when resolving external debug info files.
 	DebugInfoDirectories []string
 
+	// SubstitutePath are the substitute-path rules used by SourceFile to find
+	// the source files of the target on the machine running the debugger.
+	// Each rule replaces the prefix rule[0] with rule[1].
+	SubstitutePath [][2]string
+
 	// CheckGoVersion is true if the debugger should check the version of Go
 	// used to compile the executable and refuse to work on incompatible
 	// versions.
@@ -121,19 +185,55 @@ type Config struct {
 	// Redirects specifies redirect rules for stdin, stdout and stderr
 	Redirects [3]string
 
+	// CaptureOutput, if not empty, redirects stdin, stdout and stderr of the
+	// target so that they can be accessed through the API, see WriteStdin
+	// and api.EventOutput. It can be either "pipe", to use a separate pipe
+	// for each of them, or "pty", to use a pseudo-terminal, in which case
+	// stdout and stderr are merged. Can not be used together with TTY and
+	// Redirects.
+	CaptureOutput string
+
 	// DisableASLR disables ASLR
 	DisableASLR bool
+
+	// StopAtSafePoints is true if, after a manual stop, the threads of the
+	// target should be advanced to the nearest safe point, see
+	// proc.(*Target).AdvanceToSafePoints.
+	StopAtSafePoints bool
+
+	// QueueBreakpointsDuringNext is true if breakpoints hit by other
+	// goroutines should not interrupt next, step and stepout, see
+	// proc.(*Target).QueueBreakpointsDuringNext.
+	QueueBreakpointsDuringNext bool
+
+	// StopAtInlinedCalls is true if next should stop at the beginning of
+	// inlined calls, see proc.(*Target).StopAtInlinedCalls.
+	StopAtInlinedCalls bool
+
+	// PrettyPrinters is a list of starlark scripts that register pretty
+	// printers for user types, see loadPrettyPrinters.
+	PrettyPrinters []string
+
+	// InitState is a session, saved with SaveSession, that is restored once
+	// the target is started.
+	InitState *api.Session
 }
 
 // New creates a new Debugger. ProcessArgs specify the commandline arguments for the
 // new process.
 func New(config *Config, processArgs []string) (*Debugger, error) {
 	logger := logflags.DebuggerLogger()
-	d := &Debugger{
+	d := &Debugger{debuggerCore: &debuggerCore{
 		config:      config,
 		processArgs: processArgs,
 		log:         logger,
+	}}
+
+	pps, err := loadPrettyPrinters(d.config.PrettyPrinters)
+	if err != nil {
+		return nil, err
 	}
+	d.prettyPrinters = pps
 
 	// Create the process by either attaching or launching.
 	switch {
@@ -153,11 +253,10 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 	case d.config.CoreFile != "":
 		var p *proc.Target
 		var err error
-		switch d.config.Backend {
-		case "rr":
+		if rec, ok := proc.LookupRecorder(d.config.Backend); ok {
 			d.log.Infof("opening trace %s", d.config.CoreFile)
-			p, err = gdbserial.Replay(d.config.CoreFile, false, false, d.config.DebugInfoDirectories)
-		default:
+			p, err = rec.Replay(d.config.CoreFile, false, false, d.config.DebugInfoDirectories)
+		} else {
 			d.log.Infof("opening core file %s (executable %s)", d.config.CoreFile, d.processArgs[0])
 			p, err = core.OpenCore(d.config.CoreFile, d.processArgs[0], d.config.DebugInfoDirectories)
 		}
@@ -171,6 +270,14 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 			return nil, err
 		}
 
+	case d.config.CheckpointDir != "":
+		d.log.Infof("restoring checkpoint %s", d.config.CheckpointDir)
+		p, err := native.Restore(d.config.CheckpointDir, d.config.DebugInfoDirectories)
+		if err != nil {
+			return nil, err
+		}
+		d.target = p
+
 	default:
 		d.log.Infof("launching process with args: %v", d.processArgs)
 		p, err := d.Launch(d.processArgs, d.config.WorkingDir)
@@ -190,6 +297,13 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 			return nil, err
 		}
 	}
+	if d.target != nil {
+		// When recording in the background the session is restored after the
+		// recording is done.
+		d.targetMutex.Lock()
+		d.loadInitState()
+		d.targetMutex.Unlock()
+	}
 	return d, nil
 }
 
@@ -200,6 +314,8 @@ func (d *Debugger) canRestart() bool {
 		return false
 	case d.config.CoreFile != "":
 		return false
+	case d.config.CheckpointDir != "":
+		return false
 	default:
 		return true
 	}
@@ -234,18 +350,38 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.Target, error)
 		launchFlags |= proc.LaunchDisableASLR
 	}
 
-	switch d.config.Backend {
-	case "native":
-		return native.Launch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, d.config.TTY, d.config.Redirects)
-	case "lldb":
-		return betterGdbserialLaunchError(gdbserial.LLDBLaunch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, d.config.TTY, d.config.Redirects))
-	case "rr":
+	if d.config.CaptureOutput == "" {
+		return d.launch(processArgs, wd, launchFlags, d.config.TTY, d.config.Redirects)
+	}
+
+	if d.config.TTY != "" || d.config.Redirects != [3]string{} {
+		return nil, errors.New("can not capture the output of a target with redirects")
+	}
+	if _, isrec := proc.LookupRecorder(d.config.Backend); d.config.CaptureOutput == "pty" && (isrec || d.config.Backend == "qemu") {
+		return nil, fmt.Errorf("the %s backend does not support capturing output with a pseudo-terminal", d.config.Backend)
+	}
+	c, err := newOutputCapture(d.config.CaptureOutput)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.launch(processArgs, wd, launchFlags, c.tty, c.redirects)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	c.launched()
+	d.setOutputCapture(c)
+	return p, nil
+}
+
+func (d *Debugger) launch(processArgs []string, wd string, launchFlags proc.LaunchFlags, tty string, redirects [3]string) (*proc.Target, error) {
+	if rec, ok := proc.LookupRecorder(d.config.Backend); ok {
 		if d.target != nil {
-			// restart should not call us if the backend is 'rr'
-			panic("internal error: call to Launch with rr backend and target already exists")
+			// restart should not call us if the backend is a recorder
+			panic("internal error: call to Launch with " + rec.Name() + " backend and target already exists")
 		}
 
-		run, stop, err := gdbserial.RecordAsync(processArgs, wd, false, d.config.Redirects)
+		run, stop, err := rec.RecordAsync(processArgs, wd, false, redirects)
 		if err != nil {
 			return nil, err
 		}
@@ -259,7 +395,7 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.Target, error)
 		go func() {
 			defer d.targetMutex.Unlock()
 
-			p, err := d.recordingRun(run)
+			p, err := d.recordingRun(rec, run)
 			if err != nil {
 				d.log.Errorf("could not record target: %v", err)
 				// this is ugly but we can't respond to any client requests at this
@@ -274,15 +410,28 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.Target, error)
 				if err != nil {
 					d.log.Errorf("Error detaching from target: %v", err)
 				}
+				return
 			}
+			d.loadInitState()
 		}()
 		return nil, nil
+	}
 
+	switch d.config.Backend {
+	case "native":
+		return native.Launch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, tty, redirects)
+	case "lldb":
+		return betterGdbserialLaunchError(gdbserial.LLDBLaunch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, tty, redirects))
+	case "qemu":
+		return gdbserial.QemuLaunch(processArgs, wd, d.config.DebugInfoDirectories, redirects)
 	case "default":
-		if runtime.GOOS == "darwin" {
-			return betterGdbserialLaunchError(gdbserial.LLDBLaunch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, d.config.TTY, d.config.Redirects))
+		if defaultBackendIsLLDB() {
+			p, err := gdbserial.LLDBLaunch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, tty, redirects)
+			return nativeFallback(p, err, func() (*proc.Target, error) {
+				return native.Launch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, tty, redirects)
+			})
 		}
-		return native.Launch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, d.config.TTY, d.config.Redirects)
+		return native.Launch(processArgs, wd, launchFlags, d.config.DebugInfoDirectories, tty, redirects)
 	default:
 		return nil, fmt.Errorf("unknown backend %q", d.config.Backend)
 	}
@@ -306,25 +455,31 @@ func (d *Debugger) isRecording() bool {
 	return d.stopRecording != nil
 }
 
-func (d *Debugger) recordingRun(run func() (string, error)) (*proc.Target, error) {
+func (d *Debugger) recordingRun(rec proc.Recorder, run func() (string, error)) (*proc.Target, error) {
 	tracedir, err := run()
 	if err != nil && tracedir == "" {
 		return nil, err
 	}
 
-	return gdbserial.Replay(tracedir, false, true, d.config.DebugInfoDirectories)
+	return rec.Replay(tracedir, false, true, d.config.DebugInfoDirectories)
 }
 
 // Attach will attach to the process specified by 'pid'.
 func (d *Debugger) Attach(pid int, path string) (*proc.Target, error) {
+	if d.config.AttachStub != "" {
+		return gdbserial.Remote(d.config.AttachStub, path, pid, d.config.StubTLSConfig, d.config.DebugInfoDirectories)
+	}
 	switch d.config.Backend {
 	case "native":
 		return native.Attach(pid, d.config.DebugInfoDirectories)
 	case "lldb":
 		return betterGdbserialLaunchError(gdbserial.LLDBAttach(pid, path, d.config.DebugInfoDirectories))
 	case "default":
-		if runtime.GOOS == "darwin" {
-			return betterGdbserialLaunchError(gdbserial.LLDBAttach(pid, path, d.config.DebugInfoDirectories))
+		if defaultBackendIsLLDB() {
+			p, err := gdbserial.LLDBAttach(pid, path, d.config.DebugInfoDirectories)
+			return nativeFallback(p, err, func() (*proc.Target, error) {
+				return native.Attach(pid, d.config.DebugInfoDirectories)
+			})
 		}
 		return native.Attach(pid, d.config.DebugInfoDirectories)
 	default:
@@ -332,17 +487,41 @@ func (d *Debugger) Attach(pid int, path string) (*proc.Target, error) {
 	}
 }
 
-var errMacOSBackendUnavailable = errors.New("debugserver or lldb-server not found: install Xcode's command line tools or lldb-server")
+// defaultBackendIsLLDB returns true if the "default" backend is lldb on
+// this operating system, because the native backend is not supported.
+func defaultBackendIsLLDB() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "openbsd"
+}
 
-func betterGdbserialLaunchError(p *proc.Target, err error) (*proc.Target, error) {
-	if runtime.GOOS != "darwin" {
-		return p, err
+// nativeFallback is used when the default backend is lldb: if the
+// debugserver (or lldb-server) executable was not found and the native
+// backend was compiled in it calls fallback to use the native backend
+// instead.
+func nativeFallback(p *proc.Target, err error, fallback func() (*proc.Target, error)) (*proc.Target, error) {
+	if _, isUnavailable := err.(*gdbserial.ErrBackendUnavailable); isUnavailable {
+		if p, err := fallback(); err != native.ErrNativeBackendDisabled {
+			return p, err
+		}
 	}
+	return betterGdbserialLaunchError(p, err)
+}
+
+var (
+	errMacOSBackendUnavailable   = errors.New("debugserver or lldb-server not found: install Xcode's command line tools or lldb-server")
+	errOpenBSDBackendUnavailable = errors.New("lldb-server not found: install lldb-server (see Documentation/installation/openbsd/install.md)")
+)
+
+func betterGdbserialLaunchError(p *proc.Target, err error) (*proc.Target, error) {
 	if _, isUnavailable := err.(*gdbserial.ErrBackendUnavailable); !isUnavailable {
 		return p, err
 	}
-
-	return p, errMacOSBackendUnavailable
+	switch runtime.GOOS {
+	case "darwin":
+		return p, errMacOSBackendUnavailable
+	case "openbsd":
+		return p, errOpenBSDBackendUnavailable
+	}
+	return p, err
 }
 
 // ProcessPid returns the PID of the process
@@ -361,6 +540,12 @@ func (d *Debugger) LastModified() time.Time {
 	return d.target.BinInfo().LastModified()
 }
 
+// PrettyPrinters returns the pretty printers that should be used to
+// convert variables for clients, see Config.PrettyPrinters.
+func (d *Debugger) PrettyPrinters() api.PrettyPrinters {
+	return d.prettyPrinters
+}
+
 const deferReturn = "runtime.deferreturn"
 
 // FunctionReturnLocations returns all return locations
@@ -405,8 +590,14 @@ func (d *Debugger) FunctionReturnLocations(fnName string) ([]uint64, error) {
 // If `kill` is true we will kill the process after
 // detaching.
 func (d *Debugger) Detach(kill bool) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
+	defer d.events.close()
+	defer d.metrics.detached()
+	defer d.setOutputCapture(nil)
 	if ok, _ := d.target.Valid(); !ok {
 		return nil
 	}
@@ -425,17 +616,23 @@ func (d *Debugger) detach(kill bool) error {
 // If the target process is a recording it will restart it from the given
 // position. If pos starts with 'c' it's a checkpoint ID, otherwise it's an
 // event number. If resetArgs is true, newArgs will replace the process args.
+// Live processes can be restarted from a checkpoint on some backends.
 func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []string, newRedirects [3]string, rebuild bool) ([]api.DiscardedBreakpoint, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
+	d.history.reset()
+
 	recorded, _ := d.target.Recorded()
 	if recorded && !rerecord {
 		return nil, d.target.Restart(pos)
 	}
 
 	if pos != "" {
-		return nil, proc.ErrNotRecorded
+		return nil, d.target.Restart(pos)
 	}
 
 	if !d.canRestart() {
@@ -476,14 +673,14 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 		}
 	}
 
-	if recorded {
-		run, stop, err2 := gdbserial.RecordAsync(d.processArgs, d.config.WorkingDir, false, d.config.Redirects)
+	if rec, ok := proc.LookupRecorder(d.config.Backend); recorded && ok {
+		run, stop, err2 := rec.RecordAsync(d.processArgs, d.config.WorkingDir, false, d.config.Redirects)
 		if err2 != nil {
 			return nil, err2
 		}
 
 		d.recordingStart(stop)
-		p, err = d.recordingRun(run)
+		p, err = d.recordingRun(rec, run)
 		d.recordingDone()
 	} else {
 		p, err = d.Launch(d.processArgs, d.config.WorkingDir)
@@ -493,32 +690,85 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 	}
 
 	discarded := []api.DiscardedBreakpoint{}
-	for _, oldBp := range api.ConvertBreakpoints(d.breakpoints()) {
-		if oldBp.ID < 0 {
-			continue
-		}
-		if len(oldBp.File) > 0 {
-			addrs, err := proc.FindFileLocation(p, oldBp.File, oldBp.Line)
-			if err != nil {
-				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: err.Error()})
+	// restored are the breakpoints set on the new process, with their
+	// addresses, to check that they were written.
+	type restoredBreakpoint struct {
+		bp    *api.Breakpoint
+		addrs []uint64
+	}
+	var restored []restoredBreakpoint
+	var setErr error
+	// All breakpoints are written to the new process at once, errors writing
+	// them are returned by BatchBreakpoints.
+	writeErr := p.BatchBreakpoints(func() error {
+		for _, oldBp := range api.ConvertBreakpoints(d.breakpoints()) {
+			if oldBp.ID < 0 {
 				continue
 			}
-			createLogicalBreakpoint(p, addrs, oldBp)
-		} else {
-			// Avoid setting a breakpoint based on address when rebuilding
-			if rebuild {
-				continue
+			if len(oldBp.File) > 0 {
+				addrs, err := proc.FindFileLocation(p, oldBp.File, oldBp.Line)
+				if err != nil {
+					discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: err.Error()})
+					continue
+				}
+				if _, err := createLogicalBreakpoint(p, addrs, oldBp); err == nil {
+					restored = append(restored, restoredBreakpoint{oldBp, addrs})
+				}
+			} else {
+				// Avoid setting a breakpoint based on address when rebuilding
+				if rebuild {
+					continue
+				}
+				newBp, err := p.SetBreakpoint(oldBp.Addr, proc.UserBreakpoint, nil)
+				if err != nil {
+					setErr = err
+					return nil
+				}
+				if err := copyBreakpointInfo(newBp, oldBp); err != nil {
+					setErr = err
+					return nil
+				}
+				restored = append(restored, restoredBreakpoint{oldBp, []uint64{oldBp.Addr}})
 			}
-			newBp, err := p.SetBreakpoint(oldBp.Addr, proc.UserBreakpoint, nil)
-			if err != nil {
-				return nil, err
+		}
+		return nil
+	})
+	if setErr != nil {
+		return nil, setErr
+	}
+	if writeErr != nil {
+		// Breakpoints that could not be written were removed, the other
+		// addresses of their logical breakpoints are cleared too.
+		bpmap := p.Breakpoints()
+		for _, rbp := range restored {
+			reason := ""
+			for _, addr := range rbp.addrs {
+				if _, ok := bpmap.M[addr]; !ok {
+					reason = fmt.Sprintf("could not write breakpoint at %#x", addr)
+					break
+				}
 			}
-			if err := copyBreakpointInfo(newBp, oldBp); err != nil {
-				return nil, err
+			if reason == "" {
+				continue
+			}
+			for _, addr := range rbp.addrs {
+				if _, ok := bpmap.M[addr]; !ok {
+					continue
+				}
+				if _, err := p.ClearBreakpoint(addr); err != nil {
+					reason = fmt.Sprintf("%s, additionally the breakpoint at %#x could not be cleared: %v", reason, addr, err)
+				}
 			}
+			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: rbp.bp, Reason: reason})
+		}
+	}
+	for sig, policy := range d.target.SignalPolicies() {
+		if err := p.SetSignalPolicy(sig, policy); err != nil {
+			return nil, err
 		}
 	}
 	d.target = p
+	d.metrics.restarted()
 	return discarded, nil
 }
 
@@ -566,7 +816,7 @@ func (d *Debugger) state(retLoadCfg *proc.LoadConfig) (*api.DebuggerState, error
 
 		th.CallReturn = thread.Common().CallReturn
 		if retLoadCfg != nil {
-			th.ReturnValues = api.ConvertVars(thread.Common().ReturnValues(*retLoadCfg))
+			th.ReturnValues = d.prettyPrinters.ConvertVars(thread.Common().ReturnValues(*retLoadCfg))
 		}
 
 		state.Threads = append(state.Threads, th)
@@ -581,14 +831,36 @@ func (d *Debugger) state(retLoadCfg *proc.LoadConfig) (*api.DebuggerState, error
 		state.When, _ = d.target.When()
 	}
 
+	state.ReceivedSignals = api.ConvertReceivedSignals(d.target.BinInfo().GOOS, d.target.ReceivedSignals)
+	state.GuardFault = api.ConvertGuardFault(d.target.GuardFault)
+	state.QueuedBreakpoints = api.ConvertQueuedBreakpoints(d.target.QueuedBreakpoints)
+
+	if calls, err := d.target.InlineTree(); err == nil {
+		state.InlinedCalls = api.ConvertInlinedCalls(calls)
+	}
+
+	if gc, err := d.target.GCStatus(); err == nil {
+		state.GC = api.ConvertGCStatus(gc)
+	}
+
+	if !exited {
+		state.Watches = d.evalWatches()
+	}
+
 	return state, nil
 }
 
 // CreateBreakpoint creates a breakpoint.
 func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
+	return d.createBreakpoint(requestedBp)
+}
 
+func (d *Debugger) createBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
 	var (
 		addrs []uint64
 		err   error
@@ -683,6 +955,9 @@ func isBreakpointExistsErr(err error) bool {
 
 // AmendBreakpoint will update the breakpoint with the matching ID.
 func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
@@ -704,6 +979,9 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 // CancelNext will clear internal breakpoints, thus cancelling the 'next',
 // 'step' or 'stepout' operation.
 func (d *Debugger) CancelNext() error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 	return d.target.ClearInternalBreakpoints()
@@ -727,6 +1005,9 @@ func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err err
 
 // ClearBreakpoint clears a breakpoint.
 func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
@@ -881,6 +1162,9 @@ func (d *Debugger) isRunning() bool {
 
 // Command handles commands which control the debugger lifecycle
 func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
 	var err error
 
 	if command.Name == api.Halt {
@@ -896,10 +1180,23 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	}
 
 	withBreakpointInfo := true
+	// callErr is returned, after the stop is published, when a function call
+	// is cancelled
+	var callErr error
 
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
+	d.target.QueueBreakpointsDuringNext = d.config.QueueBreakpointsDuringNext
+	d.target.StopAtInlinedCalls = d.config.StopAtInlinedCalls
+
+	// Commands that don't resume the target don't generate events.
+	resumed := command.Name != api.SwitchThread && command.Name != api.SwitchGoroutine && command.Name != api.Halt
+	if resumed {
+		d.history.resumed()
+	}
+	nimages := len(d.target.BinInfo().Images)
+
 	d.setRunning(true)
 	defer d.setRunning(false)
 
@@ -928,7 +1225,7 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 				return nil, err
 			}
 		}
-		err = proc.EvalExpressionWithCalls(d.target, g, command.Expr, *api.LoadConfigToProc(command.ReturnInfoLoadConfig), !command.UnsafeCall)
+		err, callErr = d.callFunction(g, command)
 	case api.Rewind:
 		d.log.Debug("rewinding")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
@@ -953,6 +1250,9 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 			return nil, err
 		}
 		err = d.target.Step()
+	case api.StepInline:
+		d.log.Debugf("stepping into inlined call to %q", command.Expr)
+		err = d.target.StepInline(command.Expr)
 	case api.ReverseStep:
 		d.log.Debug("reverse stepping")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
@@ -999,12 +1299,21 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 		withBreakpointInfo = false
 	}
 
+	if err == nil && d.config.StopAtSafePoints && d.target.StopReason == proc.StopManual {
+		d.log.Debug("advancing threads to safe points")
+		err = d.target.AdvanceToSafePoints()
+	}
+
 	if err != nil {
 		if exitedErr, exited := err.(proc.ErrProcessExited); command.Name != api.SwitchGoroutine && command.Name != api.SwitchThread && exited {
 			state := &api.DebuggerState{}
 			state.Exited = true
 			state.ExitStatus = exitedErr.Status
 			state.Err = errors.New(exitedErr.Error())
+			if resumed {
+				d.events.add(api.Event{Kind: api.EventExited, ExitStatus: exitedErr.Status})
+			}
+			d.metrics.exited(exitedErr.Status)
 			return state, nil
 		}
 		return nil, err
@@ -1025,6 +1334,12 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 			}
 		}
 	}
+	if resumed {
+		d.publishStop(state, nimages)
+	}
+	if callErr != nil {
+		return state, callErr
+	}
 	return state, err
 }
 
@@ -1084,17 +1399,17 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 			if err != nil {
 				bpi.Variables[i] = api.Variable{Name: bp.Variables[i], Unreadable: fmt.Sprintf("eval error: %v", err)}
 			} else {
-				bpi.Variables[i] = *api.ConvertVar(v)
+				bpi.Variables[i] = *d.prettyPrinters.ConvertVar(v)
 			}
 		}
 		if bp.LoadArgs != nil {
 			if vars, err := s.FunctionArguments(*api.LoadConfigToProc(bp.LoadArgs)); err == nil {
-				bpi.Arguments = api.ConvertVars(vars)
+				bpi.Arguments = d.prettyPrinters.ConvertVars(vars)
 			}
 		}
 		if bp.LoadLocals != nil {
 			if locals, err := s.LocalVariables(*api.LoadConfigToProc(bp.LoadLocals)); err == nil {
-				bpi.Locals = api.ConvertVars(locals)
+				bpi.Locals = d.prettyPrinters.ConvertVars(locals)
 			}
 		}
 	}
@@ -1121,6 +1436,60 @@ func (d *Debugger) Sources(filter string) ([]string, error) {
 	return files, nil
 }
 
+// maxSourceFileSize is the size of the largest file returned by
+// SourceFile.
+const maxSourceFileSize = 16 * 1024 * 1024
+
+// SourceFile returns the contents of the source file path, which must be
+// one of the files listed in the debug info of the target, as read on the
+// machine running the debugger. If the sources are not at the same path
+// they had when the target was compiled Config.SubstitutePath is used to
+// find them. Rules supplied by clients are never used, they could redirect
+// the read to any file.
+func (d *Debugger) SourceFile(path string) (*api.SourceFile, error) {
+	d.targetMutex.Lock()
+	found := false
+	for _, f := range d.target.BinInfo().Sources {
+		if f == path {
+			found = true
+			break
+		}
+	}
+	d.targetMutex.Unlock()
+	if !found {
+		return nil, fmt.Errorf("%s is not a source file of the target", path)
+	}
+
+	hostPath := path
+	if len(d.config.SubstitutePath) > 0 {
+		hostPath = locspec.SubstitutePath(path, d.config.SubstitutePath)
+	}
+	fh, err := os.Open(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxSourceFileSize {
+		return nil, fmt.Errorf("%s is too big (%d bytes)", hostPath, fi.Size())
+	}
+	content, err := ioutil.ReadAll(fh)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	return &api.SourceFile{
+		Path:     path,
+		HostPath: hostPath,
+		Content:  content,
+		ModTime:  fi.ModTime(),
+		SHA256:   hex.EncodeToString(sum[:]),
+	}, nil
+}
+
 // Functions returns a list of functions in the target process.
 func (d *Debugger) Functions(filter string) ([]string, error) {
 	d.targetMutex.Lock()
@@ -1227,6 +1596,24 @@ func (d *Debugger) DwarfRegisterToString(i int, reg *op.DwarfRegister) (string,
 	return d.target.BinInfo().Arch.DwarfRegisterToString(i, reg)
 }
 
+// DwarfRegisterToStringFormat returns a function equivalent to
+// DwarfRegisterToString that formats the contents of vector registers
+// using vectorFormat, see proc.ParseVectorFormat.
+func (d *Debugger) DwarfRegisterToStringFormat(vectorFormat string) (func(int, *op.DwarfRegister) (string, bool, string), error) {
+	vf, err := proc.ParseVectorFormat(vectorFormat)
+	if err != nil {
+		return nil, err
+	}
+	arch := d.target.BinInfo().Arch
+	return func(i int, reg *op.DwarfRegister) (string, bool, string) {
+		name, fp, repr := arch.DwarfRegisterToString(i, reg)
+		if reg.Bytes != nil && arch.IsVectorRegister(name) {
+			repr = vf.Format(reg.Bytes)
+		}
+		return name, fp, repr
+	}, nil
+}
+
 // LocalVariables returns a list of the local variables.
 func (d *Debugger) LocalVariables(goid, frame, deferredCall int, cfg proc.LoadConfig) ([]*proc.Variable, error) {
 	d.targetMutex.Lock()
@@ -1264,130 +1651,352 @@ func (d *Debugger) EvalVariableInScope(goid, frame, deferredCall int, symbol str
 	return s.EvalVariable(symbol, cfg)
 }
 
-// SetVariableInScope will set the value of the variable represented by
-// 'symbol' to the value given, in the given scope.
-func (d *Debugger) SetVariableInScope(goid, frame, deferredCall int, symbol, value string) error {
+// EvalVariablesInScope evaluates all exprs in the same scope, returning
+// for each one either its value or the error that evaluating it caused.
+// The returned error is only set if the scope could not be found.
+func (d *Debugger) EvalVariablesInScope(goid, frame, deferredCall int, exprs []string, cfg proc.LoadConfig) ([]*proc.Variable, []error, error) {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
 	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	return s.SetVariable(symbol, value)
+	vars := make([]*proc.Variable, len(exprs))
+	errs := make([]error, len(exprs))
+	for i := range exprs {
+		vars[i], errs[i] = s.EvalVariable(exprs[i], cfg)
+	}
+	return vars, errs, nil
 }
 
-// Goroutines will return a list of goroutines in the target process.
-func (d *Debugger) Goroutines(start, count int) ([]*proc.G, int, error) {
+// CallStringer calls the String or Error method of the value of expr,
+// evaluated in the topmost frame of goroutine goid, see proc.CallStringer.
+func (d *Debugger) CallStringer(goid, frame, deferredCall int, expr string, opts proc.StringerOptions) (string, error) {
+	if err := d.checkObserver(); err != nil {
+		return "", err
+	}
+	if frame != 0 || deferredCall != 0 {
+		return "", errors.New("String and Error methods can only be called in the topmost frame")
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
-	return proc.GoroutinesInfo(d.target, start, count)
+
+	g := d.target.SelectedGoroutine()
+	if goid > 0 {
+		var err error
+		g, err = proc.FindGoroutine(d.target, goid)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	d.setRunning(true)
+	defer d.setRunning(false)
+	return proc.CallStringer(d.target, g, expr, opts)
 }
 
-// Stacktrace returns a list of Stackframes for the given goroutine. The
-// length of the returned list will be min(stack_len, depth).
-// If 'full' is true, then local vars, function args, etc will be returned as well.
-func (d *Debugger) Stacktrace(goroutineID, depth int, opts api.StacktraceOptions) ([]proc.Stackframe, error) {
+// LoadArrayRange loads elements [start, end) of the slice or array of type
+// typ at address addr, see proc.(*EvalScope).LoadArrayRange.
+func (d *Debugger) LoadArrayRange(goid, frame, deferredCall int, addr uint64, typ string, start, end int64, cfg proc.LoadConfig) (*proc.Variable, error) {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
-	if _, err := d.target.Valid(); err != nil {
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
 		return nil, err
 	}
+	return s.LoadArrayRange(addr, typ, start, end, cfg)
+}
 
-	g, err := proc.FindGoroutine(d.target, goroutineID)
+// FilterMap evaluates expr, which must be a map, in the scope provided and
+// loads the entries selected by filter, see proc.(*EvalScope).FilterMap.
+func (d *Debugger) FilterMap(goid, frame, deferredCall int, expr string, filter proc.MapFilter, cfg proc.LoadConfig) (*proc.Variable, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
 	if err != nil {
 		return nil, err
 	}
+	return s.FilterMap(expr, filter, cfg)
+}
 
-	if g == nil {
-		return proc.ThreadStacktrace(d.target.CurrentThread(), depth)
-	} else {
-		return g.Stacktrace(depth, proc.StacktraceOptions(opts))
+// SearchVariable evaluates expr in the scope provided and returns the
+// paths of the values reachable from it that are equal to value, see
+// proc.(*EvalScope).SearchVariable.
+func (d *Debugger) SearchVariable(goid, frame, deferredCall int, expr, value string, cfg proc.LoadConfig) ([]string, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
 	}
+	return s.SearchVariable(expr, value, cfg)
 }
 
-// Ancestors returns the stacktraces for the ancestors of a goroutine.
-func (d *Debugger) Ancestors(goroutineID, numAncestors, depth int) ([]api.Ancestor, error) {
+// ObjectGraph evaluates exprs in the scope provided and returns the graph
+// of the objects reachable from them, see proc.(*EvalScope).ObjectGraph.
+func (d *Debugger) ObjectGraph(goid, frame, deferredCall int, exprs []string, cfg proc.LoadConfig) (*proc.ObjectGraph, error) {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
-	if _, err := d.target.Valid(); err != nil {
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
 		return nil, err
 	}
+	return s.ObjectGraph(exprs, cfg)
+}
 
-	g, err := proc.FindGoroutine(d.target, goroutineID)
+// FindReferrers returns the objects containing pointers to the object at
+// addr, see proc.(*Target).FindReferrers.
+func (d *Debugger) FindReferrers(addr uint64) (*proc.Referrers, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.FindReferrers(addr)
+}
+
+// HeapHistogram returns the number of heap objects of each type and the
+// memory they use, see proc.(*Target).HeapHistogram.
+func (d *Debugger) HeapHistogram() ([]proc.HeapHistogramEntry, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.HeapHistogram()
+}
+
+// ViewAs evaluates expr in the scope provided and returns a variable of
+// type typ at the address it evaluates to, see proc.(*EvalScope).ViewAs.
+func (d *Debugger) ViewAs(goid, frame, deferredCall int, expr, typ string, cfg proc.LoadConfig) (*proc.Variable, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
 	if err != nil {
 		return nil, err
 	}
-	if g == nil {
-		return nil, errors.New("no selected goroutine")
-	}
+	return s.ViewAs(expr, typ, cfg)
+}
 
-	ancestors, err := proc.Ancestors(d.target, g, numAncestors)
+// ChanWaiters evaluates expr, which must be a channel, in the scope
+// provided and returns the goroutines waiting to receive from it and the
+// goroutines waiting to send to it.
+func (d *Debugger) ChanWaiters(goid, frame, deferredCall int, expr string) (*proc.ChanWaiters, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
 	if err != nil {
 		return nil, err
 	}
-
-	r := make([]api.Ancestor, len(ancestors))
-	for i := range ancestors {
-		r[i].ID = ancestors[i].ID
-		if ancestors[i].Unreadable != nil {
-			r[i].Unreadable = ancestors[i].Unreadable.Error()
-			continue
-		}
-		frames, err := ancestors[i].Stack(depth)
-		if err != nil {
-			r[i].Unreadable = fmt.Sprintf("could not read ancestor stacktrace: %v", err)
-			continue
-		}
-		r[i].Stack, err = d.convertStacktrace(frames, nil)
-		if err != nil {
-			r[i].Unreadable = fmt.Sprintf("could not read ancestor stacktrace: %v", err)
-		}
+	ch, err := s.EvalVariable(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, err
 	}
-	return r, nil
+	return proc.ChannelWaiters(ch)
 }
 
-// ConvertStacktrace converts a slice of proc.Stackframe into a slice of
-// api.Stackframe, loading local variables and arguments of each frame if
-// cfg is not nil.
-func (d *Debugger) ConvertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+// MutexInfo evaluates expr, which must be a sync.Mutex or a sync.RWMutex,
+// in the scope provided and returns its state.
+func (d *Debugger) MutexInfo(goid, frame, deferredCall int, expr string) (*proc.MutexState, error) {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
-	return d.convertStacktrace(rawlocs, cfg)
-}
 
-func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
-	locations := make([]api.Stackframe, 0, len(rawlocs))
-	for i := range rawlocs {
-		frame := api.Stackframe{
-			Location: api.ConvertLocation(rawlocs[i].Call),
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.EvalVariable(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return proc.MutexInfo(v)
+}
+
+// InterfaceItab evaluates expr, which must be an interface, in the scope
+// provided and returns its itab.
+func (d *Debugger) InterfaceItab(goid, frame, deferredCall int, expr string) (*proc.Itab, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.EvalVariable(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return proc.InterfaceItab(v)
+}
+
+// ContextChain evaluates expr, which must be a context.Context, in the
+// scope provided and returns the chain of contexts it wraps, see
+// proc.ContextChain.
+func (d *Debugger) ContextChain(goid, frame, deferredCall int, expr string, cfg proc.LoadConfig) ([]proc.ContextLayer, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.EvalVariable(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return proc.ContextChain(v, cfg)
+}
+
+// SetVariableInScope will set the value of the variable represented by
+// 'symbol' to the value given, in the given scope.
+func (d *Debugger) SetVariableInScope(goid, frame, deferredCall int, symbol, value string) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return err
+	}
+	return s.SetVariable(symbol, value)
+}
+
+// Goroutines will return a list of goroutines in the target process.
+func (d *Debugger) Goroutines(start, count int) ([]*proc.G, int, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.GoroutinesInfo(d.target, start, count)
+}
+
+// Stacktrace returns a list of Stackframes for the given goroutine. The
+// length of the returned list will be min(stack_len, depth).
+// If 'full' is true, then local vars, function args, etc will be returned as well.
+func (d *Debugger) Stacktrace(goroutineID, depth int, opts api.StacktraceOptions) ([]proc.Stackframe, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.stacktrace(goroutineID, depth, opts)
+}
+
+func (d *Debugger) stacktrace(goroutineID, depth int, opts api.StacktraceOptions) ([]proc.Stackframe, error) {
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
 
-			FrameOffset:        rawlocs[i].FrameOffset(),
-			FramePointerOffset: rawlocs[i].FramePointerOffset(),
+	g, err := proc.FindGoroutine(d.target, goroutineID)
+	if err != nil {
+		return nil, err
+	}
 
-			Defers: d.convertDefers(rawlocs[i].Defers),
+	if g == nil {
+		return proc.ThreadStacktrace(d.target.CurrentThread(), depth)
+	} else {
+		return g.Stacktrace(depth, proc.StacktraceOptions(opts))
+	}
+}
 
-			Bottom: rawlocs[i].Bottom,
+// Ancestors returns the stacktraces for the ancestors of a goroutine.
+func (d *Debugger) Ancestors(goroutineID, numAncestors, depth int) ([]api.Ancestor, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
+
+	g, err := proc.FindGoroutine(d.target, goroutineID)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errors.New("no selected goroutine")
+	}
+
+	ancestors, err := proc.Ancestors(d.target, g, numAncestors)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]api.Ancestor, len(ancestors))
+	for i := range ancestors {
+		r[i].ID = ancestors[i].ID
+		if ancestors[i].Unreadable != nil {
+			r[i].Unreadable = ancestors[i].Unreadable.Error()
+			continue
 		}
-		if rawlocs[i].Err != nil {
-			frame.Err = rawlocs[i].Err.Error()
+		frames, err := ancestors[i].Stack(depth)
+		if err != nil {
+			r[i].Unreadable = fmt.Sprintf("could not read ancestor stacktrace: %v", err)
+			continue
 		}
-		if cfg != nil && rawlocs[i].Current.Fn != nil {
-			var err error
-			scope := proc.FrameToScope(d.target.BinInfo(), d.target.Memory(), nil, rawlocs[i:]...)
-			locals, err := scope.LocalVariables(*cfg)
-			if err != nil {
-				return nil, err
-			}
-			arguments, err := scope.FunctionArguments(*cfg)
-			if err != nil {
-				return nil, err
-			}
+		r[i].Stack, err = d.convertStacktrace(frames, nil)
+		if err != nil {
+			r[i].Unreadable = fmt.Sprintf("could not read ancestor stacktrace: %v", err)
+		}
+	}
+	return r, nil
+}
+
+// GoroutineTree returns the tree of all goroutines and of their exited
+// ancestors, with the stack of the parent at the time each goroutine was
+// created, up to depth frames, see proc.GoroutineTree.
+func (d *Debugger) GoroutineTree(depth int) ([]api.GoroutineTreeNode, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
+
+	gs, _, err := proc.GoroutinesInfo(d.target, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := proc.GoroutineTree(d.target, gs)
+	if err != nil {
+		return nil, err
+	}
 
-			frame.Locals = api.ConvertVars(locals)
-			frame.Arguments = api.ConvertVars(arguments)
+	r := make([]api.GoroutineTreeNode, len(nodes))
+	for i := range nodes {
+		r[i].ID = nodes[i].ID
+		r[i].ParentID = nodes[i].ParentID
+		if nodes[i].G != nil {
+			r[i].Goroutine = api.ConvertGoroutine(nodes[i].G)
+		}
+		if nodes[i].Creation == nil || depth <= 0 {
+			continue
+		}
+		frames, err := nodes[i].Creation.Stack(depth)
+		if err != nil {
+			r[i].Unreadable = fmt.Sprintf("could not read creation stacktrace: %v", err)
+			continue
+		}
+		r[i].CreationStack, err = d.convertStacktrace(frames, nil)
+		if err != nil {
+			r[i].Unreadable = fmt.Sprintf("could not read creation stacktrace: %v", err)
+		}
+	}
+	return r, nil
+}
+
+// ConvertStacktrace converts a slice of proc.Stackframe into a slice of
+// api.Stackframe, loading local variables and arguments of each frame if
+// cfg is not nil.
+func (d *Debugger) ConvertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.convertStacktrace(rawlocs, cfg)
+}
+
+func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+	locations := make([]api.Stackframe, 0, len(rawlocs))
+	for i := range rawlocs {
+		frame, err := d.convertStackframe(rawlocs[i:], cfg)
+		if err != nil {
+			return nil, err
 		}
 		locations = append(locations, frame)
 	}
@@ -1395,6 +2004,72 @@ func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadCo
 	return locations, nil
 }
 
+// convertStackframe converts the first frame of rawlocs, the frames after
+// it are needed to evaluate its variables, which are loaded if cfg is not
+// nil.
+func (d *Debugger) convertStackframe(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) (api.Stackframe, error) {
+	frame := api.Stackframe{
+		Location: api.ConvertLocation(rawlocs[0].Call),
+
+		FrameOffset:        rawlocs[0].FrameOffset(),
+		FramePointerOffset: rawlocs[0].FramePointerOffset(),
+
+		Defers: d.convertDefers(rawlocs[0].Defers),
+
+		Bottom: rawlocs[0].Bottom,
+	}
+	if rawlocs[0].Err != nil {
+		frame.Err = rawlocs[0].Err.Error()
+	}
+	if frame.Function == nil {
+		// Frames belonging to C functions without debug info can still be
+		// described using the symbol table.
+		if name, addr := d.target.BinInfo().PCToSymbol(rawlocs[0].Call.PC); name != "" {
+			frame.Function = &api.Function{Name_: name, Value: addr}
+		}
+	}
+	if cfg != nil && rawlocs[0].Current.Fn != nil {
+		scope := proc.FrameToScope(d.target.BinInfo(), d.target.Memory(), nil, rawlocs...)
+		locals, err := scope.LocalVariables(*cfg)
+		if err != nil {
+			return frame, err
+		}
+		arguments, err := scope.FunctionArguments(*cfg)
+		if err != nil {
+			return frame, err
+		}
+
+		frame.Locals = d.prettyPrinters.ConvertVars(locals)
+		frame.Arguments = d.prettyPrinters.ConvertVars(arguments)
+	}
+	return frame, nil
+}
+
+// StackframeDetails returns frame number frame of the stack of goroutine
+// goroutineID with its local variables and function arguments, loaded with
+// cfg. It lets clients that fetched a stack trace without variables load
+// them for the frames they need.
+func (d *Debugger) StackframeDetails(goroutineID, frame int, opts api.StacktraceOptions, cfg proc.LoadConfig) (*api.Stackframe, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if frame < 0 {
+		return nil, errors.New("negative frame number")
+	}
+	rawlocs, err := d.stacktrace(goroutineID, frame, opts)
+	if err != nil {
+		return nil, err
+	}
+	if frame >= len(rawlocs) {
+		return nil, fmt.Errorf("frame %d does not exist, the stack has %d frames", frame, len(rawlocs))
+	}
+	r, err := d.convertStackframe(rawlocs[frame:], &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 func (d *Debugger) convertDefers(defers []*proc.Defer) []api.Defer {
 	r := make([]api.Defer, len(defers))
 	for i := range defers {
@@ -1540,6 +2215,9 @@ func (d *Debugger) FindThreadReturnValues(id int, cfg proc.LoadConfig) ([]*proc.
 
 // Checkpoint will set a checkpoint specified by the locspec.
 func (d *Debugger) Checkpoint(where string) (int, error) {
+	if err := d.checkObserver(); err != nil {
+		return 0, err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 	return d.target.Checkpoint(where)
@@ -1554,11 +2232,299 @@ func (d *Debugger) Checkpoints() ([]proc.Checkpoint, error) {
 
 // ClearCheckpoint will clear the checkpoint of the given ID.
 func (d *Debugger) ClearCheckpoint(id int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 	return d.target.ClearCheckpoint(id)
 }
 
+// RunToEvent resets the recording to the start of rr event number event.
+func (d *Debugger) RunToEvent(event uint64) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	if recorded, _ := d.target.Recorded(); !recorded {
+		return proc.ErrNotRecorded
+	}
+	d.history.reset()
+	// rr interprets restart positions that do not start with 'c' as event
+	// numbers.
+	return d.target.Restart(strconv.FormatUint(event, 10))
+}
+
+// SetSignalPolicy changes how the signal called name is handled, name can
+// be either the name of the signal or its number.
+func (d *Debugger) SetSignalPolicy(name string, policy proc.SignalPolicy) (api.SignalPolicy, error) {
+	if err := d.checkObserver(); err != nil {
+		return api.SignalPolicy{}, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	goos := d.target.BinInfo().GOOS
+	sig, err := proc.SignalNumber(goos, name)
+	if err != nil {
+		return api.SignalPolicy{}, err
+	}
+	if err := d.target.SetSignalPolicy(sig, policy); err != nil {
+		return api.SignalPolicy{}, err
+	}
+	return api.ConvertSignalPolicy(goos, sig, policy), nil
+}
+
+// SignalPolicies returns the list of signals whose handling policy was
+// changed with SetSignalPolicy.
+func (d *Debugger) SignalPolicies() []api.SignalPolicy {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	goos := d.target.BinInfo().GOOS
+	policies := d.target.SignalPolicies()
+	r := make([]api.SignalPolicy, 0, len(policies))
+	for _, sig := range proc.SortedSignals(policies) {
+		r = append(r, api.ConvertSignalPolicy(goos, sig, policies[sig]))
+	}
+	return r
+}
+
+// Timers returns the timers pending in the target process and an
+// approximation of the current value of its monotonic clock.
+func (d *Debugger) Timers() ([]api.Timer, int64, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	timers, now, err := d.target.Timers()
+	if err != nil {
+		return nil, 0, err
+	}
+	return api.ConvertTimers(timers), now, nil
+}
+
+// Dump writes a core file of the target process to dest, as described by
+// opts.
+func (d *Debugger) Dump(dest string, opts api.DumpOptions) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	fh, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	var flags proc.DumpFlags
+	if opts.Selective {
+		flags |= proc.DumpSelective
+	}
+	if opts.Minidump {
+		flags |= proc.DumpMinidump
+	}
+	if opts.Compress {
+		flags |= proc.DumpCompress
+	}
+	if opts.Sparse {
+		flags |= proc.DumpSparse
+	}
+	if err := d.target.Dump(fh, flags); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
+
+// RawCall calls the function at addr on the current thread, or executes
+// system call number addr if syscall is true, passing args without any
+// type checking, see proc.(*Target).RawCall.
+func (d *Debugger) RawCall(addr uint64, args []uint64, syscall bool) (*api.RawCallResult, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	d.setRunning(true)
+	defer d.setRunning(false)
+
+	var r *proc.RawCallResult
+	var err error
+	if syscall {
+		d.log.Debugf("raw syscall %d %#x", addr, args)
+		r, err = d.target.RawSyscall(addr, args)
+	} else {
+		d.log.Debugf("raw call %#x %#x", addr, args)
+		r, err = d.target.RawCall(addr, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertRawCallResult(r), nil
+}
+
+// PatchFunction changes the code of the function fnName, if code is nil
+// the function is changed to immediately return vals, see
+// proc.(*Target).PatchFunctionReturn, otherwise its entry point is changed
+// to jump to code, see proc.(*Target).PatchFunctionCode.
+func (d *Debugger) PatchFunction(fnName string, vals []string, code []byte) (*api.FunctionPatch, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	fn := d.target.BinInfo().LookupFunc[fnName]
+	if fn == nil {
+		return nil, fmt.Errorf("could not find function %s", fnName)
+	}
+
+	// Allocating memory for the patch resumes the target.
+	d.setRunning(true)
+	defer d.setRunning(false)
+
+	var patch *proc.FunctionPatch
+	var err error
+	if code != nil {
+		d.log.Debugf("patching %s with %d bytes of code", fnName, len(code))
+		patch, err = d.target.PatchFunctionCode(fn, code)
+	} else {
+		d.log.Debugf("patching %s to return %v", fnName, vals)
+		patch, err = d.target.PatchFunctionReturn(fn, vals)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertFunctionPatch(patch), nil
+}
+
+// UnpatchFunction reverts the function patch with the given ID.
+func (d *Debugger) UnpatchFunction(id int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.UnpatchFunction(id)
+}
+
+// FunctionPatches returns the list of function patches currently applied.
+func (d *Debugger) FunctionPatches() []*api.FunctionPatch {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	patches := d.target.FunctionPatches()
+	r := make([]*api.FunctionPatch, len(patches))
+	for i := range patches {
+		r[i] = api.ConvertFunctionPatch(patches[i])
+	}
+	return r
+}
+
+// GuardMemory changes the protection of the pages containing the memory
+// range [addr, addr+size) to prot, see proc.(*Target).GuardMemory.
+func (d *Debugger) GuardMemory(addr, size uint64, prot proc.MemoryProtection) (*api.MemoryGuard, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	// Calling mprotect resumes the target.
+	d.setRunning(true)
+	defer d.setRunning(false)
+
+	d.log.Debugf("guarding %#x-%#x (%s)", addr, addr+size, prot)
+	guard, err := d.target.GuardMemory(addr, size, prot)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertMemoryGuard(guard), nil
+}
+
+// UnguardMemory restores the protection of the memory guarded by the
+// guard with the given ID.
+func (d *Debugger) UnguardMemory(id int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	d.setRunning(true)
+	defer d.setRunning(false)
+
+	return d.target.UnguardMemory(id)
+}
+
+// MemoryGuards returns the list of memory guards.
+func (d *Debugger) MemoryGuards() []*api.MemoryGuard {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	guards := d.target.MemoryGuards()
+	r := make([]*api.MemoryGuard, len(guards))
+	for i := range guards {
+		r[i] = api.ConvertMemoryGuard(guards[i])
+	}
+	return r
+}
+
+// EnableBranchTrace starts, or stops if enable is false, recording the
+// branches executed by the target, see proc.(*Target).EnableBranchTrace.
+func (d *Debugger) EnableBranchTrace(enable bool) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.EnableBranchTrace(enable)
+}
+
+// BranchHistory returns the last count branches executed by the thread
+// with the specified ID, or by the current thread if threadID is 0.
+func (d *Debugger) BranchHistory(threadID, count int) ([]api.Branch, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
+
+	thread := d.target.CurrentThread()
+	if threadID != 0 {
+		var ok bool
+		thread, ok = d.target.FindThread(threadID)
+		if !ok {
+			return nil, fmt.Errorf("could not find thread %d", threadID)
+		}
+	}
+	branches, err := d.target.BranchHistory(thread, count)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]api.Branch, len(branches))
+	for i := range branches {
+		r[i] = api.Branch{From: api.ConvertLocation(branches[i].From), To: api.ConvertLocation(branches[i].To)}
+	}
+	return r, nil
+}
+
+// Recorders returns the record and replay engines that can be used as
+// backends.
+func (d *Debugger) Recorders() []api.Recorder {
+	recs := proc.Recorders()
+	r := make([]api.Recorder, len(recs))
+	for i := range recs {
+		r[i] = api.ConvertRecorder(recs[i])
+	}
+	return r
+}
+
+// ListImages returns all the images of the target, starting with the
+// executable file.
+func (d *Debugger) ListImages() []*proc.Image {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.BinInfo().Images
+}
+
 // ListDynamicLibraries returns a list of loaded dynamic libraries.
 func (d *Debugger) ListDynamicLibraries() []*proc.Image {
 	d.targetMutex.Lock()
@@ -1586,24 +2552,42 @@ func (d *Debugger) ExamineMemory(address uint64, length int) ([]byte, error) {
 	return data, nil
 }
 
-func (d *Debugger) GetVersion(out *api.GetVersionOut) error {
+// WriteMemory writes data to the memory of the target at address.
+func (d *Debugger) WriteMemory(address uint64, data []byte) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Memory().WriteMemory(address, data); err != nil {
+		return err
+	}
+	// The cached goroutines could be stale.
+	d.target.ClearAllGCache()
+	return nil
+}
+
+// backendName returns the name of the backend in use, "core" for core
+// files.
+func (d *Debugger) backendName() string {
 	if d.config.CoreFile != "" {
-		if d.config.Backend == "rr" {
-			out.Backend = "rr"
-		} else {
-			out.Backend = "core"
+		if _, ok := proc.LookupRecorder(d.config.Backend); ok {
+			return d.config.Backend
 		}
-	} else {
-		if d.config.Backend == "default" {
-			if runtime.GOOS == "darwin" {
-				out.Backend = "lldb"
-			} else {
-				out.Backend = "native"
-			}
-		} else {
-			out.Backend = d.config.Backend
+		return "core"
+	}
+	if d.config.Backend == "default" {
+		if defaultBackendIsLLDB() {
+			return "lldb"
 		}
+		return "native"
 	}
+	return d.config.Backend
+}
+
+func (d *Debugger) GetVersion(out *api.GetVersionOut) error {
+	out.Backend = d.backendName()
 
 	if !d.isRecording() && !d.isRunning() {
 		out.TargetGoVersion = d.target.BinInfo().Producer()
@@ -1626,6 +2610,9 @@ func (d *Debugger) ListPackagesBuildInfo(includeFiles bool) []*proc.PackageBuild
 
 // StopRecording stops a recording (if one is in progress)
 func (d *Debugger) StopRecording() error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
 	d.recordMutex.Lock()
 	defer d.recordMutex.Unlock()
 	if d.stopRecording == nil {
@@ -1,13 +1,21 @@
 package debugger
 
 import (
+	"errors"
 	"fmt"
+	"go/constant"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	"github.com/go-delve/delve/pkg/gobuild"
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/gdbserial"
+	"github.com/go-delve/delve/pkg/proc/native"
 	protest "github.com/go-delve/delve/pkg/proc/test"
 	"github.com/go-delve/delve/service/api"
 )
@@ -68,3 +76,223 @@ func TestDebugger_LaunchInvalidFormat(t *testing.T) {
 		t.Fatalf("expected error \"%s\" got \"%v\"", api.ErrNotExecutable, err)
 	}
 }
+
+func TestPrettyPrinters(t *testing.T) {
+	f, err := ioutil.TempFile("", "prettyprinters*.star")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, `
+def decimal(v):
+	if v.exp < 0:
+		return None
+	return str(v.value) + "0" * v.exp
+
+pretty_printer("main.Decimal", decimal)
+`)
+	f.Close()
+
+	pps, err := loadPrettyPrinters([]string{f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intType := &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{Name: "int", ByteSize: 8, ReflectKind: reflect.Int}}}
+	decimal := func(value, exp int64) *proc.Variable {
+		return &proc.Variable{
+			Name:      "d",
+			Kind:      reflect.Struct,
+			DwarfType: &godwarf.StructType{CommonType: godwarf.CommonType{Name: "main.Decimal"}, StructName: "main.Decimal"},
+			Len:       2,
+			Children: []proc.Variable{
+				{Name: "value", Kind: reflect.Int, DwarfType: intType, Value: constant.MakeInt64(value)},
+				{Name: "exp", Kind: reflect.Int, DwarfType: intType, Value: constant.MakeInt64(exp)},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		v           *proc.Variable
+		value, line string
+	}{
+		{decimal(12, 2), "1200", "main.Decimal 1200"},
+		{decimal(12, -1), "", "main.Decimal {value: 12, exp: -1}"},
+	} {
+		if v := pps.VariableValueAsString(tc.v); v != tc.value {
+			t.Errorf("VariableValueAsString: got %q expected %q", v, tc.value)
+		}
+		if line := pps.ConvertVar(tc.v).SinglelineString(); line != tc.line {
+			t.Errorf("SinglelineString: got %q expected %q", line, tc.line)
+		}
+	}
+
+	// Pretty printers are only used by the Debugger that loaded them.
+	if line := api.ConvertVar(decimal(12, 2)).SinglelineString(); line != "main.Decimal {value: 12, exp: 2}" {
+		t.Errorf("SinglelineString without pretty printers: got %q", line)
+	}
+}
+
+func TestValueHistory(t *testing.T) {
+	point := func(x, y string) api.Variable {
+		return api.Variable{
+			Name: "p",
+			Kind: reflect.Struct,
+			Children: []api.Variable{
+				{Name: "X", Kind: reflect.Int, Value: x},
+				{Name: "Y", Kind: reflect.Int, Value: y},
+			},
+		}
+	}
+
+	var h valueHistory
+
+	v := point("1", "2")
+	h.mark("p", &v)
+	if v.Changed || v.Children[0].Changed || v.Children[1].Changed {
+		t.Errorf("variable marked as changed on first stop")
+	}
+
+	// Reporting a different value during the same stop is not a change.
+	v = point("3", "2")
+	h.mark("p", &v)
+	if v.Changed {
+		t.Errorf("variable marked as changed during the same stop")
+	}
+
+	h.resumed()
+	v = point("3", "4")
+	h.mark("p", &v)
+	if !v.Changed || v.Children[0].Changed || !v.Children[1].Changed {
+		t.Errorf("wrong changed flags after stop: %v %v %v", v.Changed, v.Children[0].Changed, v.Children[1].Changed)
+	}
+
+	h.resumed()
+	v = point("3", "4")
+	h.mark("p", &v)
+	if v.Changed || v.Children[0].Changed || v.Children[1].Changed {
+		t.Errorf("unchanged variable marked as changed")
+	}
+
+	h.reset()
+	h.resumed()
+	v = point("5", "6")
+	h.mark("p", &v)
+	if v.Changed {
+		t.Errorf("variable marked as changed after reset")
+	}
+
+	// Values not reported during a stop are forgotten.
+	h.resumed()
+	h.resumed()
+	if len(h.values) != 0 {
+		t.Errorf("values not pruned: %d left", len(h.values))
+	}
+	v = point("7", "8")
+	h.mark("p", &v)
+	if v.Changed {
+		t.Errorf("variable marked as changed after it was forgotten")
+	}
+}
+
+func TestGoroutineFilterExpr(t *testing.T) {
+	ctx := &goroutineFilterCtx{
+		g: &proc.G{
+			ID:         7,
+			Status:     proc.Gwaiting,
+			WaitReason: 14, // chan receive
+			WaitSince:  1e9,
+		},
+		now: 121e9,
+	}
+	testCases := []struct {
+		expr string
+		res  bool
+	}{
+		{`id == 7`, true},
+		{`id != 7 || id > 5`, true},
+		{`!(id == 7)`, false},
+		{`waitreason == "chan receive"`, true},
+		{`contains(waitreason, "chan") && hasprefix(waitreason, "chan")`, true},
+		{`hassuffix(waitreason, "send")`, false},
+		{`matches(waitreason, "^chan (send|receive)$")`, true},
+		{`waitfor > "1m"`, true},
+		{`waitfor > "3m"`, false},
+		{`"2m" == waitfor`, true},
+		{`waitfor >= 120e9`, true},
+		{`id == 8 && waitfor > "invalid"`, false}, // not evaluated
+	}
+	for _, tc := range testCases {
+		expr, err := compileGoroutineFilter(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		res, err := evalGoroutineFilterBool(expr, ctx)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if res != tc.res {
+			t.Errorf("%s: got %v expected %v", tc.expr, res, tc.res)
+		}
+	}
+
+	for _, s := range []string{`foo == 1`, `id +`, `id + 1 == 2`, `id[0]`, `matches(waitreason, "(")`, `len(waitreason)`} {
+		if _, err := compileGoroutineFilter(s); err == nil {
+			t.Errorf("%s: no compile error", s)
+		}
+	}
+
+	for _, s := range []string{`id`, `id == "a"`, `waitfor > "1 minute"`, `running < true`, `contains(id, "1")`, `label[1] == ""`} {
+		expr, err := compileGoroutineFilter(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if _, err := evalGoroutineFilterBool(expr, ctx); err == nil {
+			t.Errorf("%s: no evaluation error", s)
+		}
+	}
+}
+
+func TestNativeFallback(t *testing.T) {
+	launched := &proc.Target{}
+	otherErr := errors.New("some other error")
+	called := false
+	fallback := func(p *proc.Target, err error) func() (*proc.Target, error) {
+		return func() (*proc.Target, error) {
+			called = true
+			return p, err
+		}
+	}
+
+	// Errors other than a missing debugserver are returned as is, without
+	// trying the native backend.
+	p, err := nativeFallback(nil, otherErr, fallback(launched, nil))
+	if p != nil || err != otherErr || called {
+		t.Errorf("wrong result for unrelated error: %v %v (fallback called: %v)", p, err, called)
+	}
+
+	// The native backend is used if debugserver is missing.
+	p, err = nativeFallback(nil, &gdbserial.ErrBackendUnavailable{}, fallback(launched, nil))
+	if p != launched || err != nil || !called {
+		t.Errorf("native backend not used: %v %v (fallback called: %v)", p, err, called)
+	}
+
+	// Errors of the native backend are returned.
+	called = false
+	p, err = nativeFallback(nil, &gdbserial.ErrBackendUnavailable{}, fallback(nil, otherErr))
+	if p != nil || err != otherErr || !called {
+		t.Errorf("native backend error not returned: %v %v (fallback called: %v)", p, err, called)
+	}
+
+	// If the native backend was not compiled in the original error is
+	// reported.
+	called = false
+	_, err = nativeFallback(nil, &gdbserial.ErrBackendUnavailable{}, fallback(nil, native.ErrNativeBackendDisabled))
+	_, want := betterGdbserialLaunchError(nil, &gdbserial.ErrBackendUnavailable{})
+	if err != want || !called {
+		t.Errorf("wrong error with the native backend disabled: %v, expected %v (fallback called: %v)", err, want, called)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/go-delve/delve/pkg/gobuild"
@@ -95,3 +97,55 @@ func TestDebugger_LaunchWithTTY(t *testing.T) {
 		t.Fatal("process open file list does not contain expected tty")
 	}
 }
+
+func TestDebugger_CaptureOutput(t *testing.T) {
+	// Ensure no env meddling is leftover from previous tests.
+	os.Setenv("GOOS", runtime.GOOS)
+	os.Setenv("GOARCH", runtime.GOARCH)
+
+	var backend string
+	protest.DefaultTestBackend(&backend)
+	if backend == "rr" {
+		t.Skip("not supported with rr")
+	}
+	fixturesDir := protest.FindFixturesDir()
+	exepath := filepath.Join(fixturesDir, "debugcapture")
+	if err := gobuild.GoBuild("debugcapture", []string{filepath.Join(fixturesDir, "redirect.go")}, fmt.Sprintf("-o %s", exepath)); err != nil {
+		t.Fatalf("go build error %v", err)
+	}
+	defer os.Remove(exepath)
+	d, err := New(&Config{CaptureOutput: "pipe", Backend: backend}, []string{exepath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Detach(true)
+
+	if err := d.WriteStdin([]byte("Capture test"), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Command(&api.DebuggerCommand{Name: api.Continue}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out string
+	var last uint64
+	for !strings.Contains(out, "\n") {
+		evs, err := d.WaitEvents(last, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(evs) == 0 {
+			t.Fatalf("timed out waiting for output, got %q", out)
+		}
+		for _, ev := range evs {
+			if ev.Kind == api.EventOutput && ev.Stream == "stdout" {
+				out += ev.Output
+			}
+			last = ev.ID
+		}
+	}
+	t.Logf("output %q", out)
+	if !strings.HasPrefix(out, "Capture test") {
+		t.Fatalf("wrong output %q", out)
+	}
+}
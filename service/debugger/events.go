@@ -0,0 +1,122 @@
+package debugger
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// maxEvents is the number of events retained by eventLog.
+const maxEvents = 100
+
+// ErrDetached is returned by WaitEvents after the debugger detached from
+// the target.
+var ErrDetached = errors.New("debugger detached")
+
+// eventLog records the last maxEvents events generated by the debugger, so
+// that every client can receive them regardless of which client caused
+// them, see WaitEvents.
+type eventLog struct {
+	mu     sync.Mutex
+	events []api.Event
+	lastID uint64
+	closed bool
+	// wait is closed, and replaced, every time a new event is added or the
+	// log is closed.
+	wait chan struct{}
+}
+
+func (el *eventLog) add(ev api.Event) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.lastID++
+	ev.ID = el.lastID
+	el.events = append(el.events, ev)
+	if len(el.events) > maxEvents {
+		el.events = append(el.events[:0], el.events[len(el.events)-maxEvents:]...)
+	}
+	el.wake()
+}
+
+func (el *eventLog) close() {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.closed = true
+	el.wake()
+}
+
+// wake wakes up all the goroutines waiting for events, must be called with
+// mu held.
+func (el *eventLog) wake() {
+	if el.wait != nil {
+		close(el.wait)
+		el.wait = nil
+	}
+}
+
+// after returns the events with an ID greater than id. If there are none
+// it also returns a channel that is closed when new events are added.
+func (el *eventLog) after(id uint64) ([]api.Event, <-chan struct{}, bool) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	var r []api.Event
+	for i := range el.events {
+		if el.events[i].ID > id {
+			r = append(r, el.events[i])
+		}
+	}
+	if len(r) > 0 || el.closed {
+		return r, nil, el.closed
+	}
+	if el.wait == nil {
+		el.wait = make(chan struct{})
+	}
+	return nil, el.wait, false
+}
+
+// WaitEvents returns the events with an ID greater than after, waiting for
+// one to happen if there are none. Waiting ends after timeout, if it is
+// not zero, in which case no events are returned.
+// Events are generated every time the target stops or exits after being
+// resumed, by any client, and when it loads new images. Only the last
+// maxEvents events are retained, clients can detect that they missed some
+// by looking at the event IDs, which are consecutive.
+func (d *Debugger) WaitEvents(after uint64, timeout time.Duration) ([]api.Event, error) {
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	for {
+		evs, wait, closed := d.events.after(after)
+		if len(evs) > 0 {
+			return evs, nil
+		}
+		if closed {
+			return nil, ErrDetached
+		}
+		select {
+		case <-wait:
+		case <-timeoutCh:
+			return nil, nil
+		}
+	}
+}
+
+// publishStop adds the events caused by the target stopping after being
+// resumed, nimages is the number of images the target had before it was
+// resumed. Must be called with targetMutex held.
+func (d *Debugger) publishStop(state *api.DebuggerState, nimages int) {
+	if images := d.target.BinInfo().Images; len(images) > nimages {
+		ev := api.Event{Kind: api.EventImagesLoaded}
+		for _, image := range images[nimages:] {
+			ev.Images = append(ev.Images, api.ConvertImage(image))
+		}
+		d.events.add(ev)
+	}
+	d.events.add(api.Event{Kind: api.EventStopped, State: state})
+	d.metrics.stopped(api.ConvertBreakpoints(d.breakpoints()))
+}
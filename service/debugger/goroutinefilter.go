@@ -0,0 +1,309 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// goroutineFilterCtx is the goroutine a filter expression is evaluated
+// against.
+type goroutineFilterCtx struct {
+	g *proc.G
+	// now is the current value of the runtime monotonic clock of the
+	// target, or zero if it is not known.
+	now int64
+}
+
+// goroutineFilterExpr is a compiled goroutine filter expression, see
+// api.ListGoroutinesFilter.
+type goroutineFilterExpr func(*goroutineFilterCtx) (constant.Value, error)
+
+// goroutineFilterProps are the properties of a goroutine that filter
+// expressions can refer to.
+var goroutineFilterProps = map[string]func(*goroutineFilterCtx) constant.Value{
+	"id": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeInt64(int64(ctx.g.ID))
+	},
+	"curloc": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeString(formatGoroutineLoc(ctx.g.CurrentLoc))
+	},
+	"userloc": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeString(formatGoroutineLoc(ctx.g.UserCurrent()))
+	},
+	"goloc": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeString(formatGoroutineLoc(ctx.g.Go()))
+	},
+	"startloc": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeString(formatGoroutineLoc(ctx.g.StartLoc()))
+	},
+	"fn": func(ctx *goroutineFilterCtx) constant.Value {
+		if fn := ctx.g.UserCurrent().Fn; fn != nil {
+			return constant.MakeString(fn.Name)
+		}
+		return constant.MakeString("")
+	},
+	"pkg": func(ctx *goroutineFilterCtx) constant.Value {
+		if fn := ctx.g.UserCurrent().Fn; fn != nil {
+			return constant.MakeString(fn.PackageName())
+		}
+		return constant.MakeString("")
+	},
+	"running": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeBool(ctx.g.Thread != nil)
+	},
+	"user": func(ctx *goroutineFilterCtx) constant.Value {
+		return constant.MakeBool(!ctx.g.System())
+	},
+	"waitreason": func(ctx *goroutineFilterCtx) constant.Value {
+		if !goroutineBlocked(ctx.g) {
+			return constant.MakeString("")
+		}
+		return constant.MakeString(api.WaitReasonString(ctx.g.WaitReason))
+	},
+	"waitfor": func(ctx *goroutineFilterCtx) constant.Value {
+		if !goroutineBlocked(ctx.g) || ctx.g.WaitSince == 0 || ctx.now == 0 {
+			return constant.MakeInt64(0)
+		}
+		return constant.MakeInt64(ctx.now - ctx.g.WaitSince)
+	},
+}
+
+func goroutineBlocked(g *proc.G) bool {
+	return (g.Status == proc.Gwaiting || g.Status == proc.Gsyscall) && g.WaitReason != 0
+}
+
+// goroutineFilterFuncs are the functions that filter expressions can call.
+var goroutineFilterFuncs = map[string]func(a, b string) bool{
+	"contains":  strings.Contains,
+	"hasprefix": strings.HasPrefix,
+	"hassuffix": strings.HasSuffix,
+}
+
+// compileGoroutineFilter parses the filter expression expr.
+func compileGoroutineFilter(expr string) (goroutineFilterExpr, error) {
+	t, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse filter expression %q: %v", expr, err)
+	}
+	return compileGoroutineFilterNode(t)
+}
+
+func compileGoroutineFilterNode(t ast.Expr) (goroutineFilterExpr, error) {
+	switch t := t.(type) {
+	case *ast.ParenExpr:
+		return compileGoroutineFilterNode(t.X)
+
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(t.Value, t.Kind, 0)
+		if v.Kind() == constant.Unknown {
+			return nil, fmt.Errorf("unsupported literal %s", t.Value)
+		}
+		return func(*goroutineFilterCtx) (constant.Value, error) { return v, nil }, nil
+
+	case *ast.Ident:
+		switch t.Name {
+		case "true", "false":
+			v := constant.MakeBool(t.Name == "true")
+			return func(*goroutineFilterCtx) (constant.Value, error) { return v, nil }, nil
+		}
+		prop, ok := goroutineFilterProps[t.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown goroutine property %q", t.Name)
+		}
+		return func(ctx *goroutineFilterCtx) (constant.Value, error) { return prop(ctx), nil }, nil
+
+	case *ast.IndexExpr:
+		if ident, ok := t.X.(*ast.Ident); !ok || ident.Name != "label" {
+			return nil, errors.New("only label can be indexed")
+		}
+		key, err := compileGoroutineFilterNode(t.Index)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx *goroutineFilterCtx) (constant.Value, error) {
+			k, err := key(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if k.Kind() != constant.String {
+				return nil, errors.New("label name must be a string")
+			}
+			return constant.MakeString(ctx.g.Labels()[constant.StringVal(k)]), nil
+		}, nil
+
+	case *ast.UnaryExpr:
+		if t.Op != token.NOT {
+			return nil, fmt.Errorf("operator %s not supported", t.Op)
+		}
+		x, err := compileGoroutineFilterNode(t.X)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx *goroutineFilterCtx) (constant.Value, error) {
+			v, err := evalGoroutineFilterBool(x, ctx)
+			if err != nil {
+				return nil, err
+			}
+			return constant.MakeBool(!v), nil
+		}, nil
+
+	case *ast.BinaryExpr:
+		return compileGoroutineFilterBinary(t)
+
+	case *ast.CallExpr:
+		return compileGoroutineFilterCall(t)
+	}
+	return nil, fmt.Errorf("expression %T not supported", t)
+}
+
+func compileGoroutineFilterBinary(t *ast.BinaryExpr) (goroutineFilterExpr, error) {
+	x, err := compileGoroutineFilterNode(t.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := compileGoroutineFilterNode(t.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Op {
+	case token.LAND, token.LOR:
+		return func(ctx *goroutineFilterCtx) (constant.Value, error) {
+			xv, err := evalGoroutineFilterBool(x, ctx)
+			if err != nil {
+				return nil, err
+			}
+			if xv == (t.Op == token.LOR) {
+				return constant.MakeBool(xv), nil
+			}
+			yv, err := evalGoroutineFilterBool(y, ctx)
+			if err != nil {
+				return nil, err
+			}
+			return constant.MakeBool(yv), nil
+		}, nil
+
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return func(ctx *goroutineFilterCtx) (constant.Value, error) {
+			xv, err := x(ctx)
+			if err != nil {
+				return nil, err
+			}
+			yv, err := y(ctx)
+			if err != nil {
+				return nil, err
+			}
+			xv, yv, err = convertGoroutineFilterOperands(xv, yv)
+			if err != nil {
+				return nil, err
+			}
+			if xv.Kind() == constant.Bool && t.Op != token.EQL && t.Op != token.NEQ {
+				return nil, fmt.Errorf("operator %s not defined on booleans", t.Op)
+			}
+			return constant.MakeBool(constant.Compare(xv, t.Op, yv)), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("operator %s not supported", t.Op)
+}
+
+// convertGoroutineFilterOperands converts the operands of a comparison to
+// the same kind, strings compared with numbers are parsed as durations.
+func convertGoroutineFilterOperands(x, y constant.Value) (constant.Value, constant.Value, error) {
+	isNumber := func(v constant.Value) bool {
+		return v.Kind() == constant.Int || v.Kind() == constant.Float
+	}
+	duration := func(v constant.Value) (constant.Value, error) {
+		d, err := time.ParseDuration(constant.StringVal(v))
+		if err != nil {
+			return nil, err
+		}
+		return constant.MakeInt64(int64(d)), nil
+	}
+	var err error
+	switch {
+	case isNumber(x) && y.Kind() == constant.String:
+		y, err = duration(y)
+	case x.Kind() == constant.String && isNumber(y):
+		x, err = duration(x)
+	case isNumber(x) && isNumber(y):
+		// ok
+	case x.Kind() != y.Kind():
+		err = fmt.Errorf("mismatched types in comparison of %s and %s", x, y)
+	}
+	return x, y, err
+}
+
+func compileGoroutineFilterCall(t *ast.CallExpr) (goroutineFilterExpr, error) {
+	ident, ok := t.Fun.(*ast.Ident)
+	if !ok {
+		return nil, errors.New("function call not supported")
+	}
+	fn, ok := goroutineFilterFuncs[ident.Name]
+	if !ok && ident.Name != "matches" {
+		return nil, fmt.Errorf("unknown function %q", ident.Name)
+	}
+	if len(t.Args) != 2 {
+		return nil, fmt.Errorf("wrong number of arguments to %s", ident.Name)
+	}
+	x, err := compileGoroutineFilterNode(t.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := compileGoroutineFilterNode(t.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if ident.Name == "matches" {
+		fn = func(s, expr string) bool {
+			// An invalid regular expression does not match anything.
+			re, _ := regexp.Compile(expr)
+			return re != nil && re.MatchString(s)
+		}
+		if lit, ok := t.Args[1].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			// Regular expressions are usually literals, compile them once.
+			yv, _ := y(nil)
+			re, err := regexp.Compile(constant.StringVal(yv))
+			if err != nil {
+				return nil, err
+			}
+			fn = func(s, _ string) bool { return re.MatchString(s) }
+		}
+	}
+
+	return func(ctx *goroutineFilterCtx) (constant.Value, error) {
+		xv, err := x(ctx)
+		if err != nil {
+			return nil, err
+		}
+		yv, err := y(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if xv.Kind() != constant.String || yv.Kind() != constant.String {
+			return nil, fmt.Errorf("arguments of %s must be strings", ident.Name)
+		}
+		return constant.MakeBool(fn(constant.StringVal(xv), constant.StringVal(yv))), nil
+	}, nil
+}
+
+func evalGoroutineFilterBool(expr goroutineFilterExpr, ctx *goroutineFilterCtx) (bool, error) {
+	v, err := expr(ctx)
+	if err != nil {
+		return false, err
+	}
+	if v.Kind() != constant.Bool {
+		return false, fmt.Errorf("%s is not a boolean", v)
+	}
+	return constant.BoolVal(v), nil
+}
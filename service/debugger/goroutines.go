@@ -0,0 +1,160 @@
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// FilterGoroutines returns the goroutines in gs that satisfy all filters.
+func (d *Debugger) FilterGoroutines(gs []*proc.G, filters []api.ListGoroutinesFilter) ([]*proc.G, error) {
+	if len(filters) == 0 {
+		return gs, nil
+	}
+	exprs := make([]goroutineFilterExpr, len(filters))
+	hasExprs := false
+	for i := range filters {
+		if filters[i].Kind == api.GoroutineExpr {
+			var err error
+			exprs[i], err = compileGoroutineFilter(filters[i].Arg)
+			if err != nil {
+				return nil, err
+			}
+			hasExprs = true
+		}
+	}
+
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	ctx := &goroutineFilterCtx{}
+	if hasExprs {
+		ctx.now = d.target.Nanotime()
+	}
+	r := []*proc.G{}
+	for _, g := range gs {
+		ok := true
+		ctx.g = g
+		for i := range filters {
+			match, err := matchGoroutineFilter(ctx, &filters[i], exprs[i])
+			if err != nil {
+				return nil, fmt.Errorf("goroutine %d: %v", g.ID, err)
+			}
+			if !match {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			r = append(r, g)
+		}
+	}
+	return r, nil
+}
+
+func matchGoroutineFilter(ctx *goroutineFilterCtx, filter *api.ListGoroutinesFilter, expr goroutineFilterExpr) (bool, error) {
+	g := ctx.g
+	var val bool
+	switch filter.Kind {
+	default:
+		fallthrough
+	case api.GoroutineFieldNone:
+		val = true
+	case api.GoroutineCurrentLoc:
+		val = strings.Contains(formatGoroutineLoc(g.CurrentLoc), filter.Arg)
+	case api.GoroutineUserLoc:
+		val = strings.Contains(formatGoroutineLoc(g.UserCurrent()), filter.Arg)
+	case api.GoroutineGoLoc:
+		val = strings.Contains(formatGoroutineLoc(g.Go()), filter.Arg)
+	case api.GoroutineStartLoc:
+		val = strings.Contains(formatGoroutineLoc(g.StartLoc()), filter.Arg)
+	case api.GoroutineLabel:
+		if i := strings.Index(filter.Arg, "="); i >= 0 {
+			val = g.Labels()[filter.Arg[:i]] == filter.Arg[i+1:]
+		} else {
+			_, val = g.Labels()[filter.Arg]
+		}
+	case api.GoroutineRunning:
+		val = g.Thread != nil
+	case api.GoroutineUser:
+		val = !g.System()
+	case api.GoroutineExpr:
+		var err error
+		val, err = evalGoroutineFilterBool(expr, ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+	if filter.Negated {
+		val = !val
+	}
+	return val, nil
+}
+
+func formatGoroutineLoc(loc proc.Location) string {
+	fnname := "?"
+	if loc.Fn != nil {
+		fnname = loc.Fn.Name
+	}
+	return fmt.Sprintf("%s:%d in %s", loc.File, loc.Line, fnname)
+}
+
+// GroupGoroutines divides gs in groups, as specified by group, and returns
+// the goroutines of each group, one group after the other, and a
+// description of the groups, sorted by name. The third return value is
+// true if there were more than group.MaxGroups groups, in which case the
+// groups that did not fit are discarded.
+func (d *Debugger) GroupGoroutines(gs []*proc.G, group *api.GoroutineGroupingOptions) ([]*proc.G, []api.GoroutineGroup, bool) {
+	if group.GroupBy == api.GoroutineFieldNone {
+		return gs, nil, false
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	members := map[string][]*proc.G{}
+	totals := map[string]int{}
+	for _, g := range gs {
+		var key string
+		switch group.GroupBy {
+		case api.GoroutineCurrentLoc:
+			key = formatGoroutineLoc(g.CurrentLoc)
+		case api.GoroutineUserLoc:
+			key = formatGoroutineLoc(g.UserCurrent())
+		case api.GoroutineGoLoc:
+			key = formatGoroutineLoc(g.Go())
+		case api.GoroutineStartLoc:
+			key = formatGoroutineLoc(g.StartLoc())
+		case api.GoroutineLabel:
+			key = fmt.Sprintf("%s=%s", group.GroupByKey, g.Labels()[group.GroupByKey])
+		case api.GoroutineRunning:
+			key = fmt.Sprintf("running=%v", g.Thread != nil)
+		case api.GoroutineUser:
+			key = fmt.Sprintf("user=%v", !g.System())
+		}
+		if group.MaxGroupMembers <= 0 || len(members[key]) < group.MaxGroupMembers {
+			members[key] = append(members[key], g)
+		}
+		totals[key]++
+	}
+
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tooManyGroups := false
+	r := []*proc.G{}
+	groups := []api.GoroutineGroup{}
+	for _, key := range keys {
+		if group.MaxGroups > 0 && len(groups) >= group.MaxGroups {
+			tooManyGroups = true
+			break
+		}
+		groups = append(groups, api.GoroutineGroup{Name: key, Offset: len(r), Count: len(members[key]), Total: totals[key]})
+		r = append(r, members[key]...)
+	}
+	return r, groups, tooManyGroups
+}
@@ -0,0 +1,93 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// eventHooks are the scripts run by the server when events happen, see
+// AddEventHook. The debugger only keeps the list of hooks, running them is
+// up to the server.
+type eventHooks struct {
+	mu     sync.Mutex
+	hooks  []*api.EventHook
+	lastID int
+}
+
+// AddEventHook registers hook, which will run every time an event of kind
+// hook.On happens, until it is removed, regardless of which clients are
+// connected.
+func (d *Debugger) AddEventHook(hook api.EventHook) (*api.EventHook, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	switch hook.On {
+	case api.EventStopped:
+	case api.EventExited:
+		if hook.Breakpoint != "" || hook.Resume {
+			return nil, errors.New("breakpoint and resume can only be used with hooks that run when the target stops")
+		}
+	default:
+		return nil, fmt.Errorf("can not run hooks on %q events", hook.On)
+	}
+	if hook.Script == "" {
+		return nil, errors.New("empty script")
+	}
+
+	d.hooks.mu.Lock()
+	defer d.hooks.mu.Unlock()
+	d.hooks.lastID++
+	hook.ID = d.hooks.lastID
+	hook.Runs, hook.Output, hook.Err = 0, "", ""
+	d.hooks.hooks = append(d.hooks.hooks, &hook)
+	r := hook
+	return &r, nil
+}
+
+// RemoveEventHook removes the event hook with the given ID.
+func (d *Debugger) RemoveEventHook(id int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.hooks.mu.Lock()
+	defer d.hooks.mu.Unlock()
+	for i := range d.hooks.hooks {
+		if d.hooks.hooks[i].ID == id {
+			d.hooks.hooks = append(d.hooks.hooks[:i], d.hooks.hooks[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no event hook with ID %d", id)
+}
+
+// EventHooks returns the registered event hooks.
+func (d *Debugger) EventHooks() []api.EventHook {
+	d.hooks.mu.Lock()
+	defer d.hooks.mu.Unlock()
+	r := make([]api.EventHook, len(d.hooks.hooks))
+	for i := range d.hooks.hooks {
+		r[i] = *d.hooks.hooks[i]
+	}
+	return r
+}
+
+// EventHookRan records the result of running the event hook with the
+// given ID.
+func (d *Debugger) EventHookRan(id int, output string, err error) {
+	d.hooks.mu.Lock()
+	defer d.hooks.mu.Unlock()
+	for _, hook := range d.hooks.hooks {
+		if hook.ID == id {
+			hook.Runs++
+			hook.Output = output
+			hook.Err = ""
+			if err != nil {
+				hook.Err = err.Error()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package debugger
+
+import (
+	"sync"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// Metrics describes the state of the debugger for monitoring, see
+// Debugger.Metrics.
+type Metrics struct {
+	// Running is true if the target is running.
+	Running bool
+	// Exited is true if the target process exited, ExitStatus is its exit
+	// status.
+	Exited     bool
+	ExitStatus int
+	// Detached is true after the debugger detached from the target.
+	Detached bool
+	// Stops is the number of times the target stopped after being resumed.
+	Stops uint64
+	// Breakpoints are the user breakpoints, with their hit counts, as they
+	// were the last time the target stopped.
+	Breakpoints []*api.Breakpoint
+}
+
+// metricsCache holds the values returned by Metrics. It is updated every
+// time the target stops so that it can be read while the target is running,
+// without acquiring targetMutex.
+type metricsCache struct {
+	mu sync.Mutex
+	m  Metrics
+}
+
+func (mc *metricsCache) stopped(bps []*api.Breakpoint) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.m.Stops++
+	mc.m.Breakpoints = bps
+}
+
+func (mc *metricsCache) exited(status int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.m.Exited = true
+	mc.m.ExitStatus = status
+}
+
+func (mc *metricsCache) restarted() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.m.Exited = false
+	mc.m.ExitStatus = 0
+}
+
+func (mc *metricsCache) detached() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.m.Detached = true
+}
+
+// Metrics returns the state of the debugger, for monitoring. Unlike the
+// other methods of Debugger it never waits for the target to stop.
+func (d *Debugger) Metrics() Metrics {
+	d.metrics.mu.Lock()
+	m := d.metrics.m
+	d.metrics.mu.Unlock()
+	m.Running = d.isRunning()
+	return m
+}
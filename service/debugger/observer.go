@@ -0,0 +1,27 @@
+package debugger
+
+import "errors"
+
+// ErrObserver is returned when an observer tries to change the state of
+// the target.
+var ErrObserver = errors.New("observers can not change the state of the target")
+
+// Observer returns a view of d that can be used to inspect the target but
+// not to change its state: resuming it, modifying breakpoints, memory or
+// registers and calling functions all fail with ErrObserver.
+// The returned Debugger shares everything else with d.
+func (d *Debugger) Observer() *Debugger {
+	return &Debugger{debuggerCore: d.debuggerCore, observer: true}
+}
+
+// IsObserver returns true if d was returned by Observer.
+func (d *Debugger) IsObserver() bool {
+	return d.observer
+}
+
+func (d *Debugger) checkObserver() error {
+	if d.observer {
+		return ErrObserver
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package debugger
+
+import (
+	"errors"
+	"os"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// ErrOutputNotCaptured is returned by WriteStdin when the debugger is not
+// capturing the standard input of the target.
+var ErrOutputNotCaptured = errors.New("standard input and output of the target are not captured")
+
+// outputCapture holds the files used to capture the standard input, output
+// and error of the target, see Config.CaptureOutput.
+type outputCapture struct {
+	// tty and redirects are passed to the backend when launching the target.
+	tty       string
+	redirects [3]string
+
+	// stdin is the end of the standard input of the target written by the
+	// debugger.
+	stdin *os.File
+	// ptmx is true if stdin is the master side of a pseudo-terminal.
+	ptmx bool
+
+	outputs []capturedStream
+
+	// toclose contains files that must be kept open until the target is
+	// launched.
+	toclose []*os.File
+	// dir is a temporary directory that must be removed once the target is
+	// launched.
+	dir string
+}
+
+// capturedStream is a file the debugger reads the output of the target
+// from.
+type capturedStream struct {
+	name string
+	file *os.File
+}
+
+// launched releases the resources that were only needed to launch the
+// target.
+func (c *outputCapture) launched() {
+	for _, f := range c.toclose {
+		_ = f.Close()
+	}
+	c.toclose = nil
+	if c.dir == "" {
+		return
+	}
+	// Our end of stdin was opened for both reading and writing, so that
+	// opening it wouldn't block, replace it with one that is only open for
+	// writing so that writing to it fails once the target exits.
+	if f, err := os.OpenFile(c.redirects[0], os.O_WRONLY|nonblockFlag, 0); err == nil {
+		_ = c.stdin.Close()
+		c.stdin = f
+	}
+	_ = os.RemoveAll(c.dir)
+	c.dir = ""
+}
+
+// close releases all the resources held by c.
+func (c *outputCapture) close() {
+	c.launched()
+	if c.stdin != nil {
+		_ = c.stdin.Close()
+	}
+	for _, out := range c.outputs {
+		_ = out.file.Close()
+	}
+}
+
+// readOutput reads the output of the target until it closes it, adding an
+// EventOutput event for every chunk of data read.
+func (d *Debugger) readOutput(out capturedStream) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := out.file.Read(buf)
+		if n > 0 {
+			d.events.add(api.Event{Kind: api.EventOutput, Stream: out.name, Output: string(buf[:n])})
+		}
+		if err != nil {
+			_ = out.file.Close()
+			return
+		}
+	}
+}
+
+// setOutputCapture replaces the current output capture with c, which must
+// belong to a target that was just launched.
+func (d *Debugger) setOutputCapture(c *outputCapture) {
+	d.outputMutex.Lock()
+	defer d.outputMutex.Unlock()
+	if d.output != nil {
+		d.output.close()
+	}
+	d.output = c
+	if c == nil {
+		return
+	}
+	for _, out := range c.outputs {
+		go d.readOutput(out)
+	}
+}
+
+// WriteStdin writes data to the standard input of the target, if eof is
+// set the standard input is closed afterwards.
+// Only available if the debugger was configured to capture the output of
+// the target. Writing blocks if the target is not reading its standard
+// input.
+func (d *Debugger) WriteStdin(data []byte, eof bool) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.outputMutex.Lock()
+	c := d.output
+	d.outputMutex.Unlock()
+	if c == nil {
+		return ErrOutputNotCaptured
+	}
+	if len(data) > 0 {
+		if _, err := c.stdin.Write(data); err != nil {
+			return err
+		}
+	}
+	if !eof {
+		return nil
+	}
+	if c.ptmx {
+		// Closing the master side of the pseudo-terminal would hang up the
+		// target, send the EOF character instead.
+		_, err := c.stdin.Write([]byte{4})
+		return err
+	}
+	return c.stdin.Close()
+}
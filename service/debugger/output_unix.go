@@ -0,0 +1,78 @@
+// +build !windows
+
+package debugger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+const nonblockFlag = syscall.O_NONBLOCK
+
+func newOutputCapture(mode string) (*outputCapture, error) {
+	switch mode {
+	case "pipe":
+		return newPipeCapture()
+	case "pty":
+		return newPtyCapture()
+	default:
+		return nil, fmt.Errorf("unknown output capture mode %q", mode)
+	}
+}
+
+// newPipeCapture creates a named pipe for stdin, stdout and stderr of the
+// target. Named pipes are used, instead of anonymous ones, because
+// redirects are passed to the backends as paths.
+func newPipeCapture() (c *outputCapture, err error) {
+	dir, err := ioutil.TempDir("", "dlv-output")
+	if err != nil {
+		return nil, err
+	}
+	c = &outputCapture{dir: dir}
+	defer func() {
+		if err != nil {
+			c.close()
+		}
+	}()
+	for i, name := range []string{"stdin", "stdout", "stderr"} {
+		c.redirects[i] = filepath.Join(dir, name)
+		if err := syscall.Mkfifo(c.redirects[i], 0600); err != nil {
+			return nil, err
+		}
+	}
+	// Opening one end of a named pipe blocks until the other end is opened,
+	// unless it is opened for reading and writing or in non-blocking mode.
+	c.stdin, err = os.OpenFile(c.redirects[0], os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range []string{"stdout", "stderr"} {
+		f, err := os.OpenFile(c.redirects[i+1], os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			return nil, err
+		}
+		c.outputs = append(c.outputs, capturedStream{name, f})
+	}
+	return c, nil
+}
+
+// newPtyCapture creates a pseudo-terminal for the target, its standard
+// output and standard error are merged and reported as stdout.
+func newPtyCapture() (*outputCapture, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &outputCapture{
+		tty:     tty.Name(),
+		stdin:   ptmx,
+		ptmx:    true,
+		outputs: []capturedStream{{"stdout", ptmx}},
+		toclose: []*os.File{tty},
+	}, nil
+}
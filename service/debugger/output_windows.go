@@ -0,0 +1,9 @@
+package debugger
+
+import "errors"
+
+const nonblockFlag = 0
+
+func newOutputCapture(mode string) (*outputCapture, error) {
+	return nil, errors.New("capturing the output of the target is not supported on windows")
+}
@@ -0,0 +1,197 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"go.starlark.net/starlark"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+const (
+	prettyPrinterBuiltinName = "pretty_printer"
+
+	// prettyPrinterMaxSteps is the maximum number of starlark instructions
+	// that a pretty printer can execute to format a single variable.
+	prettyPrinterMaxSteps = 1000000
+)
+
+// loadPrettyPrinters executes the starlark scripts in paths and returns the
+// pretty printers they register. Scripts register pretty printers for user types by calling:
+//
+//	pretty_printer(type_name, fn)
+//
+// Every time a variable of type type_name is converted for a client fn is
+// called with the variable, converted to a starlark value, as argument.
+// If fn returns a string it is used as the value of the variable, if it
+// returns None the variable is displayed normally.
+//
+// Structs are converted to values whose fields can be accessed either as
+// attributes or by indexing with the field name, arrays and slices to
+// lists, maps to dicts and pointers and interfaces to the value they point
+// to (or None). Only the parts of the variable loaded by the client's
+// LoadConfig are available to fn.
+func loadPrettyPrinters(paths []string) (api.PrettyPrinters, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	pps := make(api.PrettyPrinters)
+	loading := true
+	builtin := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		// pps is read concurrently once loading is done, it must not change.
+		if !loading {
+			return starlark.None, errors.New("pretty printers can only be registered while their script is loaded")
+		}
+		return builtinPrettyPrinter(pps, b, args, kwargs)
+	}
+	defer func() { loading = false }()
+	for _, path := range paths {
+		predeclared := starlark.StringDict{
+			prettyPrinterBuiltinName: starlark.NewBuiltin(prettyPrinterBuiltinName, builtin),
+		}
+		thread := &starlark.Thread{Name: path}
+		if _, err := starlark.ExecFile(thread, path, nil, predeclared); err != nil {
+			return nil, fmt.Errorf("could not load pretty printers from %s: %v", path, err)
+		}
+	}
+	return pps, nil
+}
+
+func builtinPrettyPrinter(pps api.PrettyPrinters, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var typ string
+	var fn starlark.Callable
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "type_name", &typ, "fn", &fn); err != nil {
+		return starlark.None, err
+	}
+	pps[typ] = func(v *api.Variable) (string, bool) {
+		thread := &starlark.Thread{Name: prettyPrinterBuiltinName}
+		thread.SetMaxExecutionSteps(prettyPrinterMaxSteps)
+		r, err := starlark.Call(thread, fn, starlark.Tuple{variableToStarlarkValue(v)}, nil)
+		if err != nil {
+			return fmt.Sprintf("(pretty printer error: %v)", err), true
+		}
+		if r == starlark.None {
+			return "", false
+		}
+		if s, ok := starlark.AsString(r); ok {
+			return s, true
+		}
+		return r.String(), true
+	}
+	return starlark.None, nil
+}
+
+// variableToStarlarkValue converts v into a starlark value.
+func variableToStarlarkValue(v *api.Variable) starlark.Value {
+	if v.Unreadable != "" {
+		return starlark.None
+	}
+	switch v.Kind {
+	case reflect.Bool:
+		return starlark.Bool(v.Value == "true")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, _ := strconv.ParseInt(v.Value, 0, 64)
+		return starlark.MakeInt64(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, _ := strconv.ParseUint(v.Value, 0, 64)
+		return starlark.MakeUint64(n)
+	case reflect.Float32, reflect.Float64:
+		switch v.Value {
+		case "+Inf":
+			return starlark.Float(math.Inf(+1))
+		case "-Inf":
+			return starlark.Float(math.Inf(-1))
+		case "NaN":
+			return starlark.Float(math.NaN())
+		}
+		n, _ := strconv.ParseFloat(v.Value, 64)
+		return starlark.Float(n)
+	case reflect.String:
+		return starlark.String(v.Value)
+	case reflect.Struct:
+		r := &prettyStruct{typ: v.Type, fields: make(starlark.StringDict, len(v.Children))}
+		for i := range v.Children {
+			r.fields[v.Children[i].Name] = variableToStarlarkValue(&v.Children[i])
+		}
+		return r
+	case reflect.Array, reflect.Slice:
+		elems := make([]starlark.Value, len(v.Children))
+		for i := range v.Children {
+			elems[i] = variableToStarlarkValue(&v.Children[i])
+		}
+		return starlark.NewList(elems)
+	case reflect.Map:
+		r := starlark.NewDict(len(v.Children) / 2)
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			// unhashable keys are skipped
+			_ = r.SetKey(variableToStarlarkValue(&v.Children[i]), variableToStarlarkValue(&v.Children[i+1]))
+		}
+		return r
+	case reflect.Ptr, reflect.Interface:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 || v.Children[0].OnlyAddr {
+			return starlark.None
+		}
+		return variableToStarlarkValue(&v.Children[0])
+	}
+	if v.Value == "" {
+		return starlark.None
+	}
+	return starlark.String(v.Value)
+}
+
+// prettyStruct is the starlark value for a struct passed to a pretty
+// printer.
+type prettyStruct struct {
+	typ    string
+	fields starlark.StringDict
+}
+
+func (v *prettyStruct) Freeze() {
+	v.fields.Freeze()
+}
+
+func (v *prettyStruct) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (v *prettyStruct) String() string {
+	return v.typ + v.fields.String()
+}
+
+func (v *prettyStruct) Truth() starlark.Bool {
+	return true
+}
+
+func (v *prettyStruct) Type() string {
+	return v.typ
+}
+
+func (v *prettyStruct) Attr(name string) (starlark.Value, error) {
+	if r, ok := v.fields[name]; ok {
+		return r, nil
+	}
+	return nil, nil
+}
+
+func (v *prettyStruct) AttrNames() []string {
+	r := make([]string, 0, len(v.fields))
+	for name := range v.fields {
+		r = append(r, name)
+	}
+	sort.Strings(r)
+	return r
+}
+
+func (v *prettyStruct) Get(key starlark.Value) (starlark.Value, bool, error) {
+	name, ok := starlark.AsString(key)
+	if !ok {
+		return nil, false, fmt.Errorf("wrong key type %s", key.Type())
+	}
+	r, ok := v.fields[name]
+	return r, ok, nil
+}
@@ -0,0 +1,82 @@
+package debugger
+
+import (
+	"github.com/go-delve/delve/service/api"
+)
+
+// SaveSession returns the breakpoints and watch expressions of the debug
+// session, so that they can be restored with LoadSession against a new
+// instance of the target.
+func (d *Debugger) SaveSession() *api.Session {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	s := &api.Session{Breakpoints: []*api.Breakpoint{}}
+	for _, bp := range api.ConvertBreakpoints(d.breakpoints()) {
+		if bp.ID < 0 || bp.TraceReturn {
+			continue
+		}
+		bp.HitCount = nil
+		bp.TotalHitCount = 0
+		s.Breakpoints = append(s.Breakpoints, bp)
+	}
+	for _, w := range d.watches {
+		s.Watches = append(s.Watches, api.SessionWatch{Expr: w.expr, Cfg: *api.LoadConfigFromProc(&w.cfg)})
+	}
+	return s
+}
+
+// LoadSession creates the breakpoints and watch expressions saved in s.
+// Breakpoints are set using their file and line, if they have one, since
+// the addresses are only valid for the executable that was debugged when
+// the session was saved. Breakpoints that can not be created are returned
+// with the reason.
+func (d *Debugger) LoadSession(s *api.Session) ([]api.DiscardedBreakpoint, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.loadSession(s), nil
+}
+
+func (d *Debugger) loadSession(s *api.Session) []api.DiscardedBreakpoint {
+	discarded := []api.DiscardedBreakpoint{}
+	for _, bp := range s.Breakpoints {
+		requestedBp := *bp
+		requestedBp.ID = 0
+		requestedBp.HitCount = nil
+		requestedBp.TotalHitCount = 0
+		if requestedBp.File != "" {
+			requestedBp.Addr = 0
+			requestedBp.Addrs = nil
+		}
+		if _, err := d.createBreakpoint(&requestedBp); err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: bp, Reason: err.Error()})
+		}
+	}
+	for _, w := range s.Watches {
+		found := false
+		for _, w2 := range d.watches {
+			if w2.expr == w.Expr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.addWatch(w.Expr, *api.LoadConfigToProc(&w.Cfg))
+		}
+	}
+	return discarded
+}
+
+// loadInitState restores the session specified by Config.InitState, the
+// breakpoints that can not be restored are logged.
+// Must be called with targetMutex held.
+func (d *Debugger) loadInitState() {
+	if d.config.InitState == nil {
+		return
+	}
+	for _, dbp := range d.loadSession(d.config.InitState) {
+		d.log.Errorf("could not restore breakpoint at %s:%d: %s", dbp.Breakpoint.File, dbp.Breakpoint.Line, dbp.Reason)
+	}
+}
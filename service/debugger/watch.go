@@ -0,0 +1,96 @@
+package debugger
+
+import (
+	"fmt"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// watchExpr is an expression evaluated every time the target stops.
+type watchExpr struct {
+	id   int
+	expr string
+	cfg  proc.LoadConfig
+}
+
+// AddWatch adds expr to the list of expressions that are evaluated in the
+// current frame of the selected goroutine every time the target stops,
+// their values are returned in the Watches field of api.DebuggerState.
+func (d *Debugger) AddWatch(expr string, cfg proc.LoadConfig) (*api.Watch, error) {
+	if err := d.checkObserver(); err != nil {
+		return nil, err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.addWatch(expr, cfg)
+}
+
+func (d *Debugger) addWatch(expr string, cfg proc.LoadConfig) (*api.Watch, error) {
+	for _, w := range d.watches {
+		if w.expr == expr {
+			return nil, fmt.Errorf("expression %q is already watched by watch %d", expr, w.id)
+		}
+	}
+	d.lastWatchID++
+	d.watches = append(d.watches, watchExpr{id: d.lastWatchID, expr: expr, cfg: cfg})
+	return &api.Watch{ID: d.lastWatchID, Expr: expr}, nil
+}
+
+// RemoveWatch removes the watch expression with the given ID.
+func (d *Debugger) RemoveWatch(id int) error {
+	if err := d.checkObserver(); err != nil {
+		return err
+	}
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	for i := range d.watches {
+		if d.watches[i].id == id {
+			d.watches = append(d.watches[:i], d.watches[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no watch expression with ID %d", id)
+}
+
+// ListWatches returns the list of watch expressions, without their values.
+func (d *Debugger) ListWatches() []api.Watch {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	r := make([]api.Watch, 0, len(d.watches))
+	for _, w := range d.watches {
+		r = append(r, api.Watch{ID: w.id, Expr: w.expr})
+	}
+	return r
+}
+
+// evalWatches evaluates all watch expressions in the current frame of the
+// selected goroutine and marks the ones that changed since the previous
+// stop. Must be called with targetMutex held.
+func (d *Debugger) evalWatches() []api.Watch {
+	if len(d.watches) == 0 {
+		return nil
+	}
+	s, err := proc.ConvertEvalScope(d.target, -1, 0, 0)
+	r := make([]api.Watch, 0, len(d.watches))
+	d.history.mu.Lock()
+	defer d.history.mu.Unlock()
+	for _, w := range d.watches {
+		aw := api.Watch{ID: w.id, Expr: w.expr}
+		if err != nil {
+			aw.Err = err.Error()
+			r = append(r, aw)
+			continue
+		}
+		v, err := s.EvalExpression(w.expr, w.cfg)
+		if err != nil {
+			aw.Err = err.Error()
+		} else {
+			aw.Value = d.prettyPrinters.ConvertVar(v)
+			aw.Value.Name = w.expr
+			d.history.mark(fmt.Sprintf("watch %d", w.id), aw.Value)
+		}
+		r = append(r, aw)
+	}
+	return r
+}
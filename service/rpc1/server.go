@@ -36,6 +36,9 @@ func (s *RPCServer) ProcessPid(arg1 interface{}, pid *int) error {
 
 func (s *RPCServer) Detach(kill bool, ret *int) error {
 	err := s.debugger.Detach(kill)
+	if err == debugger.ErrObserver {
+		return err
+	}
 	if s.config.DisconnectChan != nil {
 		close(s.config.DisconnectChan)
 	}
@@ -176,7 +179,7 @@ func (s *RPCServer) ListPackageVars(filter string, variables *[]api.Variable) er
 	if err != nil {
 		return err
 	}
-	*variables = api.ConvertVars(vars)
+	*variables = s.debugger.PrettyPrinters().ConvertVars(vars)
 	return nil
 }
 
@@ -190,7 +193,7 @@ func (s *RPCServer) ListThreadPackageVars(args *ThreadListArgs, variables *[]api
 	if err != nil {
 		return err
 	}
-	*variables = api.ConvertVars(vars)
+	*variables = s.debugger.PrettyPrinters().ConvertVars(vars)
 	return nil
 }
 
@@ -214,7 +217,7 @@ func (s *RPCServer) ListLocalVars(scope api.EvalScope, variables *[]api.Variable
 	if err != nil {
 		return err
 	}
-	*variables = api.ConvertVars(vars)
+	*variables = s.debugger.PrettyPrinters().ConvertVars(vars)
 	return nil
 }
 
@@ -223,7 +226,7 @@ func (s *RPCServer) ListFunctionArgs(scope api.EvalScope, variables *[]api.Varia
 	if err != nil {
 		return err
 	}
-	*variables = api.ConvertVars(vars)
+	*variables = s.debugger.PrettyPrinters().ConvertVars(vars)
 	return nil
 }
 
@@ -237,7 +240,7 @@ func (s *RPCServer) EvalSymbol(args EvalSymbolArgs, variable *api.Variable) erro
 	if err != nil {
 		return err
 	}
-	*variable = *api.ConvertVar(v)
+	*variable = *s.debugger.PrettyPrinters().ConvertVar(v)
 	return nil
 }
 
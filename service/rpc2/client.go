@@ -1,6 +1,7 @@
 package rpc2
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -17,6 +18,7 @@ type RPCClient struct {
 	client *rpc.Client
 
 	retValLoadCfg *api.LoadConfig
+	observer      bool
 }
 
 // Ensure the implementation satisfies the interface.
@@ -31,6 +33,15 @@ func NewClient(addr string) *RPCClient {
 	return newFromRPCClient(client)
 }
 
+// NewClientTLS creates a new RPCClient connected to addr using TLS.
+func NewClientTLS(addr string, tlsConfig *tls.Config) *RPCClient {
+	conn, err := tls.Dial("tcp", addr, tlsConfig)
+	if err != nil {
+		log.Fatal("dialing:", err)
+	}
+	return NewClientFromConn(conn)
+}
+
 func newFromRPCClient(client *rpc.Client) *RPCClient {
 	c := &RPCClient{client: client}
 	c.call("SetApiVersion", api.SetAPIVersionIn{APIVersion: 2}, &api.SetAPIVersionOut{})
@@ -42,6 +53,17 @@ func NewClientFromConn(conn net.Conn) *RPCClient {
 	return newFromRPCClient(jsonrpc.NewClient(conn))
 }
 
+// NewClientFromConnWithToken creates a new RPCClient from the given
+// connection, authenticating it with token, see RPCServer.Authenticate.
+func NewClientFromConnWithToken(conn net.Conn, token string) (*RPCClient, error) {
+	client := jsonrpc.NewClient(conn)
+	if err := client.Call("RPCServer.Authenticate", api.AuthenticateIn{Token: token}, &api.AuthenticateOut{}); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return newFromRPCClient(client), nil
+}
+
 func (c *RPCClient) ProcessPid() int {
 	out := new(ProcessPidOut)
 	c.call("ProcessPid", ProcessPidIn{}, out)
@@ -152,6 +174,12 @@ func (c *RPCClient) Step() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) StepInline(fnname string) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepInline, Expr: fnname, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) ReverseStep() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStep, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
@@ -176,6 +204,16 @@ func (c *RPCClient) Call(goroutineID int, expr string, unsafe bool) (*api.Debugg
 	return &out.State, err
 }
 
+func (c *RPCClient) CallWithTimeout(goroutineID int, expr string, unsafe bool, timeout time.Duration) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Call, ReturnInfoLoadConfig: c.retValLoadCfg, Expr: expr, UnsafeCall: unsafe, GoroutineID: goroutineID, CallTimeout: timeout}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) CancelCall(goroutineID int) error {
+	return c.call("CancelCall", CancelCallIn{GoroutineID: goroutineID}, &CancelCallOut{})
+}
+
 func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction}, &out)
@@ -275,7 +313,60 @@ func (c *RPCClient) GetThread(id int) (*api.Thread, error) {
 
 func (c *RPCClient) EvalVariable(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error) {
 	var out EvalOut
-	err := c.call("Eval", EvalIn{scope, expr, &cfg}, &out)
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, nil}, &out)
+	return out.Variable, err
+}
+
+// EvalVariableStringer is like EvalVariable but also calls the String or
+// Error method of the value of expr, with the limits specified by opts.
+// If the call fails the variable is still returned, without StringerValue,
+// and the reason is returned as stringerErr.
+func (c *RPCClient) EvalVariableStringer(scope api.EvalScope, expr string, cfg api.LoadConfig, opts api.StringerOptions) (v *api.Variable, stringerErr string, err error) {
+	var out EvalOut
+	err = c.call("Eval", EvalIn{scope, expr, &cfg, &opts}, &out)
+	return out.Variable, out.StringerErr, err
+}
+
+// EvalVariables evaluates all exprs in the same scope with a single call.
+func (c *RPCClient) EvalVariables(scope api.EvalScope, exprs []string, cfg api.LoadConfig) ([]api.EvalResult, error) {
+	var out EvalManyOut
+	err := c.call("EvalMany", EvalManyIn{scope, exprs, &cfg}, &out)
+	return out.Results, err
+}
+
+func (c *RPCClient) LoadArrayRange(scope api.EvalScope, addr uint64, typ string, start, end int64, cfg api.LoadConfig) (*api.Variable, error) {
+	var out LoadArrayRangeOut
+	err := c.call("LoadArrayRange", LoadArrayRangeIn{scope, addr, typ, start, end, &cfg}, &out)
+	return out.Variable, err
+}
+
+func (c *RPCClient) FilterMap(scope api.EvalScope, expr, keyRegexp string, sorted bool, cfg api.LoadConfig) (*api.Variable, error) {
+	var out FilterMapOut
+	err := c.call("FilterMap", FilterMapIn{scope, expr, keyRegexp, sorted, &cfg}, &out)
+	return out.Variable, err
+}
+
+func (c *RPCClient) SearchVariable(scope api.EvalScope, expr, value string, cfg api.LoadConfig) ([]string, error) {
+	var out SearchVariableOut
+	err := c.call("SearchVariable", SearchVariableIn{scope, expr, value, &cfg}, &out)
+	return out.Paths, err
+}
+
+func (c *RPCClient) ExportVariable(scope api.EvalScope, expr, format string, cfg api.LoadConfig) (string, error) {
+	var out ExportVariableOut
+	err := c.call("ExportVariable", ExportVariableIn{scope, expr, format, &cfg}, &out)
+	return out.Data, err
+}
+
+func (c *RPCClient) ObjectGraph(scope api.EvalScope, exprs []string, cfg api.LoadConfig) (*api.ObjectGraph, error) {
+	var out ObjectGraphOut
+	err := c.call("ObjectGraph", ObjectGraphIn{scope, exprs, &cfg}, &out)
+	return out.Graph, err
+}
+
+func (c *RPCClient) ViewAs(scope api.EvalScope, expr, typ string, cfg api.LoadConfig) (*api.Variable, error) {
+	var out ViewAsOut
+	err := c.call("ViewAs", ViewAsIn{scope, expr, typ, &cfg}, &out)
 	return out.Variable, err
 }
 
@@ -290,6 +381,12 @@ func (c *RPCClient) ListSources(filter string) ([]string, error) {
 	return sources.Sources, err
 }
 
+func (c *RPCClient) GetSourceFile(path string) (*api.SourceFile, error) {
+	var out GetSourceFileOut
+	err := c.call("GetSourceFile", GetSourceFileIn{path}, &out)
+	return &out.File, err
+}
+
 func (c *RPCClient) ListFunctions(filter string) ([]string, error) {
 	funcs := new(ListFunctionsOut)
 	err := c.call("ListFunctions", ListFunctionsIn{filter}, funcs)
@@ -326,6 +423,14 @@ func (c *RPCClient) ListScopeRegisters(scope api.EvalScope, includeFp bool) (api
 	return out.Regs, err
 }
 
+// ListRegistersWithFormat lists the registers of the thread threadID, or
+// of scope if it isn't nil, formatting vector registers with vectorFormat.
+func (c *RPCClient) ListRegistersWithFormat(threadID int, scope *api.EvalScope, includeFp bool, vectorFormat string) (api.Registers, error) {
+	out := new(ListRegistersOut)
+	err := c.call("ListRegisters", ListRegistersIn{ThreadID: threadID, IncludeFp: includeFp, Scope: scope, VectorFormat: vectorFormat}, out)
+	return out.Regs, err
+}
+
 func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error) {
 	var out ListFunctionArgsOut
 	err := c.call("ListFunctionArgs", ListFunctionArgsIn{scope, cfg}, &out)
@@ -334,22 +439,50 @@ func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([
 
 func (c *RPCClient) ListGoroutines(start, count int) ([]*api.Goroutine, int, error) {
 	var out ListGoroutinesOut
-	err := c.call("ListGoroutines", ListGoroutinesIn{start, count}, &out)
+	err := c.call("ListGoroutines", ListGoroutinesIn{Start: start, Count: count}, &out)
 	return out.Goroutines, out.Nextg, err
 }
 
+func (c *RPCClient) ListGoroutinesWithFilter(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions) ([]*api.Goroutine, []api.GoroutineGroup, int, bool, error) {
+	if group == nil {
+		group = &api.GoroutineGroupingOptions{}
+	}
+	var out ListGoroutinesOut
+	err := c.call("ListGoroutines", ListGoroutinesIn{Start: start, Count: count, Filters: filters, GoroutineGroupingOptions: *group}, &out)
+	return out.Goroutines, out.Groups, out.Nextg, out.TooManyGroups, err
+}
+
 func (c *RPCClient) Stacktrace(goroutineId, depth int, opts api.StacktraceOptions, cfg *api.LoadConfig) ([]api.Stackframe, error) {
 	var out StacktraceOut
-	err := c.call("Stacktrace", StacktraceIn{goroutineId, depth, false, false, opts, cfg}, &out)
+	err := c.call("Stacktrace", StacktraceIn{Id: goroutineId, Depth: depth, Opts: opts, Cfg: cfg}, &out)
 	return out.Locations, err
 }
 
+func (c *RPCClient) StacktraceRange(goroutineId, start, depth int, opts api.StacktraceOptions, cfg *api.LoadConfig) ([]api.Stackframe, error) {
+	var out StacktraceOut
+	err := c.call("Stacktrace", StacktraceIn{Id: goroutineId, Start: start, Depth: depth, Opts: opts, Cfg: cfg}, &out)
+	return out.Locations, err
+}
+
+func (c *RPCClient) StackframeDetails(goroutineId, frame int, opts api.StacktraceOptions, cfg *api.LoadConfig) (*api.Stackframe, error) {
+	var out StackframeDetailsOut
+	err := c.call("StackframeDetails", StackframeDetailsIn{Id: goroutineId, Frame: frame, Opts: opts, Cfg: cfg}, &out)
+	return &out.Frame, err
+}
+
 func (c *RPCClient) Ancestors(goroutineID int, numAncestors int, depth int) ([]api.Ancestor, error) {
 	var out AncestorsOut
 	err := c.call("Ancestors", AncestorsIn{goroutineID, numAncestors, depth}, &out)
 	return out.Ancestors, err
 }
 
+// GoroutineTree returns the tree of goroutines and of their ancestors.
+func (c *RPCClient) GoroutineTree(depth int) ([]api.GoroutineTreeNode, error) {
+	var out GoroutineTreeOut
+	err := c.call("GoroutineTree", GoroutineTreeIn{depth}, &out)
+	return out.Nodes, err
+}
+
 func (c *RPCClient) AttachedToExistingProcess() bool {
 	out := new(AttachedToExistingProcessOut)
 	c.call("AttachedToExistingProcess", AttachedToExistingProcessIn{}, out)
@@ -383,6 +516,14 @@ func (c *RPCClient) Recorded() bool {
 	return out.Recorded
 }
 
+// ListRecorders returns the record and replay engines supported by the
+// server.
+func (c *RPCClient) ListRecorders() ([]api.Recorder, error) {
+	var out ListRecordersOut
+	err := c.call("ListRecorders", ListRecordersIn{}, &out)
+	return out.Recorders, err
+}
+
 // TraceDirectory returns the path to the trace directory for a recording.
 func (c *RPCClient) TraceDirectory() (string, error) {
 	var out RecordedOut
@@ -411,6 +552,48 @@ func (c *RPCClient) ClearCheckpoint(id int) error {
 	return err
 }
 
+// RunToEvent resets the recording to the start of the specified rr event.
+func (c *RPCClient) RunToEvent(event uint64) error {
+	var out RunToEventOut
+	return c.call("RunToEvent", RunToEventIn{event}, &out)
+}
+
+// WaitEvents returns the events that happened after the event with ID
+// after, waiting up to timeout for one to happen.
+func (c *RPCClient) WaitEvents(after uint64, timeout time.Duration) ([]api.Event, error) {
+	var out WaitEventsOut
+	err := c.call("WaitEvents", WaitEventsIn{after, timeout}, &out)
+	return out.Events, err
+}
+
+// AddEventHook registers a script that the server runs every time an event
+// happens.
+func (c *RPCClient) AddEventHook(hook api.EventHook) (*api.EventHook, error) {
+	var out AddEventHookOut
+	err := c.call("AddEventHook", AddEventHookIn{hook}, &out)
+	return &out.Hook, err
+}
+
+// RemoveEventHook removes an event hook.
+func (c *RPCClient) RemoveEventHook(id int) error {
+	var out RemoveEventHookOut
+	return c.call("RemoveEventHook", RemoveEventHookIn{id}, &out)
+}
+
+// ListEventHooks returns the event hooks.
+func (c *RPCClient) ListEventHooks() ([]api.EventHook, error) {
+	var out ListEventHooksOut
+	err := c.call("ListEventHooks", ListEventHooksIn{}, &out)
+	return out.Hooks, err
+}
+
+// WriteStdin writes data to the standard input of the target, if eof is
+// set the standard input is closed afterwards.
+func (c *RPCClient) WriteStdin(data string, eof bool) error {
+	var out WriteStdinOut
+	return c.call("WriteStdin", WriteStdinIn{data, eof}, &out)
+}
+
 func (c *RPCClient) SetReturnValuesLoadConfig(cfg *api.LoadConfig) {
 	c.retValLoadCfg = cfg
 }
@@ -427,6 +610,29 @@ func (c *RPCClient) IsMulticlient() bool {
 	return out.IsMulticlient
 }
 
+// GetCapabilities returns the features supported by the target and the
+// backend.
+func (c *RPCClient) GetCapabilities() (*api.Capabilities, error) {
+	var out GetCapabilitiesOut
+	err := c.call("GetCapabilities", GetCapabilitiesIn{}, &out)
+	return &out.Capabilities, err
+}
+
+// SetObserver turns this connection into an observer connection, which can
+// inspect the target but not change its state.
+func (c *RPCClient) SetObserver() error {
+	err := c.call("SetObserver", api.SetObserverIn{}, &api.SetObserverOut{})
+	if err == nil {
+		c.observer = true
+	}
+	return err
+}
+
+// IsObserver returns true if SetObserver was called.
+func (c *RPCClient) IsObserver() bool {
+	return c.observer
+}
+
 func (c *RPCClient) Disconnect(cont bool) error {
 	if cont {
 		out := new(CommandOut)
@@ -451,10 +657,198 @@ func (c *RPCClient) ExamineMemory(address uint64, count int) ([]byte, bool, erro
 	return out.Mem, out.IsLittleEndian, nil
 }
 
+func (c *RPCClient) WriteMemory(address uint64, data []byte) error {
+	return c.call("WriteMemory", WriteMemoryIn{Address: address, Data: data}, &WriteMemoryOut{})
+}
+
 func (c *RPCClient) StopRecording() error {
 	return c.call("StopRecording", StopRecordingIn{}, &StopRecordingOut{})
 }
 
+// SetSignalPolicy changes how the signal sig is handled.
+func (c *RPCClient) SetSignalPolicy(sig string, stop, print, pass bool) (api.SignalPolicy, error) {
+	var out SetSignalPolicyOut
+	err := c.call("SetSignalPolicy", SetSignalPolicyIn{Signal: sig, Stop: stop, Print: print, Pass: pass}, &out)
+	return out.Policy, err
+}
+
+// ListSignalPolicies lists the signals whose handling was changed.
+func (c *RPCClient) ListSignalPolicies() ([]api.SignalPolicy, error) {
+	var out ListSignalPoliciesOut
+	err := c.call("ListSignalPolicies", ListSignalPoliciesIn{}, &out)
+	return out.Policies, err
+}
+
+// ListTimers lists the timers pending in the target process.
+func (c *RPCClient) ListTimers() ([]api.Timer, int64, error) {
+	var out ListTimersOut
+	err := c.call("ListTimers", ListTimersIn{}, &out)
+	return out.Timers, out.Now, err
+}
+
+// ChanWaiters returns the goroutines waiting to receive from, or send to,
+// the channel expr.
+func (c *RPCClient) ChanWaiters(scope api.EvalScope, expr string) (recv, send []*api.Goroutine, err error) {
+	var out ChanWaitersOut
+	err = c.call("ChanWaiters", ChanWaitersIn{scope, expr}, &out)
+	return out.Recv, out.Send, err
+}
+
+// MutexInfo returns the state of the sync.Mutex or sync.RWMutex expr.
+func (c *RPCClient) MutexInfo(scope api.EvalScope, expr string) (*api.MutexState, error) {
+	var out MutexInfoOut
+	err := c.call("MutexInfo", MutexInfoIn{scope, expr}, &out)
+	return out.State, err
+}
+
+// InterfaceItab returns the itab of the interface expr.
+func (c *RPCClient) InterfaceItab(scope api.EvalScope, expr string) (*api.Itab, error) {
+	var out InterfaceItabOut
+	err := c.call("InterfaceItab", InterfaceItabIn{scope, expr}, &out)
+	return out.Itab, err
+}
+
+// ContextChain returns the chain of contexts wrapped by the
+// context.Context expr.
+func (c *RPCClient) ContextChain(scope api.EvalScope, expr string, cfg api.LoadConfig) ([]api.ContextLayer, error) {
+	var out ContextChainOut
+	err := c.call("ContextChain", ContextChainIn{scope, expr, &cfg}, &out)
+	return out.Layers, err
+}
+
+// AddWatch adds a watch expression, evaluated every time the target stops.
+func (c *RPCClient) AddWatch(expr string, cfg api.LoadConfig) (*api.Watch, error) {
+	var out AddWatchOut
+	err := c.call("AddWatch", AddWatchIn{expr, &cfg}, &out)
+	return &out.Watch, err
+}
+
+// RemoveWatch removes the watch expression with the given ID.
+func (c *RPCClient) RemoveWatch(id int) error {
+	var out RemoveWatchOut
+	return c.call("RemoveWatch", RemoveWatchIn{id}, &out)
+}
+
+// ListWatches lists all watch expressions.
+func (c *RPCClient) ListWatches() ([]api.Watch, error) {
+	var out ListWatchesOut
+	err := c.call("ListWatches", ListWatchesIn{}, &out)
+	return out.Watches, err
+}
+
+// SaveSession returns the breakpoints and watch expressions of the debug
+// session, along with substitutePathRules.
+func (c *RPCClient) SaveSession(substitutePathRules [][2]string) (*api.Session, error) {
+	var out SaveSessionOut
+	err := c.call("SaveSession", SaveSessionIn{substitutePathRules}, &out)
+	return &out.Session, err
+}
+
+// LoadSession restores a session returned by SaveSession.
+func (c *RPCClient) LoadSession(session *api.Session) ([]api.DiscardedBreakpoint, error) {
+	var out LoadSessionOut
+	err := c.call("LoadSession", LoadSessionIn{*session}, &out)
+	return out.DiscardedBreakpoints, err
+}
+
+// FindReferrers returns the objects containing pointers to the object at
+// addr.
+func (c *RPCClient) FindReferrers(addr uint64) (*api.Referrers, error) {
+	var out FindReferrersOut
+	err := c.call("FindReferrers", FindReferrersIn{addr}, &out)
+	return &out.Referrers, err
+}
+
+// HeapHistogram returns the number of heap objects of each type and the
+// memory they use.
+func (c *RPCClient) HeapHistogram() ([]api.HeapHistogramEntry, error) {
+	var out HeapHistogramOut
+	err := c.call("HeapHistogram", HeapHistogramIn{}, &out)
+	return out.Entries, err
+}
+
+// LoadStringChunk reads length bytes of the contents of a string or []byte
+// starting at addr, see RPCServer.LoadStringChunk.
+func (c *RPCClient) LoadStringChunk(addr uint64, length int) ([]byte, error) {
+	var out LoadStringChunkOut
+	err := c.call("LoadStringChunk", LoadStringChunkIn{addr, length}, &out)
+	return out.Data, err
+}
+
+// Dump writes a core file of the target process to dest.
+func (c *RPCClient) Dump(dest string, opts api.DumpOptions) error {
+	var out DumpOut
+	return c.call("Dump", DumpIn{Destination: dest, Selective: opts.Selective, Minidump: opts.Minidump, Compress: opts.Compress, Sparse: opts.Sparse}, &out)
+}
+
+// RawCall calls the function at addr on the current thread, or executes
+// system call number addr if syscall is true, without any type checking.
+// This is unsafe, see RPCServer.RawCall.
+func (c *RPCClient) RawCall(addr uint64, args []uint64, syscall bool) (*api.RawCallResult, error) {
+	var out RawCallOut
+	err := c.call("RawCall", RawCallIn{Unsafe: true, Addr: addr, Args: args, Syscall: syscall}, &out)
+	return &out.Result, err
+}
+
+// PatchFunction changes the code of the function fn, to return vals if
+// code is empty or to jump to code otherwise.
+func (c *RPCClient) PatchFunction(fn string, vals []string, code []byte) (*api.FunctionPatch, error) {
+	var out PatchFunctionOut
+	err := c.call("PatchFunction", PatchFunctionIn{Function: fn, Return: vals, Code: code}, &out)
+	return &out.Patch, err
+}
+
+// UnpatchFunction reverts the function patch with the given ID.
+func (c *RPCClient) UnpatchFunction(id int) error {
+	var out UnpatchFunctionOut
+	return c.call("UnpatchFunction", UnpatchFunctionIn{ID: id}, &out)
+}
+
+// ListFunctionPatches returns the list of function patches currently
+// applied.
+func (c *RPCClient) ListFunctionPatches() ([]*api.FunctionPatch, error) {
+	var out ListFunctionPatchesOut
+	err := c.call("ListFunctionPatches", ListFunctionPatchesIn{}, &out)
+	return out.Patches, err
+}
+
+// GuardMemory changes the protection of the memory range [addr,
+// addr+size) to prot, see RPCServer.GuardMemory.
+func (c *RPCClient) GuardMemory(addr, size uint64, prot string) (*api.MemoryGuard, error) {
+	var out GuardMemoryOut
+	err := c.call("GuardMemory", GuardMemoryIn{Addr: addr, Size: size, Prot: prot}, &out)
+	return &out.Guard, err
+}
+
+// UnguardMemory restores the protection of the memory guarded by the
+// guard with the given ID.
+func (c *RPCClient) UnguardMemory(id int) error {
+	var out UnguardMemoryOut
+	return c.call("UnguardMemory", UnguardMemoryIn{ID: id}, &out)
+}
+
+// ListMemoryGuards returns the list of memory guards.
+func (c *RPCClient) ListMemoryGuards() ([]*api.MemoryGuard, error) {
+	var out ListMemoryGuardsOut
+	err := c.call("ListMemoryGuards", ListMemoryGuardsIn{}, &out)
+	return out.Guards, err
+}
+
+// EnableBranchTrace starts, or stops if enable is false, recording the
+// branches executed by the target.
+func (c *RPCClient) EnableBranchTrace(enable bool) error {
+	var out EnableBranchTraceOut
+	return c.call("EnableBranchTrace", EnableBranchTraceIn{Enable: enable}, &out)
+}
+
+// BranchHistory returns the last count branches executed by the thread
+// with the given ID, or by the current thread if threadID is 0.
+func (c *RPCClient) BranchHistory(threadID, count int) ([]api.Branch, error) {
+	var out BranchHistoryOut
+	err := c.call("BranchHistory", BranchHistoryIn{ThreadID: threadID, Count: count}, &out)
+	return out.Branches, err
+}
+
 func (c *RPCClient) call(method string, args, reply interface{}) error {
 	return c.client.Call("RPCServer."+method, args, reply)
 }
@@ -59,6 +59,9 @@ type DetachOut struct {
 // Detach detaches the debugger, optionally killing the process.
 func (s *RPCServer) Detach(arg DetachIn, out *DetachOut) error {
 	err := s.debugger.Detach(arg.Kill)
+	if err == debugger.ErrObserver {
+		return err
+	}
 	if s.config.DisconnectChan != nil {
 		close(s.config.DisconnectChan)
 		s.config.DisconnectChan = nil
@@ -139,6 +142,28 @@ func (s *RPCServer) Command(command api.DebuggerCommand, cb service.RPCCallback)
 	cb.Return(out, nil)
 }
 
+type CancelCallIn struct {
+	// GoroutineID is the goroutine of the call, zero cancels any call that
+	// is running.
+	GoroutineID int
+}
+
+type CancelCallOut struct {
+}
+
+// CancelCall cancels a function call started with the Call command. If the
+// command is still running the target is stopped and the command returns an
+// error.
+// If the target is already stopped, because the call was interrupted by a
+// breakpoint or by a Halt command, the function is not called, if it
+// wasn't yet, and its return values are discarded when the target is
+// resumed.
+// Once a cancelled call returns, its goroutine continues from where it was
+// when the call started.
+func (s *RPCServer) CancelCall(arg CancelCallIn, out *CancelCallOut) error {
+	return s.debugger.CancelCall(arg.GoroutineID)
+}
+
 type GetBreakpointIn struct {
 	Id   int
 	Name string
@@ -173,6 +198,9 @@ type StacktraceIn struct {
 	Defers bool // read deferred functions (equivalent to passing StacktraceReadDefers in Opts)
 	Opts   api.StacktraceOptions
 	Cfg    *api.LoadConfig
+	// Start is the number of the first frame returned, frames are returned
+	// from Start to Start+Depth.
+	Start int
 }
 
 type StacktraceOut struct {
@@ -183,6 +211,10 @@ type StacktraceOut struct {
 //
 // If Full is set it will also the variable of all local variables
 // and function arguments of all stack frames.
+//
+// Clients displaying deep stacks can request them without Full, one window
+// of frames at a time using Start, and then use StackframeDetails to load
+// the variables of the frames they display.
 func (s *RPCServer) Stacktrace(arg StacktraceIn, out *StacktraceOut) error {
 	cfg := arg.Cfg
 	if cfg == nil && arg.Full {
@@ -191,15 +223,50 @@ func (s *RPCServer) Stacktrace(arg StacktraceIn, out *StacktraceOut) error {
 	if arg.Defers {
 		arg.Opts |= api.StacktraceReadDefers
 	}
+	if arg.Start < 0 {
+		return errors.New("negative start frame")
+	}
 	var err error
-	rawlocs, err := s.debugger.Stacktrace(arg.Id, arg.Depth, arg.Opts)
+	rawlocs, err := s.debugger.Stacktrace(arg.Id, arg.Start+arg.Depth, arg.Opts)
 	if err != nil {
 		return err
 	}
-	out.Locations, err = s.debugger.ConvertStacktrace(rawlocs, api.LoadConfigToProc(cfg))
+	if arg.Start >= len(rawlocs) {
+		out.Locations = []api.Stackframe{}
+		return nil
+	}
+	out.Locations, err = s.debugger.ConvertStacktrace(rawlocs[arg.Start:], api.LoadConfigToProc(cfg))
 	return err
 }
 
+type StackframeDetailsIn struct {
+	Id    int
+	Frame int
+	Opts  api.StacktraceOptions
+	// Cfg is used to load the variables of the frame, if it is nil the same
+	// configuration used by Stacktrace with Full set is used.
+	Cfg *api.LoadConfig
+}
+
+type StackframeDetailsOut struct {
+	Frame api.Stackframe
+}
+
+// StackframeDetails returns frame number Frame of the stack of goroutine
+// Id, with its local variables and function arguments.
+func (s *RPCServer) StackframeDetails(arg StackframeDetailsIn, out *StackframeDetailsOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	frame, err := s.debugger.StackframeDetails(arg.Id, arg.Frame, arg.Opts, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Frame = *frame
+	return nil
+}
+
 type AncestorsIn struct {
 	GoroutineID  int
 	NumAncestors int
@@ -217,6 +284,27 @@ func (s *RPCServer) Ancestors(arg AncestorsIn, out *AncestorsOut) error {
 	return err
 }
 
+type GoroutineTreeIn struct {
+	// Depth is the maximum number of frames of the creation stack of each
+	// goroutine.
+	Depth int
+}
+
+type GoroutineTreeOut struct {
+	Nodes []api.GoroutineTreeNode
+}
+
+// GoroutineTree returns the tree of all goroutines, and of the ancestors
+// that have exited, with the stack of each parent goroutine at the time it
+// created its child. The target must be run with
+// GODEBUG=tracebackancestors=N for the ancestors of goroutines to be
+// recorded.
+func (s *RPCServer) GoroutineTree(arg GoroutineTreeIn, out *GoroutineTreeOut) error {
+	var err error
+	out.Nodes, err = s.debugger.GoroutineTree(arg.Depth)
+	return err
+}
+
 type ListBreakpointsIn struct {
 }
 
@@ -375,7 +463,8 @@ func (s *RPCServer) ListPackageVars(arg ListPackageVarsIn, out *ListPackageVarsO
 	if err != nil {
 		return err
 	}
-	out.Variables = api.ConvertVars(vars)
+	out.Variables = s.debugger.PrettyPrinters().ConvertVars(vars)
+	s.debugger.MarkChangedVariables(-1, -1, 0, out.Variables)
 	return nil
 }
 
@@ -383,6 +472,10 @@ type ListRegistersIn struct {
 	ThreadID  int
 	IncludeFp bool
 	Scope     *api.EvalScope
+	// VectorFormat, if not empty, is the format used for the contents of
+	// vector registers: i8x16, i16x8, i32x4, i64x2, u8x16, u16x8, u32x4,
+	// u64x2, f32x4 or f64x2.
+	VectorFormat string
 }
 
 type ListRegistersOut struct {
@@ -413,7 +506,14 @@ func (s *RPCServer) ListRegisters(arg ListRegistersIn, out *ListRegistersOut) er
 	if err != nil {
 		return err
 	}
-	out.Regs = api.ConvertRegisters(regs, s.debugger.DwarfRegisterToString, arg.IncludeFp)
+	regToString := s.debugger.DwarfRegisterToString
+	if arg.VectorFormat != "" {
+		regToString, err = s.debugger.DwarfRegisterToStringFormat(arg.VectorFormat)
+		if err != nil {
+			return err
+		}
+	}
+	out.Regs = api.ConvertRegisters(regs, regToString, arg.IncludeFp)
 	out.Registers = out.Regs.String()
 
 	return nil
@@ -434,7 +534,8 @@ func (s *RPCServer) ListLocalVars(arg ListLocalVarsIn, out *ListLocalVarsOut) er
 	if err != nil {
 		return err
 	}
-	out.Variables = api.ConvertVars(vars)
+	out.Variables = s.debugger.PrettyPrinters().ConvertVars(vars)
+	s.debugger.MarkChangedVariables(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, out.Variables)
 	return nil
 }
 
@@ -453,7 +554,8 @@ func (s *RPCServer) ListFunctionArgs(arg ListFunctionArgsIn, out *ListFunctionAr
 	if err != nil {
 		return err
 	}
-	out.Args = api.ConvertVars(vars)
+	out.Args = s.debugger.PrettyPrinters().ConvertVars(vars)
+	s.debugger.MarkChangedVariables(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, out.Args)
 	return nil
 }
 
@@ -461,16 +563,32 @@ type EvalIn struct {
 	Scope api.EvalScope
 	Expr  string
 	Cfg   *api.LoadConfig
+	// Stringer, if not nil, requests that the String or Error method of the
+	// value of Expr is called, see RPCServer.Eval.
+	Stringer *api.StringerOptions
 }
 
 type EvalOut struct {
 	Variable *api.Variable
+	// StringerErr is the reason why the String or Error method of the
+	// variable could not be called, if it was requested.
+	StringerErr string
 }
 
 // EvalVariable returns a variable in the specified context.
 //
 // See https://github.com/go-delve/delve/wiki/Expressions for
 // a description of acceptable values of arg.Expr.
+//
+// If arg.Stringer is not nil and the value of arg.Expr implements error or
+// fmt.Stringer its Error or String method is called, by injecting a
+// function call in the target like the 'call' command does, and the result
+// is returned in the StringerValue field of the variable. If the call is
+// not possible (for example because the target is a core file), fails or
+// exceeds the limits in arg.Stringer the variable is returned without
+// StringerValue and the reason is returned in out.StringerErr. A call that
+// exceeds the timeout stops the target and remains in progress, it will
+// complete when the target is resumed.
 func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 	cfg := arg.Cfg
 	if cfg == nil {
@@ -480,7 +598,486 @@ func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 	if err != nil {
 		return err
 	}
-	out.Variable = api.ConvertVar(v)
+	out.Variable = s.debugger.PrettyPrinters().ConvertVar(v)
+	vars := []api.Variable{*out.Variable}
+	vars[0].Name = arg.Expr
+	s.debugger.MarkChangedVariables(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, vars)
+	out.Variable.Changed = vars[0].Changed
+	if arg.Stringer != nil {
+		opts := proc.StringerOptions{Timeout: arg.Stringer.Timeout, MaxAlloc: arg.Stringer.MaxAlloc, MaxStringLen: cfg.MaxStringLen}
+		str, err := s.debugger.CallStringer(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, opts)
+		if err != nil {
+			out.StringerErr = err.Error()
+		} else {
+			out.Variable.StringerValue = str
+		}
+	}
+	return nil
+}
+
+type EvalManyIn struct {
+	Scope api.EvalScope
+	Exprs []string
+	Cfg   *api.LoadConfig
+}
+
+type EvalManyOut struct {
+	// Results contains the result of evaluating each expression of Exprs,
+	// in the same order.
+	Results []api.EvalResult
+}
+
+// EvalMany evaluates all the expressions in arg.Exprs in the same scope, it
+// is equivalent to calling Eval for each one of them but only needs one
+// round trip. An expression that can not be evaluated does not stop the
+// evaluation of the others, its error is returned in the Err field of its
+// result instead. An error is returned only if the scope does not exist.
+func (s *RPCServer) EvalMany(arg EvalManyIn, out *EvalManyOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	vs, errs, err := s.debugger.EvalVariablesInScope(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Exprs, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Results = make([]api.EvalResult, len(arg.Exprs))
+	vars := []api.Variable{}
+	idx := []int{}
+	for i := range arg.Exprs {
+		if errs[i] != nil {
+			out.Results[i].Err = errs[i].Error()
+			continue
+		}
+		out.Results[i].Variable = s.debugger.PrettyPrinters().ConvertVar(vs[i])
+		v := *out.Results[i].Variable
+		v.Name = arg.Exprs[i]
+		vars = append(vars, v)
+		idx = append(idx, i)
+	}
+	s.debugger.MarkChangedVariables(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, vars)
+	for j, i := range idx {
+		out.Results[i].Variable.Changed = vars[j].Changed
+	}
+	return nil
+}
+
+type LoadArrayRangeIn struct {
+	Scope api.EvalScope
+	// Addr and Type are the address and type of the slice or array, as
+	// returned in api.Variable.
+	Addr  uint64
+	Type  string
+	Start int64
+	End   int64
+	Cfg   *api.LoadConfig
+}
+
+type LoadArrayRangeOut struct {
+	Variable *api.Variable
+}
+
+// LoadArrayRange returns elements [Start, End) of the slice or array of
+// type Type at address Addr. The returned variable is a slice containing
+// exactly End-Start elements (less if End is past the end of the slice or
+// array), Cfg.MaxArrayValues only applies to the elements themselves.
+// Clients can use this to page through large slices and arrays.
+func (s *RPCServer) LoadArrayRange(arg LoadArrayRangeIn, out *LoadArrayRangeOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	v, err := s.debugger.LoadArrayRange(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Addr, arg.Type, arg.Start, arg.End, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Variable = s.debugger.PrettyPrinters().ConvertVar(v)
+	return nil
+}
+
+type FilterMapIn struct {
+	Scope api.EvalScope
+	Expr  string
+	// KeyRegexp, if not empty, selects the entries whose key, formatted as a
+	// string, matches it.
+	KeyRegexp string
+	// Sorted sorts the entries by key.
+	Sorted bool
+	Cfg    *api.LoadConfig
+}
+
+type FilterMapOut struct {
+	Variable *api.Variable
+}
+
+// FilterMap evaluates Expr, which must be a map, and returns the entries
+// whose key matches KeyRegexp, sorted by key if Sorted is set. At most
+// Cfg.MaxArrayValues entries are returned, the Len field of the returned
+// variable is the total number of entries of the map.
+// Only maps with string, numeric or boolean keys can be filtered and only
+// maps with string or numeric keys can be sorted.
+func (s *RPCServer) FilterMap(arg FilterMapIn, out *FilterMapOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	v, err := s.debugger.FilterMap(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, proc.MapFilter{KeyRegexp: arg.KeyRegexp, Sorted: arg.Sorted}, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Variable = s.debugger.PrettyPrinters().ConvertVar(v)
+	return nil
+}
+
+type SearchVariableIn struct {
+	Scope api.EvalScope
+	Expr  string
+	// Value is the value to search for, numbers and booleans are matched
+	// against their value formatted as a string.
+	Value string
+	Cfg   *api.LoadConfig
+}
+
+type SearchVariableOut struct {
+	// Paths are the expressions that evaluate to the values found.
+	Paths []string
+}
+
+// SearchVariable evaluates Expr and searches the values reachable from it,
+// following pointers, interfaces, slices and maps, for strings, numbers and
+// booleans equal to Value.
+// Cfg limits the search: Cfg.MaxVariableRecurse is the maximum depth,
+// Cfg.MaxArrayValues the maximum number of elements searched in every
+// array, slice or map and Cfg.MaxStringLen the maximum length of the
+// strings read.
+func (s *RPCServer) SearchVariable(arg SearchVariableIn, out *SearchVariableOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 10, MaxStringLen: 1024, MaxArrayValues: 100, MaxStructFields: -1}
+	}
+	paths, err := s.debugger.SearchVariable(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, arg.Value, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Paths = paths
+	return nil
+}
+
+type ExportVariableIn struct {
+	Scope api.EvalScope
+	Expr  string
+	// Format is either "json" or "go".
+	Format string
+	Cfg    *api.LoadConfig
+}
+
+type ExportVariableOut struct {
+	Data string
+}
+
+// ExportVariable evaluates Expr, loading it as specified by Cfg, and
+// serializes its value as a JSON document (if Format is "json") or as a Go
+// composite literal (if Format is "go").
+// If Cfg is not specified the variable is loaded up to a depth of 10.
+func (s *RPCServer) ExportVariable(arg ExportVariableIn, out *ExportVariableOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 10, MaxStringLen: 4096, MaxArrayValues: 1000, MaxStructFields: -1}
+	}
+	v, err := s.debugger.EvalVariableInScope(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Data, err = api.ExportVariable(s.debugger.PrettyPrinters().ConvertVar(v), arg.Format)
+	return err
+}
+
+type ObjectGraphIn struct {
+	Scope api.EvalScope
+	// Exprs are the expressions of the root variables.
+	Exprs []string
+	Cfg   *api.LoadConfig
+}
+
+type ObjectGraphOut struct {
+	Graph *api.ObjectGraph
+}
+
+// ObjectGraph evaluates Exprs and returns the graph of the objects
+// reachable from them through pointers, slices, maps and interfaces.
+// Cfg.MaxVariableRecurse is the maximum number of pointers followed from
+// the roots and Cfg.MaxArrayValues the maximum number of elements of each
+// array, slice or map that are scanned.
+func (s *RPCServer) ObjectGraph(arg ObjectGraphIn, out *ObjectGraphOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 10, MaxStringLen: 64, MaxArrayValues: 100, MaxStructFields: -1}
+	}
+	g, err := s.debugger.ObjectGraph(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Exprs, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Graph = api.ConvertObjectGraph(g)
+	return nil
+}
+
+type ViewAsIn struct {
+	Scope api.EvalScope
+	// Expr must evaluate to a pointer, an unsafe.Pointer or an integer.
+	Expr string
+	Type string
+	Cfg  *api.LoadConfig
+}
+
+type ViewAsOut struct {
+	Variable *api.Variable
+}
+
+// ViewAs evaluates Expr and returns a variable of type Type at the address
+// it evaluates to. If Type is a pointer type the returned variable is a
+// pointer to the address instead.
+// Type can be a Go type expression or a type name with the full package
+// path, for example "*github.com/org/pkg.T".
+func (s *RPCServer) ViewAs(arg ViewAsIn, out *ViewAsOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	v, err := s.debugger.ViewAs(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, arg.Type, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Variable = s.debugger.PrettyPrinters().ConvertVar(v)
+	return nil
+}
+
+type ChanWaitersIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type ChanWaitersOut struct {
+	// Recv lists the goroutines waiting to receive from the channel.
+	Recv []*api.Goroutine
+	// Send lists the goroutines waiting to send to the channel.
+	Send []*api.Goroutine
+}
+
+// ChanWaiters evaluates arg.Expr, which must be a channel, and returns the
+// goroutines blocked receiving from it or sending to it.
+func (s *RPCServer) ChanWaiters(arg ChanWaitersIn, out *ChanWaitersOut) error {
+	waiters, err := s.debugger.ChanWaiters(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr)
+	if err != nil {
+		return err
+	}
+	out.Recv = api.ConvertGoroutines(waiters.Recv)
+	out.Send = api.ConvertGoroutines(waiters.Send)
+	return nil
+}
+
+type MutexInfoIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type MutexInfoOut struct {
+	State *api.MutexState
+}
+
+// MutexInfo evaluates arg.Expr, which must be a sync.Mutex or a
+// sync.RWMutex, and returns its state and the goroutines blocked on it.
+// The Go runtime does not record which goroutine holds a lock.
+func (s *RPCServer) MutexInfo(arg MutexInfoIn, out *MutexInfoOut) error {
+	state, err := s.debugger.MutexInfo(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr)
+	if err != nil {
+		return err
+	}
+	out.State = api.ConvertMutexState(state)
+	return nil
+}
+
+type InterfaceItabIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type InterfaceItabOut struct {
+	Itab *api.Itab
+}
+
+// InterfaceItab evaluates arg.Expr, which must be an interface, and
+// returns its itab: the interface type, the concrete type of the value it
+// stores and the functions its method table points to.
+// Empty interfaces do not have an itab, only their concrete type is
+// returned.
+func (s *RPCServer) InterfaceItab(arg InterfaceItabIn, out *InterfaceItabOut) error {
+	itab, err := s.debugger.InterfaceItab(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr)
+	if err != nil {
+		return err
+	}
+	out.Itab = api.ConvertItab(itab)
+	return nil
+}
+
+type ContextChainIn struct {
+	Scope api.EvalScope
+	Expr  string
+	// Cfg is used to load the keys, values and errors of the contexts.
+	Cfg *api.LoadConfig
+}
+
+type ContextChainOut struct {
+	Layers []api.ContextLayer
+}
+
+// ContextChain evaluates arg.Expr, which must be a context.Context, and
+// returns the chain of contexts it wraps, starting with the value of
+// arg.Expr and ending with the root context.
+func (s *RPCServer) ContextChain(arg ContextChainIn, out *ContextChainOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	layers, err := s.debugger.ContextChain(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Layers = api.ConvertContextChain(layers)
+	return nil
+}
+
+type AddWatchIn struct {
+	Expr string
+	// Cfg is used to load the value of Expr every time it is evaluated.
+	Cfg *api.LoadConfig
+}
+
+type AddWatchOut struct {
+	Watch api.Watch
+}
+
+// AddWatch adds a watch expression. Watch expressions are evaluated in the
+// current frame of the selected goroutine every time the target stops and
+// their values are returned in the Watches field of api.DebuggerState.
+func (s *RPCServer) AddWatch(arg AddWatchIn, out *AddWatchOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	w, err := s.debugger.AddWatch(arg.Expr, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Watch = *w
+	return nil
+}
+
+type RemoveWatchIn struct {
+	ID int
+}
+
+type RemoveWatchOut struct {
+}
+
+// RemoveWatch removes the watch expression with the given ID.
+func (s *RPCServer) RemoveWatch(arg RemoveWatchIn, out *RemoveWatchOut) error {
+	return s.debugger.RemoveWatch(arg.ID)
+}
+
+type ListWatchesIn struct {
+}
+
+type ListWatchesOut struct {
+	Watches []api.Watch
+}
+
+// ListWatches lists all watch expressions, their values are returned by
+// State and by the commands that resume the target.
+func (s *RPCServer) ListWatches(arg ListWatchesIn, out *ListWatchesOut) error {
+	out.Watches = s.debugger.ListWatches()
+	return nil
+}
+
+type SaveSessionIn struct {
+	// SubstitutePathRules are stored in the session, so that the client can
+	// restore them, but they are not used by the debugger.
+	SubstitutePathRules [][2]string
+}
+
+type SaveSessionOut struct {
+	Session api.Session
+}
+
+// SaveSession returns the breakpoints, with their conditions, and the
+// watch expressions of the debug session. The session can be saved by the
+// client and restored with LoadSession, or with the --init-state command
+// line option, against a new instance of the target.
+func (s *RPCServer) SaveSession(arg SaveSessionIn, out *SaveSessionOut) error {
+	out.Session = *s.debugger.SaveSession()
+	out.Session.SubstitutePath = arg.SubstitutePathRules
+	return nil
+}
+
+type LoadSessionIn struct {
+	Session api.Session
+}
+
+type LoadSessionOut struct {
+	// DiscardedBreakpoints are the breakpoints of the session that could
+	// not be created.
+	DiscardedBreakpoints []api.DiscardedBreakpoint
+}
+
+// LoadSession creates the breakpoints and watch expressions of a session
+// returned by SaveSession. Breakpoints are set using their file and line,
+// breakpoints that can not be set, for example because the source file
+// changed, are returned in DiscardedBreakpoints.
+func (s *RPCServer) LoadSession(arg LoadSessionIn, out *LoadSessionOut) error {
+	var err error
+	out.DiscardedBreakpoints, err = s.debugger.LoadSession(&arg.Session)
+	return err
+}
+
+type FindReferrersIn struct {
+	Addr uint64
+}
+
+type FindReferrersOut struct {
+	Referrers api.Referrers
+}
+
+// FindReferrers scans the heap, the package variables and the goroutine
+// stacks for pointers to the object containing arg.Addr and returns the
+// objects containing them. Memory is scanned conservatively: any word that
+// has the value of an address inside the object is considered a pointer to
+// it.
+func (s *RPCServer) FindReferrers(arg FindReferrersIn, out *FindReferrersOut) error {
+	refs, err := s.debugger.FindReferrers(arg.Addr)
+	if err != nil {
+		return err
+	}
+	out.Referrers = *api.ConvertReferrers(refs)
+	return nil
+}
+
+type HeapHistogramIn struct {
+}
+
+type HeapHistogramOut struct {
+	Entries []api.HeapHistogramEntry
+}
+
+// HeapHistogram walks the heap of the target process, which can be a live
+// process or a core file, and returns the number of allocated objects of
+// each type and the memory they use, sorted by the memory used.
+// The type of heap objects is only known for objects larger than 512 bytes
+// allocated by Go 1.22 or later, other objects are grouped by size class.
+func (s *RPCServer) HeapHistogram(arg HeapHistogramIn, out *HeapHistogramOut) error {
+	entries, err := s.debugger.HeapHistogram()
+	if err != nil {
+		return err
+	}
+	out.Entries = api.ConvertHeapHistogram(entries)
 	return nil
 }
 
@@ -517,6 +1114,29 @@ func (s *RPCServer) ListSources(arg ListSourcesIn, out *ListSourcesOut) error {
 	return nil
 }
 
+type GetSourceFileIn struct {
+	// Path is the path of the file, as returned by ListSources.
+	Path string
+}
+
+type GetSourceFileOut struct {
+	File api.SourceFile
+}
+
+// GetSourceFile returns the contents of one of the source files listed in
+// the debug info of the target, read from the machine running the
+// debugger. It lets clients display the sources when they do not share a
+// filesystem with it. Only files returned by ListSources can be read, the
+// substitute-path rules of the server are used to find them.
+func (s *RPCServer) GetSourceFile(arg GetSourceFileIn, out *GetSourceFileOut) error {
+	f, err := s.debugger.SourceFile(arg.Path)
+	if err != nil {
+		return err
+	}
+	out.File = *f
+	return nil
+}
+
 type ListFunctionsIn struct {
 	Filter string
 }
@@ -556,11 +1176,21 @@ func (s *RPCServer) ListTypes(arg ListTypesIn, out *ListTypesOut) error {
 type ListGoroutinesIn struct {
 	Start int
 	Count int
+
+	// Filters, if specified, restrict the goroutines returned to the ones
+	// that satisfy all of them.
+	Filters []api.ListGoroutinesFilter
+	api.GoroutineGroupingOptions
 }
 
 type ListGoroutinesOut struct {
 	Goroutines []*api.Goroutine
 	Nextg      int
+	// Groups describes the groups of goroutines, when GroupBy is specified.
+	Groups []api.GoroutineGroup
+	// TooManyGroups is true if some groups were discarded because there were
+	// more than MaxGroups.
+	TooManyGroups bool
 }
 
 // ListGoroutines lists all goroutines.
@@ -569,11 +1199,26 @@ type ListGoroutinesOut struct {
 // parameter, to get more goroutines from ListGoroutines.
 // Passing a value of Start that wasn't returned by ListGoroutines will skip
 // an undefined number of goroutines.
+//
+// Filters are applied to each batch of Count goroutines, therefore fewer
+// than Count goroutines can be returned even when Nextg is not negative.
+//
+// If GroupBy is specified the goroutines are returned grouped by the value
+// of the specified field, sorted by the name of the group, and Groups
+// describes where each group starts. At most MaxGroupMembers goroutines are
+// returned for each group, but the Total of each group counts all its
+// members. Grouping applies to each batch of goroutines, use a Count of
+// zero to group all goroutines.
 func (s *RPCServer) ListGoroutines(arg ListGoroutinesIn, out *ListGoroutinesOut) error {
 	gs, nextg, err := s.debugger.Goroutines(arg.Start, arg.Count)
 	if err != nil {
 		return err
 	}
+	gs, err = s.debugger.FilterGoroutines(gs, arg.Filters)
+	if err != nil {
+		return err
+	}
+	gs, out.Groups, out.TooManyGroups = s.debugger.GroupGoroutines(gs, &arg.GoroutineGroupingOptions)
 	s.debugger.LockTarget()
 	defer s.debugger.UnlockTarget()
 	out.Goroutines = api.ConvertGoroutines(gs)
@@ -675,6 +1320,21 @@ func (s *RPCServer) Recorded(arg RecordedIn, out *RecordedOut) error {
 	return nil
 }
 
+type ListRecordersIn struct {
+}
+
+type ListRecordersOut struct {
+	Recorders []api.Recorder
+}
+
+// ListRecorders returns the record and replay engines supported by this
+// instance of Delve, which can be used as the backend of a new debug
+// session, and the features supported by their recordings.
+func (s *RPCServer) ListRecorders(arg ListRecordersIn, out *ListRecordersOut) error {
+	out.Recorders = s.debugger.Recorders()
+	return nil
+}
+
 type CheckpointIn struct {
 	Where string
 }
@@ -720,6 +1380,405 @@ func (s *RPCServer) ClearCheckpoint(arg ClearCheckpointIn, out *ClearCheckpointO
 	return s.debugger.ClearCheckpoint(arg.ID)
 }
 
+type RunToEventIn struct {
+	// Event is the rr event number, as reported by DebuggerState.When.
+	Event uint64
+}
+
+type RunToEventOut struct {
+}
+
+// RunToEvent resets a recording to the start of the specified rr event.
+// Only supported by the rr backend.
+func (s *RPCServer) RunToEvent(arg RunToEventIn, out *RunToEventOut) error {
+	return s.debugger.RunToEvent(arg.Event)
+}
+
+type WaitEventsIn struct {
+	// After is the ID of the last event received by the client, only events
+	// with a greater ID are returned.
+	After uint64
+	// Timeout is the maximum time to wait for an event, zero means that
+	// there is no timeout.
+	Timeout time.Duration
+}
+
+type WaitEventsOut struct {
+	Events []api.Event
+}
+
+// WaitEvents returns the events that happened after the event with ID
+// After, waiting for one to happen if there are none. Events are reported
+// to every client connected to the server: they are generated when the
+// target stops or exits after a command from any client and when it loads
+// new shared objects or plugins. Nothing is returned if the timeout
+// expires before an event happens.
+func (s *RPCServer) WaitEvents(arg WaitEventsIn, cb service.RPCCallback) {
+	evs, err := s.debugger.WaitEvents(arg.After, arg.Timeout)
+	if err != nil {
+		cb.Return(nil, err)
+		return
+	}
+	cb.Return(WaitEventsOut{Events: evs}, nil)
+}
+
+type AddEventHookIn struct {
+	Hook api.EventHook
+}
+
+type AddEventHookOut struct {
+	Hook api.EventHook
+}
+
+// AddEventHook registers a starlark script that the server runs every time
+// the target stops or exits, until it is removed with RemoveEventHook. Hooks
+// keep running after the client that added them disconnects, which is
+// useful with --accept-multiclient to collect data from a target left
+// running unattended.
+//
+// The script must define a function main, which is called with the event
+// that triggered the hook as its only argument, and can use all the
+// builtins available to starlark scripts run by the terminal client,
+// except dlv_command. The script runs with a timeout of 30 seconds.
+func (s *RPCServer) AddEventHook(arg AddEventHookIn, out *AddEventHookOut) error {
+	hook, err := s.debugger.AddEventHook(arg.Hook)
+	if err != nil {
+		return err
+	}
+	out.Hook = *hook
+	return nil
+}
+
+type RemoveEventHookIn struct {
+	Id int
+}
+
+type RemoveEventHookOut struct {
+}
+
+// RemoveEventHook removes an event hook.
+func (s *RPCServer) RemoveEventHook(arg RemoveEventHookIn, out *RemoveEventHookOut) error {
+	return s.debugger.RemoveEventHook(arg.Id)
+}
+
+type ListEventHooksIn struct {
+}
+
+type ListEventHooksOut struct {
+	Hooks []api.EventHook
+}
+
+// ListEventHooks returns the event hooks, with the result of the last time
+// each of them ran.
+func (s *RPCServer) ListEventHooks(arg ListEventHooksIn, out *ListEventHooksOut) error {
+	out.Hooks = s.debugger.EventHooks()
+	return nil
+}
+
+type WriteStdinIn struct {
+	Data string
+	// EOF closes the standard input of the target after writing Data.
+	EOF bool
+}
+
+type WriteStdinOut struct {
+}
+
+// WriteStdin writes to the standard input of the target. Only available
+// if the headless instance was started with --capture-output, in which
+// case the output of the target is reported as events, see WaitEvents.
+// The call does not return until the target has consumed the data.
+func (s *RPCServer) WriteStdin(arg WriteStdinIn, cb service.RPCCallback) {
+	if err := s.debugger.WriteStdin([]byte(arg.Data), arg.EOF); err != nil {
+		cb.Return(nil, err)
+		return
+	}
+	cb.Return(WriteStdinOut{}, nil)
+}
+
+type SetSignalPolicyIn struct {
+	// Signal is the name or the number of the signal.
+	Signal string
+	Stop   bool
+	Print  bool
+	Pass   bool
+}
+
+type SetSignalPolicyOut struct {
+	Policy api.SignalPolicy
+}
+
+// SetSignalPolicy changes how a signal received by the target process is
+// handled: whether it stops the target process, whether it is reported to
+// the user and whether it is delivered to the target process.
+func (s *RPCServer) SetSignalPolicy(arg SetSignalPolicyIn, out *SetSignalPolicyOut) error {
+	var err error
+	out.Policy, err = s.debugger.SetSignalPolicy(arg.Signal, proc.SignalPolicy{Stop: arg.Stop, Print: arg.Print, Pass: arg.Pass})
+	return err
+}
+
+type ListSignalPoliciesIn struct {
+}
+
+type ListSignalPoliciesOut struct {
+	Policies []api.SignalPolicy
+}
+
+// ListSignalPolicies lists the signals whose handling was changed with
+// SetSignalPolicy, all other signals are silently delivered to the target
+// process.
+func (s *RPCServer) ListSignalPolicies(arg ListSignalPoliciesIn, out *ListSignalPoliciesOut) error {
+	out.Policies = s.debugger.SignalPolicies()
+	return nil
+}
+
+type ListTimersIn struct {
+}
+
+type ListTimersOut struct {
+	Timers []api.Timer
+	// Now is an approximation of the current value of the monotonic clock
+	// of the target process, zero if it could not be determined.
+	Now int64
+}
+
+// ListTimers lists the timers pending in the target process, sorted by the
+// time at which they will fire.
+func (s *RPCServer) ListTimers(arg ListTimersIn, out *ListTimersOut) error {
+	var err error
+	out.Timers, out.Now, err = s.debugger.Timers()
+	return err
+}
+
+type DumpIn struct {
+	// Destination is the path of the core file to write.
+	Destination string
+	// Selective restricts the dump to the stacks and global variables of the
+	// target and the memory reachable from them.
+	Selective bool
+	// Minidump writes a Windows minidump instead of an ELF core file, this
+	// is only supported by the native backend on windows.
+	Minidump bool
+	// Compress compresses the output file with gzip.
+	Compress bool
+	// Sparse omits pages that only contain zeroes from the output file.
+	Sparse bool
+}
+
+type DumpOut struct {
+}
+
+// Dump writes a core file of the target process to arg.Destination, the
+// core file can be opened with 'dlv core'.
+func (s *RPCServer) Dump(arg DumpIn, out *DumpOut) error {
+	return s.debugger.Dump(arg.Destination, api.DumpOptions{
+		Selective: arg.Selective,
+		Minidump:  arg.Minidump,
+		Compress:  arg.Compress,
+		Sparse:    arg.Sparse,
+	})
+}
+
+type RawCallIn struct {
+	// Unsafe must be set to acknowledge that raw calls bypass all type
+	// checking and can corrupt or crash the target process.
+	Unsafe bool
+	// Addr is the address of the function to call or, if Syscall is set,
+	// the number of the system call to execute.
+	Addr uint64
+	// Args are the arguments of the call, passed according to the C
+	// calling convention (or system call convention) of the target.
+	Args    []uint64
+	Syscall bool
+}
+
+type RawCallOut struct {
+	Result api.RawCallResult
+}
+
+// RawCall calls the function at arg.Addr on the current thread, or
+// executes the system call number arg.Addr if arg.Syscall is set, without
+// any type checking of its arguments and returns the contents of the
+// return registers.
+// This is meant for calls that can not be made by evaluating a call
+// expression (C functions, runtime internals): all other threads are
+// resumed while the call runs, breakpoints hit during the call are ignored
+// and the function runs on the stack of the current thread, which can
+// corrupt or crash the target. The call is only made if arg.Unsafe is set.
+func (s *RPCServer) RawCall(arg RawCallIn, out *RawCallOut) error {
+	if !arg.Unsafe {
+		return errors.New("raw calls can corrupt the target process, set Unsafe to make one")
+	}
+	r, err := s.debugger.RawCall(arg.Addr, arg.Args, arg.Syscall)
+	if err != nil {
+		return err
+	}
+	out.Result = *r
+	return nil
+}
+
+type PatchFunctionIn struct {
+	// Function is the name of the function to patch.
+	Function string
+	// Return is the list of values returned by the patched function, one
+	// for each result. It is only used if Code is empty.
+	Return []string
+	// Code is position independent machine code that replaces the body of
+	// the function.
+	Code []byte
+}
+
+type PatchFunctionOut struct {
+	Patch api.FunctionPatch
+}
+
+// PatchFunction changes the code of arg.Function. If arg.Code is empty
+// the function is changed to immediately return the values in
+// arg.Return, otherwise its entry point is changed to jump to a copy of
+// arg.Code.
+// Integer and boolean literals, nil, addresses and quoted strings can be
+// returned. Memory for the patch is allocated by resuming the current
+// thread to make a system call.
+func (s *RPCServer) PatchFunction(arg PatchFunctionIn, out *PatchFunctionOut) error {
+	var code []byte
+	if len(arg.Code) > 0 {
+		code = arg.Code
+	}
+	patch, err := s.debugger.PatchFunction(arg.Function, arg.Return, code)
+	if err != nil {
+		return err
+	}
+	out.Patch = *patch
+	return nil
+}
+
+type UnpatchFunctionIn struct {
+	ID int
+}
+
+type UnpatchFunctionOut struct {
+}
+
+// UnpatchFunction reverts the function patch with the given ID.
+func (s *RPCServer) UnpatchFunction(arg UnpatchFunctionIn, out *UnpatchFunctionOut) error {
+	return s.debugger.UnpatchFunction(arg.ID)
+}
+
+type ListFunctionPatchesIn struct {
+}
+
+type ListFunctionPatchesOut struct {
+	Patches []*api.FunctionPatch
+}
+
+// ListFunctionPatches returns the list of function patches currently
+// applied.
+func (s *RPCServer) ListFunctionPatches(arg ListFunctionPatchesIn, out *ListFunctionPatchesOut) error {
+	out.Patches = s.debugger.FunctionPatches()
+	return nil
+}
+
+type GuardMemoryIn struct {
+	Addr uint64
+	Size uint64
+	// Prot is the new protection of the memory, "r" (the default) for
+	// read-only memory, "none" for memory that can not be accessed.
+	Prot string
+}
+
+type GuardMemoryOut struct {
+	Guard api.MemoryGuard
+}
+
+// GuardMemory changes the protection of the pages containing the memory
+// range [arg.Addr, arg.Addr+arg.Size), an access not allowed by the new
+// protection stops the target process and is reported in the GuardFault
+// field of the DebuggerState.
+// The protection is changed by resuming the current thread to call
+// mprotect. Only supported by the native backend on linux.
+func (s *RPCServer) GuardMemory(arg GuardMemoryIn, out *GuardMemoryOut) error {
+	var prot proc.MemoryProtection
+	switch arg.Prot {
+	case "", "r":
+		prot = proc.ProtRead
+	case "none":
+		prot = proc.ProtNone
+	default:
+		return fmt.Errorf("unknown memory protection %q", arg.Prot)
+	}
+	guard, err := s.debugger.GuardMemory(arg.Addr, arg.Size, prot)
+	if err != nil {
+		return err
+	}
+	out.Guard = *guard
+	return nil
+}
+
+type UnguardMemoryIn struct {
+	ID int
+}
+
+type UnguardMemoryOut struct {
+}
+
+// UnguardMemory restores the protection of the memory guarded by the
+// guard with the given ID.
+func (s *RPCServer) UnguardMemory(arg UnguardMemoryIn, out *UnguardMemoryOut) error {
+	return s.debugger.UnguardMemory(arg.ID)
+}
+
+type ListMemoryGuardsIn struct {
+}
+
+type ListMemoryGuardsOut struct {
+	Guards []*api.MemoryGuard
+}
+
+// ListMemoryGuards returns the list of memory guards.
+func (s *RPCServer) ListMemoryGuards(arg ListMemoryGuardsIn, out *ListMemoryGuardsOut) error {
+	out.Guards = s.debugger.MemoryGuards()
+	return nil
+}
+
+type EnableBranchTraceIn struct {
+	Enable bool
+}
+
+type EnableBranchTraceOut struct {
+}
+
+// EnableBranchTrace starts, or stops if arg.Enable is false, recording the
+// branches executed by all threads of the target, using Intel Processor
+// Trace. Only supported by the native backend on linux/amd64.
+func (s *RPCServer) EnableBranchTrace(arg EnableBranchTraceIn, out *EnableBranchTraceOut) error {
+	return s.debugger.EnableBranchTrace(arg.Enable)
+}
+
+type BranchHistoryIn struct {
+	// ThreadID is the thread whose branches are returned, 0 for the
+	// current thread.
+	ThreadID int
+	Count    int
+}
+
+type BranchHistoryOut struct {
+	// Branches contains the last Count branches executed by the thread,
+	// oldest first, the last one leads to the instruction where the thread
+	// is stopped.
+	Branches []api.Branch
+}
+
+// BranchHistory returns the last branches executed by a thread, branch
+// tracing must have been enabled with EnableBranchTrace.
+func (s *RPCServer) BranchHistory(arg BranchHistoryIn, out *BranchHistoryOut) error {
+	branches, err := s.debugger.BranchHistory(arg.ThreadID, arg.Count)
+	if err != nil {
+		return err
+	}
+	out.Branches = branches
+	return nil
+}
+
 type IsMulticlientIn struct {
 }
 
@@ -735,6 +1794,22 @@ func (s *RPCServer) IsMulticlient(arg IsMulticlientIn, out *IsMulticlientOut) er
 	return nil
 }
 
+type GetCapabilitiesIn struct {
+}
+
+type GetCapabilitiesOut struct {
+	Capabilities api.Capabilities
+}
+
+// GetCapabilities returns the features supported by the target and the
+// backend, clients can use it to disable commands that are bound to fail,
+// for example reverse execution on a live process or function calls on a
+// core file.
+func (s *RPCServer) GetCapabilities(arg GetCapabilitiesIn, out *GetCapabilitiesOut) error {
+	out.Capabilities = *s.debugger.Capabilities()
+	return nil
+}
+
 // FunctionReturnLocationsIn holds arguments for the
 // FunctionReturnLocationsRPC call. It holds the name of
 // the function for which all return locations should be
@@ -849,6 +1924,61 @@ func (s *RPCServer) ExamineMemory(arg ExamineMemoryIn, out *ExaminedMemoryOut) e
 	return nil
 }
 
+// WriteMemoryIn holds the arguments of WriteMemory
+type WriteMemoryIn struct {
+	Address uint64
+	Data    []byte
+}
+
+// WriteMemoryOut holds the return values of WriteMemory
+type WriteMemoryOut struct {
+}
+
+// WriteMemory writes arg.Data to the memory of the target at arg.Address.
+func (s *RPCServer) WriteMemory(arg WriteMemoryIn, out *WriteMemoryOut) error {
+	if len(arg.Data) > 1000 {
+		return fmt.Errorf("len must be less than or equal to 1000")
+	}
+	return s.debugger.WriteMemory(arg.Address, arg.Data)
+}
+
+// maxStringChunkLen is the maximum number of bytes returned by
+// LoadStringChunk.
+const maxStringChunkLen = 1 << 20
+
+// LoadStringChunkIn holds the arguments of LoadStringChunk
+type LoadStringChunkIn struct {
+	Addr   uint64
+	Length int
+}
+
+// LoadStringChunkOut holds the return values of LoadStringChunk
+type LoadStringChunkOut struct {
+	Data []byte
+}
+
+// LoadStringChunk reads arg.Length bytes starting at arg.Addr, it is meant
+// to be used to load the contents of a string or []byte truncated because
+// of the MaxStringLen or MaxArrayValues limits of the LoadConfig, in chunks,
+// without evaluating the variable again with a bigger limit.
+// The chunk starting at offset off of a variable v is at address
+// v.Base+off, and the total length of the string is v.Len.
+// At most 1MB can be read by a single call.
+func (s *RPCServer) LoadStringChunk(arg LoadStringChunkIn, out *LoadStringChunkOut) error {
+	if arg.Length < 0 || arg.Length > maxStringChunkLen {
+		return fmt.Errorf("length must be between 0 and %d", maxStringChunkLen)
+	}
+	if arg.Addr == 0 {
+		return errors.New("invalid address")
+	}
+	data, err := s.debugger.ExamineMemory(arg.Addr, arg.Length)
+	if err != nil {
+		return err
+	}
+	out.Data = data
+	return nil
+}
+
 type StopRecordingIn struct {
 }
 
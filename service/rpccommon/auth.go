@@ -0,0 +1,100 @@
+package rpccommon
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-delve/delve/service"
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// methodOperations maps the methods that an authentication policy can deny
+// to the operation they perform.
+var methodOperations = map[string]string{
+	"RPCServer.Set":               service.OpSet,
+	"RPCServer.SetSymbol":         service.OpSet,
+	"RPCServer.WriteMemory":       service.OpSet,
+	"RPCServer.WriteStdin":        service.OpSet,
+	"RPCServer.LoadSession":       service.OpSet,
+	"RPCServer.EnableBranchTrace": service.OpSet,
+	"RPCServer.RawCall":           service.OpCall,
+	"RPCServer.PatchFunction":     service.OpCall,
+	"RPCServer.UnpatchFunction":   service.OpCall,
+	// Memory guards are set by calling mprotect in the target.
+	"RPCServer.GuardMemory":   service.OpCall,
+	"RPCServer.UnguardMemory": service.OpCall,
+	"RPCServer.Detach":        service.OpDetach,
+	"RPCServer.Restart":       service.OpExec,
+	"RPCServer.Dump":          service.OpDump,
+	// Checkpoints made with CRIU are images written on the machine running
+	// the server.
+	"RPCServer.Checkpoint":      service.OpDump,
+	"RPCServer.ClearCheckpoint": service.OpDump,
+	// Event hooks run arbitrary scripts, with the same privileges as the
+	// server.
+	"RPCServer.AddEventHook": service.OpExec,
+}
+
+// unauthenticatedMethods can be called before authenticating.
+var unauthenticatedMethods = map[string]bool{
+	"RPCServer.Authenticate": true,
+	"RPCServer.GetVersion":   true,
+}
+
+// operation returns the operation, that an authentication policy can
+// deny, performed by calling method with argument arg.
+func operation(method string, arg interface{}) string {
+	switch arg := arg.(type) {
+	case api.DebuggerCommand:
+		if arg.Name == api.Call {
+			return service.OpCall
+		}
+	case *api.DebuggerCommand:
+		if arg != nil && arg.Name == api.Call {
+			return service.OpCall
+		}
+	case rpc2.EvalIn:
+		if arg.Stringer != nil {
+			return service.OpCall
+		}
+	}
+	return methodOperations[method]
+}
+
+// checkAuth returns an error if the connection served by s is not allowed
+// to call method with argument arg.
+func (s *RPCServer) checkAuth(method string, arg interface{}) error {
+	if s.s.config.AuthPolicy == nil {
+		return nil
+	}
+	if s.token == nil {
+		if unauthenticatedMethods[method] {
+			return nil
+		}
+		return service.ErrNotAuthenticated
+	}
+	if op := operation(method, arg); op != "" && s.token.Denies(op) {
+		return fmt.Errorf("operation %q not allowed by the authentication policy", op)
+	}
+	return nil
+}
+
+// Authenticate authenticates the connection with one of the tokens of the
+// server's authentication policy. When the server has an authentication
+// policy every other request, except GetVersion, fails until the
+// connection is authenticated.
+// Servers without an authentication policy accept any token.
+func (s *RPCServer) Authenticate(args api.AuthenticateIn, out *api.AuthenticateOut) error {
+	policy := s.s.config.AuthPolicy
+	if policy == nil {
+		return nil
+	}
+	t := policy.Lookup(args.Token)
+	if t == nil {
+		return errors.New("invalid authentication token")
+	}
+	s.token = t
+	out.Deny = t.Deny
+	return nil
+}
@@ -0,0 +1,55 @@
+package rpccommon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// unrestrictedMethods are the methods that do not need to be mapped to an
+// operation because they only inspect the target or control its execution
+// in ways that a debugger client is always allowed to.
+var unrestrictedMethods = map[string]bool{
+	"AddWatch": true, "AmendBreakpoint": true, "Ancestors": true, "AttachedToExistingProcess": true,
+	"BranchHistory": true, "Disassemble": true, "FindLocation": true,
+	"CancelCall": true, "CancelNext": true, "ChanWaiters": true, "ClearBreakpoint": true,
+	"Command": true, "ContextChain": true, "CreateBreakpoint": true, "Eval": true,
+	"EvalMany": true, "ExamineMemory": true, "ExportVariable": true, "FilterMap": true,
+	"FindReferrers": true, "FunctionReturnLocations": true, "GetBreakpoint": true,
+	"GetCapabilities": true, "GetSourceFile": true, "GetThread": true, "GoroutineTree": true,
+	"HeapHistogram": true, "InterfaceItab": true, "IsMulticlient": true, "LastModified": true,
+	"ListBreakpoints": true, "ListCheckpoints": true, "ListDynamicLibraries": true,
+	"ListEventHooks": true, "ListFunctionArgs": true, "ListFunctionPatches": true,
+	"ListFunctions": true, "ListGoroutines": true, "ListLocalVars": true,
+	"ListMemoryGuards": true, "ListPackageVars": true, "ListPackagesBuildInfo": true,
+	"ListRecorders": true, "ListRegisters": true, "ListSignalPolicies": true,
+	"ListSources": true, "ListThreads": true, "ListTimers": true, "ListTypes": true,
+	"ListWatches": true, "LoadArrayRange": true, "LoadStringChunk": true, "MutexInfo": true,
+	"ObjectGraph": true, "ProcessPid": true, "Recorded": true, "RemoveEventHook": true,
+	"RemoveWatch": true, "RunToEvent": true, "SaveSession": true, "SearchVariable": true,
+	"SetApiVersion": true, "SetObserver": true, "SetSignalPolicy": true,
+	"StackframeDetails": true, "Stacktrace": true, "State": true, "StopRecording": true,
+	"ViewAs": true, "WaitEvents": true,
+}
+
+func TestMethodOperations(t *testing.T) {
+	// Every method that changes the state of the target, or of the machine
+	// running the server, must be mapped to an operation so that
+	// authentication policies can deny it.
+	for _, rcvr := range []interface{}{&rpc2.RPCServer{}, &RPCServer{}} {
+		typ := reflect.TypeOf(rcvr)
+		for i := 0; i < typ.NumMethod(); i++ {
+			name := typ.Method(i).Name
+			method := "RPCServer." + name
+			if methodOperations[method] == "" && !unauthenticatedMethods[method] && !unrestrictedMethods[name] {
+				t.Errorf("%s is not mapped to an operation, add it to methodOperations or, if it does not change any state, to unrestrictedMethods", method)
+			}
+		}
+	}
+	for method := range methodOperations {
+		if unrestrictedMethods[method[len("RPCServer."):]] {
+			t.Errorf("%s is both restricted and unrestricted", method)
+		}
+	}
+}
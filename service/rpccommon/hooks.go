@@ -0,0 +1,148 @@
+package rpccommon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-delve/delve/pkg/terminal/starbind"
+	"github.com/go-delve/delve/service"
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// eventHookTimeout is the maximum time an event hook can run.
+const eventHookTimeout = 30 * time.Second
+
+// hookContext is the context of the starlark scripts of event hooks, they
+// use a client connected to the server itself.
+type hookContext struct {
+	client service.Client
+}
+
+func (ctx *hookContext) Client() service.Client { return ctx.client }
+
+func (ctx *hookContext) RegisterCommand(name, helpMsg string, cmdfn func(args string) error) {
+	// Commands defined by event hooks are ignored, there is no terminal to
+	// run them.
+}
+
+func (ctx *hookContext) CallCommand(cmdstr string) error {
+	return errors.New("dlv_command can not be used by event hooks")
+}
+
+func (ctx *hookContext) Scope() api.EvalScope {
+	return api.EvalScope{GoroutineID: -1}
+}
+
+func (ctx *hookContext) LoadConfig() api.LoadConfig {
+	return api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+}
+
+// runEventHooks runs the event hooks registered with the debugger, see
+// RPCServer.AddEventHook, until the debugger detaches.
+func (s *ServerImpl) runEventHooks() {
+	var ctx *hookContext
+	envs := map[int]*starbind.Env{}
+	var after uint64
+	for {
+		evs, err := s.debugger.WaitEvents(after, 0)
+		if err != nil {
+			if ctx != nil {
+				ctx.client.Disconnect(false)
+			}
+			return
+		}
+		for _, ev := range evs {
+			after = ev.ID
+			resume := false
+			hooks := s.debugger.EventHooks()
+			for id := range envs {
+				if !hasEventHook(hooks, id) {
+					delete(envs, id)
+				}
+			}
+			for _, hook := range hooks {
+				if !eventHookMatches(&hook, &ev) {
+					continue
+				}
+				if ctx == nil {
+					ctx = s.newHookContext()
+				}
+				env := envs[hook.ID]
+				if env == nil {
+					env = starbind.New(ctx)
+					envs[hook.ID] = env
+				}
+				output, err := runEventHook(env, &hook, &ev)
+				s.debugger.EventHookRan(hook.ID, output, err)
+				if err != nil {
+					s.log.Errorf("event hook %d: %v", hook.ID, err)
+				}
+				resume = resume || hook.Resume
+			}
+			if resume && ev.State != nil && !ev.State.Exited {
+				// Continue returns once the target stops again, which will be
+				// seen by the next call to WaitEvents.
+				go func(ch <-chan *api.DebuggerState) {
+					for range ch {
+					}
+				}(ctx.client.Continue())
+			}
+		}
+	}
+}
+
+// newHookContext returns a context for event hooks with a client connected
+// to the server through an in-memory connection.
+func (s *ServerImpl) newHookContext() *hookContext {
+	serverConn, clientConn := net.Pipe()
+	go s.serveJSONCodec(serverConn, true)
+	return &hookContext{client: rpc2.NewClientFromConn(clientConn)}
+}
+
+func hasEventHook(hooks []api.EventHook, id int) bool {
+	for i := range hooks {
+		if hooks[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// eventHookMatches returns true if hook must run when ev happens.
+func eventHookMatches(hook *api.EventHook, ev *api.Event) bool {
+	if hook.On != ev.Kind {
+		return false
+	}
+	if hook.On != api.EventStopped || hook.Breakpoint == "" {
+		return true
+	}
+	if ev.State == nil {
+		return false
+	}
+	for _, th := range ev.State.Threads {
+		bp := th.Breakpoint
+		if bp == nil {
+			continue
+		}
+		if hook.Breakpoint == "*" || hook.Breakpoint == bp.Name || hook.Breakpoint == strconv.Itoa(bp.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// runEventHook runs the script of hook, passing ev to its main function,
+// and returns what it printed.
+func runEventHook(env *starbind.Env, hook *api.EventHook, ev *api.Event) (string, error) {
+	var buf bytes.Buffer
+	env.SetOutput(&buf)
+	t := time.AfterFunc(eventHookTimeout, env.Cancel)
+	defer t.Stop()
+	_, err := env.Execute(fmt.Sprintf("hook %d", hook.ID), hook.Script, "main", []interface{}{*ev})
+	return buf.String(), err
+}
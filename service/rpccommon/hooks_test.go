@@ -0,0 +1,37 @@
+package rpccommon
+
+import (
+	"testing"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+func TestEventHookMatches(t *testing.T) {
+	stop := &api.Event{Kind: api.EventStopped, State: &api.DebuggerState{
+		Threads: []*api.Thread{
+			{ID: 1},
+			{ID: 2, Breakpoint: &api.Breakpoint{ID: 3, Name: "mybp"}},
+		},
+	}}
+	exit := &api.Event{Kind: api.EventExited}
+
+	testCases := []struct {
+		hook api.EventHook
+		ev   *api.Event
+		res  bool
+	}{
+		{api.EventHook{On: api.EventStopped}, stop, true},
+		{api.EventHook{On: api.EventStopped}, exit, false},
+		{api.EventHook{On: api.EventExited}, exit, true},
+		{api.EventHook{On: api.EventStopped, Breakpoint: "*"}, stop, true},
+		{api.EventHook{On: api.EventStopped, Breakpoint: "3"}, stop, true},
+		{api.EventHook{On: api.EventStopped, Breakpoint: "mybp"}, stop, true},
+		{api.EventHook{On: api.EventStopped, Breakpoint: "4"}, stop, false},
+		{api.EventHook{On: api.EventStopped, Breakpoint: "*"}, &api.Event{Kind: api.EventStopped, State: &api.DebuggerState{Threads: []*api.Thread{{ID: 1}}}}, false},
+	}
+	for i, tc := range testCases {
+		if res := eventHookMatches(&tc.hook, tc.ev); res != tc.res {
+			t.Errorf("%d: got %v expected %v", i, res, tc.res)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package rpccommon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-delve/delve/service/debugger"
+)
+
+// serverMetrics are the metrics of the server itself, reported together
+// with debugger.Metrics.
+type serverMetrics struct {
+	// Clients is the number of connected clients.
+	Clients int64
+	// Requests is the number of requests received.
+	Requests uint64
+}
+
+// serveMetrics serves /metrics, in the Prometheus text format, and
+// /healthz on the metrics listener of the server.
+func (s *ServerImpl) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		sm := serverMetrics{
+			Clients:  atomic.LoadInt64(&s.clients),
+			Requests: atomic.LoadUint64(&s.requests),
+		}
+		writeMetrics(w, sm, s.debugger.Metrics())
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if s.debugger.Metrics().Detached {
+			http.Error(w, "detached", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	err := http.Serve(s.config.MetricsListener, mux)
+	select {
+	case <-s.stopChan:
+	default:
+		s.log.Errorf("metrics server: %v", err)
+	}
+}
+
+func writeMetrics(w io.Writer, sm serverMetrics, dm debugger.Metrics) {
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	b2i := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	metric("delve_connected_clients", "gauge", "Number of clients connected to the server.")
+	fmt.Fprintf(w, "delve_connected_clients %d\n", sm.Clients)
+	metric("delve_rpc_requests_total", "counter", "Number of requests received by the server.")
+	fmt.Fprintf(w, "delve_rpc_requests_total %d\n", sm.Requests)
+
+	metric("delve_target_running", "gauge", "Whether the target is running.")
+	fmt.Fprintf(w, "delve_target_running %d\n", b2i(dm.Running))
+	metric("delve_target_exited", "gauge", "Whether the target process exited.")
+	fmt.Fprintf(w, "delve_target_exited %d\n", b2i(dm.Exited))
+	if dm.Exited {
+		metric("delve_target_exit_status", "gauge", "Exit status of the target process.")
+		fmt.Fprintf(w, "delve_target_exit_status %d\n", dm.ExitStatus)
+	}
+	metric("delve_target_stops_total", "counter", "Number of times the target stopped after being resumed.")
+	fmt.Fprintf(w, "delve_target_stops_total %d\n", dm.Stops)
+
+	if len(dm.Breakpoints) > 0 {
+		metric("delve_breakpoint_hits_total", "counter", "Number of times each breakpoint was hit, as of the last time the target stopped.")
+		for _, bp := range dm.Breakpoints {
+			fmt.Fprintf(w, "delve_breakpoint_hits_total{id=\"%d\",name=\"%s\",location=\"%s\"} %d\n", bp.ID, escapeLabel(bp.Name), escapeLabel(fmt.Sprintf("%s:%d", bp.File, bp.Line)), bp.TotalHitCount)
+		}
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	metric("go_goroutines", "gauge", "Number of goroutines of the debugger.")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+	metric("go_memstats_heap_alloc_bytes", "gauge", "Bytes of heap objects allocated by the debugger.")
+	fmt.Fprintf(w, "go_memstats_heap_alloc_bytes %d\n", ms.HeapAlloc)
+	metric("go_memstats_sys_bytes", "gauge", "Bytes of memory obtained from the OS by the debugger.")
+	fmt.Fprintf(w, "go_memstats_sys_bytes %d\n", ms.Sys)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes s for use as a label value in the Prometheus text
+// format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
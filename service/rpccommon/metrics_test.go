@@ -0,0 +1,38 @@
+package rpccommon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/debugger"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetrics(&buf, serverMetrics{Clients: 2, Requests: 10}, debugger.Metrics{
+		Stops: 3,
+		Breakpoints: []*api.Breakpoint{
+			{ID: 1, File: "/src/main.go", Line: 12, TotalHitCount: 4},
+			{ID: 2, Name: `a"b`, File: `C:\src\main.go`, Line: 20},
+		},
+	})
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE delve_connected_clients gauge\ndelve_connected_clients 2\n",
+		"delve_rpc_requests_total 10\n",
+		"delve_target_running 0\n",
+		"delve_target_stops_total 3\n",
+		`delve_breakpoint_hits_total{id="1",name="",location="/src/main.go:12"} 4` + "\n",
+		`delve_breakpoint_hits_total{id="2",name="a\"b",location="C:\\src\\main.go:20"} 0` + "\n",
+		"go_memstats_heap_alloc_bytes ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not found in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "delve_target_exit_status") {
+		t.Errorf("exit status reported for a running target:\n%s", out)
+	}
+}
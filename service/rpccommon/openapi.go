@@ -0,0 +1,270 @@
+package rpccommon
+
+// restOpenAPI is the OpenAPI document describing the REST interface, see
+// serveREST. The responses are the output values of the corresponding API
+// v2 methods, documented in package rpc2, their schemas only list the most
+// commonly used fields.
+const restOpenAPI = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Delve",
+    "description": "HTTP+JSON interface to a headless Delve server. Each operation corresponds to a method of the JSON-RPC API v2, documented at https://pkg.go.dev/github.com/go-delve/delve/service/rpc2, and returns its output value. Failed requests return an Error object.",
+    "version": "2"
+  },
+  "components": {
+    "securitySchemes": {
+      "token": {
+        "type": "http",
+        "scheme": "bearer",
+        "description": "One of the tokens of the authentication policy of the server, only required if the server was started with --auth-policy."
+      }
+    },
+    "schemas": {
+      "Error": {
+        "type": "object",
+        "properties": {
+          "error": {"type": "string"}
+        }
+      },
+      "Location": {
+        "type": "object",
+        "properties": {
+          "pc": {"type": "integer"},
+          "file": {"type": "string"},
+          "line": {"type": "integer"},
+          "function": {"type": "object", "properties": {"name": {"type": "string"}}}
+        }
+      },
+      "Breakpoint": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "name": {"type": "string"},
+          "file": {"type": "string"},
+          "line": {"type": "integer"},
+          "functionName": {"type": "string"},
+          "Cond": {"type": "string"},
+          "continue": {"type": "boolean", "description": "The breakpoint is a tracepoint."},
+          "variables": {"type": "array", "items": {"type": "string"}},
+          "totalHitCount": {"type": "integer"}
+        }
+      },
+      "Variable": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "type": {"type": "string"},
+          "kind": {"type": "integer"},
+          "value": {"type": "string"},
+          "len": {"type": "integer"},
+          "children": {"type": "array", "items": {"$ref": "#/components/schemas/Variable"}}
+        }
+      },
+      "Goroutine": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "currentLoc": {"$ref": "#/components/schemas/Location"},
+          "userCurrentLoc": {"$ref": "#/components/schemas/Location"},
+          "threadID": {"type": "integer"},
+          "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+        }
+      },
+      "Stackframe": {
+        "allOf": [
+          {"$ref": "#/components/schemas/Location"},
+          {
+            "type": "object",
+            "properties": {
+              "Locals": {"type": "array", "items": {"$ref": "#/components/schemas/Variable"}},
+              "Arguments": {"type": "array", "items": {"$ref": "#/components/schemas/Variable"}}
+            }
+          }
+        ]
+      },
+      "DebuggerState": {
+        "type": "object",
+        "properties": {
+          "Running": {"type": "boolean"},
+          "exited": {"type": "boolean"},
+          "exitStatus": {"type": "integer"},
+          "currentThread": {"type": "object"},
+          "currentGoroutine": {"$ref": "#/components/schemas/Goroutine"}
+        }
+      },
+      "StateOut": {
+        "type": "object",
+        "properties": {"State": {"$ref": "#/components/schemas/DebuggerState"}}
+      },
+      "BreakpointOut": {
+        "type": "object",
+        "properties": {"Breakpoint": {"$ref": "#/components/schemas/Breakpoint"}}
+      }
+    },
+    "parameters": {
+      "breakpoint": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "description": "ID or name of the breakpoint.",
+        "schema": {"type": "string"}
+      }
+    },
+    "responses": {
+      "Error": {
+        "description": "The request failed.",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+      },
+      "Breakpoint": {
+        "description": "The breakpoint.",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/BreakpointOut"}}}
+      }
+    }
+  },
+  "security": [{}, {"token": []}],
+  "paths": {
+    "/v2/state": {
+      "get": {
+        "summary": "Returns the state of the debugger, without waiting for the target to stop (State).",
+        "responses": {
+          "200": {"description": "The state of the debugger.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/StateOut"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/command": {
+      "post": {
+        "summary": "Runs a command, like continue, next or halt, and waits for the target to stop (Command).",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "type": "object",
+                "required": ["name"],
+                "properties": {
+                  "name": {"type": "string", "enum": ["continue", "rewind", "next", "reverseNext", "step", "reverseStep", "stepout", "reverseStepout", "stepInstruction", "reverseStepInstruction", "halt", "call"]},
+                  "goroutineID": {"type": "integer"},
+                  "expr": {"type": "string", "description": "Expression of the function call, for the call command."}
+                }
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {"description": "The state of the debugger after the command.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/StateOut"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/breakpoints": {
+      "get": {
+        "summary": "Lists the breakpoints (ListBreakpoints).",
+        "responses": {
+          "200": {
+            "description": "The breakpoints.",
+            "content": {"application/json": {"schema": {"type": "object", "properties": {"Breakpoints": {"type": "array", "items": {"$ref": "#/components/schemas/Breakpoint"}}}}}}
+          },
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "post": {
+        "summary": "Creates a breakpoint, at file and line or at functionName (CreateBreakpoint).",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Breakpoint"}}}
+        },
+        "responses": {
+          "200": {"$ref": "#/components/responses/Breakpoint"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/breakpoints/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/breakpoint"}],
+      "get": {
+        "summary": "Returns a breakpoint (GetBreakpoint).",
+        "responses": {
+          "200": {"$ref": "#/components/responses/Breakpoint"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {
+        "summary": "Deletes a breakpoint (ClearBreakpoint).",
+        "responses": {
+          "200": {"$ref": "#/components/responses/Breakpoint"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/eval": {
+      "post": {
+        "summary": "Evaluates an expression (Eval).",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "type": "object",
+                "required": ["Expr"],
+                "properties": {
+                  "Expr": {"type": "string"},
+                  "Scope": {
+                    "type": "object",
+                    "description": "Goroutine and frame where the expression is evaluated, by default the topmost frame of the current goroutine.",
+                    "properties": {"GoroutineID": {"type": "integer"}, "Frame": {"type": "integer"}}
+                  }
+                }
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The value of the expression.",
+            "content": {"application/json": {"schema": {"type": "object", "properties": {"Variable": {"$ref": "#/components/schemas/Variable"}}}}}
+          },
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/goroutines": {
+      "get": {
+        "summary": "Lists the goroutines (ListGoroutines).",
+        "parameters": [
+          {"name": "start", "in": "query", "schema": {"type": "integer"}, "description": "Value of Nextg returned by a previous request."},
+          {"name": "count", "in": "query", "schema": {"type": "integer"}, "description": "Maximum number of goroutines returned, zero means all of them."}
+        ],
+        "responses": {
+          "200": {
+            "description": "The goroutines.",
+            "content": {"application/json": {"schema": {"type": "object", "properties": {
+              "Goroutines": {"type": "array", "items": {"$ref": "#/components/schemas/Goroutine"}},
+              "Nextg": {"type": "integer"}
+            }}}}
+          },
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/goroutines/{id}/stacktrace": {
+      "get": {
+        "summary": "Returns the stack trace of a goroutine (Stacktrace).",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}, "description": "ID of the goroutine, or current."},
+          {"name": "start", "in": "query", "schema": {"type": "integer"}, "description": "Number of the first frame returned."},
+          {"name": "depth", "in": "query", "schema": {"type": "integer", "default": 50}},
+          {"name": "full", "in": "query", "schema": {"type": "boolean"}, "description": "Also return the local variables and arguments of each frame."}
+        ],
+        "responses": {
+          "200": {
+            "description": "The stack frames.",
+            "content": {"application/json": {"schema": {"type": "object", "properties": {"Locations": {"type": "array", "items": {"$ref": "#/components/schemas/Stackframe"}}}}}}
+          },
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,311 @@
+package rpccommon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-delve/delve/service"
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// restPrefix is the prefix of the paths of all the requests served by the
+// REST interface, other than the OpenAPI document.
+const restPrefix = "/v2/"
+
+// errRESTNotFound is returned by restRoute for requests that do not match
+// any route.
+var errRESTNotFound = errors.New("not found")
+
+// restRequest is an API call described by an HTTP request.
+type restRequest struct {
+	// method is the name of the API v2 method called.
+	method string
+	// arg is the argument of the call, of the type of the argument of
+	// method.
+	arg interface{}
+}
+
+// restRoute returns the API call described by the HTTP request r, see
+// restOpenAPI.
+func restRoute(r *http.Request) (*restRequest, error) {
+	if !strings.HasPrefix(r.URL.Path, restPrefix) {
+		return nil, errRESTNotFound
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, restPrefix), "/"), "/")
+	q := r.URL.Query()
+
+	route := r.Method + " " + path[0]
+	if len(path) > 1 {
+		route += "/{id}"
+	}
+	if len(path) > 2 {
+		route += "/" + strings.Join(path[2:], "/")
+	}
+
+	switch route {
+	case "GET state":
+		return &restRequest{"State", rpc2.StateIn{NonBlocking: true}}, nil
+
+	case "POST command":
+		var arg api.DebuggerCommand
+		if err := decodeRESTBody(r, &arg); err != nil {
+			return nil, err
+		}
+		return &restRequest{"Command", arg}, nil
+
+	case "GET breakpoints":
+		return &restRequest{"ListBreakpoints", rpc2.ListBreakpointsIn{}}, nil
+
+	case "POST breakpoints":
+		var arg rpc2.CreateBreakpointIn
+		if err := decodeRESTBody(r, &arg.Breakpoint); err != nil {
+			return nil, err
+		}
+		return &restRequest{"CreateBreakpoint", arg}, nil
+
+	case "GET breakpoints/{id}":
+		var arg rpc2.GetBreakpointIn
+		if id, err := strconv.Atoi(path[1]); err == nil {
+			arg.Id = id
+		} else {
+			arg.Name = path[1]
+		}
+		return &restRequest{"GetBreakpoint", arg}, nil
+
+	case "DELETE breakpoints/{id}":
+		var arg rpc2.ClearBreakpointIn
+		if id, err := strconv.Atoi(path[1]); err == nil {
+			arg.Id = id
+		} else {
+			arg.Name = path[1]
+		}
+		return &restRequest{"ClearBreakpoint", arg}, nil
+
+	case "POST eval":
+		arg := rpc2.EvalIn{Scope: api.EvalScope{GoroutineID: -1}}
+		if err := decodeRESTBody(r, &arg); err != nil {
+			return nil, err
+		}
+		return &restRequest{"Eval", arg}, nil
+
+	case "GET goroutines":
+		var arg rpc2.ListGoroutinesIn
+		var err error
+		if arg.Start, err = restIntParam(q.Get("start"), 0); err != nil {
+			return nil, err
+		}
+		if arg.Count, err = restIntParam(q.Get("count"), 0); err != nil {
+			return nil, err
+		}
+		return &restRequest{"ListGoroutines", arg}, nil
+
+	case "GET goroutines/{id}/stacktrace":
+		arg := rpc2.StacktraceIn{Id: -1}
+		var err error
+		if path[1] != "current" {
+			if arg.Id, err = strconv.Atoi(path[1]); err != nil {
+				return nil, fmt.Errorf("invalid goroutine ID %q", path[1])
+			}
+		}
+		if arg.Start, err = restIntParam(q.Get("start"), 0); err != nil {
+			return nil, err
+		}
+		if arg.Depth, err = restIntParam(q.Get("depth"), 50); err != nil {
+			return nil, err
+		}
+		arg.Full = q.Get("full") == "true"
+		return &restRequest{"Stacktrace", arg}, nil
+	}
+	return nil, errRESTNotFound
+}
+
+func decodeRESTBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("could not decode request body: %v", err)
+	}
+	return nil
+}
+
+func restIntParam(s string, dflt int) (int, error) {
+	if s == "" {
+		return dflt, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid parameter %q", s)
+	}
+	return n, nil
+}
+
+// restCallback collects the result of an asynchronous API method.
+type restCallback struct {
+	out  interface{}
+	err  error
+	done chan struct{}
+}
+
+func (cb *restCallback) Return(out interface{}, err error) {
+	cb.out, cb.err = out, err
+	close(cb.done)
+}
+
+// restCall calls the API v2 method of req, as a client authenticated with
+// token.
+func (s *ServerImpl) restCall(req *restRequest, token *service.AuthToken) (out interface{}, err error) {
+	method := "RPCServer." + req.method
+	mtype, ok := s.methodMaps[1][method]
+	if !ok {
+		return nil, fmt.Errorf("unknown method: %s", method)
+	}
+	rpcServer := &RPCServer{s: s, token: token}
+	if err := rpcServer.checkAuth(method, req.arg); err != nil {
+		return nil, err
+	}
+
+	args := []reflect.Value{mtype.Rcvr, reflect.ValueOf(req.arg)}
+	defer func() {
+		if ierr := recover(); ierr != nil {
+			err = newInternalError(ierr, 2)
+		}
+	}()
+	if !mtype.Synchronous {
+		cb := &restCallback{done: make(chan struct{})}
+		mtype.method.Func.Call(append(args, reflect.ValueOf(cb)))
+		<-cb.done
+		return cb.out, cb.err
+	}
+	replyv := reflect.New(mtype.ReplyType.Elem())
+	returnValues := mtype.method.Func.Call(append(args, replyv))
+	if errInter := returnValues[0].Interface(); errInter != nil {
+		return nil, errInter.(error)
+	}
+	return replyv.Interface(), nil
+}
+
+// restAuthenticate returns the token of the authentication policy sent
+// with r, as a bearer token in the Authorization header.
+func (s *ServerImpl) restAuthenticate(r *http.Request) (*service.AuthToken, error) {
+	policy := s.config.AuthPolicy
+	if policy == nil {
+		return nil, nil
+	}
+	const bearer = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, bearer) {
+		return nil, service.ErrNotAuthenticated
+	}
+	t := policy.Lookup(strings.TrimPrefix(h, bearer))
+	if t == nil {
+		return nil, errors.New("invalid authentication token")
+	}
+	return t, nil
+}
+
+// restCheckRequest returns an error, and the HTTP status that goes with it,
+// if r could have been sent by a web page that is not allowed to use the
+// REST interface, including pages that reach it through DNS rebinding.
+// Requiring POST requests to be application/json also means that browsers
+// can not send them cross-origin without a CORS preflight.
+func (s *ServerImpl) restCheckRequest(r *http.Request) (int, error) {
+	if origin := r.Header.Get("Origin"); !service.OriginAllowed(origin, s.config.RESTOrigins) {
+		return http.StatusForbidden, fmt.Errorf("origin %q not allowed", origin)
+	}
+	if r.Method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("request body must be application/json")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// serveREST serves the HTTP+JSON interface on the REST listener of the
+// server. The result of each request is the output value of the API v2
+// method it is mapped to, errors are returned as a JSON object with a
+// single "error" field.
+func (s *ServerImpl) serveREST() {
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	writeError := func(w http.ResponseWriter, status int, err error) {
+		writeJSON(w, status, struct {
+			Error string `json:"error"`
+		}{err.Error()})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, restOpenAPI)
+	})
+	mux.HandleFunc(restPrefix, func(w http.ResponseWriter, r *http.Request) {
+		if status, err := s.restCheckRequest(r); err != nil {
+			writeError(w, status, err)
+			return
+		}
+		token, err := s.restAuthenticate(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		req, err := restRoute(r)
+		if err != nil {
+			status := http.StatusBadRequest
+			if err == errRESTNotFound {
+				status = http.StatusNotFound
+			}
+			writeError(w, status, err)
+			return
+		}
+		atomic.AddUint64(&s.requests, 1)
+		s.log.Debugf("<- REST %s %s", r.Method, r.URL.Path)
+		out, err := s.restCall(req, token)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+
+	var listener net.Listener = s.config.RESTListener
+	if s.config.CheckLocalConnUser {
+		listener = &sameUserListener{listener}
+	}
+	err := http.Serve(listener, mux)
+	select {
+	case <-s.stopChan:
+	default:
+		s.log.Errorf("REST server: %v", err)
+	}
+}
+
+// sameUserListener is a listener that only accepts connections from the
+// same user that is running the server, see canAccept.
+type sameUserListener struct {
+	net.Listener
+}
+
+func (l *sameUserListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if canAccept(l.Addr(), c.RemoteAddr()) {
+			return c, nil
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package rpccommon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-delve/delve/service"
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+func TestRESTRoute(t *testing.T) {
+	testCases := []struct {
+		method, target, body string
+		want                 *restRequest
+	}{
+		{"GET", "/v2/state", "", &restRequest{"State", rpc2.StateIn{NonBlocking: true}}},
+		{"POST", "/v2/command", `{"name":"continue"}`, &restRequest{"Command", api.DebuggerCommand{Name: api.Continue}}},
+		{"GET", "/v2/breakpoints", "", &restRequest{"ListBreakpoints", rpc2.ListBreakpointsIn{}}},
+		{"POST", "/v2/breakpoints", `{"file":"main.go","line":10,"Cond":"i == 1"}`, &restRequest{"CreateBreakpoint", rpc2.CreateBreakpointIn{Breakpoint: api.Breakpoint{File: "main.go", Line: 10, Cond: "i == 1"}}}},
+		{"GET", "/v2/breakpoints/2", "", &restRequest{"GetBreakpoint", rpc2.GetBreakpointIn{Id: 2}}},
+		{"DELETE", "/v2/breakpoints/mybp/", "", &restRequest{"ClearBreakpoint", rpc2.ClearBreakpointIn{Name: "mybp"}}},
+		{"POST", "/v2/eval", `{"Expr":"x"}`, &restRequest{"Eval", rpc2.EvalIn{Scope: api.EvalScope{GoroutineID: -1}, Expr: "x"}}},
+		{"GET", "/v2/goroutines?count=10", "", &restRequest{"ListGoroutines", rpc2.ListGoroutinesIn{Count: 10}}},
+		{"GET", "/v2/goroutines/current/stacktrace", "", &restRequest{"Stacktrace", rpc2.StacktraceIn{Id: -1, Depth: 50}}},
+		{"GET", "/v2/goroutines/5/stacktrace?start=2&depth=3&full=true", "", &restRequest{"Stacktrace", rpc2.StacktraceIn{Id: 5, Start: 2, Depth: 3, Full: true}}},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		req, err := restRoute(r)
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.method, tc.target, err)
+			continue
+		}
+		if !reflect.DeepEqual(req, tc.want) {
+			t.Errorf("%s %s: got %#v expected %#v", tc.method, tc.target, req, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ method, target string }{
+		{"GET", "/state"},
+		{"PUT", "/v2/state"},
+		{"GET", "/v2/breakpoints/1/foo"},
+		{"GET", "/v2/goroutines/1"},
+	} {
+		if _, err := restRoute(httptest.NewRequest(tc.method, tc.target, nil)); err != errRESTNotFound {
+			t.Errorf("%s %s: expected not found, got %v", tc.method, tc.target, err)
+		}
+	}
+	for _, target := range []string{"/v2/goroutines?count=a", "/v2/goroutines/a/stacktrace"} {
+		if _, err := restRoute(httptest.NewRequest("GET", target, nil)); err == nil || err == errRESTNotFound {
+			t.Errorf("%s: expected bad request, got %v", target, err)
+		}
+	}
+}
+
+func TestRESTOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(restOpenAPI), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Every operation of the document must be served.
+	for path, ops := range doc.Paths {
+		path = strings.Replace(path, "{id}", "1", -1)
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			body := ""
+			if method == "post" {
+				body = "{}"
+			}
+			if _, err := restRoute(httptest.NewRequest(strings.ToUpper(method), path, strings.NewReader(body))); err != nil {
+				t.Errorf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+}
+
+func TestRESTCheckRequest(t *testing.T) {
+	s := &ServerImpl{config: &service.Config{RESTOrigins: []string{"http://localhost:8080"}}}
+	for _, tc := range []struct {
+		method, origin, contentType string
+		want                        int
+	}{
+		{"GET", "", "", http.StatusOK},
+		{"GET", "http://localhost:8080", "", http.StatusOK},
+		{"GET", "http://evil.example", "", http.StatusForbidden},
+		{"POST", "", "application/json", http.StatusOK},
+		{"POST", "http://localhost:8080", "application/json; charset=utf-8", http.StatusOK},
+		{"POST", "http://evil.example", "application/json", http.StatusForbidden},
+		{"POST", "", "", http.StatusUnsupportedMediaType},
+		{"POST", "http://localhost:8080", "text/plain", http.StatusUnsupportedMediaType},
+	} {
+		r := httptest.NewRequest(tc.method, "/v2/eval", strings.NewReader("{}"))
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		if status, _ := s.restCheckRequest(r); status != tc.want {
+			t.Errorf("%s origin=%q content-type=%q: got status %d expected %d", tc.method, tc.origin, tc.contentType, status, tc.want)
+		}
+	}
+}
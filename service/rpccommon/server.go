@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"unicode"
 	"unicode/utf8"
 
@@ -42,7 +43,15 @@ type ServerImpl struct {
 	s2 *rpc2.RPCServer
 	// maps of served methods, one for each supported API.
 	methodMaps []map[string]*methodType
-	log        *logrus.Entry
+	// observerMethodMaps are the methods served to observer connections,
+	// see RPCServer.SetObserver.
+	observerMethodMaps []map[string]*methodType
+	log                *logrus.Entry
+
+	// clients and requests are the number of connected clients and of
+	// requests received, see serveMetrics. Accessed atomically.
+	clients  int64
+	requests uint64
 }
 
 type RPCCallback struct {
@@ -55,6 +64,12 @@ type RPCCallback struct {
 // RPCServer implements the RPC method calls common to all versions of the API.
 type RPCServer struct {
 	s *ServerImpl
+	// observer is true if the connection served by this RPCServer was turned
+	// into an observer connection.
+	observer bool
+	// token is the token the connection authenticated with, see
+	// Authenticate.
+	token *service.AuthToken
 }
 
 type methodType struct {
@@ -86,10 +101,16 @@ func NewServer(config *service.Config) *ServerImpl {
 
 // Stop stops the JSON-RPC server.
 func (s *ServerImpl) Stop() error {
+	close(s.stopChan)
 	if s.config.AcceptMulti {
-		close(s.stopChan)
 		s.listener.Close()
 	}
+	if s.config.MetricsListener != nil {
+		s.config.MetricsListener.Close()
+	}
+	if s.config.RESTListener != nil {
+		s.config.RESTListener.Close()
+	}
 	kill := s.config.Debugger.AttachPid == 0
 	return s.debugger.Detach(kill)
 }
@@ -116,16 +137,26 @@ func (s *ServerImpl) Run() error {
 	s.s1 = rpc1.NewServer(s.config, s.debugger)
 	s.s2 = rpc2.NewServer(s.config, s.debugger)
 
-	rpcServer := &RPCServer{s}
-
 	s.methodMaps = make([]map[string]*methodType, 2)
-
 	s.methodMaps[0] = map[string]*methodType{}
 	s.methodMaps[1] = map[string]*methodType{}
 	suitableMethods(s.s1, s.methodMaps[0], s.log)
-	suitableMethods(rpcServer, s.methodMaps[0], s.log)
 	suitableMethods(s.s2, s.methodMaps[1], s.log)
-	suitableMethods(rpcServer, s.methodMaps[1], s.log)
+
+	observer := s.debugger.Observer()
+	s.observerMethodMaps = make([]map[string]*methodType, 2)
+	s.observerMethodMaps[0] = map[string]*methodType{}
+	s.observerMethodMaps[1] = map[string]*methodType{}
+	suitableMethods(rpc1.NewServer(s.config, observer), s.observerMethodMaps[0], s.log)
+	suitableMethods(rpc2.NewServer(s.config, observer), s.observerMethodMaps[1], s.log)
+
+	if s.config.MetricsListener != nil {
+		go s.serveMetrics()
+	}
+	if s.config.RESTListener != nil {
+		go s.serveREST()
+	}
+	go s.runEventHooks()
 
 	go func() {
 		defer s.listener.Close()
@@ -148,7 +179,7 @@ func (s *ServerImpl) Run() error {
 				}
 			}
 
-			go s.serveJSONCodec(c)
+			go s.serveJSONCodec(c, false)
 			if !s.config.AcceptMulti {
 				break
 			}
@@ -247,17 +278,35 @@ func suitableMethods(rcvr interface{}, methods map[string]*methodType, log *logr
 	}
 }
 
-func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
-	defer func() {
-		if !s.config.AcceptMulti && s.config.DisconnectChan != nil {
-			close(s.config.DisconnectChan)
-		}
-	}()
+// serveJSONCodec serves the JSON-RPC connection conn. Internal connections
+// are the ones used by the server itself to run event hooks, they are
+// always authenticated and their disconnection does not stop the server.
+func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser, internal bool) {
+	if !internal {
+		atomic.AddInt64(&s.clients, 1)
+		defer atomic.AddInt64(&s.clients, -1)
+		defer func() {
+			if !s.config.AcceptMulti && s.config.DisconnectChan != nil {
+				close(s.config.DisconnectChan)
+			}
+		}()
+	}
 
 	sending := new(sync.Mutex)
 	codec := jsonrpc.NewServerCodec(conn)
 	var req rpc.Request
 	var resp rpc.Response
+
+	// The methods common to all versions of the API are served by a
+	// different RPCServer for each connection, since they can change its
+	// state.
+	rpcServer := &RPCServer{s: s}
+	if internal {
+		rpcServer.token = &service.AuthToken{}
+	}
+	commonMethods := map[string]*methodType{}
+	suitableMethods(rpcServer, commonMethods, s.log)
+
 	for {
 		req = rpc.Request{}
 		err := codec.ReadRequestHeader(&req)
@@ -267,8 +316,16 @@ func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
 			}
 			break
 		}
+		atomic.AddUint64(&s.requests, 1)
 
-		mtype, ok := s.methodMaps[s.config.APIVersion-1][req.ServiceMethod]
+		methodMaps := s.methodMaps
+		if rpcServer.observer {
+			methodMaps = s.observerMethodMaps
+		}
+		mtype, ok := methodMaps[s.config.APIVersion-1][req.ServiceMethod]
+		if !ok {
+			mtype, ok = commonMethods[req.ServiceMethod]
+		}
 		if !ok {
 			s.log.Errorf("rpc: can't find method %s", req.ServiceMethod)
 			s.sendResponse(sending, &req, &rpc.Response{}, nil, codec, fmt.Sprintf("unknown method: %s", req.ServiceMethod))
@@ -293,6 +350,12 @@ func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
 			argv = argv.Elem()
 		}
 
+		if err := rpcServer.checkAuth(req.ServiceMethod, argv.Interface()); err != nil {
+			s.log.Debugf("<- %s denied: %v", req.ServiceMethod, err)
+			s.sendResponse(sending, &req, &rpc.Response{}, nil, codec, err.Error())
+			continue
+		}
+
 		if mtype.Synchronous {
 			if logflags.RPC() {
 				argvbytes, _ := json.Marshal(argv.Interface())
@@ -395,6 +458,18 @@ func (s *RPCServer) SetApiVersion(args api.SetAPIVersionIn, out *api.SetAPIVersi
 	return nil
 }
 
+// SetObserver turns the connection into an observer connection: from then
+// on it can be used to inspect the target but every request that would
+// change its state, like resuming it, changing breakpoints or variables and
+// calling functions, fails. Observer connections can not go back to being
+// normal connections. This is meant for headless instances started with
+// --accept-multiclient, where one client controls the target and others
+// follow along.
+func (s *RPCServer) SetObserver(args api.SetObserverIn, out *api.SetObserverOut) error {
+	s.observer = true
+	return nil
+}
+
 type internalError struct {
 	Err   interface{}
 	Stack []internalErrorFrame
@@ -1,6 +1,8 @@
 package service_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -60,7 +62,9 @@ func withTestClient2(name string, t *testing.T, fn func(c service.Client)) {
 	})
 }
 
-func startServer(name string, buildFlags protest.BuildFlags, t *testing.T, redirects [3]string) (clientConn net.Conn, fixture protest.Fixture) {
+// startServer starts a server debugging fixture name, if setup isn't nil
+// it is called to change the configuration of the debugger.
+func startServer(name string, buildFlags protest.BuildFlags, t *testing.T, redirects [3]string, setup func(*debugger.Config, protest.Fixture)) (clientConn net.Conn, fixture protest.Fixture) {
 	if testBackend == "rr" {
 		protest.MustHaveRecordingAllowed(t)
 	}
@@ -75,17 +79,21 @@ func startServer(name string, buildFlags protest.BuildFlags, t *testing.T, redir
 			redirects[i] = filepath.Join(fixture.BuildDir, redirects[i])
 		}
 	}
+	cfg := debugger.Config{
+		Backend:        testBackend,
+		CheckGoVersion: true,
+		Packages:       []string{fixture.Source},
+		BuildFlags:     "", // build flags can be an empty string here because the only test that uses it, does not set special flags.
+		ExecuteKind:    debugger.ExecutingGeneratedFile,
+		Redirects:      redirects,
+	}
+	if setup != nil {
+		setup(&cfg, fixture)
+	}
 	server := rpccommon.NewServer(&service.Config{
 		Listener:    listener,
 		ProcessArgs: []string{fixture.Path},
-		Debugger: debugger.Config{
-			Backend:        testBackend,
-			CheckGoVersion: true,
-			Packages:       []string{fixture.Source},
-			BuildFlags:     "", // build flags can be an empty string here because the only test that uses it, does not set special flags.
-			ExecuteKind:    debugger.ExecutingGeneratedFile,
-			Redirects:      redirects,
-		},
+		Debugger:    cfg,
 	})
 	if err := server.Run(); err != nil {
 		t.Fatal(err)
@@ -94,7 +102,7 @@ func startServer(name string, buildFlags protest.BuildFlags, t *testing.T, redir
 }
 
 func withTestClient2Extended(name string, t *testing.T, buildFlags protest.BuildFlags, redirects [3]string, fn func(c service.Client, fixture protest.Fixture)) {
-	clientConn, fixture := startServer(name, buildFlags, t, redirects)
+	clientConn, fixture := startServer(name, buildFlags, t, redirects, nil)
 	client := rpc2.NewClientFromConn(clientConn)
 	defer func() {
 		client.Detach(true)
@@ -206,6 +214,45 @@ func TestRestart_duringStop(t *testing.T) {
 	})
 }
 
+func TestRestart_discardUnwritableBreakpoint(t *testing.T) {
+	// A breakpoint on the stack of the main goroutine can not be written
+	// after restarting, the new process is stopped before the Go heap is
+	// mapped, and must be reported as discarded.
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		v, err := c.EvalVariable(api.EvalScope{GoroutineID: -1}, "&i1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(&i1)")
+		if len(v.Children) != 1 {
+			t.Fatalf("could not read the address of i1: %#v", v)
+		}
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{Addr: v.Children[0].Addr})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		discarded, err := c.Restart(false)
+		assertNoError(err, t, "Restart()")
+		found := false
+		for _, dbp := range discarded {
+			if dbp.Breakpoint.ID == bp.ID {
+				found = true
+				if !strings.Contains(dbp.Reason, "could not write breakpoint") {
+					t.Errorf("wrong reason for discarded breakpoint: %q", dbp.Reason)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("breakpoint %d at %#x not discarded: %#v", bp.ID, bp.Addr, discarded)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, lbp := range bps {
+			if lbp.ID == bp.ID {
+				t.Errorf("discarded breakpoint %d still set", bp.ID)
+			}
+		}
+	})
+}
+
 // This source is a slightly modified version of
 // _fixtures/testenv.go. The only difference is that
 // the name of the environment variable we are trying to
@@ -884,6 +931,216 @@ func TestClientServer_EvalVariable(t *testing.T) {
 	})
 }
 
+func TestClientServer_EvalVariables(t *testing.T) {
+	withTestClient2("testvariables", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		exprs := []string{"a1", "nonexistent", "a2"}
+		results, err := c.EvalVariables(api.EvalScope{GoroutineID: -1}, exprs, normalLoadConfig)
+		assertNoError(err, t, "EvalVariables")
+		if len(results) != len(exprs) {
+			t.Fatalf("wrong number of results %d", len(results))
+		}
+		for i, expr := range exprs {
+			v, err := c.EvalVariable(api.EvalScope{GoroutineID: -1}, expr, normalLoadConfig)
+			if err != nil {
+				t.Logf("%s: %v", expr, err)
+				if results[i].Err != err.Error() || results[i].Variable != nil {
+					t.Errorf("%s: expected error %q got %#v", expr, err.Error(), results[i])
+				}
+				continue
+			}
+			if results[i].Variable == nil || results[i].Variable.SinglelineString() != v.SinglelineString() {
+				t.Errorf("%s: expected %s got %#v", expr, v.SinglelineString(), results[i])
+			}
+		}
+	})
+}
+
+func TestClientServer_GetCapabilities(t *testing.T) {
+	withTestClient2("testvariables", t, func(c service.Client) {
+		caps, err := c.GetCapabilities()
+		assertNoError(err, t, "GetCapabilities")
+		t.Logf("%#v", caps)
+		if caps.Backend != testBackend {
+			t.Errorf("wrong backend %q, expected %q", caps.Backend, testBackend)
+		}
+		if caps.Core || caps.ReadOnly || !caps.Restart {
+			t.Errorf("wrong capabilities for a live process")
+		}
+		if recorded := c.Recorded(); caps.Recorded != recorded || caps.ReverseExecution != recorded {
+			t.Errorf("wrong recording capabilities, Recorded() returned %v", recorded)
+		}
+		if caps.FunctionCalls && runtime.GOARCH != "amd64" {
+			t.Errorf("function calls reported on %s", runtime.GOARCH)
+		}
+		if caps.NonStop && testBackend == "native" {
+			t.Errorf("non-stop mode reported by the native backend")
+		}
+	})
+}
+
+func TestClientServer_GetSourceFile(t *testing.T) {
+	withTestClient2Extended("testvariables", t, 0, [3]string{}, func(c service.Client, fixture protest.Fixture) {
+		want, err := ioutil.ReadFile(fixture.Source)
+		assertNoError(err, t, "ReadFile")
+		path := filepath.ToSlash(fixture.Source)
+
+		f, err := c.GetSourceFile(path)
+		assertNoError(err, t, "GetSourceFile")
+		if string(f.Content) != string(want) || f.HostPath != path {
+			t.Errorf("wrong file returned: %q (%d bytes)", f.HostPath, len(f.Content))
+		}
+		if sum := sha256.Sum256(want); f.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("wrong checksum %s", f.SHA256)
+		}
+
+		dir, err := ioutil.TempDir("", "delve-sources")
+		assertNoError(err, t, "TempDir")
+		defer os.RemoveAll(dir)
+		moved := filepath.Join(dir, filepath.Base(fixture.Source))
+		assertNoError(ioutil.WriteFile(moved, want, 0600), t, "WriteFile")
+
+		// Files that are not listed in the debug info can not be read.
+		if _, err := c.GetSourceFile(moved); err == nil {
+			t.Errorf("read a file that is not a source of the target")
+		}
+	})
+
+	// Files moved after the target was compiled are found with the
+	// substitute-path rules of the server, rules sent by the client are
+	// ignored.
+	dir, err := ioutil.TempDir("", "delve-sources")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(dir)
+	clientDir, err := ioutil.TempDir("", "delve-sources-client")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(clientDir)
+
+	clientConn, fixture := startServer("testvariables", 0, t, [3]string{}, func(cfg *debugger.Config, fixture protest.Fixture) {
+		cfg.SubstitutePath = [][2]string{{filepath.Dir(fixture.Source), dir}}
+	})
+	c := rpc2.NewClientFromConn(clientConn)
+	defer c.Detach(true)
+
+	want, err := ioutil.ReadFile(fixture.Source)
+	assertNoError(err, t, "ReadFile")
+	want = append(want, "// moved\n"...)
+	moved := filepath.Join(dir, filepath.Base(fixture.Source))
+	assertNoError(ioutil.WriteFile(moved, want, 0600), t, "WriteFile")
+	assertNoError(ioutil.WriteFile(filepath.Join(clientDir, filepath.Base(fixture.Source)), []byte("// client\n"), 0600), t, "WriteFile")
+	path := filepath.ToSlash(fixture.Source)
+
+	f, err := c.GetSourceFile(path)
+	assertNoError(err, t, "GetSourceFile")
+	if string(f.Content) != string(want) || f.HostPath != moved || f.Path != path {
+		t.Errorf("wrong file returned with substitute-path: %q -> %q (%d bytes)", f.Path, f.HostPath, len(f.Content))
+	}
+
+	var out rpc2.GetSourceFileOut
+	err = c.CallAPI("GetSourceFile", struct {
+		Path                string
+		SubstitutePathRules [][2]string
+	}{path, [][2]string{{filepath.Dir(fixture.Source), clientDir}}}, &out)
+	assertNoError(err, t, "GetSourceFile with client rules")
+	if string(out.File.Content) != string(want) || out.File.HostPath != moved {
+		t.Errorf("substitute-path rules of the client were used: %q (%d bytes)", out.File.HostPath, len(out.File.Content))
+	}
+}
+
+func TestClientServer_EventHooks(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 24})
+		assertNoError(err, t, "CreateBreakpoint")
+
+		stopHook, err := c.AddEventHook(api.EventHook{
+			On:         api.EventStopped,
+			Breakpoint: "*",
+			Resume:     true,
+			Script:     "def main(event):\n\tprint(eval(None, \"i\").Variable.Value)\n",
+		})
+		assertNoError(err, t, "AddEventHook(stop)")
+		exitHook, err := c.AddEventHook(api.EventHook{On: api.EventExited, Script: "def main(event):\n\tprint(event.ExitStatus)\n"})
+		assertNoError(err, t, "AddEventHook(exit)")
+		_, err = c.AddEventHook(api.EventHook{On: api.EventOutput, Script: "def main(event):\n\tpass\n"})
+		if err == nil {
+			t.Errorf("no error adding a hook for output events")
+		}
+
+		<-c.Continue()
+
+		hooks := map[int]api.EventHook{}
+		for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(100 * time.Millisecond) {
+			hs, err := c.ListEventHooks()
+			assertNoError(err, t, "ListEventHooks")
+			for _, h := range hs {
+				hooks[h.ID] = h
+			}
+			if hooks[exitHook.ID].Runs > 0 {
+				break
+			}
+		}
+		if h := hooks[exitHook.ID]; h.Runs != 1 || h.Output != "0\n" || h.Err != "" {
+			t.Errorf("wrong exit hook %#v", h)
+		}
+		if h := hooks[stopHook.ID]; h.Runs != 3 || h.Output != "2\n" || h.Err != "" {
+			t.Errorf("wrong stop hook %#v", h)
+		}
+
+		assertNoError(c.RemoveEventHook(stopHook.ID), t, "RemoveEventHook")
+		if err := c.RemoveEventHook(stopHook.ID); err == nil {
+			t.Errorf("no error removing a hook twice")
+		}
+	})
+}
+
+func TestClientServer_SaveLoadSession(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 24, Cond: "i == 2"})
+		assertNoError(err, t, "CreateBreakpoint")
+		_, err = c.AddWatch("j", normalLoadConfig)
+		assertNoError(err, t, "AddWatch")
+
+		s, err := c.SaveSession([][2]string{{"/from", "/to"}})
+		assertNoError(err, t, "SaveSession")
+		if len(s.Breakpoints) != 1 || len(s.Watches) != 1 || len(s.SubstitutePath) != 1 {
+			t.Fatalf("wrong session saved: %#v", s)
+		}
+
+		_, err = c.ClearBreakpoint(bp.ID)
+		assertNoError(err, t, "ClearBreakpoint")
+		s.Breakpoints = append(s.Breakpoints, &api.Breakpoint{File: fp, Line: 1000})
+
+		discarded, err := c.LoadSession(s)
+		assertNoError(err, t, "LoadSession")
+		if len(discarded) != 1 || discarded[0].Breakpoint.Line != 1000 {
+			t.Errorf("wrong discarded breakpoints: %#v", discarded)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints")
+		found := false
+		for _, bp2 := range bps {
+			if bp2.File == fp && bp2.Line == 24 {
+				found = true
+				if bp2.Cond != "i == 2" || bp2.ID == bp.ID {
+					t.Errorf("breakpoint not restored correctly: %#v", bp2)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("breakpoint not restored")
+		}
+		watches, err := c.ListWatches()
+		assertNoError(err, t, "ListWatches")
+		if len(watches) != 1 {
+			t.Errorf("watch expression duplicated: %#v", watches)
+		}
+	})
+}
+
 func TestClientServer_SetVariable(t *testing.T) {
 	withTestClient2("testvariables", t, func(c service.Client) {
 		state := <-c.Continue()
@@ -909,6 +1166,56 @@ func TestClientServer_SetVariable(t *testing.T) {
 	})
 }
 
+func TestClientServer_ListGoroutinesWithFilter(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		filters := []api.ListGoroutinesFilter{{Kind: api.GoroutineStartLoc, Arg: "main.agoroutine"}}
+		gs, _, nextg, _, err := c.ListGoroutinesWithFilter(0, 0, filters, nil)
+		assertNoError(err, t, "ListGoroutinesWithFilter()")
+		if len(gs) != 10 || nextg >= 0 {
+			t.Fatalf("wrong number of goroutines %d (nextg %d)", len(gs), nextg)
+		}
+
+		// Each batch is filtered independently.
+		n := 0
+		for start := 0; start >= 0; {
+			gs, _, start, _, err = c.ListGoroutinesWithFilter(start, 2, filters, nil)
+			assertNoError(err, t, "ListGoroutinesWithFilter()")
+			n += len(gs)
+		}
+		if n != 10 {
+			t.Errorf("wrong number of goroutines with pagination %d", n)
+		}
+
+		filters = append(filters, api.ListGoroutinesFilter{Kind: api.GoroutineUser})
+		gs, groups, _, tooManyGroups, err := c.ListGoroutinesWithFilter(0, 0, filters, &api.GoroutineGroupingOptions{GroupBy: api.GoroutineStartLoc, MaxGroupMembers: 3})
+		assertNoError(err, t, "ListGoroutinesWithFilter()")
+		if len(groups) != 1 || tooManyGroups {
+			t.Fatalf("wrong groups %#v %v", groups, tooManyGroups)
+		}
+		if !strings.Contains(groups[0].Name, "main.agoroutine") || groups[0].Total != 10 || groups[0].Count != 3 || len(gs) != 3 {
+			t.Errorf("wrong group %#v (%d goroutines)", groups[0], len(gs))
+		}
+
+		filters = []api.ListGoroutinesFilter{{Kind: api.GoroutineExpr, Arg: `fn == "main.agoroutine" && waitreason == "chan send" && pkg == "main"`}}
+		gs, _, _, _, err = c.ListGoroutinesWithFilter(0, 0, filters, nil)
+		assertNoError(err, t, "ListGoroutinesWithFilter(expr)")
+		if len(gs) != 10 {
+			t.Errorf("wrong number of goroutines matching filter expression %d", len(gs))
+		}
+
+		filters = []api.ListGoroutinesFilter{{Kind: api.GoroutineExpr, Arg: `nosuchproperty == 1`}}
+		_, _, _, _, err = c.ListGoroutinesWithFilter(0, 0, filters, nil)
+		if err == nil {
+			t.Errorf("no error for invalid filter expression")
+		}
+	})
+}
+
 func TestClientServer_FullStacktrace(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestClient2("goroutinestackprog", t, func(c service.Client) {
@@ -982,6 +1289,55 @@ func TestClientServer_FullStacktrace(t *testing.T) {
 	})
 }
 
+func TestClientServer_StacktraceRange(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		for i := 0; i < 2; i++ {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+		}
+
+		all, err := c.Stacktrace(-1, 10, 0, nil)
+		assertNoError(err, t, "Stacktrace")
+		frames, err := c.StacktraceRange(-1, 1, 2, 0, nil)
+		assertNoError(err, t, "StacktraceRange")
+		if len(frames) != 2 {
+			t.Fatalf("wrong number of frames %d", len(frames))
+		}
+		for i := range frames {
+			if frames[i].PC != all[i+1].PC {
+				t.Errorf("frame %d: PC mismatch %#x %#x", i+1, frames[i].PC, all[i+1].PC)
+			}
+			if frames[i].Locals != nil || frames[i].Arguments != nil {
+				t.Errorf("frame %d: variables loaded", i+1)
+			}
+		}
+
+		frames, err = c.StacktraceRange(-1, 100, 10, 0, nil)
+		assertNoError(err, t, "StacktraceRange")
+		if len(frames) != 0 {
+			t.Errorf("expected no frames past the bottom of the stack, got %d", len(frames))
+		}
+
+		frame, err := c.StackframeDetails(-1, 1, 0, nil)
+		assertNoError(err, t, "StackframeDetails")
+		if frame.PC != all[1].PC {
+			t.Errorf("PC mismatch %#x %#x", frame.PC, all[1].PC)
+		}
+		v := frame.Var("n")
+		if v == nil || v.Value != "3" {
+			t.Errorf("wrong value of n in frame 1: %v", v)
+		}
+
+		_, err = c.StackframeDetails(-1, 1000, 0, nil)
+		if err == nil {
+			t.Errorf("expected error for frame past the bottom of the stack")
+		}
+	})
+}
+
 func TestIssue355(t *testing.T) {
 	// After the target process has terminated should return an error but not crash
 	protest.AllowRecording(t)
@@ -1726,6 +2082,149 @@ func TestAcceptMulticlient(t *testing.T) {
 	<-serverDone
 }
 
+func TestObserverClient(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("recording not allowed for TestObserverClient")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		defer listener.Close()
+		disconnectChan := make(chan struct{})
+		server := rpccommon.NewServer(&service.Config{
+			Listener:       listener,
+			ProcessArgs:    []string{protest.BuildFixture("testvariables2", 0).Path},
+			AcceptMulti:    true,
+			DisconnectChan: disconnectChan,
+			Debugger: debugger.Config{
+				Backend:     testBackend,
+				ExecuteKind: debugger.ExecutingGeneratedTest,
+			},
+		})
+		if err := server.Run(); err != nil {
+			panic(err)
+		}
+		<-disconnectChan
+		server.Stop()
+	}()
+	client := rpc2.NewClient(listener.Addr().String())
+	state := <-client.Continue()
+	assertNoError(state.Err, t, "Continue()")
+
+	observer := rpc2.NewClient(listener.Addr().String())
+	assertNoError(observer.SetObserver(), t, "SetObserver()")
+
+	// Inspecting the target is allowed.
+	_, err = observer.Stacktrace(-1, 10, 0, nil)
+	assertNoError(err, t, "Stacktrace()")
+	_, err = observer.EvalVariable(api.EvalScope{GoroutineID: -1}, "i1", normalLoadConfig)
+	assertNoError(err, t, "EvalVariable()")
+	_, err = observer.ListBreakpoints()
+	assertNoError(err, t, "ListBreakpoints()")
+
+	// Changing it is not.
+	checkObserverErr := func(err error, what string) {
+		t.Helper()
+		if err == nil || err.Error() != debugger.ErrObserver.Error() {
+			t.Errorf("%s: expected %q got %v", what, debugger.ErrObserver, err)
+		}
+	}
+	_, err = observer.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+	checkObserverErr(err, "CreateBreakpoint()")
+	checkObserverErr(observer.SetVariable(api.EvalScope{GoroutineID: -1}, "i1", "2"), "SetVariable()")
+	_, err = observer.Next()
+	checkObserverErr(err, "Next()")
+	checkObserverErr((<-observer.Continue()).Err, "Continue()")
+	checkObserverErr(observer.Detach(true), "Detach()")
+
+	// The other client is not affected.
+	_, err = client.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+	assertNoError(err, t, "CreateBreakpoint()")
+	client.Detach(true)
+	<-serverDone
+}
+
+func TestAuthPolicy(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("recording not allowed for TestAuthPolicy")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		defer listener.Close()
+		disconnectChan := make(chan struct{})
+		server := rpccommon.NewServer(&service.Config{
+			Listener:       listener,
+			ProcessArgs:    []string{protest.BuildFixture("testvariables2", 0).Path},
+			AcceptMulti:    true,
+			APIVersion:     2,
+			DisconnectChan: disconnectChan,
+			AuthPolicy: &service.AuthPolicy{Tokens: []*service.AuthToken{
+				{Token: "admin"},
+				{Token: "viewer", Deny: []string{service.OpSet, service.OpCall, service.OpDetach}},
+			}},
+			Debugger: debugger.Config{
+				Backend:     testBackend,
+				ExecuteKind: debugger.ExecutingGeneratedTest,
+			},
+		})
+		if err := server.Run(); err != nil {
+			panic(err)
+		}
+		<-disconnectChan
+		server.Stop()
+	}()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	// Unauthenticated connections can not do anything.
+	anon := rpc2.NewClientFromConn(dial())
+	if _, err := anon.ListBreakpoints(); err == nil || err.Error() != service.ErrNotAuthenticated.Error() {
+		t.Errorf("ListBreakpoints() without authenticating: expected %q got %v", service.ErrNotAuthenticated, err)
+	}
+	if _, err := rpc2.NewClientFromConnWithToken(dial(), "wrong"); err == nil {
+		t.Errorf("authenticated with the wrong token")
+	}
+
+	client, err := rpc2.NewClientFromConnWithToken(dial(), "admin")
+	assertNoError(err, t, "NewClientFromConnWithToken(admin)")
+	state := <-client.Continue()
+	assertNoError(state.Err, t, "Continue()")
+
+	viewer, err := rpc2.NewClientFromConnWithToken(dial(), "viewer")
+	assertNoError(err, t, "NewClientFromConnWithToken(viewer)")
+	_, err = viewer.EvalVariable(api.EvalScope{GoroutineID: -1}, "i1", normalLoadConfig)
+	assertNoError(err, t, "EvalVariable()")
+	checkDenied := func(err error, what string) {
+		t.Helper()
+		if err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("%s: expected the operation to be denied, got %v", what, err)
+		}
+	}
+	checkDenied(viewer.SetVariable(api.EvalScope{GoroutineID: -1}, "i1", "2"), "SetVariable()")
+	_, err = viewer.Call(-1, "fn()", false)
+	checkDenied(err, "Call()")
+	checkDenied(viewer.Detach(true), "Detach()")
+
+	assertNoError(client.SetVariable(api.EvalScope{GoroutineID: -1}, "i1", "2"), t, "SetVariable()")
+	client.Detach(true)
+	<-serverDone
+}
+
 func mustHaveDebugCalls(t *testing.T, c service.Client) {
 	locs, err := c.FindLocation(api.EvalScope{GoroutineID: -1}, "runtime.debugCallV1", false, nil)
 	if len(locs) == 0 || err != nil {
@@ -1764,6 +2263,39 @@ func TestClientServerFunctionCall(t *testing.T) {
 	})
 }
 
+func TestClientServerFunctionCallCancel(t *testing.T) {
+	protest.MustSupportFunctionCalls(t, testBackend)
+	withTestClient2("fncall", t, func(c service.Client) {
+		mustHaveDebugCalls(t, c)
+		c.SetReturnValuesLoadConfig(&normalLoadConfig)
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		beforeCallFn := state.CurrentThread.Function.Name()
+
+		// A call interrupted by a breakpoint is cancelled when the target is
+		// resumed.
+		_, err := c.Call(-1, "callbreak()", false)
+		assertNoError(err, t, "Call()")
+		assertNoError(c.CancelCall(0), t, "CancelCall()")
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Function.Name() != beforeCallFn {
+			t.Fatalf("did not return to the calling function %q %q", beforeCallFn, state.CurrentThread.Function.Name())
+		}
+		if state.CurrentThread.ReturnValues != nil {
+			t.Fatalf("return values of a cancelled call returned: %v", state.CurrentThread.ReturnValues)
+		}
+
+		// A call that does not return is cancelled after the timeout.
+		_, err = c.CallWithTimeout(-1, "callforever()", false, time.Second)
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("call did not time out: %v", err)
+		}
+		_, err = c.GetState()
+		assertNoError(err, t, "GetState()")
+	})
+}
+
 func TestClientServerFunctionCallBadPos(t *testing.T) {
 	protest.MustSupportFunctionCalls(t, testBackend)
 	if goversion.VersionAfterOrEqual(runtime.Version(), 1, 12) {
@@ -1893,7 +2425,7 @@ func (c *brokenRPCClient) call(method string, args, reply interface{}) error {
 }
 
 func TestUnknownMethodCall(t *testing.T) {
-	clientConn, _ := startServer("continuetestprog", 0, t, [3]string{})
+	clientConn, _ := startServer("continuetestprog", 0, t, [3]string{}, nil)
 	client := &brokenRPCClient{jsonrpc.NewClient(clientConn)}
 	client.call("SetApiVersion", api.SetAPIVersionIn{APIVersion: 2}, &api.SetAPIVersionOut{})
 	defer client.Detach(true)
@@ -2164,3 +2696,114 @@ func TestDetachLeaveRunning(t *testing.T) {
 	defer server.Stop()
 	assertNoError(client.Detach(false), t, "Detach")
 }
+
+func TestLoadStringChunk(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		const longstr = "very long string 0123456789a0123456789b0123456789c0123456789d0123456789e0123456789f0123456789g012345678h90123456789i0123456789j0123456789"
+		v, err := c.EvalVariable(api.EvalScope{GoroutineID: -1}, "longstr", api.LoadConfig{MaxStringLen: 10})
+		assertNoError(err, t, "EvalVariable(longstr)")
+		if v.Value != longstr[:10] || v.Len != int64(len(longstr)) {
+			t.Fatalf("wrong value %q (len %d)", v.Value, v.Len)
+		}
+
+		for off := 10; off < len(longstr); off += 64 {
+			n := 64
+			if off+n > len(longstr) {
+				n = len(longstr) - off
+			}
+			data, err := c.LoadStringChunk(v.Base+uint64(off), n)
+			assertNoError(err, t, "LoadStringChunk")
+			if string(data) != longstr[off:off+n] {
+				t.Errorf("wrong chunk at %d: %q", off, data)
+			}
+		}
+
+		if _, err := c.LoadStringChunk(v.Base, 2<<20); err == nil {
+			t.Errorf("no error loading a chunk larger than the limit")
+		}
+	})
+}
+
+func TestEvalStringer(t *testing.T) {
+	protest.MustSupportFunctionCalls(t, testBackend)
+	withTestClient2("stringercall", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		scope := api.EvalScope{GoroutineID: -1}
+		cfg := normalLoadConfig
+		opts := api.StringerOptions{Timeout: 2 * time.Second, MaxAlloc: 1 << 20}
+
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"p", "(1, 2)"},
+			{"err", "some error"},
+		} {
+			v, stringerErr, err := c.EvalVariableStringer(scope, tc.expr, cfg, opts)
+			assertNoError(err, t, fmt.Sprintf("EvalVariableStringer(%s)", tc.expr))
+			if stringerErr != "" || v.StringerValue != tc.tgt {
+				t.Errorf("%s: got %q (error %q), expected %q", tc.expr, v.StringerValue, stringerErr, tc.tgt)
+			}
+		}
+
+		for _, expr := range []string{"pl", "big", "slow"} {
+			v, stringerErr, err := c.EvalVariableStringer(scope, expr, cfg, opts)
+			assertNoError(err, t, fmt.Sprintf("EvalVariableStringer(%s)", expr))
+			t.Logf("%s: %q", expr, stringerErr)
+			if stringerErr == "" || v.StringerValue != "" {
+				t.Errorf("%s: expected a stringer error, got %q", expr, v.StringerValue)
+			}
+			if v.Name != expr {
+				t.Errorf("%s: structural value not returned: %#v", expr, v)
+			}
+		}
+	})
+}
+
+func TestWaitEvents(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("testprog", t, func(c service.Client) {
+		evs, err := c.WaitEvents(0, 100*time.Millisecond)
+		assertNoError(err, t, "WaitEvents()")
+		if len(evs) != 0 {
+			t.Fatalf("unexpected events before resuming the target: %#v", evs)
+		}
+
+		// Events are received by a client that is waiting while another
+		// call resumes the target.
+		done := make(chan []api.Event)
+		go func() {
+			evs, err := c.WaitEvents(0, 0)
+			if err != nil {
+				t.Errorf("WaitEvents(): %v", err)
+			}
+			done <- evs
+		}()
+
+		_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		evs = <-done
+		last := evs[len(evs)-1]
+		if last.Kind != api.EventStopped || last.State == nil || last.State.CurrentThread.PC != state.CurrentThread.PC {
+			t.Fatalf("wrong stop event %#v", last)
+		}
+
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("expected exit after continue: %#v", state)
+		}
+		evs, err = c.WaitEvents(last.ID, 0)
+		assertNoError(err, t, "WaitEvents()")
+		if len(evs) != 1 || evs[0].Kind != api.EventExited || evs[0].ID != last.ID+1 {
+			t.Fatalf("wrong exit event %#v", evs)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+)
+
+// TLSConfig describes how connections between a client and a headless
+// instance of Delve are secured with TLS.
+type TLSConfig struct {
+	// CertFile and KeyFile are the paths of the PEM encoded certificate, and
+	// its private key, presented to the other side of the connection. They
+	// are required for servers and optional for clients.
+	CertFile, KeyFile string
+
+	// CAFile is the path of a PEM encoded list of certificate authorities.
+	// Servers will only accept clients presenting a certificate signed by one
+	// of them, clients will use them instead of the system's certificate
+	// pool to verify the server.
+	CAFile string
+}
+
+// Enabled returns true if any TLS option was specified.
+func (cfg *TLSConfig) Enabled() bool {
+	return cfg.CertFile != "" || cfg.KeyFile != "" || cfg.CAFile != ""
+}
+
+// ServerConfig returns the configuration used to secure the connections
+// accepted by a server.
+func (cfg *TLSConfig) ServerConfig() (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both a certificate and a key are required to accept TLS connections")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		tlsConfig.ClientCAs, err = loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientConfig returns the configuration used by a client to connect to
+// addr.
+func (cfg *TLSConfig) ClientConfig(addr string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.CAFile != "" {
+		tlsConfig.RootCAs, err = loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tlsConfig, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
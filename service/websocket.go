@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is used to compute Sec-WebSocket-Accept, see RFC 6455
+// section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errWebSocketClosed = errors.New("use of closed websocket connection")
+
+// WebSocket opcodes, see RFC 6455 section 5.2.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// WebSocketListener returns a listener that accepts WebSocket connections
+// made to l, so that browsers can connect to a headless instance.
+// The connections it returns carry the same byte stream a plain TCP
+// connection would: each message received contains the next chunk of the
+// stream and every write is sent as a binary message. Message boundaries
+// have no meaning and clients should not rely on them.
+//
+// Connections from web pages are only accepted if the page's origin is
+// listed in allowedOrigins, "*" allows every origin. Clients that are not
+// browsers, which do not send the Origin header, are always accepted.
+func WebSocketListener(l net.Listener, allowedOrigins []string) net.Listener {
+	wsl := &websocketListener{
+		l:              l,
+		allowedOrigins: allowedOrigins,
+		conns:          make(chan net.Conn),
+		closech:        make(chan struct{}),
+	}
+	wsl.srv = &http.Server{Handler: wsl}
+	go func() {
+		err := wsl.srv.Serve(l)
+		wsl.closeMu.Lock()
+		wsl.err = err
+		wsl.closeMu.Unlock()
+		wsl.Close()
+	}()
+	return wsl
+}
+
+type websocketListener struct {
+	l              net.Listener
+	srv            *http.Server
+	allowedOrigins []string
+	conns          chan net.Conn
+
+	closeMu sync.Mutex
+	closech chan struct{}
+	err     error
+}
+
+// Accept waits for the next WebSocket connection.
+func (wsl *websocketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-wsl.conns:
+		return conn, nil
+	case <-wsl.closech:
+		wsl.closeMu.Lock()
+		defer wsl.closeMu.Unlock()
+		if wsl.err != nil && wsl.err != http.ErrServerClosed {
+			return nil, wsl.err
+		}
+		return nil, errors.New("accept failed: listener closed")
+	}
+}
+
+// Close closes the listener, connections that were already accepted are
+// not closed.
+func (wsl *websocketListener) Close() error {
+	wsl.closeMu.Lock()
+	defer wsl.closeMu.Unlock()
+	select {
+	case <-wsl.closech:
+		return nil
+	default:
+	}
+	close(wsl.closech)
+	return wsl.srv.Close()
+}
+
+// Addr returns the listener's network address.
+func (wsl *websocketListener) Addr() net.Addr {
+	return wsl.l.Addr()
+}
+
+// OriginAllowed returns true if a request sent with the Origin header
+// origin can be accepted by a server that allows the origins in
+// allowedOrigins, "*" allows every origin. Requests without an Origin
+// header do not come from web pages and are always allowed.
+func OriginAllowed(origin string, allowedOrigins []string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP performs the opening handshake described in RFC 6455 section
+// 4.2 and passes the connection to Accept.
+func (wsl *websocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "this is a WebSocket endpoint", http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return
+	}
+	if !OriginAllowed(r.Header.Get("Origin"), wsl.allowedOrigins) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can not be upgraded", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	rw.WriteString(base64.StdEncoding.EncodeToString(sum[:]))
+	rw.WriteString("\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+	select {
+	case wsl.conns <- &websocketConn{Conn: conn, r: rw.Reader}:
+	case <-wsl.closech:
+		conn.Close()
+	}
+}
+
+// headerContains returns true if one of the comma separated values of
+// header name is token.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// websocketConn is a net.Conn that sends and receives data as WebSocket
+// messages.
+type websocketConn struct {
+	net.Conn
+	r *bufio.Reader
+
+	readMu sync.Mutex
+	// remaining is the number of bytes left in the payload of the data
+	// frame being read, mask and maskPos are used to unmask it.
+	remaining uint64
+	mask      [4]byte
+	maskPos   int
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// Read reads the payload of the data frames sent by the client, answering
+// to control frames.
+func (c *websocketConn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	for c.remaining == 0 {
+		opcode, length, err := c.readFrameHeader()
+		if err != nil {
+			return 0, err
+		}
+		switch opcode {
+		case wsContinuation, wsText, wsBinary:
+			c.remaining = length
+		case wsClose, wsPing, wsPong:
+			if length > 125 {
+				return 0, errors.New("websocket: control frame too long")
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(c.r, payload); err != nil {
+				return 0, err
+			}
+			c.unmask(payload)
+			switch opcode {
+			case wsClose:
+				// Echo the status code back as required by RFC 6455 section
+				// 5.5.1.
+				if len(payload) > 2 {
+					payload = payload[:2]
+				}
+				c.writeFrame(wsClose, payload)
+				return 0, io.EOF
+			case wsPing:
+				if err := c.writeFrame(wsPong, payload); err != nil {
+					return 0, err
+				}
+			}
+		default:
+			return 0, errors.New("websocket: unknown opcode")
+		}
+	}
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.unmask(p[:n])
+	c.remaining -= uint64(n)
+	return n, err
+}
+
+// readFrameHeader reads the header of the next frame and returns its
+// opcode and payload length, see RFC 6455 section 5.2.
+func (c *websocketConn) readFrameHeader() (opcode byte, length uint64, err error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return 0, 0, err
+	}
+	opcode = hdr[0] & 0xf
+	if hdr[1]&0x80 == 0 {
+		return 0, 0, errors.New("websocket: unmasked frame from client")
+	}
+	length = uint64(hdr[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, 0, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, 0, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if _, err := io.ReadFull(c.r, c.mask[:]); err != nil {
+		return 0, 0, err
+	}
+	c.maskPos = 0
+	return opcode, length, nil
+}
+
+func (c *websocketConn) unmask(p []byte) {
+	for i := range p {
+		p[i] ^= c.mask[c.maskPos]
+		c.maskPos = (c.maskPos + 1) % len(c.mask)
+	}
+}
+
+// Write sends p as a single binary message.
+func (c *websocketConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeFrame writes an unfragmented, unmasked, frame.
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return errWebSocketClosed
+	}
+	hdr := make([]byte, 2, 10+len(payload))
+	hdr[0] = 0x80 | opcode
+	switch {
+	case len(payload) <= 125:
+		hdr[1] = byte(len(payload))
+	case len(payload) <= 0xffff:
+		hdr[1] = 126
+		hdr = append(hdr, 0, 0)
+		binary.BigEndian.PutUint16(hdr[2:], uint16(len(payload)))
+	default:
+		hdr[1] = 127
+		hdr = append(hdr, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(hdr[2:], uint64(len(payload)))
+	}
+	if opcode == wsClose {
+		c.closed = true
+	}
+	_, err := c.Conn.Write(append(hdr, payload...))
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *websocketConn) Close() error {
+	c.writeFrame(wsClose, nil)
+	return c.Conn.Close()
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// dialWebSocket performs the opening handshake with the server listening
+// at addr, it returns the response status code and, if it succeeded, the
+// connection.
+func dialWebSocket(t *testing.T, addr, origin string) (int, net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "http://"+addr+"/", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return resp.StatusCode, nil, nil
+	}
+	// Example from RFC 6455 section 1.3.
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("wrong Sec-WebSocket-Accept %q", accept)
+	}
+	return resp.StatusCode, conn, r
+}
+
+// writeClientFrame writes a masked frame, as clients are required to do.
+func writeClientFrame(t *testing.T, conn net.Conn, opcode byte, payload []byte) {
+	t.Helper()
+	mask := []byte{1, 2, 3, 4}
+	buf := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	buf = append(buf, mask...)
+	for i := range payload {
+		buf = append(buf, payload[i]^mask[i%4])
+	}
+	if _, err := conn.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[1]&0x80 != 0 || hdr[1]&0x7f > 125 {
+		t.Fatalf("unexpected frame header %#x", hdr)
+	}
+	payload := make([]byte, hdr[1])
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return hdr[0] & 0xf, payload
+}
+
+func TestWebSocketListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsl := WebSocketListener(l, []string{"http://allowed.example"})
+	defer wsl.Close()
+
+	if status, _, _ := dialWebSocket(t, wsl.Addr().String(), "http://evil.example"); status != http.StatusForbidden {
+		t.Fatalf("connection from a forbidden origin: got status %d", status)
+	}
+
+	_, client, r := dialWebSocket(t, wsl.Addr().String(), "http://allowed.example")
+	defer client.Close()
+	server, err := wsl.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	// Messages are concatenated in a single stream, control frames are
+	// answered transparently.
+	writeClientFrame(t, client, wsText, []byte("hello "))
+	writeClientFrame(t, client, wsPing, []byte("ping"))
+	writeClientFrame(t, client, wsBinary, []byte("world"))
+	buf := make([]byte, len("hello world"))
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello world" {
+		t.Fatalf("server read %q", buf)
+	}
+	if opcode, payload := readServerFrame(t, r); opcode != wsPong || string(payload) != "ping" {
+		t.Fatalf("expected pong got %#x %q", opcode, payload)
+	}
+
+	if _, err := server.Write([]byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if opcode, payload := readServerFrame(t, r); opcode != wsBinary || !bytes.Equal(payload, []byte(`{"id":1}`)) {
+		t.Fatalf("expected binary message got %#x %q", opcode, payload)
+	}
+
+	writeClientFrame(t, client, wsClose, []byte{0x03, 0xe8})
+	if _, err := server.Read(buf); err != io.EOF {
+		t.Fatalf("expected EOF after close frame, got %v", err)
+	}
+	if opcode, _ := readServerFrame(t, r); opcode != wsClose {
+		t.Fatalf("expected close frame got %#x", opcode)
+	}
+}
+
+func TestWebSocketListenerPlainHTTP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsl := WebSocketListener(l, nil)
+	defer wsl.Close()
+	resp, err := http.Get("http://" + wsl.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "WebSocket") {
+		t.Fatalf("unexpected body %q", body)
+	}
+}